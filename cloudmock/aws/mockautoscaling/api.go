@@ -28,6 +28,15 @@ type MockAutoscaling struct {
 
 	Groups               map[string]*autoscaling.Group
 	LaunchConfigurations map[string]*autoscaling.LaunchConfiguration
+	LifecycleHooks       map[string]*autoscaling.LifecycleHook
+
+	// CompletedLifecycleActions records the calls to CompleteLifecycleAction
+	CompletedLifecycleActions []*autoscaling.CompleteLifecycleActionInput
+	// LifecycleActionHeartbeats records the calls to RecordLifecycleActionHeartbeat
+	LifecycleActionHeartbeats []*autoscaling.RecordLifecycleActionHeartbeatInput
+
+	// InstanceRefreshes are the instance refreshes of all groups, in the order they were started
+	InstanceRefreshes []*autoscaling.InstanceRefresh
 }
 
 var _ autoscalingiface.AutoScalingAPI = &MockAutoscaling{}
@@ -58,13 +58,15 @@ func (m *MockAutoscaling) CreateAutoScalingGroup(input *autoscaling.CreateAutoSc
 		DefaultCooldown:      input.DefaultCooldown,
 		DesiredCapacity:      input.DesiredCapacity,
 		// EnabledMetrics:          input.EnabledMetrics,
-		HealthCheckGracePeriod:  input.HealthCheckGracePeriod,
-		HealthCheckType:         input.HealthCheckType,
-		Instances:               []*autoscaling.Instance{},
-		LaunchConfigurationName: input.LaunchConfigurationName,
-		LoadBalancerNames:       input.LoadBalancerNames,
-		MaxSize:                 input.MaxSize,
-		MinSize:                 input.MinSize,
+		HealthCheckGracePeriod:           input.HealthCheckGracePeriod,
+		HealthCheckType:                  input.HealthCheckType,
+		Instances:                        []*autoscaling.Instance{},
+		LaunchConfigurationName:          input.LaunchConfigurationName,
+		LaunchTemplate:                   input.LaunchTemplate,
+		LoadBalancerNames:                input.LoadBalancerNames,
+		MaxSize:                          input.MaxSize,
+		MinSize:                          input.MinSize,
+		MixedInstancesPolicy:             input.MixedInstancesPolicy,
 		NewInstancesProtectedFromScaleIn: input.NewInstancesProtectedFromScaleIn,
 		PlacementGroup:                   input.PlacementGroup,
 		// Status:                           input.Status,
@@ -159,6 +161,9 @@ func (m *MockAutoscaling) TerminateInstanceInAutoScalingGroup(input *autoscaling
 		for i := range group.Instances {
 			if aws.StringValue(group.Instances[i].InstanceId) == aws.StringValue(input.InstanceId) {
 				group.Instances = append(group.Instances[:i], group.Instances[i+1:]...)
+				if aws.BoolValue(input.ShouldDecrementDesiredCapacity) {
+					group.DesiredCapacity = aws.Int64(aws.Int64Value(group.DesiredCapacity) - 1)
+				}
 				return &autoscaling.TerminateInstanceInAutoScalingGroupOutput{
 					Activity: nil, // TODO
 				}, nil
@@ -169,6 +174,45 @@ func (m *MockAutoscaling) TerminateInstanceInAutoScalingGroup(input *autoscaling
 	return nil, fmt.Errorf("Instance not found")
 }
 
+func (m *MockAutoscaling) UpdateAutoScalingGroup(request *autoscaling.UpdateAutoScalingGroupInput) (*autoscaling.UpdateAutoScalingGroupOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.V(2).Infof("UpdateAutoScalingGroup %v", request)
+
+	g := m.Groups[aws.StringValue(request.AutoScalingGroupName)]
+	if g == nil {
+		return nil, fmt.Errorf("AutoScalingGroup not found")
+	}
+
+	if request.DesiredCapacity != nil {
+		g.DesiredCapacity = request.DesiredCapacity
+	}
+	if request.MinSize != nil {
+		g.MinSize = request.MinSize
+	}
+	if request.MaxSize != nil {
+		g.MaxSize = request.MaxSize
+	}
+	if request.LaunchConfigurationName != nil {
+		g.LaunchConfigurationName = request.LaunchConfigurationName
+		g.LaunchTemplate = nil
+		g.MixedInstancesPolicy = nil
+	}
+	if request.LaunchTemplate != nil {
+		g.LaunchTemplate = request.LaunchTemplate
+		g.LaunchConfigurationName = nil
+		g.MixedInstancesPolicy = nil
+	}
+	if request.MixedInstancesPolicy != nil {
+		g.MixedInstancesPolicy = request.MixedInstancesPolicy
+		g.LaunchConfigurationName = nil
+		g.LaunchTemplate = nil
+	}
+
+	return &autoscaling.UpdateAutoScalingGroupOutput{}, nil
+}
+
 func (m *MockAutoscaling) DescribeAutoScalingGroupsWithContext(aws.Context, *autoscaling.DescribeAutoScalingGroupsInput, ...request.Option) (*autoscaling.DescribeAutoScalingGroupsOutput, error) {
 	glog.Fatalf("Not implemented")
 	return nil, nil
@@ -0,0 +1,135 @@
+/*
+Copyright 2016 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mockautoscaling
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/golang/glog"
+)
+
+// instanceRefreshStep is how far an instance refresh advances each time it is described
+const instanceRefreshStep = 50
+
+func isActiveInstanceRefresh(refresh *autoscaling.InstanceRefresh) bool {
+	switch aws.StringValue(refresh.Status) {
+	case autoscaling.InstanceRefreshStatusPending, autoscaling.InstanceRefreshStatusInProgress:
+		return true
+	}
+	return false
+}
+
+func (m *MockAutoscaling) StartInstanceRefresh(request *autoscaling.StartInstanceRefreshInput) (*autoscaling.StartInstanceRefreshOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("StartInstanceRefresh: %v", request)
+
+	groupName := aws.StringValue(request.AutoScalingGroupName)
+	group := m.Groups[groupName]
+	if group == nil {
+		return nil, fmt.Errorf("Group %q not found", groupName)
+	}
+
+	for _, refresh := range m.InstanceRefreshes {
+		if aws.StringValue(refresh.AutoScalingGroupName) == groupName && isActiveInstanceRefresh(refresh) {
+			return nil, awserr.New(autoscaling.ErrCodeInstanceRefreshInProgressFault, "An Instance Refresh is already in progress and blocks the execution of this Instance Refresh.", nil)
+		}
+	}
+
+	refresh := &autoscaling.InstanceRefresh{
+		AutoScalingGroupName: request.AutoScalingGroupName,
+		InstanceRefreshId:    aws.String(fmt.Sprintf("ir-%d", len(m.InstanceRefreshes)+1)),
+		InstancesToUpdate:    aws.Int64(int64(len(group.Instances))),
+		PercentageComplete:   aws.Int64(0),
+		StartTime:            aws.Time(time.Now()),
+		Status:               aws.String(autoscaling.InstanceRefreshStatusPending),
+	}
+	m.InstanceRefreshes = append(m.InstanceRefreshes, refresh)
+
+	return &autoscaling.StartInstanceRefreshOutput{InstanceRefreshId: refresh.InstanceRefreshId}, nil
+}
+
+// DescribeInstanceRefreshes returns the instance refreshes of the group, most recent first.
+// Every call advances the active instance refresh, so that it completes after a few polls.
+func (m *MockAutoscaling) DescribeInstanceRefreshes(request *autoscaling.DescribeInstanceRefreshesInput) (*autoscaling.DescribeInstanceRefreshesOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("DescribeInstanceRefreshes: %v", request)
+
+	groupName := aws.StringValue(request.AutoScalingGroupName)
+	if m.Groups[groupName] == nil {
+		return nil, fmt.Errorf("Group %q not found", groupName)
+	}
+
+	ids := make(map[string]bool)
+	for _, id := range request.InstanceRefreshIds {
+		ids[aws.StringValue(id)] = true
+	}
+
+	response := &autoscaling.DescribeInstanceRefreshesOutput{}
+	for i := len(m.InstanceRefreshes) - 1; i >= 0; i-- {
+		refresh := m.InstanceRefreshes[i]
+		if aws.StringValue(refresh.AutoScalingGroupName) != groupName {
+			continue
+		}
+		if len(ids) != 0 && !ids[aws.StringValue(refresh.InstanceRefreshId)] {
+			continue
+		}
+
+		switch aws.StringValue(refresh.Status) {
+		case autoscaling.InstanceRefreshStatusPending:
+			refresh.Status = aws.String(autoscaling.InstanceRefreshStatusInProgress)
+		case autoscaling.InstanceRefreshStatusInProgress:
+			percentage := aws.Int64Value(refresh.PercentageComplete) + instanceRefreshStep
+			if percentage >= 100 {
+				percentage = 100
+				refresh.Status = aws.String(autoscaling.InstanceRefreshStatusSuccessful)
+				refresh.EndTime = aws.Time(time.Now())
+			}
+			refresh.PercentageComplete = aws.Int64(percentage)
+		}
+
+		r := *refresh
+		response.InstanceRefreshes = append(response.InstanceRefreshes, &r)
+	}
+
+	return response, nil
+}
+
+func (m *MockAutoscaling) CancelInstanceRefresh(request *autoscaling.CancelInstanceRefreshInput) (*autoscaling.CancelInstanceRefreshOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("CancelInstanceRefresh: %v", request)
+
+	groupName := aws.StringValue(request.AutoScalingGroupName)
+	for _, refresh := range m.InstanceRefreshes {
+		if aws.StringValue(refresh.AutoScalingGroupName) == groupName && isActiveInstanceRefresh(refresh) {
+			refresh.Status = aws.String(autoscaling.InstanceRefreshStatusCancelled)
+			refresh.EndTime = aws.Time(time.Now())
+			return &autoscaling.CancelInstanceRefreshOutput{InstanceRefreshId: refresh.InstanceRefreshId}, nil
+		}
+	}
+
+	return nil, awserr.New(autoscaling.ErrCodeActiveInstanceRefreshNotFoundFault, "No in progress or pending Instance Refresh found.", nil)
+}
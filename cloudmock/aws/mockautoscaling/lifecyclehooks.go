@@ -0,0 +1,190 @@
+/*
+Copyright 2016 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mockautoscaling
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/golang/glog"
+)
+
+func lifecycleHookKey(groupName, hookName string) string {
+	return groupName + "/" + hookName
+}
+
+func (m *MockAutoscaling) PutLifecycleHook(request *autoscaling.PutLifecycleHookInput) (*autoscaling.PutLifecycleHookOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("PutLifecycleHook: %v", request)
+
+	groupName := aws.StringValue(request.AutoScalingGroupName)
+	if m.Groups[groupName] == nil {
+		return nil, fmt.Errorf("Group %q not found", groupName)
+	}
+
+	hook := &autoscaling.LifecycleHook{
+		AutoScalingGroupName:  request.AutoScalingGroupName,
+		DefaultResult:         request.DefaultResult,
+		HeartbeatTimeout:      request.HeartbeatTimeout,
+		LifecycleHookName:     request.LifecycleHookName,
+		LifecycleTransition:   request.LifecycleTransition,
+		NotificationMetadata:  request.NotificationMetadata,
+		NotificationTargetARN: request.NotificationTargetARN,
+		RoleARN:               request.RoleARN,
+	}
+	if hook.DefaultResult == nil {
+		hook.DefaultResult = aws.String("ABANDON")
+	}
+	if hook.HeartbeatTimeout == nil {
+		hook.HeartbeatTimeout = aws.Int64(3600)
+	}
+
+	if m.LifecycleHooks == nil {
+		m.LifecycleHooks = make(map[string]*autoscaling.LifecycleHook)
+	}
+	m.LifecycleHooks[lifecycleHookKey(groupName, aws.StringValue(request.LifecycleHookName))] = hook
+
+	return &autoscaling.PutLifecycleHookOutput{}, nil
+}
+
+func (m *MockAutoscaling) PutLifecycleHookWithContext(aws.Context, *autoscaling.PutLifecycleHookInput, ...request.Option) (*autoscaling.PutLifecycleHookOutput, error) {
+	glog.Fatalf("Not implemented")
+	return nil, nil
+}
+func (m *MockAutoscaling) PutLifecycleHookRequest(*autoscaling.PutLifecycleHookInput) (*request.Request, *autoscaling.PutLifecycleHookOutput) {
+	glog.Fatalf("Not implemented")
+	return nil, nil
+}
+
+func (m *MockAutoscaling) DescribeLifecycleHooks(request *autoscaling.DescribeLifecycleHooksInput) (*autoscaling.DescribeLifecycleHooksOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("DescribeLifecycleHooks: %v", request)
+
+	groupName := aws.StringValue(request.AutoScalingGroupName)
+	if m.Groups[groupName] == nil {
+		return nil, fmt.Errorf("Group %q not found", groupName)
+	}
+
+	response := &autoscaling.DescribeLifecycleHooksOutput{}
+	for _, hook := range m.LifecycleHooks {
+		if aws.StringValue(hook.AutoScalingGroupName) != groupName {
+			continue
+		}
+		if len(request.LifecycleHookNames) != 0 {
+			match := false
+			for _, name := range request.LifecycleHookNames {
+				if aws.StringValue(name) == aws.StringValue(hook.LifecycleHookName) {
+					match = true
+				}
+			}
+			if !match {
+				continue
+			}
+		}
+		description := *hook
+		response.LifecycleHooks = append(response.LifecycleHooks, &description)
+	}
+
+	return response, nil
+}
+
+func (m *MockAutoscaling) DescribeLifecycleHooksWithContext(aws.Context, *autoscaling.DescribeLifecycleHooksInput, ...request.Option) (*autoscaling.DescribeLifecycleHooksOutput, error) {
+	glog.Fatalf("Not implemented")
+	return nil, nil
+}
+func (m *MockAutoscaling) DescribeLifecycleHooksRequest(*autoscaling.DescribeLifecycleHooksInput) (*request.Request, *autoscaling.DescribeLifecycleHooksOutput) {
+	glog.Fatalf("Not implemented")
+	return nil, nil
+}
+
+func (m *MockAutoscaling) DeleteLifecycleHook(request *autoscaling.DeleteLifecycleHookInput) (*autoscaling.DeleteLifecycleHookOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("DeleteLifecycleHook: %v", request)
+
+	key := lifecycleHookKey(aws.StringValue(request.AutoScalingGroupName), aws.StringValue(request.LifecycleHookName))
+	if m.LifecycleHooks[key] == nil {
+		return nil, fmt.Errorf("LifecycleHook %q not found", key)
+	}
+	delete(m.LifecycleHooks, key)
+
+	return &autoscaling.DeleteLifecycleHookOutput{}, nil
+}
+
+func (m *MockAutoscaling) DeleteLifecycleHookWithContext(aws.Context, *autoscaling.DeleteLifecycleHookInput, ...request.Option) (*autoscaling.DeleteLifecycleHookOutput, error) {
+	glog.Fatalf("Not implemented")
+	return nil, nil
+}
+func (m *MockAutoscaling) DeleteLifecycleHookRequest(*autoscaling.DeleteLifecycleHookInput) (*request.Request, *autoscaling.DeleteLifecycleHookOutput) {
+	glog.Fatalf("Not implemented")
+	return nil, nil
+}
+
+func (m *MockAutoscaling) CompleteLifecycleAction(request *autoscaling.CompleteLifecycleActionInput) (*autoscaling.CompleteLifecycleActionOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("CompleteLifecycleAction: %v", request)
+
+	key := lifecycleHookKey(aws.StringValue(request.AutoScalingGroupName), aws.StringValue(request.LifecycleHookName))
+	if m.LifecycleHooks[key] == nil {
+		return nil, fmt.Errorf("LifecycleHook %q not found", key)
+	}
+	m.CompletedLifecycleActions = append(m.CompletedLifecycleActions, request)
+
+	return &autoscaling.CompleteLifecycleActionOutput{}, nil
+}
+
+func (m *MockAutoscaling) CompleteLifecycleActionWithContext(aws.Context, *autoscaling.CompleteLifecycleActionInput, ...request.Option) (*autoscaling.CompleteLifecycleActionOutput, error) {
+	glog.Fatalf("Not implemented")
+	return nil, nil
+}
+func (m *MockAutoscaling) CompleteLifecycleActionRequest(*autoscaling.CompleteLifecycleActionInput) (*request.Request, *autoscaling.CompleteLifecycleActionOutput) {
+	glog.Fatalf("Not implemented")
+	return nil, nil
+}
+
+func (m *MockAutoscaling) RecordLifecycleActionHeartbeat(request *autoscaling.RecordLifecycleActionHeartbeatInput) (*autoscaling.RecordLifecycleActionHeartbeatOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("RecordLifecycleActionHeartbeat: %v", request)
+
+	key := lifecycleHookKey(aws.StringValue(request.AutoScalingGroupName), aws.StringValue(request.LifecycleHookName))
+	if m.LifecycleHooks[key] == nil {
+		return nil, fmt.Errorf("LifecycleHook %q not found", key)
+	}
+	m.LifecycleActionHeartbeats = append(m.LifecycleActionHeartbeats, request)
+
+	return &autoscaling.RecordLifecycleActionHeartbeatOutput{}, nil
+}
+
+func (m *MockAutoscaling) RecordLifecycleActionHeartbeatWithContext(aws.Context, *autoscaling.RecordLifecycleActionHeartbeatInput, ...request.Option) (*autoscaling.RecordLifecycleActionHeartbeatOutput, error) {
+	glog.Fatalf("Not implemented")
+	return nil, nil
+}
+func (m *MockAutoscaling) RecordLifecycleActionHeartbeatRequest(*autoscaling.RecordLifecycleActionHeartbeatInput) (*request.Request, *autoscaling.RecordLifecycleActionHeartbeatOutput) {
+	glog.Fatalf("Not implemented")
+	return nil, nil
+}
@@ -20,6 +20,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"net"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/request"
@@ -137,7 +138,11 @@ func (m *MockEC2) DescribeAddresses(request *ec2.DescribeAddressesInput) (*ec2.D
 				}
 
 			default:
-				return nil, fmt.Errorf("unknown filter name: %q", *filter.Name)
+				if strings.HasPrefix(*filter.Name, "tag:") {
+					match = m.hasTag(ResourceTypeAddress, *address.AllocationId, filter)
+				} else {
+					return nil, fmt.Errorf("unknown filter name: %q", *filter.Name)
+				}
 			}
 
 			if !match {
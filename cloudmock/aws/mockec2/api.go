@@ -57,6 +57,8 @@ type MockEC2 struct {
 
 	NatGateways map[string]*ec2.NatGateway
 
+	LaunchTemplates map[string]*launchTemplateInfo
+
 	idsMutex sync.Mutex
 	ids      map[string]*idAllocator
 }
@@ -99,6 +101,9 @@ func (m *MockEC2) All() map[string]interface{} {
 	for id, o := range m.NatGateways {
 		all[id] = o
 	}
+	for id, o := range m.LaunchTemplates {
+		all[id] = o.template
+	}
 
 	return all
 }
@@ -0,0 +1,349 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mockec2
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/golang/glog"
+)
+
+type launchTemplateInfo struct {
+	template *ec2.LaunchTemplate
+	versions []*ec2.LaunchTemplateVersion
+}
+
+// responseData converts the request data to the form it is described in.
+// The request and response types have the same field names, so we convert through JSON.
+func responseData(data *ec2.RequestLaunchTemplateData) (*ec2.ResponseLaunchTemplateData, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	response := &ec2.ResponseLaunchTemplateData{}
+	if err := json.Unmarshal(b, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// findLaunchTemplate finds the launch template by id or by name; the caller must hold the mutex
+func (m *MockEC2) findLaunchTemplate(id *string, name *string) (*launchTemplateInfo, error) {
+	for _, lt := range m.LaunchTemplates {
+		if id != nil && aws.StringValue(lt.template.LaunchTemplateId) == aws.StringValue(id) {
+			return lt, nil
+		}
+		if id == nil && name != nil && aws.StringValue(lt.template.LaunchTemplateName) == aws.StringValue(name) {
+			return lt, nil
+		}
+	}
+	return nil, fmt.Errorf("LaunchTemplate %q not found", aws.StringValue(id)+aws.StringValue(name))
+}
+
+// resolveVersion returns the version number of a version, which may be $Latest or $Default
+func (lt *launchTemplateInfo) resolveVersion(version string) (int64, error) {
+	switch version {
+	case "$Latest":
+		return aws.Int64Value(lt.template.LatestVersionNumber), nil
+	case "$Default":
+		return aws.Int64Value(lt.template.DefaultVersionNumber), nil
+	default:
+		return strconv.ParseInt(version, 10, 64)
+	}
+}
+
+func (m *MockEC2) CreateLaunchTemplate(request *ec2.CreateLaunchTemplateInput) (*ec2.CreateLaunchTemplateOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("CreateLaunchTemplate: %v", request)
+
+	for _, lt := range m.LaunchTemplates {
+		if aws.StringValue(lt.template.LaunchTemplateName) == aws.StringValue(request.LaunchTemplateName) {
+			return nil, fmt.Errorf("LaunchTemplate %q already exists", aws.StringValue(request.LaunchTemplateName))
+		}
+	}
+
+	data, err := responseData(request.LaunchTemplateData)
+	if err != nil {
+		return nil, err
+	}
+
+	id := m.allocateId("lt")
+	now := time.Now().UTC()
+	lt := &launchTemplateInfo{
+		template: &ec2.LaunchTemplate{
+			CreateTime:           &now,
+			DefaultVersionNumber: aws.Int64(1),
+			LatestVersionNumber:  aws.Int64(1),
+			LaunchTemplateId:     aws.String(id),
+			LaunchTemplateName:   request.LaunchTemplateName,
+		},
+	}
+	lt.versions = append(lt.versions, &ec2.LaunchTemplateVersion{
+		CreateTime:         &now,
+		DefaultVersion:     aws.Bool(true),
+		LaunchTemplateData: data,
+		LaunchTemplateId:   aws.String(id),
+		LaunchTemplateName: request.LaunchTemplateName,
+		VersionDescription: request.VersionDescription,
+		VersionNumber:      aws.Int64(1),
+	})
+
+	if m.LaunchTemplates == nil {
+		m.LaunchTemplates = make(map[string]*launchTemplateInfo)
+	}
+	m.LaunchTemplates[id] = lt
+
+	copy := *lt.template
+	return &ec2.CreateLaunchTemplateOutput{LaunchTemplate: &copy}, nil
+}
+
+func (m *MockEC2) CreateLaunchTemplateVersion(request *ec2.CreateLaunchTemplateVersionInput) (*ec2.CreateLaunchTemplateVersionOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("CreateLaunchTemplateVersion: %v", request)
+
+	lt, err := m.findLaunchTemplate(request.LaunchTemplateId, request.LaunchTemplateName)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := responseData(request.LaunchTemplateData)
+	if err != nil {
+		return nil, err
+	}
+
+	number := aws.Int64Value(lt.template.LatestVersionNumber) + 1
+	now := time.Now().UTC()
+	version := &ec2.LaunchTemplateVersion{
+		CreateTime:         &now,
+		DefaultVersion:     aws.Bool(false),
+		LaunchTemplateData: data,
+		LaunchTemplateId:   lt.template.LaunchTemplateId,
+		LaunchTemplateName: lt.template.LaunchTemplateName,
+		VersionDescription: request.VersionDescription,
+		VersionNumber:      aws.Int64(number),
+	}
+	lt.versions = append(lt.versions, version)
+	lt.template.LatestVersionNumber = aws.Int64(number)
+
+	copy := *version
+	return &ec2.CreateLaunchTemplateVersionOutput{LaunchTemplateVersion: &copy}, nil
+}
+
+func (m *MockEC2) ModifyLaunchTemplate(request *ec2.ModifyLaunchTemplateInput) (*ec2.ModifyLaunchTemplateOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("ModifyLaunchTemplate: %v", request)
+
+	lt, err := m.findLaunchTemplate(request.LaunchTemplateId, request.LaunchTemplateName)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.DefaultVersion != nil {
+		number, err := lt.resolveVersion(aws.StringValue(request.DefaultVersion))
+		if err != nil {
+			return nil, err
+		}
+		found := false
+		for _, v := range lt.versions {
+			isDefault := aws.Int64Value(v.VersionNumber) == number
+			v.DefaultVersion = aws.Bool(isDefault)
+			found = found || isDefault
+		}
+		if !found {
+			return nil, fmt.Errorf("version %d of LaunchTemplate %q not found", number, aws.StringValue(lt.template.LaunchTemplateId))
+		}
+		lt.template.DefaultVersionNumber = aws.Int64(number)
+	}
+
+	copy := *lt.template
+	return &ec2.ModifyLaunchTemplateOutput{LaunchTemplate: &copy}, nil
+}
+
+func (m *MockEC2) DescribeLaunchTemplates(request *ec2.DescribeLaunchTemplatesInput) (*ec2.DescribeLaunchTemplatesOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("DescribeLaunchTemplates: %v", request)
+
+	var templates []*ec2.LaunchTemplate
+	for _, lt := range m.LaunchTemplates {
+		allFiltersMatch := true
+
+		if len(request.LaunchTemplateIds) != 0 {
+			match := false
+			for _, id := range request.LaunchTemplateIds {
+				if aws.StringValue(id) == aws.StringValue(lt.template.LaunchTemplateId) {
+					match = true
+				}
+			}
+			if !match {
+				allFiltersMatch = false
+			}
+		}
+
+		if len(request.LaunchTemplateNames) != 0 {
+			match := false
+			for _, name := range request.LaunchTemplateNames {
+				if aws.StringValue(name) == aws.StringValue(lt.template.LaunchTemplateName) {
+					match = true
+				}
+			}
+			if !match {
+				allFiltersMatch = false
+			}
+		}
+
+		for _, filter := range request.Filters {
+			match := false
+			switch aws.StringValue(filter.Name) {
+			case "launch-template-name":
+				for _, v := range filter.Values {
+					// EC2 filters support * wildcards
+					if ok, _ := path.Match(aws.StringValue(v), aws.StringValue(lt.template.LaunchTemplateName)); ok {
+						match = true
+					}
+				}
+			default:
+				return nil, fmt.Errorf("unknown filter name: %q", aws.StringValue(filter.Name))
+			}
+
+			if !match {
+				allFiltersMatch = false
+				break
+			}
+		}
+
+		if !allFiltersMatch {
+			continue
+		}
+
+		copy := *lt.template
+		templates = append(templates, &copy)
+	}
+
+	return &ec2.DescribeLaunchTemplatesOutput{LaunchTemplates: templates}, nil
+}
+
+func (m *MockEC2) DescribeLaunchTemplateVersions(request *ec2.DescribeLaunchTemplateVersionsInput) (*ec2.DescribeLaunchTemplateVersionsOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("DescribeLaunchTemplateVersions: %v", request)
+
+	lt, err := m.findLaunchTemplate(request.LaunchTemplateId, request.LaunchTemplateName)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[int64]bool)
+	for _, v := range request.Versions {
+		number, err := lt.resolveVersion(aws.StringValue(v))
+		if err != nil {
+			return nil, err
+		}
+		wanted[number] = true
+	}
+
+	var versions []*ec2.LaunchTemplateVersion
+	for _, v := range lt.versions {
+		if len(wanted) != 0 && !wanted[aws.Int64Value(v.VersionNumber)] {
+			continue
+		}
+		copy := *v
+		versions = append(versions, &copy)
+	}
+
+	return &ec2.DescribeLaunchTemplateVersionsOutput{LaunchTemplateVersions: versions}, nil
+}
+
+func (m *MockEC2) DeleteLaunchTemplateVersions(request *ec2.DeleteLaunchTemplateVersionsInput) (*ec2.DeleteLaunchTemplateVersionsOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("DeleteLaunchTemplateVersions: %v", request)
+
+	lt, err := m.findLaunchTemplate(request.LaunchTemplateId, request.LaunchTemplateName)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &ec2.DeleteLaunchTemplateVersionsOutput{}
+	for _, v := range request.Versions {
+		number, err := lt.resolveVersion(aws.StringValue(v))
+		if err != nil {
+			return nil, err
+		}
+
+		var remaining []*ec2.LaunchTemplateVersion
+		var deleted *ec2.LaunchTemplateVersion
+		for _, version := range lt.versions {
+			if aws.Int64Value(version.VersionNumber) == number {
+				deleted = version
+			} else {
+				remaining = append(remaining, version)
+			}
+		}
+
+		if deleted == nil || aws.BoolValue(deleted.DefaultVersion) {
+			response.UnsuccessfullyDeletedLaunchTemplateVersions = append(response.UnsuccessfullyDeletedLaunchTemplateVersions, &ec2.DeleteLaunchTemplateVersionsResponseErrorItem{
+				LaunchTemplateId: lt.template.LaunchTemplateId,
+				VersionNumber:    aws.Int64(number),
+				ResponseError: &ec2.ResponseError{
+					Code:    aws.String("launchTemplateVersionDoesNotExist"),
+					Message: aws.String(fmt.Sprintf("version %d cannot be deleted", number)),
+				},
+			})
+			continue
+		}
+
+		lt.versions = remaining
+		response.SuccessfullyDeletedLaunchTemplateVersions = append(response.SuccessfullyDeletedLaunchTemplateVersions, &ec2.DeleteLaunchTemplateVersionsResponseSuccessItem{
+			LaunchTemplateId: lt.template.LaunchTemplateId,
+			VersionNumber:    aws.Int64(number),
+		})
+	}
+
+	return response, nil
+}
+
+func (m *MockEC2) DeleteLaunchTemplate(request *ec2.DeleteLaunchTemplateInput) (*ec2.DeleteLaunchTemplateOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("DeleteLaunchTemplate: %v", request)
+
+	lt, err := m.findLaunchTemplate(request.LaunchTemplateId, request.LaunchTemplateName)
+	if err != nil {
+		return nil, err
+	}
+	delete(m.LaunchTemplates, aws.StringValue(lt.template.LaunchTemplateId))
+
+	copy := *lt.template
+	return &ec2.DeleteLaunchTemplateOutput{LaunchTemplate: &copy}, nil
+}
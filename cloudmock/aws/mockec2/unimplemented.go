@@ -2107,10 +2107,6 @@ func (m *MockEC2) CreateDefaultSubnetRequest(*ec2.CreateDefaultSubnetInput) (*re
 	panic("Not implemented")
 }
 
-func (m *MockEC2) CreateLaunchTemplate(*ec2.CreateLaunchTemplateInput) (*ec2.CreateLaunchTemplateOutput, error) {
-	panic("Not implemented")
-}
-
 func (m *MockEC2) CreateLaunchTemplateWithContext(aws.Context, *ec2.CreateLaunchTemplateInput, ...request.Option) (*ec2.CreateLaunchTemplateOutput, error) {
 	panic("Not implemented")
 }
@@ -2119,10 +2115,6 @@ func (m *MockEC2) CreateLaunchTemplateRequest(*ec2.CreateLaunchTemplateInput) (*
 	panic("Not implemented")
 }
 
-func (m *MockEC2) CreateLaunchTemplateVersion(*ec2.CreateLaunchTemplateVersionInput) (*ec2.CreateLaunchTemplateVersionOutput, error) {
-	panic("Not implemented")
-}
-
 func (m *MockEC2) CreateLaunchTemplateVersionWithContext(aws.Context, *ec2.CreateLaunchTemplateVersionInput, ...request.Option) (*ec2.CreateLaunchTemplateVersionOutput, error) {
 	panic("Not implemented")
 }
@@ -2155,10 +2147,6 @@ func (m *MockEC2) CreateVpcEndpointServiceConfigurationRequest(*ec2.CreateVpcEnd
 	panic("Not implemented")
 }
 
-func (m *MockEC2) DeleteLaunchTemplate(*ec2.DeleteLaunchTemplateInput) (*ec2.DeleteLaunchTemplateOutput, error) {
-	panic("Not implemented")
-}
-
 func (m *MockEC2) DeleteLaunchTemplateWithContext(aws.Context, *ec2.DeleteLaunchTemplateInput, ...request.Option) (*ec2.DeleteLaunchTemplateOutput, error) {
 	panic("Not implemented")
 }
@@ -2167,10 +2155,6 @@ func (m *MockEC2) DeleteLaunchTemplateRequest(*ec2.DeleteLaunchTemplateInput) (*
 	panic("Not implemented")
 }
 
-func (m *MockEC2) DeleteLaunchTemplateVersions(*ec2.DeleteLaunchTemplateVersionsInput) (*ec2.DeleteLaunchTemplateVersionsOutput, error) {
-	panic("Not implemented")
-}
-
 func (m *MockEC2) DeleteLaunchTemplateVersionsWithContext(aws.Context, *ec2.DeleteLaunchTemplateVersionsInput, ...request.Option) (*ec2.DeleteLaunchTemplateVersionsOutput, error) {
 	panic("Not implemented")
 }
@@ -2213,9 +2197,6 @@ func (m *MockEC2) DescribeInstanceCreditSpecificationsRequest(*ec2.DescribeInsta
 	panic("Not implemented")
 }
 
-func (m *MockEC2) DescribeLaunchTemplateVersions(*ec2.DescribeLaunchTemplateVersionsInput) (*ec2.DescribeLaunchTemplateVersionsOutput, error) {
-	panic("Not implemented")
-}
 func (m *MockEC2) DescribeLaunchTemplateVersionsWithContext(aws.Context, *ec2.DescribeLaunchTemplateVersionsInput, ...request.Option) (*ec2.DescribeLaunchTemplateVersionsOutput, error) {
 	panic("Not implemented")
 }
@@ -2223,10 +2204,6 @@ func (m *MockEC2) DescribeLaunchTemplateVersionsWithContext(aws.Context, *ec2.De
 func (m *MockEC2) DescribeLaunchTemplateVersionsRequest(*ec2.DescribeLaunchTemplateVersionsInput) (*request.Request, *ec2.DescribeLaunchTemplateVersionsOutput) {
 	panic("Not implemented")
 }
-func (m *MockEC2) DescribeLaunchTemplates(*ec2.DescribeLaunchTemplatesInput) (*ec2.DescribeLaunchTemplatesOutput, error) {
-	panic("Not implemented")
-}
-
 func (m *MockEC2) DescribeLaunchTemplatesWithContext(aws.Context, *ec2.DescribeLaunchTemplatesInput, ...request.Option) (*ec2.DescribeLaunchTemplatesOutput, error) {
 	panic("Not implemented")
 }
@@ -2301,10 +2278,6 @@ func (m *MockEC2) ModifyInstanceCreditSpecificationRequest(*ec2.ModifyInstanceCr
 	panic("Not implemented")
 }
 
-func (m *MockEC2) ModifyLaunchTemplate(*ec2.ModifyLaunchTemplateInput) (*ec2.ModifyLaunchTemplateOutput, error) {
-	panic("Not implemented")
-}
-
 func (m *MockEC2) ModifyLaunchTemplateWithContext(aws.Context, *ec2.ModifyLaunchTemplateInput, ...request.Option) (*ec2.ModifyLaunchTemplateOutput, error) {
 	panic("Not implemented")
 }
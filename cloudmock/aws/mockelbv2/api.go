@@ -17,6 +17,7 @@ limitations under the License.
 package mockelbv2
 
 import (
+	"fmt"
 	"sync"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -25,6 +26,8 @@ import (
 	"github.com/golang/glog"
 )
 
+const elbv2ZoneID = "FAKEZONE-CLOUDMOCK-ELBV2"
+
 type MockELBV2 struct {
 	elbv2iface.ELBV2API
 
@@ -32,13 +35,21 @@ type MockELBV2 struct {
 
 	LoadBalancers map[string]*loadBalancer
 	TargetGroups  map[string]*targetGroup
+	Listeners     map[string]*listener
+
+	nextId int
 }
 
 type loadBalancer struct {
 	description elbv2.LoadBalancer
+	attributes  map[string]string
 	tags        map[string]string
 }
 
+type listener struct {
+	description elbv2.Listener
+}
+
 type targetGroup struct {
 	description elbv2.TargetGroup
 	tags        map[string]string
@@ -67,6 +78,12 @@ func (m *MockELBV2) DescribeLoadBalancers(request *elbv2.DescribeLoadBalancersIn
 					match = true
 				}
 			}
+		} else if len(request.Names) > 0 {
+			for _, name := range request.Names {
+				if aws.StringValue(elb.description.LoadBalancerName) == aws.StringValue(name) {
+					match = true
+				}
+			}
 		} else {
 			match = true
 		}
@@ -116,6 +133,12 @@ func (m *MockELBV2) DescribeTargetGroups(request *elbv2.DescribeTargetGroupsInpu
 					match = true
 				}
 			}
+		} else if len(request.Names) > 0 {
+			for _, name := range request.Names {
+				if aws.StringValue(tg.description.TargetGroupName) == aws.StringValue(name) {
+					match = true
+				}
+			}
 		} else {
 			match = true
 		}
@@ -140,3 +163,215 @@ func (m *MockELBV2) DescribeTargetGroupsPages(request *elbv2.DescribeTargetGroup
 
 	return nil
 }
+
+func (m *MockELBV2) CreateLoadBalancer(request *elbv2.CreateLoadBalancerInput) (*elbv2.CreateLoadBalancerOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("CreateLoadBalancer v2 %v", request)
+
+	name := aws.StringValue(request.Name)
+	for _, elb := range m.LoadBalancers {
+		if aws.StringValue(elb.description.LoadBalancerName) == name {
+			return nil, fmt.Errorf("load balancer %q already exists", name)
+		}
+	}
+
+	m.nextId++
+	id := fmt.Sprintf("%016x", m.nextId)
+	lbType := aws.StringValue(request.Type)
+	if lbType == "" {
+		lbType = elbv2.LoadBalancerTypeEnumApplication
+	}
+	scheme := aws.StringValue(request.Scheme)
+	if scheme == "" {
+		scheme = elbv2.LoadBalancerSchemeEnumInternetFacing
+	}
+
+	lb := &loadBalancer{
+		description: elbv2.LoadBalancer{
+			LoadBalancerArn:       aws.String(fmt.Sprintf("arn:aws:elasticloadbalancing:us-test-1:000000000000:loadbalancer/%s/%s/%s", lbType[:3], name, id)),
+			LoadBalancerName:      aws.String(name),
+			DNSName:               aws.String(fmt.Sprintf("%s-%s.elb.us-test-1.amazonaws.com", name, id)),
+			CanonicalHostedZoneId: aws.String(elbv2ZoneID),
+			Scheme:                aws.String(scheme),
+			Type:                  aws.String(lbType),
+			SecurityGroups:        request.SecurityGroups,
+		},
+		attributes: make(map[string]string),
+		tags:       make(map[string]string),
+	}
+	for _, subnet := range request.Subnets {
+		lb.description.AvailabilityZones = append(lb.description.AvailabilityZones, &elbv2.AvailabilityZone{
+			SubnetId: subnet,
+		})
+	}
+	for _, mapping := range request.SubnetMappings {
+		az := &elbv2.AvailabilityZone{
+			SubnetId: mapping.SubnetId,
+		}
+		if mapping.AllocationId != nil {
+			az.LoadBalancerAddresses = []*elbv2.LoadBalancerAddress{{AllocationId: mapping.AllocationId}}
+		}
+		lb.description.AvailabilityZones = append(lb.description.AvailabilityZones, az)
+	}
+	for _, tag := range request.Tags {
+		lb.tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+
+	if m.LoadBalancers == nil {
+		m.LoadBalancers = make(map[string]*loadBalancer)
+	}
+	m.LoadBalancers[aws.StringValue(lb.description.LoadBalancerArn)] = lb
+
+	description := lb.description
+	return &elbv2.CreateLoadBalancerOutput{
+		LoadBalancers: []*elbv2.LoadBalancer{&description},
+	}, nil
+}
+
+func (m *MockELBV2) DeleteLoadBalancer(request *elbv2.DeleteLoadBalancerInput) (*elbv2.DeleteLoadBalancerOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("DeleteLoadBalancer v2 %v", request)
+
+	arn := aws.StringValue(request.LoadBalancerArn)
+	if m.LoadBalancers[arn] == nil {
+		return nil, fmt.Errorf("load balancer %q not found", arn)
+	}
+	delete(m.LoadBalancers, arn)
+	for k, l := range m.Listeners {
+		if aws.StringValue(l.description.LoadBalancerArn) == arn {
+			delete(m.Listeners, k)
+		}
+	}
+
+	return &elbv2.DeleteLoadBalancerOutput{}, nil
+}
+
+func (m *MockELBV2) DescribeLoadBalancerAttributes(request *elbv2.DescribeLoadBalancerAttributesInput) (*elbv2.DescribeLoadBalancerAttributesOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.V(2).Infof("DescribeLoadBalancerAttributes v2 %v", request)
+
+	arn := aws.StringValue(request.LoadBalancerArn)
+	lb := m.LoadBalancers[arn]
+	if lb == nil {
+		return nil, fmt.Errorf("load balancer %q not found", arn)
+	}
+
+	response := &elbv2.DescribeLoadBalancerAttributesOutput{}
+	for k, v := range lb.attributes {
+		response.Attributes = append(response.Attributes, &elbv2.LoadBalancerAttribute{
+			Key:   aws.String(k),
+			Value: aws.String(v),
+		})
+	}
+	return response, nil
+}
+
+func (m *MockELBV2) ModifyLoadBalancerAttributes(request *elbv2.ModifyLoadBalancerAttributesInput) (*elbv2.ModifyLoadBalancerAttributesOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("ModifyLoadBalancerAttributes v2 %v", request)
+
+	arn := aws.StringValue(request.LoadBalancerArn)
+	lb := m.LoadBalancers[arn]
+	if lb == nil {
+		return nil, fmt.Errorf("load balancer %q not found", arn)
+	}
+
+	for _, attribute := range request.Attributes {
+		lb.attributes[aws.StringValue(attribute.Key)] = aws.StringValue(attribute.Value)
+	}
+	return &elbv2.ModifyLoadBalancerAttributesOutput{Attributes: request.Attributes}, nil
+}
+
+func (m *MockELBV2) CreateTargetGroup(request *elbv2.CreateTargetGroupInput) (*elbv2.CreateTargetGroupOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("CreateTargetGroup %v", request)
+
+	name := aws.StringValue(request.Name)
+	for _, tg := range m.TargetGroups {
+		if aws.StringValue(tg.description.TargetGroupName) == name {
+			return nil, fmt.Errorf("target group %q already exists", name)
+		}
+	}
+
+	m.nextId++
+	tg := &targetGroup{
+		description: elbv2.TargetGroup{
+			TargetGroupArn:  aws.String(fmt.Sprintf("arn:aws:elasticloadbalancing:us-test-1:000000000000:targetgroup/%s/%016x", name, m.nextId)),
+			TargetGroupName: aws.String(name),
+			Port:            request.Port,
+			Protocol:        request.Protocol,
+			VpcId:           request.VpcId,
+			HealthCheckIntervalSeconds: request.HealthCheckIntervalSeconds,
+			HealthCheckPath:            request.HealthCheckPath,
+			HealthCheckPort:            request.HealthCheckPort,
+			HealthCheckProtocol:        request.HealthCheckProtocol,
+			HealthyThresholdCount:      request.HealthyThresholdCount,
+			UnhealthyThresholdCount:    request.UnhealthyThresholdCount,
+			TargetType:                 request.TargetType,
+		},
+		tags: make(map[string]string),
+	}
+
+	if m.TargetGroups == nil {
+		m.TargetGroups = make(map[string]*targetGroup)
+	}
+	m.TargetGroups[aws.StringValue(tg.description.TargetGroupArn)] = tg
+
+	description := tg.description
+	return &elbv2.CreateTargetGroupOutput{
+		TargetGroups: []*elbv2.TargetGroup{&description},
+	}, nil
+}
+
+func (m *MockELBV2) ModifyTargetGroup(request *elbv2.ModifyTargetGroupInput) (*elbv2.ModifyTargetGroupOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("ModifyTargetGroup %v", request)
+
+	arn := aws.StringValue(request.TargetGroupArn)
+	tg := m.TargetGroups[arn]
+	if tg == nil {
+		return nil, fmt.Errorf("target group %q not found", arn)
+	}
+
+	if request.HealthCheckIntervalSeconds != nil {
+		tg.description.HealthCheckIntervalSeconds = request.HealthCheckIntervalSeconds
+	}
+	if request.HealthyThresholdCount != nil {
+		tg.description.HealthyThresholdCount = request.HealthyThresholdCount
+	}
+	if request.UnhealthyThresholdCount != nil {
+		tg.description.UnhealthyThresholdCount = request.UnhealthyThresholdCount
+	}
+
+	description := tg.description
+	return &elbv2.ModifyTargetGroupOutput{
+		TargetGroups: []*elbv2.TargetGroup{&description},
+	}, nil
+}
+
+func (m *MockELBV2) DeleteTargetGroup(request *elbv2.DeleteTargetGroupInput) (*elbv2.DeleteTargetGroupOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("DeleteTargetGroup %v", request)
+
+	arn := aws.StringValue(request.TargetGroupArn)
+	if m.TargetGroups[arn] == nil {
+		return nil, fmt.Errorf("target group %q not found", arn)
+	}
+	delete(m.TargetGroups, arn)
+
+	return &elbv2.DeleteTargetGroupOutput{}, nil
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mockelbv2
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/golang/glog"
+)
+
+func (m *MockELBV2) CreateListener(request *elbv2.CreateListenerInput) (*elbv2.CreateListenerOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("CreateListener %v", request)
+
+	lbArn := aws.StringValue(request.LoadBalancerArn)
+	if m.LoadBalancers[lbArn] == nil {
+		return nil, fmt.Errorf("load balancer %q not found", lbArn)
+	}
+	for _, l := range m.Listeners {
+		if aws.StringValue(l.description.LoadBalancerArn) == lbArn && aws.Int64Value(l.description.Port) == aws.Int64Value(request.Port) {
+			return nil, fmt.Errorf("listener on port %d already exists", aws.Int64Value(request.Port))
+		}
+	}
+
+	m.nextId++
+	l := &listener{
+		description: elbv2.Listener{
+			ListenerArn:     aws.String(fmt.Sprintf("%s/%016x", lbArn, m.nextId)),
+			LoadBalancerArn: request.LoadBalancerArn,
+			Port:            request.Port,
+			Protocol:        request.Protocol,
+			DefaultActions:  request.DefaultActions,
+			Certificates:    request.Certificates,
+			SslPolicy:       request.SslPolicy,
+		},
+	}
+
+	if m.Listeners == nil {
+		m.Listeners = make(map[string]*listener)
+	}
+	m.Listeners[aws.StringValue(l.description.ListenerArn)] = l
+
+	description := l.description
+	return &elbv2.CreateListenerOutput{
+		Listeners: []*elbv2.Listener{&description},
+	}, nil
+}
+
+func (m *MockELBV2) DescribeListeners(request *elbv2.DescribeListenersInput) (*elbv2.DescribeListenersOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.V(2).Infof("DescribeListeners %v", request)
+
+	if request.Marker != nil {
+		glog.Fatalf("Marker not implemented")
+	}
+
+	var listeners []*elbv2.Listener
+	for _, l := range m.Listeners {
+		match := false
+
+		if len(request.ListenerArns) > 0 {
+			for _, arn := range request.ListenerArns {
+				if aws.StringValue(l.description.ListenerArn) == aws.StringValue(arn) {
+					match = true
+				}
+			}
+		} else if request.LoadBalancerArn != nil {
+			match = aws.StringValue(l.description.LoadBalancerArn) == aws.StringValue(request.LoadBalancerArn)
+		} else {
+			match = true
+		}
+
+		if match {
+			description := l.description
+			listeners = append(listeners, &description)
+		}
+	}
+
+	return &elbv2.DescribeListenersOutput{
+		Listeners: listeners,
+	}, nil
+}
+
+func (m *MockELBV2) ModifyListener(request *elbv2.ModifyListenerInput) (*elbv2.ModifyListenerOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("ModifyListener %v", request)
+
+	arn := aws.StringValue(request.ListenerArn)
+	l := m.Listeners[arn]
+	if l == nil {
+		return nil, fmt.Errorf("listener %q not found", arn)
+	}
+
+	if request.Port != nil {
+		l.description.Port = request.Port
+	}
+	if request.Protocol != nil {
+		l.description.Protocol = request.Protocol
+	}
+	if request.DefaultActions != nil {
+		l.description.DefaultActions = request.DefaultActions
+	}
+
+	description := l.description
+	return &elbv2.ModifyListenerOutput{
+		Listeners: []*elbv2.Listener{&description},
+	}, nil
+}
+
+func (m *MockELBV2) DeleteListener(request *elbv2.DeleteListenerInput) (*elbv2.DeleteListenerOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("DeleteListener %v", request)
+
+	arn := aws.StringValue(request.ListenerArn)
+	if m.Listeners[arn] == nil {
+		return nil, fmt.Errorf("listener %q not found", arn)
+	}
+	delete(m.Listeners, arn)
+
+	return &elbv2.DeleteListenerOutput{}, nil
+}
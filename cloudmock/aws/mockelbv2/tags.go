@@ -0,0 +1,85 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mockelbv2
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/golang/glog"
+)
+
+// findTags returns the tags of the load balancer or target group with the ARN
+func (m *MockELBV2) findTags(arn string) map[string]string {
+	if lb := m.LoadBalancers[arn]; lb != nil {
+		return lb.tags
+	}
+	if tg := m.TargetGroups[arn]; tg != nil {
+		return tg.tags
+	}
+	return nil
+}
+
+func (m *MockELBV2) DescribeTags(request *elbv2.DescribeTagsInput) (*elbv2.DescribeTagsOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("DescribeTags v2 %v", request)
+
+	var tags []*elbv2.TagDescription
+	for _, arn := range request.ResourceArns {
+		resourceTags := m.findTags(aws.StringValue(arn))
+		if resourceTags == nil {
+			return nil, fmt.Errorf("resource %q not found", aws.StringValue(arn))
+		}
+
+		tagDescription := &elbv2.TagDescription{
+			ResourceArn: arn,
+		}
+		for k, v := range resourceTags {
+			tagDescription.Tags = append(tagDescription.Tags, &elbv2.Tag{
+				Key:   aws.String(k),
+				Value: aws.String(v),
+			})
+		}
+		tags = append(tags, tagDescription)
+	}
+
+	return &elbv2.DescribeTagsOutput{
+		TagDescriptions: tags,
+	}, nil
+}
+
+func (m *MockELBV2) AddTags(request *elbv2.AddTagsInput) (*elbv2.AddTagsOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("AddTags v2 %v", request)
+
+	for _, arn := range request.ResourceArns {
+		resourceTags := m.findTags(aws.StringValue(arn))
+		if resourceTags == nil {
+			return nil, fmt.Errorf("resource %q not found", aws.StringValue(arn))
+		}
+		for _, tag := range request.Tags {
+			resourceTags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+		}
+	}
+
+	return &elbv2.AddTagsOutput{}, nil
+}
@@ -61,6 +61,7 @@ func (m *MockIAM) CreateRole(request *iam.CreateRoleInput) (*iam.CreateRoleOutpu
 		Path:                     request.Path,
 		RoleName:                 request.RoleName,
 		RoleId:                   &roleID,
+		Arn:                      aws.String("arn:aws:iam::123456789012:role/" + aws.StringValue(request.RoleName)),
 	}
 
 	if m.Roles == nil {
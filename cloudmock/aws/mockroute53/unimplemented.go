@@ -537,3 +537,10 @@ func (m *MockRoute53) ListQueryLoggingConfigsWithContext(aws.Context, *route53.L
 func (m *MockRoute53) ListQueryLoggingConfigsRequest(*route53.ListQueryLoggingConfigsInput) (*request.Request, *route53.ListQueryLoggingConfigsOutput) {
 	panic("Not implemented")
 }
+
+func (m *MockRoute53) ListQueryLoggingConfigsPages(*route53.ListQueryLoggingConfigsInput, func(*route53.ListQueryLoggingConfigsOutput, bool) bool) error {
+	panic("Not implemented")
+}
+func (m *MockRoute53) ListQueryLoggingConfigsPagesWithContext(aws.Context, *route53.ListQueryLoggingConfigsInput, func(*route53.ListQueryLoggingConfigsOutput, bool) bool, ...request.Option) error {
+	panic("Not implemented")
+}
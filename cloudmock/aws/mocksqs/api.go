@@ -0,0 +1,284 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mocksqs
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/golang/glog"
+)
+
+const (
+	accountID = "123456789012"
+	region    = "us-test-1"
+)
+
+type MockSQS struct {
+	sqsiface.SQSAPI
+
+	mutex sync.Mutex
+
+	Queues map[string]*queue
+
+	nextId int
+}
+
+var _ sqsiface.SQSAPI = &MockSQS{}
+
+type queue struct {
+	name       string
+	attributes map[string]string
+	tags       map[string]string
+	messages   []*sqs.Message
+}
+
+func queueURL(name string) string {
+	return fmt.Sprintf("https://sqs.%s.amazonaws.com/%s/%s", region, accountID, name)
+}
+
+func (m *MockSQS) findQueue(url *string) (*queue, error) {
+	q := m.Queues[aws.StringValue(url)]
+	if q == nil {
+		return nil, awserr.New(sqs.ErrCodeQueueDoesNotExist, "The specified queue does not exist.", nil)
+	}
+	return q, nil
+}
+
+func (m *MockSQS) CreateQueue(request *sqs.CreateQueueInput) (*sqs.CreateQueueOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("CreateQueue: %v", request)
+
+	name := aws.StringValue(request.QueueName)
+	url := queueURL(name)
+	if m.Queues == nil {
+		m.Queues = make(map[string]*queue)
+	}
+	if m.Queues[url] == nil {
+		q := &queue{
+			name:       name,
+			attributes: make(map[string]string),
+			tags:       make(map[string]string),
+		}
+		q.attributes[sqs.QueueAttributeNameQueueArn] = fmt.Sprintf("arn:aws:sqs:%s:%s:%s", region, accountID, name)
+		for k, v := range request.Attributes {
+			q.attributes[k] = aws.StringValue(v)
+		}
+		m.Queues[url] = q
+	}
+
+	return &sqs.CreateQueueOutput{QueueUrl: aws.String(url)}, nil
+}
+
+func (m *MockSQS) GetQueueUrl(request *sqs.GetQueueUrlInput) (*sqs.GetQueueUrlOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("GetQueueUrl: %v", request)
+
+	url := queueURL(aws.StringValue(request.QueueName))
+	if _, err := m.findQueue(&url); err != nil {
+		return nil, err
+	}
+	return &sqs.GetQueueUrlOutput{QueueUrl: aws.String(url)}, nil
+}
+
+func (m *MockSQS) ListQueues(request *sqs.ListQueuesInput) (*sqs.ListQueuesOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("ListQueues: %v", request)
+
+	response := &sqs.ListQueuesOutput{}
+	for url, q := range m.Queues {
+		if strings.HasPrefix(q.name, aws.StringValue(request.QueueNamePrefix)) {
+			response.QueueUrls = append(response.QueueUrls, aws.String(url))
+		}
+	}
+	return response, nil
+}
+
+func (m *MockSQS) DeleteQueue(request *sqs.DeleteQueueInput) (*sqs.DeleteQueueOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("DeleteQueue: %v", request)
+
+	if _, err := m.findQueue(request.QueueUrl); err != nil {
+		return nil, err
+	}
+	delete(m.Queues, aws.StringValue(request.QueueUrl))
+	return &sqs.DeleteQueueOutput{}, nil
+}
+
+func (m *MockSQS) GetQueueAttributes(request *sqs.GetQueueAttributesInput) (*sqs.GetQueueAttributesOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("GetQueueAttributes: %v", request)
+
+	q, err := m.findQueue(request.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &sqs.GetQueueAttributesOutput{Attributes: make(map[string]*string)}
+	for _, name := range request.AttributeNames {
+		if v, ok := q.attributes[aws.StringValue(name)]; ok {
+			response.Attributes[aws.StringValue(name)] = aws.String(v)
+		}
+	}
+	return response, nil
+}
+
+func (m *MockSQS) SetQueueAttributes(request *sqs.SetQueueAttributesInput) (*sqs.SetQueueAttributesOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("SetQueueAttributes: %v", request)
+
+	q, err := m.findQueue(request.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range request.Attributes {
+		q.attributes[k] = aws.StringValue(v)
+	}
+	return &sqs.SetQueueAttributesOutput{}, nil
+}
+
+func (m *MockSQS) ListQueueTags(request *sqs.ListQueueTagsInput) (*sqs.ListQueueTagsOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("ListQueueTags: %v", request)
+
+	q, err := m.findQueue(request.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &sqs.ListQueueTagsOutput{Tags: make(map[string]*string)}
+	for k, v := range q.tags {
+		response.Tags[k] = aws.String(v)
+	}
+	return response, nil
+}
+
+func (m *MockSQS) TagQueue(request *sqs.TagQueueInput) (*sqs.TagQueueOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("TagQueue: %v", request)
+
+	q, err := m.findQueue(request.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range request.Tags {
+		q.tags[k] = aws.StringValue(v)
+	}
+	return &sqs.TagQueueOutput{}, nil
+}
+
+func (m *MockSQS) SendMessage(request *sqs.SendMessageInput) (*sqs.SendMessageOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("SendMessage: %v", request)
+
+	q, err := m.findQueue(request.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	m.nextId++
+	message := &sqs.Message{
+		MessageId:     aws.String(fmt.Sprintf("message-%d", m.nextId)),
+		ReceiptHandle: aws.String(fmt.Sprintf("receipt-%d", m.nextId)),
+		Body:          request.MessageBody,
+	}
+	q.messages = append(q.messages, message)
+	return &sqs.SendMessageOutput{MessageId: message.MessageId}, nil
+}
+
+// MessageCount returns the number of messages in the queue that have not been deleted
+func (m *MockSQS) MessageCount(url string) int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	q := m.Queues[url]
+	if q == nil {
+		return 0
+	}
+	return len(q.messages)
+}
+
+func (m *MockSQS) ReceiveMessage(request *sqs.ReceiveMessageInput) (*sqs.ReceiveMessageOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("ReceiveMessage: %v", request)
+
+	q, err := m.findQueue(request.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	max := int(aws.Int64Value(request.MaxNumberOfMessages))
+	if max == 0 {
+		max = 1
+	}
+
+	response := &sqs.ReceiveMessageOutput{}
+	for _, message := range q.messages {
+		if len(response.Messages) >= max {
+			break
+		}
+		description := *message
+		response.Messages = append(response.Messages, &description)
+	}
+	return response, nil
+}
+
+func (m *MockSQS) DeleteMessage(request *sqs.DeleteMessageInput) (*sqs.DeleteMessageOutput, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	glog.Infof("DeleteMessage: %v", request)
+
+	q, err := m.findQueue(request.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []*sqs.Message
+	for _, message := range q.messages {
+		if aws.StringValue(message.ReceiptHandle) != aws.StringValue(request.ReceiptHandle) {
+			messages = append(messages, message)
+		}
+	}
+	q.messages = messages
+	return &sqs.DeleteMessageOutput{}, nil
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/kops"
+	"k8s.io/kops/cmd/kops/util"
+	api "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/audit"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+// auditedCommands are the commands that change a cluster, and so are recorded in its audit log.
+// The value is the flag that must be set for the command to make any changes, if there is one;
+// commands run with --dry-run are never recorded.
+// kops delete cluster is not recorded, as it removes the audit log along with the rest of the cluster.
+var auditedCommands = map[string]string{
+	"kops create":                         "",
+	"kops create cluster":                 "",
+	"kops create instancegroup":           "",
+	"kops create secret dockerconfig":     "",
+	"kops create secret encryptionconfig": "",
+	"kops create secret keypair ca":       "",
+	"kops create secret sshpublickey":     "",
+	"kops create secret weavepassword":    "",
+	"kops delete":                         "yes",
+	"kops delete instancegroup":           "yes",
+	"kops delete secret":                  "",
+	"kops edit cluster":                   "",
+	"kops edit instancegroup":             "",
+	"kops import cluster":                 "",
+	"kops replace":                        "",
+	"kops rollback cluster":               "yes",
+	"kops rolling-update cluster":         "yes",
+	"kops rotate ca":                      "yes",
+	"kops rotate keypair":                 "yes",
+	"kops set cluster":                    "",
+	"kops toolbox convert-imported":       "",
+	"kops toolbox migrate-state":          "",
+	"kops unlock cluster":                 "force",
+	"kops update cluster":                 "yes",
+	"kops upgrade cluster":                "yes",
+}
+
+// commandAudit is the audit record of the running command, written once the command finishes
+type commandAudit struct {
+	factory *util.Factory
+	record  *audit.Record
+	// clusters are the clusters the command changes, in order, and specHashes are their spec hashes before it ran
+	clusters   []string
+	specHashes map[string]string
+}
+
+var runningAudit *commandAudit
+
+// startAudit starts the audit record for the command, if it changes a cluster
+func startAudit(f *util.Factory, cmd *cobra.Command, args []string) {
+	command := cmd.CommandPath()
+	gate, found := auditedCommands[command]
+	if !found {
+		return
+	}
+	if gate != "" {
+		if enabled, err := cmd.Flags().GetBool(gate); err != nil || !enabled {
+			return
+		}
+	}
+	if dryRun := cmd.Flags().Lookup("dry-run"); dryRun != nil && dryRun.Value.String() == "true" {
+		return
+	}
+
+	flags := make(map[string]string)
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		flags[flag.Name] = flag.Value.String()
+	})
+
+	record, err := audit.NewRecord(command, args, flags, kops.Version)
+	if err != nil {
+		glog.Warningf("not recording command in audit log: %v", err)
+		return
+	}
+	runningAudit = &commandAudit{
+		factory:    f,
+		record:     record,
+		specHashes: make(map[string]string),
+	}
+
+	// The cluster name is normally found when the command runs, but we need it now for the spec hash
+	clusterName := rootCommand.clusterName
+	if clusterName == "" && len(args) == 1 && strings.HasSuffix(command, " cluster") {
+		clusterName = args[0]
+	}
+	if clusterName != "" {
+		auditCluster(clusterName)
+	}
+}
+
+// auditCluster records that the running command changes the cluster, for commands that find the clusters they
+// change as they run (e.g. kops create -f).  It must be called before the cluster is changed.
+func auditCluster(clusterName string) {
+	a := runningAudit
+	if a == nil {
+		return
+	}
+	if _, found := a.specHashes[clusterName]; found {
+		return
+	}
+
+	specHash := ""
+	if configBase := a.configBase(clusterName); configBase != nil {
+		var err error
+		specHash, err = audit.SpecHash(configBase)
+		if err != nil {
+			glog.Warningf("error hashing cluster spec for audit log: %v", err)
+		}
+	}
+	a.clusters = append(a.clusters, clusterName)
+	a.specHashes[clusterName] = specHash
+}
+
+// configBase returns the ConfigBase of the cluster, which need not exist yet
+func (a *commandAudit) configBase(clusterName string) vfs.Path {
+	clientset, err := a.factory.Clientset()
+	if err != nil {
+		glog.V(2).Infof("not recording command in audit log: %v", err)
+		return nil
+	}
+	cluster := &api.Cluster{}
+	cluster.ObjectMeta.Name = clusterName
+	configBase, err := clientset.ConfigBaseFor(cluster)
+	if err != nil {
+		glog.V(2).Infof("not recording command in audit log: %v", err)
+		return nil
+	}
+	return configBase
+}
+
+// finishAudit writes the audit record of the command to each cluster it changed.
+// Errors are logged rather than returned, so that they do not hide the outcome of the command.
+func finishAudit(commandErr error) {
+	a := runningAudit
+	if a == nil {
+		return
+	}
+	runningAudit = nil
+
+	// The cluster may only have been found when the command ran, e.g. from the kubectl context
+	clusters := a.clusters
+	if rootCommand.clusterName != "" {
+		if _, found := a.specHashes[rootCommand.clusterName]; !found {
+			clusters = append(clusters, rootCommand.clusterName)
+		}
+	}
+	if len(clusters) == 0 {
+		glog.V(2).Infof("not recording %s in audit log: cluster name not known", a.record.Command)
+		return
+	}
+
+	a.record.Finish(commandErr)
+	for _, clusterName := range clusters {
+		a.write(clusterName)
+	}
+}
+
+// write writes the audit record to the cluster
+func (a *commandAudit) write(clusterName string) {
+	configBase := a.configBase(clusterName)
+	if configBase == nil {
+		return
+	}
+
+	record := *a.record
+	record.SpecHashBefore = a.specHashes[clusterName]
+
+	var err error
+	record.SpecHashAfter, err = audit.SpecHash(configBase)
+	if err != nil {
+		glog.Warningf("error hashing cluster spec for audit log: %v", err)
+	} else if record.SpecHashAfter == "" {
+		// There is no cluster to record the command against, e.g. because the command failed to create it
+		glog.V(2).Infof("not recording %s in audit log: cluster %q not found", record.Command, clusterName)
+		return
+	}
+
+	cluster := &api.Cluster{}
+	cluster.ObjectMeta.Name = clusterName
+	if clientset, err := a.factory.Clientset(); err == nil {
+		if c, err := clientset.GetCluster(clusterName); err == nil && c != nil {
+			cluster = c
+		}
+	}
+
+	if err := audit.Write(cluster, configBase, &record); err != nil {
+		glog.Warningf("error writing audit record: %v", err)
+	}
+}
@@ -160,6 +160,7 @@ func RunCreate(f *util.Factory, out io.Writer, c *CreateOptions) error {
 				if err != nil {
 					return fmt.Errorf("error populating configuration: %v", err)
 				}
+				auditCluster(v.ObjectMeta.Name)
 				_, err = clientset.CreateCluster(v)
 				if err != nil {
 					if apierrors.IsAlreadyExists(err) {
@@ -184,6 +185,7 @@ func RunCreate(f *util.Factory, out io.Writer, c *CreateOptions) error {
 					return fmt.Errorf("cluster %q not found", clusterName)
 				}
 
+				auditCluster(clusterName)
 				_, err = clientset.InstanceGroupsFor(cluster).Create(v)
 				if err != nil {
 					if apierrors.IsAlreadyExists(err) {
@@ -213,6 +215,7 @@ func RunCreate(f *util.Factory, out io.Writer, c *CreateOptions) error {
 					return err
 				}
 
+				auditCluster(clusterName)
 				sshKeyArr := []byte(v.Spec.PublicKey)
 				err = sshCredentialStore.AddSSHPublicKey("admin", sshKeyArr)
 				if err != nil {
@@ -153,6 +153,7 @@ func RunDelete(factory *util.Factory, out io.Writer, d *DeleteOptions) error {
 					continue
 				}
 
+				auditCluster(options.ClusterName)
 				err := RunDeleteInstanceGroup(factory, out, options)
 				if err != nil {
 					exitWithError(err)
@@ -170,6 +171,7 @@ func RunDelete(factory *util.Factory, out io.Writer, d *DeleteOptions) error {
 					SecretID:    fingerprint,
 				}
 
+				auditCluster(options.ClusterName)
 				err = RunDeleteSecret(factory, out, options)
 				if err != nil {
 					exitWithError(err)
@@ -0,0 +1,220 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kops/cmd/kops/util"
+	api "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/apis/kops/registry"
+	"k8s.io/kops/pkg/client/simple"
+	"k8s.io/kops/pkg/diff"
+	"k8s.io/kops/pkg/statehistory"
+	"k8s.io/kubernetes/pkg/kubectl/cmd/templates"
+	"k8s.io/kubernetes/pkg/kubectl/util/i18n"
+)
+
+var (
+	diffClusterLong = templates.LongDesc(i18n.T(`
+	Show the changes to a cluster and its instance groups since a revision in the state store.
+
+	Revisions are listed by "kops get cluster --history".`))
+
+	diffClusterExample = templates.Examples(i18n.T(`
+	# Show what has changed since revision 3
+	kops diff cluster --name k8s-cluster.example.com --revision 3
+	`))
+
+	diffClusterShort = i18n.T(`Compare a cluster with an earlier revision.`)
+)
+
+type DiffClusterOptions struct {
+	ClusterName string
+	Revision    int
+}
+
+func NewCmdDiffCluster(f *util.Factory, out io.Writer) *cobra.Command {
+	options := &DiffClusterOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "cluster",
+		Short:   diffClusterShort,
+		Long:    diffClusterLong,
+		Example: diffClusterExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := rootCommand.ProcessArgs(args)
+			if err != nil {
+				exitWithError(err)
+			}
+
+			options.ClusterName = rootCommand.ClusterName()
+
+			err = RunDiffCluster(f, os.Stdout, options)
+			if err != nil {
+				exitWithError(err)
+			}
+		},
+	}
+
+	cmd.Flags().IntVar(&options.Revision, "revision", options.Revision, "Revision to compare the cluster with")
+
+	return cmd
+}
+
+func RunDiffCluster(f *util.Factory, out io.Writer, options *DiffClusterOptions) error {
+	if options.ClusterName == "" {
+		return fmt.Errorf("ClusterName is required")
+	}
+
+	clientset, err := f.Clientset()
+	if err != nil {
+		return err
+	}
+
+	cluster, err := GetCluster(f, options.ClusterName)
+	if err != nil {
+		return err
+	}
+
+	revisions, err := loadClusterHistory(clientset, cluster)
+	if err != nil {
+		return err
+	}
+
+	state, err := clusterStateAt(revisions, options.Revision)
+	if err != nil {
+		return err
+	}
+
+	current, err := readCurrentClusterState(clientset, cluster)
+	if err != nil {
+		return err
+	}
+
+	s := formatClusterStateDiff(state, current)
+	if s == "" {
+		fmt.Fprintf(out, "No changes since revision %d\n", options.Revision)
+		return nil
+	}
+	_, err = fmt.Fprint(out, s)
+	return err
+}
+
+// loadClusterHistory returns the revisions of the cluster and its instance groups
+func loadClusterHistory(clientset simple.Clientset, cluster *api.Cluster) ([]*statehistory.Revision, error) {
+	configBase, err := clientset.ConfigBaseFor(cluster)
+	if err != nil {
+		return nil, fmt.Errorf("error building ConfigBase for cluster: %v", err)
+	}
+	return statehistory.NewStore(cluster, configBase).List()
+}
+
+// clusterStateAt returns the objects as of the revision, checking that the revision exists
+func clusterStateAt(revisions []*statehistory.Revision, revision int) (map[string]*statehistory.Revision, error) {
+	if len(revisions) == 0 {
+		return nil, fmt.Errorf("no history found for cluster; history is recorded from the first change made by this version of kops")
+	}
+	found := false
+	for _, r := range revisions {
+		if r.Revision == revision {
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("revision %d not found; list revisions with kops get cluster --history", revision)
+	}
+	return statehistory.StateAt(revisions, revision), nil
+}
+
+// readCurrentClusterState returns the cluster and instance groups as they are stored in the state store,
+// keyed in the same way as the history
+func readCurrentClusterState(clientset simple.Clientset, cluster *api.Cluster) (map[string]string, error) {
+	configBase, err := clientset.ConfigBaseFor(cluster)
+	if err != nil {
+		return nil, fmt.Errorf("error building ConfigBase for cluster: %v", err)
+	}
+
+	state := make(map[string]string)
+
+	p := configBase.Join(registry.PathCluster)
+	b, err := p.ReadFile()
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", p, err)
+	}
+	state["Cluster/"+cluster.ObjectMeta.Name] = string(b)
+
+	igs, err := configBase.Join("instancegroup").ReadDir()
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("error listing instance groups: %v", err)
+	}
+	for _, p := range igs {
+		b, err := p.ReadFile()
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %v", p, err)
+		}
+		state["InstanceGroup/"+p.Base()] = string(b)
+	}
+
+	return state, nil
+}
+
+// formatClusterStateDiff returns the diff from the objects at a revision to the current objects,
+// or "" if they are the same
+func formatClusterStateDiff(state map[string]*statehistory.Revision, current map[string]string) string {
+	keys := make(map[string]bool)
+	for k, r := range state {
+		if !r.Deleted {
+			keys[k] = true
+		}
+	}
+	for k := range current {
+		keys[k] = true
+	}
+
+	var sorted []string
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	// The Cluster sorts before the InstanceGroups
+	sort.Strings(sorted)
+
+	var s string
+	for _, k := range sorted {
+		old := ""
+		if r := state[k]; r != nil && !r.Deleted {
+			old = r.Data
+		}
+		if old == current[k] {
+			continue
+		}
+		switch {
+		case old == "":
+			s += fmt.Sprintf("%s (created since revision):\n", k)
+		case current[k] == "":
+			s += fmt.Sprintf("%s (deleted since revision):\n", k)
+		default:
+			s += fmt.Sprintf("%s:\n", k)
+		}
+		s += diff.FormatDiff(old, current[k]) + "\n"
+	}
+	return s
+}
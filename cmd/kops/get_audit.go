@@ -0,0 +1,190 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/pkg/audit"
+	"k8s.io/kops/upup/pkg/fi/utils"
+	"k8s.io/kops/util/pkg/tables"
+	"k8s.io/kubernetes/pkg/kubectl/cmd/templates"
+	"k8s.io/kubernetes/pkg/kubectl/util/i18n"
+)
+
+var (
+	getAuditLong = templates.LongDesc(i18n.T(`
+	Display the audit log of a cluster.
+
+	Each kops command that changes a cluster records who ran it, its arguments
+	(with secret values redacted), the kops version, the hash of the cluster
+	and instance group specs before and after, and whether it succeeded.
+	The records are kept in the state store, under audit/ in the cluster's
+	directory, and are never changed once written.`))
+
+	getAuditExample = templates.Examples(i18n.T(`
+	# Get the audit log of a cluster
+	kops get audit --name k8s-cluster.example.com
+
+	# Get the updates made to a cluster in the last day
+	kops get audit --name k8s-cluster.example.com --since 24h --command "kops update cluster"
+
+	# Get the full audit records as YAML
+	kops get audit --name k8s-cluster.example.com -o yaml`))
+
+	getAuditShort = i18n.T(`Get the audit log of a cluster.`)
+)
+
+type GetAuditOptions struct {
+	*GetOptions
+
+	// Since only shows records newer than this
+	Since time.Duration
+	// User only shows records of commands run by this user
+	User string
+	// Command only shows records of commands that start with this, e.g. "kops update"
+	Command string
+}
+
+func NewCmdGetAudit(f *util.Factory, out io.Writer, getOptions *GetOptions) *cobra.Command {
+	options := GetAuditOptions{
+		GetOptions: getOptions,
+	}
+	cmd := &cobra.Command{
+		Use:     "audit",
+		Aliases: []string{"audits"},
+		Short:   getAuditShort,
+		Long:    getAuditLong,
+		Example: getAuditExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := RunGetAudit(f, os.Stdout, &options)
+			if err != nil {
+				exitWithError(err)
+			}
+		},
+	}
+
+	cmd.Flags().DurationVar(&options.Since, "since", options.Since, "Only show records newer than this, e.g. 24h")
+	cmd.Flags().StringVar(&options.User, "user", options.User, "Only show commands run by this user, as user or user@host")
+	cmd.Flags().StringVar(&options.Command, "command", options.Command, "Only show commands starting with this, e.g. \"kops update\"")
+
+	return cmd
+}
+
+func RunGetAudit(f *util.Factory, out io.Writer, options *GetAuditOptions) error {
+	cluster, err := rootCommand.Cluster()
+	if err != nil {
+		return err
+	}
+
+	clientset, err := f.Clientset()
+	if err != nil {
+		return err
+	}
+
+	configBase, err := clientset.ConfigBaseFor(cluster)
+	if err != nil {
+		return err
+	}
+
+	all, err := audit.List(configBase)
+	if err != nil {
+		return err
+	}
+
+	var records []*audit.Record
+	for _, r := range all {
+		if options.Since != 0 && time.Since(r.Timestamp) > options.Since {
+			continue
+		}
+		if options.User != "" && r.User != options.User && !strings.HasPrefix(r.User, options.User+"@") {
+			continue
+		}
+		if options.Command != "" && !strings.HasPrefix(r.Command, options.Command) {
+			continue
+		}
+		records = append(records, r)
+	}
+
+	switch options.output {
+	case OutputTable:
+		if len(records) == 0 {
+			return fmt.Errorf("no audit records found for cluster %q", cluster.ObjectMeta.Name)
+		}
+		t := &tables.Table{}
+		t.AddColumn("TIMESTAMP", func(r *audit.Record) string {
+			return r.Timestamp.Format(time.RFC3339)
+		})
+		t.AddColumn("USER", func(r *audit.Record) string {
+			return r.User
+		})
+		t.AddColumn("COMMAND", func(r *audit.Record) string {
+			return strings.Join(append([]string{r.Command}, r.Args...), " ")
+		})
+		t.AddColumn("VERSION", func(r *audit.Record) string {
+			return r.KopsVersion
+		})
+		t.AddColumn("SPEC", func(r *audit.Record) string {
+			before, after := shortHash(r.SpecHashBefore), shortHash(r.SpecHashAfter)
+			if before == after {
+				return after
+			}
+			return before + " -> " + after
+		})
+		t.AddColumn("OUTCOME", func(r *audit.Record) string {
+			return string(r.Outcome)
+		})
+		return t.Render(records, out, "TIMESTAMP", "USER", "COMMAND", "VERSION", "SPEC", "OUTCOME")
+
+	case OutputYaml:
+		b, err := utils.YamlMarshal(records)
+		if err != nil {
+			return fmt.Errorf("error marshaling yaml: %v", err)
+		}
+		_, err = out.Write(b)
+		return err
+
+	case OutputJSON:
+		b, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error marshaling json: %v", err)
+		}
+		_, err = fmt.Fprintf(out, "%s\n", b)
+		return err
+
+	default:
+		return fmt.Errorf("Unknown output format: %q", options.output)
+	}
+}
+
+// shortHash abbreviates a spec hash for display, the way git abbreviates commit hashes
+func shortHash(hash string) string {
+	if hash == "" {
+		return "-"
+	}
+	if len(hash) > 8 {
+		return hash[:8]
+	}
+	return hash
+}
@@ -0,0 +1,273 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/kubeconfig"
+	"k8s.io/kops/pkg/pki"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/util/pkg/tables"
+	"k8s.io/kubernetes/pkg/kubectl/cmd/templates"
+	"k8s.io/kubernetes/pkg/kubectl/util/i18n"
+)
+
+var (
+	getCertificatesLong = templates.LongDesc(i18n.T(`
+	Display the certificates of a cluster, and when they expire.
+
+	Every certificate in the keystore is listed, along with the certificates in the kubeconfig
+	exported by kops export kubecfg, if the cluster is in the kubeconfig.
+
+	With --expiring-within, only the certificates that expire within that time, or have already expired,
+	are listed, and the command fails if there are any, so it can be run from cron.`))
+
+	getCertificatesExample = templates.Examples(i18n.T(`
+	# Get the certificates of a cluster
+	kops get certificates --name k8s-cluster.example.com
+
+	# Fail if any certificate expires within 30 days
+	kops get certificates --name k8s-cluster.example.com --expiring-within 30d
+
+	# Get the certificates as JSON
+	kops get certificates --name k8s-cluster.example.com -o json`))
+
+	getCertificatesShort = i18n.T(`Get the certificates of a cluster, and when they expire.`)
+)
+
+type GetCertificatesOptions struct {
+	*GetOptions
+
+	// ExpiringWithin, if set, lists only the certificates that expire within this time, e.g. 30d or 72h
+	ExpiringWithin string
+}
+
+func NewCmdGetCertificates(f *util.Factory, out io.Writer, getOptions *GetOptions) *cobra.Command {
+	options := GetCertificatesOptions{
+		GetOptions: getOptions,
+	}
+	cmd := &cobra.Command{
+		Use:     "certificates",
+		Aliases: []string{"certificate", "certs", "cert"},
+		Short:   getCertificatesShort,
+		Long:    getCertificatesLong,
+		Example: getCertificatesExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := RunGetCertificates(f, os.Stdout, &options)
+			if err != nil {
+				exitWithError(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&options.ExpiringWithin, "expiring-within", options.ExpiringWithin, "List only the certificates that expire within this time, e.g. 30d or 72h, and fail if there are any")
+
+	return cmd
+}
+
+// certificateItem is a certificate listed by kops get certificates
+type certificateItem struct {
+	// Name is the name of the keyset, or where the certificate was found in the kubeconfig
+	Name      string    `json:"name"`
+	Subject   string    `json:"subject"`
+	Issuer    string    `json:"issuer"`
+	Serial    string    `json:"serial"`
+	NotBefore time.Time `json:"notBefore"`
+	NotAfter  time.Time `json:"notAfter"`
+	Usage     []string  `json:"usage,omitempty"`
+}
+
+func newCertificateItem(name string, cert *pki.Certificate) *certificateItem {
+	return &certificateItem{
+		Name:      name,
+		Subject:   cert.Certificate.Subject.String(),
+		Issuer:    cert.Certificate.Issuer.String(),
+		Serial:    cert.Certificate.SerialNumber.String(),
+		NotBefore: cert.Certificate.NotBefore,
+		NotAfter:  cert.Certificate.NotAfter,
+		Usage:     cert.Usages(),
+	}
+}
+
+// parseExpiryWindow parses a duration, which as well as the units of time.ParseDuration may be a number of days, e.g. 30d
+func parseExpiryWindow(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil || days < 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// listKeystoreCertificates returns every certificate in every keyset in the keystore
+func listKeystoreCertificates(keyStore fi.CAStore) ([]*certificateItem, error) {
+	keysets, err := keyStore.ListKeysets()
+	if err != nil {
+		return nil, fmt.Errorf("error listing Keysets: %v", err)
+	}
+
+	var items []*certificateItem
+	for _, k := range keysets {
+		if k.Spec.Type != kops.SecretTypeKeypair {
+			continue
+		}
+		keyset, err := keyStore.FindCertificateKeyset(k.Name)
+		if err != nil {
+			return nil, err
+		}
+		if keyset == nil {
+			continue
+		}
+		for _, key := range keyset.Spec.Keys {
+			if len(key.PublicMaterial) == 0 {
+				continue
+			}
+			cert, err := pki.ParsePEMCertificate(key.PublicMaterial)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing certificate %s/%s: %v", keyset.Name, key.Id, err)
+			}
+			items = append(items, newCertificateItem(keyset.Name, cert))
+		}
+	}
+	return items, nil
+}
+
+func RunGetCertificates(f *util.Factory, out io.Writer, options *GetCertificatesOptions) error {
+	var window time.Duration
+	if options.ExpiringWithin != "" {
+		d, err := parseExpiryWindow(options.ExpiringWithin)
+		if err != nil {
+			return fmt.Errorf("invalid --expiring-within: %v", err)
+		}
+		window = d
+	}
+
+	cluster, err := rootCommand.Cluster()
+	if err != nil {
+		return err
+	}
+
+	clientset, err := f.Clientset()
+	if err != nil {
+		return err
+	}
+
+	keyStore, err := clientset.KeyStore(cluster)
+	if err != nil {
+		return err
+	}
+
+	items, err := listKeystoreCertificates(keyStore)
+	if err != nil {
+		return err
+	}
+
+	kubeconfigCerts, err := kubeconfig.FindKubeconfigCertificates(cluster.ObjectMeta.Name)
+	if err != nil {
+		return err
+	}
+	for _, c := range kubeconfigCerts {
+		items = append(items, newCertificateItem("kubeconfig/"+c.Name, c.Certificate))
+	}
+
+	if options.ExpiringWithin != "" {
+		deadline := time.Now().Add(window)
+		var expiring []*certificateItem
+		for _, item := range items {
+			if item.NotAfter.Before(deadline) {
+				expiring = append(expiring, item)
+			}
+		}
+		items = expiring
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if !items[i].NotAfter.Equal(items[j].NotAfter) {
+			return items[i].NotAfter.Before(items[j].NotAfter)
+		}
+		return items[i].Name < items[j].Name
+	})
+
+	if err := printCertificates(out, options.output, items); err != nil {
+		return err
+	}
+
+	if options.ExpiringWithin != "" && len(items) != 0 {
+		return fmt.Errorf("%d certificate(s) expire within %s", len(items), options.ExpiringWithin)
+	}
+	return nil
+}
+
+func printCertificates(out io.Writer, output string, items []*certificateItem) error {
+	switch output {
+	case OutputTable:
+		t := &tables.Table{}
+		t.AddColumn("NAME", func(i *certificateItem) string {
+			return i.Name
+		})
+		t.AddColumn("SUBJECT", func(i *certificateItem) string {
+			return i.Subject
+		})
+		t.AddColumn("ISSUER", func(i *certificateItem) string {
+			return i.Issuer
+		})
+		t.AddColumn("SERIAL", func(i *certificateItem) string {
+			return i.Serial
+		})
+		t.AddColumn("NOT AFTER", func(i *certificateItem) string {
+			return i.NotAfter.Format(time.RFC3339)
+		})
+		t.AddColumn("USAGE", func(i *certificateItem) string {
+			return strings.Join(i.Usage, ",")
+		})
+		return t.Render(items, out, "NAME", "SUBJECT", "ISSUER", "SERIAL", "NOT AFTER", "USAGE")
+
+	case OutputYaml:
+		return fmt.Errorf("yaml output format is not (currently) supported for certificates")
+
+	case OutputJSON:
+		if items == nil {
+			items = []*certificateItem{}
+		}
+		b, err := json.MarshalIndent(items, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error marshaling json: %v", err)
+		}
+		_, err = fmt.Fprintf(out, "%s\n", b)
+		return err
+
+	default:
+		return fmt.Errorf("Unknown output format: %q", output)
+	}
+}
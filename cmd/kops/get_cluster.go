@@ -17,10 +17,13 @@ limitations under the License.
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -30,6 +33,9 @@ import (
 	"k8s.io/kops/cmd/kops/util"
 	api "k8s.io/kops/pkg/apis/kops"
 	"k8s.io/kops/pkg/apis/kops/registry"
+	"k8s.io/kops/pkg/client/simple"
+	"k8s.io/kops/pkg/statehistory"
+	"k8s.io/kops/upup/pkg/fi/utils"
 	"k8s.io/kops/util/pkg/tables"
 	"k8s.io/kubernetes/pkg/kubectl/cmd/templates"
 	"k8s.io/kubernetes/pkg/kubectl/util/i18n"
@@ -51,6 +57,9 @@ var (
 
 	# Save a cluster desired configuration to YAML file
 	kops get cluster k8s-cluster.example.com -o yaml > cluster-desired-config.yaml
+
+	# List the revisions of a cluster and its instance groups in the state store
+	kops get cluster k8s-cluster.example.com --history
 	`))
 
 	getClusterShort = i18n.T(`Get one or many clusters.`)
@@ -79,6 +88,9 @@ type GetClusterOptions struct {
 
 	// ClusterNames is a list of cluster names to show; if not specified all clusters will be shown
 	ClusterNames []string
+
+	// History lists the revisions of the cluster in the state store, instead of the cluster
+	History bool
 }
 
 func NewCmdGetCluster(f *util.Factory, out io.Writer, getOptions *GetOptions) *cobra.Command {
@@ -113,6 +125,7 @@ func NewCmdGetCluster(f *util.Factory, out io.Writer, getOptions *GetOptions) *c
 	}
 
 	cmd.Flags().BoolVar(&options.FullSpec, "full", options.FullSpec, "Show fully populated configuration")
+	cmd.Flags().BoolVar(&options.History, "history", options.History, "Show the revisions of the cluster and its instance groups")
 
 	return cmd
 }
@@ -153,6 +166,13 @@ func RunGetClusters(context Factory, out io.Writer, options *GetClusterOptions)
 		return fmt.Errorf("no clusters found")
 	}
 
+	if options.History {
+		if len(clusters) != 1 {
+			return fmt.Errorf("specify a single cluster to show its history")
+		}
+		return clusterHistoryOutput(client, clusters[0], options.output, out)
+	}
+
 	if options.FullSpec {
 		var err error
 		clusters, err = fullClusterSpecs(clusters)
@@ -227,6 +247,70 @@ func clusterOutputTable(clusters []*api.Cluster, out io.Writer) error {
 	return t.Render(clusters, out, "NAME", "CLOUD", "ZONES")
 }
 
+// clusterHistoryOutput outputs the revisions of the cluster and its instance groups, without their contents
+func clusterHistoryOutput(clientset simple.Clientset, cluster *api.Cluster, output string, out io.Writer) error {
+	revisions, err := loadClusterHistory(clientset, cluster)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range revisions {
+		r.Data = ""
+	}
+
+	switch output {
+	case OutputTable:
+		t := &tables.Table{}
+		t.AddColumn("REVISION", func(r *statehistory.Revision) string {
+			return strconv.Itoa(r.Revision)
+		})
+		t.AddColumn("TIMESTAMP", func(r *statehistory.Revision) string {
+			return r.Timestamp.Format(time.RFC3339)
+		})
+		t.AddColumn("USER", func(r *statehistory.Revision) string {
+			return r.User
+		})
+		t.AddColumn("KIND", func(r *statehistory.Revision) string {
+			return r.Kind
+		})
+		t.AddColumn("NAME", func(r *statehistory.Revision) string {
+			return r.Name
+		})
+		t.AddColumn("HASH", func(r *statehistory.Revision) string {
+			if r.Deleted {
+				return "(deleted)"
+			}
+			if len(r.Hash) > 12 {
+				return r.Hash[:12]
+			}
+			return r.Hash
+		})
+		return t.Render(revisions, out, "REVISION", "TIMESTAMP", "USER", "KIND", "NAME", "HASH")
+
+	case OutputYaml:
+		b, err := utils.YamlMarshal(revisions)
+		if err != nil {
+			return fmt.Errorf("error marshaling yaml: %v", err)
+		}
+		_, err = out.Write(b)
+		return err
+
+	case OutputJSON:
+		if revisions == nil {
+			revisions = []*statehistory.Revision{}
+		}
+		b, err := json.MarshalIndent(revisions, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error marshaling json: %v", err)
+		}
+		_, err = fmt.Fprintf(out, "%s\n", b)
+		return err
+
+	default:
+		return fmt.Errorf("Unknown output format: %q", output)
+	}
+}
+
 // fullOutputJson outputs the marshalled JSON of a list of clusters and instance groups.  It will handle
 // nils for clusters and instanceGroups slices.
 func fullOutputJSON(out io.Writer, args ...runtime.Object) error {
@@ -0,0 +1,206 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/util/pkg/tables"
+	"k8s.io/kubernetes/pkg/kubectl/cmd/templates"
+	"k8s.io/kubernetes/pkg/kubectl/util/i18n"
+)
+
+var (
+	getKeypairsLong = templates.LongDesc(i18n.T(`
+	Display the keypairs in the keystore, with the certificate of each that is primary.
+
+	While the CA is being rotated, the ROTATION column shows the step that the rotation has reached,
+	and which of the CAs is new and which old.`))
+
+	getKeypairsExample = templates.Examples(i18n.T(`
+	# Get all keypairs
+	kops get keypairs --name k8s-cluster.example.com
+
+	# Get the CA keypairs
+	kops get keypairs ca --name k8s-cluster.example.com`))
+
+	getKeypairsShort = i18n.T(`Get one or many keypairs.`)
+)
+
+type GetKeypairsOptions struct {
+	*GetOptions
+}
+
+func NewCmdGetKeypairs(f *util.Factory, out io.Writer, getOptions *GetOptions) *cobra.Command {
+	options := GetKeypairsOptions{
+		GetOptions: getOptions,
+	}
+	cmd := &cobra.Command{
+		Use:     "keypairs",
+		Aliases: []string{"keypair"},
+		Short:   getKeypairsShort,
+		Long:    getKeypairsLong,
+		Example: getKeypairsExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := RunGetKeypairs(&options, args)
+			if err != nil {
+				exitWithError(err)
+			}
+		},
+	}
+
+	return cmd
+}
+
+// keypairItem is a row of kops get keypairs
+type keypairItem struct {
+	Name     string
+	Id       string
+	Primary  bool
+	Rotation string
+}
+
+func listKeypairs(keyStore fi.CAStore, names []string) ([]*keypairItem, error) {
+	keysets, err := keyStore.ListKeysets()
+	if err != nil {
+		return nil, fmt.Errorf("error listing Keysets: %v", err)
+	}
+
+	var items []*keypairItem
+	found := make(map[string]bool)
+	for _, k := range keysets {
+		if k.Spec.Type != kops.SecretTypeKeypair {
+			continue
+		}
+		if len(names) != 0 && !stringInSlice(k.Name, names) {
+			continue
+		}
+		found[k.Name] = true
+
+		keyset, err := keyStore.FindCertificateKeyset(k.Name)
+		if err != nil {
+			return nil, err
+		}
+		if keyset == nil {
+			continue
+		}
+
+		primary := fi.FindPrimary(keyset)
+		for _, key := range keyset.Spec.Keys {
+			item := &keypairItem{
+				Name:    keyset.Name,
+				Id:      key.Id,
+				Primary: primary != nil && primary.Id == key.Id,
+			}
+			if rotation := keyset.Spec.Rotation; rotation != nil {
+				item.Rotation = string(rotation.Phase)
+				if key.Id == rotation.NewId {
+					item.Rotation += " (new)"
+				} else if stringInSlice(key.Id, rotation.OldIds) {
+					item.Rotation += " (old)"
+				}
+			}
+			items = append(items, item)
+		}
+	}
+
+	for _, name := range names {
+		if !found[name] {
+			return nil, fmt.Errorf("Keypair not found: %q", name)
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Name != items[j].Name {
+			return items[i].Name < items[j].Name
+		}
+		return items[i].Id < items[j].Id
+	})
+
+	return items, nil
+}
+
+func stringInSlice(s string, l []string) bool {
+	for _, v := range l {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func RunGetKeypairs(options *GetKeypairsOptions, args []string) error {
+	cluster, err := rootCommand.Cluster()
+	if err != nil {
+		return err
+	}
+
+	clientset, err := rootCommand.Clientset()
+	if err != nil {
+		return err
+	}
+
+	keyStore, err := clientset.KeyStore(cluster)
+	if err != nil {
+		return err
+	}
+
+	items, err := listKeypairs(keyStore, args)
+	if err != nil {
+		return err
+	}
+
+	if len(items) == 0 {
+		return fmt.Errorf("No keypairs found")
+	}
+	switch options.output {
+
+	case OutputTable:
+		t := &tables.Table{}
+		t.AddColumn("NAME", func(i *keypairItem) string {
+			return i.Name
+		})
+		t.AddColumn("ID", func(i *keypairItem) string {
+			return i.Id
+		})
+		t.AddColumn("PRIMARY", func(i *keypairItem) string {
+			if i.Primary {
+				return "*"
+			}
+			return ""
+		})
+		t.AddColumn("ROTATION", func(i *keypairItem) string {
+			return i.Rotation
+		})
+		return t.Render(items, os.Stdout, "NAME", "ID", "PRIMARY", "ROTATION")
+
+	case OutputYaml:
+		return fmt.Errorf("yaml output format is not (currently) supported for keypairs")
+	case OutputJSON:
+		return fmt.Errorf("json output format is not (currently) supported for keypairs")
+
+	default:
+		return fmt.Errorf("Unknown output format: %q", options.output)
+	}
+}
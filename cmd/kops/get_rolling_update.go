@@ -0,0 +1,156 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/pkg/instancegroups"
+	"k8s.io/kops/upup/pkg/fi/utils"
+	"k8s.io/kops/util/pkg/tables"
+	"k8s.io/kubernetes/pkg/kubectl/cmd/templates"
+	"k8s.io/kubernetes/pkg/kubectl/util/i18n"
+)
+
+var (
+	getRollingUpdateLong = templates.LongDesc(i18n.T(`
+	Display the progress of the most recent rolling-update of a cluster.
+
+	The progress is recorded in the state store while the rolling-update runs,
+	so an interrupted rolling-update can be continued with
+	kops rolling-update cluster --yes --resume.`))
+
+	getRollingUpdateExample = templates.Examples(i18n.T(`
+	# Get the progress of the rolling-update of a cluster
+	kops get rolling-update --name k8s-cluster.example.com
+
+	# Get the full progress record as YAML
+	kops get rolling-update --name k8s-cluster.example.com -o yaml`))
+
+	getRollingUpdateShort = i18n.T(`Get the progress of a rolling-update.`)
+)
+
+type GetRollingUpdateOptions struct {
+	*GetOptions
+}
+
+func NewCmdGetRollingUpdate(f *util.Factory, out io.Writer, getOptions *GetOptions) *cobra.Command {
+	options := GetRollingUpdateOptions{
+		GetOptions: getOptions,
+	}
+	cmd := &cobra.Command{
+		Use:     "rolling-update",
+		Aliases: []string{"rollingupdate"},
+		Short:   getRollingUpdateShort,
+		Long:    getRollingUpdateLong,
+		Example: getRollingUpdateExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := RunGetRollingUpdate(f, os.Stdout, &options)
+			if err != nil {
+				exitWithError(err)
+			}
+		},
+	}
+
+	return cmd
+}
+
+func RunGetRollingUpdate(f *util.Factory, out io.Writer, options *GetRollingUpdateOptions) error {
+	cluster, err := rootCommand.Cluster()
+	if err != nil {
+		return err
+	}
+
+	clientset, err := f.Clientset()
+	if err != nil {
+		return err
+	}
+
+	configBase, err := clientset.ConfigBaseFor(cluster)
+	if err != nil {
+		return err
+	}
+
+	progress, err := instancegroups.NewProgressStore(cluster, configBase).Load()
+	if err != nil {
+		return err
+	}
+	if progress == nil {
+		return fmt.Errorf("no rolling-update has been recorded for cluster %q", cluster.ObjectMeta.Name)
+	}
+
+	switch options.output {
+	case OutputTable:
+		t := &tables.Table{}
+		t.AddColumn("PHASE", func(p *instancegroups.RollingUpdateProgress) string {
+			return string(p.Phase)
+		})
+		t.AddColumn("ACTIVE GROUPS", func(p *instancegroups.RollingUpdateProgress) string {
+			return strings.Join(p.ActiveGroupNames(), ",")
+		})
+		t.AddColumn("COMPLETED GROUPS", func(p *instancegroups.RollingUpdateProgress) string {
+			return strings.Join(p.CompletedGroups, ",")
+		})
+		t.AddColumn("REPLACED INSTANCES", func(p *instancegroups.RollingUpdateProgress) string {
+			count := 0
+			for _, ids := range p.CompletedInstances {
+				count += len(ids)
+			}
+			return strconv.Itoa(count)
+		})
+		t.AddColumn("LAST VALIDATION", func(p *instancegroups.RollingUpdateProgress) string {
+			if p.LastValidation == nil {
+				return ""
+			}
+			if p.LastValidation.Passed {
+				return "Passed"
+			}
+			return "Failed: " + p.LastValidation.Message
+		})
+		t.AddColumn("UPDATED", func(p *instancegroups.RollingUpdateProgress) string {
+			return p.UpdatedAt.Format(time.RFC3339)
+		})
+		return t.Render([]*instancegroups.RollingUpdateProgress{progress}, out, "PHASE", "ACTIVE GROUPS", "COMPLETED GROUPS", "REPLACED INSTANCES", "LAST VALIDATION", "UPDATED")
+
+	case OutputYaml:
+		b, err := utils.YamlMarshal(progress)
+		if err != nil {
+			return fmt.Errorf("error marshaling yaml: %v", err)
+		}
+		_, err = out.Write(b)
+		return err
+
+	case OutputJSON:
+		b, err := json.MarshalIndent(progress, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error marshaling json: %v", err)
+		}
+		_, err = fmt.Fprintf(out, "%s\n", b)
+		return err
+
+	default:
+		return fmt.Errorf("Unknown output format: %q", options.output)
+	}
+}
@@ -28,6 +28,7 @@ func main() {
 // exitWithError will terminate execution with an error result
 // It prints the error to stderr and exits with a non-zero exit code
 func exitWithError(err error) {
+	finishAudit(err)
 	fmt.Fprintf(os.Stderr, "\n%v\n", err)
 	os.Exit(1)
 }
@@ -132,6 +132,7 @@ func RunReplace(f *util.Factory, cmd *cobra.Command, out io.Writer, c *replaceOp
 
 					// Check if the cluster exists already
 					clusterName := v.Name
+					auditCluster(clusterName)
 					cluster, err := clientset.GetCluster(clusterName)
 					if err != nil {
 						if errors.IsNotFound(err) {
@@ -168,6 +169,7 @@ func RunReplace(f *util.Factory, cmd *cobra.Command, out io.Writer, c *replaceOp
 					}
 					return fmt.Errorf("error fetching cluster %q: %v", clusterName, err)
 				}
+				auditCluster(clusterName)
 				// check if the instancegroup exists already
 				igName := v.ObjectMeta.Name
 				ig, err := clientset.InstanceGroupsFor(cluster).Get(igName, metav1.GetOptions{})
@@ -212,6 +214,7 @@ func RunReplace(f *util.Factory, cmd *cobra.Command, out io.Writer, c *replaceOp
 					return err
 				}
 
+				auditCluster(clusterName)
 				sshKeyArr := []byte(v.Spec.PublicKey)
 				err = sshCredentialStore.AddSSHPublicKey("admin", sshKeyArr)
 				if err != nil {
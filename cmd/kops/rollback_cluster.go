@@ -0,0 +1,218 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kops/cmd/kops/util"
+	api "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/commands"
+	"k8s.io/kops/pkg/diff"
+	"k8s.io/kops/pkg/kopscodecs"
+	"k8s.io/kubernetes/pkg/kubectl/cmd/templates"
+	"k8s.io/kubernetes/pkg/kubectl/util/i18n"
+)
+
+var (
+	rollbackClusterLong = templates.LongDesc(i18n.T(`
+	Restore a cluster and its instance groups to a revision in the state store.
+
+	Revisions are listed by "kops get cluster --history". Instance groups that were deleted since the
+	revision are created again; instance groups that were created since the revision are not deleted,
+	but are listed so they can be removed with "kops delete instancegroup".
+
+	The rollback is itself recorded as a new revision. Apply the changes with "kops update cluster".`))
+
+	rollbackClusterExample = templates.Examples(i18n.T(`
+	# Show what would be restored to revision 3
+	kops rollback cluster --name k8s-cluster.example.com --to-revision 3
+
+	# Restore the cluster and its instance groups to revision 3
+	kops rollback cluster --name k8s-cluster.example.com --to-revision 3 --yes
+	`))
+
+	rollbackClusterShort = i18n.T(`Restore a cluster to an earlier revision.`)
+)
+
+type RollbackClusterOptions struct {
+	ClusterName string
+	ToRevision  int
+	Yes         bool
+}
+
+func NewCmdRollbackCluster(f *util.Factory, out io.Writer) *cobra.Command {
+	options := &RollbackClusterOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "cluster",
+		Short:   rollbackClusterShort,
+		Long:    rollbackClusterLong,
+		Example: rollbackClusterExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := rootCommand.ProcessArgs(args)
+			if err != nil {
+				exitWithError(err)
+			}
+
+			options.ClusterName = rootCommand.ClusterName()
+
+			err = RunRollbackCluster(f, os.Stdout, options)
+			if err != nil {
+				exitWithError(err)
+			}
+		},
+	}
+
+	cmd.Flags().IntVar(&options.ToRevision, "to-revision", options.ToRevision, "Revision to restore the cluster to")
+	cmd.Flags().BoolVarP(&options.Yes, "yes", "y", options.Yes, "Restore the revision, without --yes the changes are only shown")
+
+	return cmd
+}
+
+func RunRollbackCluster(f *util.Factory, out io.Writer, options *RollbackClusterOptions) error {
+	if options.ClusterName == "" {
+		return fmt.Errorf("ClusterName is required")
+	}
+
+	clientset, err := f.Clientset()
+	if err != nil {
+		return err
+	}
+
+	cluster, err := GetCluster(f, options.ClusterName)
+	if err != nil {
+		return err
+	}
+
+	revisions, err := loadClusterHistory(clientset, cluster)
+	if err != nil {
+		return err
+	}
+
+	state, err := clusterStateAt(revisions, options.ToRevision)
+	if err != nil {
+		return err
+	}
+
+	current, err := readCurrentClusterState(clientset, cluster)
+	if err != nil {
+		return err
+	}
+
+	// Instance groups created since the revision are left alone
+	var created []string
+	for k := range current {
+		if r := state[k]; r == nil || r.Deleted {
+			created = append(created, k)
+		}
+	}
+	sort.Strings(created)
+
+	var keys []string
+	for k, r := range state {
+		if !r.Deleted && r.Data != current[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	if len(keys) == 0 {
+		fmt.Fprintf(out, "Cluster is unchanged since revision %d\n", options.ToRevision)
+	}
+
+	if !options.Yes {
+		for _, k := range keys {
+			fmt.Fprintf(out, "%s:\n%s\n", k, diff.FormatDiff(current[k], state[k].Data))
+		}
+		if len(keys) != 0 {
+			fmt.Fprintf(out, "\nMust specify --yes to restore revision %d\n", options.ToRevision)
+		}
+		printCreatedSinceRevision(out, created)
+		return nil
+	}
+
+	for _, k := range keys {
+		r := state[k]
+		o, _, err := kopscodecs.ParseVersionedYaml([]byte(r.Data))
+		if err != nil {
+			return fmt.Errorf("error parsing revision %d of %s: %v", r.Revision, k, err)
+		}
+
+		switch v := o.(type) {
+		case *api.Cluster:
+			// Retrieve the current status of the cluster.  This will eventually be part of the cluster object.
+			statusDiscovery := &commands.CloudDiscoveryStatusStore{}
+			status, err := statusDiscovery.FindClusterStatus(cluster)
+			if err != nil {
+				return err
+			}
+			// The revision replaces the current cluster, whatever its resourceVersion
+			v.ObjectMeta.ResourceVersion = cluster.ObjectMeta.ResourceVersion
+			if _, err := clientset.UpdateCluster(v, status); err != nil {
+				return fmt.Errorf("error restoring cluster: %v", err)
+			}
+
+		case *api.InstanceGroup:
+			igs := clientset.InstanceGroupsFor(cluster)
+			existing, err := igs.Get(v.ObjectMeta.Name, metav1.GetOptions{})
+			if err != nil {
+				if !errors.IsNotFound(err) {
+					return fmt.Errorf("error reading InstanceGroup %q: %v", v.ObjectMeta.Name, err)
+				}
+				if _, err := igs.Create(v); err != nil {
+					return fmt.Errorf("error restoring InstanceGroup %q: %v", v.ObjectMeta.Name, err)
+				}
+			} else {
+				v.ObjectMeta.ResourceVersion = existing.ObjectMeta.ResourceVersion
+				if _, err := igs.Update(v); err != nil {
+					return fmt.Errorf("error restoring InstanceGroup %q: %v", v.ObjectMeta.Name, err)
+				}
+			}
+
+		default:
+			return fmt.Errorf("unexpected object %T in revision %d", o, r.Revision)
+		}
+
+		fmt.Fprintf(out, "Restored %s to revision %d\n", k, r.Revision)
+	}
+
+	printCreatedSinceRevision(out, created)
+
+	if len(keys) != 0 {
+		fmt.Fprintf(out, "\nTo apply the changes, run:\n")
+		fmt.Fprintf(out, " * kops update cluster --name %s --yes\n", options.ClusterName)
+	}
+
+	return nil
+}
+
+func printCreatedSinceRevision(out io.Writer, created []string) {
+	if len(created) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "\nThese objects were created since the revision, and will not be deleted:\n")
+	for _, k := range created {
+		fmt.Fprintf(out, " * %s\n", k)
+	}
+}
@@ -94,6 +94,17 @@ var (
 		  --fail-on-validate-error="false" \
 		  --node-interval 8m \
 		  --instance-group nodes
+
+		# Resume a rolling-update of the k8s-cluster.example.com kops cluster
+		# that was interrupted, skipping the instance groups and instances
+		# that were already replaced.
+		kops rolling-update cluster k8s-cluster.example.com --yes \
+		  --resume
+
+		# Roll up to 5 node instance groups of the k8s-cluster.example.com
+		# kops cluster at the same time.
+		kops rolling-update cluster k8s-cluster.example.com --yes \
+		  --node-group-concurrency 5
 		`))
 
 	rollingupdateShort = i18n.T(`Rolling update a cluster.`)
@@ -133,6 +144,12 @@ type RollingUpdateOptions struct {
 	// Interactive rolling-update prompts user to continue after each instances is updated.
 	Interactive bool
 
+	// Resume continues an interrupted rolling-update, using the progress recorded in the state store
+	Resume bool
+
+	// NodeGroupConcurrency is the maximum number of node instance groups to roll at the same time
+	NodeGroupConcurrency int
+
 	ClusterName string
 
 	// InstanceGroups is the list of instance groups to rolling-update;
@@ -155,6 +172,8 @@ func (o *RollingUpdateOptions) InitDefaults() {
 	o.NodeInterval = 4 * time.Minute
 	o.BastionInterval = 5 * time.Minute
 	o.Interactive = false
+	o.Resume = false
+	o.NodeGroupConcurrency = 1
 
 	o.PostDrainDelay = 90 * time.Second
 	o.ValidationTimeout = 5 * time.Minute
@@ -181,6 +200,8 @@ func NewCmdRollingUpdateCluster(f *util.Factory, out io.Writer) *cobra.Command {
 	cmd.Flags().DurationVar(&options.NodeInterval, "node-interval", options.NodeInterval, "Time to wait between restarting nodes")
 	cmd.Flags().DurationVar(&options.BastionInterval, "bastion-interval", options.BastionInterval, "Time to wait between restarting bastions")
 	cmd.Flags().BoolVarP(&options.Interactive, "interactive", "i", options.Interactive, "Prompt to continue after each instance is updated")
+	cmd.Flags().IntVar(&options.NodeGroupConcurrency, "node-group-concurrency", options.NodeGroupConcurrency, "Maximum number of node instance groups to roll at the same time; PodDisruptionBudgets are checked across all of them before each drain")
+	cmd.Flags().BoolVar(&options.Resume, "resume", options.Resume, "Resume an interrupted rolling update, skipping instance groups and instances that were already updated")
 	cmd.Flags().StringSliceVar(&options.InstanceGroups, "instance-group", options.InstanceGroups, "List of instance groups to update (defaults to all if not specified)")
 	cmd.Flags().StringSliceVar(&options.InstanceGroupRoles, "instance-group-roles", options.InstanceGroupRoles, "If specified, only instance groups of the specified role will be updated (e.g. Master,Node,Bastion)")
 
@@ -217,6 +238,13 @@ func NewCmdRollingUpdateCluster(f *util.Factory, out io.Writer) *cobra.Command {
 
 func RunRollingUpdateCluster(f *util.Factory, out io.Writer, options *RollingUpdateOptions) error {
 
+	if options.NodeGroupConcurrency < 1 {
+		return fmt.Errorf("--node-group-concurrency must be at least 1")
+	}
+	if options.NodeGroupConcurrency > 1 && options.Interactive {
+		return fmt.Errorf("--interactive cannot be used when rolling more than one node instance group at a time")
+	}
+
 	clientset, err := f.Clientset()
 	if err != nil {
 		return err
@@ -386,6 +414,32 @@ func RunRollingUpdateCluster(f *util.Factory, out io.Writer, options *RollingUpd
 		return nil
 	}
 
+	lock, err := acquireClusterLock(clientset, cluster, "rolling-update cluster")
+	if err != nil {
+		return err
+	}
+	defer releaseClusterLock(lock)
+
+	configBase, err := clientset.ConfigBaseFor(cluster)
+	if err != nil {
+		return err
+	}
+
+	progress := instancegroups.NewProgressStore(cluster, configBase)
+	if options.Resume {
+		if err := progress.Resume(); err != nil {
+			return err
+		}
+	} else {
+		previous, err := progress.Load()
+		if err != nil {
+			return err
+		}
+		if previous != nil && previous.Phase != instancegroups.RollingUpdatePhaseComplete {
+			glog.Warningf("A previous rolling-update of cluster %q did not complete; starting a new rolling-update (use --resume to continue the previous one)", cluster.ObjectMeta.Name)
+		}
+	}
+
 	if featureflag.DrainAndValidateRollingUpdate.Enabled() {
 		glog.V(2).Infof("Rolling update with drain and validate enabled.")
 	}
@@ -404,6 +458,10 @@ func RunRollingUpdateCluster(f *util.Factory, out io.Writer, options *RollingUpd
 		ClusterName:       options.ClusterName,
 		PostDrainDelay:    options.PostDrainDelay,
 		ValidationTimeout: options.ValidationTimeout,
+		Progress:          progress,
+		Abort:             lock.Err,
+
+		NodeGroupConcurrency: options.NodeGroupConcurrency,
 	}
 	return d.RollingUpdate(groups, cluster, list)
 }
@@ -97,6 +97,7 @@ func Execute() {
 	if err := rootCommand.cobraCommand.Execute(); err != nil {
 		exitWithError(err)
 	}
+	finishAudit(nil)
 }
 
 func init() {
@@ -112,6 +113,11 @@ func NewCmdRoot(f *util.Factory, out io.Writer) *cobra.Command {
 
 	cmd := rootCommand.cobraCommand
 
+	// Commands that change a cluster are recorded in its audit log
+	cmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		startAudit(f, cmd, args)
+	}
+
 	//cmd.PersistentFlags().AddGoFlagSet(goflag.CommandLine)
 	goflag.CommandLine.VisitAll(func(goflag *goflag.Flag) {
 		switch goflag.Name {
@@ -138,14 +144,18 @@ func NewCmdRoot(f *util.Factory, out io.Writer) *cobra.Command {
 	cmd.AddCommand(NewCmdCompletion(f, out))
 	cmd.AddCommand(NewCmdCreate(f, out))
 	cmd.AddCommand(NewCmdDelete(f, out))
+	cmd.AddCommand(NewCmdDiff(f, out))
 	cmd.AddCommand(NewCmdEdit(f, out))
 	cmd.AddCommand(NewCmdExport(f, out))
 	cmd.AddCommand(NewCmdGet(f, out))
 	cmd.AddCommand(NewCmdUpdate(f, out))
 	cmd.AddCommand(NewCmdReplace(f, out))
+	cmd.AddCommand(NewCmdRollback(f, out))
+	cmd.AddCommand(NewCmdRotate(f, out))
 	cmd.AddCommand(NewCmdRollingUpdate(f, out))
 	cmd.AddCommand(NewCmdSet(f, out))
 	cmd.AddCommand(NewCmdToolbox(f, out))
+	cmd.AddCommand(NewCmdUnlock(f, out))
 	cmd.AddCommand(NewCmdValidate(f, out))
 
 	return cmd
@@ -0,0 +1,59 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kubernetes/pkg/kubectl/cmd/templates"
+	"k8s.io/kubernetes/pkg/kubectl/util/i18n"
+)
+
+var (
+	rotateLong = templates.LongDesc(i18n.T(`
+	Rotate the cluster CA or keypairs.
+
+	kops rotate only changes the keystore; to apply the changes use "kops update cluster",
+	followed by "kops rolling-update cluster --force".`))
+
+	rotateExample = templates.Examples(i18n.T(`
+	# Start rotating the cluster CA
+	kops rotate ca --name k8s-cluster.example.com --yes
+
+	# Issue a new kubelet keypair
+	kops rotate keypair kubelet --name k8s-cluster.example.com --yes
+	`))
+
+	rotateShort = i18n.T(`Rotate the cluster CA or keypairs.`)
+)
+
+func NewCmdRotate(f *util.Factory, out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "rotate",
+		Short:   rotateShort,
+		Long:    rotateLong,
+		Example: rotateExample,
+	}
+
+	// create subcommands
+	cmd.AddCommand(NewCmdRotateCA(f, out))
+	cmd.AddCommand(NewCmdRotateKeypair(f, out))
+
+	return cmd
+}
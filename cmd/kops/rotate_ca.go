@@ -0,0 +1,244 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/client/simple"
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/pkg/keyrotation"
+	"k8s.io/kops/upup/pkg/fi/cloudup"
+	"k8s.io/kubernetes/pkg/kubectl/cmd/templates"
+	"k8s.io/kubernetes/pkg/kubectl/genericclioptions"
+	"k8s.io/kubernetes/pkg/kubectl/util/i18n"
+)
+
+var (
+	rotateCALong = templates.LongDesc(i18n.T(`
+	Rotate the cluster CA, in stages, so that the cluster keeps working throughout.
+
+	Each run of kops rotate ca performs the next step of the rotation:
+
+	1. A new CA is generated, and added to the keystore alongside the old CA, which still signs certificates.
+	2. The new CA is made primary, and the certificates signed by the old CA are reissued, signed by the new CA.
+	3. The old CA, and the certificates it signed, are removed from the keystore.
+
+	After each step, apply the change with "kops update cluster --yes", and replace every instance with
+	"kops rolling-update cluster --force --yes", before running kops rotate ca again.  Instances that have
+	been replaced trust both CAs until the last step, so they work alongside those that have not.  The new
+	CA is not promoted while any instance started before it was added, or has not registered as a node, and
+	the old CA is not removed while any instance started before the new CA was promoted.
+
+	"kops get keypairs" shows the step that the rotation has reached.`))
+
+	rotateCAExample = templates.Examples(i18n.T(`
+	# Show the next step of the rotation
+	kops rotate ca --name k8s-cluster.example.com
+
+	# Perform the next step of the rotation
+	kops rotate ca --name k8s-cluster.example.com --yes
+	kops update cluster --name k8s-cluster.example.com --yes
+	kops rolling-update cluster --name k8s-cluster.example.com --force --yes
+	`))
+
+	rotateCAShort = i18n.T(`Rotate the cluster CA.`)
+)
+
+type RotateCAOptions struct {
+	ClusterName string
+	Yes         bool
+}
+
+func NewCmdRotateCA(f *util.Factory, out io.Writer) *cobra.Command {
+	options := &RotateCAOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "ca",
+		Short:   rotateCAShort,
+		Long:    rotateCALong,
+		Example: rotateCAExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := rootCommand.ProcessArgs(args); err != nil {
+				exitWithError(err)
+			}
+
+			options.ClusterName = rootCommand.ClusterName()
+
+			err := RunRotateCA(f, out, options)
+			if err != nil {
+				exitWithError(err)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVarP(&options.Yes, "yes", "y", options.Yes, "Perform the next step of the rotation, without --yes it is only described")
+
+	return cmd
+}
+
+func RunRotateCA(f *util.Factory, out io.Writer, options *RotateCAOptions) error {
+	if options.ClusterName == "" {
+		return fmt.Errorf("ClusterName is required")
+	}
+
+	clientset, err := f.Clientset()
+	if err != nil {
+		return err
+	}
+
+	cluster, err := GetCluster(f, options.ClusterName)
+	if err != nil {
+		return err
+	}
+
+	keyStore, err := clientset.KeyStore(cluster)
+	if err != nil {
+		return err
+	}
+
+	phase, err := keyrotation.CARotationPhase(keyStore)
+	if err != nil {
+		return err
+	}
+
+	var step string
+	switch phase {
+	case "":
+		step = "generate a new CA, trusted alongside the current CA"
+	case kops.KeysetRotationPhaseTrusting:
+		step = "make the new CA primary, and reissue the certificates signed by the old CA"
+	case kops.KeysetRotationPhaseSigning:
+		step = "remove the old CA, and the certificates it signed"
+	default:
+		return fmt.Errorf("unknown rotation phase %q", phase)
+	}
+
+	// The new CA can only be promoted once every instance trusts it, and the old CA removed once no instance uses it
+	var groups map[string]*cloudinstances.CloudInstanceGroup
+	if phase == kops.KeysetRotationPhaseTrusting || phase == kops.KeysetRotationPhaseSigning {
+		groups, err = getCloudGroupsWithNodes(clientset, cluster)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !options.Yes {
+		fmt.Fprintf(out, "The next step of the rotation will %s.\n", step)
+		switch phase {
+		case kops.KeysetRotationPhaseTrusting:
+			rotation, err := keyrotation.CARotation(keyStore)
+			if err != nil {
+				return err
+			}
+			if untrusting := keyrotation.InstancesNotTrustingNewCA(rotation, groups); len(untrusting) != 0 {
+				fmt.Fprintf(out, "\nThese instances started before the new CA was added, and must be replaced first: %s\n", strings.Join(untrusting, ", "))
+			}
+		case kops.KeysetRotationPhaseSigning:
+			rotation, err := keyrotation.CARotation(keyStore)
+			if err != nil {
+				return err
+			}
+			if old := keyrotation.InstancesUsingOldCA(rotation, groups); len(old) != 0 {
+				fmt.Fprintf(out, "\nThese instances started before the new CA was promoted, and must be replaced first: %s\n", strings.Join(old, ", "))
+			}
+		}
+		fmt.Fprintf(out, "\nMust specify --yes to perform it\n")
+		return nil
+	}
+
+	switch phase {
+	case "":
+		cert, err := keyrotation.StartCARotation(keyStore)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Generated new CA %s\n", cert.Certificate.SerialNumber)
+
+	case kops.KeysetRotationPhaseTrusting:
+		reissued, err := keyrotation.PromoteCA(keyStore, groups)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Promoted the new CA\n")
+		if len(reissued) != 0 {
+			fmt.Fprintf(out, "Reissued certificates: %s\n", strings.Join(reissued, ", "))
+		}
+
+	case kops.KeysetRotationPhaseSigning:
+		if err := keyrotation.FinishCARotation(keyStore, groups); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Removed the old CA\n")
+	}
+
+	fmt.Fprintf(out, "\nTo apply the change, run:\n")
+	fmt.Fprintf(out, " * kops update cluster --name %s --yes\n", options.ClusterName)
+	fmt.Fprintf(out, " * kops rolling-update cluster --name %s --force --yes\n", options.ClusterName)
+	switch phase {
+	case kops.KeysetRotationPhaseTrusting:
+		fmt.Fprintf(out, " * kops export kubecfg --name %s, as the admin certificate is signed by the new CA\n", options.ClusterName)
+		fmt.Fprintf(out, "\nThen run kops rotate ca again to remove the old CA.\n")
+	case "":
+		fmt.Fprintf(out, "\nThen run kops rotate ca again to promote the new CA.\n")
+	}
+
+	return nil
+}
+
+// getCloudGroupsWithNodes returns the cloud groups of every instance group of the cluster, with their instances matched to nodes
+func getCloudGroupsWithNodes(clientset simple.Clientset, cluster *kops.Cluster) (map[string]*cloudinstances.CloudInstanceGroup, error) {
+	contextName := cluster.ObjectMeta.Name
+	clientGetter := genericclioptions.NewConfigFlags()
+	clientGetter.Context = &contextName
+
+	config, err := clientGetter.ToRESTConfig()
+	if err != nil {
+		return nil, fmt.Errorf("cannot load kubecfg settings for %q: %v", contextName, err)
+	}
+	k8sClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot build kube client for %q: %v", contextName, err)
+	}
+	nodeList, err := k8sClient.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing nodes in cluster: %v", err)
+	}
+
+	list, err := clientset.InstanceGroupsFor(cluster).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var instanceGroups []*kops.InstanceGroup
+	for i := range list.Items {
+		instanceGroups = append(instanceGroups, &list.Items[i])
+	}
+
+	cloud, err := cloudup.BuildCloud(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	return cloud.GetCloudGroups(cluster, instanceGroups, true, nodeList.Items)
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/pkg/keyrotation"
+	"k8s.io/kubernetes/pkg/kubectl/cmd/templates"
+	"k8s.io/kubernetes/pkg/kubectl/util/i18n"
+)
+
+var (
+	rotateKeypairLong = templates.LongDesc(i18n.T(`
+	Issue a new private key and certificate for a keypair signed by the cluster CA, such as kubelet,
+	kube-proxy or master.
+
+	The new certificate becomes primary; the old one is kept, so it is still listed by "kops get keypairs",
+	and can be removed with "kops delete secret keypair <name> <id>" once no instance uses it.
+	Apply the change with "kops update cluster --yes" and "kops rolling-update cluster --force --yes".`))
+
+	rotateKeypairExample = templates.Examples(i18n.T(`
+	# Issue a new kubelet keypair
+	kops rotate keypair kubelet --name k8s-cluster.example.com --yes
+	`))
+
+	rotateKeypairShort = i18n.T(`Rotate a keypair.`)
+)
+
+type RotateKeypairOptions struct {
+	ClusterName string
+	KeypairName string
+	Yes         bool
+}
+
+func NewCmdRotateKeypair(f *util.Factory, out io.Writer) *cobra.Command {
+	options := &RotateKeypairOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "keypair",
+		Short:   rotateKeypairShort,
+		Long:    rotateKeypairLong,
+		Example: rotateKeypairExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) != 1 {
+				exitWithError(fmt.Errorf("Syntax: <name>"))
+			}
+			options.KeypairName = args[0]
+
+			options.ClusterName = rootCommand.ClusterName()
+
+			err := RunRotateKeypair(f, out, options)
+			if err != nil {
+				exitWithError(err)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVarP(&options.Yes, "yes", "y", options.Yes, "Issue the new keypair, without --yes the keypair is not changed")
+
+	return cmd
+}
+
+func RunRotateKeypair(f *util.Factory, out io.Writer, options *RotateKeypairOptions) error {
+	if options.ClusterName == "" {
+		return fmt.Errorf("ClusterName is required")
+	}
+	if options.KeypairName == "" {
+		return fmt.Errorf("KeypairName is required")
+	}
+
+	if !options.Yes {
+		fmt.Fprintf(out, "A new private key and certificate will be issued for keypair %q.\n", options.KeypairName)
+		fmt.Fprintf(out, "\nMust specify --yes to issue them\n")
+		return nil
+	}
+
+	clientset, err := f.Clientset()
+	if err != nil {
+		return err
+	}
+
+	cluster, err := GetCluster(f, options.ClusterName)
+	if err != nil {
+		return err
+	}
+
+	keyStore, err := clientset.KeyStore(cluster)
+	if err != nil {
+		return err
+	}
+
+	cert, err := keyrotation.RotateKeypair(keyStore, options.KeypairName)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Issued keypair %s/%s\n", options.KeypairName, cert.Certificate.SerialNumber)
+	fmt.Fprintf(out, "\nTo apply the change, run:\n")
+	fmt.Fprintf(out, " * kops update cluster --name %s --yes\n", options.ClusterName)
+	fmt.Fprintf(out, " * kops rolling-update cluster --name %s --force --yes\n", options.ClusterName)
+
+	return nil
+}
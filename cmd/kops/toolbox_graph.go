@@ -0,0 +1,145 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup"
+	"k8s.io/kubernetes/pkg/kubectl/cmd/templates"
+	"k8s.io/kubernetes/pkg/kubectl/util/i18n"
+)
+
+// OutputDot prints a graph in the DOT language of graphviz
+const OutputDot = "dot"
+
+var (
+	toolboxGraphLong = templates.LongDesc(i18n.T(`
+	Displays the graph of the tasks that kops update cluster runs, and the dependencies between them.
+
+	Each task is labelled with its type, name and lifecycle, and with the change that kops update cluster
+	would make to it, found by a dry run.  Objects that would be deleted are included, without dependencies.
+
+	In the DOT output, edges point from a task to the tasks that depend on it, which is the order in which they run.
+	In the JSON output, each task lists the keys of the tasks it depends on.`))
+
+	toolboxGraphExample = templates.Examples(i18n.T(`
+	# Render the task graph with graphviz
+	kops toolbox graph --name k8s-cluster.example.com | dot -Tsvg > tasks.svg
+
+	# Show the tasks of the network phase, as JSON
+	kops toolbox graph --name k8s-cluster.example.com --phase network -o json
+	`))
+
+	toolboxGraphShort = i18n.T(`Display the task dependency graph`)
+)
+
+type ToolboxGraphOptions struct {
+	ClusterName string
+
+	// Output is the format of the graph: dot or json
+	Output string
+
+	// Phase and LifecycleOverrides select the tasks as for kops update cluster
+	Phase              string
+	LifecycleOverrides []string
+}
+
+func (o *ToolboxGraphOptions) InitDefaults() {
+	o.Output = OutputDot
+}
+
+func NewCmdToolboxGraph(f *util.Factory, out io.Writer) *cobra.Command {
+	options := &ToolboxGraphOptions{}
+	options.InitDefaults()
+
+	cmd := &cobra.Command{
+		Use:     "graph",
+		Short:   toolboxGraphShort,
+		Long:    toolboxGraphLong,
+		Example: toolboxGraphExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := rootCommand.ProcessArgs(args); err != nil {
+				exitWithError(err)
+			}
+
+			options.ClusterName = rootCommand.ClusterName()
+
+			err := RunToolboxGraph(f, out, options)
+			if err != nil {
+				exitWithError(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.Output, "output", "o", options.Output, "output format.  One of: dot, json")
+	cmd.Flags().StringVar(&options.Phase, "phase", options.Phase, "Subset of tasks to show: "+strings.Join(cloudup.Phases.List(), ", "))
+	cmd.Flags().StringSliceVar(&options.LifecycleOverrides, "lifecycle-overrides", options.LifecycleOverrides, "comma separated list of phase overrides, example: SecurityGroups=Ignore,InternetGateway=ExistsAndWarnIfChanges")
+
+	return cmd
+}
+
+func RunToolboxGraph(f *util.Factory, out io.Writer, options *ToolboxGraphOptions) error {
+	if options.ClusterName == "" {
+		return fmt.Errorf("ClusterName is required")
+	}
+
+	switch options.Output {
+	case OutputDot, OutputJSON:
+	default:
+		return fmt.Errorf("unsupported output format %q, available formats: dot, json", options.Output)
+	}
+
+	updateOptions := &UpdateClusterOptions{}
+	updateOptions.InitDefaults()
+	updateOptions.Target = cloudup.TargetDryRun
+	updateOptions.Phase = options.Phase
+	updateOptions.LifecycleOverrides = options.LifecycleOverrides
+	// We only want the tasks and the changes; asking for structured output suppresses the text report
+	updateOptions.Output = OutputJSON
+
+	results, err := RunUpdateCluster(f, options.ClusterName, ioutil.Discard, updateOptions)
+	if err != nil {
+		return err
+	}
+
+	changes, err := results.Target.(*fi.DryRunTarget).Changes(results.TaskMap)
+	if err != nil {
+		return err
+	}
+	graph := fi.BuildTaskGraph(results.TaskMap, changes)
+
+	switch options.Output {
+	case OutputJSON:
+		data, err := json.MarshalIndent(graph, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error serializing graph: %v", err)
+		}
+		_, err = out.Write(append(data, '\n'))
+		return err
+
+	default:
+		return graph.WriteDOT(out)
+	}
+}
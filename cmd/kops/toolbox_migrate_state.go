@@ -0,0 +1,176 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kops/pkg/client/simple/vfsclientset"
+	"k8s.io/kops/pkg/statelock"
+	"k8s.io/kops/pkg/statemigrate"
+	"k8s.io/kops/util/pkg/vfs"
+	"k8s.io/kubernetes/pkg/kubectl/cmd/templates"
+	"k8s.io/kubernetes/pkg/kubectl/util/i18n"
+)
+
+var (
+	toolboxMigrateStateLong = templates.LongDesc(i18n.T(`
+	Copy the state of a cluster to another state store.
+
+	Every file under the cluster in the state store is copied: the cluster config, instance groups, keysets,
+	secrets, SSH keys and addon manifests.  The hash of each file is verified once it is written, and the
+	ConfigBase, KeyStore and SecretStore of the cluster are rewritten to the new location.  The cluster is not
+	removed from the old state store; instances keep reading their configuration from it until the cluster is
+	updated and rolled.`))
+
+	toolboxMigrateStateExample = templates.Examples(i18n.T(`
+	# Show what would be copied
+	kops toolbox migrate-state --name k8s-cluster.example.com \
+	  --from s3://old-state-store --to gs://new-state-store --dry-run
+
+	# Copy the cluster to the new state store
+	kops toolbox migrate-state --name k8s-cluster.example.com \
+	  --from s3://old-state-store --to gs://new-state-store
+	`))
+
+	toolboxMigrateStateShort = i18n.T(`Copy the state of a cluster to another state store.`)
+)
+
+type ToolboxMigrateStateOptions struct {
+	ClusterName string
+
+	// From is the state store to copy the cluster from
+	From string
+	// To is the state store to copy the cluster to
+	To string
+
+	DryRun bool
+}
+
+func NewCmdToolboxMigrateState(f *util.Factory, out io.Writer) *cobra.Command {
+	options := &ToolboxMigrateStateOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "migrate-state",
+		Short:   toolboxMigrateStateShort,
+		Long:    toolboxMigrateStateLong,
+		Example: toolboxMigrateStateExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := rootCommand.ProcessArgs(args); err != nil {
+				exitWithError(err)
+			}
+
+			options.ClusterName = rootCommand.ClusterName()
+
+			err := RunToolboxMigrateState(f, out, options)
+			if err != nil {
+				exitWithError(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&options.From, "from", options.From, "State store to copy the cluster from")
+	cmd.Flags().StringVar(&options.To, "to", options.To, "State store to copy the cluster to")
+	cmd.Flags().BoolVar(&options.DryRun, "dry-run", options.DryRun, "If true, only print the files that would be copied")
+
+	return cmd
+}
+
+func RunToolboxMigrateState(f *util.Factory, out io.Writer, options *ToolboxMigrateStateOptions) error {
+	if options.ClusterName == "" {
+		return fmt.Errorf("ClusterName is required")
+	}
+	if options.From == "" {
+		return fmt.Errorf("--from is required")
+	}
+	if options.To == "" {
+		return fmt.Errorf("--to is required")
+	}
+
+	from, err := vfs.Context.BuildVfsPath(options.From)
+	if err != nil {
+		return fmt.Errorf("error building path for %q: %v", options.From, err)
+	}
+	to, err := vfs.Context.BuildVfsPath(options.To)
+	if err != nil {
+		return fmt.Errorf("error building path for %q: %v", options.To, err)
+	}
+
+	clientset := vfsclientset.NewVFSClientset(from, true)
+	cluster, err := clientset.GetCluster(options.ClusterName)
+	if err != nil {
+		return err
+	}
+	if cluster == nil {
+		return fmt.Errorf("cluster %q not found in %s", options.ClusterName, options.From)
+	}
+
+	// Hold the lock in the old state store, so the cluster is not changed while it is being copied
+	if !options.DryRun {
+		lock, err := statelock.Acquire(cluster, from.Join(options.ClusterName), "migrate-state", statelock.DefaultTTL)
+		if err != nil {
+			return err
+		}
+		defer releaseClusterLock(lock)
+	}
+
+	migration := &statemigrate.Migration{
+		ClusterName: options.ClusterName,
+		From:        from,
+		To:          to,
+	}
+
+	files, migrated, err := migration.Plan()
+	if err != nil {
+		return err
+	}
+
+	if options.DryRun {
+		fmt.Fprintf(out, "Would copy %d files from %s to %s:\n", len(files), from, to)
+	} else {
+		fmt.Fprintf(out, "Copying %d files from %s to %s:\n", len(files), from, to)
+	}
+	for _, f := range files {
+		if f.Rewritten {
+			fmt.Fprintf(out, "  %s (rewritten for the new state store)\n", f.RelativePath)
+		} else {
+			fmt.Fprintf(out, "  %s\n", f.RelativePath)
+		}
+	}
+	fmt.Fprintf(out, "\nConfigBase:  %s\n", migrated.Spec.ConfigBase)
+	if migrated.Spec.KeyStore != "" {
+		fmt.Fprintf(out, "KeyStore:    %s\n", migrated.Spec.KeyStore)
+	}
+	if migrated.Spec.SecretStore != "" {
+		fmt.Fprintf(out, "SecretStore: %s\n", migrated.Spec.SecretStore)
+	}
+
+	if options.DryRun {
+		return nil
+	}
+
+	if err := migration.Apply(files, migrated); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "\nCopied and verified %d files.  Use --state %s to manage the cluster from the new state store.\n", len(files), options.To)
+	fmt.Fprintf(out, "Instances read their configuration from the old state store until you run kops update cluster and kops rolling-update cluster.\n")
+	return nil
+}
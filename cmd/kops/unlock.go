@@ -0,0 +1,52 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+	"k8s.io/kops/cmd/kops/util"
+	"k8s.io/kubernetes/pkg/kubectl/cmd/templates"
+	"k8s.io/kubernetes/pkg/kubectl/util/i18n"
+)
+
+var (
+	unlockLong = templates.LongDesc(i18n.T(`
+	Remove the lock that kops holds on a cluster while it is being changed.`))
+
+	unlockExample = templates.Examples(i18n.T(`
+	# Remove the lock left by an interrupted update
+	kops unlock cluster --name k8s-cluster.example.com --force
+	`))
+
+	unlockShort = i18n.T(`Remove the lock on a cluster.`)
+)
+
+func NewCmdUnlock(f *util.Factory, out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "unlock",
+		Short:   unlockShort,
+		Long:    unlockLong,
+		Example: unlockExample,
+	}
+
+	// create subcommands
+	cmd.AddCommand(NewCmdUnlockCluster(f, out))
+
+	return cmd
+}
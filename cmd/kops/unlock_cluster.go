@@ -0,0 +1,155 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/spf13/cobra"
+	"k8s.io/kops/cmd/kops/util"
+	api "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/client/simple"
+	"k8s.io/kops/pkg/statelock"
+	"k8s.io/kubernetes/pkg/kubectl/cmd/templates"
+	"k8s.io/kubernetes/pkg/kubectl/util/i18n"
+)
+
+var (
+	unlockClusterLong = templates.LongDesc(i18n.T(`
+	Remove the lock on a cluster.
+
+	kops locks a cluster while "kops update cluster --yes" or "kops rolling-update cluster --yes" runs, so that two
+	of them cannot change the cluster at once. The lock is renewed while the command runs, and expires 10 minutes
+	after the command stops; if a command was interrupted, the lock can be removed sooner with --force.
+	Without --force, the holder of the lock is shown.`))
+
+	unlockClusterExample = templates.Examples(i18n.T(`
+	# Show who holds the lock on a cluster
+	kops unlock cluster --name k8s-cluster.example.com
+
+	# Remove the lock left by an interrupted update
+	kops unlock cluster --name k8s-cluster.example.com --force
+	`))
+
+	unlockClusterShort = i18n.T(`Remove the lock on a cluster.`)
+)
+
+type UnlockClusterOptions struct {
+	ClusterName string
+	Force       bool
+}
+
+func NewCmdUnlockCluster(f *util.Factory, out io.Writer) *cobra.Command {
+	options := &UnlockClusterOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "cluster",
+		Short:   unlockClusterShort,
+		Long:    unlockClusterLong,
+		Example: unlockClusterExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := rootCommand.ProcessArgs(args)
+			if err != nil {
+				exitWithError(err)
+			}
+
+			options.ClusterName = rootCommand.ClusterName()
+
+			err = RunUnlockCluster(f, os.Stdout, options)
+			if err != nil {
+				exitWithError(err)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&options.Force, "force", options.Force, "Remove the lock, even though it may be held by a running command")
+
+	return cmd
+}
+
+func RunUnlockCluster(f *util.Factory, out io.Writer, options *UnlockClusterOptions) error {
+	if options.ClusterName == "" {
+		return fmt.Errorf("ClusterName is required")
+	}
+
+	clientset, err := f.Clientset()
+	if err != nil {
+		return err
+	}
+
+	cluster, err := GetCluster(f, options.ClusterName)
+	if err != nil {
+		return err
+	}
+
+	configBase, err := clientset.ConfigBaseFor(cluster)
+	if err != nil {
+		return fmt.Errorf("error building ConfigBase for cluster: %v", err)
+	}
+
+	record, err := statelock.Read(configBase)
+	if err != nil {
+		return err
+	}
+	if record == nil {
+		fmt.Fprintf(out, "Cluster %q is not locked\n", options.ClusterName)
+		return nil
+	}
+
+	fmt.Fprintf(out, "Cluster %q is locked by %s for %q since %s; the lock expires at %s unless renewed\n",
+		options.ClusterName, record.Owner, record.Operation, record.AcquiredAt.Format(time.RFC3339), record.ExpiresAt.Format(time.RFC3339))
+
+	if !options.Force {
+		fmt.Fprintf(out, "\nMust specify --force to remove the lock\n")
+		return nil
+	}
+
+	if err := statelock.ForceUnlock(configBase); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Removed lock\n")
+
+	return nil
+}
+
+// acquireClusterLock locks the cluster for an operation that changes it; the caller must release the lock
+func acquireClusterLock(clientset simple.Clientset, cluster *api.Cluster, operation string) (*statelock.Lock, error) {
+	configBase, err := clientset.ConfigBaseFor(cluster)
+	if err != nil {
+		return nil, fmt.Errorf("error building ConfigBase for cluster: %v", err)
+	}
+
+	lock, err := statelock.Acquire(cluster, configBase, operation, statelock.DefaultTTL)
+	if err != nil {
+		if statelock.IsLocked(err) {
+			return nil, fmt.Errorf("%v\nIf that command is no longer running, remove the lock with: kops unlock cluster --name %s --force", err, cluster.ObjectMeta.Name)
+		}
+		return nil, err
+	}
+	return lock, nil
+}
+
+// releaseClusterLock releases a lock taken by acquireClusterLock, logging rather than returning any error
+func releaseClusterLock(lock *statelock.Lock) {
+	if err := lock.Release(); err != nil {
+		glog.Errorf("error releasing cluster lock: %v", err)
+	}
+}
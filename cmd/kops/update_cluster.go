@@ -18,19 +18,23 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"path/filepath"
 	"strings"
 
+	"github.com/ghodss/yaml"
 	"github.com/golang/glog"
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/kops/cmd/kops/util"
 	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/apis/kops/registry"
 	"k8s.io/kops/pkg/commands"
 	"k8s.io/kops/pkg/kubeconfig"
+	"k8s.io/kops/pkg/statelock"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup"
 	"k8s.io/kops/upup/pkg/fi/utils"
@@ -51,6 +55,11 @@ var (
 	updateClusterExample = templates.Examples(i18n.T(`
 	# After cluster has been edited or upgraded, configure it with:
 	kops update cluster k8s-cluster.example.com --yes --state=s3://kops-state-1234 --yes
+
+	# Save the changes to a plan file, which can be reviewed and then applied.
+	# Applying the plan fails if the cluster or cloud has changed since it was saved.
+	kops update cluster k8s-cluster.example.com --out-plan=cluster.plan
+	kops update cluster k8s-cluster.example.com --plan=cluster.plan --yes
 	`))
 
 	updateClusterShort = i18n.T("Update a cluster.")
@@ -70,6 +79,17 @@ type UpdateClusterOptions struct {
 	// LifecycleOverrides is a slice of taskName=lifecycle name values.  This slice is used
 	// to populate the LifecycleOverrides struct member in ApplyClusterCmd struct.
 	LifecycleOverrides []string
+
+	// TaskRetryPolicies override how types of task are retried, as TaskType=maxTaskDuration[:initialBackoff[:maxBackoff]]
+	TaskRetryPolicies []string
+
+	// OutPlan is the path of a file to which a dry run saves the changes it would make
+	OutPlan string
+	// Plan is the path of a plan file saved by OutPlan; only the changes in the plan are applied
+	Plan string
+
+	// Output is the format in which a dry run prints its changes: text (the default), json or yaml
+	Output string
 }
 
 func (o *UpdateClusterOptions) InitDefaults() {
@@ -113,6 +133,11 @@ func NewCmdUpdateCluster(f *util.Factory, out io.Writer) *cobra.Command {
 	cmd.Flags().BoolVar(&options.CreateKubecfg, "create-kube-config", options.CreateKubecfg, "Will control automatically creating the kube config file on your local filesystem")
 	cmd.Flags().StringVar(&options.Phase, "phase", options.Phase, "Subset of tasks to run: "+strings.Join(cloudup.Phases.List(), ", "))
 	cmd.Flags().StringSliceVar(&options.LifecycleOverrides, "lifecycle-overrides", options.LifecycleOverrides, "comma separated list of phase overrides, example: SecurityGroups=Ignore,InternetGateway=ExistsAndWarnIfChanges")
+	cmd.Flags().StringSliceVar(&options.TaskRetryPolicies, "task-retry-policy", options.TaskRetryPolicies, "comma separated list of retry policy overrides for types of task, as TaskType=maxTaskDuration[:initialBackoff[:maxBackoff]], example: AutoscalingGroup=20m:10s:2m")
+	cmd.Flags().IntVar(&options.RunTasksOptions.MaxParallelism, "max-task-parallelism", options.RunTasksOptions.MaxParallelism, "Maximum number of tasks to run at once, to avoid cloud API rate limits; 0 for no limit")
+	cmd.Flags().StringVar(&options.OutPlan, "out-plan", options.OutPlan, "Save the changes a dry run would make to this plan file")
+	cmd.Flags().StringVar(&options.Plan, "plan", options.Plan, "Apply the changes saved in this plan file, refusing if the cluster or cloud has changed since it was saved")
+	cmd.Flags().StringVarP(&options.Output, "output", "o", options.Output, "Output format for the changes of a dry run. One of json|yaml; defaults to a text report")
 
 	return cmd
 }
@@ -143,6 +168,39 @@ func RunUpdateCluster(f *util.Factory, clusterName string, out io.Writer, c *Upd
 		targetName = cloudup.TargetDryRun
 	}
 
+	switch c.Output {
+	case "":
+	case OutputJSON, OutputYaml:
+		if !isDryrun {
+			return results, fmt.Errorf("--output can only be used for a dry run")
+		}
+	default:
+		return results, fmt.Errorf("unknown output format %q, available formats: json, yaml", c.Output)
+	}
+
+	if c.OutPlan != "" && (c.Target != cloudup.TargetDirect || !isDryrun) {
+		return results, fmt.Errorf("--out-plan can only be used for a dry run of the direct target")
+	}
+
+	var plan *cloudup.Plan
+	if c.Plan != "" {
+		if c.Target != cloudup.TargetDirect || isDryrun {
+			return results, fmt.Errorf("--plan can only be used with --yes and the direct target")
+		}
+		if c.OutPlan != "" {
+			return results, fmt.Errorf("--plan and --out-plan cannot be used together")
+		}
+		if c.Phase != "" || len(c.LifecycleOverrides) != 0 {
+			return results, fmt.Errorf("--phase and --lifecycle-overrides cannot be used with --plan, as the plan records them")
+		}
+
+		p, err := cloudup.LoadPlan(c.Plan)
+		if err != nil {
+			return results, err
+		}
+		plan = p
+	}
+
 	if c.OutDir == "" {
 		if c.Target == cloudup.TargetTerraform {
 			c.OutDir = "out/terraform"
@@ -163,6 +221,15 @@ func RunUpdateCluster(f *util.Factory, clusterName string, out io.Writer, c *Upd
 		return results, err
 	}
 
+	var lock *statelock.Lock
+	if !isDryrun {
+		lock, err = acquireClusterLock(clientset, cluster, "update cluster")
+		if err != nil {
+			return results, err
+		}
+		defer releaseClusterLock(lock)
+	}
+
 	keyStore, err := clientset.KeyStore(cluster)
 	if err != nil {
 		return results, err
@@ -210,6 +277,12 @@ func RunUpdateCluster(f *util.Factory, clusterName string, out io.Writer, c *Upd
 		}
 	}
 
+	for _, override := range c.TaskRetryPolicies {
+		if err := c.RunTasksOptions.SetRetryPolicy(override); err != nil {
+			return results, err
+		}
+	}
+
 	lifecycleOverrideMap := make(map[string]fi.Lifecycle)
 
 	for _, override := range c.LifecycleOverrides {
@@ -229,6 +302,13 @@ func RunUpdateCluster(f *util.Factory, clusterName string, out io.Writer, c *Upd
 		lifecycleOverrideMap[taskName] = lifecycleOverride
 	}
 
+	if plan != nil {
+		phase = plan.Phase
+		if plan.LifecycleOverrides != nil {
+			lifecycleOverrideMap = plan.LifecycleOverrides
+		}
+	}
+
 	var instanceGroups []*kops.InstanceGroup
 	{
 		list, err := clientset.InstanceGroupsFor(cluster).List(metav1.ListOptions{})
@@ -240,26 +320,105 @@ func RunUpdateCluster(f *util.Factory, clusterName string, out io.Writer, c *Upd
 		}
 	}
 
-	applyCmd := &cloudup.ApplyClusterCmd{
-		Clientset:          clientset,
-		Cluster:            cluster,
-		DryRun:             isDryrun,
-		InstanceGroups:     instanceGroups,
-		RunTasksOptions:    &c.RunTasksOptions,
-		Models:             strings.Split(c.Models, ","),
-		OutDir:             c.OutDir,
-		Phase:              phase,
-		TargetName:         targetName,
-		LifecycleOverrides: lifecycleOverrideMap,
+	newApplyCmd := func(targetName string) *cloudup.ApplyClusterCmd {
+		return &cloudup.ApplyClusterCmd{
+			Clientset: clientset,
+			Cluster:   cluster,
+			DryRun:    targetName == cloudup.TargetDryRun,
+			// ApplyClusterCmd replaces the instance groups in the slice with their completed specs
+			InstanceGroups:     append([]*kops.InstanceGroup(nil), instanceGroups...),
+			RunTasksOptions:    &c.RunTasksOptions,
+			Models:             strings.Split(c.Models, ","),
+			OutDir:             c.OutDir,
+			Phase:              phase,
+			TargetName:         targetName,
+			LifecycleOverrides: lifecycleOverrideMap,
+		}
+	}
+
+	var current *cloudup.Plan
+	if plan != nil || c.OutPlan != "" {
+		configBase, err := registry.ConfigBase(cluster)
+		if err != nil {
+			return results, err
+		}
+		current, err = cloudup.NewPlan(cluster, instanceGroups, configBase)
+		if err != nil {
+			return results, err
+		}
+	}
+
+	if plan != nil {
+		if err := plan.CheckCurrent(current); err != nil {
+			return results, err
+		}
+
+		// We compute the changes again, to check that applying the plan now would make exactly the planned changes
+		verifyCmd := newApplyCmd(cloudup.TargetDryRun)
+		verifyCmd.DryRunOutput = ioutil.Discard
+		if err := verifyCmd.Run(); err != nil {
+			return results, err
+		}
+		changes, err := verifyCmd.Target.(*fi.DryRunTarget).Changes(verifyCmd.TaskMap)
+		if err != nil {
+			return results, err
+		}
+		if err := plan.CheckChanges(changes); err != nil {
+			return results, err
+		}
+
+		glog.Infof("Cluster and cloud are unchanged since plan %q was computed; applying its %d changes", c.Plan, len(plan.Changes))
+	}
+
+	applyCmd := newApplyCmd(targetName)
+	if c.Output != "" {
+		// We print the changes ourselves, instead of the text report
+		applyCmd.DryRunOutput = ioutil.Discard
 	}
 
 	if err := applyCmd.Run(); err != nil {
 		return results, err
 	}
 
+	// Another command may have changed the cluster while it was being updated
+	if lock != nil {
+		if err := lock.Err(); err != nil {
+			return results, err
+		}
+	}
+
 	results.Target = applyCmd.Target
 	results.TaskMap = applyCmd.TaskMap
 
+	if c.OutPlan != "" {
+		changes, err := applyCmd.Target.(*fi.DryRunTarget).Changes(applyCmd.TaskMap)
+		if err != nil {
+			return results, err
+		}
+		current.Phase = phase
+		if len(lifecycleOverrideMap) != 0 {
+			current.LifecycleOverrides = lifecycleOverrideMap
+		}
+		current.Changes = changes
+		if err := current.Save(c.OutPlan); err != nil {
+			return results, err
+		}
+		if c.Output == "" {
+			fmt.Fprintf(out, "Saved plan with %d changes to %s\n", len(changes), c.OutPlan)
+		}
+	}
+
+	if c.Output != "" {
+		changes, err := applyCmd.Target.(*fi.DryRunTarget).Changes(applyCmd.TaskMap)
+		if err != nil {
+			return results, err
+		}
+		if err := writeChanges(out, c.Output, changes); err != nil {
+			return results, err
+		}
+		return results, nil
+	}
+
 	if isDryrun {
 		target := applyCmd.Target.(*fi.DryRunTarget)
 		if target.HasChanges() {
@@ -373,6 +532,35 @@ func RunUpdateCluster(f *util.Factory, clusterName string, out io.Writer, c *Upd
 	return results, nil
 }
 
+// writeChanges prints the changes of a dry run as a JSON or YAML list
+func writeChanges(out io.Writer, format string, changes []*fi.TaskChange) error {
+	if changes == nil {
+		changes = []*fi.TaskChange{}
+	}
+
+	var data []byte
+	var err error
+	switch format {
+	case OutputJSON:
+		data, err = json.MarshalIndent(changes, "", "  ")
+		if err == nil {
+			data = append(data, '\n')
+		}
+	case OutputYaml:
+		data, err = yaml.Marshal(changes)
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+	if err != nil {
+		return fmt.Errorf("error serializing changes: %v", err)
+	}
+
+	if _, err := out.Write(data); err != nil {
+		return fmt.Errorf("error writing to output: %v", err)
+	}
+	return nil
+}
+
 func parseLifecycle(lifecycle string) (fi.Lifecycle, error) {
 	if v, ok := fi.LifecycleNameMap[lifecycle]; ok {
 		return v, nil
@@ -24,6 +24,7 @@ import (
 	"github.com/golang/glog"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/client-go/rest"
+	azureblobacls "k8s.io/kops/pkg/acls/azureblob"
 	gceacls "k8s.io/kops/pkg/acls/gce"
 	s3acls "k8s.io/kops/pkg/acls/s3"
 	kopsclient "k8s.io/kops/pkg/client/clientset_generated/clientset"
@@ -43,6 +44,7 @@ type Factory struct {
 }
 
 func NewFactory(options *FactoryOptions) *Factory {
+	azureblobacls.Register()
 	gceacls.Register()
 	s3acls.Register()
 
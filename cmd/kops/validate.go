@@ -33,13 +33,21 @@ var (
 	2. All k8s nodes are running and have "Ready" status.
 	3. Component status returns healthy for all components.
 	4. All pods in the kube-system namespace are running and healthy.
+	5. The etcd members, API load balancer, API DNS records and kube-system addons are healthy.
+	6. Any additional checks in the cluster spec pass.
+
+	Failures with severity Warning are reported, but do not fail validation.
 	`))
 
 	validateExample = templates.Examples(i18n.T(`
 	# Validate a cluster.
 	# This command uses the currently selected kops cluster as
 	# set by the kubectl config.
-	kops validate cluster`))
+	kops validate cluster
+
+	# Wait up to 10 minutes for a new cluster to validate,
+	# printing a JSON summary of each attempt.
+	kops validate cluster --wait 10m -o jsonl`))
 
 	validateShort = i18n.T(`Validate a kops cluster.`)
 )
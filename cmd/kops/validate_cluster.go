@@ -23,6 +23,7 @@ import (
 	"os"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/ghodss/yaml"
 	"github.com/golang/glog"
@@ -44,8 +45,15 @@ func init() {
 	}
 }
 
+// OutputJSONLines prints a JSON object on each line, for each step of a long-running command
+const OutputJSONLines = "jsonl"
+
+// validateClusterWaitInterval is the time between attempts to validate the cluster, when waiting for it to validate
+const validateClusterWaitInterval = 10 * time.Second
+
 type ValidateClusterOptions struct {
 	output string
+	wait   time.Duration
 }
 
 func (o *ValidateClusterOptions) InitDefaults() {
@@ -67,14 +75,15 @@ func NewCmdValidateCluster(f *util.Factory, out io.Writer) *cobra.Command {
 				exitWithError(err)
 			}
 			// We want the validate command to exit non-zero if validation found a problem,
-			// even if we didn't really hit an error during validation.
-			if len(result.Failures) != 0 {
+			// even if we didn't really hit an error during validation.  Warnings alone do not fail validation.
+			if len(result.Errors()) != 0 {
 				os.Exit(2)
 			}
 		},
 	}
 
-	cmd.Flags().StringVarP(&options.output, "output", "o", options.output, "Output format. One of json|yaml|table.")
+	cmd.Flags().StringVarP(&options.output, "output", "o", options.output, "Output format. One of json|yaml|table|jsonl.  jsonl prints a JSON summary of each attempt at validating the cluster.")
+	cmd.Flags().DurationVar(&options.wait, "wait", options.wait, "If set, keep retrying validation until the cluster validates or this duration expires")
 
 	return cmd
 }
@@ -100,6 +109,12 @@ func RunValidateCluster(f *util.Factory, cmd *cobra.Command, args []string, out
 		return nil, fmt.Errorf("cannot get InstanceGroups for %q: %v", cluster.ObjectMeta.Name, err)
 	}
 
+	switch options.output {
+	case OutputTable, OutputYaml, OutputJSON, OutputJSONLines:
+	default:
+		return nil, fmt.Errorf("Unknown output format: %q", options.output)
+	}
+
 	if options.output == OutputTable {
 		fmt.Fprintf(out, "Validating cluster %v\n\n", cluster.ObjectMeta.Name)
 	}
@@ -128,8 +143,29 @@ func RunValidateCluster(f *util.Factory, cmd *cobra.Command, args []string, out
 		return nil, fmt.Errorf("Cannot build kubernetes api client for %q: %v", contextName, err)
 	}
 
-	result, err := validation.ValidateCluster(cluster, list, k8sClient)
-	if err != nil {
+	validate := func() (*validation.ValidationCluster, error) {
+		return validation.ValidateCluster(cluster, list, k8sClient)
+	}
+	var reportErr error
+	report := func(attempt *validation.ValidationAttempt) {
+		if options.output == OutputJSONLines {
+			if err := writeJSONLine(out, attempt); err != nil && reportErr == nil {
+				reportErr = err
+			}
+		} else if !attempt.Passed && options.wait != 0 {
+			message := attempt.Error
+			if message == "" {
+				message = fmt.Sprintf("%d validation errors", attempt.Errors)
+			}
+			glog.Infof("Cluster did not validate after %s (%s), will try again in %s", time.Duration(attempt.ElapsedSeconds*float64(time.Second)).Round(time.Second), message, validateClusterWaitInterval)
+		}
+	}
+
+	result, err := validation.WaitForValidation(options.wait, validateClusterWaitInterval, validate, report)
+	if reportErr != nil {
+		return nil, reportErr
+	}
+	if result == nil {
 		return nil, fmt.Errorf("unexpected error during validation: %v", err)
 	}
 
@@ -157,13 +193,27 @@ func RunValidateCluster(f *util.Factory, cmd *cobra.Command, args []string, out
 			return nil, fmt.Errorf("error writing to output: %v", err)
 		}
 
-	default:
-		return nil, fmt.Errorf("Unknown output format: %q", options.output)
+	case OutputJSONLines:
+		// Each attempt has already been written
+
 	}
 
 	return result, nil
 }
 
+// writeJSONLine writes the object as JSON, followed by a newline
+func writeJSONLine(out io.Writer, obj interface{}) error {
+	j, err := json.Marshal(obj)
+	if err != nil {
+		return fmt.Errorf("unable to marshal JSON: %v", err)
+	}
+	j = append(j, '\n')
+	if _, err := out.Write(j); err != nil {
+		return fmt.Errorf("error writing to output: %v", err)
+	}
+	return nil
+}
+
 func validateClusterOutputTable(result *validation.ValidationCluster, cluster *api.Cluster, instanceGroups []api.InstanceGroup, out io.Writer) error {
 	t := &tables.Table{}
 	t.AddColumn("NAME", func(c api.InstanceGroup) string {
@@ -220,17 +270,20 @@ func validateClusterOutputTable(result *validation.ValidationCluster, cluster *a
 		failuresTable.AddColumn("NAME", func(e *validation.ValidationError) string {
 			return e.Name
 		})
+		failuresTable.AddColumn("SEVERITY", func(e *validation.ValidationError) string {
+			return string(e.Severity)
+		})
 		failuresTable.AddColumn("MESSAGE", func(e *validation.ValidationError) string {
 			return e.Message
 		})
 
 		fmt.Fprintln(out, "\nVALIDATION ERRORS")
-		if err := failuresTable.Render(result.Failures, out, "KIND", "NAME", "MESSAGE"); err != nil {
+		if err := failuresTable.Render(result.Failures, out, "KIND", "NAME", "SEVERITY", "MESSAGE"); err != nil {
 			return fmt.Errorf("error rendering failures table: %v", err)
 		}
 	}
 
-	if len(result.Failures) == 0 {
+	if len(result.Errors()) == 0 {
 		fmt.Fprintf(out, "\nYour cluster %s is ready\n", cluster.Name)
 	} else {
 		fmt.Fprint(out, "\nValidation Failed\n")
@@ -0,0 +1,108 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/golang/glog"
+	"github.com/spf13/pflag"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"k8s.io/kops/node-drainer/pkg/drainer"
+)
+
+var (
+	flags        = pflag.NewFlagSet("", pflag.ExitOnError)
+	BuildVersion = "0.1"
+)
+
+func main() {
+	fmt.Printf("node-drainer version %s\n", BuildVersion)
+	var queueName, region string
+	var drainTimeout, pollInterval time.Duration
+
+	// Be sure to get the glog flags
+	glog.Flush()
+
+	flags.StringVar(&queueName, "queue-name", "", "Name of the SQS queue the lifecycle hooks notify")
+	flags.StringVar(&region, "region", "", "AWS region of the queue, by default the region of the instance")
+	flags.DurationVar(&drainTimeout, "drain-timeout", 5*time.Minute, "How long to wait for evicted pods to terminate before letting the instance terminate")
+	flags.DurationVar(&pollInterval, "poll-interval", 5*time.Second, "How often to retry evictions and check whether evicted pods have terminated")
+
+	// Trick to avoid 'logging before flag.Parse' warning
+	flag.CommandLine.Parse([]string{})
+
+	flag.Set("logtostderr", "true")
+	flags.AddGoFlagSet(flag.CommandLine)
+	flags.Parse(os.Args)
+
+	if queueName == "" {
+		glog.Errorf("--queue-name is required")
+		os.Exit(1)
+	}
+
+	sess, err := session.NewSession()
+	if err != nil {
+		glog.Fatalf("error building AWS session: %v", err)
+	}
+	if region == "" {
+		region, err = ec2metadata.New(sess).Region()
+		if err != nil {
+			glog.Fatalf("error getting region from instance metadata: %v", err)
+		}
+	}
+	config := aws.NewConfig().WithRegion(region)
+
+	sqsClient := sqs.New(sess, config)
+	queue, err := sqsClient.GetQueueUrl(&sqs.GetQueueUrlInput{QueueName: aws.String(queueName)})
+	if err != nil {
+		glog.Fatalf("error getting URL of SQS queue %q: %v", queueName, err)
+	}
+
+	restConfig, err := rest.InClusterConfig()
+	if err != nil {
+		glog.Errorf("error building client configuration: %v", err)
+		os.Exit(1)
+	}
+
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		glog.Fatalf("error building REST client: %v", err)
+	}
+
+	d := &drainer.Drainer{
+		SQS:          sqsClient,
+		Autoscaling:  autoscaling.New(sess, config),
+		Client:       client,
+		QueueURL:     aws.StringValue(queue.QueueUrl),
+		DrainTimeout: drainTimeout,
+		PollInterval: pollInterval,
+	}
+
+	glog.Infof("watching SQS queue %q for lifecycle notifications", aws.StringValue(queue.QueueUrl))
+	d.Run(make(chan struct{}))
+}
@@ -0,0 +1,360 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package drainer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/golang/glog"
+	"k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// TestNotificationEvent is the event of the notification sent when a lifecycle hook is created
+	TestNotificationEvent = "autoscaling:TEST_NOTIFICATION"
+	// TerminatingTransition is the transition of instances that are being terminated
+	TerminatingTransition = "autoscaling:EC2_INSTANCE_TERMINATING"
+
+	// mirrorPodAnnotation marks the static pods of a node, which cannot be evicted
+	mirrorPodAnnotation = "kubernetes.io/config.mirror"
+
+	// defaultHeartbeatInterval is used if we cannot read the heartbeat timeout of a lifecycle hook; it is a third of
+	// the shortest heartbeat timeout that kops allows
+	defaultHeartbeatInterval = 10 * time.Second
+)
+
+// LifecycleNotification is the message a lifecycle hook sends for each instance it pauses
+type LifecycleNotification struct {
+	Event                string `json:"Event,omitempty"`
+	AutoScalingGroupName string `json:"AutoScalingGroupName"`
+	LifecycleHookName    string `json:"LifecycleHookName"`
+	LifecycleTransition  string `json:"LifecycleTransition"`
+	LifecycleActionToken string `json:"LifecycleActionToken"`
+	EC2InstanceId        string `json:"EC2InstanceId"`
+}
+
+// Drainer receives the notifications of the lifecycle hooks from an SQS queue, drains the node of each terminating instance,
+// and then completes the lifecycle action so that the instance terminates
+type Drainer struct {
+	SQS         sqsiface.SQSAPI
+	Autoscaling autoscalingiface.AutoScalingAPI
+	Client      kubernetes.Interface
+
+	// QueueURL is the URL of the queue the lifecycle hooks notify
+	QueueURL string
+	// DrainTimeout is how long we wait for evicted pods to terminate before we let the instance terminate anyway
+	DrainTimeout time.Duration
+	// PollInterval is how often we retry evictions and check whether the evicted pods have terminated
+	PollInterval time.Duration
+	// HeartbeatInterval is how often we record a heartbeat for a lifecycle action while we drain its node, so that
+	// the action does not time out before the drain does.  If zero, it is a third of the heartbeat timeout of the hook.
+	HeartbeatInterval time.Duration
+}
+
+// Run handles notifications until stopCh is closed
+func (d *Drainer) Run(stopCh <-chan struct{}) {
+	wait.Until(func() {
+		if err := d.ProcessMessages(); err != nil {
+			glog.Warningf("error processing lifecycle notifications: %v", err)
+		}
+	}, time.Second, stopCh)
+}
+
+// ProcessMessages waits for a batch of notifications and handles them concurrently
+func (d *Drainer) ProcessMessages() error {
+	response, err := d.SQS.ReceiveMessage(&sqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(d.QueueURL),
+		MaxNumberOfMessages: aws.Int64(10),
+		WaitTimeSeconds:     aws.Int64(20),
+		// We keep the message hidden while we drain the node
+		VisibilityTimeout: aws.Int64(int64((d.DrainTimeout + time.Minute) / time.Second)),
+	})
+	if err != nil {
+		return fmt.Errorf("error receiving messages from %q: %v", d.QueueURL, err)
+	}
+
+	var wg sync.WaitGroup
+	for _, message := range response.Messages {
+		wg.Add(1)
+		go func(message *sqs.Message) {
+			defer wg.Done()
+			if err := d.handleMessage(message); err != nil {
+				glog.Warningf("error handling message %s: %v", aws.StringValue(message.MessageId), err)
+			}
+		}(message)
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// handleMessage drains the node for a notification and completes the lifecycle action.  The message is deleted once it
+// has been handled; if we fail it is received again once its visibility timeout expires.
+func (d *Drainer) handleMessage(message *sqs.Message) error {
+	notification := &LifecycleNotification{}
+	if err := json.Unmarshal([]byte(aws.StringValue(message.Body)), notification); err != nil {
+		glog.Warningf("ignoring message %s which is not a lifecycle notification: %v", aws.StringValue(message.MessageId), err)
+		return d.deleteMessage(message)
+	}
+
+	if notification.Event == TestNotificationEvent {
+		glog.V(2).Infof("ignoring test notification from AutoscalingGroup %q", notification.AutoScalingGroupName)
+		return d.deleteMessage(message)
+	}
+
+	if notification.LifecycleTransition != TerminatingTransition {
+		glog.V(2).Infof("ignoring %s notification for instance %q; it must be completed by the instance", notification.LifecycleTransition, notification.EC2InstanceId)
+		return d.deleteMessage(message)
+	}
+
+	glog.Infof("instance %q of AutoscalingGroup %q is terminating", notification.EC2InstanceId, notification.AutoScalingGroupName)
+
+	// The drain can take longer than the heartbeat timeout of the hook, so we keep the lifecycle action alive until it is done
+	stopHeartbeats := make(chan struct{})
+	heartbeatsDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatsDone)
+		d.recordHeartbeats(notification, stopHeartbeats)
+	}()
+	err := d.DrainInstance(notification.EC2InstanceId)
+	close(stopHeartbeats)
+	<-heartbeatsDone
+	if err != nil {
+		return err
+	}
+
+	_, err = d.Autoscaling.CompleteLifecycleAction(&autoscaling.CompleteLifecycleActionInput{
+		AutoScalingGroupName:  aws.String(notification.AutoScalingGroupName),
+		LifecycleHookName:     aws.String(notification.LifecycleHookName),
+		InstanceId:            aws.String(notification.EC2InstanceId),
+		LifecycleActionToken:  aws.String(notification.LifecycleActionToken),
+		LifecycleActionResult: aws.String("CONTINUE"),
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); !ok || awsErr.Code() != "ValidationError" {
+			return fmt.Errorf("error completing lifecycle action for instance %q: %v", notification.EC2InstanceId, err)
+		}
+		// The lifecycle action has already completed, most likely because it timed out
+		glog.Warningf("lifecycle action for instance %q is no longer active: %v", notification.EC2InstanceId, err)
+	}
+
+	return d.deleteMessage(message)
+}
+
+// recordHeartbeats records a heartbeat for the lifecycle action of the notification every heartbeat interval, until stopCh is closed
+func (d *Drainer) recordHeartbeats(notification *LifecycleNotification, stopCh <-chan struct{}) {
+	ticker := time.NewTicker(d.heartbeatInterval(notification))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			_, err := d.Autoscaling.RecordLifecycleActionHeartbeat(&autoscaling.RecordLifecycleActionHeartbeatInput{
+				AutoScalingGroupName: aws.String(notification.AutoScalingGroupName),
+				LifecycleHookName:    aws.String(notification.LifecycleHookName),
+				InstanceId:           aws.String(notification.EC2InstanceId),
+				LifecycleActionToken: aws.String(notification.LifecycleActionToken),
+			})
+			if err != nil {
+				glog.Warningf("error recording heartbeat for lifecycle action of instance %q: %v", notification.EC2InstanceId, err)
+			}
+		}
+	}
+}
+
+// heartbeatInterval returns how often to record heartbeats for the lifecycle action of the notification
+func (d *Drainer) heartbeatInterval(notification *LifecycleNotification) time.Duration {
+	if d.HeartbeatInterval != 0 {
+		return d.HeartbeatInterval
+	}
+
+	response, err := d.Autoscaling.DescribeLifecycleHooks(&autoscaling.DescribeLifecycleHooksInput{
+		AutoScalingGroupName: aws.String(notification.AutoScalingGroupName),
+		LifecycleHookNames:   []*string{aws.String(notification.LifecycleHookName)},
+	})
+	if err != nil {
+		glog.Warningf("error reading lifecycle hook %q of AutoscalingGroup %q: %v", notification.LifecycleHookName, notification.AutoScalingGroupName, err)
+		return defaultHeartbeatInterval
+	}
+	for _, hook := range response.LifecycleHooks {
+		if timeout := aws.Int64Value(hook.HeartbeatTimeout); timeout > 0 {
+			return time.Duration(timeout) * time.Second / 3
+		}
+	}
+	return defaultHeartbeatInterval
+}
+
+func (d *Drainer) deleteMessage(message *sqs.Message) error {
+	_, err := d.SQS.DeleteMessage(&sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(d.QueueURL),
+		ReceiptHandle: message.ReceiptHandle,
+	})
+	if err != nil {
+		return fmt.Errorf("error deleting message %s: %v", aws.StringValue(message.MessageId), err)
+	}
+	return nil
+}
+
+// DrainInstance cordons the node of an EC2 instance and evicts its pods, waiting up to DrainTimeout for them to terminate
+func (d *Drainer) DrainInstance(instanceID string) error {
+	node, err := d.findNode(instanceID)
+	if err != nil {
+		return err
+	}
+	if node == nil {
+		glog.Infof("no node found for instance %q; nothing to drain", instanceID)
+		return nil
+	}
+
+	if !node.Spec.Unschedulable {
+		glog.Infof("cordoning node %q", node.Name)
+		node.Spec.Unschedulable = true
+		if _, err := d.Client.CoreV1().Nodes().Update(node); err != nil {
+			return fmt.Errorf("error cordoning node %q: %v", node.Name, err)
+		}
+	}
+
+	pods, err := d.podsToEvict(node)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(d.DrainTimeout)
+	evicted := make(map[types.UID]bool)
+	for {
+		var remaining []v1.Pod
+		for _, pod := range pods {
+			done, err := d.evictPod(&pod, evicted)
+			if err != nil {
+				glog.Warningf("error evicting pod %s/%s: %v", pod.Namespace, pod.Name, err)
+			}
+			if !done {
+				remaining = append(remaining, pod)
+			}
+		}
+		pods = remaining
+
+		if len(pods) == 0 {
+			glog.Infof("drained node %q", node.Name)
+			return nil
+		}
+		if time.Now().After(deadline) {
+			glog.Warningf("timed out draining node %q, with %d pods remaining", node.Name, len(pods))
+			return nil
+		}
+		time.Sleep(d.PollInterval)
+	}
+}
+
+// findNode returns the node whose provider ID refers to the instance, or nil if there is none
+func (d *Drainer) findNode(instanceID string) (*v1.Node, error) {
+	nodes, err := d.Client.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing nodes: %v", err)
+	}
+
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		// The provider ID is of the form aws:///us-east-1a/i-0123456789abcdef0
+		if strings.HasSuffix(node.Spec.ProviderID, "/"+instanceID) {
+			return node, nil
+		}
+	}
+	return nil, nil
+}
+
+// podsToEvict returns the pods of the node, except for those of DaemonSets, mirror pods and pods that have finished
+func (d *Drainer) podsToEvict(node *v1.Node) ([]v1.Pod, error) {
+	pods, err := d.Client.CoreV1().Pods(metav1.NamespaceAll).List(metav1.ListOptions{
+		FieldSelector: "spec.nodeName=" + node.Name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing pods on node %q: %v", node.Name, err)
+	}
+
+	var evict []v1.Pod
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName != node.Name {
+			continue
+		}
+		if _, found := pod.Annotations[mirrorPodAnnotation]; found {
+			continue
+		}
+		if controllerRef := metav1.GetControllerOf(&pod); controllerRef != nil && controllerRef.Kind == "DaemonSet" {
+			continue
+		}
+		if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		evict = append(evict, pod)
+	}
+	return evict, nil
+}
+
+// evictPod evicts the pod, if it has not been evicted already, and returns true once it has terminated
+func (d *Drainer) evictPod(pod *v1.Pod, evicted map[types.UID]bool) (bool, error) {
+	if evicted[pod.UID] {
+		current, err := d.Client.CoreV1().Pods(pod.Namespace).Get(pod.Name, metav1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return true, nil
+			}
+			return false, err
+		}
+		return current.UID != pod.UID, nil
+	}
+
+	err := d.Client.CoreV1().Pods(pod.Namespace).Evict(&policy.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+		},
+	})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+		if errors.IsTooManyRequests(err) {
+			// The eviction would violate a PodDisruptionBudget; we try again later
+			glog.V(2).Infof("eviction of pod %s/%s is not yet allowed: %v", pod.Namespace, pod.Name, err)
+			return false, nil
+		}
+		return false, err
+	}
+
+	glog.V(2).Infof("evicted pod %s/%s", pod.Namespace, pod.Name)
+	evicted[pod.UID] = true
+	return false, nil
+}
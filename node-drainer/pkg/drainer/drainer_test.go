@@ -0,0 +1,301 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package drainer
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	core "k8s.io/client-go/testing"
+
+	"k8s.io/kops/cloudmock/aws/mockautoscaling"
+	"k8s.io/kops/cloudmock/aws/mocksqs"
+)
+
+const (
+	groupName = "nodes.example.com"
+	hookName  = "drain"
+)
+
+type testHarness struct {
+	drainer     *Drainer
+	sqs         *mocksqs.MockSQS
+	autoscaling *mockautoscaling.MockAutoscaling
+	client      *fake.Clientset
+
+	// evicted records the pods evicted through the eviction API
+	evicted []string
+}
+
+func newTestHarness(t *testing.T, objects ...runtime.Object) *testHarness {
+	h := &testHarness{
+		sqs:         &mocksqs.MockSQS{},
+		autoscaling: &mockautoscaling.MockAutoscaling{},
+		client:      fake.NewSimpleClientset(objects...),
+	}
+
+	queue, err := h.sqs.CreateQueue(&sqs.CreateQueueInput{QueueName: aws.String("lifecycle-hooks-example-com")})
+	if err != nil {
+		t.Fatalf("error creating queue: %v", err)
+	}
+
+	h.autoscaling.Groups = map[string]*autoscaling.Group{
+		groupName: {AutoScalingGroupName: aws.String(groupName)},
+	}
+	_, err = h.autoscaling.PutLifecycleHook(&autoscaling.PutLifecycleHookInput{
+		AutoScalingGroupName: aws.String(groupName),
+		LifecycleHookName:    aws.String(hookName),
+		LifecycleTransition:  aws.String(TerminatingTransition),
+	})
+	if err != nil {
+		t.Fatalf("error creating lifecycle hook: %v", err)
+	}
+
+	// The fake clientset does not implement evictions, so we record them and then report the evicted pods as deleted
+	h.client.PrependReactor("create", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		eviction := action.(core.CreateAction).GetObject().(*policy.Eviction)
+		h.evicted = append(h.evicted, eviction.Namespace+"/"+eviction.Name)
+		return true, nil, nil
+	})
+	h.client.PrependReactor("get", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		get := action.(core.GetAction)
+		for _, evicted := range h.evicted {
+			if evicted == get.GetNamespace()+"/"+get.GetName() {
+				return true, nil, errors.NewNotFound(schema.GroupResource{Resource: "pods"}, get.GetName())
+			}
+		}
+		return false, nil, nil
+	})
+
+	h.drainer = &Drainer{
+		SQS:          h.sqs,
+		Autoscaling:  h.autoscaling,
+		Client:       h.client,
+		QueueURL:     aws.StringValue(queue.QueueUrl),
+		DrainTimeout: 10 * time.Second,
+		PollInterval: time.Millisecond,
+	}
+	return h
+}
+
+func (h *testHarness) sendNotification(t *testing.T, notification *LifecycleNotification) {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		t.Fatalf("error encoding notification: %v", err)
+	}
+	h.sendMessage(t, string(body))
+}
+
+func (h *testHarness) sendMessage(t *testing.T, body string) {
+	if _, err := h.sqs.SendMessage(&sqs.SendMessageInput{
+		QueueUrl:    aws.String(h.drainer.QueueURL),
+		MessageBody: aws.String(body),
+	}); err != nil {
+		t.Fatalf("error sending message: %v", err)
+	}
+}
+
+func (h *testHarness) processMessages(t *testing.T) {
+	if err := h.drainer.ProcessMessages(); err != nil {
+		t.Fatalf("unexpected error processing messages: %v", err)
+	}
+	if n := h.sqs.MessageCount(h.drainer.QueueURL); n != 0 {
+		t.Errorf("expected all messages to be deleted, %d remain", n)
+	}
+}
+
+func buildNode(name string, instanceID string) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       v1.NodeSpec{ProviderID: "aws:///us-test-1a/" + instanceID},
+	}
+}
+
+func buildPod(name string, nodeName string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			UID:       types.UID(name),
+		},
+		Spec:   v1.PodSpec{NodeName: nodeName},
+		Status: v1.PodStatus{Phase: v1.PodRunning},
+	}
+}
+
+func terminatingNotification(instanceID string) *LifecycleNotification {
+	return &LifecycleNotification{
+		AutoScalingGroupName: groupName,
+		LifecycleHookName:    hookName,
+		LifecycleTransition:  TerminatingTransition,
+		LifecycleActionToken: "token-" + instanceID,
+		EC2InstanceId:        instanceID,
+	}
+}
+
+func TestDrainTerminatingInstance(t *testing.T) {
+	daemonSetPod := buildPod("daemonset-pod", "node-a")
+	daemonSetPod.OwnerReferences = []metav1.OwnerReference{
+		{Kind: "DaemonSet", Name: "daemonset", Controller: aws.Bool(true)},
+	}
+	mirrorPod := buildPod("mirror-pod", "node-a")
+	mirrorPod.Annotations = map[string]string{mirrorPodAnnotation: "hash"}
+	completedPod := buildPod("completed-pod", "node-a")
+	completedPod.Status.Phase = v1.PodSucceeded
+
+	h := newTestHarness(t,
+		buildNode("node-a", "i-0000000a"),
+		buildNode("node-b", "i-0000000b"),
+		buildPod("pod-1", "node-a"),
+		buildPod("pod-2", "node-a"),
+		buildPod("pod-3", "node-b"),
+		daemonSetPod,
+		mirrorPod,
+		completedPod,
+	)
+
+	h.sendNotification(t, terminatingNotification("i-0000000a"))
+	h.processMessages(t)
+
+	node, err := h.client.CoreV1().Nodes().Get("node-a", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting node: %v", err)
+	}
+	if !node.Spec.Unschedulable {
+		t.Errorf("expected node-a to be cordoned")
+	}
+	other, err := h.client.CoreV1().Nodes().Get("node-b", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting node: %v", err)
+	}
+	if other.Spec.Unschedulable {
+		t.Errorf("expected node-b not to be cordoned")
+	}
+
+	sort.Strings(h.evicted)
+	if expected := []string{"default/pod-1", "default/pod-2"}; !reflect.DeepEqual(h.evicted, expected) {
+		t.Errorf("unexpected evictions: %v, expected %v", h.evicted, expected)
+	}
+
+	if len(h.autoscaling.CompletedLifecycleActions) != 1 {
+		t.Fatalf("expected 1 completed lifecycle action, got %d", len(h.autoscaling.CompletedLifecycleActions))
+	}
+	completed := h.autoscaling.CompletedLifecycleActions[0]
+	if aws.StringValue(completed.InstanceId) != "i-0000000a" ||
+		aws.StringValue(completed.LifecycleActionToken) != "token-i-0000000a" ||
+		aws.StringValue(completed.LifecycleActionResult) != "CONTINUE" {
+		t.Errorf("unexpected lifecycle action: %v", completed)
+	}
+}
+
+func TestHeartbeatsWhileDraining(t *testing.T) {
+	h := newTestHarness(t, buildNode("node-a", "i-0000000a"), buildPod("pod-1", "node-a"))
+	h.drainer.HeartbeatInterval = time.Millisecond
+	h.drainer.PollInterval = 10 * time.Millisecond
+
+	// A PodDisruptionBudget holds up the eviction for a while
+	blocked := 0
+	h.client.PrependReactor("create", "pods", func(action core.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" || blocked >= 5 {
+			return false, nil, nil
+		}
+		blocked++
+		return true, nil, errors.NewTooManyRequests("disruption budget", 0)
+	})
+
+	h.sendNotification(t, terminatingNotification("i-0000000a"))
+	h.processMessages(t)
+
+	if len(h.autoscaling.LifecycleActionHeartbeats) == 0 {
+		t.Fatalf("expected heartbeats to be recorded while draining")
+	}
+	heartbeat := h.autoscaling.LifecycleActionHeartbeats[0]
+	if aws.StringValue(heartbeat.InstanceId) != "i-0000000a" || aws.StringValue(heartbeat.LifecycleActionToken) != "token-i-0000000a" {
+		t.Errorf("unexpected heartbeat: %v", heartbeat)
+	}
+	if len(h.autoscaling.CompletedLifecycleActions) != 1 {
+		t.Errorf("expected the lifecycle action to be completed, got %v", h.autoscaling.CompletedLifecycleActions)
+	}
+}
+
+func TestHeartbeatInterval(t *testing.T) {
+	h := newTestHarness(t)
+
+	// The hook in the harness has the default heartbeat timeout of an hour
+	if interval := h.drainer.heartbeatInterval(terminatingNotification("i-0000000a")); interval != 20*time.Minute {
+		t.Errorf("unexpected heartbeat interval %v, expected a third of the heartbeat timeout", interval)
+	}
+
+	notification := terminatingNotification("i-0000000a")
+	notification.AutoScalingGroupName = "missing.example.com"
+	if interval := h.drainer.heartbeatInterval(notification); interval != defaultHeartbeatInterval {
+		t.Errorf("unexpected heartbeat interval %v for missing group", interval)
+	}
+}
+
+func TestTerminatingInstanceWithoutNode(t *testing.T) {
+	h := newTestHarness(t, buildNode("node-a", "i-0000000a"), buildPod("pod-1", "node-a"))
+
+	h.sendNotification(t, terminatingNotification("i-0000000c"))
+	h.processMessages(t)
+
+	if len(h.evicted) != 0 {
+		t.Errorf("unexpected evictions: %v", h.evicted)
+	}
+	if len(h.autoscaling.CompletedLifecycleActions) != 1 {
+		t.Errorf("expected the lifecycle action to be completed, got %v", h.autoscaling.CompletedLifecycleActions)
+	}
+}
+
+func TestIgnoredNotifications(t *testing.T) {
+	h := newTestHarness(t, buildNode("node-a", "i-0000000a"), buildPod("pod-1", "node-a"))
+
+	h.sendNotification(t, &LifecycleNotification{
+		Event:                TestNotificationEvent,
+		AutoScalingGroupName: groupName,
+	})
+	launching := terminatingNotification("i-0000000a")
+	launching.LifecycleTransition = "autoscaling:EC2_INSTANCE_LAUNCHING"
+	h.sendNotification(t, launching)
+	h.sendMessage(t, "not json")
+
+	h.processMessages(t)
+
+	if len(h.evicted) != 0 {
+		t.Errorf("unexpected evictions: %v", h.evicted)
+	}
+	if len(h.autoscaling.CompletedLifecycleActions) != 0 {
+		t.Errorf("unexpected lifecycle actions: %v", h.autoscaling.CompletedLifecycleActions)
+	}
+}
@@ -140,6 +140,20 @@ func (i *Installation) buildSystemdJob() *nodetasks.Service {
 		buffer.WriteString("\" ")
 	}
 
+	if os.Getenv(vfs.EnvEncryptionPrefixes) != "" {
+		buffer.WriteString("\"" + vfs.EnvEncryptionPrefixes + "=")
+		buffer.WriteString(os.Getenv(vfs.EnvEncryptionPrefixes))
+		buffer.WriteString("\" ")
+		buffer.WriteString("\"" + vfs.EnvEncryptionKeyFile + "=")
+		buffer.WriteString(os.Getenv(vfs.EnvEncryptionKeyFile))
+		buffer.WriteString("\" ")
+		if os.Getenv(vfs.EnvEncryptionAllowUnencrypted) != "" {
+			buffer.WriteString("\"" + vfs.EnvEncryptionAllowUnencrypted + "=")
+			buffer.WriteString(os.Getenv(vfs.EnvEncryptionAllowUnencrypted))
+			buffer.WriteString("\" ")
+		}
+	}
+
 	if os.Getenv("DIGITALOCEAN_ACCESS_TOKEN") != "" {
 		buffer.WriteString("\"DIGITALOCEAN_ACCESS_TOKEN=")
 		buffer.WriteString(os.Getenv("DIGITALOCEAN_ACCESS_TOKEN"))
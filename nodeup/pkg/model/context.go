@@ -151,7 +151,7 @@ func (c *NodeupModelContext) CNIConfDir() string {
 
 // BuildPKIKubeconfig generates a kubeconfig
 func (c *NodeupModelContext) BuildPKIKubeconfig(name string) (string, error) {
-	ca, err := c.FindCert(fi.CertificateId_CA)
+	ca, err := c.FindCertificatePool(fi.CertificateId_CA)
 	if err != nil {
 		return "", err
 	}
@@ -381,6 +381,33 @@ func (c *NodeupModelContext) BuildCertificateTask(ctx *fi.ModelBuilderContext, n
 	return nil
 }
 
+// BuildCertificatePoolTask writes all the certificates in the named keyset to a file, so that during a
+// rotation both the old and the new CA are trusted
+func (c *NodeupModelContext) BuildCertificatePoolTask(ctx *fi.ModelBuilderContext, name, filename string) error {
+	pool, err := c.KeyStore.FindCertificatePool(name)
+	if err != nil {
+		return err
+	}
+
+	if pool == nil || pool.Primary == nil {
+		return fmt.Errorf("certificate %q not found", name)
+	}
+
+	serialized, err := pool.AsString()
+	if err != nil {
+		return err
+	}
+
+	ctx.AddTask(&nodetasks.File{
+		Path:     filepath.Join(c.PathSrvKubernetes(), filename),
+		Contents: fi.NewStringResource(serialized),
+		Type:     nodetasks.FileType_File,
+		Mode:     s("0600"),
+	})
+
+	return nil
+}
+
 // BuildPrivateKeyTask is responsible for build a certificate request task
 func (c *NodeupModelContext) BuildPrivateKeyTask(ctx *fi.ModelBuilderContext, name, filename string) error {
 	cert, err := c.KeyStore.FindPrivateKey(name)
@@ -478,6 +505,23 @@ func (c *NodeupModelContext) FindCert(name string) ([]byte, error) {
 	return cert.AsBytes()
 }
 
+// FindCertificatePool is a helper method to retrieve all the certificates in a keyset from the store
+func (c *NodeupModelContext) FindCertificatePool(name string) ([]byte, error) {
+	pool, err := c.KeyStore.FindCertificatePool(name)
+	if err != nil {
+		return []byte{}, fmt.Errorf("error fetching certificate pool: %v from keystore: %v", name, err)
+	}
+	if pool == nil || pool.Primary == nil {
+		return []byte{}, fmt.Errorf("unable to found certificate: %s", name)
+	}
+
+	serialized, err := pool.AsString()
+	if err != nil {
+		return []byte{}, err
+	}
+	return []byte(serialized), nil
+}
+
 // FindPrivateKey is a helper method to retrieving a private key from the store
 func (c *NodeupModelContext) FindPrivateKey(name string) ([]byte, error) {
 	key, err := c.KeyStore.FindPrivateKey(name)
@@ -32,6 +32,7 @@ import (
 	"k8s.io/kops/pkg/systemd"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/nodeup/nodetasks"
+	"k8s.io/kops/util/pkg/vfs"
 
 	"github.com/blang/semver"
 	"github.com/golang/glog"
@@ -410,6 +411,23 @@ func (t *ProtokubeBuilder) ProtokubeEnvironmentVariables() string {
 		buffer.WriteString(" ")
 	}
 
+	// The host filesystem is mounted at /rootfs, so that is where protokube finds the encryption key
+	if os.Getenv(vfs.EnvEncryptionPrefixes) != "" {
+		buffer.WriteString(" ")
+		buffer.WriteString("-e '" + vfs.EnvEncryptionPrefixes + "=")
+		buffer.WriteString(os.Getenv(vfs.EnvEncryptionPrefixes))
+		buffer.WriteString("'")
+		buffer.WriteString(" -e '" + vfs.EnvEncryptionKeyFile + "=/rootfs")
+		buffer.WriteString(os.Getenv(vfs.EnvEncryptionKeyFile))
+		buffer.WriteString("'")
+		if os.Getenv(vfs.EnvEncryptionAllowUnencrypted) != "" {
+			buffer.WriteString(" -e '" + vfs.EnvEncryptionAllowUnencrypted + "=")
+			buffer.WriteString(os.Getenv(vfs.EnvEncryptionAllowUnencrypted))
+			buffer.WriteString("'")
+		}
+		buffer.WriteString(" ")
+	}
+
 	if kops.CloudProviderID(t.Cluster.Spec.CloudProvider) == kops.CloudProviderDO && os.Getenv("DIGITALOCEAN_ACCESS_TOKEN") != "" {
 		buffer.WriteString(" ")
 		buffer.WriteString("-e 'DIGITALOCEAN_ACCESS_TOKEN=")
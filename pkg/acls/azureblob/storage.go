@@ -0,0 +1,88 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azureblob
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+	"k8s.io/kops/pkg/acls"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/values"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+// azureBlobPublicAclStrategy is the AclStrategy for blobs written to an Azure file repository, which must be readable
+// without credentials.  The blobs are only readable if the container allows anonymous access; the AzureBlobACL makes
+// the write fail if it does not.
+type azureBlobPublicAclStrategy struct {
+}
+
+var _ acls.ACLStrategy = &azureBlobPublicAclStrategy{}
+
+// GetACL returns a public read AzureBlobACL for blobs in the container of the assets FileRepository.
+// Blobs in the state store are never made public.
+func (s *azureBlobPublicAclStrategy) GetACL(p vfs.Path, cluster *kops.Cluster) (vfs.ACL, error) {
+	if cluster.Spec.Assets == nil || cluster.Spec.Assets.FileRepository == nil {
+		return nil, nil
+	}
+
+	blobPath, ok := vfs.UnwrapEncryption(p).(*vfs.AzureBlobPath)
+	if !ok {
+		return nil, nil
+	}
+
+	fileRepository := values.StringValue(cluster.Spec.Assets.FileRepository)
+	repositoryPath, err := vfs.AzureBlobVFSPath(fileRepository)
+	if err != nil {
+		glog.V(8).Infof("file repository %q is not in azure blob storage: %v", fileRepository, err)
+		return nil, nil
+	}
+	repository, err := vfs.Context.BuildVfsPath(repositoryPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse file repository %q: %v", fileRepository, err)
+	}
+	repositoryBlobPath := repository.(*vfs.AzureBlobPath)
+
+	if blobPath.Container() != repositoryBlobPath.Container() {
+		glog.V(8).Infof("path %q is not inside the file repository %q, not setting public read acl", p, fileRepository)
+		return nil, nil
+	}
+
+	// We do NOT make the state store public, even if it shares the container with the file repository
+	if cluster.Spec.ConfigStore != "" {
+		configStore, err := vfs.Context.BuildVfsPath(cluster.Spec.ConfigStore)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse config store %q: %v", cluster.Spec.ConfigStore, err)
+		}
+		if configBlobPath, ok := configStore.(*vfs.AzureBlobPath); ok && configBlobPath.Container() == blobPath.Container() {
+			if configBlobPath.Key() == "" || blobPath.Key() == configBlobPath.Key() || strings.HasPrefix(blobPath.Key(), configBlobPath.Key()+"/") {
+				glog.V(8).Infof("path %q is inside of config store %q, not setting public read acl", p, cluster.Spec.ConfigStore)
+				return nil, nil
+			}
+		}
+	}
+
+	return &vfs.AzureBlobACL{
+		PublicRead: true,
+	}, nil
+}
+
+func Register() {
+	acls.RegisterPlugin("k8s.io/kops/acl/azureblob", &azureBlobPublicAclStrategy{})
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package azureblob
+
+import (
+	"testing"
+
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/values"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+func Test_Strategy(t *testing.T) {
+	grid := []struct {
+		Path           string
+		ConfigStore    string
+		FileRepository string
+		ExpectPublic   bool
+	}{
+		{
+			Path:           "azureblob://assets/kubernetes/kubectl",
+			ConfigStore:    "azureblob://state/cluster.example.com",
+			FileRepository: "https://account.blob.core.windows.net/assets/kubernetes",
+			ExpectPublic:   true,
+		},
+		{
+			// Outside the file repository container
+			Path:           "azureblob://other/kubernetes/kubectl",
+			ConfigStore:    "azureblob://state/cluster.example.com",
+			FileRepository: "https://account.blob.core.windows.net/assets/kubernetes",
+		},
+		{
+			// Inside the state store
+			Path:           "azureblob://assets/cluster.example.com/config",
+			ConfigStore:    "azureblob://assets/cluster.example.com",
+			FileRepository: "https://account.blob.core.windows.net/assets/kubernetes",
+		},
+		{
+			// Not an azure file repository
+			Path:           "azureblob://assets/kubernetes/kubectl",
+			ConfigStore:    "azureblob://state/cluster.example.com",
+			FileRepository: "https://s3.amazonaws.com/assets",
+		},
+		{
+			// Not an azure path
+			Path:           "s3://assets/kubernetes/kubectl",
+			ConfigStore:    "azureblob://state/cluster.example.com",
+			FileRepository: "https://account.blob.core.windows.net/assets/kubernetes",
+		},
+	}
+	for _, g := range grid {
+		p, err := vfs.Context.BuildVfsPath(g.Path)
+		if err != nil {
+			t.Fatalf("unable to create path %q: %v", g.Path, err)
+		}
+
+		cluster := &kops.Cluster{
+			Spec: kops.ClusterSpec{
+				ConfigStore: g.ConfigStore,
+				Assets: &kops.Assets{
+					FileRepository: values.String(g.FileRepository),
+				},
+			},
+		}
+
+		s := &azureBlobPublicAclStrategy{}
+		acl, err := s.GetACL(p, cluster)
+		if err != nil {
+			t.Fatalf("error getting ACL for %q: %v", g.Path, err)
+		}
+		if !g.ExpectPublic {
+			if acl != nil {
+				t.Errorf("expected no ACL for %q, got %v", g.Path, acl)
+			}
+			continue
+		}
+		azureACL, ok := acl.(*vfs.AzureBlobACL)
+		if !ok || !azureACL.PublicRead {
+			t.Errorf("expected public read ACL for %q, got %v", g.Path, acl)
+		}
+	}
+}
@@ -38,7 +38,7 @@ func (s *gcsAclStrategy) GetACL(p vfs.Path, cluster *kops.Cluster) (vfs.ACL, err
 	if kops.CloudProviderID(cluster.Spec.CloudProvider) != kops.CloudProviderGCE {
 		return nil, nil
 	}
-	gcsPath, ok := p.(*vfs.GSPath)
+	gcsPath, ok := vfs.UnwrapEncryption(p).(*vfs.GSPath)
 	if !ok {
 		return nil, nil
 	}
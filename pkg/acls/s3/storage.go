@@ -44,7 +44,7 @@ func (s *s3PublicAclStrategy) GetACL(p vfs.Path, cluster *kops.Cluster) (vfs.ACL
 		return nil, nil
 	}
 
-	s3Path, ok := p.(*vfs.S3Path)
+	s3Path, ok := vfs.UnwrapEncryption(p).(*vfs.S3Path)
 	if !ok {
 		return nil, nil
 	}
@@ -69,4 +69,40 @@ type KeysetSpec struct {
 
 	// Keys is the set of keys that make up the keyset
 	Keys []KeysetItem `json:"keys,omitempty"`
+
+	// PrimaryId is the id of the key that is used to sign, when it is not the newest key, which is the default.
+	// It is set while a new key is being rotated in.
+	PrimaryId string `json:"primaryId,omitempty"`
+
+	// Rotation records the progress of a staged rotation of the keyset, if one is in progress
+	Rotation *KeysetRotation `json:"rotation,omitempty"`
+}
+
+// KeysetRotationPhase is a step in the staged rotation of a keyset
+type KeysetRotationPhase string
+
+const (
+	// KeysetRotationPhaseTrusting means a new key has been added, and is trusted alongside the old key, which still signs
+	KeysetRotationPhaseTrusting KeysetRotationPhase = "Trusting"
+	// KeysetRotationPhaseSigning means the new key signs, and the certificates it signs have been reissued;
+	// the old key is still trusted until the rotation is finished
+	KeysetRotationPhaseSigning KeysetRotationPhase = "Signing"
+)
+
+// KeysetRotation is the state of a staged rotation of a keyset
+type KeysetRotation struct {
+	// Phase is the step the rotation has reached
+	Phase KeysetRotationPhase `json:"phase,omitempty"`
+
+	// NewId is the id of the key being rotated in
+	NewId string `json:"newId,omitempty"`
+
+	// OldIds are the ids of the keys being rotated out
+	OldIds []string `json:"oldIds,omitempty"`
+
+	// TrustingSince is when the new key was added; instances started before then do not trust it
+	TrustingSince *metav1.Time `json:"trustingSince,omitempty"`
+
+	// SigningSince is when the new key was made primary; instances started before then may use certificates signed by the old key
+	SigningSince *metav1.Time `json:"signingSince,omitempty"`
 }
@@ -104,6 +104,102 @@ type InstanceGroupSpec struct {
 	IAM *IAMProfileSpec `json:"iam,omitempty"`
 	// SecurityGroupOverride overrides the default security group created by Kops for this IG (AWS only).
 	SecurityGroupOverride *string `json:"securityGroupOverride,omitempty"`
+	// RollingUpdate defines the rolling-update behavior, overriding the cluster-wide defaults
+	RollingUpdate *RollingUpdate `json:"rollingUpdate,omitempty"`
+	// RollingUpdateHooks is a list of hooks run around the replacement of each instance during a rolling update
+	RollingUpdateHooks []RollingUpdateHook `json:"rollingUpdateHooks,omitempty"`
+	// MixedInstancesPolicy runs the group with a mix of instance types and of on-demand and spot instances (AWS only)
+	MixedInstancesPolicy *MixedInstancesPolicySpec `json:"mixedInstancesPolicy,omitempty"`
+	// LifecycleHooks pause instances of the autoscaling group as they launch or terminate (AWS only)
+	LifecycleHooks []LifecycleHookSpec `json:"lifecycleHooks,omitempty"`
+}
+
+// MixedInstancesPolicySpec defines the instance types and purchase options of an AWS autoscaling group
+type MixedInstancesPolicySpec struct {
+	// Instances is the list of instance types the group can launch, in order of preference for on-demand instances
+	Instances []string `json:"instances,omitempty"`
+	// OnDemandBase is the number of instances that are always on-demand, defaults to 0
+	OnDemandBase *int64 `json:"onDemandBase,omitempty"`
+	// OnDemandAboveBase is the percentage of instances above OnDemandBase that are on-demand, defaults to 100
+	OnDemandAboveBase *int64 `json:"onDemandAboveBase,omitempty"`
+	// SpotAllocationStrategy is how spot instances are spread across the instance types, defaults to lowest-price
+	SpotAllocationStrategy *string `json:"spotAllocationStrategy,omitempty"`
+}
+
+// LifecycleTransition is the autoscaling group lifecycle transition paused by a LifecycleHookSpec
+type LifecycleTransition string
+
+const (
+	// LifecycleTransitionLaunching pauses instances as they launch
+	LifecycleTransitionLaunching LifecycleTransition = "autoscaling:EC2_INSTANCE_LAUNCHING"
+	// LifecycleTransitionTerminating pauses instances as they terminate, while their node is drained
+	LifecycleTransitionTerminating LifecycleTransition = "autoscaling:EC2_INSTANCE_TERMINATING"
+)
+
+// LifecycleHookSpec defines an AWS autoscaling group lifecycle hook
+type LifecycleHookSpec struct {
+	// Name is the name of the hook, unique within the instance group
+	Name string `json:"name,omitempty"`
+	// Transition is the lifecycle transition the hook pauses
+	Transition LifecycleTransition `json:"transition,omitempty"`
+	// HeartbeatTimeout is the number of seconds an instance stays paused before DefaultResult is applied, defaults to 3600
+	HeartbeatTimeout *int64 `json:"heartbeatTimeout,omitempty"`
+	// DefaultResult is the action taken when the hook times out, either CONTINUE or ABANDON; defaults to ABANDON
+	DefaultResult *string `json:"defaultResult,omitempty"`
+}
+
+// RollingUpdateHookEvent is a point in the replacement of an instance at which a RollingUpdateHook can run
+type RollingUpdateHookEvent string
+
+const (
+	// RollingUpdateHookBeforeDrain runs before the instance's node is drained
+	RollingUpdateHookBeforeDrain RollingUpdateHookEvent = "BeforeDrain"
+	// RollingUpdateHookAfterDrain runs after the instance's node has been drained
+	RollingUpdateHookAfterDrain RollingUpdateHookEvent = "AfterDrain"
+	// RollingUpdateHookAfterTerminate runs after the instance has been terminated
+	RollingUpdateHookAfterTerminate RollingUpdateHookEvent = "AfterTerminate"
+	// RollingUpdateHookAfterValidate runs after the cluster has validated following the instance's replacement
+	RollingUpdateHookAfterValidate RollingUpdateHookEvent = "AfterValidate"
+)
+
+// RollingUpdateHookFailurePolicy says what happens to a rolling update when a hook fails
+type RollingUpdateHookFailurePolicy string
+
+const (
+	// RollingUpdateHookFailurePolicyAbort stops the rolling update when the hook fails
+	RollingUpdateHookFailurePolicyAbort RollingUpdateHookFailurePolicy = "Abort"
+	// RollingUpdateHookFailurePolicyContinue logs the failure and carries on with the rolling update
+	RollingUpdateHookFailurePolicyContinue RollingUpdateHookFailurePolicy = "Continue"
+)
+
+// RollingUpdateHook is custom logic run by kops rolling-update around the replacement of each instance in the group
+type RollingUpdateHook struct {
+	// Name identifies the hook in logs
+	Name string `json:"name,omitempty"`
+	// Events is the list of events at which the hook runs: BeforeDrain, AfterDrain, AfterTerminate or AfterValidate
+	Events []RollingUpdateHookEvent `json:"events,omitempty"`
+	// Exec runs a command on the machine running kops
+	Exec *RollingUpdateExecHook `json:"exec,omitempty"`
+	// Webhook sends a POST request to a URL
+	Webhook *RollingUpdateWebhook `json:"webhook,omitempty"`
+	// Timeout is the maximum time the hook may run for, defaults to 5 minutes
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// FailurePolicy is Abort (the default) to stop the rolling update if the hook fails, or Continue to carry on
+	FailurePolicy RollingUpdateHookFailurePolicy `json:"failurePolicy,omitempty"`
+}
+
+// RollingUpdateExecHook runs a command; details of the instance are passed in KOPS_ environment variables
+type RollingUpdateExecHook struct {
+	// Command is the command and its arguments
+	Command []string `json:"command,omitempty"`
+}
+
+// RollingUpdateWebhook posts the details of the instance as JSON to a URL; any response other than 2xx is a failure
+type RollingUpdateWebhook struct {
+	// URL is the http or https URL to post to
+	URL string `json:"url,omitempty"`
+	// Headers are additional HTTP headers to send
+	Headers map[string]string `json:"headers,omitempty"`
 }
 
 // IAMProfileSpec is the AWS IAM Profile to attach to instances in this instance
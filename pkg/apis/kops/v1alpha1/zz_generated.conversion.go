@@ -70,6 +70,10 @@ func RegisterConversions(scheme *runtime.Scheme) error {
 		Convert_kops_ClusterList_To_v1alpha1_ClusterList,
 		Convert_v1alpha1_ClusterSpec_To_kops_ClusterSpec,
 		Convert_kops_ClusterSpec_To_v1alpha1_ClusterSpec,
+		Convert_v1alpha1_ClusterValidationCheck_To_kops_ClusterValidationCheck,
+		Convert_kops_ClusterValidationCheck_To_v1alpha1_ClusterValidationCheck,
+		Convert_v1alpha1_ClusterValidationSpec_To_kops_ClusterValidationSpec,
+		Convert_kops_ClusterValidationSpec_To_v1alpha1_ClusterValidationSpec,
 		Convert_v1alpha1_DNSAccessSpec_To_kops_DNSAccessSpec,
 		Convert_kops_DNSAccessSpec_To_v1alpha1_DNSAccessSpec,
 		Convert_v1alpha1_DNSSpec_To_kops_DNSSpec,
@@ -132,12 +136,16 @@ func RegisterConversions(scheme *runtime.Scheme) error {
 		Convert_kops_KuberouterNetworkingSpec_To_v1alpha1_KuberouterNetworkingSpec,
 		Convert_v1alpha1_LeaderElectionConfiguration_To_kops_LeaderElectionConfiguration,
 		Convert_kops_LeaderElectionConfiguration_To_v1alpha1_LeaderElectionConfiguration,
+		Convert_v1alpha1_LifecycleHookSpec_To_kops_LifecycleHookSpec,
+		Convert_kops_LifecycleHookSpec_To_v1alpha1_LifecycleHookSpec,
 		Convert_v1alpha1_LoadBalancer_To_kops_LoadBalancer,
 		Convert_kops_LoadBalancer_To_v1alpha1_LoadBalancer,
 		Convert_v1alpha1_LoadBalancerAccessSpec_To_kops_LoadBalancerAccessSpec,
 		Convert_kops_LoadBalancerAccessSpec_To_v1alpha1_LoadBalancerAccessSpec,
 		Convert_v1alpha1_LyftVPCNetworkingSpec_To_kops_LyftVPCNetworkingSpec,
 		Convert_kops_LyftVPCNetworkingSpec_To_v1alpha1_LyftVPCNetworkingSpec,
+		Convert_v1alpha1_MixedInstancesPolicySpec_To_kops_MixedInstancesPolicySpec,
+		Convert_kops_MixedInstancesPolicySpec_To_v1alpha1_MixedInstancesPolicySpec,
 		Convert_v1alpha1_NetworkingSpec_To_kops_NetworkingSpec,
 		Convert_kops_NetworkingSpec_To_v1alpha1_NetworkingSpec,
 		Convert_v1alpha1_NodeAuthorizationSpec_To_kops_NodeAuthorizationSpec,
@@ -146,6 +154,16 @@ func RegisterConversions(scheme *runtime.Scheme) error {
 		Convert_kops_NodeAuthorizerSpec_To_v1alpha1_NodeAuthorizerSpec,
 		Convert_v1alpha1_RBACAuthorizationSpec_To_kops_RBACAuthorizationSpec,
 		Convert_kops_RBACAuthorizationSpec_To_v1alpha1_RBACAuthorizationSpec,
+		Convert_v1alpha1_ReadyReplicasCheck_To_kops_ReadyReplicasCheck,
+		Convert_kops_ReadyReplicasCheck_To_v1alpha1_ReadyReplicasCheck,
+		Convert_v1alpha1_RollingUpdate_To_kops_RollingUpdate,
+		Convert_kops_RollingUpdate_To_v1alpha1_RollingUpdate,
+		Convert_v1alpha1_RollingUpdateExecHook_To_kops_RollingUpdateExecHook,
+		Convert_kops_RollingUpdateExecHook_To_v1alpha1_RollingUpdateExecHook,
+		Convert_v1alpha1_RollingUpdateHook_To_kops_RollingUpdateHook,
+		Convert_kops_RollingUpdateHook_To_v1alpha1_RollingUpdateHook,
+		Convert_v1alpha1_RollingUpdateWebhook_To_kops_RollingUpdateWebhook,
+		Convert_kops_RollingUpdateWebhook_To_v1alpha1_RollingUpdateWebhook,
 		Convert_v1alpha1_RomanaNetworkingSpec_To_kops_RomanaNetworkingSpec,
 		Convert_kops_RomanaNetworkingSpec_To_v1alpha1_RomanaNetworkingSpec,
 		Convert_v1alpha1_SSHCredential_To_kops_SSHCredential,
@@ -1080,6 +1098,24 @@ func autoConvert_v1alpha1_ClusterSpec_To_kops_ClusterSpec(in *ClusterSpec, out *
 	} else {
 		out.Target = nil
 	}
+	if in.RollingUpdate != nil {
+		in, out := &in.RollingUpdate, &out.RollingUpdate
+		*out = new(kops.RollingUpdate)
+		if err := Convert_v1alpha1_RollingUpdate_To_kops_RollingUpdate(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.RollingUpdate = nil
+	}
+	if in.Validation != nil {
+		in, out := &in.Validation, &out.Validation
+		*out = new(kops.ClusterValidationSpec)
+		if err := Convert_v1alpha1_ClusterValidationSpec_To_kops_ClusterValidationSpec(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Validation = nil
+	}
 	return nil
 }
 
@@ -1347,9 +1383,107 @@ func autoConvert_kops_ClusterSpec_To_v1alpha1_ClusterSpec(in *kops.ClusterSpec,
 	} else {
 		out.Target = nil
 	}
+	if in.RollingUpdate != nil {
+		in, out := &in.RollingUpdate, &out.RollingUpdate
+		*out = new(RollingUpdate)
+		if err := Convert_kops_RollingUpdate_To_v1alpha1_RollingUpdate(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.RollingUpdate = nil
+	}
+	if in.Validation != nil {
+		in, out := &in.Validation, &out.Validation
+		*out = new(ClusterValidationSpec)
+		if err := Convert_kops_ClusterValidationSpec_To_v1alpha1_ClusterValidationSpec(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Validation = nil
+	}
+	return nil
+}
+
+func autoConvert_v1alpha1_ClusterValidationCheck_To_kops_ClusterValidationCheck(in *ClusterValidationCheck, out *kops.ClusterValidationCheck, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Severity = kops.ValidationSeverity(in.Severity)
+	if in.ReadyReplicas != nil {
+		in, out := &in.ReadyReplicas, &out.ReadyReplicas
+		*out = new(kops.ReadyReplicasCheck)
+		if err := Convert_v1alpha1_ReadyReplicasCheck_To_kops_ReadyReplicasCheck(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.ReadyReplicas = nil
+	}
+	return nil
+}
+
+// Convert_v1alpha1_ClusterValidationCheck_To_kops_ClusterValidationCheck is an autogenerated conversion function.
+func Convert_v1alpha1_ClusterValidationCheck_To_kops_ClusterValidationCheck(in *ClusterValidationCheck, out *kops.ClusterValidationCheck, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ClusterValidationCheck_To_kops_ClusterValidationCheck(in, out, s)
+}
+
+func autoConvert_kops_ClusterValidationCheck_To_v1alpha1_ClusterValidationCheck(in *kops.ClusterValidationCheck, out *ClusterValidationCheck, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Severity = ValidationSeverity(in.Severity)
+	if in.ReadyReplicas != nil {
+		in, out := &in.ReadyReplicas, &out.ReadyReplicas
+		*out = new(ReadyReplicasCheck)
+		if err := Convert_kops_ReadyReplicasCheck_To_v1alpha1_ReadyReplicasCheck(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.ReadyReplicas = nil
+	}
+	return nil
+}
+
+// Convert_kops_ClusterValidationCheck_To_v1alpha1_ClusterValidationCheck is an autogenerated conversion function.
+func Convert_kops_ClusterValidationCheck_To_v1alpha1_ClusterValidationCheck(in *kops.ClusterValidationCheck, out *ClusterValidationCheck, s conversion.Scope) error {
+	return autoConvert_kops_ClusterValidationCheck_To_v1alpha1_ClusterValidationCheck(in, out, s)
+}
+
+func autoConvert_v1alpha1_ClusterValidationSpec_To_kops_ClusterValidationSpec(in *ClusterValidationSpec, out *kops.ClusterValidationSpec, s conversion.Scope) error {
+	if in.Checks != nil {
+		in, out := &in.Checks, &out.Checks
+		*out = make([]kops.ClusterValidationCheck, len(*in))
+		for i := range *in {
+			if err := Convert_v1alpha1_ClusterValidationCheck_To_kops_ClusterValidationCheck(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Checks = nil
+	}
 	return nil
 }
 
+// Convert_v1alpha1_ClusterValidationSpec_To_kops_ClusterValidationSpec is an autogenerated conversion function.
+func Convert_v1alpha1_ClusterValidationSpec_To_kops_ClusterValidationSpec(in *ClusterValidationSpec, out *kops.ClusterValidationSpec, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ClusterValidationSpec_To_kops_ClusterValidationSpec(in, out, s)
+}
+
+func autoConvert_kops_ClusterValidationSpec_To_v1alpha1_ClusterValidationSpec(in *kops.ClusterValidationSpec, out *ClusterValidationSpec, s conversion.Scope) error {
+	if in.Checks != nil {
+		in, out := &in.Checks, &out.Checks
+		*out = make([]ClusterValidationCheck, len(*in))
+		for i := range *in {
+			if err := Convert_kops_ClusterValidationCheck_To_v1alpha1_ClusterValidationCheck(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Checks = nil
+	}
+	return nil
+}
+
+// Convert_kops_ClusterValidationSpec_To_v1alpha1_ClusterValidationSpec is an autogenerated conversion function.
+func Convert_kops_ClusterValidationSpec_To_v1alpha1_ClusterValidationSpec(in *kops.ClusterValidationSpec, out *ClusterValidationSpec, s conversion.Scope) error {
+	return autoConvert_kops_ClusterValidationSpec_To_v1alpha1_ClusterValidationSpec(in, out, s)
+}
+
 func autoConvert_v1alpha1_DNSAccessSpec_To_kops_DNSAccessSpec(in *DNSAccessSpec, out *kops.DNSAccessSpec, s conversion.Scope) error {
 	return nil
 }
@@ -2041,6 +2175,46 @@ func autoConvert_v1alpha1_InstanceGroupSpec_To_kops_InstanceGroupSpec(in *Instan
 		out.IAM = nil
 	}
 	out.SecurityGroupOverride = in.SecurityGroupOverride
+	if in.RollingUpdate != nil {
+		in, out := &in.RollingUpdate, &out.RollingUpdate
+		*out = new(kops.RollingUpdate)
+		if err := Convert_v1alpha1_RollingUpdate_To_kops_RollingUpdate(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.RollingUpdate = nil
+	}
+	if in.RollingUpdateHooks != nil {
+		in, out := &in.RollingUpdateHooks, &out.RollingUpdateHooks
+		*out = make([]kops.RollingUpdateHook, len(*in))
+		for i := range *in {
+			if err := Convert_v1alpha1_RollingUpdateHook_To_kops_RollingUpdateHook(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.RollingUpdateHooks = nil
+	}
+	if in.MixedInstancesPolicy != nil {
+		in, out := &in.MixedInstancesPolicy, &out.MixedInstancesPolicy
+		*out = new(kops.MixedInstancesPolicySpec)
+		if err := Convert_v1alpha1_MixedInstancesPolicySpec_To_kops_MixedInstancesPolicySpec(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.MixedInstancesPolicy = nil
+	}
+	if in.LifecycleHooks != nil {
+		in, out := &in.LifecycleHooks, &out.LifecycleHooks
+		*out = make([]kops.LifecycleHookSpec, len(*in))
+		for i := range *in {
+			if err := Convert_v1alpha1_LifecycleHookSpec_To_kops_LifecycleHookSpec(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.LifecycleHooks = nil
+	}
 	return nil
 }
 
@@ -2128,6 +2302,46 @@ func autoConvert_kops_InstanceGroupSpec_To_v1alpha1_InstanceGroupSpec(in *kops.I
 		out.IAM = nil
 	}
 	out.SecurityGroupOverride = in.SecurityGroupOverride
+	if in.RollingUpdate != nil {
+		in, out := &in.RollingUpdate, &out.RollingUpdate
+		*out = new(RollingUpdate)
+		if err := Convert_kops_RollingUpdate_To_v1alpha1_RollingUpdate(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.RollingUpdate = nil
+	}
+	if in.RollingUpdateHooks != nil {
+		in, out := &in.RollingUpdateHooks, &out.RollingUpdateHooks
+		*out = make([]RollingUpdateHook, len(*in))
+		for i := range *in {
+			if err := Convert_kops_RollingUpdateHook_To_v1alpha1_RollingUpdateHook(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.RollingUpdateHooks = nil
+	}
+	if in.MixedInstancesPolicy != nil {
+		in, out := &in.MixedInstancesPolicy, &out.MixedInstancesPolicy
+		*out = new(MixedInstancesPolicySpec)
+		if err := Convert_kops_MixedInstancesPolicySpec_To_v1alpha1_MixedInstancesPolicySpec(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.MixedInstancesPolicy = nil
+	}
+	if in.LifecycleHooks != nil {
+		in, out := &in.LifecycleHooks, &out.LifecycleHooks
+		*out = make([]LifecycleHookSpec, len(*in))
+		for i := range *in {
+			if err := Convert_kops_LifecycleHookSpec_To_v1alpha1_LifecycleHookSpec(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.LifecycleHooks = nil
+	}
 	return nil
 }
 
@@ -2745,6 +2959,32 @@ func Convert_kops_LeaderElectionConfiguration_To_v1alpha1_LeaderElectionConfigur
 	return autoConvert_kops_LeaderElectionConfiguration_To_v1alpha1_LeaderElectionConfiguration(in, out, s)
 }
 
+func autoConvert_v1alpha1_LifecycleHookSpec_To_kops_LifecycleHookSpec(in *LifecycleHookSpec, out *kops.LifecycleHookSpec, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Transition = kops.LifecycleTransition(in.Transition)
+	out.HeartbeatTimeout = in.HeartbeatTimeout
+	out.DefaultResult = in.DefaultResult
+	return nil
+}
+
+// Convert_v1alpha1_LifecycleHookSpec_To_kops_LifecycleHookSpec is an autogenerated conversion function.
+func Convert_v1alpha1_LifecycleHookSpec_To_kops_LifecycleHookSpec(in *LifecycleHookSpec, out *kops.LifecycleHookSpec, s conversion.Scope) error {
+	return autoConvert_v1alpha1_LifecycleHookSpec_To_kops_LifecycleHookSpec(in, out, s)
+}
+
+func autoConvert_kops_LifecycleHookSpec_To_v1alpha1_LifecycleHookSpec(in *kops.LifecycleHookSpec, out *LifecycleHookSpec, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Transition = LifecycleTransition(in.Transition)
+	out.HeartbeatTimeout = in.HeartbeatTimeout
+	out.DefaultResult = in.DefaultResult
+	return nil
+}
+
+// Convert_kops_LifecycleHookSpec_To_v1alpha1_LifecycleHookSpec is an autogenerated conversion function.
+func Convert_kops_LifecycleHookSpec_To_v1alpha1_LifecycleHookSpec(in *kops.LifecycleHookSpec, out *LifecycleHookSpec, s conversion.Scope) error {
+	return autoConvert_kops_LifecycleHookSpec_To_v1alpha1_LifecycleHookSpec(in, out, s)
+}
+
 func autoConvert_v1alpha1_LoadBalancer_To_kops_LoadBalancer(in *LoadBalancer, out *kops.LoadBalancer, s conversion.Scope) error {
 	out.LoadBalancerName = in.LoadBalancerName
 	out.TargetGroupARN = in.TargetGroupARN
@@ -2774,6 +3014,8 @@ func autoConvert_v1alpha1_LoadBalancerAccessSpec_To_kops_LoadBalancerAccessSpec(
 	out.AdditionalSecurityGroups = in.AdditionalSecurityGroups
 	out.UseForInternalApi = in.UseForInternalApi
 	out.SSLCertificate = in.SSLCertificate
+	out.Class = kops.LoadBalancerClass(in.Class)
+	out.CrossZoneLoadBalancing = in.CrossZoneLoadBalancing
 	return nil
 }
 
@@ -2789,6 +3031,8 @@ func autoConvert_kops_LoadBalancerAccessSpec_To_v1alpha1_LoadBalancerAccessSpec(
 	out.AdditionalSecurityGroups = in.AdditionalSecurityGroups
 	out.UseForInternalApi = in.UseForInternalApi
 	out.SSLCertificate = in.SSLCertificate
+	out.Class = LoadBalancerClass(in.Class)
+	out.CrossZoneLoadBalancing = in.CrossZoneLoadBalancing
 	return nil
 }
 
@@ -2817,6 +3061,32 @@ func Convert_kops_LyftVPCNetworkingSpec_To_v1alpha1_LyftVPCNetworkingSpec(in *ko
 	return autoConvert_kops_LyftVPCNetworkingSpec_To_v1alpha1_LyftVPCNetworkingSpec(in, out, s)
 }
 
+func autoConvert_v1alpha1_MixedInstancesPolicySpec_To_kops_MixedInstancesPolicySpec(in *MixedInstancesPolicySpec, out *kops.MixedInstancesPolicySpec, s conversion.Scope) error {
+	out.Instances = in.Instances
+	out.OnDemandBase = in.OnDemandBase
+	out.OnDemandAboveBase = in.OnDemandAboveBase
+	out.SpotAllocationStrategy = in.SpotAllocationStrategy
+	return nil
+}
+
+// Convert_v1alpha1_MixedInstancesPolicySpec_To_kops_MixedInstancesPolicySpec is an autogenerated conversion function.
+func Convert_v1alpha1_MixedInstancesPolicySpec_To_kops_MixedInstancesPolicySpec(in *MixedInstancesPolicySpec, out *kops.MixedInstancesPolicySpec, s conversion.Scope) error {
+	return autoConvert_v1alpha1_MixedInstancesPolicySpec_To_kops_MixedInstancesPolicySpec(in, out, s)
+}
+
+func autoConvert_kops_MixedInstancesPolicySpec_To_v1alpha1_MixedInstancesPolicySpec(in *kops.MixedInstancesPolicySpec, out *MixedInstancesPolicySpec, s conversion.Scope) error {
+	out.Instances = in.Instances
+	out.OnDemandBase = in.OnDemandBase
+	out.OnDemandAboveBase = in.OnDemandAboveBase
+	out.SpotAllocationStrategy = in.SpotAllocationStrategy
+	return nil
+}
+
+// Convert_kops_MixedInstancesPolicySpec_To_v1alpha1_MixedInstancesPolicySpec is an autogenerated conversion function.
+func Convert_kops_MixedInstancesPolicySpec_To_v1alpha1_MixedInstancesPolicySpec(in *kops.MixedInstancesPolicySpec, out *MixedInstancesPolicySpec, s conversion.Scope) error {
+	return autoConvert_kops_MixedInstancesPolicySpec_To_v1alpha1_MixedInstancesPolicySpec(in, out, s)
+}
+
 func autoConvert_v1alpha1_NetworkingSpec_To_kops_NetworkingSpec(in *NetworkingSpec, out *kops.NetworkingSpec, s conversion.Scope) error {
 	if in.Classic != nil {
 		in, out := &in.Classic, &out.Classic
@@ -3173,6 +3443,190 @@ func Convert_kops_RBACAuthorizationSpec_To_v1alpha1_RBACAuthorizationSpec(in *ko
 	return autoConvert_kops_RBACAuthorizationSpec_To_v1alpha1_RBACAuthorizationSpec(in, out, s)
 }
 
+func autoConvert_v1alpha1_ReadyReplicasCheck_To_kops_ReadyReplicasCheck(in *ReadyReplicasCheck, out *kops.ReadyReplicasCheck, s conversion.Scope) error {
+	out.Kind = in.Kind
+	out.Namespace = in.Namespace
+	out.Name = in.Name
+	out.MinReadyReplicas = in.MinReadyReplicas
+	return nil
+}
+
+// Convert_v1alpha1_ReadyReplicasCheck_To_kops_ReadyReplicasCheck is an autogenerated conversion function.
+func Convert_v1alpha1_ReadyReplicasCheck_To_kops_ReadyReplicasCheck(in *ReadyReplicasCheck, out *kops.ReadyReplicasCheck, s conversion.Scope) error {
+	return autoConvert_v1alpha1_ReadyReplicasCheck_To_kops_ReadyReplicasCheck(in, out, s)
+}
+
+func autoConvert_kops_ReadyReplicasCheck_To_v1alpha1_ReadyReplicasCheck(in *kops.ReadyReplicasCheck, out *ReadyReplicasCheck, s conversion.Scope) error {
+	out.Kind = in.Kind
+	out.Namespace = in.Namespace
+	out.Name = in.Name
+	out.MinReadyReplicas = in.MinReadyReplicas
+	return nil
+}
+
+// Convert_kops_ReadyReplicasCheck_To_v1alpha1_ReadyReplicasCheck is an autogenerated conversion function.
+func Convert_kops_ReadyReplicasCheck_To_v1alpha1_ReadyReplicasCheck(in *kops.ReadyReplicasCheck, out *ReadyReplicasCheck, s conversion.Scope) error {
+	return autoConvert_kops_ReadyReplicasCheck_To_v1alpha1_ReadyReplicasCheck(in, out, s)
+}
+
+func autoConvert_v1alpha1_RollingUpdate_To_kops_RollingUpdate(in *RollingUpdate, out *kops.RollingUpdate, s conversion.Scope) error {
+	out.MaxUnavailable = in.MaxUnavailable
+	out.MaxSurge = in.MaxSurge
+	if in.Strategy != nil {
+		in, out := &in.Strategy, &out.Strategy
+		*out = new(kops.RollingUpdateStrategy)
+		**out = kops.RollingUpdateStrategy(**in)
+	} else {
+		out.Strategy = nil
+	}
+	out.MinHealthyPercentage = in.MinHealthyPercentage
+	return nil
+}
+
+// Convert_v1alpha1_RollingUpdate_To_kops_RollingUpdate is an autogenerated conversion function.
+func Convert_v1alpha1_RollingUpdate_To_kops_RollingUpdate(in *RollingUpdate, out *kops.RollingUpdate, s conversion.Scope) error {
+	return autoConvert_v1alpha1_RollingUpdate_To_kops_RollingUpdate(in, out, s)
+}
+
+func autoConvert_kops_RollingUpdate_To_v1alpha1_RollingUpdate(in *kops.RollingUpdate, out *RollingUpdate, s conversion.Scope) error {
+	out.MaxUnavailable = in.MaxUnavailable
+	out.MaxSurge = in.MaxSurge
+	if in.Strategy != nil {
+		in, out := &in.Strategy, &out.Strategy
+		*out = new(RollingUpdateStrategy)
+		**out = RollingUpdateStrategy(**in)
+	} else {
+		out.Strategy = nil
+	}
+	out.MinHealthyPercentage = in.MinHealthyPercentage
+	return nil
+}
+
+// Convert_kops_RollingUpdate_To_v1alpha1_RollingUpdate is an autogenerated conversion function.
+func Convert_kops_RollingUpdate_To_v1alpha1_RollingUpdate(in *kops.RollingUpdate, out *RollingUpdate, s conversion.Scope) error {
+	return autoConvert_kops_RollingUpdate_To_v1alpha1_RollingUpdate(in, out, s)
+}
+
+func autoConvert_v1alpha1_RollingUpdateExecHook_To_kops_RollingUpdateExecHook(in *RollingUpdateExecHook, out *kops.RollingUpdateExecHook, s conversion.Scope) error {
+	out.Command = in.Command
+	return nil
+}
+
+// Convert_v1alpha1_RollingUpdateExecHook_To_kops_RollingUpdateExecHook is an autogenerated conversion function.
+func Convert_v1alpha1_RollingUpdateExecHook_To_kops_RollingUpdateExecHook(in *RollingUpdateExecHook, out *kops.RollingUpdateExecHook, s conversion.Scope) error {
+	return autoConvert_v1alpha1_RollingUpdateExecHook_To_kops_RollingUpdateExecHook(in, out, s)
+}
+
+func autoConvert_kops_RollingUpdateExecHook_To_v1alpha1_RollingUpdateExecHook(in *kops.RollingUpdateExecHook, out *RollingUpdateExecHook, s conversion.Scope) error {
+	out.Command = in.Command
+	return nil
+}
+
+// Convert_kops_RollingUpdateExecHook_To_v1alpha1_RollingUpdateExecHook is an autogenerated conversion function.
+func Convert_kops_RollingUpdateExecHook_To_v1alpha1_RollingUpdateExecHook(in *kops.RollingUpdateExecHook, out *RollingUpdateExecHook, s conversion.Scope) error {
+	return autoConvert_kops_RollingUpdateExecHook_To_v1alpha1_RollingUpdateExecHook(in, out, s)
+}
+
+func autoConvert_v1alpha1_RollingUpdateHook_To_kops_RollingUpdateHook(in *RollingUpdateHook, out *kops.RollingUpdateHook, s conversion.Scope) error {
+	out.Name = in.Name
+	if in.Events != nil {
+		in, out := &in.Events, &out.Events
+		*out = make([]kops.RollingUpdateHookEvent, len(*in))
+		for i := range *in {
+			(*out)[i] = kops.RollingUpdateHookEvent((*in)[i])
+		}
+	} else {
+		out.Events = nil
+	}
+	if in.Exec != nil {
+		in, out := &in.Exec, &out.Exec
+		*out = new(kops.RollingUpdateExecHook)
+		if err := Convert_v1alpha1_RollingUpdateExecHook_To_kops_RollingUpdateExecHook(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Exec = nil
+	}
+	if in.Webhook != nil {
+		in, out := &in.Webhook, &out.Webhook
+		*out = new(kops.RollingUpdateWebhook)
+		if err := Convert_v1alpha1_RollingUpdateWebhook_To_kops_RollingUpdateWebhook(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Webhook = nil
+	}
+	out.Timeout = in.Timeout
+	out.FailurePolicy = kops.RollingUpdateHookFailurePolicy(in.FailurePolicy)
+	return nil
+}
+
+// Convert_v1alpha1_RollingUpdateHook_To_kops_RollingUpdateHook is an autogenerated conversion function.
+func Convert_v1alpha1_RollingUpdateHook_To_kops_RollingUpdateHook(in *RollingUpdateHook, out *kops.RollingUpdateHook, s conversion.Scope) error {
+	return autoConvert_v1alpha1_RollingUpdateHook_To_kops_RollingUpdateHook(in, out, s)
+}
+
+func autoConvert_kops_RollingUpdateHook_To_v1alpha1_RollingUpdateHook(in *kops.RollingUpdateHook, out *RollingUpdateHook, s conversion.Scope) error {
+	out.Name = in.Name
+	if in.Events != nil {
+		in, out := &in.Events, &out.Events
+		*out = make([]RollingUpdateHookEvent, len(*in))
+		for i := range *in {
+			(*out)[i] = RollingUpdateHookEvent((*in)[i])
+		}
+	} else {
+		out.Events = nil
+	}
+	if in.Exec != nil {
+		in, out := &in.Exec, &out.Exec
+		*out = new(RollingUpdateExecHook)
+		if err := Convert_kops_RollingUpdateExecHook_To_v1alpha1_RollingUpdateExecHook(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Exec = nil
+	}
+	if in.Webhook != nil {
+		in, out := &in.Webhook, &out.Webhook
+		*out = new(RollingUpdateWebhook)
+		if err := Convert_kops_RollingUpdateWebhook_To_v1alpha1_RollingUpdateWebhook(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Webhook = nil
+	}
+	out.Timeout = in.Timeout
+	out.FailurePolicy = RollingUpdateHookFailurePolicy(in.FailurePolicy)
+	return nil
+}
+
+// Convert_kops_RollingUpdateHook_To_v1alpha1_RollingUpdateHook is an autogenerated conversion function.
+func Convert_kops_RollingUpdateHook_To_v1alpha1_RollingUpdateHook(in *kops.RollingUpdateHook, out *RollingUpdateHook, s conversion.Scope) error {
+	return autoConvert_kops_RollingUpdateHook_To_v1alpha1_RollingUpdateHook(in, out, s)
+}
+
+func autoConvert_v1alpha1_RollingUpdateWebhook_To_kops_RollingUpdateWebhook(in *RollingUpdateWebhook, out *kops.RollingUpdateWebhook, s conversion.Scope) error {
+	out.URL = in.URL
+	out.Headers = in.Headers
+	return nil
+}
+
+// Convert_v1alpha1_RollingUpdateWebhook_To_kops_RollingUpdateWebhook is an autogenerated conversion function.
+func Convert_v1alpha1_RollingUpdateWebhook_To_kops_RollingUpdateWebhook(in *RollingUpdateWebhook, out *kops.RollingUpdateWebhook, s conversion.Scope) error {
+	return autoConvert_v1alpha1_RollingUpdateWebhook_To_kops_RollingUpdateWebhook(in, out, s)
+}
+
+func autoConvert_kops_RollingUpdateWebhook_To_v1alpha1_RollingUpdateWebhook(in *kops.RollingUpdateWebhook, out *RollingUpdateWebhook, s conversion.Scope) error {
+	out.URL = in.URL
+	out.Headers = in.Headers
+	return nil
+}
+
+// Convert_kops_RollingUpdateWebhook_To_v1alpha1_RollingUpdateWebhook is an autogenerated conversion function.
+func Convert_kops_RollingUpdateWebhook_To_v1alpha1_RollingUpdateWebhook(in *kops.RollingUpdateWebhook, out *RollingUpdateWebhook, s conversion.Scope) error {
+	return autoConvert_kops_RollingUpdateWebhook_To_v1alpha1_RollingUpdateWebhook(in, out, s)
+}
+
 func autoConvert_v1alpha1_RomanaNetworkingSpec_To_kops_RomanaNetworkingSpec(in *RomanaNetworkingSpec, out *kops.RomanaNetworkingSpec, s conversion.Scope) error {
 	out.DaemonServiceIP = in.DaemonServiceIP
 	out.EtcdServiceIP = in.EtcdServiceIP
@@ -18,6 +18,7 @@ package v1alpha2
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // +genclient
@@ -168,6 +169,10 @@ type ClusterSpec struct {
 	DisableSubnetTags bool `json:"DisableSubnetTags,omitempty"`
 	// Target allows for us to nest extra config for targets such as terraform
 	Target *TargetSpec `json:"target,omitempty"`
+	// RollingUpdate defines the default rolling-update settings for instance groups
+	RollingUpdate *RollingUpdate `json:"rollingUpdate,omitempty"`
+	// Validation configures additional checks run when validating the cluster
+	Validation *ClusterValidationSpec `json:"validation,omitempty"`
 }
 
 // NodeAuthorizationSpec is used to node authorization
@@ -315,6 +320,14 @@ const (
 	LoadBalancerTypeInternal LoadBalancerType = "Internal"
 )
 
+// LoadBalancerClass string describes the class of AWS load balancer (Classic, Network)
+type LoadBalancerClass string
+
+const (
+	LoadBalancerClassClassic LoadBalancerClass = "Classic"
+	LoadBalancerClassNetwork LoadBalancerClass = "Network"
+)
+
 // LoadBalancerAccessSpec provides configuration details related to API LoadBalancer and its access
 type LoadBalancerAccessSpec struct {
 	// Type of load balancer to create may Public or Internal.
@@ -329,6 +342,10 @@ type LoadBalancerAccessSpec struct {
 	UseForInternalApi bool `json:"useForInternalApi,omitempty"`
 	// SSLCertificate allows you to specify the ACM cert to be used the LB
 	SSLCertificate string `json:"sslCertificate,omitempty"`
+	// Class of load balancer to create on AWS may be Classic (the default) or Network.
+	Class LoadBalancerClass `json:"class,omitempty"`
+	// CrossZoneLoadBalancing allows the load balancer to send traffic to masters in every zone.
+	CrossZoneLoadBalancing *bool `json:"crossZoneLoadBalancing,omitempty"`
 }
 
 // KubeDNSConfig defines the kube dns configuration
@@ -483,6 +500,77 @@ func (t *TargetSpec) IsEmpty() bool {
 	return t.Terraform == nil
 }
 
+// RollingUpdate defines how instances are replaced during a rolling update
+type RollingUpdate struct {
+	// MaxUnavailable is the maximum number of instances that can be unavailable during the update.
+	// The value can be an absolute number (for example 5) or a percentage of the instances in the group
+	// at the start of the update (for example 10%). The absolute number is calculated from a percentage
+	// by rounding down. The value cannot be 0 if MaxSurge is 0.
+	// Defaults to 1 if MaxSurge is 0, otherwise defaults to 0.
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+	// MaxSurge is the maximum number of extra instances that can be created during the update.
+	// The value can be an absolute number (for example 5) or a percentage of the instances in the group
+	// at the start of the update (for example 10%). The absolute number is calculated from a percentage
+	// by rounding up. Surge instances are created before any existing instance is drained.
+	// Defaults to 0.
+	MaxSurge *intstr.IntOrString `json:"maxSurge,omitempty"`
+	// Strategy is how the instances of a group are replaced. With Drain, the default, kops drains and terminates
+	// the instances itself. With InstanceRefresh, kops starts an AWS autoscaling instance refresh, which replaces
+	// the instances server-side, and follows its progress. InstanceRefresh is only supported on AWS.
+	Strategy *RollingUpdateStrategy `json:"strategy,omitempty"`
+	// MinHealthyPercentage is the percentage of the group that must remain healthy during an instance refresh.
+	// It is only used by the InstanceRefresh strategy. Defaults to 90.
+	MinHealthyPercentage *int32 `json:"minHealthyPercentage,omitempty"`
+}
+
+// RollingUpdateStrategy is how the instances of a group are replaced during a rolling update
+type RollingUpdateStrategy string
+
+const (
+	// RollingUpdateStrategyDrain has kops drain and terminate the instances, honoring maxSurge and maxUnavailable
+	RollingUpdateStrategyDrain RollingUpdateStrategy = "Drain"
+	// RollingUpdateStrategyInstanceRefresh delegates the replacement of the instances to an AWS autoscaling instance refresh
+	RollingUpdateStrategyInstanceRefresh RollingUpdateStrategy = "InstanceRefresh"
+)
+
+// ValidationSeverity is how serious a validation failure is
+type ValidationSeverity string
+
+const (
+	// ValidationSeverityError is a failure that means the cluster does not validate
+	ValidationSeverityError ValidationSeverity = "Error"
+	// ValidationSeverityWarning is a failure that is reported, but does not stop the cluster validating
+	ValidationSeverityWarning ValidationSeverity = "Warning"
+)
+
+// ClusterValidationSpec configures the checks run by kops validate cluster, in addition to the built-in checks
+type ClusterValidationSpec struct {
+	// Checks is a list of additional checks
+	Checks []ClusterValidationCheck `json:"checks,omitempty"`
+}
+
+// ClusterValidationCheck is an additional check run by kops validate cluster
+type ClusterValidationCheck struct {
+	// Name identifies the check in the validation results
+	Name string `json:"name,omitempty"`
+	// Severity is Error (the default) if the cluster should fail validation when the check fails, or Warning
+	Severity ValidationSeverity `json:"severity,omitempty"`
+	// ReadyReplicas checks that a workload has enough ready replicas
+	ReadyReplicas *ReadyReplicasCheck `json:"readyReplicas,omitempty"`
+}
+
+// ReadyReplicasCheck checks that a Deployment, StatefulSet or DaemonSet has enough ready replicas
+type ReadyReplicasCheck struct {
+	// Kind is the kind of workload: Deployment, StatefulSet or DaemonSet
+	Kind string `json:"kind,omitempty"`
+	// Namespace is the namespace of the workload
+	Namespace string `json:"namespace,omitempty"`
+	// Name is the name of the workload
+	Name string `json:"name,omitempty"`
+	// MinReadyReplicas is the number of replicas that must be ready; defaults to all desired replicas
+	MinReadyReplicas *int32 `json:"minReadyReplicas,omitempty"`
+}
+
 // TerraformSpec allows us to specify terraform config in an extensible way
 type TerraformSpec struct {
 	// ProviderExtraConfig contains key/value pairs to add to the rendered terraform "provider" block
@@ -74,6 +74,10 @@ func RegisterConversions(scheme *runtime.Scheme) error {
 		Convert_kops_ClusterSpec_To_v1alpha2_ClusterSpec,
 		Convert_v1alpha2_ClusterSubnetSpec_To_kops_ClusterSubnetSpec,
 		Convert_kops_ClusterSubnetSpec_To_v1alpha2_ClusterSubnetSpec,
+		Convert_v1alpha2_ClusterValidationCheck_To_kops_ClusterValidationCheck,
+		Convert_kops_ClusterValidationCheck_To_v1alpha2_ClusterValidationCheck,
+		Convert_v1alpha2_ClusterValidationSpec_To_kops_ClusterValidationSpec,
+		Convert_kops_ClusterValidationSpec_To_v1alpha2_ClusterValidationSpec,
 		Convert_v1alpha2_DNSAccessSpec_To_kops_DNSAccessSpec,
 		Convert_kops_DNSAccessSpec_To_v1alpha2_DNSAccessSpec,
 		Convert_v1alpha2_DNSSpec_To_kops_DNSSpec,
@@ -120,6 +124,8 @@ func RegisterConversions(scheme *runtime.Scheme) error {
 		Convert_kops_KeysetItem_To_v1alpha2_KeysetItem,
 		Convert_v1alpha2_KeysetList_To_kops_KeysetList,
 		Convert_kops_KeysetList_To_v1alpha2_KeysetList,
+		Convert_v1alpha2_KeysetRotation_To_kops_KeysetRotation,
+		Convert_kops_KeysetRotation_To_v1alpha2_KeysetRotation,
 		Convert_v1alpha2_KeysetSpec_To_kops_KeysetSpec,
 		Convert_kops_KeysetSpec_To_v1alpha2_KeysetSpec,
 		Convert_v1alpha2_KopeioAuthenticationSpec_To_kops_KopeioAuthenticationSpec,
@@ -144,12 +150,16 @@ func RegisterConversions(scheme *runtime.Scheme) error {
 		Convert_kops_KuberouterNetworkingSpec_To_v1alpha2_KuberouterNetworkingSpec,
 		Convert_v1alpha2_LeaderElectionConfiguration_To_kops_LeaderElectionConfiguration,
 		Convert_kops_LeaderElectionConfiguration_To_v1alpha2_LeaderElectionConfiguration,
+		Convert_v1alpha2_LifecycleHookSpec_To_kops_LifecycleHookSpec,
+		Convert_kops_LifecycleHookSpec_To_v1alpha2_LifecycleHookSpec,
 		Convert_v1alpha2_LoadBalancer_To_kops_LoadBalancer,
 		Convert_kops_LoadBalancer_To_v1alpha2_LoadBalancer,
 		Convert_v1alpha2_LoadBalancerAccessSpec_To_kops_LoadBalancerAccessSpec,
 		Convert_kops_LoadBalancerAccessSpec_To_v1alpha2_LoadBalancerAccessSpec,
 		Convert_v1alpha2_LyftVPCNetworkingSpec_To_kops_LyftVPCNetworkingSpec,
 		Convert_kops_LyftVPCNetworkingSpec_To_v1alpha2_LyftVPCNetworkingSpec,
+		Convert_v1alpha2_MixedInstancesPolicySpec_To_kops_MixedInstancesPolicySpec,
+		Convert_kops_MixedInstancesPolicySpec_To_v1alpha2_MixedInstancesPolicySpec,
 		Convert_v1alpha2_NetworkingSpec_To_kops_NetworkingSpec,
 		Convert_kops_NetworkingSpec_To_v1alpha2_NetworkingSpec,
 		Convert_v1alpha2_NodeAuthorizationSpec_To_kops_NodeAuthorizationSpec,
@@ -158,6 +168,16 @@ func RegisterConversions(scheme *runtime.Scheme) error {
 		Convert_kops_NodeAuthorizerSpec_To_v1alpha2_NodeAuthorizerSpec,
 		Convert_v1alpha2_RBACAuthorizationSpec_To_kops_RBACAuthorizationSpec,
 		Convert_kops_RBACAuthorizationSpec_To_v1alpha2_RBACAuthorizationSpec,
+		Convert_v1alpha2_ReadyReplicasCheck_To_kops_ReadyReplicasCheck,
+		Convert_kops_ReadyReplicasCheck_To_v1alpha2_ReadyReplicasCheck,
+		Convert_v1alpha2_RollingUpdate_To_kops_RollingUpdate,
+		Convert_kops_RollingUpdate_To_v1alpha2_RollingUpdate,
+		Convert_v1alpha2_RollingUpdateExecHook_To_kops_RollingUpdateExecHook,
+		Convert_kops_RollingUpdateExecHook_To_v1alpha2_RollingUpdateExecHook,
+		Convert_v1alpha2_RollingUpdateHook_To_kops_RollingUpdateHook,
+		Convert_kops_RollingUpdateHook_To_v1alpha2_RollingUpdateHook,
+		Convert_v1alpha2_RollingUpdateWebhook_To_kops_RollingUpdateWebhook,
+		Convert_kops_RollingUpdateWebhook_To_v1alpha2_RollingUpdateWebhook,
 		Convert_v1alpha2_RomanaNetworkingSpec_To_kops_RomanaNetworkingSpec,
 		Convert_kops_RomanaNetworkingSpec_To_v1alpha2_RomanaNetworkingSpec,
 		Convert_v1alpha2_SSHCredential_To_kops_SSHCredential,
@@ -1127,6 +1147,24 @@ func autoConvert_v1alpha2_ClusterSpec_To_kops_ClusterSpec(in *ClusterSpec, out *
 	} else {
 		out.Target = nil
 	}
+	if in.RollingUpdate != nil {
+		in, out := &in.RollingUpdate, &out.RollingUpdate
+		*out = new(kops.RollingUpdate)
+		if err := Convert_v1alpha2_RollingUpdate_To_kops_RollingUpdate(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.RollingUpdate = nil
+	}
+	if in.Validation != nil {
+		in, out := &in.Validation, &out.Validation
+		*out = new(kops.ClusterValidationSpec)
+		if err := Convert_v1alpha2_ClusterValidationSpec_To_kops_ClusterValidationSpec(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Validation = nil
+	}
 	return nil
 }
 
@@ -1409,6 +1447,24 @@ func autoConvert_kops_ClusterSpec_To_v1alpha2_ClusterSpec(in *kops.ClusterSpec,
 	} else {
 		out.Target = nil
 	}
+	if in.RollingUpdate != nil {
+		in, out := &in.RollingUpdate, &out.RollingUpdate
+		*out = new(RollingUpdate)
+		if err := Convert_kops_RollingUpdate_To_v1alpha2_RollingUpdate(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.RollingUpdate = nil
+	}
+	if in.Validation != nil {
+		in, out := &in.Validation, &out.Validation
+		*out = new(ClusterValidationSpec)
+		if err := Convert_kops_ClusterValidationSpec_To_v1alpha2_ClusterValidationSpec(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Validation = nil
+	}
 	return nil
 }
 
@@ -1451,6 +1507,86 @@ func Convert_kops_ClusterSubnetSpec_To_v1alpha2_ClusterSubnetSpec(in *kops.Clust
 	return autoConvert_kops_ClusterSubnetSpec_To_v1alpha2_ClusterSubnetSpec(in, out, s)
 }
 
+func autoConvert_v1alpha2_ClusterValidationCheck_To_kops_ClusterValidationCheck(in *ClusterValidationCheck, out *kops.ClusterValidationCheck, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Severity = kops.ValidationSeverity(in.Severity)
+	if in.ReadyReplicas != nil {
+		in, out := &in.ReadyReplicas, &out.ReadyReplicas
+		*out = new(kops.ReadyReplicasCheck)
+		if err := Convert_v1alpha2_ReadyReplicasCheck_To_kops_ReadyReplicasCheck(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.ReadyReplicas = nil
+	}
+	return nil
+}
+
+// Convert_v1alpha2_ClusterValidationCheck_To_kops_ClusterValidationCheck is an autogenerated conversion function.
+func Convert_v1alpha2_ClusterValidationCheck_To_kops_ClusterValidationCheck(in *ClusterValidationCheck, out *kops.ClusterValidationCheck, s conversion.Scope) error {
+	return autoConvert_v1alpha2_ClusterValidationCheck_To_kops_ClusterValidationCheck(in, out, s)
+}
+
+func autoConvert_kops_ClusterValidationCheck_To_v1alpha2_ClusterValidationCheck(in *kops.ClusterValidationCheck, out *ClusterValidationCheck, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Severity = ValidationSeverity(in.Severity)
+	if in.ReadyReplicas != nil {
+		in, out := &in.ReadyReplicas, &out.ReadyReplicas
+		*out = new(ReadyReplicasCheck)
+		if err := Convert_kops_ReadyReplicasCheck_To_v1alpha2_ReadyReplicasCheck(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.ReadyReplicas = nil
+	}
+	return nil
+}
+
+// Convert_kops_ClusterValidationCheck_To_v1alpha2_ClusterValidationCheck is an autogenerated conversion function.
+func Convert_kops_ClusterValidationCheck_To_v1alpha2_ClusterValidationCheck(in *kops.ClusterValidationCheck, out *ClusterValidationCheck, s conversion.Scope) error {
+	return autoConvert_kops_ClusterValidationCheck_To_v1alpha2_ClusterValidationCheck(in, out, s)
+}
+
+func autoConvert_v1alpha2_ClusterValidationSpec_To_kops_ClusterValidationSpec(in *ClusterValidationSpec, out *kops.ClusterValidationSpec, s conversion.Scope) error {
+	if in.Checks != nil {
+		in, out := &in.Checks, &out.Checks
+		*out = make([]kops.ClusterValidationCheck, len(*in))
+		for i := range *in {
+			if err := Convert_v1alpha2_ClusterValidationCheck_To_kops_ClusterValidationCheck(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Checks = nil
+	}
+	return nil
+}
+
+// Convert_v1alpha2_ClusterValidationSpec_To_kops_ClusterValidationSpec is an autogenerated conversion function.
+func Convert_v1alpha2_ClusterValidationSpec_To_kops_ClusterValidationSpec(in *ClusterValidationSpec, out *kops.ClusterValidationSpec, s conversion.Scope) error {
+	return autoConvert_v1alpha2_ClusterValidationSpec_To_kops_ClusterValidationSpec(in, out, s)
+}
+
+func autoConvert_kops_ClusterValidationSpec_To_v1alpha2_ClusterValidationSpec(in *kops.ClusterValidationSpec, out *ClusterValidationSpec, s conversion.Scope) error {
+	if in.Checks != nil {
+		in, out := &in.Checks, &out.Checks
+		*out = make([]ClusterValidationCheck, len(*in))
+		for i := range *in {
+			if err := Convert_kops_ClusterValidationCheck_To_v1alpha2_ClusterValidationCheck(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.Checks = nil
+	}
+	return nil
+}
+
+// Convert_kops_ClusterValidationSpec_To_v1alpha2_ClusterValidationSpec is an autogenerated conversion function.
+func Convert_kops_ClusterValidationSpec_To_v1alpha2_ClusterValidationSpec(in *kops.ClusterValidationSpec, out *ClusterValidationSpec, s conversion.Scope) error {
+	return autoConvert_kops_ClusterValidationSpec_To_v1alpha2_ClusterValidationSpec(in, out, s)
+}
+
 func autoConvert_v1alpha2_DNSAccessSpec_To_kops_DNSAccessSpec(in *DNSAccessSpec, out *kops.DNSAccessSpec, s conversion.Scope) error {
 	return nil
 }
@@ -2153,6 +2289,46 @@ func autoConvert_v1alpha2_InstanceGroupSpec_To_kops_InstanceGroupSpec(in *Instan
 		out.IAM = nil
 	}
 	out.SecurityGroupOverride = in.SecurityGroupOverride
+	if in.RollingUpdate != nil {
+		in, out := &in.RollingUpdate, &out.RollingUpdate
+		*out = new(kops.RollingUpdate)
+		if err := Convert_v1alpha2_RollingUpdate_To_kops_RollingUpdate(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.RollingUpdate = nil
+	}
+	if in.RollingUpdateHooks != nil {
+		in, out := &in.RollingUpdateHooks, &out.RollingUpdateHooks
+		*out = make([]kops.RollingUpdateHook, len(*in))
+		for i := range *in {
+			if err := Convert_v1alpha2_RollingUpdateHook_To_kops_RollingUpdateHook(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.RollingUpdateHooks = nil
+	}
+	if in.MixedInstancesPolicy != nil {
+		in, out := &in.MixedInstancesPolicy, &out.MixedInstancesPolicy
+		*out = new(kops.MixedInstancesPolicySpec)
+		if err := Convert_v1alpha2_MixedInstancesPolicySpec_To_kops_MixedInstancesPolicySpec(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.MixedInstancesPolicy = nil
+	}
+	if in.LifecycleHooks != nil {
+		in, out := &in.LifecycleHooks, &out.LifecycleHooks
+		*out = make([]kops.LifecycleHookSpec, len(*in))
+		for i := range *in {
+			if err := Convert_v1alpha2_LifecycleHookSpec_To_kops_LifecycleHookSpec(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.LifecycleHooks = nil
+	}
 	return nil
 }
 
@@ -2245,6 +2421,46 @@ func autoConvert_kops_InstanceGroupSpec_To_v1alpha2_InstanceGroupSpec(in *kops.I
 		out.IAM = nil
 	}
 	out.SecurityGroupOverride = in.SecurityGroupOverride
+	if in.RollingUpdate != nil {
+		in, out := &in.RollingUpdate, &out.RollingUpdate
+		*out = new(RollingUpdate)
+		if err := Convert_kops_RollingUpdate_To_v1alpha2_RollingUpdate(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.RollingUpdate = nil
+	}
+	if in.RollingUpdateHooks != nil {
+		in, out := &in.RollingUpdateHooks, &out.RollingUpdateHooks
+		*out = make([]RollingUpdateHook, len(*in))
+		for i := range *in {
+			if err := Convert_kops_RollingUpdateHook_To_v1alpha2_RollingUpdateHook(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.RollingUpdateHooks = nil
+	}
+	if in.MixedInstancesPolicy != nil {
+		in, out := &in.MixedInstancesPolicy, &out.MixedInstancesPolicy
+		*out = new(MixedInstancesPolicySpec)
+		if err := Convert_kops_MixedInstancesPolicySpec_To_v1alpha2_MixedInstancesPolicySpec(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.MixedInstancesPolicy = nil
+	}
+	if in.LifecycleHooks != nil {
+		in, out := &in.LifecycleHooks, &out.LifecycleHooks
+		*out = make([]LifecycleHookSpec, len(*in))
+		for i := range *in {
+			if err := Convert_kops_LifecycleHookSpec_To_v1alpha2_LifecycleHookSpec(&(*in)[i], &(*out)[i], s); err != nil {
+				return err
+			}
+		}
+	} else {
+		out.LifecycleHooks = nil
+	}
 	return nil
 }
 
@@ -2353,6 +2569,34 @@ func Convert_kops_KeysetList_To_v1alpha2_KeysetList(in *kops.KeysetList, out *Ke
 	return autoConvert_kops_KeysetList_To_v1alpha2_KeysetList(in, out, s)
 }
 
+func autoConvert_v1alpha2_KeysetRotation_To_kops_KeysetRotation(in *KeysetRotation, out *kops.KeysetRotation, s conversion.Scope) error {
+	out.Phase = kops.KeysetRotationPhase(in.Phase)
+	out.NewId = in.NewId
+	out.OldIds = in.OldIds
+	out.TrustingSince = in.TrustingSince
+	out.SigningSince = in.SigningSince
+	return nil
+}
+
+// Convert_v1alpha2_KeysetRotation_To_kops_KeysetRotation is an autogenerated conversion function.
+func Convert_v1alpha2_KeysetRotation_To_kops_KeysetRotation(in *KeysetRotation, out *kops.KeysetRotation, s conversion.Scope) error {
+	return autoConvert_v1alpha2_KeysetRotation_To_kops_KeysetRotation(in, out, s)
+}
+
+func autoConvert_kops_KeysetRotation_To_v1alpha2_KeysetRotation(in *kops.KeysetRotation, out *KeysetRotation, s conversion.Scope) error {
+	out.Phase = KeysetRotationPhase(in.Phase)
+	out.NewId = in.NewId
+	out.OldIds = in.OldIds
+	out.TrustingSince = in.TrustingSince
+	out.SigningSince = in.SigningSince
+	return nil
+}
+
+// Convert_kops_KeysetRotation_To_v1alpha2_KeysetRotation is an autogenerated conversion function.
+func Convert_kops_KeysetRotation_To_v1alpha2_KeysetRotation(in *kops.KeysetRotation, out *KeysetRotation, s conversion.Scope) error {
+	return autoConvert_kops_KeysetRotation_To_v1alpha2_KeysetRotation(in, out, s)
+}
+
 func autoConvert_v1alpha2_KeysetSpec_To_kops_KeysetSpec(in *KeysetSpec, out *kops.KeysetSpec, s conversion.Scope) error {
 	out.Type = kops.KeysetType(in.Type)
 	if in.Keys != nil {
@@ -2366,6 +2610,16 @@ func autoConvert_v1alpha2_KeysetSpec_To_kops_KeysetSpec(in *KeysetSpec, out *kop
 	} else {
 		out.Keys = nil
 	}
+	out.PrimaryId = in.PrimaryId
+	if in.Rotation != nil {
+		in, out := &in.Rotation, &out.Rotation
+		*out = new(kops.KeysetRotation)
+		if err := Convert_v1alpha2_KeysetRotation_To_kops_KeysetRotation(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Rotation = nil
+	}
 	return nil
 }
 
@@ -2387,6 +2641,16 @@ func autoConvert_kops_KeysetSpec_To_v1alpha2_KeysetSpec(in *kops.KeysetSpec, out
 	} else {
 		out.Keys = nil
 	}
+	out.PrimaryId = in.PrimaryId
+	if in.Rotation != nil {
+		in, out := &in.Rotation, &out.Rotation
+		*out = new(KeysetRotation)
+		if err := Convert_kops_KeysetRotation_To_v1alpha2_KeysetRotation(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Rotation = nil
+	}
 	return nil
 }
 
@@ -3009,6 +3273,32 @@ func Convert_kops_LeaderElectionConfiguration_To_v1alpha2_LeaderElectionConfigur
 	return autoConvert_kops_LeaderElectionConfiguration_To_v1alpha2_LeaderElectionConfiguration(in, out, s)
 }
 
+func autoConvert_v1alpha2_LifecycleHookSpec_To_kops_LifecycleHookSpec(in *LifecycleHookSpec, out *kops.LifecycleHookSpec, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Transition = kops.LifecycleTransition(in.Transition)
+	out.HeartbeatTimeout = in.HeartbeatTimeout
+	out.DefaultResult = in.DefaultResult
+	return nil
+}
+
+// Convert_v1alpha2_LifecycleHookSpec_To_kops_LifecycleHookSpec is an autogenerated conversion function.
+func Convert_v1alpha2_LifecycleHookSpec_To_kops_LifecycleHookSpec(in *LifecycleHookSpec, out *kops.LifecycleHookSpec, s conversion.Scope) error {
+	return autoConvert_v1alpha2_LifecycleHookSpec_To_kops_LifecycleHookSpec(in, out, s)
+}
+
+func autoConvert_kops_LifecycleHookSpec_To_v1alpha2_LifecycleHookSpec(in *kops.LifecycleHookSpec, out *LifecycleHookSpec, s conversion.Scope) error {
+	out.Name = in.Name
+	out.Transition = LifecycleTransition(in.Transition)
+	out.HeartbeatTimeout = in.HeartbeatTimeout
+	out.DefaultResult = in.DefaultResult
+	return nil
+}
+
+// Convert_kops_LifecycleHookSpec_To_v1alpha2_LifecycleHookSpec is an autogenerated conversion function.
+func Convert_kops_LifecycleHookSpec_To_v1alpha2_LifecycleHookSpec(in *kops.LifecycleHookSpec, out *LifecycleHookSpec, s conversion.Scope) error {
+	return autoConvert_kops_LifecycleHookSpec_To_v1alpha2_LifecycleHookSpec(in, out, s)
+}
+
 func autoConvert_v1alpha2_LoadBalancer_To_kops_LoadBalancer(in *LoadBalancer, out *kops.LoadBalancer, s conversion.Scope) error {
 	out.LoadBalancerName = in.LoadBalancerName
 	out.TargetGroupARN = in.TargetGroupARN
@@ -3038,6 +3328,8 @@ func autoConvert_v1alpha2_LoadBalancerAccessSpec_To_kops_LoadBalancerAccessSpec(
 	out.AdditionalSecurityGroups = in.AdditionalSecurityGroups
 	out.UseForInternalApi = in.UseForInternalApi
 	out.SSLCertificate = in.SSLCertificate
+	out.Class = kops.LoadBalancerClass(in.Class)
+	out.CrossZoneLoadBalancing = in.CrossZoneLoadBalancing
 	return nil
 }
 
@@ -3053,6 +3345,8 @@ func autoConvert_kops_LoadBalancerAccessSpec_To_v1alpha2_LoadBalancerAccessSpec(
 	out.AdditionalSecurityGroups = in.AdditionalSecurityGroups
 	out.UseForInternalApi = in.UseForInternalApi
 	out.SSLCertificate = in.SSLCertificate
+	out.Class = LoadBalancerClass(in.Class)
+	out.CrossZoneLoadBalancing = in.CrossZoneLoadBalancing
 	return nil
 }
 
@@ -3081,6 +3375,32 @@ func Convert_kops_LyftVPCNetworkingSpec_To_v1alpha2_LyftVPCNetworkingSpec(in *ko
 	return autoConvert_kops_LyftVPCNetworkingSpec_To_v1alpha2_LyftVPCNetworkingSpec(in, out, s)
 }
 
+func autoConvert_v1alpha2_MixedInstancesPolicySpec_To_kops_MixedInstancesPolicySpec(in *MixedInstancesPolicySpec, out *kops.MixedInstancesPolicySpec, s conversion.Scope) error {
+	out.Instances = in.Instances
+	out.OnDemandBase = in.OnDemandBase
+	out.OnDemandAboveBase = in.OnDemandAboveBase
+	out.SpotAllocationStrategy = in.SpotAllocationStrategy
+	return nil
+}
+
+// Convert_v1alpha2_MixedInstancesPolicySpec_To_kops_MixedInstancesPolicySpec is an autogenerated conversion function.
+func Convert_v1alpha2_MixedInstancesPolicySpec_To_kops_MixedInstancesPolicySpec(in *MixedInstancesPolicySpec, out *kops.MixedInstancesPolicySpec, s conversion.Scope) error {
+	return autoConvert_v1alpha2_MixedInstancesPolicySpec_To_kops_MixedInstancesPolicySpec(in, out, s)
+}
+
+func autoConvert_kops_MixedInstancesPolicySpec_To_v1alpha2_MixedInstancesPolicySpec(in *kops.MixedInstancesPolicySpec, out *MixedInstancesPolicySpec, s conversion.Scope) error {
+	out.Instances = in.Instances
+	out.OnDemandBase = in.OnDemandBase
+	out.OnDemandAboveBase = in.OnDemandAboveBase
+	out.SpotAllocationStrategy = in.SpotAllocationStrategy
+	return nil
+}
+
+// Convert_kops_MixedInstancesPolicySpec_To_v1alpha2_MixedInstancesPolicySpec is an autogenerated conversion function.
+func Convert_kops_MixedInstancesPolicySpec_To_v1alpha2_MixedInstancesPolicySpec(in *kops.MixedInstancesPolicySpec, out *MixedInstancesPolicySpec, s conversion.Scope) error {
+	return autoConvert_kops_MixedInstancesPolicySpec_To_v1alpha2_MixedInstancesPolicySpec(in, out, s)
+}
+
 func autoConvert_v1alpha2_NetworkingSpec_To_kops_NetworkingSpec(in *NetworkingSpec, out *kops.NetworkingSpec, s conversion.Scope) error {
 	if in.Classic != nil {
 		in, out := &in.Classic, &out.Classic
@@ -3437,6 +3757,190 @@ func Convert_kops_RBACAuthorizationSpec_To_v1alpha2_RBACAuthorizationSpec(in *ko
 	return autoConvert_kops_RBACAuthorizationSpec_To_v1alpha2_RBACAuthorizationSpec(in, out, s)
 }
 
+func autoConvert_v1alpha2_ReadyReplicasCheck_To_kops_ReadyReplicasCheck(in *ReadyReplicasCheck, out *kops.ReadyReplicasCheck, s conversion.Scope) error {
+	out.Kind = in.Kind
+	out.Namespace = in.Namespace
+	out.Name = in.Name
+	out.MinReadyReplicas = in.MinReadyReplicas
+	return nil
+}
+
+// Convert_v1alpha2_ReadyReplicasCheck_To_kops_ReadyReplicasCheck is an autogenerated conversion function.
+func Convert_v1alpha2_ReadyReplicasCheck_To_kops_ReadyReplicasCheck(in *ReadyReplicasCheck, out *kops.ReadyReplicasCheck, s conversion.Scope) error {
+	return autoConvert_v1alpha2_ReadyReplicasCheck_To_kops_ReadyReplicasCheck(in, out, s)
+}
+
+func autoConvert_kops_ReadyReplicasCheck_To_v1alpha2_ReadyReplicasCheck(in *kops.ReadyReplicasCheck, out *ReadyReplicasCheck, s conversion.Scope) error {
+	out.Kind = in.Kind
+	out.Namespace = in.Namespace
+	out.Name = in.Name
+	out.MinReadyReplicas = in.MinReadyReplicas
+	return nil
+}
+
+// Convert_kops_ReadyReplicasCheck_To_v1alpha2_ReadyReplicasCheck is an autogenerated conversion function.
+func Convert_kops_ReadyReplicasCheck_To_v1alpha2_ReadyReplicasCheck(in *kops.ReadyReplicasCheck, out *ReadyReplicasCheck, s conversion.Scope) error {
+	return autoConvert_kops_ReadyReplicasCheck_To_v1alpha2_ReadyReplicasCheck(in, out, s)
+}
+
+func autoConvert_v1alpha2_RollingUpdate_To_kops_RollingUpdate(in *RollingUpdate, out *kops.RollingUpdate, s conversion.Scope) error {
+	out.MaxUnavailable = in.MaxUnavailable
+	out.MaxSurge = in.MaxSurge
+	if in.Strategy != nil {
+		in, out := &in.Strategy, &out.Strategy
+		*out = new(kops.RollingUpdateStrategy)
+		**out = kops.RollingUpdateStrategy(**in)
+	} else {
+		out.Strategy = nil
+	}
+	out.MinHealthyPercentage = in.MinHealthyPercentage
+	return nil
+}
+
+// Convert_v1alpha2_RollingUpdate_To_kops_RollingUpdate is an autogenerated conversion function.
+func Convert_v1alpha2_RollingUpdate_To_kops_RollingUpdate(in *RollingUpdate, out *kops.RollingUpdate, s conversion.Scope) error {
+	return autoConvert_v1alpha2_RollingUpdate_To_kops_RollingUpdate(in, out, s)
+}
+
+func autoConvert_kops_RollingUpdate_To_v1alpha2_RollingUpdate(in *kops.RollingUpdate, out *RollingUpdate, s conversion.Scope) error {
+	out.MaxUnavailable = in.MaxUnavailable
+	out.MaxSurge = in.MaxSurge
+	if in.Strategy != nil {
+		in, out := &in.Strategy, &out.Strategy
+		*out = new(RollingUpdateStrategy)
+		**out = RollingUpdateStrategy(**in)
+	} else {
+		out.Strategy = nil
+	}
+	out.MinHealthyPercentage = in.MinHealthyPercentage
+	return nil
+}
+
+// Convert_kops_RollingUpdate_To_v1alpha2_RollingUpdate is an autogenerated conversion function.
+func Convert_kops_RollingUpdate_To_v1alpha2_RollingUpdate(in *kops.RollingUpdate, out *RollingUpdate, s conversion.Scope) error {
+	return autoConvert_kops_RollingUpdate_To_v1alpha2_RollingUpdate(in, out, s)
+}
+
+func autoConvert_v1alpha2_RollingUpdateExecHook_To_kops_RollingUpdateExecHook(in *RollingUpdateExecHook, out *kops.RollingUpdateExecHook, s conversion.Scope) error {
+	out.Command = in.Command
+	return nil
+}
+
+// Convert_v1alpha2_RollingUpdateExecHook_To_kops_RollingUpdateExecHook is an autogenerated conversion function.
+func Convert_v1alpha2_RollingUpdateExecHook_To_kops_RollingUpdateExecHook(in *RollingUpdateExecHook, out *kops.RollingUpdateExecHook, s conversion.Scope) error {
+	return autoConvert_v1alpha2_RollingUpdateExecHook_To_kops_RollingUpdateExecHook(in, out, s)
+}
+
+func autoConvert_kops_RollingUpdateExecHook_To_v1alpha2_RollingUpdateExecHook(in *kops.RollingUpdateExecHook, out *RollingUpdateExecHook, s conversion.Scope) error {
+	out.Command = in.Command
+	return nil
+}
+
+// Convert_kops_RollingUpdateExecHook_To_v1alpha2_RollingUpdateExecHook is an autogenerated conversion function.
+func Convert_kops_RollingUpdateExecHook_To_v1alpha2_RollingUpdateExecHook(in *kops.RollingUpdateExecHook, out *RollingUpdateExecHook, s conversion.Scope) error {
+	return autoConvert_kops_RollingUpdateExecHook_To_v1alpha2_RollingUpdateExecHook(in, out, s)
+}
+
+func autoConvert_v1alpha2_RollingUpdateHook_To_kops_RollingUpdateHook(in *RollingUpdateHook, out *kops.RollingUpdateHook, s conversion.Scope) error {
+	out.Name = in.Name
+	if in.Events != nil {
+		in, out := &in.Events, &out.Events
+		*out = make([]kops.RollingUpdateHookEvent, len(*in))
+		for i := range *in {
+			(*out)[i] = kops.RollingUpdateHookEvent((*in)[i])
+		}
+	} else {
+		out.Events = nil
+	}
+	if in.Exec != nil {
+		in, out := &in.Exec, &out.Exec
+		*out = new(kops.RollingUpdateExecHook)
+		if err := Convert_v1alpha2_RollingUpdateExecHook_To_kops_RollingUpdateExecHook(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Exec = nil
+	}
+	if in.Webhook != nil {
+		in, out := &in.Webhook, &out.Webhook
+		*out = new(kops.RollingUpdateWebhook)
+		if err := Convert_v1alpha2_RollingUpdateWebhook_To_kops_RollingUpdateWebhook(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Webhook = nil
+	}
+	out.Timeout = in.Timeout
+	out.FailurePolicy = kops.RollingUpdateHookFailurePolicy(in.FailurePolicy)
+	return nil
+}
+
+// Convert_v1alpha2_RollingUpdateHook_To_kops_RollingUpdateHook is an autogenerated conversion function.
+func Convert_v1alpha2_RollingUpdateHook_To_kops_RollingUpdateHook(in *RollingUpdateHook, out *kops.RollingUpdateHook, s conversion.Scope) error {
+	return autoConvert_v1alpha2_RollingUpdateHook_To_kops_RollingUpdateHook(in, out, s)
+}
+
+func autoConvert_kops_RollingUpdateHook_To_v1alpha2_RollingUpdateHook(in *kops.RollingUpdateHook, out *RollingUpdateHook, s conversion.Scope) error {
+	out.Name = in.Name
+	if in.Events != nil {
+		in, out := &in.Events, &out.Events
+		*out = make([]RollingUpdateHookEvent, len(*in))
+		for i := range *in {
+			(*out)[i] = RollingUpdateHookEvent((*in)[i])
+		}
+	} else {
+		out.Events = nil
+	}
+	if in.Exec != nil {
+		in, out := &in.Exec, &out.Exec
+		*out = new(RollingUpdateExecHook)
+		if err := Convert_kops_RollingUpdateExecHook_To_v1alpha2_RollingUpdateExecHook(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Exec = nil
+	}
+	if in.Webhook != nil {
+		in, out := &in.Webhook, &out.Webhook
+		*out = new(RollingUpdateWebhook)
+		if err := Convert_kops_RollingUpdateWebhook_To_v1alpha2_RollingUpdateWebhook(*in, *out, s); err != nil {
+			return err
+		}
+	} else {
+		out.Webhook = nil
+	}
+	out.Timeout = in.Timeout
+	out.FailurePolicy = RollingUpdateHookFailurePolicy(in.FailurePolicy)
+	return nil
+}
+
+// Convert_kops_RollingUpdateHook_To_v1alpha2_RollingUpdateHook is an autogenerated conversion function.
+func Convert_kops_RollingUpdateHook_To_v1alpha2_RollingUpdateHook(in *kops.RollingUpdateHook, out *RollingUpdateHook, s conversion.Scope) error {
+	return autoConvert_kops_RollingUpdateHook_To_v1alpha2_RollingUpdateHook(in, out, s)
+}
+
+func autoConvert_v1alpha2_RollingUpdateWebhook_To_kops_RollingUpdateWebhook(in *RollingUpdateWebhook, out *kops.RollingUpdateWebhook, s conversion.Scope) error {
+	out.URL = in.URL
+	out.Headers = in.Headers
+	return nil
+}
+
+// Convert_v1alpha2_RollingUpdateWebhook_To_kops_RollingUpdateWebhook is an autogenerated conversion function.
+func Convert_v1alpha2_RollingUpdateWebhook_To_kops_RollingUpdateWebhook(in *RollingUpdateWebhook, out *kops.RollingUpdateWebhook, s conversion.Scope) error {
+	return autoConvert_v1alpha2_RollingUpdateWebhook_To_kops_RollingUpdateWebhook(in, out, s)
+}
+
+func autoConvert_kops_RollingUpdateWebhook_To_v1alpha2_RollingUpdateWebhook(in *kops.RollingUpdateWebhook, out *RollingUpdateWebhook, s conversion.Scope) error {
+	out.URL = in.URL
+	out.Headers = in.Headers
+	return nil
+}
+
+// Convert_kops_RollingUpdateWebhook_To_v1alpha2_RollingUpdateWebhook is an autogenerated conversion function.
+func Convert_kops_RollingUpdateWebhook_To_v1alpha2_RollingUpdateWebhook(in *kops.RollingUpdateWebhook, out *RollingUpdateWebhook, s conversion.Scope) error {
+	return autoConvert_kops_RollingUpdateWebhook_To_v1alpha2_RollingUpdateWebhook(in, out, s)
+}
+
 func autoConvert_v1alpha2_RomanaNetworkingSpec_To_kops_RomanaNetworkingSpec(in *RomanaNetworkingSpec, out *kops.RomanaNetworkingSpec, s conversion.Scope) error {
 	out.DaemonServiceIP = in.DaemonServiceIP
 	out.EtcdServiceIP = in.EtcdServiceIP
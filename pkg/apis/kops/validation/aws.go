@@ -18,6 +18,7 @@ package validation
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/golang/glog"
@@ -33,12 +34,44 @@ func awsValidateCluster(c *kops.Cluster) field.ErrorList {
 	if c.Spec.API != nil {
 		if c.Spec.API.LoadBalancer != nil {
 			allErrs = append(allErrs, awsValidateAdditionalSecurityGroups(field.NewPath("spec", "api", "loadBalancer", "additionalSecurityGroups"), c.Spec.API.LoadBalancer.AdditionalSecurityGroups)...)
+			allErrs = append(allErrs, awsValidateLoadBalancerClass(field.NewPath("spec", "api", "loadBalancer"), c.Spec.API.LoadBalancer)...)
 		}
 	}
 
 	return allErrs
 }
 
+func awsValidateLoadBalancerClass(fieldPath *field.Path, spec *kops.LoadBalancerAccessSpec) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	switch spec.Class {
+	case "", kops.LoadBalancerClassClassic:
+		if spec.CrossZoneLoadBalancing != nil {
+			allErrs = append(allErrs, field.Forbidden(fieldPath.Child("crossZoneLoadBalancing"), "crossZoneLoadBalancing is only supported for the Network class"))
+		}
+
+	case kops.LoadBalancerClassNetwork:
+		// An NLB has no security groups, and passes TLS through to the masters
+		if spec.IdleTimeoutSeconds != nil {
+			allErrs = append(allErrs, field.Forbidden(fieldPath.Child("idleTimeoutSeconds"), "idleTimeoutSeconds is not supported for the Network class"))
+		}
+		if spec.SecurityGroupOverride != nil {
+			allErrs = append(allErrs, field.Forbidden(fieldPath.Child("securityGroupOverride"), "securityGroupOverride is not supported for the Network class"))
+		}
+		if len(spec.AdditionalSecurityGroups) != 0 {
+			allErrs = append(allErrs, field.Forbidden(fieldPath.Child("additionalSecurityGroups"), "additionalSecurityGroups are not supported for the Network class"))
+		}
+		if spec.SSLCertificate != "" {
+			allErrs = append(allErrs, field.Forbidden(fieldPath.Child("sslCertificate"), "sslCertificate is not supported for the Network class, which passes TLS through to the masters"))
+		}
+
+	default:
+		allErrs = append(allErrs, field.NotSupported(fieldPath.Child("class"), spec.Class, kops.SupportedLoadBalancerClasses))
+	}
+
+	return allErrs
+}
+
 func awsValidateInstanceGroup(ig *kops.InstanceGroup) field.ErrorList {
 	allErrs := field.ErrorList{}
 
@@ -48,6 +81,84 @@ func awsValidateInstanceGroup(ig *kops.InstanceGroup) field.ErrorList {
 
 	allErrs = append(allErrs, awsValidateAMIforNVMe(field.NewPath(ig.GetName(), "spec", "machineType"), ig)...)
 
+	if ig.Spec.MixedInstancesPolicy != nil {
+		allErrs = append(allErrs, awsValidateMixedInstancesPolicy(field.NewPath("spec", "mixedInstancesPolicy"), ig.Spec.MixedInstancesPolicy)...)
+	}
+
+	allErrs = append(allErrs, awsValidateLifecycleHooks(field.NewPath("spec", "lifecycleHooks"), ig.Spec.LifecycleHooks)...)
+
+	return allErrs
+}
+
+var validLifecycleHookName = regexp.MustCompile(`^[A-Za-z0-9\-_\/]+$`)
+
+func awsValidateLifecycleHooks(fieldPath *field.Path, hooks []kops.LifecycleHookSpec) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	names := sets.NewString()
+	for i, hook := range hooks {
+		hookPath := fieldPath.Index(i)
+
+		if hook.Name == "" {
+			allErrs = append(allErrs, field.Required(hookPath.Child("name"), "lifecycle hooks must have a name"))
+		} else if len(hook.Name) > 255 || !validLifecycleHookName.MatchString(hook.Name) {
+			allErrs = append(allErrs, field.Invalid(hookPath.Child("name"), hook.Name, "must be at most 255 letters, digits, '-', '_' or '/'"))
+		} else if names.Has(hook.Name) {
+			allErrs = append(allErrs, field.Duplicate(hookPath.Child("name"), hook.Name))
+		}
+		names.Insert(hook.Name)
+
+		switch hook.Transition {
+		case kops.LifecycleTransitionLaunching, kops.LifecycleTransitionTerminating:
+		case "":
+			allErrs = append(allErrs, field.Required(hookPath.Child("transition"), "lifecycle hooks must have a transition"))
+		default:
+			valid := []string{string(kops.LifecycleTransitionLaunching), string(kops.LifecycleTransitionTerminating)}
+			allErrs = append(allErrs, field.NotSupported(hookPath.Child("transition"), hook.Transition, valid))
+		}
+
+		if hook.HeartbeatTimeout != nil && (*hook.HeartbeatTimeout < 30 || *hook.HeartbeatTimeout > 7200) {
+			allErrs = append(allErrs, field.Invalid(hookPath.Child("heartbeatTimeout"), *hook.HeartbeatTimeout, "must be between 30 and 7200 seconds"))
+		}
+
+		if hook.DefaultResult != nil {
+			valid := []string{"CONTINUE", "ABANDON"}
+			if !sets.NewString(valid...).Has(*hook.DefaultResult) {
+				allErrs = append(allErrs, field.NotSupported(hookPath.Child("defaultResult"), *hook.DefaultResult, valid))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+func awsValidateMixedInstancesPolicy(fieldPath *field.Path, spec *kops.MixedInstancesPolicySpec) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if len(spec.Instances) == 0 {
+		allErrs = append(allErrs, field.Required(fieldPath.Child("instances"), "at least one instance type must be specified"))
+	}
+	for i, instance := range spec.Instances {
+		if _, err := awsup.GetMachineTypeInfo(instance); err != nil {
+			allErrs = append(allErrs, field.Invalid(fieldPath.Child("instances").Index(i), instance, "machine type specified is invalid"))
+		}
+	}
+
+	if spec.OnDemandBase != nil && *spec.OnDemandBase < 0 {
+		allErrs = append(allErrs, field.Invalid(fieldPath.Child("onDemandBase"), *spec.OnDemandBase, "cannot be negative"))
+	}
+
+	if spec.OnDemandAboveBase != nil && (*spec.OnDemandAboveBase < 0 || *spec.OnDemandAboveBase > 100) {
+		allErrs = append(allErrs, field.Invalid(fieldPath.Child("onDemandAboveBase"), *spec.OnDemandAboveBase, "must be a percentage between 0 and 100"))
+	}
+
+	if spec.SpotAllocationStrategy != nil {
+		valid := []string{kops.SpotAllocationStrategyLowestPrice}
+		if !sets.NewString(valid...).Has(*spec.SpotAllocationStrategy) {
+			allErrs = append(allErrs, field.NotSupported(fieldPath.Child("spotAllocationStrategy"), *spec.SpotAllocationStrategy, valid))
+		}
+	}
+
 	return allErrs
 }
 
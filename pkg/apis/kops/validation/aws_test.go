@@ -21,6 +21,7 @@ import (
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/upup/pkg/fi"
 )
 
 func TestValidateInstanceGroupSpec(t *testing.T) {
@@ -102,6 +103,77 @@ func TestValidateInstanceGroupSpec(t *testing.T) {
 				"Forbidden::test-nodes.spec.machineType",
 			},
 		},
+		{
+			Input: kops.InstanceGroupSpec{
+				MixedInstancesPolicy: &kops.MixedInstancesPolicySpec{
+					Instances:              []string{"m4.large", "m5.large"},
+					OnDemandBase:           fi.Int64(1),
+					OnDemandAboveBase:      fi.Int64(25),
+					SpotAllocationStrategy: fi.String("lowest-price"),
+				},
+			},
+		},
+		{
+			Input: kops.InstanceGroupSpec{
+				MixedInstancesPolicy: &kops.MixedInstancesPolicySpec{},
+			},
+			ExpectedErrors: []string{"Required value::spec.mixedInstancesPolicy.instances"},
+		},
+		{
+			Input: kops.InstanceGroupSpec{
+				MixedInstancesPolicy: &kops.MixedInstancesPolicySpec{
+					Instances:              []string{"m4.large", "m4.invalidType"},
+					OnDemandBase:           fi.Int64(-1),
+					OnDemandAboveBase:      fi.Int64(101),
+					SpotAllocationStrategy: fi.String("highest-price"),
+				},
+			},
+			ExpectedErrors: []string{
+				"Invalid value::spec.mixedInstancesPolicy.instances[1]",
+				"Invalid value::spec.mixedInstancesPolicy.onDemandBase",
+				"Invalid value::spec.mixedInstancesPolicy.onDemandAboveBase",
+				"Unsupported value::spec.mixedInstancesPolicy.spotAllocationStrategy",
+			},
+		},
+		{
+			Input: kops.InstanceGroupSpec{
+				LifecycleHooks: []kops.LifecycleHookSpec{
+					{
+						Name:             "drain",
+						Transition:       kops.LifecycleTransitionTerminating,
+						HeartbeatTimeout: fi.Int64(600),
+						DefaultResult:    fi.String("CONTINUE"),
+					},
+				},
+			},
+		},
+		{
+			Input: kops.InstanceGroupSpec{
+				LifecycleHooks: []kops.LifecycleHookSpec{
+					{
+						Name:       "drain",
+						Transition: kops.LifecycleTransitionTerminating,
+					},
+					{
+						Name:             "drain",
+						Transition:       "autoscaling:EC2_INSTANCE_REBOOTING",
+						HeartbeatTimeout: fi.Int64(10),
+						DefaultResult:    fi.String("RETRY"),
+					},
+					{
+						Name: "bad name",
+					},
+				},
+			},
+			ExpectedErrors: []string{
+				"Duplicate value::spec.lifecycleHooks[1].name",
+				"Unsupported value::spec.lifecycleHooks[1].transition",
+				"Invalid value::spec.lifecycleHooks[1].heartbeatTimeout",
+				"Unsupported value::spec.lifecycleHooks[1].defaultResult",
+				"Invalid value::spec.lifecycleHooks[2].name",
+				"Required value::spec.lifecycleHooks[2].transition",
+			},
+		},
 	}
 	for _, g := range grid {
 		ig := &kops.InstanceGroup{
@@ -115,3 +187,69 @@ func TestValidateInstanceGroupSpec(t *testing.T) {
 		testErrors(t, g.Input, errs, g.ExpectedErrors)
 	}
 }
+
+func TestValidateClusterLoadBalancerClass(t *testing.T) {
+	grid := []struct {
+		Input          kops.LoadBalancerAccessSpec
+		ExpectedErrors []string
+	}{
+		{
+			Input: kops.LoadBalancerAccessSpec{
+				Type:               kops.LoadBalancerTypePublic,
+				IdleTimeoutSeconds: fi.Int64(300),
+			},
+		},
+		{
+			Input: kops.LoadBalancerAccessSpec{
+				Type:  kops.LoadBalancerTypePublic,
+				Class: kops.LoadBalancerClassClassic,
+			},
+		},
+		{
+			Input: kops.LoadBalancerAccessSpec{
+				Type: kops.LoadBalancerTypeInternal,
+				CrossZoneLoadBalancing: fi.Bool(true),
+			},
+			ExpectedErrors: []string{"Forbidden::spec.api.loadBalancer.crossZoneLoadBalancing"},
+		},
+		{
+			Input: kops.LoadBalancerAccessSpec{
+				Type:  kops.LoadBalancerTypePublic,
+				Class: kops.LoadBalancerClassNetwork,
+				CrossZoneLoadBalancing: fi.Bool(true),
+			},
+		},
+		{
+			Input: kops.LoadBalancerAccessSpec{
+				Type:                     kops.LoadBalancerTypePublic,
+				Class:                    kops.LoadBalancerClassNetwork,
+				IdleTimeoutSeconds:       fi.Int64(300),
+				SecurityGroupOverride:    fi.String("sg-1234abcd"),
+				AdditionalSecurityGroups: []string{"sg-1234abcd"},
+				SSLCertificate:           "arn:aws:acm:us-test-1:000000000000:certificate/example",
+			},
+			ExpectedErrors: []string{
+				"Forbidden::spec.api.loadBalancer.idleTimeoutSeconds",
+				"Forbidden::spec.api.loadBalancer.securityGroupOverride",
+				"Forbidden::spec.api.loadBalancer.additionalSecurityGroups",
+				"Forbidden::spec.api.loadBalancer.sslCertificate",
+			},
+		},
+		{
+			Input: kops.LoadBalancerAccessSpec{
+				Type:  kops.LoadBalancerTypePublic,
+				Class: "Application",
+			},
+			ExpectedErrors: []string{"Unsupported value::spec.api.loadBalancer.class"},
+		},
+	}
+	for _, g := range grid {
+		cluster := &kops.Cluster{}
+		cluster.Spec.API = &kops.AccessSpec{
+			LoadBalancer: &g.Input,
+		}
+		errs := awsValidateCluster(cluster)
+
+		testErrors(t, g.Input, errs, g.ExpectedErrors)
+	}
+}
@@ -18,11 +18,14 @@ package validation
 
 import (
 	"fmt"
+	"net/url"
 	"regexp"
 
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/kops/pkg/apis/kops"
 	"k8s.io/kops/pkg/apis/kops/util"
+	"k8s.io/kops/pkg/featureflag"
 	"k8s.io/kops/upup/pkg/fi"
 )
 
@@ -91,9 +94,88 @@ func ValidateInstanceGroup(g *kops.InstanceGroup) error {
 		return err
 	}
 
+	if g.Spec.RollingUpdate != nil {
+		if errs := validateRollingUpdate(g.Spec.RollingUpdate, field.NewPath("rollingUpdate")); len(errs) > 0 {
+			return errs.ToAggregate()
+		}
+	}
+
+	// Hooks run around each instance that kops replaces; an instance refresh replaces them server-side
+	if g.Spec.RollingUpdate != nil && g.Spec.RollingUpdate.Strategy != nil && *g.Spec.RollingUpdate.Strategy == kops.RollingUpdateStrategyInstanceRefresh && len(g.Spec.RollingUpdateHooks) != 0 {
+		return field.Forbidden(field.NewPath("rollingUpdateHooks"), "rollingUpdateHooks are not run with the InstanceRefresh strategy")
+	}
+
+	for i := range g.Spec.RollingUpdateHooks {
+		if errs := validateRollingUpdateHook(&g.Spec.RollingUpdateHooks[i], field.NewPath("rollingUpdateHooks").Index(i)); len(errs) > 0 {
+			return errs.ToAggregate()
+		}
+	}
+
 	return nil
 }
 
+func validateRollingUpdateHook(v *kops.RollingUpdateHook, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if v.Name == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("name"), "name must be specified"))
+	}
+
+	if len(v.Events) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("events"), "at least one event must be specified"))
+	}
+	for i, event := range v.Events {
+		valid := false
+		for _, e := range kops.RollingUpdateHookEvents {
+			if event == e {
+				valid = true
+			}
+		}
+		if !valid {
+			allErrs = append(allErrs, field.NotSupported(fldPath.Child("events").Index(i), event, rollingUpdateHookEventNames()))
+		}
+	}
+
+	if v.Exec == nil && v.Webhook == nil {
+		allErrs = append(allErrs, field.Required(fldPath, "you must set either exec or webhook for a rolling-update hook"))
+	}
+	if v.Exec != nil && v.Webhook != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath, "exec and webhook may not both be set for a rolling-update hook"))
+	}
+
+	if v.Exec != nil && len(v.Exec.Command) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("exec", "command"), "command must be specified"))
+	}
+
+	if v.Webhook != nil {
+		u, err := url.Parse(v.Webhook.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("webhook", "url"), v.Webhook.URL, "must be an http or https URL"))
+		}
+	}
+
+	if v.Timeout != nil && v.Timeout.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("timeout"), v.Timeout.Duration.String(), "must be greater than zero"))
+	}
+
+	switch v.FailurePolicy {
+	case "", kops.RollingUpdateHookFailurePolicyAbort, kops.RollingUpdateHookFailurePolicyContinue:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("failurePolicy"), v.FailurePolicy,
+			[]string{string(kops.RollingUpdateHookFailurePolicyAbort), string(kops.RollingUpdateHookFailurePolicyContinue)}))
+	}
+
+	return allErrs
+}
+
+func rollingUpdateHookEventNames() []string {
+	var names []string
+	for _, e := range kops.RollingUpdateHookEvents {
+		names = append(names, string(e))
+	}
+	return names
+}
+
 // CrossValidateInstanceGroup performs validation of the instance group, including that it is consistent with the Cluster
 // It calls ValidateInstanceGroup, so all that validation is included.
 func CrossValidateInstanceGroup(g *kops.InstanceGroup, cluster *kops.Cluster, strict bool) error {
@@ -128,6 +210,35 @@ func CrossValidateInstanceGroup(g *kops.InstanceGroup, cluster *kops.Cluster, st
 	allErrs := field.ErrorList{}
 	fieldPath := field.NewPath("InstanceGroup")
 
+	// An instance refresh terminates instances without draining them, unless a lifecycle hook holds them while the node drainer does.
+	// Bastions have no node to drain.
+	strategy := kops.RollingUpdateStrategyDrain
+	if cluster.Spec.RollingUpdate != nil && cluster.Spec.RollingUpdate.Strategy != nil {
+		strategy = *cluster.Spec.RollingUpdate.Strategy
+	}
+	if g.Spec.RollingUpdate != nil && g.Spec.RollingUpdate.Strategy != nil {
+		strategy = *g.Spec.RollingUpdate.Strategy
+	}
+	if strategy == kops.RollingUpdateStrategyInstanceRefresh && g.Spec.Role != kops.InstanceGroupRoleBastion && !g.HasLifecycleHook(kops.LifecycleTransitionTerminating) {
+		allErrs = append(allErrs, field.Forbidden(fieldPath.Child("Spec", "RollingUpdate", "Strategy"),
+			fmt.Sprintf("the %s strategy requires a lifecycle hook on %s, so that nodes are drained before their instances are terminated", kops.RollingUpdateStrategyInstanceRefresh, kops.LifecycleTransitionTerminating)))
+	}
+
+	// Surging resizes the group, which only some clouds support
+	var maxSurge *intstr.IntOrString
+	if cluster.Spec.RollingUpdate != nil && cluster.Spec.RollingUpdate.MaxSurge != nil {
+		maxSurge = cluster.Spec.RollingUpdate.MaxSurge
+	}
+	if g.Spec.RollingUpdate != nil && g.Spec.RollingUpdate.MaxSurge != nil {
+		maxSurge = g.Spec.RollingUpdate.MaxSurge
+	}
+	if maxSurge != nil && !canResizeGroups(cluster) {
+		if surge, err := intstr.GetValueFromIntOrPercent(maxSurge, 100, true); err == nil && surge > 0 {
+			allErrs = append(allErrs, field.Forbidden(fieldPath.Child("Spec", "RollingUpdate", "MaxSurge"),
+				fmt.Sprintf("surging is not supported on cloud provider %q, as it cannot resize instance groups", cloudProviderName(cluster))))
+		}
+	}
+
 	if k8sVersion.Major == 1 && k8sVersion.Minor <= 5 {
 		if len(g.Spec.Taints) > 0 {
 			if !(g.IsMaster() && g.Spec.Taints[0] == kops.TaintNoScheduleMaster15 && len(g.Spec.Taints) == 1) {
@@ -143,6 +254,26 @@ func CrossValidateInstanceGroup(g *kops.InstanceGroup, cluster *kops.Cluster, st
 	return nil
 }
 
+// canResizeGroups returns true if kops can resize the instance groups of the cluster, which surging needs
+func canResizeGroups(cluster *kops.Cluster) bool {
+	switch kops.CloudProviderID(cluster.Spec.CloudProvider) {
+	case kops.CloudProviderAWS:
+		return !featureflag.Spotinst.Enabled()
+	case kops.CloudProviderGCE:
+		return true
+	default:
+		return false
+	}
+}
+
+// cloudProviderName returns the name of the cloud provider of the cluster, as it is reported in errors
+func cloudProviderName(cluster *kops.Cluster) string {
+	if kops.CloudProviderID(cluster.Spec.CloudProvider) == kops.CloudProviderAWS && featureflag.Spotinst.Enabled() {
+		return "aws (spotinst)"
+	}
+	return cluster.Spec.CloudProvider
+}
+
 func validateExtraUserData(userData *kops.UserData) error {
 	fieldPath := field.NewPath("AdditionalUserData")
 
@@ -19,8 +19,10 @@ package validation
 import (
 	"strings"
 	"testing"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/kops/pkg/apis/kops"
 	"k8s.io/kops/upup/pkg/fi"
@@ -67,6 +69,101 @@ func TestDefaultTaintsEnforcedBefore160(t *testing.T) {
 	}
 }
 
+func TestInstanceRefreshRequiresTerminatingLifecycleHook(t *testing.T) {
+	instanceRefresh := kops.RollingUpdateStrategyInstanceRefresh
+	drain := kops.RollingUpdateStrategyDrain
+	terminating := []kops.LifecycleHookSpec{{Name: "drain", Transition: kops.LifecycleTransitionTerminating}}
+	launching := []kops.LifecycleHookSpec{{Name: "launch", Transition: kops.LifecycleTransitionLaunching}}
+
+	grid := []struct {
+		ClusterStrategy *kops.RollingUpdateStrategy
+		GroupStrategy   *kops.RollingUpdateStrategy
+		Role            kops.InstanceGroupRole
+		Hooks           []kops.LifecycleHookSpec
+		ExpectError     bool
+	}{
+		{GroupStrategy: &instanceRefresh, Role: kops.InstanceGroupRoleNode, Hooks: terminating},
+		{GroupStrategy: &instanceRefresh, Role: kops.InstanceGroupRoleNode, ExpectError: true},
+		{GroupStrategy: &instanceRefresh, Role: kops.InstanceGroupRoleNode, Hooks: launching, ExpectError: true},
+		{ClusterStrategy: &instanceRefresh, Role: kops.InstanceGroupRoleNode, ExpectError: true},
+		{ClusterStrategy: &instanceRefresh, GroupStrategy: &drain, Role: kops.InstanceGroupRoleNode},
+		{ClusterStrategy: &instanceRefresh, Role: kops.InstanceGroupRoleBastion},
+		{Role: kops.InstanceGroupRoleNode},
+	}
+
+	for i, g := range grid {
+		cluster := &kops.Cluster{Spec: kops.ClusterSpec{KubernetesVersion: "1.10.0"}}
+		if g.ClusterStrategy != nil {
+			cluster.Spec.RollingUpdate = &kops.RollingUpdate{Strategy: g.ClusterStrategy}
+		}
+		ig := &kops.InstanceGroup{
+			ObjectMeta: metav1.ObjectMeta{Name: "test"},
+			Spec: kops.InstanceGroupSpec{
+				Role:           g.Role,
+				LifecycleHooks: g.Hooks,
+			},
+		}
+		if g.GroupStrategy != nil {
+			ig.Spec.RollingUpdate = &kops.RollingUpdate{Strategy: g.GroupStrategy}
+		}
+
+		err := CrossValidateInstanceGroup(ig, cluster, false)
+		if g.ExpectError {
+			if err == nil || !strings.Contains(err.Error(), "requires a lifecycle hook") {
+				t.Errorf("case %d: expected error requiring a lifecycle hook, got %v", i, err)
+			}
+		} else if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+		}
+	}
+}
+
+func TestSurgeRequiresResizableGroups(t *testing.T) {
+	one := intstr.FromInt(1)
+	zero := intstr.FromInt(0)
+	percent := intstr.FromString("25%")
+
+	grid := []struct {
+		CloudProvider kops.CloudProviderID
+		ClusterSurge  *intstr.IntOrString
+		GroupSurge    *intstr.IntOrString
+		ExpectError   bool
+	}{
+		{CloudProvider: kops.CloudProviderAWS, GroupSurge: &one},
+		{CloudProvider: kops.CloudProviderGCE, ClusterSurge: &percent},
+		{CloudProvider: kops.CloudProviderALI, GroupSurge: &one, ExpectError: true},
+		{CloudProvider: kops.CloudProviderALI, ClusterSurge: &percent, ExpectError: true},
+		{CloudProvider: kops.CloudProviderALI, ClusterSurge: &one, GroupSurge: &zero},
+		{CloudProvider: kops.CloudProviderOpenstack, GroupSurge: &one, ExpectError: true},
+		{CloudProvider: kops.CloudProviderALI},
+	}
+
+	for i, g := range grid {
+		cluster := &kops.Cluster{Spec: kops.ClusterSpec{KubernetesVersion: "1.10.0", CloudProvider: string(g.CloudProvider)}}
+		if g.ClusterSurge != nil {
+			cluster.Spec.RollingUpdate = &kops.RollingUpdate{MaxSurge: g.ClusterSurge}
+		}
+		ig := &kops.InstanceGroup{
+			ObjectMeta: metav1.ObjectMeta{Name: "test"},
+			Spec: kops.InstanceGroupSpec{
+				Role: kops.InstanceGroupRoleNode,
+			},
+		}
+		if g.GroupSurge != nil {
+			ig.Spec.RollingUpdate = &kops.RollingUpdate{MaxSurge: g.GroupSurge}
+		}
+
+		err := CrossValidateInstanceGroup(ig, cluster, false)
+		if g.ExpectError {
+			if err == nil || !strings.Contains(err.Error(), "surging is not supported") {
+				t.Errorf("case %d: expected error rejecting the surge, got %v", i, err)
+			}
+		} else if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+		}
+	}
+}
+
 func s(v string) *string {
 	return fi.String(v)
 }
@@ -127,3 +224,77 @@ func TestValidateInstanceProfile(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateRollingUpdateHook(t *testing.T) {
+	grid := []struct {
+		Input          kops.RollingUpdateHook
+		ExpectedErrors []string
+	}{
+		{
+			Input: kops.RollingUpdateHook{
+				Name:   "deregister",
+				Events: []kops.RollingUpdateHookEvent{kops.RollingUpdateHookBeforeDrain},
+				Exec:   &kops.RollingUpdateExecHook{Command: []string{"/bin/deregister"}},
+			},
+		},
+		{
+			Input: kops.RollingUpdateHook{
+				Name:          "notify",
+				Events:        []kops.RollingUpdateHookEvent{kops.RollingUpdateHookAfterTerminate, kops.RollingUpdateHookAfterValidate},
+				Webhook:       &kops.RollingUpdateWebhook{URL: "https://hooks.example.com/kops"},
+				Timeout:       &metav1.Duration{Duration: time.Minute},
+				FailurePolicy: kops.RollingUpdateHookFailurePolicyContinue,
+			},
+		},
+		{
+			Input: kops.RollingUpdateHook{
+				Name:   "nothing",
+				Events: []kops.RollingUpdateHookEvent{kops.RollingUpdateHookBeforeDrain},
+			},
+			ExpectedErrors: []string{"Required value::rollingUpdateHooks[0]"},
+		},
+		{
+			Input: kops.RollingUpdateHook{
+				Name:    "both",
+				Events:  []kops.RollingUpdateHookEvent{kops.RollingUpdateHookBeforeDrain},
+				Exec:    &kops.RollingUpdateExecHook{Command: []string{"/bin/true"}},
+				Webhook: &kops.RollingUpdateWebhook{URL: "https://hooks.example.com/kops"},
+			},
+			ExpectedErrors: []string{"Forbidden::rollingUpdateHooks[0]"},
+		},
+		{
+			Input: kops.RollingUpdateHook{
+				Name:   "bad-event",
+				Events: []kops.RollingUpdateHookEvent{"BeforeLunch"},
+				Exec:   &kops.RollingUpdateExecHook{Command: []string{"/bin/true"}},
+			},
+			ExpectedErrors: []string{"Unsupported value::rollingUpdateHooks[0].events[0]"},
+		},
+		{
+			Input: kops.RollingUpdateHook{
+				Name:    "bad-url",
+				Events:  []kops.RollingUpdateHookEvent{kops.RollingUpdateHookAfterDrain},
+				Webhook: &kops.RollingUpdateWebhook{URL: "ftp://hooks.example.com"},
+			},
+			ExpectedErrors: []string{"Invalid value::rollingUpdateHooks[0].webhook.url"},
+		},
+		{
+			Input: kops.RollingUpdateHook{
+				Name:          "bad-policy",
+				Events:        []kops.RollingUpdateHookEvent{kops.RollingUpdateHookAfterDrain},
+				Exec:          &kops.RollingUpdateExecHook{Command: []string{"/bin/true"}},
+				Timeout:       &metav1.Duration{},
+				FailurePolicy: "Retry",
+			},
+			ExpectedErrors: []string{
+				"Invalid value::rollingUpdateHooks[0].timeout",
+				"Unsupported value::rollingUpdateHooks[0].failurePolicy",
+			},
+		},
+	}
+
+	for _, g := range grid {
+		errs := validateRollingUpdateHook(&g.Input, field.NewPath("rollingUpdateHooks").Index(0))
+		testErrors(t, g.Input, errs, g.ExpectedErrors)
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/blang/semver"
 
 	"k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	utilnet "k8s.io/apimachinery/pkg/util/net"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation/field"
@@ -116,6 +117,16 @@ func validateClusterSpec(spec *kops.ClusterSpec, fieldPath *field.Path) field.Er
 		}
 	}
 
+	if spec.RollingUpdate != nil {
+		allErrs = append(allErrs, validateRollingUpdate(spec.RollingUpdate, fieldPath.Child("rollingUpdate"))...)
+	}
+
+	if spec.Validation != nil {
+		for i := range spec.Validation.Checks {
+			allErrs = append(allErrs, validateClusterValidationCheck(&spec.Validation.Checks[i], fieldPath.Child("validation", "checks").Index(i))...)
+		}
+	}
+
 	return allErrs
 }
 
@@ -247,6 +258,97 @@ func validateExecContainerAction(v *kops.ExecContainerAction, fldPath *field.Pat
 	return allErrs
 }
 
+// validateRollingUpdate checks that the surge and unavailability settings are parseable and consistent
+func validateRollingUpdate(rollingUpdate *kops.RollingUpdate, fldpath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	var err error
+	unavailable := 1
+	if rollingUpdate.MaxUnavailable != nil {
+		unavailable, err = intstr.GetValueFromIntOrPercent(rollingUpdate.MaxUnavailable, 100, false)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(fldpath.Child("maxUnavailable"), rollingUpdate.MaxUnavailable,
+				fmt.Sprintf("Unable to parse: %v", err)))
+		} else if unavailable < 0 {
+			allErrs = append(allErrs, field.Invalid(fldpath.Child("maxUnavailable"), rollingUpdate.MaxUnavailable, "Cannot be negative"))
+		}
+	}
+
+	surge := 0
+	if rollingUpdate.MaxSurge != nil {
+		surge, err = intstr.GetValueFromIntOrPercent(rollingUpdate.MaxSurge, 100, true)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(fldpath.Child("maxSurge"), rollingUpdate.MaxSurge,
+				fmt.Sprintf("Unable to parse: %v", err)))
+		} else if surge < 0 {
+			allErrs = append(allErrs, field.Invalid(fldpath.Child("maxSurge"), rollingUpdate.MaxSurge, "Cannot be negative"))
+		}
+	}
+
+	if rollingUpdate.MaxUnavailable != nil && unavailable == 0 && surge == 0 {
+		allErrs = append(allErrs, field.Forbidden(fldpath.Child("maxUnavailable"), "Cannot be zero if maxSurge is zero"))
+	}
+
+	if rollingUpdate.Strategy != nil {
+		switch *rollingUpdate.Strategy {
+		case kops.RollingUpdateStrategyDrain:
+		case kops.RollingUpdateStrategyInstanceRefresh:
+			if surge > 0 {
+				allErrs = append(allErrs, field.Forbidden(fldpath.Child("maxSurge"), "Cannot surge with the InstanceRefresh strategy"))
+			}
+		default:
+			allErrs = append(allErrs, field.NotSupported(fldpath.Child("strategy"), *rollingUpdate.Strategy,
+				[]string{string(kops.RollingUpdateStrategyDrain), string(kops.RollingUpdateStrategyInstanceRefresh)}))
+		}
+	}
+
+	if rollingUpdate.MinHealthyPercentage != nil {
+		if v := *rollingUpdate.MinHealthyPercentage; v < 0 || v > 100 {
+			allErrs = append(allErrs, field.Invalid(fldpath.Child("minHealthyPercentage"), v, "Must be between 0 and 100"))
+		}
+	}
+
+	return allErrs
+}
+
+func validateClusterValidationCheck(v *kops.ClusterValidationCheck, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if v.Name == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("name"), "name must be specified"))
+	}
+
+	switch v.Severity {
+	case "", kops.ValidationSeverityError, kops.ValidationSeverityWarning:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("severity"), v.Severity,
+			[]string{string(kops.ValidationSeverityError), string(kops.ValidationSeverityWarning)}))
+	}
+
+	if v.ReadyReplicas == nil {
+		allErrs = append(allErrs, field.Required(fldPath.Child("readyReplicas"), "readyReplicas must be specified"))
+		return allErrs
+	}
+
+	r := v.ReadyReplicas
+	switch r.Kind {
+	case "Deployment", "StatefulSet", "DaemonSet":
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("readyReplicas", "kind"), r.Kind, []string{"Deployment", "StatefulSet", "DaemonSet"}))
+	}
+	if r.Namespace == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("readyReplicas", "namespace"), "namespace must be specified"))
+	}
+	if r.Name == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("readyReplicas", "name"), "name must be specified"))
+	}
+	if r.MinReadyReplicas != nil && *r.MinReadyReplicas < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("readyReplicas", "minReadyReplicas"), *r.MinReadyReplicas, "Cannot be negative"))
+	}
+
+	return allErrs
+}
+
 func validateKubeAPIServer(v *kops.KubeAPIServerConfig, fldPath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
 
@@ -19,10 +19,12 @@ package validation
 import (
 	"testing"
 
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/upup/pkg/fi"
 )
 
 func Test_Validate_DNS(t *testing.T) {
@@ -335,3 +337,138 @@ func Test_Validate_Calico(t *testing.T) {
 		testErrors(t, g.Input, errs, g.ExpectedErrors)
 	}
 }
+
+func intStr(i intstr.IntOrString) *intstr.IntOrString {
+	return &i
+}
+
+func Test_Validate_RollingUpdate(t *testing.T) {
+	instanceRefresh := kops.RollingUpdateStrategyInstanceRefresh
+	unknownStrategy := kops.RollingUpdateStrategy("Unknown")
+
+	grid := []struct {
+		Input          kops.RollingUpdate
+		ExpectedErrors []string
+	}{
+		{
+			Input: kops.RollingUpdate{},
+		},
+		{
+			Input: kops.RollingUpdate{
+				MaxUnavailable: intStr(intstr.FromInt(0)),
+				MaxSurge:       intStr(intstr.FromString("20%")),
+			},
+		},
+		{
+			Input: kops.RollingUpdate{
+				MaxUnavailable: intStr(intstr.FromString("25%")),
+			},
+		},
+		{
+			Input: kops.RollingUpdate{
+				MaxUnavailable: intStr(intstr.FromInt(-1)),
+			},
+			ExpectedErrors: []string{"Invalid value::RollingUpdate.maxUnavailable"},
+		},
+		{
+			Input: kops.RollingUpdate{
+				MaxSurge: intStr(intstr.FromString("nope")),
+			},
+			ExpectedErrors: []string{"Invalid value::RollingUpdate.maxSurge"},
+		},
+		{
+			Input: kops.RollingUpdate{
+				MaxUnavailable: intStr(intstr.FromInt(0)),
+			},
+			ExpectedErrors: []string{"Forbidden::RollingUpdate.maxUnavailable"},
+		},
+		{
+			Input: kops.RollingUpdate{
+				MaxUnavailable: intStr(intstr.FromString("0%")),
+				MaxSurge:       intStr(intstr.FromInt(0)),
+			},
+			ExpectedErrors: []string{"Forbidden::RollingUpdate.maxUnavailable"},
+		},
+		{
+			Input: kops.RollingUpdate{
+				Strategy:             &instanceRefresh,
+				MinHealthyPercentage: fi.Int32(80),
+			},
+		},
+		{
+			Input: kops.RollingUpdate{
+				Strategy: &instanceRefresh,
+				MaxSurge: intStr(intstr.FromInt(1)),
+			},
+			ExpectedErrors: []string{"Forbidden::RollingUpdate.maxSurge"},
+		},
+		{
+			Input: kops.RollingUpdate{
+				Strategy:             &unknownStrategy,
+				MinHealthyPercentage: fi.Int32(101),
+			},
+			ExpectedErrors: []string{"Unsupported value::RollingUpdate.strategy", "Invalid value::RollingUpdate.minHealthyPercentage"},
+		},
+	}
+	for _, g := range grid {
+		errs := validateRollingUpdate(&g.Input, field.NewPath("RollingUpdate"))
+		testErrors(t, g.Input, errs, g.ExpectedErrors)
+	}
+}
+
+func Test_Validate_ClusterValidationCheck(t *testing.T) {
+	grid := []struct {
+		Input          kops.ClusterValidationCheck
+		ExpectedErrors []string
+	}{
+		{
+			Input: kops.ClusterValidationCheck{
+				Name: "ingress",
+				ReadyReplicas: &kops.ReadyReplicasCheck{
+					Kind:      "Deployment",
+					Namespace: "ingress",
+					Name:      "nginx-ingress",
+				},
+			},
+		},
+		{
+			Input: kops.ClusterValidationCheck{
+				Name:     "logging",
+				Severity: kops.ValidationSeverityWarning,
+				ReadyReplicas: &kops.ReadyReplicasCheck{
+					Kind:             "DaemonSet",
+					Namespace:        "logging",
+					Name:             "fluentd",
+					MinReadyReplicas: fi.Int32(3),
+				},
+			},
+		},
+		{
+			Input: kops.ClusterValidationCheck{
+				Name: "nothing",
+			},
+			ExpectedErrors: []string{"Required value::Check.readyReplicas"},
+		},
+		{
+			Input: kops.ClusterValidationCheck{
+				Severity: "Fatal",
+				ReadyReplicas: &kops.ReadyReplicasCheck{
+					Kind:             "Pod",
+					MinReadyReplicas: fi.Int32(-1),
+				},
+			},
+			ExpectedErrors: []string{
+				"Required value::Check.name",
+				"Unsupported value::Check.severity",
+				"Unsupported value::Check.readyReplicas.kind",
+				"Required value::Check.readyReplicas.namespace",
+				"Required value::Check.readyReplicas.name",
+				"Invalid value::Check.readyReplicas.minReadyReplicas",
+			},
+		},
+	}
+	for _, g := range grid {
+		errs := validateClusterValidationCheck(&g.Input, field.NewPath("Check"))
+		testErrors(t, g.Input, errs, g.ExpectedErrors)
+	}
+}
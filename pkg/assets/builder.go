@@ -147,6 +147,17 @@ func (a *AssetBuilder) RemapImage(image string) (string, error) {
 		}
 	}
 
+	if strings.HasPrefix(image, "kope/node-drainer:") {
+		// The node-drainer image is not published yet, so it must be built and pushed first:
+		// 1. DOCKER_REGISTRY=[your docker hub repo] DOCKER_TAG=[tag] make push-node-drainer
+		// 2. export NODEDRAINER_IMAGE=[your docker hub repo]/node-drainer:[tag]
+		// 3. make kops and create/apply cluster
+		override := os.Getenv("NODEDRAINER_IMAGE")
+		if override != "" {
+			image = override
+		}
+	}
+
 	if a.AssetsLocation != nil && a.AssetsLocation.ContainerProxy != nil {
 		containerProxy := strings.TrimRight(*a.AssetsLocation.ContainerProxy, "/")
 		normalized := image
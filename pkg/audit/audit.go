@@ -0,0 +1,233 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/kops/pkg/acls"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/apis/kops/registry"
+	"k8s.io/kops/pkg/util/localuser"
+	"k8s.io/kops/upup/pkg/fi/utils"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+// PathAudit is the directory under the ConfigBase of a cluster that holds its audit records
+const PathAudit = "audit"
+
+// Redacted replaces the values of arguments that look like secrets
+const Redacted = "REDACTED"
+
+// Outcome is the result of an audited command
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "Success"
+	OutcomeFailure Outcome = "Failure"
+)
+
+// Record is the audit record of a kops command that changed a cluster.
+// Each record is written to its own file with CreateFile, and records are never changed once written.
+type Record struct {
+	// ID identifies the record; it is also the name of its file
+	ID string `json:"id"`
+	// Timestamp is when the command started
+	Timestamp time.Time `json:"timestamp"`
+	// User is the local user and host that ran the command
+	User string `json:"user"`
+	// Command is the command that was run, e.g. kops update cluster
+	Command string `json:"command"`
+	// Args are the positional arguments, with secret values redacted
+	Args []string `json:"args,omitempty"`
+	// Flags are the flags that were set, with secret values redacted
+	Flags map[string]string `json:"flags,omitempty"`
+	// KopsVersion is the version of kops that ran the command
+	KopsVersion string `json:"kopsVersion"`
+	// SpecHashBefore is the hash of the cluster and instance group specs before the command, if they existed
+	SpecHashBefore string `json:"specHashBefore,omitempty"`
+	// SpecHashAfter is the hash of the cluster and instance group specs after the command
+	SpecHashAfter string `json:"specHashAfter,omitempty"`
+	// Outcome is whether the command succeeded
+	Outcome Outcome `json:"outcome"`
+	// Error is the error the command failed with
+	Error string `json:"error,omitempty"`
+}
+
+// secretNames matches the names of flags and settings whose values are secrets
+var secretNames = regexp.MustCompile(`(?i)(password|passwd|secret|token|credential|private)`)
+
+// RedactValue returns the value of the named flag, or Redacted if the name looks like it holds a secret
+func RedactValue(name string, value string) string {
+	if secretNames.MatchString(name) {
+		return Redacted
+	}
+	// Flags such as --override and --set take a list of settings, which is shown as [a=b,c=d]
+	list := strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+	var settings []string
+	for _, setting := range strings.Split(list, ",") {
+		settings = append(settings, redactSetting(setting))
+	}
+	return strings.Replace(value, list, strings.Join(settings, ","), 1)
+}
+
+// RedactArgs returns the positional arguments with secret values redacted
+func RedactArgs(args []string) []string {
+	var redacted []string
+	for _, arg := range args {
+		redacted = append(redacted, redactSetting(arg))
+	}
+	return redacted
+}
+
+// redactSetting redacts the value of a key=value setting (as taken by kops set cluster or --override) if the key
+// looks like it holds a secret
+func redactSetting(s string) string {
+	tokens := strings.SplitN(s, "=", 2)
+	if len(tokens) == 2 && secretNames.MatchString(tokens[0]) {
+		return tokens[0] + "=" + Redacted
+	}
+	return s
+}
+
+// NewRecord starts a record for the command, with the spec hash from before it runs
+func NewRecord(command string, args []string, flags map[string]string, kopsVersion string) (*Record, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("error generating audit record id: %v", err)
+	}
+	now := time.Now().UTC()
+
+	redactedFlags := make(map[string]string)
+	for k, v := range flags {
+		redactedFlags[k] = RedactValue(k, v)
+	}
+
+	return &Record{
+		// The timestamp comes first so the records sort in order
+		ID:          now.Format("20060102T150405Z") + "-" + hex.EncodeToString(b),
+		Timestamp:   now,
+		User:        localuser.Describe(),
+		Command:     command,
+		Args:        RedactArgs(args),
+		Flags:       redactedFlags,
+		KopsVersion: kopsVersion,
+	}, nil
+}
+
+// Finish sets the outcome of the record
+func (r *Record) Finish(err error) {
+	if err != nil {
+		r.Outcome = OutcomeFailure
+		r.Error = err.Error()
+	} else {
+		r.Outcome = OutcomeSuccess
+	}
+}
+
+// SpecHash returns the hash of the cluster and instance group specs under the ConfigBase, or "" if there is no cluster
+func SpecHash(configBase vfs.Path) (string, error) {
+	config, err := configBase.Join(registry.PathCluster).ReadFile()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("error reading cluster spec: %v", err)
+	}
+
+	hasher := sha256.New()
+	hasher.Write(config)
+
+	instanceGroups, err := configBase.Join("instancegroup").ReadDir()
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("error listing instance groups: %v", err)
+	}
+	sort.Slice(instanceGroups, func(i, j int) bool {
+		return instanceGroups[i].Base() < instanceGroups[j].Base()
+	})
+	for _, p := range instanceGroups {
+		data, err := p.ReadFile()
+		if err != nil {
+			return "", fmt.Errorf("error reading instance group %s: %v", p, err)
+		}
+		hash := sha256.Sum256(data)
+		fmt.Fprintf(hasher, "\n%s %s", p.Base(), hex.EncodeToString(hash[:]))
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// Write writes the record under the ConfigBase of the cluster
+func Write(cluster *kops.Cluster, configBase vfs.Path, record *Record) error {
+	data, err := utils.YamlMarshal(record)
+	if err != nil {
+		return fmt.Errorf("error serializing audit record: %v", err)
+	}
+
+	p := configBase.Join(PathAudit, record.ID)
+	acl, err := acls.GetACL(p, cluster)
+	if err != nil {
+		return err
+	}
+	if err := p.CreateFile(bytes.NewReader(data), acl); err != nil {
+		return fmt.Errorf("error writing audit record %s: %v", p, err)
+	}
+	return nil
+}
+
+// List returns the audit records of the cluster, oldest first
+func List(configBase vfs.Path) ([]*Record, error) {
+	files, err := configBase.Join(PathAudit).ReadDir()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error listing audit records: %v", err)
+	}
+
+	var records []*Record
+	for _, f := range files {
+		data, err := f.ReadFile()
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("error reading audit record %s: %v", f, err)
+		}
+		record := &Record{}
+		if err := utils.YamlUnmarshal(data, record); err != nil {
+			glog.Warningf("ignoring audit record %s that could not be parsed: %v", f, err)
+			continue
+		}
+		records = append(records, record)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].ID < records[j].ID
+	})
+	return records, nil
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+func TestRedact(t *testing.T) {
+	grid := []struct {
+		Name     string
+		Value    string
+		Expected string
+	}{
+		{Name: "weave-password", Value: "hunter2", Expected: Redacted},
+		{Name: "name", Value: "cluster.example.com", Expected: "cluster.example.com"},
+		{Name: "override", Value: "[cluster.spec.nodePortAccess=10.0.0.0/8,cluster.spec.authentication.token=abc]", Expected: "[cluster.spec.nodePortAccess=10.0.0.0/8,cluster.spec.authentication.token=REDACTED]"},
+	}
+	for _, g := range grid {
+		if actual := RedactValue(g.Name, g.Value); actual != g.Expected {
+			t.Errorf("unexpected redaction of --%s=%s: expected %q, got %q", g.Name, g.Value, g.Expected, actual)
+		}
+	}
+
+	args := RedactArgs([]string{"cluster.example.com", "spec.kubeAPIServer.oidcClientSecret=abc", "spec.kubernetesVersion=1.11.0"})
+	expected := []string{"cluster.example.com", "spec.kubeAPIServer.oidcClientSecret=REDACTED", "spec.kubernetesVersion=1.11.0"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("unexpected redacted args: %v", args)
+	}
+}
+
+func TestWriteAndList(t *testing.T) {
+	configBase := vfs.NewMemFSPath(vfs.NewMemFSContext(), "cluster.example.com")
+	cluster := &kops.Cluster{}
+	cluster.ObjectMeta.Name = "cluster.example.com"
+
+	before, err := SpecHash(configBase)
+	if err != nil {
+		t.Fatalf("error hashing missing spec: %v", err)
+	}
+	if before != "" {
+		t.Fatalf("expected empty hash for missing spec, got %q", before)
+	}
+
+	if err := configBase.Join("config").WriteFile(bytes.NewReader([]byte("cluster")), nil); err != nil {
+		t.Fatalf("error writing config: %v", err)
+	}
+	hashCluster, err := SpecHash(configBase)
+	if err != nil {
+		t.Fatalf("error hashing spec: %v", err)
+	}
+	if err := configBase.Join("instancegroup", "nodes").WriteFile(bytes.NewReader([]byte("nodes")), nil); err != nil {
+		t.Fatalf("error writing instance group: %v", err)
+	}
+	hashNodes, err := SpecHash(configBase)
+	if err != nil {
+		t.Fatalf("error hashing spec: %v", err)
+	}
+	if hashCluster == "" || hashCluster == hashNodes {
+		t.Fatalf("expected instance groups to change the spec hash: %q %q", hashCluster, hashNodes)
+	}
+
+	for i, outcome := range []error{nil, fmt.Errorf("something went wrong")} {
+		record, err := NewRecord("kops update cluster", []string{"cluster.example.com"}, map[string]string{"yes": "true"}, "1.11.0")
+		if err != nil {
+			t.Fatalf("error building record: %v", err)
+		}
+		record.ID = fmt.Sprintf("%d-%s", i, record.ID)
+		record.SpecHashAfter = hashNodes
+		record.Finish(outcome)
+		if err := Write(cluster, configBase, record); err != nil {
+			t.Fatalf("error writing record: %v", err)
+		}
+		if err := Write(cluster, configBase, record); err == nil {
+			t.Fatalf("expected error overwriting record")
+		}
+	}
+
+	records, err := List(configBase)
+	if err != nil {
+		t.Fatalf("error listing records: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("unexpected records: %v", records)
+	}
+	if records[0].Outcome != OutcomeSuccess || records[1].Outcome != OutcomeFailure || records[1].Error != "something went wrong" {
+		t.Fatalf("unexpected outcomes: %v %v", records[0], records[1])
+	}
+	if records[0].Command != "kops update cluster" || records[0].Flags["yes"] != "true" || records[0].SpecHashAfter != hashNodes || records[0].User == "" {
+		t.Fatalf("unexpected record: %v", records[0])
+	}
+}
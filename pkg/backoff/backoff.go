@@ -0,0 +1,56 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backoff
+
+import (
+	"math"
+	"time"
+)
+
+// Backoff is an exponential backoff: the pause doubles after each consecutive failure, up to a maximum.
+// Unlike the global backoff, it holds no state, so the caller counts the failures.
+// The zero value never pauses.
+type Backoff struct {
+	// Initial is the pause after the first failure
+	Initial time.Duration
+	// Max is the maximum pause; if zero the pause is not capped
+	Max time.Duration
+}
+
+// IsZero returns true if the backoff never pauses
+func (b Backoff) IsZero() bool {
+	return b.Initial <= 0
+}
+
+// Duration returns the pause after the given number of consecutive failures, counting from 1
+func (b Backoff) Duration(failures int) time.Duration {
+	if b.IsZero() || failures <= 0 {
+		return 0
+	}
+
+	v := b.Initial
+	for i := 1; i < failures && v < math.MaxInt64/2; i++ {
+		v = v + v
+		if b.Max != 0 && v >= b.Max {
+			return b.Max
+		}
+	}
+	if b.Max != 0 && v > b.Max {
+		v = b.Max
+	}
+	return v
+}
@@ -44,3 +44,31 @@ func TestBackoff(t *testing.T) {
 		}
 	}
 }
+
+func TestBackoffDuration(t *testing.T) {
+	b := Backoff{Initial: time.Second, Max: 10 * time.Second}
+	expected := []time.Duration{
+		0,
+		1 * time.Second,
+		2 * time.Second,
+		4 * time.Second,
+		8 * time.Second,
+		10 * time.Second,
+		10 * time.Second,
+	}
+
+	for failures := range expected {
+		actual := b.Duration(failures)
+		if actual != expected[failures] {
+			t.Errorf("unexpected backoff after %d failures: %v", failures, actual)
+		}
+	}
+
+	if d := b.Duration(1000); d != 10*time.Second {
+		t.Errorf("unexpected backoff after 1000 failures: %v", d)
+	}
+
+	if d := (Backoff{}).Duration(5); d != 0 {
+		t.Errorf("unexpected backoff for zero value: %v", d)
+	}
+}
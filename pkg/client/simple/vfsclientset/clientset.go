@@ -74,21 +74,34 @@ func (c *VFSClientset) InstanceGroupsFor(cluster *kops.Cluster) kopsinternalvers
 	return newInstanceGroupVFS(c, cluster)
 }
 
-func (c *VFSClientset) SecretStore(cluster *kops.Cluster) (fi.SecretStore, error) {
+// storeBasedir returns the directory of the secret store or keystore.
+// They are normally kept in the state store and mirrored to the path in the cluster spec, but if that is
+// a secrets manager (such as Vault) they are kept there instead, so that they are never written to the state store.
+func storeBasedir(cluster *kops.Cluster, specPath string, name string) (vfs.Path, error) {
+	if strings.HasPrefix(specPath, "vault://") {
+		return vfs.Context.BuildVfsPath(specPath)
+	}
+
 	configBase, err := registry.ConfigBase(cluster)
 	if err != nil {
 		return nil, err
 	}
-	basedir := configBase.Join("secrets")
+	return vfs.Context.WithEncryption(configBase.Join(name))
+}
+
+func (c *VFSClientset) SecretStore(cluster *kops.Cluster) (fi.SecretStore, error) {
+	basedir, err := storeBasedir(cluster, cluster.Spec.SecretStore, "secrets")
+	if err != nil {
+		return nil, err
+	}
 	return secrets.NewVFSSecretStore(cluster, basedir), nil
 }
 
 func (c *VFSClientset) KeyStore(cluster *kops.Cluster) (fi.CAStore, error) {
-	configBase, err := registry.ConfigBase(cluster)
+	basedir, err := storeBasedir(cluster, cluster.Spec.KeyStore, "pki")
 	if err != nil {
 		return nil, err
 	}
-	basedir := configBase.Join("pki")
 	return fi.NewVFSCAStore(cluster, basedir, c.allowList), nil
 }
 
@@ -117,7 +130,7 @@ func DeleteAllClusterState(basePath vfs.Path) error {
 			continue
 		}
 
-		if relativePath == "config" || relativePath == "cluster.spec" {
+		if relativePath == "config" || relativePath == "cluster.spec" || relativePath == "lock" {
 			continue
 		}
 		if strings.HasPrefix(relativePath, "addons/") {
@@ -135,6 +148,15 @@ func DeleteAllClusterState(basePath vfs.Path) error {
 		if strings.HasPrefix(relativePath, "manifests/") {
 			continue
 		}
+		if strings.HasPrefix(relativePath, "rollingupdate/") {
+			continue
+		}
+		if strings.HasPrefix(relativePath, "history/") {
+			continue
+		}
+		if strings.HasPrefix(relativePath, "audit/") {
+			continue
+		}
 		// TODO: offer an option _not_ to delete backups?
 		if strings.HasPrefix(relativePath, "backups/") {
 			continue
@@ -33,6 +33,7 @@ import (
 	"k8s.io/kops/pkg/apis/kops/registry"
 	"k8s.io/kops/pkg/apis/kops/v1alpha1"
 	"k8s.io/kops/pkg/apis/kops/validation"
+	"k8s.io/kops/pkg/statehistory"
 	"k8s.io/kops/util/pkg/vfs"
 )
 
@@ -118,6 +119,8 @@ func (r *ClusterVFS) Create(c *api.Cluster) (*api.Cluster, error) {
 		return nil, fmt.Errorf("error writing Cluster %q: %v", c.ObjectMeta.Name, err)
 	}
 
+	r.recordClusterHistory(c)
+
 	return c, nil
 }
 
@@ -141,15 +144,32 @@ func (r *ClusterVFS) Update(c *api.Cluster, status *api.ClusterStatus) (*api.Clu
 	}
 
 	if err := r.writeConfig(c, r.basePath.Join(clusterName, registry.PathCluster), c, vfs.WriteOptionOnlyIfExists); err != nil {
-		if os.IsNotExist(err) {
+		if os.IsNotExist(err) || errors.IsConflict(err) {
 			return nil, err
 		}
 		return nil, fmt.Errorf("error writing Cluster: %v", err)
 	}
 
+	r.recordClusterHistory(c)
+
 	return c, nil
 }
 
+// recordClusterHistory records a write of the cluster in its history, which is kept under its own ConfigBase.
+// The write has already been made, so failures are logged rather than returned.
+func (r *ClusterVFS) recordClusterHistory(c *api.Cluster) {
+	data, err := r.serialize(c)
+	if err != nil {
+		glog.Warningf("error serializing Cluster %q for history: %v", c.ObjectMeta.Name, err)
+		return
+	}
+
+	history := statehistory.NewStore(c, r.basePath.Join(c.ObjectMeta.Name))
+	if _, err := history.Record(r.kind, c.ObjectMeta.Name, data); err != nil {
+		glog.Warningf("error recording history of Cluster %q: %v", c.ObjectMeta.Name, err)
+	}
+}
+
 // List returns a slice containing all the cluster names
 // It skips directories that don't look like clusters
 func (r *ClusterVFS) listNames() ([]string, error) {
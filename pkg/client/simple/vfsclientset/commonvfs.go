@@ -22,9 +22,11 @@ import (
 	"os"
 	"reflect"
 	"sort"
+	"strconv"
 	"time"
 
 	"github.com/golang/glog"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -34,6 +36,7 @@ import (
 	"k8s.io/kops/pkg/apis/kops"
 	"k8s.io/kops/pkg/apis/kops/v1alpha2"
 	"k8s.io/kops/pkg/kopscodecs"
+	"k8s.io/kops/pkg/statehistory"
 	"k8s.io/kops/util/pkg/vfs"
 )
 
@@ -48,6 +51,9 @@ type commonVFS struct {
 	encoder            runtime.Encoder
 	defaultReadVersion *schema.GroupVersionKind
 	validate           ValidationFunction
+
+	// history, if set, records every write to the state store
+	history *statehistory.Store
 }
 
 func (c *commonVFS) init(kind string, basePath vfs.Path, storeVersion runtime.GroupVersioner) {
@@ -104,9 +110,33 @@ func (c *commonVFS) create(cluster *kops.Cluster, i runtime.Object) error {
 		return fmt.Errorf("error writing %s: %v", c.kind, err)
 	}
 
+	c.recordHistory(objectMeta.GetName(), i)
+
 	return nil
 }
 
+// recordHistory records a write of the object in the history, if one is kept; o is nil if the object was deleted.
+// The write has already been made, so failures are logged rather than returned.
+func (c *commonVFS) recordHistory(name string, o runtime.Object) {
+	if c.history == nil {
+		return
+	}
+
+	var data []byte
+	if o != nil {
+		b, err := c.serialize(o)
+		if err != nil {
+			glog.Warningf("error serializing %s %q for history: %v", c.kind, name, err)
+			return
+		}
+		data = b
+	}
+
+	if _, err := c.history.Record(c.kind, name, data); err != nil {
+		glog.Warningf("error recording history of %s %q: %v", c.kind, name, err)
+	}
+}
+
 func (c *commonVFS) serialize(o runtime.Object) ([]byte, error) {
 	var b bytes.Buffer
 	err := c.encoder.Encode(o, &b)
@@ -134,29 +164,41 @@ func (c *commonVFS) readConfig(configPath vfs.Path) (runtime.Object, error) {
 }
 
 func (c *commonVFS) writeConfig(cluster *kops.Cluster, configPath vfs.Path, o runtime.Object, writeOptions ...vfs.WriteOption) error {
-	data, err := c.serialize(o)
-	if err != nil {
-		return fmt.Errorf("error marshalling object: %v", err)
-	}
-
 	create := false
+	// version is the version of the existing file, when the store can write only if the file is unchanged since we read it
+	version := ""
+	conditional, _ := configPath.(vfs.HasConditionalWrite)
 	for _, writeOption := range writeOptions {
 		switch writeOption {
 		case vfs.WriteOptionCreate:
 			create = true
 		case vfs.WriteOptionOnlyIfExists:
-			_, err = configPath.ReadFile()
+			var existing []byte
+			var err error
+			if conditional != nil {
+				existing, version, err = conditional.ReadFileVersion()
+			} else {
+				existing, err = configPath.ReadFile()
+			}
 			if err != nil {
 				if os.IsNotExist(err) {
 					return fmt.Errorf("cannot update configuration file %s: does not exist", configPath)
 				}
 				return fmt.Errorf("error checking if configuration file %s exists already: %v", configPath, err)
 			}
+			if err := c.nextResourceVersion(configPath, existing, o); err != nil {
+				return err
+			}
 		default:
 			return fmt.Errorf("unknown write option: %q", writeOption)
 		}
 	}
 
+	data, err := c.serialize(o)
+	if err != nil {
+		return fmt.Errorf("error marshalling object: %v", err)
+	}
+
 	acl, err := acls.GetACL(configPath, cluster)
 	if err != nil {
 		return err
@@ -165,6 +207,8 @@ func (c *commonVFS) writeConfig(cluster *kops.Cluster, configPath vfs.Path, o ru
 	rs := bytes.NewReader(data)
 	if create {
 		err = configPath.CreateFile(rs, acl)
+	} else if version != "" {
+		err = conditional.WriteFileIfVersion(rs, acl, version)
 	} else {
 		err = configPath.WriteFile(rs, acl)
 	}
@@ -173,11 +217,65 @@ func (c *commonVFS) writeConfig(cluster *kops.Cluster, configPath vfs.Path, o ru
 			glog.Warningf("failed to create file as already exists: %v", configPath)
 			return err
 		}
+		if err == vfs.ErrVersionConflict {
+			objectMeta, metaErr := meta.Accessor(o)
+			if metaErr != nil {
+				return metaErr
+			}
+			return apierrors.NewConflict(schema.GroupResource{Group: kops.GroupName, Resource: c.kind}, objectMeta.GetName(),
+				fmt.Errorf("it was changed by someone else while it was being written; reload it and apply your changes again"))
+		}
 		return fmt.Errorf("error writing configuration file %s: %v", configPath, err)
 	}
 	return nil
 }
 
+// nextResourceVersion checks that the object being written is based on the existing object, then sets its resourceVersion.
+// The resourceVersion is stored with the object, and counts the changes to it; an object without a resourceVersion
+// (for example one read from a file) replaces the existing object unconditionally.
+// Where the state store supports conditional writes (S3, GCS, etcd) the write is then only made if the existing object
+// is unchanged; otherwise this detects, rather than prevents, almost all concurrent changes.
+func (c *commonVFS) nextResourceVersion(configPath vfs.Path, existingData []byte, o runtime.Object) error {
+	existing, _, err := c.decoder.Decode(existingData, c.defaultReadVersion, nil)
+	if err != nil {
+		return fmt.Errorf("error parsing %s: %v", configPath, err)
+	}
+	existingMeta, err := meta.Accessor(existing)
+	if err != nil {
+		return err
+	}
+	objectMeta, err := meta.Accessor(o)
+	if err != nil {
+		return err
+	}
+
+	current := existingMeta.GetResourceVersion()
+	if objectMeta.GetResourceVersion() != "" && objectMeta.GetResourceVersion() != current {
+		return apierrors.NewConflict(schema.GroupResource{Group: kops.GroupName, Resource: c.kind}, objectMeta.GetName(),
+			fmt.Errorf("it was changed by someone else (resourceVersion %s, expected %s); reload it and apply your changes again", current, objectMeta.GetResourceVersion()))
+	}
+
+	// Writing the object unchanged does not change its resourceVersion
+	objectMeta.SetResourceVersion(current)
+	data, err := c.serialize(o)
+	if err != nil {
+		return fmt.Errorf("error marshalling object: %v", err)
+	}
+	if bytes.Equal(data, existingData) {
+		return nil
+	}
+
+	version := 0
+	if current != "" {
+		version, err = strconv.Atoi(current)
+		if err != nil {
+			return fmt.Errorf("unexpected resourceVersion %q in %s", current, configPath)
+		}
+	}
+	objectMeta.SetResourceVersion(strconv.Itoa(version + 1))
+	return nil
+}
+
 func (c *commonVFS) update(cluster *kops.Cluster, i runtime.Object) error {
 	objectMeta, err := meta.Accessor(i)
 	if err != nil {
@@ -198,9 +296,14 @@ func (c *commonVFS) update(cluster *kops.Cluster, i runtime.Object) error {
 
 	err = c.writeConfig(cluster, c.basePath.Join(objectMeta.GetName()), i, vfs.WriteOptionOnlyIfExists)
 	if err != nil {
+		if apierrors.IsConflict(err) {
+			return err
+		}
 		return fmt.Errorf("error writing %s: %v", c.kind, err)
 	}
 
+	c.recordHistory(objectMeta.GetName(), i)
+
 	return nil
 }
 
@@ -213,6 +316,9 @@ func (c *commonVFS) delete(name string, options *metav1.DeleteOptions) error {
 		}
 		return fmt.Errorf("error deleting %s configuration %q: %v", c.kind, name, err)
 	}
+
+	c.recordHistory(name, nil)
+
 	return nil
 }
 
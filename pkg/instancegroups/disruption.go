@@ -0,0 +1,244 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+// disruptionGuard coordinates the draining of nodes in instance groups that are rolled concurrently,
+// so that between them they do not evict more pods than a PodDisruptionBudget allows.
+// Each drain reserves room in every budget covering the pods on the node, and only proceeds once the budgets
+// have room for it, after the evictions the other drains in flight have still to make.
+// The eviction API enforces the budgets in the end; the guard stops concurrent drains from racing each other
+// for the same disruptions, and from waiting out the validation timeout on evictions that can never succeed.
+type disruptionGuard struct {
+	k8sClient kubernetes.Interface
+
+	// pollInterval is how often we recheck the budgets while waiting for room
+	pollInterval time.Duration
+
+	mutex sync.Mutex
+	// reserved is the room reserved by each drain in progress, keyed by node and then by PodDisruptionBudget
+	reserved map[string]map[string]int
+}
+
+// newDisruptionGuard builds a disruptionGuard that reads budgets and pods using the given client
+func newDisruptionGuard(k8sClient kubernetes.Interface) *disruptionGuard {
+	return &disruptionGuard{
+		k8sClient:    k8sClient,
+		pollInterval: 10 * time.Second,
+		reserved:     make(map[string]map[string]int),
+	}
+}
+
+// budgetUsage is the number of pods on a node covered by a PodDisruptionBudget
+type budgetUsage struct {
+	// pods is the number of pods on the node selected by the budget
+	pods int
+	// allowed is the number of disruptions the budget currently allows
+	allowed int
+}
+
+// required returns the room a drain needs in the budget before it starts.
+// A node can hold more pods of a budget than it allows to be disrupted at once, in which case the drain only waits
+// for all the disruptions the budget allows, and the eviction retries wait for the rest as the evicted pods recover.
+func (u *budgetUsage) required() int {
+	required := u.pods
+	if required > u.allowed {
+		required = u.allowed
+	}
+	if required < 1 {
+		required = 1
+	}
+	return required
+}
+
+// acquire waits until every PodDisruptionBudget covering the pods on the node has room for them to be evicted,
+// taking into account the drains already in progress, and reserves that room.
+// The returned function must be called to release the reservation, once the evicted pods are expected to be running again.
+func (g *disruptionGuard) acquire(nodeName string, timeout time.Duration) (func(), error) {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		usage, err := g.budgetsForNode(nodeName)
+		if err != nil {
+			return nil, err
+		}
+		remaining, err := g.remainingEvictions()
+		if err != nil {
+			return nil, err
+		}
+
+		blocked := g.tryReserve(nodeName, usage, remaining)
+		if len(blocked) == 0 {
+			return func() { g.release(nodeName) }, nil
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("PodDisruptionBudgets %s did not allow node %q to be drained within %s", strings.Join(blocked, ", "), nodeName, timeout)
+		}
+
+		glog.Infof("Waiting to drain node %q, as PodDisruptionBudgets %s do not currently allow its pods to be evicted", nodeName, strings.Join(blocked, ", "))
+		time.Sleep(g.pollInterval)
+	}
+}
+
+// remainingEvictions returns, for each node being drained, the number of pods covered by each budget that are still on it.
+// Pods that have been evicted are already counted against the disruptions a budget allows, so they must not also
+// be counted against the reservation of the drain that evicted them.
+func (g *disruptionGuard) remainingEvictions() (map[string]map[string]int, error) {
+	g.mutex.Lock()
+	var nodeNames []string
+	for nodeName := range g.reserved {
+		nodeNames = append(nodeNames, nodeName)
+	}
+	g.mutex.Unlock()
+
+	remaining := make(map[string]map[string]int)
+	for _, nodeName := range nodeNames {
+		usage, err := g.budgetsForNode(nodeName)
+		if err != nil {
+			return nil, err
+		}
+		remaining[nodeName] = make(map[string]int)
+		for key, u := range usage {
+			remaining[nodeName][key] = u.pods
+		}
+	}
+	return remaining, nil
+}
+
+// tryReserve reserves room in every budget, returning the names of the budgets that do not have room (in which case nothing is reserved).
+// remaining is the result of remainingEvictions; drains that started since it was computed are counted in full.
+func (g *disruptionGuard) tryReserve(nodeName string, usage map[string]*budgetUsage, remaining map[string]map[string]int) []string {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	var blocked []string
+	for key, u := range usage {
+		pending := 0
+		for other, reserved := range g.reserved {
+			n := reserved[key]
+			if counts, found := remaining[other]; found && counts[key] < n {
+				n = counts[key]
+			}
+			pending += n
+		}
+		if u.allowed-pending >= u.required() {
+			continue
+		}
+		blocked = append(blocked, key)
+	}
+
+	if len(blocked) != 0 {
+		sort.Strings(blocked)
+		return blocked
+	}
+
+	reserved := make(map[string]int)
+	for key, u := range usage {
+		reserved[key] = u.required()
+	}
+	g.reserved[nodeName] = reserved
+	return nil
+}
+
+// release returns the room reserved by tryReserve for the node
+func (g *disruptionGuard) release(nodeName string) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	delete(g.reserved, nodeName)
+}
+
+// budgetsForNode returns the PodDisruptionBudgets, in any namespace, that cover the pods that draining the node would evict
+func (g *disruptionGuard) budgetsForNode(nodeName string) (map[string]*budgetUsage, error) {
+	budgets, err := g.k8sClient.PolicyV1beta1().PodDisruptionBudgets(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing PodDisruptionBudgets: %v", err)
+	}
+
+	usage := make(map[string]*budgetUsage)
+	if len(budgets.Items) == 0 {
+		return usage, nil
+	}
+
+	pods, err := g.k8sClient.CoreV1().Pods(metav1.NamespaceAll).List(metav1.ListOptions{
+		FieldSelector: "spec.nodeName=" + nodeName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing pods on node %q: %v", nodeName, err)
+	}
+
+	for i := range budgets.Items {
+		budget := &budgets.Items[i]
+		if budget.Spec.Selector == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(budget.Spec.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing selector of PodDisruptionBudget %s/%s: %v", budget.Namespace, budget.Name, err)
+		}
+		// An empty selector matches no pods
+		if selector.Empty() {
+			continue
+		}
+
+		key := budget.Namespace + "/" + budget.Name
+		for j := range pods.Items {
+			pod := &pods.Items[j]
+			if pod.Spec.NodeName != nodeName || pod.Namespace != budget.Namespace || !isEvictable(pod) {
+				continue
+			}
+			if !selector.Matches(labels.Set(pod.Labels)) {
+				continue
+			}
+
+			if usage[key] == nil {
+				usage[key] = &budgetUsage{allowed: int(budget.Status.PodDisruptionsAllowed)}
+			}
+			usage[key].pods++
+		}
+	}
+
+	return usage, nil
+}
+
+// isEvictable returns false for pods that draining a node leaves alone, or that have already finished
+func isEvictable(pod *corev1.Pod) bool {
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return false
+	}
+	if controllerRef := metav1.GetControllerOf(pod); controllerRef != nil && controllerRef.Kind == "DaemonSet" {
+		return false
+	}
+	if _, found := pod.Annotations[corev1.MirrorPodAnnotationKey]; found {
+		return false
+	}
+	return true
+}
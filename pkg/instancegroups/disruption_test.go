@@ -0,0 +1,191 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func testPod(name string, nodeName string, labels map[string]string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: v1meta.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    labels,
+		},
+		Spec: v1.PodSpec{
+			NodeName: nodeName,
+		},
+	}
+}
+
+func TestDisruptionGuard(t *testing.T) {
+	budget := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: v1meta.ObjectMeta{
+			Name:      "db",
+			Namespace: "default",
+		},
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			Selector: &v1meta.LabelSelector{
+				MatchLabels: map[string]string{"app": "db"},
+			},
+		},
+		Status: policyv1beta1.PodDisruptionBudgetStatus{
+			PodDisruptionsAllowed: 1,
+		},
+	}
+
+	k8sClient := fake.NewSimpleClientset(
+		budget,
+		testPod("db-0", "node-a", map[string]string{"app": "db"}),
+		testPod("db-1", "node-b", map[string]string{"app": "db"}),
+		testPod("web-0", "node-c", map[string]string{"app": "web"}),
+	)
+
+	g := newDisruptionGuard(k8sClient)
+	g.pollInterval = time.Millisecond
+
+	releaseA, err := g.acquire("node-a", time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error draining node-a: %v", err)
+	}
+
+	// node-b holds a pod of the same budget, which has no room left while node-a drains
+	if _, err := g.acquire("node-b", 10*time.Millisecond); err == nil {
+		t.Fatalf("expected node-b to be blocked by the budget while node-a drains")
+	}
+
+	// node-c holds no pods covered by a budget
+	releaseC, err := g.acquire("node-c", 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error draining node-c: %v", err)
+	}
+	releaseC()
+
+	releaseA()
+
+	releaseB, err := g.acquire("node-b", time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error draining node-b after node-a: %v", err)
+	}
+	releaseB()
+
+	if len(g.reserved) != 0 {
+		t.Errorf("expected no reservations left, got %v", g.reserved)
+	}
+}
+
+func TestDisruptionGuardMorePodsThanAllowed(t *testing.T) {
+	budget := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: v1meta.ObjectMeta{
+			Name:      "db",
+			Namespace: "default",
+		},
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			Selector: &v1meta.LabelSelector{
+				MatchLabels: map[string]string{"app": "db"},
+			},
+		},
+		Status: policyv1beta1.PodDisruptionBudgetStatus{
+			PodDisruptionsAllowed: 1,
+		},
+	}
+
+	k8sClient := fake.NewSimpleClientset(
+		budget,
+		testPod("db-0", "node-a", map[string]string{"app": "db"}),
+		testPod("db-1", "node-a", map[string]string{"app": "db"}),
+		testPod("db-2", "node-a", map[string]string{"app": "db"}),
+		testPod("db-3", "node-b", map[string]string{"app": "db"}),
+	)
+
+	g := newDisruptionGuard(k8sClient)
+	g.pollInterval = time.Millisecond
+
+	// node-a holds three pods of a budget that allows one disruption; it is drained, and the evictions wait for the rest
+	releaseA, err := g.acquire("node-a", 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error draining node-a: %v", err)
+	}
+	if g.reserved["node-a"]["default/db"] != 1 {
+		t.Errorf("expected node-a to reserve the one disruption the budget allows, got %v", g.reserved)
+	}
+
+	// The budget has no room left for node-b while node-a drains
+	if _, err := g.acquire("node-b", 10*time.Millisecond); err == nil {
+		t.Fatalf("expected node-b to be blocked by the budget while node-a drains")
+	}
+
+	releaseA()
+	if len(g.reserved) != 0 {
+		t.Errorf("expected no reservations left, got %v", g.reserved)
+	}
+}
+
+func TestDisruptionGuardCountsEvictedPodsOnce(t *testing.T) {
+	budget := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: v1meta.ObjectMeta{
+			Name:      "db",
+			Namespace: "default",
+		},
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			Selector: &v1meta.LabelSelector{
+				MatchLabels: map[string]string{"app": "db"},
+			},
+		},
+		Status: policyv1beta1.PodDisruptionBudgetStatus{
+			PodDisruptionsAllowed: 2,
+		},
+	}
+
+	k8sClient := fake.NewSimpleClientset(
+		budget,
+		testPod("db-0", "node-a", map[string]string{"app": "db"}),
+		testPod("db-1", "node-b", map[string]string{"app": "db"}),
+	)
+
+	g := newDisruptionGuard(k8sClient)
+	g.pollInterval = time.Millisecond
+
+	releaseA, err := g.acquire("node-a", 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error draining node-a: %v", err)
+	}
+	defer releaseA()
+
+	// node-a's pod is evicted, which the budget now counts against the disruptions it allows
+	if err := k8sClient.CoreV1().Pods("default").Delete("db-0", &v1meta.DeleteOptions{}); err != nil {
+		t.Fatalf("error deleting pod: %v", err)
+	}
+	budget.Status.PodDisruptionsAllowed = 1
+	if _, err := k8sClient.PolicyV1beta1().PodDisruptionBudgets("default").UpdateStatus(budget); err != nil {
+		t.Fatalf("error updating budget: %v", err)
+	}
+
+	// The eviction is not counted again against node-a's reservation, so node-b can be drained
+	releaseB, err := g.acquire("node-b", 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error draining node-b: %v", err)
+	}
+	releaseB()
+}
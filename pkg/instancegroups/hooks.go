@@ -0,0 +1,208 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	api "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+)
+
+// defaultHookTimeout is the maximum time a hook from the InstanceGroup spec may run, if it does not set a timeout
+const defaultHookTimeout = 5 * time.Minute
+
+// InstanceHook is custom logic run around the replacement of each instance during a rolling update
+type InstanceHook interface {
+	// Name identifies the hook in logs
+	Name() string
+	// Run is called at each event in the replacement of an instance; returning an error stops the rolling update
+	Run(hookContext *InstanceHookContext) error
+}
+
+// InstanceHookContext describes the event and instance a hook is run for
+type InstanceHookContext struct {
+	// Event is the point in the replacement of the instance that has been reached
+	Event api.RollingUpdateHookEvent `json:"event"`
+	// ClusterName is the name of the cluster being rolled
+	ClusterName string `json:"clusterName"`
+	// InstanceGroup is the name of the InstanceGroup the instance belongs to
+	InstanceGroup string `json:"instanceGroup"`
+	// InstanceID is the cloud ID of the instance
+	InstanceID string `json:"instanceID"`
+	// NodeName is the name of the kubernetes node for the instance, if it is registered
+	NodeName string `json:"nodeName,omitempty"`
+}
+
+// runHooks runs the hooks configured for the RollingUpdateCluster and for the instance's InstanceGroup
+func (r *RollingUpdateInstanceGroup) runHooks(rollingUpdateData *RollingUpdateCluster, cluster *api.Cluster, event api.RollingUpdateHookEvent, u *cloudinstances.CloudInstanceGroupMember) error {
+	var hooks []InstanceHook
+	hooks = append(hooks, rollingUpdateData.Hooks...)
+	hooks = append(hooks, buildSpecHooks(r.CloudGroup.InstanceGroup)...)
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	hookContext := &InstanceHookContext{
+		Event:         event,
+		ClusterName:   cluster.ObjectMeta.Name,
+		InstanceGroup: r.CloudGroup.InstanceGroup.ObjectMeta.Name,
+		InstanceID:    u.ID,
+	}
+	if u.Node != nil {
+		hookContext.NodeName = u.Node.Name
+	}
+
+	for _, hook := range hooks {
+		if err := hook.Run(hookContext); err != nil {
+			return fmt.Errorf("hook %q failed at %s for instance %q: %v", hook.Name(), event, u.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// buildSpecHooks builds the hooks configured in the InstanceGroup spec
+func buildSpecHooks(ig *api.InstanceGroup) []InstanceHook {
+	var hooks []InstanceHook
+	for i := range ig.Spec.RollingUpdateHooks {
+		hooks = append(hooks, &specHook{spec: &ig.Spec.RollingUpdateHooks[i]})
+	}
+	return hooks
+}
+
+// specHook is an InstanceHook configured in the InstanceGroup spec, running a command or calling a webhook
+type specHook struct {
+	spec *api.RollingUpdateHook
+}
+
+var _ InstanceHook = &specHook{}
+
+// Name implements InstanceHook::Name
+func (h *specHook) Name() string {
+	return h.spec.Name
+}
+
+// Run implements InstanceHook::Run, running the hook only for the events it is configured for, and applying its failure policy
+func (h *specHook) Run(hookContext *InstanceHookContext) error {
+	found := false
+	for _, event := range h.spec.Events {
+		if event == hookContext.Event {
+			found = true
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	timeout := defaultHookTimeout
+	if h.spec.Timeout != nil {
+		timeout = h.spec.Timeout.Duration
+	}
+
+	var output string
+	var err error
+	if h.spec.Exec != nil {
+		output, err = runExecHook(h.spec.Exec, hookContext, timeout)
+	} else if h.spec.Webhook != nil {
+		output, err = runWebhook(h.spec.Webhook, hookContext, timeout)
+	} else {
+		err = fmt.Errorf("neither exec nor webhook is set")
+	}
+
+	if err != nil {
+		if h.spec.FailurePolicy == api.RollingUpdateHookFailurePolicyContinue {
+			glog.Warningf("Hook %q failed at %s for instance %q, continuing as its failure policy is %s: %v", h.spec.Name, hookContext.Event, hookContext.InstanceID, h.spec.FailurePolicy, err)
+			return nil
+		}
+		return err
+	}
+
+	glog.Infof("Hook %q succeeded at %s for instance %q", h.spec.Name, hookContext.Event, hookContext.InstanceID)
+	if output != "" {
+		glog.V(2).Infof("Output of hook %q for instance %q: %s", h.spec.Name, hookContext.InstanceID, output)
+	}
+	return nil
+}
+
+// runExecHook runs the command, passing the details of the instance in environment variables
+func runExecHook(spec *api.RollingUpdateExecHook, hookContext *InstanceHookContext, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, spec.Command[0], spec.Command[1:]...)
+	cmd.Env = append(os.Environ(),
+		"KOPS_HOOK_EVENT="+string(hookContext.Event),
+		"KOPS_CLUSTER_NAME="+hookContext.ClusterName,
+		"KOPS_INSTANCE_GROUP="+hookContext.InstanceGroup,
+		"KOPS_INSTANCE_ID="+hookContext.InstanceID,
+		"KOPS_NODE_NAME="+hookContext.NodeName,
+	)
+
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return string(output), fmt.Errorf("command %q did not complete within %s", strings.Join(spec.Command, " "), timeout)
+	}
+	if err != nil {
+		return string(output), fmt.Errorf("error running command %q: %v: %s", strings.Join(spec.Command, " "), err, output)
+	}
+	return string(output), nil
+}
+
+// runWebhook posts the details of the instance as JSON to the webhook's URL
+func runWebhook(spec *api.RollingUpdateWebhook, hookContext *InstanceHookContext, timeout time.Duration) (string, error) {
+	body, err := json.Marshal(hookContext)
+	if err != nil {
+		return "", fmt.Errorf("error serializing webhook request: %v", err)
+	}
+
+	req, err := http.NewRequest("POST", spec.URL, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("error building webhook request for %q: %v", spec.URL, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range spec.Headers {
+		req.Header.Set(k, v)
+	}
+
+	client := &http.Client{Timeout: timeout}
+	response, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error calling webhook %q: %v", spec.URL, err)
+	}
+	defer response.Body.Close()
+
+	output, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", fmt.Errorf("error reading response from webhook %q: %v", spec.URL, err)
+	}
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return string(output), fmt.Errorf("webhook %q returned status %s: %s", spec.URL, response.Status, output)
+	}
+	return string(output), nil
+}
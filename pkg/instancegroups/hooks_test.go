@@ -0,0 +1,300 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/api/core/v1"
+	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/kops/cloudmock/aws/mockautoscaling"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/pkg/validation"
+	"k8s.io/kops/upup/pkg/fi/cloudup/awsup"
+)
+
+// recordingHook is an InstanceHook that records every event it sees
+type recordingHook struct {
+	mutex  sync.Mutex
+	events []string
+}
+
+func (h *recordingHook) Name() string {
+	return "recording"
+}
+
+func (h *recordingHook) Run(hookContext *InstanceHookContext) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.events = append(h.events, hookContext.InstanceID+":"+string(hookContext.Event))
+	return nil
+}
+
+// stubValidation replaces cluster validation with one that returns err, or passes when err is nil
+func stubValidation(err error) func() {
+	original := validateCluster
+	validateCluster = func(cluster *kopsapi.Cluster, instanceGroupList *kopsapi.InstanceGroupList, k8sClient kubernetes.Interface) (*validation.ValidationCluster, error) {
+		if err != nil {
+			return nil, err
+		}
+		return &validation.ValidationCluster{}, nil
+	}
+	return func() {
+		validateCluster = original
+	}
+}
+
+func TestRollingUpdateRunsHooks(t *testing.T) {
+	defer stubValidation(nil)()
+
+	k8sClient := fake.NewSimpleClientset()
+
+	mockcloud := awsup.BuildMockAWSCloud("us-east-1", "abc")
+	mockcloud.MockAutoscaling = &mockautoscaling.MockAutoscaling{}
+
+	cluster := &kopsapi.Cluster{}
+	cluster.Name = "test.k8s.local"
+
+	hook := &recordingHook{}
+	c := &RollingUpdateCluster{
+		Cloud:           mockcloud,
+		MasterInterval:  1 * time.Millisecond,
+		NodeInterval:    1 * time.Millisecond,
+		BastionInterval: 1 * time.Millisecond,
+		K8sClient:       k8sClient,
+		Hooks:           []InstanceHook{hook},
+	}
+
+	setUpCloud(c)
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	groups["node-1"] = &cloudinstances.CloudInstanceGroup{
+		InstanceGroup: &kopsapi.InstanceGroup{
+			ObjectMeta: v1meta.ObjectMeta{
+				Name: "node-1",
+			},
+			Spec: kopsapi.InstanceGroupSpec{
+				Role: kopsapi.InstanceGroupRoleNode,
+			},
+		},
+		NeedUpdate: []*cloudinstances.CloudInstanceGroupMember{
+			{
+				ID:   "node-1a",
+				Node: &v1.Node{},
+			},
+			{
+				ID:   "node-1b",
+				Node: &v1.Node{},
+			},
+		},
+	}
+
+	instanceGroupList := &kopsapi.InstanceGroupList{
+		Items: []kopsapi.InstanceGroup{*groups["node-1"].InstanceGroup},
+	}
+	if err := c.RollingUpdate(groups, cluster, instanceGroupList); err != nil {
+		t.Errorf("Error on rolling update: %v", err)
+	}
+
+	expected := []string{
+		"node-1a:BeforeDrain", "node-1a:AfterDrain", "node-1a:AfterTerminate", "node-1a:AfterValidate",
+		"node-1b:BeforeDrain", "node-1b:AfterDrain", "node-1b:AfterTerminate", "node-1b:AfterValidate",
+	}
+	if !reflect.DeepEqual(hook.events, expected) {
+		t.Errorf("unexpected hook events %v, expected %v", hook.events, expected)
+	}
+}
+
+func TestRollingUpdateSkipsAfterValidateHooksWhenValidationFails(t *testing.T) {
+	defer stubValidation(fmt.Errorf("node not ready"))()
+
+	k8sClient := fake.NewSimpleClientset()
+
+	mockcloud := awsup.BuildMockAWSCloud("us-east-1", "abc")
+	mockcloud.MockAutoscaling = &mockautoscaling.MockAutoscaling{}
+
+	cluster := &kopsapi.Cluster{}
+	cluster.Name = "test.k8s.local"
+
+	hook := &recordingHook{}
+	c := &RollingUpdateCluster{
+		Cloud:           mockcloud,
+		MasterInterval:  1 * time.Millisecond,
+		NodeInterval:    1 * time.Millisecond,
+		BastionInterval: 1 * time.Millisecond,
+		K8sClient:       k8sClient,
+		FailOnValidate:  false,
+		Hooks:           []InstanceHook{hook},
+	}
+
+	setUpCloud(c)
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	groups["node-1"] = &cloudinstances.CloudInstanceGroup{
+		InstanceGroup: &kopsapi.InstanceGroup{
+			ObjectMeta: v1meta.ObjectMeta{
+				Name: "node-1",
+			},
+			Spec: kopsapi.InstanceGroupSpec{
+				Role: kopsapi.InstanceGroupRoleNode,
+			},
+		},
+		NeedUpdate: []*cloudinstances.CloudInstanceGroupMember{
+			{
+				ID:   "node-1a",
+				Node: &v1.Node{},
+			},
+		},
+	}
+
+	instanceGroupList := &kopsapi.InstanceGroupList{
+		Items: []kopsapi.InstanceGroup{*groups["node-1"].InstanceGroup},
+	}
+	if err := c.RollingUpdate(groups, cluster, instanceGroupList); err != nil {
+		t.Errorf("Error on rolling update: %v", err)
+	}
+
+	expected := []string{
+		"node-1a:BeforeDrain", "node-1a:AfterDrain", "node-1a:AfterTerminate",
+	}
+	if !reflect.DeepEqual(hook.events, expected) {
+		t.Errorf("unexpected hook events %v, expected %v", hook.events, expected)
+	}
+}
+
+func TestSpecHookExec(t *testing.T) {
+	hookContext := &InstanceHookContext{
+		Event:      kopsapi.RollingUpdateHookBeforeDrain,
+		InstanceID: "i-1234",
+	}
+
+	grid := []struct {
+		Spec        kopsapi.RollingUpdateHook
+		ExpectError bool
+	}{
+		{
+			Spec: kopsapi.RollingUpdateHook{
+				Name:   "checks-instance",
+				Events: []kopsapi.RollingUpdateHookEvent{kopsapi.RollingUpdateHookBeforeDrain},
+				Exec:   &kopsapi.RollingUpdateExecHook{Command: []string{"sh", "-c", `test "$KOPS_INSTANCE_ID" = i-1234`}},
+			},
+		},
+		{
+			Spec: kopsapi.RollingUpdateHook{
+				Name:   "fails",
+				Events: []kopsapi.RollingUpdateHookEvent{kopsapi.RollingUpdateHookBeforeDrain},
+				Exec:   &kopsapi.RollingUpdateExecHook{Command: []string{"false"}},
+			},
+			ExpectError: true,
+		},
+		{
+			Spec: kopsapi.RollingUpdateHook{
+				Name:          "fails-but-continues",
+				Events:        []kopsapi.RollingUpdateHookEvent{kopsapi.RollingUpdateHookBeforeDrain},
+				Exec:          &kopsapi.RollingUpdateExecHook{Command: []string{"false"}},
+				FailurePolicy: kopsapi.RollingUpdateHookFailurePolicyContinue,
+			},
+		},
+		{
+			Spec: kopsapi.RollingUpdateHook{
+				Name:    "times-out",
+				Events:  []kopsapi.RollingUpdateHookEvent{kopsapi.RollingUpdateHookBeforeDrain},
+				Exec:    &kopsapi.RollingUpdateExecHook{Command: []string{"sleep", "10"}},
+				Timeout: &v1meta.Duration{Duration: 10 * time.Millisecond},
+			},
+			ExpectError: true,
+		},
+		{
+			Spec: kopsapi.RollingUpdateHook{
+				Name:   "other-event",
+				Events: []kopsapi.RollingUpdateHookEvent{kopsapi.RollingUpdateHookAfterTerminate},
+				Exec:   &kopsapi.RollingUpdateExecHook{Command: []string{"false"}},
+			},
+		},
+	}
+
+	for _, g := range grid {
+		h := &specHook{spec: &g.Spec}
+		err := h.Run(hookContext)
+		if g.ExpectError && err == nil {
+			t.Errorf("expected error from hook %q", g.Spec.Name)
+		}
+		if !g.ExpectError && err != nil {
+			t.Errorf("unexpected error from hook %q: %v", g.Spec.Name, err)
+		}
+	}
+}
+
+func TestSpecHookWebhook(t *testing.T) {
+	var received *InstanceHookContext
+	var token string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = r.Header.Get("X-Token")
+		received = &InstanceHookContext{}
+		if err := json.NewDecoder(r.Body).Decode(received); err != nil {
+			t.Errorf("error decoding webhook request: %v", err)
+		}
+		if received.InstanceID == "i-fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	h := &specHook{
+		spec: &kopsapi.RollingUpdateHook{
+			Name:   "notify",
+			Events: []kopsapi.RollingUpdateHookEvent{kopsapi.RollingUpdateHookAfterValidate},
+			Webhook: &kopsapi.RollingUpdateWebhook{
+				URL:     server.URL,
+				Headers: map[string]string{"X-Token": "secret"},
+			},
+		},
+	}
+
+	hookContext := &InstanceHookContext{
+		Event:         kopsapi.RollingUpdateHookAfterValidate,
+		ClusterName:   "test.k8s.local",
+		InstanceGroup: "nodes",
+		InstanceID:    "i-1234",
+		NodeName:      "node-1",
+	}
+	if err := h.Run(hookContext); err != nil {
+		t.Fatalf("unexpected error from webhook: %v", err)
+	}
+	if !reflect.DeepEqual(received, hookContext) {
+		t.Errorf("webhook received %v, expected %v", received, hookContext)
+	}
+	if token != "secret" {
+		t.Errorf("webhook received header %q, expected %q", token, "secret")
+	}
+
+	hookContext.InstanceID = "i-fail"
+	if err := h.Run(hookContext); err == nil {
+		t.Errorf("expected error from webhook returning a 503")
+	}
+}
@@ -100,9 +100,7 @@ func promptInteractive(upgradedHostId, upgradedHostName string) (stopPrompting b
 	return stopPrompting, err
 }
 
-// TODO: Temporarily increase size of ASG?
 // TODO: Remove from ASG first so status is immediately updated?
-// TODO: Batch termination, like a rolling-update
 
 // RollingUpdate performs a rolling update on a list of ec2 instances.
 func (r *RollingUpdateInstanceGroup) RollingUpdate(rollingUpdateData *RollingUpdateCluster, cluster *api.Cluster, instanceGroupList *api.InstanceGroupList, isBastion bool, sleepAfterTerminate time.Duration, validationTimeout time.Duration) (err error) {
@@ -121,19 +119,47 @@ func (r *RollingUpdateInstanceGroup) RollingUpdate(rollingUpdateData *RollingUpd
 		return fmt.Errorf("rollingUpdate is missing the InstanceGroupList")
 	}
 
-	update := r.CloudGroup.NeedUpdate
+	groupName := r.CloudGroup.InstanceGroup.ObjectMeta.Name
+
+	candidates := r.CloudGroup.NeedUpdate
 	if rollingUpdateData.Force {
-		update = append(update, r.CloudGroup.Ready...)
+		candidates = append(candidates, r.CloudGroup.Ready...)
+	}
+	update, planErr := rollingUpdateData.Progress.PlanInstances(groupName, candidates)
+	logProgressError(planErr)
+
+	// If we were interrupted while the group was surged, its current size and MaxSize include the surge
+	groupSize := r.CloudGroup.TargetSize
+	surge, surged := rollingUpdateData.Progress.Surge(groupName)
+	if surged {
+		glog.Infof("Group %q was surged to %d instances before the rolling-update was interrupted; its original size was %d", r.CloudGroup.HumanName, groupSize, surge.DesiredSize)
+		groupSize = surge.DesiredSize
+		r.CloudGroup.MaxSize = surge.MaxSize
 	}
 
 	if len(update) == 0 {
+		if surged {
+			return r.restoreSize(rollingUpdateData, groupSize)
+		}
 		return nil
 	}
 
+	settings, err := resolveSettings(cluster, r.CloudGroup.InstanceGroup, groupSize)
+	if err != nil {
+		return fmt.Errorf("error resolving rolling-update settings for group %q: %v", r.CloudGroup.HumanName, err)
+	}
+
+	// There is no point surging more instances than we are going to replace
+	if settings.maxSurge > len(update) {
+		settings.maxSurge = len(update)
+	}
+
 	if isBastion {
 		glog.V(3).Info("Not validating the cluster as instance is a bastion.")
 	} else if rollingUpdateData.CloudOnly {
 		glog.V(3).Info("Not validating cluster as validation is turned off via the cloud-only flag.")
+	} else if rollingUpdateData.Progress.ResumingValidatedGroup(groupName) {
+		glog.Info("Not validating the cluster as it validated before the rolling-update was interrupted.")
 	} else if featureflag.DrainAndValidateRollingUpdate.Enabled() {
 		if err = r.ValidateCluster(rollingUpdateData, cluster, instanceGroupList); err != nil {
 			if rollingUpdateData.FailOnValidate {
@@ -145,82 +171,149 @@ func (r *RollingUpdateInstanceGroup) RollingUpdate(rollingUpdateData *RollingUpd
 		}
 	}
 
-	for _, u := range update {
-		instanceId := u.ID
+	if settings.strategy == api.RollingUpdateStrategyInstanceRefresh {
+		return r.instanceRefresh(rollingUpdateData, cluster, instanceGroupList, isBastion, settings, update, sleepAfterTerminate, validationTimeout)
+	}
 
-		nodeName := ""
-		if u.Node != nil {
-			nodeName = u.Node.Name
+	if settings.maxSurge > 0 {
+		// We record the original size first, so that if we are killed while surged, a resumed rolling-update restores it
+		if !surged {
+			record := SurgeRecord{DesiredSize: groupSize, MaxSize: r.CloudGroup.MaxSize}
+			if err = rollingUpdateData.Progress.RecordSurge(groupName, record); err != nil {
+				return fmt.Errorf("not surging group %q, as its original size could not be recorded: %v", r.CloudGroup.HumanName, err)
+			}
 		}
 
-		if isBastion {
-			// We don't want to validate for bastions - they aren't part of the cluster
-		} else if rollingUpdateData.CloudOnly {
+		glog.Infof("Surging group %q from %d to %d instances", r.CloudGroup.HumanName, groupSize, groupSize+settings.maxSurge)
+		if err = r.Cloud.ResizeGroup(r.CloudGroup, groupSize+settings.maxSurge); err != nil {
+			return fmt.Errorf("error surging group %q: %v", r.CloudGroup.HumanName, err)
+		}
 
-			glog.Warning("Not draining cluster nodes as 'cloudonly' flag is set.")
+		// Once we are done, or if we fail part way through, the group goes back to its original size and MaxSize
+		defer func() {
+			if restoreErr := r.restoreSize(rollingUpdateData, groupSize); restoreErr != nil {
+				if err == nil {
+					err = restoreErr
+				} else {
+					glog.Error(restoreErr)
+				}
+			}
+		}()
 
-		} else if featureflag.DrainAndValidateRollingUpdate.Enabled() {
+		glog.Infof("waiting for %v after surging group", sleepAfterTerminate)
+		time.Sleep(sleepAfterTerminate)
 
-			if u.Node != nil {
-				glog.Infof("Draining the node: %q.", nodeName)
+		// The new instances have to join and the cluster has to validate before we remove anything
+		if _, err = r.validateAfterChange(rollingUpdateData, cluster, instanceGroupList, isBastion, validationTimeout); err != nil {
+			return err
+		}
+	}
 
-				if err = r.DrainNode(u, rollingUpdateData); err != nil {
-					if rollingUpdateData.FailOnDrainError {
-						return fmt.Errorf("failed to drain node %q: %v", nodeName, err)
-					} else {
-						glog.Infof("Ignoring error draining node %q: %v", nodeName, err)
-					}
-				}
-			} else {
-				glog.Warningf("Skipping drain of instance %q, because it is not registered in kubernetes", instanceId)
+	// The last maxSurge instances we remove are not replaced, which returns the group to its original size
+	replaced := len(update) - settings.maxSurge
+
+	// The room a drain reserves in the PodDisruptionBudgets is held until the cluster has validated after the batch,
+	// so that drains in other groups do not evict more pods before the evicted pods are running again
+	var reservations []func()
+	releaseReservations := func() {
+		for _, release := range reservations {
+			release()
+		}
+		reservations = nil
+	}
+	defer releaseReservations()
+
+	for start := 0; start < len(update); start += settings.batchSize() {
+		end := start + settings.batchSize()
+		if end > len(update) {
+			end = len(update)
+		}
+		batch := update[start:end]
+
+		if rollingUpdateData.Abort != nil {
+			if err := rollingUpdateData.Abort(); err != nil {
+				return fmt.Errorf("stopping rolling-update of %q: %v", r.CloudGroup.HumanName, err)
 			}
 		}
 
-		// We unregister the node before deleting it; if the replacement comes up with the same name it would otherwise still be cordoned
-		// (It often seems like GCE tries to re-use names)
-		if !isBastion && !rollingUpdateData.CloudOnly {
-			if u.Node == nil {
-				glog.Warningf("no kubernetes Node associated with %s, skipping node deletion", instanceId)
-			} else {
-				glog.Infof("deleting node %q from kubernetes", nodeName)
-				if err := r.deleteNode(u.Node, rollingUpdateData); err != nil {
-					return fmt.Errorf("error deleting node %q: %v", nodeName, err)
-				}
+		for _, u := range batch {
+			if err = r.runHooks(rollingUpdateData, cluster, api.RollingUpdateHookBeforeDrain, u); err != nil {
+				return err
+			}
+
+			release, err := r.drainInstance(u, rollingUpdateData, isBastion)
+			if err != nil {
+				return err
+			}
+			if release != nil {
+				reservations = append(reservations, release)
+			}
+
+			if err = r.runHooks(rollingUpdateData, cluster, api.RollingUpdateHookAfterDrain, u); err != nil {
+				return err
 			}
 		}
 
-		if err = r.DeleteInstance(u); err != nil {
-			glog.Errorf("error deleting instance %q, node %q: %v", instanceId, nodeName, err)
-			return err
+		for i, u := range batch {
+			if start+i < replaced {
+				err = r.DeleteInstance(u)
+			} else {
+				err = r.deleteInstanceWithoutReplacement(u)
+			}
+			if err != nil {
+				glog.Errorf("error deleting instance %q: %v", u.ID, err)
+				return err
+			}
+
+			if err = r.runHooks(rollingUpdateData, cluster, api.RollingUpdateHookAfterTerminate, u); err != nil {
+				return err
+			}
 		}
 
 		// Wait for the minimum interval
 		glog.Infof("waiting for %v after terminating instance", sleepAfterTerminate)
 		time.Sleep(sleepAfterTerminate)
 
-		if isBastion {
-			glog.Infof("Deleted a bastion instance, %s, and continuing with rolling-update.", instanceId)
-
-			continue
-		} else if rollingUpdateData.CloudOnly {
-			glog.Warningf("Not validating cluster as cloudonly flag is set.")
-
-		} else if featureflag.DrainAndValidateRollingUpdate.Enabled() {
-			glog.Info("Validating the cluster.")
+		validated := false
+		if !isBastion {
+			if validated, err = r.validateAfterChange(rollingUpdateData, cluster, instanceGroupList, isBastion, validationTimeout); err != nil {
+				return err
+			}
+		}
+		releaseReservations()
 
-			if err = r.ValidateClusterWithDuration(rollingUpdateData, cluster, instanceGroupList, validationTimeout); err != nil {
+		// An instance only counts as replaced once its replacement has validated, so that a resumed rolling-update
+		// validates the cluster before carrying on
+		for _, u := range batch {
+			logProgressError(rollingUpdateData.Progress.CompleteInstance(groupName, u.ID))
+		}
 
-				if rollingUpdateData.FailOnValidate {
-					glog.Errorf("Cluster did not validate within %s", validationTimeout)
-					return fmt.Errorf("error validating cluster after removing a node: %v", err)
+		// AfterValidate hooks only run once the cluster has passed validation, not when validation was skipped or
+		// failed with fail-on-validate-error set to false
+		if validated {
+			for _, u := range batch {
+				if err = r.runHooks(rollingUpdateData, cluster, api.RollingUpdateHookAfterValidate, u); err != nil {
+					return err
 				}
-
-				glog.Warningf("Cluster validation failed after removing instance, proceeding since fail-on-validate is set to false: %v", err)
 			}
+		} else if !isBastion {
+			glog.V(2).Infof("Not running %s hooks for group %q, as the cluster did not pass validation", api.RollingUpdateHookAfterValidate, r.CloudGroup.HumanName)
+		}
+
+		if isBastion {
+			glog.Infof("Deleted %d bastion instance(s), and continuing with rolling-update.", len(batch))
+
+			continue
 		}
 
 		if rollingUpdateData.Interactive {
-			stopPrompting, err := promptInteractive(u.ID, nodeName)
+			last := batch[len(batch)-1]
+			nodeName := ""
+			if last.Node != nil {
+				nodeName = last.Node.Name
+			}
+
+			stopPrompting, err := promptInteractive(last.ID, nodeName)
 			if err != nil {
 				return err
 			}
@@ -234,53 +327,134 @@ func (r *RollingUpdateInstanceGroup) RollingUpdate(rollingUpdateData *RollingUpd
 	return nil
 }
 
-// ValidateClusterWithDuration runs validation.ValidateCluster until either we get positive result or the timeout expires
-func (r *RollingUpdateInstanceGroup) ValidateClusterWithDuration(rollingUpdateData *RollingUpdateCluster, cluster *api.Cluster, instanceGroupList *api.InstanceGroupList, duration time.Duration) error {
-	// TODO should we expose this to the UI?
-	tickDuration := 30 * time.Second
-	// Try to validate cluster at least once, this will handle durations that are lower
-	// than our tick time
-	if r.tryValidateCluster(rollingUpdateData, cluster, instanceGroupList, duration, tickDuration) {
-		return nil
+// drainInstance drains the node backing an instance and removes it from kubernetes, ready for the instance to be deleted.
+// If room was reserved in the PodDisruptionBudgets for the drain, the returned function releases it.
+func (r *RollingUpdateInstanceGroup) drainInstance(u *cloudinstances.CloudInstanceGroupMember, rollingUpdateData *RollingUpdateCluster, isBastion bool) (release func(), err error) {
+	instanceId := u.ID
+
+	var reserved func()
+	defer func() {
+		if err != nil && reserved != nil {
+			reserved()
+		}
+	}()
+
+	nodeName := ""
+	if u.Node != nil {
+		nodeName = u.Node.Name
 	}
 
-	timeout := time.After(duration)
-	tick := time.Tick(tickDuration)
-	// Keep trying until we're timed out or got a result or got an error
-	for {
-		select {
-		case <-timeout:
-			// Got a timeout fail with a timeout error
-			return fmt.Errorf("cluster did not validate within a duration of %q", duration)
-		case <-tick:
-			// Got a tick, validate cluster
-			if r.tryValidateCluster(rollingUpdateData, cluster, instanceGroupList, duration, tickDuration) {
-				return nil
+	if isBastion {
+		// We don't want to validate for bastions - they aren't part of the cluster
+	} else if rollingUpdateData.CloudOnly {
+
+		glog.Warning("Not draining cluster nodes as 'cloudonly' flag is set.")
+
+	} else if featureflag.DrainAndValidateRollingUpdate.Enabled() {
+
+		if u.Node != nil {
+			if rollingUpdateData.disruptions != nil {
+				reserved, err = rollingUpdateData.disruptions.acquire(nodeName, rollingUpdateData.ValidationTimeout)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			glog.Infof("Draining the node: %q.", nodeName)
+
+			if err := r.DrainNode(u, rollingUpdateData); err != nil {
+				if rollingUpdateData.FailOnDrainError {
+					return nil, fmt.Errorf("failed to drain node %q: %v", nodeName, err)
+				} else {
+					glog.Infof("Ignoring error draining node %q: %v", nodeName, err)
+				}
+			}
+		} else {
+			glog.Warningf("Skipping drain of instance %q, because it is not registered in kubernetes", instanceId)
+		}
+	}
+
+	// We unregister the node before deleting it; if the replacement comes up with the same name it would otherwise still be cordoned
+	// (It often seems like GCE tries to re-use names)
+	if !isBastion && !rollingUpdateData.CloudOnly {
+		if u.Node == nil {
+			glog.Warningf("no kubernetes Node associated with %s, skipping node deletion", instanceId)
+		} else {
+			glog.Infof("deleting node %q from kubernetes", nodeName)
+			if err := r.deleteNode(u.Node, rollingUpdateData); err != nil {
+				return nil, fmt.Errorf("error deleting node %q: %v", nodeName, err)
 			}
-			// ValidateCluster didn't work yet, so let's try again
-			// this will exit up to the for loop
 		}
 	}
+
+	return reserved, nil
 }
 
-func (r *RollingUpdateInstanceGroup) tryValidateCluster(rollingUpdateData *RollingUpdateCluster, cluster *api.Cluster, instanceGroupList *api.InstanceGroupList, duration time.Duration, tickDuration time.Duration) bool {
-	result, err := validation.ValidateCluster(cluster, instanceGroupList, rollingUpdateData.K8sClient)
+// validateAfterChange validates the cluster after instances have been added or removed, honoring the cloudonly and fail-on-validate settings.
+// It returns true only if the cluster was validated and passed.
+func (r *RollingUpdateInstanceGroup) validateAfterChange(rollingUpdateData *RollingUpdateCluster, cluster *api.Cluster, instanceGroupList *api.InstanceGroupList, isBastion bool, validationTimeout time.Duration) (bool, error) {
+	if isBastion {
+		glog.V(3).Info("Not validating the cluster as instance is a bastion.")
+	} else if rollingUpdateData.CloudOnly {
+		glog.Warningf("Not validating cluster as cloudonly flag is set.")
+
+	} else if featureflag.DrainAndValidateRollingUpdate.Enabled() {
+		glog.Info("Validating the cluster.")
 
-	if err != nil {
-		glog.Infof("Cluster did not validate, will try again in %q until duration %q expires: %v.", tickDuration, duration, err)
-		return false
-	} else if len(result.Failures) > 0 {
-		glog.Infof("Cluster did not pass validation, will try again in %q until duration %q expires: %v.", tickDuration, duration, result.Failures[0].Message)
-		return false
+		if err := r.ValidateClusterWithDuration(rollingUpdateData, cluster, instanceGroupList, validationTimeout); err != nil {
+
+			if rollingUpdateData.FailOnValidate {
+				glog.Errorf("Cluster did not validate within %s", validationTimeout)
+				return false, fmt.Errorf("error validating cluster after changing group %q: %v", r.CloudGroup.HumanName, err)
+			}
+
+			glog.Warningf("Cluster validation failed after changing group %q, proceeding since fail-on-validate is set to false: %v", r.CloudGroup.HumanName, err)
+			return false, nil
+		}
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// validateCluster validates the cluster; it is a variable so tests can stand in for a real cloud
+var validateCluster = validation.ValidateCluster
+
+// ValidateClusterWithDuration runs validation.ValidateCluster until either we get positive result or the timeout expires
+func (r *RollingUpdateInstanceGroup) ValidateClusterWithDuration(rollingUpdateData *RollingUpdateCluster, cluster *api.Cluster, instanceGroupList *api.InstanceGroupList, duration time.Duration) error {
+	// TODO should we expose this to the UI?
+	tickDuration := 30 * time.Second
+
+	validate := func() (*validation.ValidationCluster, error) {
+		return validateCluster(cluster, instanceGroupList, rollingUpdateData.K8sClient)
+	}
+	report := func(attempt *validation.ValidationAttempt) {
+		recordValidationAttempt(rollingUpdateData, attempt, duration, tickDuration)
+	}
+
+	_, err := validation.WaitForValidation(duration, tickDuration, validate, report)
+	return err
+}
+
+// recordValidationAttempt logs the outcome of an attempt to validate the cluster, and records it in the rolling update progress
+func recordValidationAttempt(rollingUpdateData *RollingUpdateCluster, attempt *validation.ValidationAttempt, duration time.Duration, tickDuration time.Duration) {
+	if attempt.Error != "" {
+		glog.Infof("Cluster did not validate, will try again in %q until duration %q expires: %v.", tickDuration, duration, attempt.Error)
+		logProgressError(rollingUpdateData.Progress.RecordValidation(false, attempt.Error))
+	} else if errors := attempt.Result.Errors(); len(errors) > 0 {
+		glog.Infof("Cluster did not pass validation, will try again in %q until duration %q expires: %v.", tickDuration, duration, errors[0].Message)
+		logProgressError(rollingUpdateData.Progress.RecordValidation(false, errors[0].Message))
 	} else {
 		glog.Info("Cluster validated.")
-		return true
+		logProgressError(rollingUpdateData.Progress.RecordValidation(true, ""))
 	}
 }
 
 // ValidateCluster runs our validation methods on the K8s Cluster.
 func (r *RollingUpdateInstanceGroup) ValidateCluster(rollingUpdateData *RollingUpdateCluster, cluster *api.Cluster, instanceGroupList *api.InstanceGroupList) error {
-	if _, err := validation.ValidateCluster(cluster, instanceGroupList, rollingUpdateData.K8sClient); err != nil {
+	if _, err := validateCluster(cluster, instanceGroupList, rollingUpdateData.K8sClient); err != nil {
+		logProgressError(rollingUpdateData.Progress.RecordValidation(false, err.Error()))
 		return fmt.Errorf("cluster %q did not pass validation: %v", cluster.Name, err)
 	}
 
@@ -313,6 +487,29 @@ func (r *RollingUpdateInstanceGroup) DeleteInstance(u *cloudinstances.CloudInsta
 
 }
 
+// deleteInstanceWithoutReplacement deletes a Cloud Instance and shrinks its group, so the instance is not replaced.
+func (r *RollingUpdateInstanceGroup) deleteInstanceWithoutReplacement(u *cloudinstances.CloudInstanceGroupMember) error {
+	glog.Infof("Stopping instance %q, in group %q, without replacing it.", u.ID, r.CloudGroup.HumanName)
+
+	if err := r.Cloud.DeleteInstanceWithoutReplacement(u); err != nil {
+		return fmt.Errorf("error deleting instance %q: %v", u.ID, err)
+	}
+
+	return nil
+}
+
+// restoreSize returns a surged group to its original size and MaxSize, and records that it is no longer surged.
+func (r *RollingUpdateInstanceGroup) restoreSize(rollingUpdateData *RollingUpdateCluster, size int) error {
+	glog.Infof("Restoring group %q to %d instances", r.CloudGroup.HumanName, size)
+
+	if err := r.Cloud.ResizeGroup(r.CloudGroup, size); err != nil {
+		return fmt.Errorf("error restoring size of group %q: %v", r.CloudGroup.HumanName, err)
+	}
+
+	logProgressError(rollingUpdateData.Progress.CompleteSurge(r.CloudGroup.InstanceGroup.ObjectMeta.Name))
+	return nil
+}
+
 // DrainNode drains a K8s node.
 func (r *RollingUpdateInstanceGroup) DrainNode(u *cloudinstances.CloudInstanceGroupMember, rollingUpdateData *RollingUpdateCluster) error {
 	if rollingUpdateData.ClientGetter == nil {
@@ -0,0 +1,227 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/golang/glog"
+	api "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/pkg/featureflag"
+	"k8s.io/kops/pkg/validation"
+	"k8s.io/kops/upup/pkg/fi/cloudup/awsup"
+)
+
+// instanceRefreshPollInterval is how often we check on the progress of an instance refresh
+var instanceRefreshPollInterval = 30 * time.Second
+
+// instanceRefresh replaces the instances of the group with an AWS autoscaling instance refresh, which runs server-side.
+// We follow its progress, validating the cluster as it goes.  If kops is interrupted the instance refresh carries on,
+// and the next rolling-update follows it rather than starting another.
+func (r *RollingUpdateInstanceGroup) instanceRefresh(rollingUpdateData *RollingUpdateCluster, cluster *api.Cluster, instanceGroupList *api.InstanceGroupList, isBastion bool, settings *rollingUpdateSettings, update []*cloudinstances.CloudInstanceGroupMember, instanceWarmup time.Duration, validationTimeout time.Duration) error {
+	c, ok := r.Cloud.(awsup.AWSCloud)
+	if !ok || featureflag.Spotinst.Enabled() {
+		return fmt.Errorf("the %s rolling-update strategy of group %q is only supported for AWS autoscaling groups", api.RollingUpdateStrategyInstanceRefresh, r.CloudGroup.HumanName)
+	}
+
+	groupName := r.CloudGroup.InstanceGroup.ObjectMeta.Name
+	asgName := r.CloudGroup.HumanName
+
+	if len(r.CloudGroup.InstanceGroup.Spec.RollingUpdateHooks) != 0 || len(rollingUpdateData.Hooks) != 0 {
+		glog.Warningf("Rolling-update hooks are not run for group %q, as its instances are replaced by an instance refresh", asgName)
+	}
+
+	// Groups written before this was validated may still lack the hook
+	if !isBastion && !r.CloudGroup.InstanceGroup.HasLifecycleHook(api.LifecycleTransitionTerminating) {
+		if !rollingUpdateData.CloudOnly {
+			return fmt.Errorf("group %q has no lifecycle hook on %s, so an instance refresh would terminate its instances without draining their nodes; add one, or use --cloudonly to replace the instances anyway", groupName, api.LifecycleTransitionTerminating)
+		}
+		glog.Warningf("Group %q has no lifecycle hook on %s; its nodes will NOT be drained before their instances are terminated", groupName, api.LifecycleTransitionTerminating)
+	}
+
+	refresh, err := r.startInstanceRefresh(c, rollingUpdateData, settings, instanceWarmup)
+	if err != nil {
+		return err
+	}
+	id := aws.StringValue(refresh.InstanceRefreshId)
+
+	validate := !isBastion && !rollingUpdateData.CloudOnly && featureflag.DrainAndValidateRollingUpdate.Enabled()
+	var failingSince time.Time
+
+	for {
+		status := aws.StringValue(refresh.Status)
+		glog.Infof("Instance refresh %q of group %q is %s, %d%% complete.", id, asgName, status, aws.Int64Value(refresh.PercentageComplete))
+		logProgressError(rollingUpdateData.Progress.RecordInstanceRefresh(groupName, InstanceRefreshRecord{
+			ID:                 id,
+			Status:             status,
+			PercentageComplete: aws.Int64Value(refresh.PercentageComplete),
+		}))
+
+		if status == autoscaling.InstanceRefreshStatusSuccessful {
+			break
+		}
+		if status != autoscaling.InstanceRefreshStatusPending && status != autoscaling.InstanceRefreshStatusInProgress {
+			return fmt.Errorf("instance refresh %q of group %q did not complete: %s: %s", id, asgName, status, aws.StringValue(refresh.StatusReason))
+		}
+
+		if validate {
+			if err := r.validateDuringInstanceRefresh(rollingUpdateData, cluster, instanceGroupList, validationTimeout); err != nil {
+				if failingSince.IsZero() {
+					failingSince = time.Now()
+				}
+				if time.Since(failingSince) > validationTimeout {
+					if rollingUpdateData.FailOnValidate {
+						r.cancelInstanceRefresh(c, id)
+						return fmt.Errorf("cluster did not validate within %s while instance refresh %q replaced group %q: %v", validationTimeout, id, asgName, err)
+					}
+					glog.Warningf("Cluster has not validated for %s during the instance refresh of group %q, proceeding since fail-on-validate is set to false: %v", validationTimeout, asgName, err)
+				}
+			} else {
+				failingSince = time.Time{}
+			}
+		}
+
+		time.Sleep(instanceRefreshPollInterval)
+
+		refresh, err = describeInstanceRefresh(c, asgName, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := r.validateAfterChange(rollingUpdateData, cluster, instanceGroupList, isBastion, validationTimeout); err != nil {
+		return err
+	}
+
+	for _, u := range update {
+		logProgressError(rollingUpdateData.Progress.CompleteInstance(groupName, u.ID))
+	}
+
+	return nil
+}
+
+// startInstanceRefresh returns the instance refresh that replaces the group: the one recorded by an interrupted
+// rolling-update, one that is already in progress, or else a new one
+func (r *RollingUpdateInstanceGroup) startInstanceRefresh(c awsup.AWSCloud, rollingUpdateData *RollingUpdateCluster, settings *rollingUpdateSettings, instanceWarmup time.Duration) (*autoscaling.InstanceRefresh, error) {
+	groupName := r.CloudGroup.InstanceGroup.ObjectMeta.Name
+	asgName := r.CloudGroup.HumanName
+
+	if id := rollingUpdateData.Progress.InstanceRefreshID(groupName); id != "" {
+		refresh, err := describeInstanceRefresh(c, asgName, id)
+		if err != nil {
+			return nil, err
+		}
+		switch status := aws.StringValue(refresh.Status); status {
+		case autoscaling.InstanceRefreshStatusPending, autoscaling.InstanceRefreshStatusInProgress, autoscaling.InstanceRefreshStatusCancelling, autoscaling.InstanceRefreshStatusSuccessful:
+			glog.Infof("Following instance refresh %q of group %q, which was started before the rolling-update was interrupted", id, asgName)
+			return refresh, nil
+		default:
+			glog.Infof("Instance refresh %q of group %q was %s before the rolling-update was interrupted; starting a new instance refresh", id, asgName, status)
+		}
+	}
+
+	request := &autoscaling.StartInstanceRefreshInput{
+		AutoScalingGroupName: aws.String(asgName),
+		Strategy:             aws.String(autoscaling.RefreshStrategyRolling),
+		Preferences: &autoscaling.RefreshPreferences{
+			InstanceWarmup: aws.Int64(int64(instanceWarmup / time.Second)),
+		},
+	}
+	if settings.minHealthyPercentage != nil {
+		request.Preferences.MinHealthyPercentage = aws.Int64(int64(*settings.minHealthyPercentage))
+	}
+
+	glog.Infof("Starting instance refresh of group %q", asgName)
+	response, err := c.Autoscaling().StartInstanceRefresh(request)
+	if err != nil {
+		if awsup.AWSErrorCode(err) != autoscaling.ErrCodeInstanceRefreshInProgressFault {
+			return nil, fmt.Errorf("error starting instance refresh of group %q: %v", asgName, err)
+		}
+
+		// Typically started by a rolling-update that was interrupted before it recorded its progress
+		refresh, err := findActiveInstanceRefresh(c, asgName)
+		if err != nil {
+			return nil, err
+		}
+		glog.Infof("Following instance refresh %q of group %q, which is already in progress", aws.StringValue(refresh.InstanceRefreshId), asgName)
+		return refresh, nil
+	}
+
+	return describeInstanceRefresh(c, asgName, aws.StringValue(response.InstanceRefreshId))
+}
+
+// validateDuringInstanceRefresh validates the cluster once, recording the result in the rolling-update progress
+func (r *RollingUpdateInstanceGroup) validateDuringInstanceRefresh(rollingUpdateData *RollingUpdateCluster, cluster *api.Cluster, instanceGroupList *api.InstanceGroupList, validationTimeout time.Duration) error {
+	validate := func() (*validation.ValidationCluster, error) {
+		return validation.ValidateCluster(cluster, instanceGroupList, rollingUpdateData.K8sClient)
+	}
+	report := func(attempt *validation.ValidationAttempt) {
+		recordValidationAttempt(rollingUpdateData, attempt, validationTimeout, instanceRefreshPollInterval)
+	}
+
+	_, err := validation.WaitForValidation(0, instanceRefreshPollInterval, validate, report)
+	return err
+}
+
+// cancelInstanceRefresh stops an instance refresh; instances that have already been replaced are kept
+func (r *RollingUpdateInstanceGroup) cancelInstanceRefresh(c awsup.AWSCloud, id string) {
+	glog.Warningf("Cancelling instance refresh %q of group %q", id, r.CloudGroup.HumanName)
+	_, err := c.Autoscaling().CancelInstanceRefresh(&autoscaling.CancelInstanceRefreshInput{
+		AutoScalingGroupName: aws.String(r.CloudGroup.HumanName),
+	})
+	if err != nil {
+		glog.Warningf("error cancelling instance refresh %q of group %q: %v", id, r.CloudGroup.HumanName, err)
+	}
+}
+
+// describeInstanceRefresh returns the current state of an instance refresh
+func describeInstanceRefresh(c awsup.AWSCloud, asgName string, id string) (*autoscaling.InstanceRefresh, error) {
+	response, err := c.Autoscaling().DescribeInstanceRefreshes(&autoscaling.DescribeInstanceRefreshesInput{
+		AutoScalingGroupName: aws.String(asgName),
+		InstanceRefreshIds:   []*string{aws.String(id)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error describing instance refresh %q of group %q: %v", id, asgName, err)
+	}
+	for _, refresh := range response.InstanceRefreshes {
+		if aws.StringValue(refresh.InstanceRefreshId) == id {
+			return refresh, nil
+		}
+	}
+	return nil, fmt.Errorf("instance refresh %q of group %q not found", id, asgName)
+}
+
+// findActiveInstanceRefresh returns the instance refresh of the group that is pending or in progress
+func findActiveInstanceRefresh(c awsup.AWSCloud, asgName string) (*autoscaling.InstanceRefresh, error) {
+	response, err := c.Autoscaling().DescribeInstanceRefreshes(&autoscaling.DescribeInstanceRefreshesInput{
+		AutoScalingGroupName: aws.String(asgName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error describing instance refreshes of group %q: %v", asgName, err)
+	}
+	for _, refresh := range response.InstanceRefreshes {
+		switch aws.StringValue(refresh.Status) {
+		case autoscaling.InstanceRefreshStatusPending, autoscaling.InstanceRefreshStatusInProgress:
+			return refresh, nil
+		}
+	}
+	return nil, fmt.Errorf("no instance refresh of group %q is in progress", asgName)
+}
@@ -0,0 +1,259 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"k8s.io/api/core/v1"
+	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/kops/cloudmock/aws/mockautoscaling"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/upup/pkg/fi/cloudup/awsup"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+// runInstanceRefreshTest rolls node-1 with the InstanceRefresh strategy, after calling setup.
+// If setup records the progress of a rolling-update, it is resumed.
+func runInstanceRefreshTest(t *testing.T, setup func(m *mockautoscaling.MockAutoscaling, progress *ProgressStore)) (*mockautoscaling.MockAutoscaling, *RollingUpdateProgress) {
+	defer func(interval time.Duration) {
+		instanceRefreshPollInterval = interval
+	}(instanceRefreshPollInterval)
+	instanceRefreshPollInterval = time.Millisecond
+
+	mockAutoscaling := &mockautoscaling.MockAutoscaling{}
+	mockcloud := awsup.BuildMockAWSCloud("us-east-1", "abc")
+	mockcloud.MockAutoscaling = mockAutoscaling
+
+	strategy := kopsapi.RollingUpdateStrategyInstanceRefresh
+	minHealthyPercentage := int32(80)
+	cluster := &kopsapi.Cluster{}
+	cluster.Name = "test.k8s.local"
+	cluster.Spec.RollingUpdate = &kopsapi.RollingUpdate{
+		Strategy:             &strategy,
+		MinHealthyPercentage: &minHealthyPercentage,
+	}
+
+	vfs.Context.ResetMemfsContext(true)
+
+	c := &RollingUpdateCluster{
+		Cloud:           mockcloud,
+		MasterInterval:  1 * time.Millisecond,
+		NodeInterval:    1 * time.Millisecond,
+		BastionInterval: 1 * time.Millisecond,
+		K8sClient:       fake.NewSimpleClientset(),
+	}
+
+	setUpCloud(c)
+	if setup != nil {
+		setup(mockAutoscaling, NewProgressStore(cluster, testConfigBase(t, cluster)))
+	}
+
+	progress := NewProgressStore(cluster, testConfigBase(t, cluster))
+	if previous, err := progress.Load(); err != nil {
+		t.Fatalf("error from Load: %v", err)
+	} else if previous != nil {
+		if err := progress.Resume(); err != nil {
+			t.Fatalf("error from Resume: %v", err)
+		}
+	}
+	c.Progress = progress
+
+	groups := map[string]*cloudinstances.CloudInstanceGroup{
+		"node-1": {
+			HumanName: "node-1",
+			InstanceGroup: &kopsapi.InstanceGroup{
+				ObjectMeta: v1meta.ObjectMeta{
+					Name: "node-1",
+				},
+				Spec: kopsapi.InstanceGroupSpec{
+					Role: kopsapi.InstanceGroupRoleNode,
+					LifecycleHooks: []kopsapi.LifecycleHookSpec{
+						{Name: "drain", Transition: kopsapi.LifecycleTransitionTerminating},
+					},
+				},
+			},
+			NeedUpdate: []*cloudinstances.CloudInstanceGroupMember{
+				{
+					ID:   "node-1a",
+					Node: &v1.Node{},
+				},
+				{
+					ID:   "node-1b",
+					Node: &v1.Node{},
+				},
+			},
+		},
+	}
+
+	if err := c.RollingUpdate(groups, cluster, &kopsapi.InstanceGroupList{}); err != nil {
+		t.Fatalf("Error on rolling update: %v", err)
+	}
+
+	recorded, err := progress.Load()
+	if err != nil {
+		t.Fatalf("error from Load: %v", err)
+	}
+	return mockAutoscaling, recorded
+}
+
+func TestRollingUpdateInstanceRefresh(t *testing.T) {
+	mockAutoscaling, recorded := runInstanceRefreshTest(t, nil)
+
+	if len(mockAutoscaling.InstanceRefreshes) != 1 {
+		t.Fatalf("expected 1 instance refresh, got %d", len(mockAutoscaling.InstanceRefreshes))
+	}
+	refresh := mockAutoscaling.InstanceRefreshes[0]
+	if aws.StringValue(refresh.AutoScalingGroupName) != "node-1" || aws.StringValue(refresh.Status) != autoscaling.InstanceRefreshStatusSuccessful {
+		t.Errorf("unexpected instance refresh: %v", refresh)
+	}
+
+	// The instances are replaced by the instance refresh, not terminated by kops
+	asgGroups, err := mockAutoscaling.DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: []*string{aws.String("node-1")},
+	})
+	if err != nil {
+		t.Fatalf("error describing groups: %v", err)
+	}
+	if len(asgGroups.AutoScalingGroups[0].Instances) != 2 {
+		t.Errorf("expected kops to leave the instances to the instance refresh, had %d instances", len(asgGroups.AutoScalingGroups[0].Instances))
+	}
+
+	record := recorded.InstanceRefreshes["node-1"]
+	if record.ID != aws.StringValue(refresh.InstanceRefreshId) || record.Status != autoscaling.InstanceRefreshStatusSuccessful || record.PercentageComplete != 100 {
+		t.Errorf("unexpected instance refresh record: %v", record)
+	}
+	if !recorded.IsGroupComplete("node-1") || !recorded.IsInstanceComplete("node-1", "node-1b") {
+		t.Errorf("expected node-1 to be recorded as complete")
+	}
+	if recorded.LastValidation == nil {
+		t.Errorf("expected the cluster to be validated during the instance refresh")
+	}
+}
+
+func TestRollingUpdateInstanceRefreshInProgress(t *testing.T) {
+	// An instance refresh started by an earlier rolling-update is followed, rather than a second one started
+	mockAutoscaling, recorded := runInstanceRefreshTest(t, func(m *mockautoscaling.MockAutoscaling, progress *ProgressStore) {
+		if _, err := m.StartInstanceRefresh(&autoscaling.StartInstanceRefreshInput{
+			AutoScalingGroupName: aws.String("node-1"),
+		}); err != nil {
+			t.Fatalf("error starting instance refresh: %v", err)
+		}
+	})
+
+	if len(mockAutoscaling.InstanceRefreshes) != 1 {
+		t.Fatalf("expected 1 instance refresh, got %d", len(mockAutoscaling.InstanceRefreshes))
+	}
+	id := aws.StringValue(mockAutoscaling.InstanceRefreshes[0].InstanceRefreshId)
+	if record := recorded.InstanceRefreshes["node-1"]; record.ID != id {
+		t.Errorf("expected instance refresh %q to be recorded, got %v", id, record)
+	}
+}
+
+func TestRollingUpdateInstanceRefreshFailed(t *testing.T) {
+	// A failed instance refresh recorded by an interrupted rolling-update is replaced by a new one
+	mockAutoscaling, recorded := runInstanceRefreshTest(t, func(m *mockautoscaling.MockAutoscaling, progress *ProgressStore) {
+		m.InstanceRefreshes = append(m.InstanceRefreshes, &autoscaling.InstanceRefresh{
+			AutoScalingGroupName: aws.String("node-1"),
+			InstanceRefreshId:    aws.String("ir-failed"),
+			Status:               aws.String(autoscaling.InstanceRefreshStatusFailed),
+		})
+
+		if _, err := progress.Begin([]string{"node-1"}); err != nil {
+			t.Fatalf("error from Begin: %v", err)
+		}
+		if err := progress.StartGroup(RollingUpdatePhaseNodes, "node-1"); err != nil {
+			t.Fatalf("error from StartGroup: %v", err)
+		}
+		if err := progress.RecordInstanceRefresh("node-1", InstanceRefreshRecord{ID: "ir-failed", Status: autoscaling.InstanceRefreshStatusInProgress}); err != nil {
+			t.Fatalf("error from RecordInstanceRefresh: %v", err)
+		}
+	})
+
+	if len(mockAutoscaling.InstanceRefreshes) != 2 {
+		t.Fatalf("expected 2 instance refreshes, got %d", len(mockAutoscaling.InstanceRefreshes))
+	}
+	if record := recorded.InstanceRefreshes["node-1"]; record.ID == "" || record.ID == "ir-failed" {
+		t.Errorf("expected a new instance refresh to be recorded, got %v", record)
+	}
+}
+
+func TestRollingUpdateInstanceRefreshRequiresLifecycleHook(t *testing.T) {
+	defer func(interval time.Duration) {
+		instanceRefreshPollInterval = interval
+	}(instanceRefreshPollInterval)
+	instanceRefreshPollInterval = time.Millisecond
+
+	mockAutoscaling := &mockautoscaling.MockAutoscaling{}
+	mockcloud := awsup.BuildMockAWSCloud("us-east-1", "abc")
+	mockcloud.MockAutoscaling = mockAutoscaling
+
+	strategy := kopsapi.RollingUpdateStrategyInstanceRefresh
+	cluster := &kopsapi.Cluster{}
+	cluster.Name = "test.k8s.local"
+	cluster.Spec.RollingUpdate = &kopsapi.RollingUpdate{Strategy: &strategy}
+
+	c := &RollingUpdateCluster{
+		Cloud:           mockcloud,
+		MasterInterval:  1 * time.Millisecond,
+		NodeInterval:    1 * time.Millisecond,
+		BastionInterval: 1 * time.Millisecond,
+		K8sClient:       fake.NewSimpleClientset(),
+	}
+	setUpCloud(c)
+
+	groups := map[string]*cloudinstances.CloudInstanceGroup{
+		"node-1": {
+			HumanName: "node-1",
+			InstanceGroup: &kopsapi.InstanceGroup{
+				ObjectMeta: v1meta.ObjectMeta{
+					Name: "node-1",
+				},
+				Spec: kopsapi.InstanceGroupSpec{
+					Role: kopsapi.InstanceGroupRoleNode,
+				},
+			},
+			NeedUpdate: []*cloudinstances.CloudInstanceGroupMember{
+				{
+					ID:   "node-1a",
+					Node: &v1.Node{},
+				},
+			},
+		},
+	}
+
+	if err := c.RollingUpdate(groups, cluster, &kopsapi.InstanceGroupList{}); err == nil {
+		t.Fatalf("expected error rolling a group without a terminating lifecycle hook")
+	}
+	if len(mockAutoscaling.InstanceRefreshes) != 0 {
+		t.Fatalf("expected no instance refresh, got %d", len(mockAutoscaling.InstanceRefreshes))
+	}
+
+	// With --cloudonly the nodes are not drained anyway
+	c.CloudOnly = true
+	if err := c.RollingUpdate(groups, cluster, &kopsapi.InstanceGroupList{}); err != nil {
+		t.Fatalf("Error on rolling update: %v", err)
+	}
+	if len(mockAutoscaling.InstanceRefreshes) != 1 {
+		t.Fatalf("expected 1 instance refresh, got %d", len(mockAutoscaling.InstanceRefreshes))
+	}
+}
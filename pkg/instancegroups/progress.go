@@ -0,0 +1,505 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/kops/pkg/acls"
+	api "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/upup/pkg/fi/utils"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+// RollingUpdatePhase is the stage a rolling update has reached
+type RollingUpdatePhase string
+
+const (
+	RollingUpdatePhaseBastions RollingUpdatePhase = "Bastions"
+	RollingUpdatePhaseMasters  RollingUpdatePhase = "Masters"
+	RollingUpdatePhaseNodes    RollingUpdatePhase = "Nodes"
+	RollingUpdatePhaseComplete RollingUpdatePhase = "Complete"
+)
+
+// RollingUpdateProgress is the record of a rolling update that we persist in the state store, so it can be resumed
+type RollingUpdateProgress struct {
+	// ClusterName is the name of the cluster being rolled
+	ClusterName string `json:"clusterName"`
+	// StartedAt is when the rolling update was first started
+	StartedAt time.Time `json:"startedAt"`
+	// UpdatedAt is when this record was last written
+	UpdatedAt time.Time `json:"updatedAt"`
+	// Phase is the phase of the rolling update
+	Phase RollingUpdatePhase `json:"phase"`
+	// ActiveGroups maps each instance group currently being rolled to the phase in which it is rolled;
+	// several node groups are rolled at once with --node-group-concurrency
+	ActiveGroups map[string]RollingUpdatePhase `json:"activeGroups,omitempty"`
+	// GroupOrder is the order in which instance groups are rolled, so a resumed update keeps the same order
+	GroupOrder []string `json:"groupOrder,omitempty"`
+	// CompletedGroups is the list of instance groups that have been fully rolled
+	CompletedGroups []string `json:"completedGroups,omitempty"`
+	// PlannedInstances maps an instance group name to the IDs of the instances the rolling update set out to replace,
+	// so that a resumed rolling update does not also replace the instances that replaced them
+	PlannedInstances map[string][]string `json:"plannedInstances,omitempty"`
+	// CompletedInstances maps an instance group name to the IDs of instances that have been replaced, and whose
+	// replacements have validated
+	CompletedInstances map[string][]string `json:"completedInstances,omitempty"`
+	// LastValidation is the result of the most recent cluster validation
+	LastValidation *ValidationRecord `json:"lastValidation,omitempty"`
+	// InstanceRefreshes maps an instance group name to the instance refresh replacing its instances
+	InstanceRefreshes map[string]InstanceRefreshRecord `json:"instanceRefreshes,omitempty"`
+	// Surges maps the name of an instance group that has been surged to its size before the surge,
+	// so that a resumed rolling update returns it to that size rather than to the surged size
+	Surges map[string]SurgeRecord `json:"surges,omitempty"`
+}
+
+// SurgeRecord is the size of an instance group before the rolling update surged it
+type SurgeRecord struct {
+	// DesiredSize is the number of instances the cloud was keeping running in the group
+	DesiredSize int `json:"desiredSize"`
+	// MaxSize is the maximum size of the group, which surging may raise
+	MaxSize int `json:"maxSize"`
+}
+
+// InstanceRefreshRecord is the last known state of an AWS autoscaling instance refresh
+type InstanceRefreshRecord struct {
+	// ID is the ID of the instance refresh
+	ID string `json:"id"`
+	// Status is the status of the instance refresh, as reported by AWS
+	Status string `json:"status,omitempty"`
+	// PercentageComplete is the percentage of the instances that have been replaced
+	PercentageComplete int64 `json:"percentageComplete"`
+}
+
+// ValidationRecord is the result of a single cluster validation
+type ValidationRecord struct {
+	// Time is when the validation was performed
+	Time time.Time `json:"time"`
+	// Passed is true if the cluster validated
+	Passed bool `json:"passed"`
+	// Message describes the first failure, if the cluster did not validate
+	Message string `json:"message,omitempty"`
+}
+
+// IsGroupComplete returns true if the named instance group has been fully rolled
+func (p *RollingUpdateProgress) IsGroupComplete(name string) bool {
+	for _, g := range p.CompletedGroups {
+		if g == name {
+			return true
+		}
+	}
+	return false
+}
+
+// IsInstanceComplete returns true if the instance in the named group has already been replaced
+func (p *RollingUpdateProgress) IsInstanceComplete(group string, id string) bool {
+	for _, i := range p.CompletedInstances[group] {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+// ActiveGroupNames returns the sorted names of the instance groups currently being rolled
+func (p *RollingUpdateProgress) ActiveGroupNames() []string {
+	var names []string
+	for name := range p.ActiveGroups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ProgressStore persists the RollingUpdateProgress for a cluster under its ConfigBase.
+// A nil ProgressStore is valid, and records nothing.
+type ProgressStore struct {
+	cluster *api.Cluster
+	path    vfs.Path
+
+	mutex    sync.Mutex
+	progress *RollingUpdateProgress
+	resumed  bool
+}
+
+// NewProgressStore builds a ProgressStore for the cluster, storing the progress document under configBase
+func NewProgressStore(cluster *api.Cluster, configBase vfs.Path) *ProgressStore {
+	return &ProgressStore{
+		cluster: cluster,
+		path:    configBase.Join("rollingupdate", "progress"),
+	}
+}
+
+// Load reads the progress document from the state store, returning (nil, nil) if there is none
+func (s *ProgressStore) Load() (*RollingUpdateProgress, error) {
+	data, err := s.path.ReadFile()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading rolling-update progress %s: %v", s.path, err)
+	}
+
+	progress := &RollingUpdateProgress{}
+	if err := utils.YamlUnmarshal(data, progress); err != nil {
+		return nil, fmt.Errorf("error parsing rolling-update progress %s: %v", s.path, err)
+	}
+	return progress, nil
+}
+
+// Resume loads the progress of an interrupted rolling update, so that Begin continues from it
+func (s *ProgressStore) Resume() error {
+	progress, err := s.Load()
+	if err != nil {
+		return err
+	}
+	if progress == nil {
+		return fmt.Errorf("no rolling-update progress found for cluster %q", s.cluster.ObjectMeta.Name)
+	}
+	if progress.Phase == RollingUpdatePhaseComplete {
+		glog.Infof("Previous rolling-update of cluster %q completed at %v; starting a new rolling-update", s.cluster.ObjectMeta.Name, progress.UpdatedAt)
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.progress = progress
+	s.resumed = true
+	return nil
+}
+
+// Begin starts recording a rolling update, returning the order in which groups should be rolled.
+// If a previous rolling update is being resumed, its order is kept, and any new groups are appended.
+func (s *ProgressStore) Begin(groupOrder []string) ([]string, error) {
+	if s == nil {
+		return groupOrder, nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.progress == nil {
+		now := time.Now().UTC()
+		s.progress = &RollingUpdateProgress{
+			ClusterName:        s.cluster.ObjectMeta.Name,
+			StartedAt:          now,
+			GroupOrder:         groupOrder,
+			CompletedInstances: make(map[string][]string),
+		}
+	} else {
+		wanted := make(map[string]bool)
+		for _, name := range groupOrder {
+			wanted[name] = true
+		}
+
+		var order []string
+		seen := make(map[string]bool)
+		for _, name := range s.progress.GroupOrder {
+			if wanted[name] {
+				order = append(order, name)
+				seen[name] = true
+			}
+		}
+		for _, name := range groupOrder {
+			if !seen[name] {
+				order = append(order, name)
+			}
+		}
+		s.progress.GroupOrder = order
+		if s.progress.CompletedInstances == nil {
+			s.progress.CompletedInstances = make(map[string][]string)
+		}
+	}
+
+	return s.progress.GroupOrder, s.save()
+}
+
+// IsGroupComplete returns true if the group was completed by the rolling update being resumed
+func (s *ProgressStore) IsGroupComplete(name string) bool {
+	if s == nil {
+		return false
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.progress != nil && s.progress.IsGroupComplete(name)
+}
+
+// IsInstanceComplete returns true if the instance was replaced by the rolling update being resumed
+func (s *ProgressStore) IsInstanceComplete(group string, id string) bool {
+	if s == nil {
+		return false
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.progress != nil && s.progress.IsInstanceComplete(group, id)
+}
+
+// ResumingValidatedGroup returns true if we are resuming the named group and the cluster last validated successfully,
+// in which case there is no need to validate again before continuing
+func (s *ProgressStore) ResumingValidatedGroup(group string) bool {
+	if s == nil {
+		return false
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.resumed {
+		return false
+	}
+	_, active := s.progress.ActiveGroups[group]
+	return active && s.progress.LastValidation != nil && s.progress.LastValidation.Passed
+}
+
+// StartGroup records that we have started rolling the named group
+func (s *ProgressStore) StartGroup(phase RollingUpdatePhase, group string) error {
+	if s == nil {
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.progress.Phase = phase
+	if s.progress.ActiveGroups == nil {
+		s.progress.ActiveGroups = make(map[string]RollingUpdatePhase)
+	}
+	s.progress.ActiveGroups[group] = phase
+	return s.save()
+}
+
+// PlanInstances returns the candidates that should be replaced in the named group.  The first time it is called for
+// a group it records the candidates; when resuming it only returns the recorded candidates that have not yet been
+// replaced, so that instances which replaced them are left alone, even with --force.
+func (s *ProgressStore) PlanInstances(group string, candidates []*cloudinstances.CloudInstanceGroupMember) ([]*cloudinstances.CloudInstanceGroupMember, error) {
+	if s == nil {
+		return candidates, nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var update []*cloudinstances.CloudInstanceGroupMember
+	for _, u := range candidates {
+		if s.progress.IsInstanceComplete(group, u.ID) {
+			glog.Infof("Skipping instance %q, which was replaced before the rolling-update was interrupted", u.ID)
+			continue
+		}
+		update = append(update, u)
+	}
+
+	planned, found := s.progress.PlannedInstances[group]
+	if !found {
+		var ids []string
+		for _, u := range update {
+			ids = append(ids, u.ID)
+		}
+		if s.progress.PlannedInstances == nil {
+			s.progress.PlannedInstances = make(map[string][]string)
+		}
+		s.progress.PlannedInstances[group] = ids
+		return update, s.save()
+	}
+
+	isPlanned := make(map[string]bool)
+	for _, id := range planned {
+		isPlanned[id] = true
+	}
+
+	var remaining []*cloudinstances.CloudInstanceGroupMember
+	for _, u := range update {
+		if !isPlanned[u.ID] {
+			glog.Infof("Skipping instance %q, which was created by the rolling-update being resumed", u.ID)
+			continue
+		}
+		remaining = append(remaining, u)
+	}
+	return remaining, nil
+}
+
+// CompleteInstance records that an instance has been replaced
+func (s *ProgressStore) CompleteInstance(group string, id string) error {
+	if s == nil {
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.progress.IsInstanceComplete(group, id) {
+		s.progress.CompletedInstances[group] = append(s.progress.CompletedInstances[group], id)
+	}
+	return s.save()
+}
+
+// CompleteGroup records that a group has been fully rolled
+func (s *ProgressStore) CompleteGroup(group string) error {
+	if s == nil {
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.progress.IsGroupComplete(group) {
+		s.progress.CompletedGroups = append(s.progress.CompletedGroups, group)
+	}
+	delete(s.progress.ActiveGroups, group)
+	return s.save()
+}
+
+// RecordValidation records the result of a cluster validation
+func (s *ProgressStore) RecordValidation(passed bool, message string) error {
+	if s == nil {
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.progress.LastValidation = &ValidationRecord{
+		Time:    time.Now().UTC(),
+		Passed:  passed,
+		Message: message,
+	}
+	return s.save()
+}
+
+// InstanceRefreshID returns the ID of the instance refresh recorded for the group, or "" if there is none
+func (s *ProgressStore) InstanceRefreshID(group string) string {
+	if s == nil {
+		return ""
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.progress == nil {
+		return ""
+	}
+	return s.progress.InstanceRefreshes[group].ID
+}
+
+// RecordInstanceRefresh records the state of the instance refresh replacing the instances of a group
+func (s *ProgressStore) RecordInstanceRefresh(group string, record InstanceRefreshRecord) error {
+	if s == nil {
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.progress.InstanceRefreshes == nil {
+		s.progress.InstanceRefreshes = make(map[string]InstanceRefreshRecord)
+	}
+	s.progress.InstanceRefreshes[group] = record
+	return s.save()
+}
+
+// Surge returns the size of the group before it was surged, if the rolling update being resumed surged it
+func (s *ProgressStore) Surge(group string) (SurgeRecord, bool) {
+	if s == nil {
+		return SurgeRecord{}, false
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.progress == nil {
+		return SurgeRecord{}, false
+	}
+	record, found := s.progress.Surges[group]
+	return record, found
+}
+
+// RecordSurge records the size of a group before it is surged
+func (s *ProgressStore) RecordSurge(group string, record SurgeRecord) error {
+	if s == nil {
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.progress.Surges == nil {
+		s.progress.Surges = make(map[string]SurgeRecord)
+	}
+	s.progress.Surges[group] = record
+	return s.save()
+}
+
+// CompleteSurge records that a surged group has been returned to its original size
+func (s *ProgressStore) CompleteSurge(group string) error {
+	if s == nil {
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, found := s.progress.Surges[group]; !found {
+		return nil
+	}
+	delete(s.progress.Surges, group)
+	return s.save()
+}
+
+// Finish records that the rolling update has completed
+func (s *ProgressStore) Finish() error {
+	if s == nil {
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.progress.Phase = RollingUpdatePhaseComplete
+	s.progress.ActiveGroups = nil
+	return s.save()
+}
+
+// save writes the progress document; the caller must hold the mutex
+func (s *ProgressStore) save() error {
+	s.progress.UpdatedAt = time.Now().UTC()
+
+	data, err := utils.YamlMarshal(s.progress)
+	if err != nil {
+		return fmt.Errorf("error serializing rolling-update progress: %v", err)
+	}
+
+	acl, err := acls.GetACL(s.path, s.cluster)
+	if err != nil {
+		return err
+	}
+
+	if err := s.path.WriteFile(bytes.NewReader(data), acl); err != nil {
+		return fmt.Errorf("error writing rolling-update progress %s: %v", s.path, err)
+	}
+	return nil
+}
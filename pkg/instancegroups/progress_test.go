@@ -0,0 +1,550 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"k8s.io/api/core/v1"
+	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/kops/cloudmock/aws/mockautoscaling"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/upup/pkg/fi/cloudup/awsup"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+func testConfigBase(t *testing.T, cluster *kopsapi.Cluster) vfs.Path {
+	configBase, err := vfs.Context.BuildVfsPath("memfs://tests/" + cluster.Name)
+	if err != nil {
+		t.Fatalf("error building vfspath: %v", err)
+	}
+	return configBase
+}
+
+func TestProgressStoreRoundTrip(t *testing.T) {
+	cluster := &kopsapi.Cluster{}
+	cluster.Name = "test.k8s.local"
+
+	vfs.Context.ResetMemfsContext(true)
+
+	s := NewProgressStore(cluster, testConfigBase(t, cluster))
+
+	if err := s.Resume(); err == nil {
+		t.Fatalf("expected error resuming without any recorded progress")
+	}
+
+	order, err := s.Begin([]string{"master-1", "node-1", "node-2"})
+	if err != nil {
+		t.Fatalf("error from Begin: %v", err)
+	}
+	if !reflect.DeepEqual(order, []string{"master-1", "node-1", "node-2"}) {
+		t.Fatalf("unexpected order %v", order)
+	}
+
+	if err := s.StartGroup(RollingUpdatePhaseMasters, "master-1"); err != nil {
+		t.Fatalf("error from StartGroup: %v", err)
+	}
+	if err := s.CompleteInstance("master-1", "master-1a"); err != nil {
+		t.Fatalf("error from CompleteInstance: %v", err)
+	}
+	if err := s.CompleteGroup("master-1"); err != nil {
+		t.Fatalf("error from CompleteGroup: %v", err)
+	}
+	if err := s.StartGroup(RollingUpdatePhaseNodes, "node-2"); err != nil {
+		t.Fatalf("error from StartGroup: %v", err)
+	}
+	if err := s.RecordValidation(true, ""); err != nil {
+		t.Fatalf("error from RecordValidation: %v", err)
+	}
+
+	// A new store simulates a new invocation of kops
+	resumed := NewProgressStore(cluster, testConfigBase(t, cluster))
+	if err := resumed.Resume(); err != nil {
+		t.Fatalf("error from Resume: %v", err)
+	}
+
+	// A group that no longer needs updating drops out of the order, and new groups go at the end
+	order, err = resumed.Begin([]string{"node-2", "node-3", "master-1"})
+	if err != nil {
+		t.Fatalf("error from Begin: %v", err)
+	}
+	if !reflect.DeepEqual(order, []string{"master-1", "node-2", "node-3"}) {
+		t.Fatalf("unexpected order after resume %v", order)
+	}
+
+	if !resumed.IsGroupComplete("master-1") {
+		t.Errorf("expected master-1 to be complete")
+	}
+	if resumed.IsGroupComplete("node-2") {
+		t.Errorf("expected node-2 not to be complete")
+	}
+	if !resumed.IsInstanceComplete("master-1", "master-1a") {
+		t.Errorf("expected master-1a to be complete")
+	}
+	if !resumed.ResumingValidatedGroup("node-2") {
+		t.Errorf("expected node-2 to be resuming after a successful validation")
+	}
+	if resumed.ResumingValidatedGroup("node-3") {
+		t.Errorf("expected node-3 not to be resuming")
+	}
+
+	if err := resumed.Finish(); err != nil {
+		t.Fatalf("error from Finish: %v", err)
+	}
+
+	progress, err := resumed.Load()
+	if err != nil {
+		t.Fatalf("error from Load: %v", err)
+	}
+	if progress.Phase != RollingUpdatePhaseComplete {
+		t.Errorf("expected phase %q, got %q", RollingUpdatePhaseComplete, progress.Phase)
+	}
+	if progress.ClusterName != cluster.Name {
+		t.Errorf("expected cluster name %q, got %q", cluster.Name, progress.ClusterName)
+	}
+}
+
+func TestRollingUpdateResume(t *testing.T) {
+	k8sClient := fake.NewSimpleClientset()
+
+	mockcloud := awsup.BuildMockAWSCloud("us-east-1", "abc")
+	mockcloud.MockAutoscaling = &mockautoscaling.MockAutoscaling{}
+
+	cluster := &kopsapi.Cluster{}
+	cluster.Name = "test.k8s.local"
+
+	vfs.Context.ResetMemfsContext(true)
+
+	// Record an interrupted rolling-update, which finished node-1 and replaced node-2a
+	{
+		s := NewProgressStore(cluster, testConfigBase(t, cluster))
+		if _, err := s.Begin([]string{"node-1", "node-2"}); err != nil {
+			t.Fatalf("error from Begin: %v", err)
+		}
+		if err := s.CompleteGroup("node-1"); err != nil {
+			t.Fatalf("error from CompleteGroup: %v", err)
+		}
+		if err := s.StartGroup(RollingUpdatePhaseNodes, "node-2"); err != nil {
+			t.Fatalf("error from StartGroup: %v", err)
+		}
+		if err := s.CompleteInstance("node-2", "node-2a"); err != nil {
+			t.Fatalf("error from CompleteInstance: %v", err)
+		}
+	}
+
+	progress := NewProgressStore(cluster, testConfigBase(t, cluster))
+	if err := progress.Resume(); err != nil {
+		t.Fatalf("error from Resume: %v", err)
+	}
+
+	c := &RollingUpdateCluster{
+		Cloud:           mockcloud,
+		MasterInterval:  1 * time.Millisecond,
+		NodeInterval:    1 * time.Millisecond,
+		BastionInterval: 1 * time.Millisecond,
+		K8sClient:       k8sClient,
+		Progress:        progress,
+	}
+
+	setUpCloud(c)
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	for _, name := range []string{"node-1", "node-2"} {
+		groups[name] = &cloudinstances.CloudInstanceGroup{
+			InstanceGroup: &kopsapi.InstanceGroup{
+				ObjectMeta: v1meta.ObjectMeta{
+					Name: name,
+				},
+				Spec: kopsapi.InstanceGroupSpec{
+					Role: kopsapi.InstanceGroupRoleNode,
+				},
+			},
+			NeedUpdate: []*cloudinstances.CloudInstanceGroupMember{
+				{
+					ID:   name + "a",
+					Node: &v1.Node{},
+				},
+				{
+					ID:   name + "b",
+					Node: &v1.Node{},
+				},
+			},
+		}
+	}
+
+	if err := c.RollingUpdate(groups, cluster, &kopsapi.InstanceGroupList{}); err != nil {
+		t.Errorf("Error on rolling update: %v", err)
+	}
+
+	cloud := c.Cloud.(awsup.AWSCloud)
+	asgGroups, err := cloud.Autoscaling().DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: []*string{aws.String("node-1"), aws.String("node-2")},
+	})
+	if err != nil {
+		t.Fatalf("error describing groups: %v", err)
+	}
+
+	remaining := make(map[string][]string)
+	for _, group := range asgGroups.AutoScalingGroups {
+		for _, instance := range group.Instances {
+			remaining[aws.StringValue(group.AutoScalingGroupName)] = append(remaining[aws.StringValue(group.AutoScalingGroupName)], aws.StringValue(instance.InstanceId))
+		}
+	}
+
+	if !reflect.DeepEqual(remaining["node-1"], []string{"node-1a", "node-1b"}) {
+		t.Errorf("expected completed group node-1 to be left alone, had instances %v", remaining["node-1"])
+	}
+	if !reflect.DeepEqual(remaining["node-2"], []string{"node-2a"}) {
+		t.Errorf("expected only node-2b to be replaced, had instances %v", remaining["node-2"])
+	}
+
+	recorded, err := progress.Load()
+	if err != nil {
+		t.Fatalf("error from Load: %v", err)
+	}
+	if recorded.Phase != RollingUpdatePhaseComplete {
+		t.Errorf("expected phase %q, got %q", RollingUpdatePhaseComplete, recorded.Phase)
+	}
+	if !recorded.IsInstanceComplete("node-2", "node-2b") {
+		t.Errorf("expected node-2b to be recorded as replaced")
+	}
+}
+
+func TestProgressStoreConcurrentGroups(t *testing.T) {
+	cluster := &kopsapi.Cluster{}
+	cluster.Name = "test.k8s.local"
+
+	vfs.Context.ResetMemfsContext(true)
+
+	s := NewProgressStore(cluster, testConfigBase(t, cluster))
+	if _, err := s.Begin([]string{"node-1", "node-2"}); err != nil {
+		t.Fatalf("error from Begin: %v", err)
+	}
+
+	// Two node groups are rolled at once, and the first to start finishes first
+	if err := s.StartGroup(RollingUpdatePhaseNodes, "node-1"); err != nil {
+		t.Fatalf("error from StartGroup: %v", err)
+	}
+	if err := s.StartGroup(RollingUpdatePhaseNodes, "node-2"); err != nil {
+		t.Fatalf("error from StartGroup: %v", err)
+	}
+	if err := s.CompleteGroup("node-1"); err != nil {
+		t.Fatalf("error from CompleteGroup: %v", err)
+	}
+	if err := s.RecordValidation(true, ""); err != nil {
+		t.Fatalf("error from RecordValidation: %v", err)
+	}
+
+	resumed := NewProgressStore(cluster, testConfigBase(t, cluster))
+	if err := resumed.Resume(); err != nil {
+		t.Fatalf("error from Resume: %v", err)
+	}
+	if !resumed.ResumingValidatedGroup("node-2") {
+		t.Errorf("expected node-2 to be resuming after a successful validation")
+	}
+	if resumed.ResumingValidatedGroup("node-1") {
+		t.Errorf("expected completed group node-1 not to be resuming")
+	}
+
+	progress, err := resumed.Load()
+	if err != nil {
+		t.Fatalf("error from Load: %v", err)
+	}
+	if !reflect.DeepEqual(progress.ActiveGroupNames(), []string{"node-2"}) {
+		t.Errorf("unexpected active groups %v", progress.ActiveGroupNames())
+	}
+}
+
+func TestRollingUpdateResumeForce(t *testing.T) {
+	k8sClient := fake.NewSimpleClientset()
+
+	mockcloud := awsup.BuildMockAWSCloud("us-east-1", "abc")
+	mockcloud.MockAutoscaling = &mockautoscaling.MockAutoscaling{}
+
+	cluster := &kopsapi.Cluster{}
+	cluster.Name = "test.k8s.local"
+
+	vfs.Context.ResetMemfsContext(true)
+
+	// Record an interrupted rolling-update of node-1, which replaced node-1a
+	{
+		s := NewProgressStore(cluster, testConfigBase(t, cluster))
+		if _, err := s.Begin([]string{"node-1"}); err != nil {
+			t.Fatalf("error from Begin: %v", err)
+		}
+		if err := s.StartGroup(RollingUpdatePhaseNodes, "node-1"); err != nil {
+			t.Fatalf("error from StartGroup: %v", err)
+		}
+		planned := []*cloudinstances.CloudInstanceGroupMember{{ID: "node-1a"}, {ID: "node-1b"}}
+		if _, err := s.PlanInstances("node-1", planned); err != nil {
+			t.Fatalf("error from PlanInstances: %v", err)
+		}
+		if err := s.CompleteInstance("node-1", "node-1a"); err != nil {
+			t.Fatalf("error from CompleteInstance: %v", err)
+		}
+	}
+
+	progress := NewProgressStore(cluster, testConfigBase(t, cluster))
+	if err := progress.Resume(); err != nil {
+		t.Fatalf("error from Resume: %v", err)
+	}
+
+	c := &RollingUpdateCluster{
+		Cloud:           mockcloud,
+		MasterInterval:  1 * time.Millisecond,
+		NodeInterval:    1 * time.Millisecond,
+		BastionInterval: 1 * time.Millisecond,
+		Force:           true,
+		K8sClient:       k8sClient,
+		Progress:        progress,
+	}
+
+	setUpCloud(c)
+
+	// node-1c replaced node-1a, and is up to date
+	cloud := c.Cloud.(awsup.AWSCloud)
+	if _, err := cloud.Autoscaling().AttachInstances(&autoscaling.AttachInstancesInput{
+		AutoScalingGroupName: aws.String("node-1"),
+		InstanceIds:          []*string{aws.String("node-1c")},
+	}); err != nil {
+		t.Fatalf("error attaching instance: %v", err)
+	}
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	groups["node-1"] = &cloudinstances.CloudInstanceGroup{
+		InstanceGroup: &kopsapi.InstanceGroup{
+			ObjectMeta: v1meta.ObjectMeta{
+				Name: "node-1",
+			},
+			Spec: kopsapi.InstanceGroupSpec{
+				Role: kopsapi.InstanceGroupRoleNode,
+			},
+		},
+		NeedUpdate: []*cloudinstances.CloudInstanceGroupMember{
+			{
+				ID:   "node-1b",
+				Node: &v1.Node{},
+			},
+		},
+		Ready: []*cloudinstances.CloudInstanceGroupMember{
+			{
+				ID:   "node-1c",
+				Node: &v1.Node{},
+			},
+		},
+	}
+
+	if err := c.RollingUpdate(groups, cluster, &kopsapi.InstanceGroupList{}); err != nil {
+		t.Errorf("Error on rolling update: %v", err)
+	}
+
+	asgGroups, err := cloud.Autoscaling().DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: []*string{aws.String("node-1")},
+	})
+	if err != nil {
+		t.Fatalf("error describing groups: %v", err)
+	}
+
+	var remaining []string
+	for _, instance := range asgGroups.AutoScalingGroups[0].Instances {
+		remaining = append(remaining, aws.StringValue(instance.InstanceId))
+	}
+	if !reflect.DeepEqual(remaining, []string{"node-1a", "node-1c"}) {
+		t.Errorf("expected only node-1b to be replaced, had instances %v", remaining)
+	}
+}
+
+func TestRollingUpdateRecordsInstanceAfterValidation(t *testing.T) {
+	k8sClient := fake.NewSimpleClientset()
+
+	mockcloud := awsup.BuildMockAWSCloud("us-east-1", "abc")
+	mockcloud.MockAutoscaling = &mockautoscaling.MockAutoscaling{}
+
+	cluster := &kopsapi.Cluster{}
+	cluster.Name = "test.k8s.local"
+
+	vfs.Context.ResetMemfsContext(true)
+
+	progress := NewProgressStore(cluster, testConfigBase(t, cluster))
+
+	c := &RollingUpdateCluster{
+		Cloud:           mockcloud,
+		MasterInterval:  1 * time.Millisecond,
+		NodeInterval:    1 * time.Millisecond,
+		BastionInterval: 1 * time.Millisecond,
+		K8sClient:       k8sClient,
+		Progress:        progress,
+	}
+
+	setUpCloud(c)
+
+	// The rolling update stops after node-1a is terminated, before the cluster is validated
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	groups["node-1"] = &cloudinstances.CloudInstanceGroup{
+		InstanceGroup: &kopsapi.InstanceGroup{
+			ObjectMeta: v1meta.ObjectMeta{
+				Name: "node-1",
+			},
+			Spec: kopsapi.InstanceGroupSpec{
+				Role: kopsapi.InstanceGroupRoleNode,
+				RollingUpdateHooks: []kopsapi.RollingUpdateHook{
+					{
+						Name:   "fails",
+						Events: []kopsapi.RollingUpdateHookEvent{kopsapi.RollingUpdateHookAfterTerminate},
+						Exec:   &kopsapi.RollingUpdateExecHook{Command: []string{"false"}},
+					},
+				},
+			},
+		},
+		NeedUpdate: []*cloudinstances.CloudInstanceGroupMember{
+			{
+				ID:   "node-1a",
+				Node: &v1.Node{},
+			},
+			{
+				ID:   "node-1b",
+				Node: &v1.Node{},
+			},
+		},
+	}
+
+	if err := c.RollingUpdate(groups, cluster, &kopsapi.InstanceGroupList{}); err == nil {
+		t.Fatalf("expected rolling update to fail")
+	}
+
+	recorded, err := progress.Load()
+	if err != nil {
+		t.Fatalf("error from Load: %v", err)
+	}
+	if recorded.IsInstanceComplete("node-1", "node-1a") {
+		t.Errorf("expected node-1a not to be recorded as replaced before the cluster validated")
+	}
+	if !reflect.DeepEqual(recorded.PlannedInstances["node-1"], []string{"node-1a", "node-1b"}) {
+		t.Errorf("unexpected planned instances %v", recorded.PlannedInstances["node-1"])
+	}
+}
+
+func TestRollingUpdateResumeSurged(t *testing.T) {
+	k8sClient := fake.NewSimpleClientset()
+
+	mockcloud := awsup.BuildMockAWSCloud("us-east-1", "abc")
+	mockcloud.MockAutoscaling = &mockautoscaling.MockAutoscaling{}
+
+	maxSurge := intstr.FromInt(1)
+	cluster := &kopsapi.Cluster{}
+	cluster.Name = "test.k8s.local"
+	cluster.Spec.RollingUpdate = &kopsapi.RollingUpdate{
+		MaxSurge: &maxSurge,
+	}
+
+	vfs.Context.ResetMemfsContext(true)
+
+	// Record a rolling-update that was interrupted after surging node-1 from 2 to 3 instances
+	{
+		s := NewProgressStore(cluster, testConfigBase(t, cluster))
+		if _, err := s.Begin([]string{"node-1"}); err != nil {
+			t.Fatalf("error from Begin: %v", err)
+		}
+		if err := s.StartGroup(RollingUpdatePhaseNodes, "node-1"); err != nil {
+			t.Fatalf("error from StartGroup: %v", err)
+		}
+		if err := s.RecordSurge("node-1", SurgeRecord{DesiredSize: 2, MaxSize: 2}); err != nil {
+			t.Fatalf("error from RecordSurge: %v", err)
+		}
+	}
+
+	progress := NewProgressStore(cluster, testConfigBase(t, cluster))
+	if err := progress.Resume(); err != nil {
+		t.Fatalf("error from Resume: %v", err)
+	}
+
+	c := &RollingUpdateCluster{
+		Cloud:           mockcloud,
+		MasterInterval:  1 * time.Millisecond,
+		NodeInterval:    1 * time.Millisecond,
+		BastionInterval: 1 * time.Millisecond,
+		K8sClient:       k8sClient,
+		Progress:        progress,
+	}
+
+	setUpCloud(c)
+
+	cloud := c.Cloud.(awsup.AWSCloud)
+	if _, err := cloud.Autoscaling().UpdateAutoScalingGroup(&autoscaling.UpdateAutoScalingGroupInput{
+		AutoScalingGroupName: aws.String("node-1"),
+		DesiredCapacity:      aws.Int64(3),
+		MaxSize:              aws.Int64(3),
+	}); err != nil {
+		t.Fatalf("error surging group: %v", err)
+	}
+	asgGroups, _ := cloud.Autoscaling().DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: []*string{aws.String("node-1")},
+	})
+	asg := asgGroups.AutoScalingGroups[0]
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	groups["node-1"] = &cloudinstances.CloudInstanceGroup{
+		InstanceGroup: &kopsapi.InstanceGroup{
+			ObjectMeta: v1meta.ObjectMeta{
+				Name: "node-1",
+			},
+			Spec: kopsapi.InstanceGroupSpec{
+				Role: kopsapi.InstanceGroupRoleNode,
+			},
+		},
+		NeedUpdate: []*cloudinstances.CloudInstanceGroupMember{
+			{
+				ID:   "node-1a",
+				Node: &v1.Node{},
+			},
+			{
+				ID:   "node-1b",
+				Node: &v1.Node{},
+			},
+		},
+		MinSize:    1,
+		MaxSize:    3,
+		TargetSize: 3,
+		Raw:        asg,
+	}
+
+	if err := c.RollingUpdate(groups, cluster, &kopsapi.InstanceGroupList{}); err != nil {
+		t.Errorf("Error on rolling update: %v", err)
+	}
+
+	if aws.Int64Value(asg.DesiredCapacity) != 2 {
+		t.Errorf("expected desired capacity to be restored to the size before the surge, 2, was %d", aws.Int64Value(asg.DesiredCapacity))
+	}
+	if aws.Int64Value(asg.MaxSize) != 2 {
+		t.Errorf("expected max size to be restored to the size before the surge, 2, was %d", aws.Int64Value(asg.MaxSize))
+	}
+
+	recorded, err := progress.Load()
+	if err != nil {
+		t.Fatalf("error from Load: %v", err)
+	}
+	if len(recorded.Surges) != 0 {
+		t.Errorf("expected the surge to be cleared once the group was restored, found %v", recorded.Surges)
+	}
+}
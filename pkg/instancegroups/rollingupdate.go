@@ -18,6 +18,7 @@ package instancegroups
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -56,6 +57,23 @@ type RollingUpdateCluster struct {
 
 	// ValidationTimeout is the maximum time to wait for the cluster to validate, once we start validation
 	ValidationTimeout time.Duration
+
+	// Progress records the progress of the rolling update in the state store, so that it can be resumed.
+	// If nil, progress is not recorded.
+	Progress *ProgressStore
+
+	// NodeGroupConcurrency is the maximum number of node instance groups to roll at the same time
+	NodeGroupConcurrency int
+
+	// Hooks are run around the replacement of every instance, in addition to the hooks in each InstanceGroup spec
+	Hooks []InstanceHook
+
+	// Abort is checked before each batch of instances is replaced; if it returns an error the rolling update stops with that error.
+	// It is used to stop when the cluster lock is lost.  If nil, the rolling update is never aborted.
+	Abort func() error
+
+	// disruptions coordinates drains across node groups that are rolled concurrently
+	disruptions *disruptionGuard
 }
 
 // RollingUpdate performs a rolling update on a K8s Cluster.
@@ -84,11 +102,22 @@ func (c *RollingUpdateCluster) RollingUpdate(groups map[string]*cloudinstances.C
 		}
 	}
 
+	// Roll the groups in a stable order, so that an interrupted rolling-update can be resumed in the same order
+	var order []string
+	order = append(order, sortedGroupNames(bastionGroups)...)
+	order = append(order, sortedGroupNames(masterGroups)...)
+	order = append(order, sortedGroupNames(nodeGroups)...)
+
+	order, err := c.Progress.Begin(order)
+	if err != nil {
+		return err
+	}
+
 	// Upgrade bastions first; if these go down we can't see anything
 	{
 		var wg sync.WaitGroup
 
-		for k, bastionGroup := range bastionGroups {
+		for _, k := range c.pendingGroups(order, bastionGroups) {
 			wg.Add(1)
 			go func(k string, group *cloudinstances.CloudInstanceGroup) {
 				resultsMutex.Lock()
@@ -97,15 +126,20 @@ func (c *RollingUpdateCluster) RollingUpdate(groups map[string]*cloudinstances.C
 
 				defer wg.Done()
 
+				logProgressError(c.Progress.StartGroup(RollingUpdatePhaseBastions, k))
+
 				g, err := NewRollingUpdateInstanceGroup(c.Cloud, group)
 				if err == nil {
 					err = g.RollingUpdate(c, cluster, instanceGroups, true, c.BastionInterval, c.ValidationTimeout)
 				}
+				if err == nil {
+					logProgressError(c.Progress.CompleteGroup(k))
+				}
 
 				resultsMutex.Lock()
 				results[k] = err
 				resultsMutex.Unlock()
-			}(k, bastionGroup)
+			}(k, bastionGroups[k])
 		}
 
 		wg.Wait()
@@ -124,8 +158,10 @@ func (c *RollingUpdateCluster) RollingUpdate(groups map[string]*cloudinstances.C
 		// typically they will be in separate instance groups, so we can force the zones,
 		// and we don't want to roll all the masters at the same time.  See issue #284
 
-		for _, group := range masterGroups {
-			g, err := NewRollingUpdateInstanceGroup(c.Cloud, group)
+		for _, k := range c.pendingGroups(order, masterGroups) {
+			logProgressError(c.Progress.StartGroup(RollingUpdatePhaseMasters, k))
+
+			g, err := NewRollingUpdateInstanceGroup(c.Cloud, masterGroups[k])
 			if err == nil {
 				err = g.RollingUpdate(c, cluster, instanceGroups, false, c.MasterInterval, c.ValidationTimeout)
 			}
@@ -134,6 +170,8 @@ func (c *RollingUpdateCluster) RollingUpdate(groups map[string]*cloudinstances.C
 			if err != nil {
 				return fmt.Errorf("master not healthy after update, stopping rolling-update: %q", err)
 			}
+
+			logProgressError(c.Progress.CompleteGroup(k))
 		}
 	}
 
@@ -141,36 +179,60 @@ func (c *RollingUpdateCluster) RollingUpdate(groups map[string]*cloudinstances.C
 	{
 		var wg sync.WaitGroup
 
-		// We run nodes in series, even if they are in separate instance groups
+		// By default we run nodes in series, even if they are in separate instance groups
 		// typically they will not being separate instance groups. If you roll the nodes in parallel
 		// you can get into a scenario where you can evict multiple statefulset pods from the same
-		// statefulset at the same time. Further improvements needs to be made to protect from this as
-		// well.
+		// statefulset at the same time. When rolling several groups at once, we check the
+		// PodDisruptionBudgets across all of them before draining each node to protect from this.
 
-		wg.Add(1)
+		pending := c.pendingGroups(order, nodeGroups)
 
-		go func() {
-			for k := range nodeGroups {
-				resultsMutex.Lock()
-				results[k] = fmt.Errorf("function panic nodes")
-				resultsMutex.Unlock()
-			}
+		concurrency := c.NodeGroupConcurrency
+		if concurrency < 1 {
+			concurrency = 1
+		}
+		if concurrency > len(pending) {
+			concurrency = len(pending)
+		}
+		if concurrency > 1 && c.K8sClient != nil && !c.CloudOnly {
+			c.disruptions = newDisruptionGuard(c.K8sClient)
+		}
 
-			defer wg.Done()
+		work := make(chan string, len(pending))
+		for _, k := range pending {
+			resultsMutex.Lock()
+			results[k] = fmt.Errorf("function panic nodes")
+			resultsMutex.Unlock()
 
-			for k, group := range nodeGroups {
-				g, err := NewRollingUpdateInstanceGroup(c.Cloud, group)
-				if err == nil {
-					err = g.RollingUpdate(c, cluster, instanceGroups, false, c.NodeInterval, c.ValidationTimeout)
-				}
+			work <- k
+		}
+		close(work)
 
-				resultsMutex.Lock()
-				results[k] = err
-				resultsMutex.Unlock()
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
 
-				// TODO: Bail on error?
-			}
-		}()
+			go func() {
+				defer wg.Done()
+
+				for k := range work {
+					logProgressError(c.Progress.StartGroup(RollingUpdatePhaseNodes, k))
+
+					g, err := NewRollingUpdateInstanceGroup(c.Cloud, nodeGroups[k])
+					if err == nil {
+						err = g.RollingUpdate(c, cluster, instanceGroups, false, c.NodeInterval, c.ValidationTimeout)
+					}
+					if err == nil {
+						logProgressError(c.Progress.CompleteGroup(k))
+					}
+
+					resultsMutex.Lock()
+					results[k] = err
+					resultsMutex.Unlock()
+
+					// TODO: Bail on error?
+				}
+			}()
+		}
 
 		wg.Wait()
 	}
@@ -181,6 +243,42 @@ func (c *RollingUpdateCluster) RollingUpdate(groups map[string]*cloudinstances.C
 		}
 	}
 
+	logProgressError(c.Progress.Finish())
+
 	glog.Infof("Rolling update completed for cluster %q!", c.ClusterName)
 	return nil
 }
+
+// pendingGroups returns the names of the groups that still need to be rolled, in the given order,
+// skipping any that were completed before an interrupted rolling-update
+func (c *RollingUpdateCluster) pendingGroups(order []string, groups map[string]*cloudinstances.CloudInstanceGroup) []string {
+	var pending []string
+	for _, k := range order {
+		if groups[k] == nil {
+			continue
+		}
+		if c.Progress.IsGroupComplete(k) {
+			glog.Infof("Skipping group %q, which was completed before the rolling-update was interrupted", k)
+			continue
+		}
+		pending = append(pending, k)
+	}
+	return pending
+}
+
+// sortedGroupNames returns the keys of the map, sorted
+func sortedGroupNames(groups map[string]*cloudinstances.CloudInstanceGroup) []string {
+	var names []string
+	for k := range groups {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// logProgressError warns if we could not record progress; the rolling-update itself carries on
+func logProgressError(err error) {
+	if err != nil {
+		glog.Warningf("error recording rolling-update progress: %v", err)
+	}
+}
@@ -25,6 +25,7 @@ import (
 
 	"k8s.io/api/core/v1"
 	v1meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/kops/cloudmock/aws/mockautoscaling"
 	kopsapi "k8s.io/kops/pkg/apis/kops"
@@ -679,3 +680,213 @@ func TestRollingUpdateUnknownRole(t *testing.T) {
 		}
 	}
 }
+
+func TestRollingUpdateSurge(t *testing.T) {
+	k8sClient := fake.NewSimpleClientset()
+
+	mockcloud := awsup.BuildMockAWSCloud("us-east-1", "abc")
+	mockcloud.MockAutoscaling = &mockautoscaling.MockAutoscaling{}
+
+	maxSurge := intstr.FromInt(1)
+	cluster := &kopsapi.Cluster{}
+	cluster.Name = "test.k8s.local"
+	cluster.Spec.RollingUpdate = &kopsapi.RollingUpdate{
+		MaxSurge: &maxSurge,
+	}
+
+	c := &RollingUpdateCluster{
+		Cloud:           mockcloud,
+		MasterInterval:  1 * time.Millisecond,
+		NodeInterval:    1 * time.Millisecond,
+		BastionInterval: 1 * time.Millisecond,
+		Force:           false,
+		K8sClient:       k8sClient,
+	}
+
+	cloud := c.Cloud.(awsup.AWSCloud)
+	setUpCloud(c)
+
+	asgGroups, _ := cloud.Autoscaling().DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: []*string{aws.String("node-1")},
+	})
+	asg := asgGroups.AutoScalingGroups[0]
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	groups["node-1"] = &cloudinstances.CloudInstanceGroup{
+		InstanceGroup: &kopsapi.InstanceGroup{
+			ObjectMeta: v1meta.ObjectMeta{
+				Name: "node-1",
+			},
+			Spec: kopsapi.InstanceGroupSpec{
+				Role: kopsapi.InstanceGroupRoleNode,
+			},
+		},
+		NeedUpdate: []*cloudinstances.CloudInstanceGroupMember{
+			{
+				ID:   "node-1a",
+				Node: &v1.Node{},
+			},
+			{
+				ID:   "node-1b",
+				Node: &v1.Node{},
+			},
+		},
+		MinSize:    1,
+		MaxSize:    2,
+		TargetSize: 2,
+		Raw:        asg,
+	}
+
+	err := c.RollingUpdate(groups, cluster, &kopsapi.InstanceGroupList{})
+	if err != nil {
+		t.Errorf("Error on rolling update: %v", err)
+	}
+
+	if len(asg.Instances) > 0 {
+		t.Error("Not all instances terminated")
+	}
+	if aws.Int64Value(asg.DesiredCapacity) != 2 {
+		t.Errorf("expected desired capacity to be restored to 2, was %d", aws.Int64Value(asg.DesiredCapacity))
+	}
+	if aws.Int64Value(asg.MaxSize) != 2 {
+		t.Errorf("expected max size to be restored to 2, was %d", aws.Int64Value(asg.MaxSize))
+	}
+}
+
+func TestRollingUpdateSurgeRestoresSizeOnError(t *testing.T) {
+	k8sClient := fake.NewSimpleClientset()
+
+	mockcloud := awsup.BuildMockAWSCloud("us-east-1", "abc")
+	mockcloud.MockAutoscaling = &mockautoscaling.MockAutoscaling{}
+
+	maxSurge := intstr.FromInt(1)
+	cluster := &kopsapi.Cluster{}
+	cluster.Name = "test.k8s.local"
+	cluster.Spec.RollingUpdate = &kopsapi.RollingUpdate{
+		MaxSurge: &maxSurge,
+	}
+
+	c := &RollingUpdateCluster{
+		Cloud:           mockcloud,
+		MasterInterval:  1 * time.Millisecond,
+		NodeInterval:    1 * time.Millisecond,
+		BastionInterval: 1 * time.Millisecond,
+		Force:           false,
+		K8sClient:       k8sClient,
+	}
+
+	cloud := c.Cloud.(awsup.AWSCloud)
+	setUpCloud(c)
+
+	asgGroups, _ := cloud.Autoscaling().DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: []*string{aws.String("node-1")},
+	})
+	asg := asgGroups.AutoScalingGroups[0]
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	groups["node-1"] = &cloudinstances.CloudInstanceGroup{
+		InstanceGroup: &kopsapi.InstanceGroup{
+			ObjectMeta: v1meta.ObjectMeta{
+				Name: "node-1",
+			},
+			Spec: kopsapi.InstanceGroupSpec{
+				Role: kopsapi.InstanceGroupRoleNode,
+			},
+		},
+		NeedUpdate: []*cloudinstances.CloudInstanceGroupMember{
+			{
+				ID:   "node-1a",
+				Node: &v1.Node{},
+			},
+			{
+				// Deleting an instance that is not in the group fails
+				ID:   "node-1z",
+				Node: &v1.Node{},
+			},
+		},
+		MinSize:    1,
+		MaxSize:    2,
+		TargetSize: 2,
+		Raw:        asg,
+	}
+
+	err := c.RollingUpdate(groups, cluster, &kopsapi.InstanceGroupList{})
+	if err == nil {
+		t.Fatalf("expected rolling update to fail")
+	}
+
+	if aws.Int64Value(asg.DesiredCapacity) != 2 {
+		t.Errorf("expected desired capacity to be restored to 2, was %d", aws.Int64Value(asg.DesiredCapacity))
+	}
+	if aws.Int64Value(asg.MaxSize) != 2 {
+		t.Errorf("expected max size to be restored to 2, was %d", aws.Int64Value(asg.MaxSize))
+	}
+}
+
+func TestRollingUpdateNodeGroupConcurrency(t *testing.T) {
+	k8sClient := fake.NewSimpleClientset()
+
+	mockcloud := awsup.BuildMockAWSCloud("us-east-1", "abc")
+	mockcloud.MockAutoscaling = &mockautoscaling.MockAutoscaling{}
+
+	cluster := &kopsapi.Cluster{}
+	cluster.Name = "test.k8s.local"
+
+	c := &RollingUpdateCluster{
+		Cloud:                mockcloud,
+		MasterInterval:       1 * time.Millisecond,
+		NodeInterval:         1 * time.Millisecond,
+		BastionInterval:      1 * time.Millisecond,
+		Force:                false,
+		K8sClient:            k8sClient,
+		NodeGroupConcurrency: 2,
+	}
+
+	cloud := c.Cloud.(awsup.AWSCloud)
+	setUpCloud(c)
+
+	groups := make(map[string]*cloudinstances.CloudInstanceGroup)
+	for _, name := range []string{"node-1", "node-2"} {
+		groups[name] = &cloudinstances.CloudInstanceGroup{
+			InstanceGroup: &kopsapi.InstanceGroup{
+				ObjectMeta: v1meta.ObjectMeta{
+					Name: name,
+				},
+				Spec: kopsapi.InstanceGroupSpec{
+					Role: kopsapi.InstanceGroupRoleNode,
+				},
+			},
+			NeedUpdate: []*cloudinstances.CloudInstanceGroupMember{
+				{
+					ID:   name + "a",
+					Node: &v1.Node{},
+				},
+				{
+					ID:   name + "b",
+					Node: &v1.Node{},
+				},
+			},
+		}
+	}
+
+	err := c.RollingUpdate(groups, cluster, &kopsapi.InstanceGroupList{})
+	if err != nil {
+		t.Errorf("Error on rolling update: %v", err)
+	}
+
+	if c.disruptions == nil {
+		t.Errorf("expected PodDisruptionBudgets to be checked when rolling node groups concurrently")
+	}
+
+	asgGroups, _ := cloud.Autoscaling().DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: []*string{aws.String("node-1"), aws.String("node-2")},
+	})
+	if len(asgGroups.AutoScalingGroups) != 2 {
+		t.Fatalf("expected 2 groups, got %d", len(asgGroups.AutoScalingGroups))
+	}
+	for _, group := range asgGroups.AutoScalingGroups {
+		if len(group.Instances) > 0 {
+			t.Errorf("Not all instances terminated in group %q", aws.StringValue(group.AutoScalingGroupName))
+		}
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/intstr"
+	api "k8s.io/kops/pkg/apis/kops"
+)
+
+// rollingUpdateSettings holds the resolved rolling-update settings for a single group
+type rollingUpdateSettings struct {
+	// maxSurge is the number of extra instances created before any existing instance is removed
+	maxSurge int
+	// maxUnavailable is the number of instances that can be removed without a replacement being ready
+	maxUnavailable int
+	// strategy is how the instances are replaced
+	strategy api.RollingUpdateStrategy
+	// minHealthyPercentage is the percentage of the group kept healthy by an instance refresh, or nil for the AWS default
+	minHealthyPercentage *int32
+}
+
+// batchSize is the number of instances that are drained and deleted together
+func (s *rollingUpdateSettings) batchSize() int {
+	return s.maxSurge + s.maxUnavailable
+}
+
+// resolveSettings merges the rolling-update settings of the InstanceGroup over the cluster-wide defaults,
+// and converts them to absolute numbers for a group of numInstances instances
+func resolveSettings(cluster *api.Cluster, group *api.InstanceGroup, numInstances int) (*rollingUpdateSettings, error) {
+	rollingUpdate := api.RollingUpdate{}
+	if cluster != nil && cluster.Spec.RollingUpdate != nil {
+		rollingUpdate = *cluster.Spec.RollingUpdate
+	}
+	if group != nil && group.Spec.RollingUpdate != nil {
+		if group.Spec.RollingUpdate.MaxSurge != nil {
+			rollingUpdate.MaxSurge = group.Spec.RollingUpdate.MaxSurge
+		}
+		if group.Spec.RollingUpdate.MaxUnavailable != nil {
+			rollingUpdate.MaxUnavailable = group.Spec.RollingUpdate.MaxUnavailable
+		}
+		if group.Spec.RollingUpdate.Strategy != nil {
+			rollingUpdate.Strategy = group.Spec.RollingUpdate.Strategy
+		}
+		if group.Spec.RollingUpdate.MinHealthyPercentage != nil {
+			rollingUpdate.MinHealthyPercentage = group.Spec.RollingUpdate.MinHealthyPercentage
+		}
+	}
+
+	settings := &rollingUpdateSettings{
+		strategy:             api.RollingUpdateStrategyDrain,
+		minHealthyPercentage: rollingUpdate.MinHealthyPercentage,
+	}
+	if rollingUpdate.Strategy != nil {
+		settings.strategy = *rollingUpdate.Strategy
+	}
+
+	if rollingUpdate.MaxSurge != nil {
+		surge, err := intstr.GetValueFromIntOrPercent(rollingUpdate.MaxSurge, numInstances, true)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maxSurge %q: %v", rollingUpdate.MaxSurge.String(), err)
+		}
+		settings.maxSurge = surge
+	}
+
+	if rollingUpdate.MaxUnavailable != nil {
+		unavailable, err := intstr.GetValueFromIntOrPercent(rollingUpdate.MaxUnavailable, numInstances, false)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maxUnavailable %q: %v", rollingUpdate.MaxUnavailable.String(), err)
+		}
+		settings.maxUnavailable = unavailable
+	} else if settings.maxSurge == 0 {
+		settings.maxUnavailable = 1
+	}
+
+	if settings.maxSurge < 0 {
+		settings.maxSurge = 0
+	}
+	if settings.maxUnavailable < 0 {
+		settings.maxUnavailable = 0
+	}
+
+	// A small percentage of a small group can round down to zero; we always need to make progress
+	if settings.maxSurge == 0 && settings.maxUnavailable == 0 {
+		settings.maxUnavailable = 1
+	}
+
+	return settings, nil
+}
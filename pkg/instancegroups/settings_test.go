@@ -0,0 +1,127 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancegroups
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/intstr"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+)
+
+func intOrString(v intstr.IntOrString) *intstr.IntOrString {
+	return &v
+}
+
+func TestResolveSettings(t *testing.T) {
+	instanceRefresh := kopsapi.RollingUpdateStrategyInstanceRefresh
+	minHealthyPercentage := int32(75)
+
+	grid := []struct {
+		Cluster        *kopsapi.RollingUpdate
+		Group          *kopsapi.RollingUpdate
+		NumInstances   int
+		MaxSurge       int
+		MaxUnavailable int
+		// Strategy is the expected strategy, if not Drain
+		Strategy kopsapi.RollingUpdateStrategy
+	}{
+		{
+			NumInstances:   3,
+			MaxSurge:       0,
+			MaxUnavailable: 1,
+		},
+		{
+			Cluster: &kopsapi.RollingUpdate{
+				MaxSurge: intOrString(intstr.FromInt(2)),
+			},
+			NumInstances:   3,
+			MaxSurge:       2,
+			MaxUnavailable: 0,
+		},
+		{
+			Cluster: &kopsapi.RollingUpdate{
+				MaxSurge:       intOrString(intstr.FromInt(2)),
+				MaxUnavailable: intOrString(intstr.FromInt(3)),
+			},
+			Group: &kopsapi.RollingUpdate{
+				MaxSurge: intOrString(intstr.FromInt(1)),
+			},
+			NumInstances:   10,
+			MaxSurge:       1,
+			MaxUnavailable: 3,
+		},
+		{
+			Group: &kopsapi.RollingUpdate{
+				MaxSurge:       intOrString(intstr.FromString("25%")),
+				MaxUnavailable: intOrString(intstr.FromString("25%")),
+			},
+			NumInstances:   10,
+			MaxSurge:       3,
+			MaxUnavailable: 2,
+		},
+		{
+			Group: &kopsapi.RollingUpdate{
+				MaxUnavailable: intOrString(intstr.FromString("10%")),
+			},
+			NumInstances:   3,
+			MaxSurge:       0,
+			MaxUnavailable: 1,
+		},
+		{
+			Cluster: &kopsapi.RollingUpdate{
+				Strategy: &instanceRefresh,
+			},
+			Group: &kopsapi.RollingUpdate{
+				MinHealthyPercentage: &minHealthyPercentage,
+			},
+			NumInstances:   3,
+			MaxSurge:       0,
+			MaxUnavailable: 1,
+			Strategy:       kopsapi.RollingUpdateStrategyInstanceRefresh,
+		},
+	}
+
+	for i, g := range grid {
+		cluster := &kopsapi.Cluster{}
+		cluster.Spec.RollingUpdate = g.Cluster
+		group := &kopsapi.InstanceGroup{}
+		group.Spec.RollingUpdate = g.Group
+
+		settings, err := resolveSettings(cluster, group, g.NumInstances)
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if settings.maxSurge != g.MaxSurge {
+			t.Errorf("case %d: expected maxSurge %d, got %d", i, g.MaxSurge, settings.maxSurge)
+		}
+		if settings.maxUnavailable != g.MaxUnavailable {
+			t.Errorf("case %d: expected maxUnavailable %d, got %d", i, g.MaxUnavailable, settings.maxUnavailable)
+		}
+		strategy := g.Strategy
+		if strategy == "" {
+			strategy = kopsapi.RollingUpdateStrategyDrain
+		}
+		if settings.strategy != strategy {
+			t.Errorf("case %d: expected strategy %q, got %q", i, strategy, settings.strategy)
+		}
+		if g.Group != nil && g.Group.MinHealthyPercentage != nil && settings.minHealthyPercentage != g.Group.MinHealthyPercentage {
+			t.Errorf("case %d: expected minHealthyPercentage from the group", i)
+		}
+	}
+}
@@ -0,0 +1,413 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyrotation
+
+import (
+	"crypto/x509"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/pkg/pki"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+// The CA is rotated in stages, so that every certificate in use is trusted at every point:
+//
+//  1. StartCARotation adds a new CA to the CA keyset.  The old CA remains primary and still signs,
+//     but nodes that are rolled trust both.
+//  2. PromoteCA makes the new CA primary, and reissues the certificates signed by the old CA.
+//     Rolled nodes use the new certificates, which are trusted by the nodes that have not been rolled yet.
+//  3. FinishCARotation removes the old CA, and the certificates it signed, once every node has been rolled again.
+//     Rolled nodes no longer trust it.
+//
+// The progress is recorded in the Rotation of the CA keyset.
+
+// CARotationPhase returns the phase of the rotation of the CA, or "" if it is not being rotated
+func CARotationPhase(keyStore fi.CAStore) (kops.KeysetRotationPhase, error) {
+	rotation, err := CARotation(keyStore)
+	if err != nil || rotation == nil {
+		return "", err
+	}
+	return rotation.Phase, nil
+}
+
+// CARotation returns the state of the rotation of the CA, or nil if it is not being rotated
+func CARotation(keyStore fi.CAStore) (*kops.KeysetRotation, error) {
+	keyset, err := findCAKeyset(keyStore)
+	if err != nil {
+		return nil, err
+	}
+	return keyset.Spec.Rotation, nil
+}
+
+// StartCARotation generates a new CA keypair and adds it to the CA keyset, pinning the existing CA as primary
+func StartCARotation(keyStore fi.CAStore) (*pki.Certificate, error) {
+	keyset, err := findCAKeyset(keyStore)
+	if err != nil {
+		return nil, err
+	}
+	if keyset.Spec.Rotation != nil {
+		return nil, fmt.Errorf("rotation of the CA is already in progress, in phase %q", keyset.Spec.Rotation.Phase)
+	}
+
+	primary := fi.FindPrimary(keyset)
+	if primary == nil {
+		return nil, fmt.Errorf("CA keyset has no primary key")
+	}
+
+	privateKey, err := pki.GeneratePrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	template := fi.BuildCAX509Template()
+	template.SerialNumber = pki.BuildPKISerial(time.Now().UnixNano())
+	cert, err := pki.SignNewCertificate(privateKey, template, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating CA certificate: %v", err)
+	}
+
+	trustingSince := metav1.Now()
+	rotation := &kops.KeysetRotation{
+		Phase:         kops.KeysetRotationPhaseTrusting,
+		NewId:         cert.Certificate.SerialNumber.String(),
+		TrustingSince: &trustingSince,
+	}
+	for _, item := range keyset.Spec.Keys {
+		rotation.OldIds = append(rotation.OldIds, item.Id)
+	}
+
+	// We pin the primary before adding the new CA, which has the highest id, so that it never becomes primary too early
+	if err := keyStore.SetKeysetRotation(fi.CertificateId_CA, primary.Id, rotation); err != nil {
+		return nil, err
+	}
+	if err := keyStore.StoreKeypair(fi.CertificateId_CA, cert, privateKey); err != nil {
+		return nil, err
+	}
+
+	return cert, nil
+}
+
+// PromoteCA makes the new CA primary, and reissues the certificates signed by the old CA.
+// It returns the names of the keypairs that were reissued.
+// It refuses while any of the instances in groups started before the new CA was added, as they would not trust
+// the certificates it signs.
+// The private keys are kept, because some are used for more than TLS, e.g. the master key signs service account tokens.
+// If it fails part way it can be run again, and only reissues the certificates that are still signed by the old CA.
+func PromoteCA(keyStore fi.CAStore, groups map[string]*cloudinstances.CloudInstanceGroup) ([]string, error) {
+	keyset, err := findCAKeyset(keyStore)
+	if err != nil {
+		return nil, err
+	}
+	rotation := keyset.Spec.Rotation
+	if rotation == nil || rotation.Phase != kops.KeysetRotationPhaseTrusting {
+		return nil, fmt.Errorf("the new CA can only be promoted in phase %q, after the rotation has been started", kops.KeysetRotationPhaseTrusting)
+	}
+
+	if untrusting := InstancesNotTrustingNewCA(rotation, groups); len(untrusting) != 0 {
+		return nil, fmt.Errorf("the new CA cannot be promoted until every instance has been replaced since the rotation started; instances that may not trust it: %s", strings.Join(untrusting, ", "))
+	}
+
+	oldCAs, err := findCertificates(keyset, rotation.OldIds)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := keyStore.SetKeysetRotation(fi.CertificateId_CA, rotation.NewId, rotation); err != nil {
+		return nil, err
+	}
+
+	keypairs, err := findKeypairsSignedBy(keyStore, oldCAs)
+	if err != nil {
+		return nil, err
+	}
+
+	var reissued []string
+	for _, name := range keypairs {
+		cert, err := keyStore.FindCert(name)
+		if err != nil {
+			return reissued, err
+		}
+		privateKey, err := keyStore.FindPrivateKey(name)
+		if err != nil {
+			return reissued, err
+		}
+		if privateKey == nil {
+			glog.Warningf("not reissuing certificate %q, as its private key was not found", name)
+			continue
+		}
+
+		glog.Infof("Reissuing certificate %q, signed by the new CA", name)
+		if _, err := keyStore.CreateKeypair(fi.CertificateId_CA, name, templateFromCertificate(cert.Certificate), privateKey); err != nil {
+			return reissued, fmt.Errorf("error reissuing certificate %q: %v", name, err)
+		}
+		reissued = append(reissued, name)
+	}
+
+	signingSince := metav1.Now()
+	signing := *rotation
+	signing.Phase = kops.KeysetRotationPhaseSigning
+	signing.SigningSince = &signingSince
+	if err := keyStore.SetKeysetRotation(fi.CertificateId_CA, rotation.NewId, &signing); err != nil {
+		return reissued, err
+	}
+
+	return reissued, nil
+}
+
+// InstancesNotTrustingNewCA returns the ids of the instances in groups that may not trust the new CA of the rotation:
+// those whose node registered before the new CA was added, and those that have not registered as a node,
+// as we cannot tell when they started.
+func InstancesNotTrustingNewCA(rotation *kops.KeysetRotation, groups map[string]*cloudinstances.CloudInstanceGroup) []string {
+	if rotation.TrustingSince == nil {
+		glog.Warningf("the rotation of the CA does not record when it started, so cannot check that every instance trusts the new CA")
+		return nil
+	}
+	return instancesStartedBefore(rotation.TrustingSince, groups)
+}
+
+// InstancesUsingOldCA returns the ids of the instances in groups that may still use certificates signed by the old CA:
+// those whose node registered before the new CA was promoted, and those that have not registered as a node.
+func InstancesUsingOldCA(rotation *kops.KeysetRotation, groups map[string]*cloudinstances.CloudInstanceGroup) []string {
+	if rotation.SigningSince == nil {
+		glog.Warningf("the rotation of the CA does not record when the new CA was promoted, so cannot check that no instance uses the old CA")
+		return nil
+	}
+	return instancesStartedBefore(rotation.SigningSince, groups)
+}
+
+// instancesStartedBefore returns the ids of the instances in groups whose node registered before since,
+// and of those that have not registered as a node, as we cannot tell when they started.
+func instancesStartedBefore(since *metav1.Time, groups map[string]*cloudinstances.CloudInstanceGroup) []string {
+	var ids []string
+	for _, group := range groups {
+		for _, members := range [][]*cloudinstances.CloudInstanceGroupMember{group.Ready, group.NeedUpdate} {
+			for _, member := range members {
+				if member.Node == nil {
+					ids = append(ids, member.ID+" (not registered as a node)")
+				} else if member.Node.CreationTimestamp.Before(since) {
+					ids = append(ids, member.ID)
+				}
+			}
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// FinishCARotation removes the old CA from the CA keyset, along with the certificates it signed that are no longer primary.
+// It refuses while any of the instances in groups started before the new CA was promoted, as they may still use
+// certificates signed by the old CA.
+func FinishCARotation(keyStore fi.CAStore, groups map[string]*cloudinstances.CloudInstanceGroup) error {
+	keyset, err := findCAKeyset(keyStore)
+	if err != nil {
+		return err
+	}
+	rotation := keyset.Spec.Rotation
+	if rotation == nil || rotation.Phase != kops.KeysetRotationPhaseSigning {
+		return fmt.Errorf("the rotation can only be finished in phase %q, after the new CA has been promoted", kops.KeysetRotationPhaseSigning)
+	}
+
+	if old := InstancesUsingOldCA(rotation, groups); len(old) != 0 {
+		return fmt.Errorf("the rotation cannot be finished until every instance has been replaced since the new CA was promoted; instances that may use the old CA: %s", strings.Join(old, ", "))
+	}
+
+	oldCAs, err := findCertificates(keyset, rotation.OldIds)
+	if err != nil {
+		return err
+	}
+
+	keysets, err := keyStore.ListKeysets()
+	if err != nil {
+		return fmt.Errorf("error listing keysets: %v", err)
+	}
+	for _, k := range keysets {
+		if k.Spec.Type != kops.SecretTypeKeypair || k.Name == fi.CertificateId_CA {
+			continue
+		}
+		full, err := keyStore.FindCertificateKeyset(k.Name)
+		if err != nil {
+			return err
+		}
+		if full == nil {
+			continue
+		}
+		primary := fi.FindPrimary(full)
+		for _, item := range full.Spec.Keys {
+			if primary != nil && item.Id == primary.Id {
+				continue
+			}
+			cert, err := parseCertificate(full.Name, item)
+			if err != nil {
+				return err
+			}
+			if cert == nil || !isSignedBy(cert.Certificate, oldCAs) {
+				continue
+			}
+			glog.Infof("Removing certificate %s/%s, signed by the old CA", full.Name, item.Id)
+			if err := keyStore.DeleteKeysetItem(full, item.Id); err != nil {
+				return fmt.Errorf("error removing certificate %s/%s: %v", full.Name, item.Id, err)
+			}
+		}
+	}
+
+	for _, id := range rotation.OldIds {
+		glog.Infof("Removing old CA %s", id)
+		if err := keyStore.DeleteKeysetItem(keyset, id); err != nil {
+			return fmt.Errorf("error removing old CA %s: %v", id, err)
+		}
+	}
+
+	return keyStore.SetKeysetRotation(fi.CertificateId_CA, "", nil)
+}
+
+// RotateKeypair issues a new certificate and private key for a keypair signed by the CA.
+// The old certificate is kept, but is no longer primary.
+func RotateKeypair(keyStore fi.CAStore, name string) (*pki.Certificate, error) {
+	if name == fi.CertificateId_CA {
+		return nil, fmt.Errorf("the CA must be rotated in stages, with kops rotate ca")
+	}
+
+	cert, err := keyStore.FindCert(name)
+	if err != nil {
+		return nil, err
+	}
+	if cert == nil {
+		return nil, fmt.Errorf("keypair %q not found", name)
+	}
+	if cert.IsCA {
+		return nil, fmt.Errorf("keypair %q is a CA, which cannot be rotated", name)
+	}
+
+	pool, err := keyStore.FindCertificatePool(fi.CertificateId_CA)
+	if err != nil {
+		return nil, err
+	}
+	if pool == nil || !isSignedBy(cert.Certificate, pool.All()) {
+		return nil, fmt.Errorf("keypair %q is not signed by the cluster CA, so cannot be rotated", name)
+	}
+
+	privateKey, err := pki.GeneratePrivateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	glog.Infof("Issuing new keypair %q", name)
+	return keyStore.CreateKeypair(fi.CertificateId_CA, name, templateFromCertificate(cert.Certificate), privateKey)
+}
+
+// findCAKeyset returns the keyset of the cluster CA
+func findCAKeyset(keyStore fi.CAStore) (*kops.Keyset, error) {
+	keyset, err := keyStore.FindCertificateKeyset(fi.CertificateId_CA)
+	if err != nil {
+		return nil, err
+	}
+	if keyset == nil {
+		return nil, fmt.Errorf("CA keyset not found")
+	}
+	return keyset, nil
+}
+
+// findKeypairsSignedBy returns the names of the keypairs whose primary certificate was signed by one of the CAs
+func findKeypairsSignedBy(keyStore fi.CAStore, cas []*pki.Certificate) ([]string, error) {
+	keysets, err := keyStore.ListKeysets()
+	if err != nil {
+		return nil, fmt.Errorf("error listing keysets: %v", err)
+	}
+
+	var names []string
+	for _, keyset := range keysets {
+		if keyset.Spec.Type != kops.SecretTypeKeypair || keyset.Name == fi.CertificateId_CA {
+			continue
+		}
+		cert, err := keyStore.FindCert(keyset.Name)
+		if err != nil {
+			return nil, err
+		}
+		if cert == nil || cert.IsCA {
+			continue
+		}
+		if isSignedBy(cert.Certificate, cas) {
+			names = append(names, keyset.Name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// findCertificates returns the certificates of the items in the keyset with the ids
+func findCertificates(keyset *kops.Keyset, ids []string) ([]*pki.Certificate, error) {
+	var certs []*pki.Certificate
+	for _, id := range ids {
+		for _, item := range keyset.Spec.Keys {
+			if item.Id != id {
+				continue
+			}
+			cert, err := parseCertificate(keyset.Name, item)
+			if err != nil {
+				return nil, err
+			}
+			if cert != nil {
+				certs = append(certs, cert)
+			}
+		}
+	}
+	return certs, nil
+}
+
+// parseCertificate returns the certificate of a KeysetItem, or nil if it has none
+func parseCertificate(name string, item kops.KeysetItem) (*pki.Certificate, error) {
+	if len(item.PublicMaterial) == 0 {
+		return nil, nil
+	}
+	cert, err := pki.ParsePEMCertificate(item.PublicMaterial)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing certificate %s/%s: %v", name, item.Id, err)
+	}
+	return cert, nil
+}
+
+// isSignedBy returns true if the certificate was signed by one of the CAs
+func isSignedBy(cert *x509.Certificate, cas []*pki.Certificate) bool {
+	for _, ca := range cas {
+		if ca == nil || ca.Certificate == nil {
+			continue
+		}
+		if err := cert.CheckSignatureFrom(ca.Certificate); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// templateFromCertificate returns a template for issuing a certificate like cert
+func templateFromCertificate(cert *x509.Certificate) *x509.Certificate {
+	return &x509.Certificate{
+		Subject:               cert.Subject,
+		DNSNames:              cert.DNSNames,
+		EmailAddresses:        cert.EmailAddresses,
+		IPAddresses:           cert.IPAddresses,
+		KeyUsage:              cert.KeyUsage,
+		ExtKeyUsage:           cert.ExtKeyUsage,
+		BasicConstraintsValid: cert.BasicConstraintsValid,
+	}
+}
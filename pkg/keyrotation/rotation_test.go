@@ -0,0 +1,253 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keyrotation
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+	"time"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/client/clientset_generated/clientset/fake"
+	"k8s.io/kops/pkg/cloudinstances"
+	"k8s.io/kops/pkg/pki"
+	"k8s.io/kops/upup/pkg/fi"
+)
+
+func buildTestKeyStore(t *testing.T) fi.CAStore {
+	clientset := fake.NewSimpleClientset()
+	keyStore := fi.NewClientsetCAStore(&kops.Cluster{}, clientset.Kops(), "default")
+
+	caKey, err := pki.GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	template := fi.BuildCAX509Template()
+	template.SerialNumber = pki.BuildPKISerial(time.Now().UnixNano())
+	caCert, err := pki.SignNewCertificate(caKey, template, nil, nil)
+	if err != nil {
+		t.Fatalf("error signing CA certificate: %v", err)
+	}
+	if err := keyStore.StoreKeypair(fi.CertificateId_CA, caCert, caKey); err != nil {
+		t.Fatalf("error storing CA: %v", err)
+	}
+
+	kubeletKey, err := pki.GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	kubeletTemplate := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "kubelet", Organization: []string{"system:nodes"}},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+	}
+	if _, err := keyStore.CreateKeypair(fi.CertificateId_CA, "kubelet", kubeletTemplate, kubeletKey); err != nil {
+		t.Fatalf("error creating kubelet keypair: %v", err)
+	}
+
+	return keyStore
+}
+
+func checkSignedBy(t *testing.T, keyStore fi.CAStore, name string, ca *pki.Certificate) {
+	cert, err := keyStore.FindCert(name)
+	if err != nil {
+		t.Fatalf("error finding certificate %q: %v", name, err)
+	}
+	if err := cert.Certificate.CheckSignatureFrom(ca.Certificate); err != nil {
+		t.Errorf("certificate %q not signed by expected CA: %v", name, err)
+	}
+}
+
+func mustAsString(t *testing.T, key *pki.PrivateKey) string {
+	s, err := key.AsString()
+	if err != nil {
+		t.Fatalf("error serializing private key: %v", err)
+	}
+	return s
+}
+
+func TestCARotation(t *testing.T) {
+	keyStore := buildTestKeyStore(t)
+
+	oldCA, err := keyStore.FindCert(fi.CertificateId_CA)
+	if err != nil {
+		t.Fatalf("error finding CA: %v", err)
+	}
+	oldKubeletKey, err := keyStore.FindPrivateKey("kubelet")
+	if err != nil {
+		t.Fatalf("error finding kubelet key: %v", err)
+	}
+
+	// Start: the new CA is trusted, but the old CA is still primary
+	newCA, err := StartCARotation(keyStore)
+	if err != nil {
+		t.Fatalf("error starting rotation: %v", err)
+	}
+	if phase, _ := CARotationPhase(keyStore); phase != kops.KeysetRotationPhaseTrusting {
+		t.Errorf("unexpected phase after start: %q", phase)
+	}
+	if ca, _ := keyStore.FindCert(fi.CertificateId_CA); ca.Certificate.SerialNumber.Cmp(oldCA.Certificate.SerialNumber) != 0 {
+		t.Errorf("primary CA changed when rotation started")
+	}
+	pool, err := keyStore.FindCertificatePool(fi.CertificateId_CA)
+	if err != nil {
+		t.Fatalf("error finding CA pool: %v", err)
+	}
+	if len(pool.All()) != 2 {
+		t.Errorf("expected 2 trusted CAs after start, got %d", len(pool.All()))
+	}
+	if _, err := StartCARotation(keyStore); err == nil {
+		t.Errorf("expected error starting rotation twice")
+	}
+	if err := FinishCARotation(keyStore, nil); err == nil {
+		t.Errorf("expected error finishing rotation before promoting")
+	}
+
+	// Promote is refused while an instance started before the new CA was added, or has not registered
+	rotation, err := CARotation(keyStore)
+	if err != nil {
+		t.Fatalf("error finding rotation: %v", err)
+	}
+	before := metav1.NewTime(rotation.TrustingSince.Add(-time.Hour))
+	after := metav1.NewTime(rotation.TrustingSince.Add(time.Minute))
+	groups := map[string]*cloudinstances.CloudInstanceGroup{
+		"nodes": {
+			Ready: []*cloudinstances.CloudInstanceGroupMember{
+				{ID: "i-old", Node: &v1.Node{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: before}}},
+				{ID: "i-new", Node: &v1.Node{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: after}}},
+			},
+			NeedUpdate: []*cloudinstances.CloudInstanceGroupMember{
+				{ID: "i-unregistered"},
+			},
+		},
+	}
+	if untrusting := InstancesNotTrustingNewCA(rotation, groups); len(untrusting) != 2 || untrusting[0] != "i-old" || untrusting[1] != "i-unregistered (not registered as a node)" {
+		t.Errorf("unexpected instances not trusting the new CA: %v", untrusting)
+	}
+	if _, err := PromoteCA(keyStore, groups); err == nil {
+		t.Errorf("expected error promoting CA while instances do not trust it")
+	}
+	if phase, _ := CARotationPhase(keyStore); phase != kops.KeysetRotationPhaseTrusting {
+		t.Errorf("phase changed after refusing to promote: %q", phase)
+	}
+	groups["nodes"].Ready = groups["nodes"].Ready[1:]
+	groups["nodes"].NeedUpdate = nil
+
+	// Promote: the new CA signs, and the leaf certificates are reissued with the same keys
+	reissued, err := PromoteCA(keyStore, groups)
+	if err != nil {
+		t.Fatalf("error promoting CA: %v", err)
+	}
+	if len(reissued) != 1 || reissued[0] != "kubelet" {
+		t.Errorf("unexpected reissued keypairs: %v", reissued)
+	}
+	if phase, _ := CARotationPhase(keyStore); phase != kops.KeysetRotationPhaseSigning {
+		t.Errorf("unexpected phase after promote: %q", phase)
+	}
+	if ca, _ := keyStore.FindCert(fi.CertificateId_CA); ca.Certificate.SerialNumber.Cmp(newCA.Certificate.SerialNumber) != 0 {
+		t.Errorf("new CA is not primary after promote")
+	}
+	checkSignedBy(t, keyStore, "kubelet", newCA)
+	kubeletKey, err := keyStore.FindPrivateKey("kubelet")
+	if err != nil {
+		t.Fatalf("error finding kubelet key: %v", err)
+	}
+	if a, b := mustAsString(t, kubeletKey), mustAsString(t, oldKubeletKey); a != b {
+		t.Errorf("kubelet private key changed when the certificate was reissued")
+	}
+
+	// Finish is refused while an instance started before the new CA was promoted
+	rotation, err = CARotation(keyStore)
+	if err != nil {
+		t.Fatalf("error finding rotation: %v", err)
+	}
+	if rotation.SigningSince == nil {
+		t.Fatalf("promote did not record when the new CA was promoted")
+	}
+	promoting := metav1.NewTime(rotation.SigningSince.Add(-time.Second))
+	promoted := metav1.NewTime(rotation.SigningSince.Add(time.Minute))
+	groups["nodes"].Ready = []*cloudinstances.CloudInstanceGroupMember{
+		{ID: "i-promoting", Node: &v1.Node{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: promoting}}},
+		{ID: "i-rolled", Node: &v1.Node{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: promoted}}},
+	}
+	if old := InstancesUsingOldCA(rotation, groups); len(old) != 1 || old[0] != "i-promoting" {
+		t.Errorf("unexpected instances using the old CA: %v", old)
+	}
+	if err := FinishCARotation(keyStore, groups); err == nil {
+		t.Errorf("expected error finishing rotation while instances use the old CA")
+	}
+	if phase, _ := CARotationPhase(keyStore); phase != kops.KeysetRotationPhaseSigning {
+		t.Errorf("phase changed after refusing to finish: %q", phase)
+	}
+	groups["nodes"].Ready = groups["nodes"].Ready[1:]
+
+	// Finish: only the new CA is trusted, and the old kubelet certificate is removed
+	if err := FinishCARotation(keyStore, groups); err != nil {
+		t.Fatalf("error finishing rotation: %v", err)
+	}
+	if phase, _ := CARotationPhase(keyStore); phase != "" {
+		t.Errorf("unexpected phase after finish: %q", phase)
+	}
+	pool, err = keyStore.FindCertificatePool(fi.CertificateId_CA)
+	if err != nil {
+		t.Fatalf("error finding CA pool: %v", err)
+	}
+	if len(pool.All()) != 1 || pool.Primary.Certificate.SerialNumber.Cmp(newCA.Certificate.SerialNumber) != 0 {
+		t.Errorf("expected only the new CA to be trusted after finish")
+	}
+	kubelet, err := keyStore.FindCertificateKeyset("kubelet")
+	if err != nil {
+		t.Fatalf("error finding kubelet keyset: %v", err)
+	}
+	if len(kubelet.Spec.Keys) != 1 {
+		t.Errorf("expected old kubelet certificate to be removed, found %d", len(kubelet.Spec.Keys))
+	}
+	checkSignedBy(t, keyStore, "kubelet", newCA)
+}
+
+func TestRotateKeypair(t *testing.T) {
+	keyStore := buildTestKeyStore(t)
+
+	ca, err := keyStore.FindCert(fi.CertificateId_CA)
+	if err != nil {
+		t.Fatalf("error finding CA: %v", err)
+	}
+	oldCert, err := keyStore.FindCert("kubelet")
+	if err != nil {
+		t.Fatalf("error finding kubelet certificate: %v", err)
+	}
+
+	newCert, err := RotateKeypair(keyStore, "kubelet")
+	if err != nil {
+		t.Fatalf("error rotating keypair: %v", err)
+	}
+	if newCert.Certificate.SerialNumber.Cmp(oldCert.Certificate.SerialNumber) == 0 {
+		t.Errorf("certificate was not reissued")
+	}
+	if newCert.Certificate.Subject.CommonName != "kubelet" {
+		t.Errorf("unexpected subject of reissued certificate: %v", newCert.Certificate.Subject)
+	}
+	checkSignedBy(t, keyStore, "kubelet", ca)
+
+	if _, err := RotateKeypair(keyStore, fi.CertificateId_CA); err == nil {
+		t.Errorf("expected error rotating the CA as a keypair")
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeconfig
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/golang/glog"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/kops/pkg/pki"
+)
+
+// KubeconfigCertificate is a certificate found in the kubeconfig
+type KubeconfigCertificate struct {
+	// Name identifies where the certificate was found, e.g. certificate-authority or client-certificate
+	Name        string
+	Certificate *pki.Certificate
+}
+
+// FindKubeconfigCertificates returns the certificates in the kubeconfig for the context, as exported by kops export kubecfg:
+// the trusted CAs of the cluster, and the client certificate of the user.
+// It returns nothing if the context is not in the kubeconfig.
+func FindKubeconfigCertificates(contextName string) ([]*KubeconfigCertificate, error) {
+	config, err := clientcmd.NewDefaultPathOptions().GetStartingConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error reading kubeconfig: %v", err)
+	}
+	return findConfigCertificates(config, contextName)
+}
+
+func findConfigCertificates(config *clientcmdapi.Config, contextName string) ([]*KubeconfigCertificate, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	context := config.Contexts[contextName]
+	if context == nil {
+		glog.V(2).Infof("context %q not found in kubeconfig", contextName)
+		return nil, nil
+	}
+
+	var certs []*KubeconfigCertificate
+	if cluster := config.Clusters[context.Cluster]; cluster != nil {
+		found, err := parseKubeconfigCertificates("certificate-authority", cluster.CertificateAuthorityData, cluster.CertificateAuthority)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, found...)
+	}
+	if authInfo := config.AuthInfos[context.AuthInfo]; authInfo != nil {
+		found, err := parseKubeconfigCertificates("client-certificate", authInfo.ClientCertificateData, authInfo.ClientCertificate)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, found...)
+	}
+	return certs, nil
+}
+
+// parseKubeconfigCertificates parses the certificates embedded in the kubeconfig, or in the file it references
+func parseKubeconfigCertificates(name string, data []byte, file string) ([]*KubeconfigCertificate, error) {
+	if len(data) == 0 && file != "" {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s file %q: %v", name, file, err)
+		}
+		data = b
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	parsed, err := pki.ParsePEMCertificates(data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s in kubeconfig: %v", name, err)
+	}
+
+	var certs []*KubeconfigCertificate
+	for _, cert := range parsed {
+		certs = append(certs, &KubeconfigCertificate{Name: name, Certificate: cert})
+	}
+	return certs, nil
+}
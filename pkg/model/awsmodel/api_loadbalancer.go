@@ -62,8 +62,17 @@ func (b *APILoadBalancerBuilder) Build(c *fi.ModelBuilderContext) error {
 		return fmt.Errorf("unhandled LoadBalancer type %q", lbSpec.Type)
 	}
 
+	switch lbSpec.Class {
+	case "", kops.LoadBalancerClassClassic, kops.LoadBalancerClassNetwork:
+	// OK
+
+	default:
+		return fmt.Errorf("unhandled LoadBalancer class %q", lbSpec.Class)
+	}
+
 	// Compute the subnets - only one per zone, and then break ties based on chooseBestSubnetForELB
 	var elbSubnets []*awstasks.Subnet
+	var elbSubnetSpecs []*kops.ClusterSubnetSpec
 	{
 		subnetsByZone := make(map[string][]*kops.ClusterSubnetSpec)
 		for i := range b.Cluster.Spec.Subnets {
@@ -91,9 +100,14 @@ func (b *APILoadBalancerBuilder) Build(c *fi.ModelBuilderContext) error {
 			subnet := b.chooseBestSubnetForELB(zone, subnets)
 
 			elbSubnets = append(elbSubnets, b.LinkToSubnet(subnet))
+			elbSubnetSpecs = append(elbSubnetSpecs, subnet)
 		}
 	}
 
+	if lbSpec.Class == kops.LoadBalancerClassNetwork {
+		return b.buildNetworkLoadBalancer(c, lbSpec, elbSubnetSpecs)
+	}
+
 	var elb *awstasks.LoadBalancer
 	{
 		loadBalancerName := b.GetELBName32("api")
@@ -276,6 +290,140 @@ func (b *APILoadBalancerBuilder) Build(c *fi.ModelBuilderContext) error {
 
 }
 
+// buildNetworkLoadBalancer builds an NLB for the API, which passes TLS connections through to the masters.
+// An NLB has no security group and preserves the client address, so access is restricted by the master security groups instead.
+func (b *APILoadBalancerBuilder) buildNetworkLoadBalancer(c *fi.ModelBuilderContext, lbSpec *kops.LoadBalancerAccessSpec, subnets []*kops.ClusterSubnetSpec) error {
+	if featureflag.Spotinst.Enabled() {
+		return fmt.Errorf("a network load balancer for the API is not supported with Spotinst")
+	}
+
+	// The NLB passes connections to the masters in the target group, which it health-checks over TCP
+	var tg *awstasks.TargetGroup
+	{
+		tg = &awstasks.TargetGroup{
+			Name:      s(b.ELBName("api")),
+			Lifecycle: b.Lifecycle,
+
+			TargetGroupName: s(b.GetELBName32("tcp-api")),
+			VPC:             b.LinkToVPC(),
+			Port:            i64(443),
+			Protocol:        s("TCP"),
+
+			// Configure fast-recovery health-checks; an NLB requires the thresholds to be equal
+			HealthyThreshold:   i64(2),
+			UnhealthyThreshold: i64(2),
+			Interval:           i64(10),
+		}
+		tg.Tags = b.CloudTags(*tg.Name, false)
+
+		c.AddTask(tg)
+	}
+
+	var nlb *awstasks.NetworkLoadBalancer
+	{
+		nlb = &awstasks.NetworkLoadBalancer{
+			Name:      s(b.ELBName("api")),
+			Lifecycle: b.Lifecycle,
+
+			LoadBalancerName: s(b.GetELBName32("api")),
+			Listeners: map[string]*awstasks.NetworkLoadBalancerListener{
+				"443": {TargetGroup: tg},
+			},
+			CrossZoneLoadBalancing: fi.Bool(fi.BoolValue(lbSpec.CrossZoneLoadBalancing)),
+		}
+		nlb.Tags = b.CloudTags(*nlb.Name, false)
+
+		switch lbSpec.Type {
+		case kops.LoadBalancerTypeInternal:
+			nlb.Scheme = s("internal")
+		case kops.LoadBalancerTypePublic:
+			nlb.Scheme = s("internet-facing")
+		default:
+			return fmt.Errorf("unknown elb Type: %q", lbSpec.Type)
+		}
+
+		sort.Slice(subnets, func(i, j int) bool {
+			return subnets[i].Name < subnets[j].Name
+		})
+		for _, subnet := range subnets {
+			mapping := &awstasks.SubnetMapping{
+				Subnet: b.LinkToSubnet(subnet),
+			}
+
+			// A public NLB gets a static address in each zone, so that clients can allow it through firewalls
+			if lbSpec.Type == kops.LoadBalancerTypePublic {
+				eip := &awstasks.ElasticIP{
+					Name:      s("api-" + subnet.Name + "." + b.ClusterName()),
+					Lifecycle: b.Lifecycle,
+				}
+				eip.Tags = b.CloudTags(*eip.Name, false)
+				c.AddTask(eip)
+
+				mapping.ElasticIP = eip
+			}
+
+			nlb.SubnetMappings = append(nlb.SubnetMappings, mapping)
+		}
+
+		c.AddTask(nlb)
+	}
+
+	masterGroups, err := b.GetSecurityGroups(kops.InstanceGroupRoleMaster)
+	if err != nil {
+		return err
+	}
+
+	// Allow HTTPS to the master instances from KubernetesAPIAccess CIDRs, which the NLB passes through,
+	// and from the VPC, where the NLB health-checks come from
+	{
+		cidrs := sets.NewString(b.Cluster.Spec.KubernetesAPIAccess...)
+		cidrs.Insert(b.Cluster.Spec.NetworkCIDR)
+		cidrs.Insert(b.Cluster.Spec.AdditionalNetworkCIDRs...)
+
+		for _, masterGroup := range masterGroups {
+			suffix := masterGroup.Suffix
+			for _, cidr := range cidrs.List() {
+				t := &awstasks.SecurityGroupRule{
+					Name:      s(fmt.Sprintf("https-nlb-to-master-%s%s", cidr, suffix)),
+					Lifecycle: b.SecurityLifecycle,
+
+					SecurityGroup: masterGroup.Task,
+					CIDR:          s(cidr),
+					FromPort:      i64(443),
+					ToPort:        i64(443),
+					Protocol:      s("tcp"),
+				}
+				c.AddTask(t)
+			}
+		}
+	}
+
+	if dns.IsGossipHostname(b.Cluster.Name) || b.UsePrivateDNS() {
+		// Ensure the NLB hostname is included in the TLS certificate,
+		// if we're not going to use an alias for it
+		masterKeypairTask, found := c.Tasks["Keypair/master"]
+		if !found {
+			return fmt.Errorf("keypair/master task not found")
+		}
+		masterKeypair := masterKeypairTask.(*fitasks.Keypair)
+		masterKeypair.AlternateNameTasks = append(masterKeypair.AlternateNameTasks, nlb)
+	}
+
+	for _, ig := range b.MasterInstanceGroups() {
+		t := &awstasks.TargetGroupAttachment{
+			Name:      s("api-" + ig.ObjectMeta.Name),
+			Lifecycle: b.Lifecycle,
+
+			TargetGroup:      b.LinkToTargetGroup("api"),
+			AutoscalingGroup: b.LinkToAutoscalingGroup(ig),
+		}
+
+		c.AddTask(t)
+	}
+
+	return nil
+}
+
 type scoredSubnet struct {
 	score  int
 	subnet *kops.ClusterSubnetSpec
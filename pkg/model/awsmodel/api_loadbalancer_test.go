@@ -0,0 +1,98 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package awsmodel
+
+import (
+	"testing"
+
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/model"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/awstasks"
+)
+
+// Tests that the Network class builds an NLB with a static address in each zone, in place of the ELB
+func TestNetworkLoadBalancerForAPI(t *testing.T) {
+	cluster := buildMinimalCluster()
+	cluster.Spec.Subnets = []kops.ClusterSubnetSpec{
+		{Name: "us-mock-1a", Zone: "us-mock-1a", CIDR: "172.20.1.0/24", Type: kops.SubnetTypePublic},
+		{Name: "us-mock-1b", Zone: "us-mock-1b", CIDR: "172.20.2.0/24", Type: kops.SubnetTypePublic},
+	}
+	cluster.Spec.API = &kops.AccessSpec{
+		LoadBalancer: &kops.LoadBalancerAccessSpec{
+			Type:  kops.LoadBalancerTypePublic,
+			Class: kops.LoadBalancerClassNetwork,
+			CrossZoneLoadBalancing: fi.Bool(true),
+		},
+	}
+
+	master := &kops.InstanceGroup{}
+	master.ObjectMeta.Name = "master-us-mock-1a"
+	master.Spec.Role = kops.InstanceGroupRoleMaster
+	master.Spec.Subnets = []string{"us-mock-1a"}
+
+	b := APILoadBalancerBuilder{
+		AWSModelContext: &AWSModelContext{
+			KopsModelContext: &model.KopsModelContext{
+				Cluster:        cluster,
+				InstanceGroups: []*kops.InstanceGroup{master},
+			},
+		},
+	}
+
+	c := &fi.ModelBuilderContext{
+		Tasks: make(map[string]fi.Task),
+	}
+
+	if err := b.Build(c); err != nil {
+		t.Fatalf("unexpected error building model: %v", err)
+	}
+
+	if _, found := c.Tasks["LoadBalancer/api.testcluster.test.com"]; found {
+		t.Fatalf("LoadBalancer was not expected with the Network class")
+	}
+
+	nlb := c.Tasks["NetworkLoadBalancer/api.testcluster.test.com"].(*awstasks.NetworkLoadBalancer)
+	if fi.StringValue(nlb.Scheme) != "internet-facing" {
+		t.Fatalf("NetworkLoadBalancer Scheme was expected to be internet-facing, but was %q", fi.StringValue(nlb.Scheme))
+	}
+	if !fi.BoolValue(nlb.CrossZoneLoadBalancing) {
+		t.Fatalf("NetworkLoadBalancer CrossZoneLoadBalancing was expected to be true")
+	}
+	if len(nlb.SubnetMappings) != 2 {
+		t.Fatalf("NetworkLoadBalancer was expected to have 2 subnets, but had %d", len(nlb.SubnetMappings))
+	}
+	for _, mapping := range nlb.SubnetMappings {
+		if mapping.ElasticIP == nil {
+			t.Fatalf("NetworkLoadBalancer subnet %q was expected to have an ElasticIP", fi.StringValue(mapping.Subnet.Name))
+		}
+	}
+
+	tg := c.Tasks["TargetGroup/api.testcluster.test.com"].(*awstasks.TargetGroup)
+	if nlb.Listeners["443"] == nil || nlb.Listeners["443"].TargetGroup != tg {
+		t.Fatalf("NetworkLoadBalancer was expected to forward port 443 to the target group")
+	}
+
+	if _, found := c.Tasks["TargetGroupAttachment/api-master-us-mock-1a"]; !found {
+		t.Fatalf("TargetGroupAttachment was expected for the master instance group")
+	}
+	for _, cidr := range []string{"0.0.0.0/0", "172.20.0.0/16"} {
+		if _, found := c.Tasks["SecurityGroupRule/https-nlb-to-master-"+cidr]; !found {
+			t.Fatalf("SecurityGroupRule allowing HTTPS to the masters from %s was expected", cidr)
+		}
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/golang/glog"
 
 	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/featureflag"
 	"k8s.io/kops/pkg/model"
 	"k8s.io/kops/pkg/model/defaults"
 	"k8s.io/kops/upup/pkg/fi"
@@ -51,8 +52,9 @@ func (b *AutoscalingGroupModelBuilder) Build(c *fi.ModelBuilderContext) error {
 	for _, ig := range b.InstanceGroups {
 		name := b.AutoscalingGroupName(ig)
 
-		// LaunchConfiguration
+		// LaunchConfiguration, or LaunchTemplate if enabled
 		var launchConfiguration *awstasks.LaunchConfiguration
+		var launchTemplate *awstasks.LaunchTemplate
 		{
 			volumeSize := fi.Int32Value(ig.Spec.RootVolumeSize)
 			if volumeSize == 0 {
@@ -185,9 +187,19 @@ func (b *AutoscalingGroupModelBuilder) Build(c *fi.ModelBuilderContext) error {
 				}
 				t.AssociatePublicIP = &associatePublicIP
 			}
-			c.AddTask(t)
 
-			launchConfiguration = t
+			// A mixed instances policy can only be used with a launch template
+			if featureflag.EnableLaunchTemplates.Enabled() || ig.Spec.MixedInstancesPolicy != nil {
+				launchTemplate = buildLaunchTemplate(t)
+				if ig.Spec.MixedInstancesPolicy != nil {
+					// Spot instances are requested by the policy, which does not allow them to be set in the launch template
+					launchTemplate.SpotPrice = ""
+				}
+				c.AddTask(launchTemplate)
+			} else {
+				c.AddTask(t)
+				launchConfiguration = t
+			}
 		}
 
 		// AutoscalingGroup
@@ -209,6 +221,7 @@ func (b *AutoscalingGroupModelBuilder) Build(c *fi.ModelBuilderContext) error {
 				},
 
 				LaunchConfiguration: launchConfiguration,
+				LaunchTemplate:      launchTemplate,
 			}
 
 			minSize := int32(1)
@@ -250,6 +263,14 @@ func (b *AutoscalingGroupModelBuilder) Build(c *fi.ModelBuilderContext) error {
 			}
 			t.SuspendProcesses = &processes
 
+			if spec := ig.Spec.MixedInstancesPolicy; spec != nil {
+				t.MixedInstanceOverrides = spec.Instances
+				t.MixedOnDemandBase = spec.OnDemandBase
+				t.MixedOnDemandAboveBase = spec.OnDemandAboveBase
+				t.MixedSpotAllocationStrategy = spec.SpotAllocationStrategy
+				t.MixedSpotMaxPrice = ig.Spec.MaxPrice
+			}
+
 			c.AddTask(t)
 		}
 
@@ -283,3 +304,29 @@ func (b *AutoscalingGroupModelBuilder) Build(c *fi.ModelBuilderContext) error {
 
 	return nil
 }
+
+// buildLaunchTemplate returns a LaunchTemplate with the same settings as the LaunchConfiguration
+func buildLaunchTemplate(lc *awstasks.LaunchConfiguration) *awstasks.LaunchTemplate {
+	return &awstasks.LaunchTemplate{
+		Name:      lc.Name,
+		Lifecycle: lc.Lifecycle,
+
+		UserData: lc.UserData,
+
+		ImageID:            lc.ImageID,
+		InstanceType:       lc.InstanceType,
+		SSHKey:             lc.SSHKey,
+		SecurityGroups:     lc.SecurityGroups,
+		AssociatePublicIP:  lc.AssociatePublicIP,
+		IAMInstanceProfile: lc.IAMInstanceProfile,
+		InstanceMonitoring: lc.InstanceMonitoring,
+
+		RootVolumeSize:         lc.RootVolumeSize,
+		RootVolumeType:         lc.RootVolumeType,
+		RootVolumeIops:         lc.RootVolumeIops,
+		RootVolumeOptimization: lc.RootVolumeOptimization,
+
+		SpotPrice: lc.SpotPrice,
+		Tenancy:   lc.Tenancy,
+	}
+}
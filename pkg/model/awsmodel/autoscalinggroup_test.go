@@ -97,3 +97,56 @@ func TestRootVolumeOptimizationFlag(t *testing.T) {
 		t.Fatalf("RootVolumeOptimization was expected to be true, but was false")
 	}
 }
+
+// Tests that a mixed instances policy uses a launch template, with the spot price set on the group
+func TestMixedInstancesPolicy(t *testing.T) {
+	cluster := buildMinimalCluster()
+	ig := buildNodeInstanceGroup("subnet-us-mock-1a")
+	ig.Spec.MachineType = "m4.large"
+	ig.Spec.MaxPrice = fi.String("0.05")
+	ig.Spec.MixedInstancesPolicy = &kops.MixedInstancesPolicySpec{
+		Instances:         []string{"m4.large", "m5.large"},
+		OnDemandBase:      fi.Int64(1),
+		OnDemandAboveBase: fi.Int64(20),
+	}
+
+	k := [][]byte{}
+	k = append(k, []byte("ssh-rsa AAAAB3NzaC1yc2EAAAADAQABAAABAQCySdqIU+FhCWl3BNrAvPaOe5VfL2aCARUWwy91ZP+T7LBwFa9lhdttfjp/VX1D1/PVwntn2EhN079m8c2kfdmiZ/iCHqrLyIGSd+BOiCz0lT47znvANSfxYjLUuKrWWWeaXqerJkOsAD4PHchRLbZGPdbfoBKwtb/WT4GMRQmb9vmiaZYjsfdPPM9KkWI9ECoWFGjGehA8D+iYIPR711kRacb1xdYmnjHqxAZHFsb5L8wDWIeAyhy49cBD+lbzTiioq2xWLorXuFmXh6Do89PgzvHeyCLY6816f/kCX6wIFts8A2eaEHFL4rAOsuh6qHmSxGCR9peSyuRW8DxV725x justin@test"))
+
+	b := AutoscalingGroupModelBuilder{
+		AWSModelContext: &AWSModelContext{
+			KopsModelContext: &model.KopsModelContext{
+				SSHPublicKeys:  k,
+				Cluster:        cluster,
+				InstanceGroups: []*kops.InstanceGroup{ig},
+			},
+		},
+	}
+
+	c := &fi.ModelBuilderContext{
+		Tasks: make(map[string]fi.Task),
+	}
+
+	if err := b.Build(c); err != nil {
+		t.Fatalf("unexpected error building model: %v", err)
+	}
+
+	if _, found := c.Tasks["LaunchConfiguration/nodes.testcluster.test.com"]; found {
+		t.Fatalf("LaunchConfiguration was not expected with a mixed instances policy")
+	}
+	lt := c.Tasks["LaunchTemplate/nodes.testcluster.test.com"].(*awstasks.LaunchTemplate)
+	if lt.SpotPrice != "" {
+		t.Fatalf("LaunchTemplate SpotPrice was expected to be empty, but was %q", lt.SpotPrice)
+	}
+
+	asg := c.Tasks["AutoscalingGroup/nodes.testcluster.test.com"].(*awstasks.AutoscalingGroup)
+	if asg.LaunchTemplate != lt {
+		t.Fatalf("AutoscalingGroup was expected to use the LaunchTemplate")
+	}
+	if !asg.UseMixedInstancesPolicy() {
+		t.Fatalf("AutoscalingGroup was expected to use a mixed instances policy")
+	}
+	if fi.StringValue(asg.MixedSpotMaxPrice) != "0.05" {
+		t.Fatalf("MixedSpotMaxPrice was expected to be 0.05, but was %q", fi.StringValue(asg.MixedSpotMaxPrice))
+	}
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package awsmodel
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/featureflag"
+	"k8s.io/kops/pkg/model/iam"
+	"k8s.io/kops/pkg/util/stringorslice"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/awstasks"
+)
+
+// LifecycleHookMessageRetentionPeriod is how long the queue keeps lifecycle notifications, which is the longest heartbeat timeout
+const LifecycleHookMessageRetentionPeriod = 7200
+
+// lifecycleHookRolePolicy allows the autoscaling service to assume the role that sends the lifecycle notifications
+const lifecycleHookRolePolicy = `{
+  "Version": "2012-10-17",
+  "Statement": [
+    {
+      "Effect": "Allow",
+      "Principal": { "Service": "autoscaling.amazonaws.com"},
+      "Action": "sts:AssumeRole"
+    }
+  ]
+}`
+
+// LifecycleHookModelBuilder configures the lifecycle hooks of the autoscaling groups, the SQS queue they notify,
+// and the permissions the node-drainer addon needs on the masters to drain the terminating nodes
+type LifecycleHookModelBuilder struct {
+	*AWSModelContext
+
+	Lifecycle         *fi.Lifecycle
+	SecurityLifecycle *fi.Lifecycle
+}
+
+var _ fi.ModelBuilder = &LifecycleHookModelBuilder{}
+
+func (b *LifecycleHookModelBuilder) Build(c *fi.ModelBuilderContext) error {
+	if !b.UseLifecycleHooks() {
+		return nil
+	}
+
+	if featureflag.Spotinst.Enabled() {
+		return fmt.Errorf("lifecycle hooks are not supported with Spotinst")
+	}
+
+	queueName := b.LifecycleHookQueueName()
+	queueARN := fmt.Sprintf("%s:sqs:%s:*:%s", (&iam.PolicyBuilder{Region: b.Region}).IAMPrefix(), b.Region, queueName)
+
+	queue := &awstasks.SQSQueue{
+		Name:      s(queueName),
+		Lifecycle: b.Lifecycle,
+
+		QueueName:              s(queueName),
+		MessageRetentionPeriod: i64(LifecycleHookMessageRetentionPeriod),
+		Tags: b.CloudTags(queueName, false),
+	}
+	c.AddTask(queue)
+
+	// The role the autoscaling groups assume to send the notifications to the queue
+	roleName := "lifecycle-hooks." + b.ClusterName()
+	role := &awstasks.IAMRole{
+		Name:      s(roleName),
+		Lifecycle: b.SecurityLifecycle,
+
+		RolePolicyDocument: fi.WrapResource(fi.NewStringResource(lifecycleHookRolePolicy)),
+	}
+	c.AddTask(role)
+
+	{
+		p := &iam.Policy{
+			Version: iam.PolicyDefaultVersion,
+			Statement: []*iam.Statement{
+				{
+					Effect:   iam.StatementEffectAllow,
+					Action:   stringorslice.Slice([]string{"sqs:SendMessage", "sqs:GetQueueUrl"}),
+					Resource: stringorslice.Slice([]string{queueARN}),
+				},
+			},
+		}
+		if err := b.addRolePolicy(c, roleName, role, p); err != nil {
+			return err
+		}
+	}
+
+	// The node-drainer runs on the masters; it receives the notifications and completes the lifecycle actions
+	masters := b.MasterInstanceGroups()
+	if len(masters) == 0 {
+		return fmt.Errorf("cannot configure lifecycle hooks for a cluster without masters")
+	}
+	profile, err := b.LinkToIAMInstanceProfile(masters[0])
+	if err != nil {
+		return err
+	}
+	{
+		p := &iam.Policy{
+			Version: iam.PolicyDefaultVersion,
+			Statement: []*iam.Statement{
+				{
+					Effect:   iam.StatementEffectAllow,
+					Action:   stringorslice.Slice([]string{"sqs:GetQueueUrl", "sqs:ReceiveMessage", "sqs:DeleteMessage"}),
+					Resource: stringorslice.Slice([]string{queueARN}),
+				},
+				{
+					Effect:   iam.StatementEffectAllow,
+					Action:   stringorslice.Slice([]string{"autoscaling:CompleteLifecycleAction", "autoscaling:RecordLifecycleActionHeartbeat"}),
+					Resource: stringorslice.String("*"),
+					Condition: iam.Condition{
+						"StringEquals": map[string]string{
+							"autoscaling:ResourceTag/KubernetesCluster": b.ClusterName(),
+						},
+					},
+				},
+				// The node-drainer reads the heartbeat timeout of the hooks; describe actions cannot be restricted to a resource
+				{
+					Effect:   iam.StatementEffectAllow,
+					Action:   stringorslice.Slice([]string{"autoscaling:DescribeLifecycleHooks"}),
+					Resource: stringorslice.String("*"),
+				},
+			},
+		}
+		if err := b.addRolePolicy(c, "lifecycle-hooks."+fi.StringValue(profile.Name), &awstasks.IAMRole{Name: profile.Name}, p); err != nil {
+			return err
+		}
+	}
+
+	for _, ig := range b.InstanceGroups {
+		for i := range ig.Spec.LifecycleHooks {
+			hook := &ig.Spec.LifecycleHooks[i]
+			if hook.Transition == kops.LifecycleTransitionLaunching {
+				glog.V(2).Infof("lifecycle hook %q of InstanceGroup %q must be completed by the instances as they launch", hook.Name, ig.ObjectMeta.Name)
+			}
+
+			t := &awstasks.LifecycleHook{
+				Name:      s(hook.Name + "." + b.AutoscalingGroupName(ig)),
+				Lifecycle: b.Lifecycle,
+
+				AutoscalingGroup: b.LinkToAutoscalingGroup(ig),
+				HookName:         s(hook.Name),
+				Transition:       s(string(hook.Transition)),
+				HeartbeatTimeout: hook.HeartbeatTimeout,
+				DefaultResult:    hook.DefaultResult,
+
+				NotificationTarget: queue,
+				Role:               role,
+			}
+			c.AddTask(t)
+		}
+	}
+
+	return nil
+}
+
+func (b *LifecycleHookModelBuilder) addRolePolicy(c *fi.ModelBuilderContext, name string, role *awstasks.IAMRole, p *iam.Policy) error {
+	policy, err := p.AsJSON()
+	if err != nil {
+		return fmt.Errorf("error building IAM policy: %v", err)
+	}
+
+	c.AddTask(&awstasks.IAMRolePolicy{
+		Name:      s(name),
+		Lifecycle: b.SecurityLifecycle,
+
+		Role:           role,
+		PolicyDocument: fi.WrapResource(fi.NewStringResource(policy)),
+	})
+	return nil
+}
@@ -34,6 +34,7 @@ import (
 	"k8s.io/kops/pkg/model/resources"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/cloudup/awsup"
+	"k8s.io/kops/util/pkg/vfs"
 )
 
 // BootstrapScript creates the bootstrap script
@@ -72,6 +73,19 @@ func (b *BootstrapScript) buildEnvironmentVariables(cluster *kops.Cluster) (map[
 		env["S3_SECRET_ACCESS_KEY"] = os.Getenv("S3_SECRET_ACCESS_KEY")
 	}
 
+	// The encryption key itself is not passed in the user data; it must already be in the key file on the instance
+	if os.Getenv(vfs.EnvEncryptionPrefixes) != "" {
+		env[vfs.EnvEncryptionPrefixes] = os.Getenv(vfs.EnvEncryptionPrefixes)
+		if os.Getenv(vfs.EnvEncryptionKeyFile) == "" {
+			glog.Warningf("%s is not set, so instances will not be able to read encrypted files from the state store", vfs.EnvEncryptionKeyFile)
+		} else {
+			env[vfs.EnvEncryptionKeyFile] = os.Getenv(vfs.EnvEncryptionKeyFile)
+		}
+		if os.Getenv(vfs.EnvEncryptionAllowUnencrypted) != "" {
+			env[vfs.EnvEncryptionAllowUnencrypted] = os.Getenv(vfs.EnvEncryptionAllowUnencrypted)
+		}
+	}
+
 	if kops.CloudProviderID(cluster.Spec.CloudProvider) == kops.CloudProviderDO {
 		doToken := os.Getenv("DIGITALOCEAN_ACCESS_TOKEN")
 		if doToken != "" {
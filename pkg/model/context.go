@@ -96,6 +96,23 @@ func (m *KopsModelContext) GetELBName32(prefix string) string {
 	return s
 }
 
+// LifecycleHookQueueName returns the name of the SQS queue to which the lifecycle hooks of the instance groups send their notifications
+func (m *KopsModelContext) LifecycleHookQueueName() string {
+	s := "lifecycle-hooks-" + strings.Replace(m.ClusterName(), ".", "-", -1)
+
+	// We have an 80 character limit for SQS queue names, so we add a hash if we have to truncate
+	if len(s) > 80 {
+		h := fnv.New32a()
+		if _, err := h.Write([]byte(s)); err != nil {
+			glog.Fatalf("error hashing values: %v", err)
+		}
+		hashString := strings.ToLower(base32.HexEncoding.EncodeToString(h.Sum(nil)))[:6]
+		s = s[:80-len(hashString)-1] + "-" + hashString
+	}
+
+	return s
+}
+
 func (m *KopsModelContext) ClusterName() string {
 	return m.Cluster.ObjectMeta.Name
 }
@@ -293,6 +310,25 @@ func (m *KopsModelContext) UseLoadBalancerForInternalAPI() bool {
 		m.Cluster.Spec.API.LoadBalancer.UseForInternalApi == true
 }
 
+// UseNetworkLoadBalancer checks if the API load balancer is an AWS network load balancer (NLB), rather than a classic ELB
+func (m *KopsModelContext) UseNetworkLoadBalancer() bool {
+	return m.UseLoadBalancerForAPI() &&
+		m.Cluster.Spec.API.LoadBalancer.Class == kops.LoadBalancerClassNetwork
+}
+
+// UseLifecycleHooks checks if any instance group has autoscaling group lifecycle hooks (AWS only)
+func (m *KopsModelContext) UseLifecycleHooks() bool {
+	if kops.CloudProviderID(m.Cluster.Spec.CloudProvider) != kops.CloudProviderAWS {
+		return false
+	}
+	for _, ig := range m.InstanceGroups {
+		if len(ig.Spec.LifecycleHooks) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
 // UsePrivateDNS checks if we are using private DNS
 func (m *KopsModelContext) UsePrivateDNS() bool {
 	topology := m.Cluster.Spec.Topology
@@ -101,11 +101,15 @@ func (b *DNSModelBuilder) Build(c *fi.ModelBuilderContext) error {
 			}
 
 			apiDnsName := &awstasks.DNSName{
-				Name:               s(b.Cluster.Spec.MasterPublicName),
-				Lifecycle:          b.Lifecycle,
-				Zone:               b.LinkToDNSZone(),
-				ResourceType:       s("A"),
-				TargetLoadBalancer: b.LinkToELB("api"),
+				Name:         s(b.Cluster.Spec.MasterPublicName),
+				Lifecycle:    b.Lifecycle,
+				Zone:         b.LinkToDNSZone(),
+				ResourceType: s("A"),
+			}
+			if b.UseNetworkLoadBalancer() {
+				apiDnsName.TargetNetworkLoadBalancer = b.LinkToNLB("api")
+			} else {
+				apiDnsName.TargetLoadBalancer = b.LinkToELB("api")
 			}
 			c.AddTask(apiDnsName)
 		}
@@ -121,11 +125,15 @@ func (b *DNSModelBuilder) Build(c *fi.ModelBuilderContext) error {
 			}
 
 			internalApiDnsName := &awstasks.DNSName{
-				Name:               s(b.Cluster.Spec.MasterInternalName),
-				Lifecycle:          b.Lifecycle,
-				Zone:               b.LinkToDNSZone(),
-				ResourceType:       s("A"),
-				TargetLoadBalancer: b.LinkToELB("api"),
+				Name:         s(b.Cluster.Spec.MasterInternalName),
+				Lifecycle:    b.Lifecycle,
+				Zone:         b.LinkToDNSZone(),
+				ResourceType: s("A"),
+			}
+			if b.UseNetworkLoadBalancer() {
+				internalApiDnsName.TargetNetworkLoadBalancer = b.LinkToNLB("api")
+			} else {
+				internalApiDnsName.TargetLoadBalancer = b.LinkToELB("api")
 			}
 			c.AddTask(internalApiDnsName)
 		}
@@ -53,7 +53,7 @@ func (b *StorageAclBuilder) Build(c *fi.ModelBuilderContext) error {
 			return fmt.Errorf("cannot parse cluster path %q: %v", clusterPath, err)
 		}
 
-		switch p := p.(type) {
+		switch p := vfs.UnwrapEncryption(p).(type) {
 		case *vfs.GSPath:
 			// It's not ideal that we have to do this at the bucket level,
 			// but GCS doesn't seem to have a way to do subtrees (like AWS IAM does)
@@ -77,7 +77,7 @@ func (b *StorageAclBuilder) Build(c *fi.ModelBuilderContext) error {
 
 	buckets := sets.NewString()
 	for _, p := range writeablePaths {
-		if gcsPath, ok := p.(*vfs.GSPath); ok {
+		if gcsPath, ok := vfs.UnwrapEncryption(p).(*vfs.GSPath); ok {
 			bucket := gcsPath.Bucket()
 			if buckets.Has(bucket) {
 				continue
@@ -315,6 +315,7 @@ func (b *PolicyBuilder) AddS3Permissions(p *Policy) (*Policy, error) {
 		if err != nil {
 			return nil, fmt.Errorf("cannot parse VFS path %q: %v", root, err)
 		}
+		vfsPath = vfs.UnwrapEncryption(vfsPath)
 
 		if s3Path, ok := vfsPath.(*vfs.S3Path); ok {
 			iamS3Path := s3Path.Bucket() + "/" + s3Path.Key()
@@ -400,6 +401,9 @@ func (b *PolicyBuilder) AddS3Permissions(p *Policy) (*Policy, error) {
 		} else if _, ok := vfsPath.(*vfs.MemFSPath); ok {
 			// Tests -ignore - nothing we can do in terms of IAM policy
 			glog.Warningf("ignoring memfs path %q for IAM policy builder", vfsPath)
+		} else if _, ok := vfsPath.(*vfs.VaultPath); ok {
+			// Instances authenticate to Vault with their own token, so there is nothing to grant in IAM
+			glog.V(4).Infof("not granting IAM permissions for vault path %q", vfsPath)
 		} else {
 			// We could implement this approach, but it seems better to
 			// get all clouds using cluster-readable storage
@@ -413,7 +417,7 @@ func (b *PolicyBuilder) AddS3Permissions(p *Policy) (*Policy, error) {
 	}
 
 	for _, vfsPath := range writeablePaths {
-		if s3Path, ok := vfsPath.(*vfs.S3Path); ok {
+		if s3Path, ok := vfs.UnwrapEncryption(vfsPath).(*vfs.S3Path); ok {
 			iamS3Path := s3Path.Bucket() + "/" + s3Path.Key()
 			iamS3Path = strings.TrimSuffix(iamS3Path, "/")
 
@@ -201,3 +201,83 @@ func TestPolicyGeneration(t *testing.T) {
 		}
 	}
 }
+
+func TestPolicyGenerationVaultStores(t *testing.T) {
+	grid := []struct {
+		Role   kops.InstanceGroupRole
+		Policy string
+	}{
+		{
+			Role:   "Master",
+			Policy: "tests/iam_builder_master_strict.json",
+		},
+		{
+			Role:   "Node",
+			Policy: "tests/iam_builder_node_strict.json",
+		},
+	}
+
+	for i, x := range grid {
+		b := &PolicyBuilder{
+			Cluster: &kops.Cluster{
+				Spec: kops.ClusterSpec{
+					CloudProvider: "aws",
+					ConfigStore:   "s3://kops-tests/iam-builder-test.k8s.local",
+					KeyStore:      "vault://vault.example.com:8200/secret/kops/iam-builder-test.k8s.local/pki",
+					SecretStore:   "vault://vault.example.com:8200/secret/kops/iam-builder-test.k8s.local/secrets",
+					IAM:           &kops.IAMSpec{},
+					EtcdClusters: []*kops.EtcdClusterSpec{
+						{
+							Members: []*kops.EtcdMemberSpec{
+								{
+									KmsKeyId: aws.String("key-id-1"),
+								},
+								{
+									KmsKeyId: aws.String("key-id-2"),
+								},
+							},
+						},
+						{
+							Members: []*kops.EtcdMemberSpec{},
+						},
+						{
+							Members: []*kops.EtcdMemberSpec{
+								{
+									KmsKeyId: aws.String("key-id-3"),
+								},
+							},
+						},
+					},
+				},
+			},
+			Role: x.Role,
+		}
+		b.Cluster.SetName("iam-builder-test.k8s.local")
+
+		// The stores in vault need no IAM permissions, so the policy is the same as when only the config store is set
+		p, err := b.BuildAWSPolicy()
+		if err != nil {
+			t.Errorf("case %d failed to build an AWS IAM policy. Error: %v", i, err)
+			continue
+		}
+
+		actualPolicy, err := p.AsJSON()
+		if err != nil {
+			t.Errorf("case %d failed to convert generated IAM Policy to JSON. Error: %v", i, err)
+			continue
+		}
+		actualPolicy = strings.TrimSpace(actualPolicy)
+
+		expectedPolicyBytes, err := ioutil.ReadFile(x.Policy)
+		if err != nil {
+			t.Fatalf("unexpected error reading IAM Policy from file %q: %v", x.Policy, err)
+		}
+		expectedPolicy := strings.TrimSpace(string(expectedPolicyBytes))
+
+		if expectedPolicy != actualPolicy {
+			diffString := diff.FormatDiff(expectedPolicy, actualPolicy)
+			t.Logf("diff:\n%s\n", diffString)
+			t.Errorf("case %d failed, policy output differed from expected (%s).", i, x.Policy)
+		}
+	}
+}
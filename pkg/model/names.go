@@ -86,6 +86,16 @@ func (b *KopsModelContext) LinkToELB(prefix string) *awstasks.LoadBalancer {
 	return &awstasks.LoadBalancer{Name: &name}
 }
 
+func (b *KopsModelContext) LinkToNLB(prefix string) *awstasks.NetworkLoadBalancer {
+	name := b.ELBName(prefix)
+	return &awstasks.NetworkLoadBalancer{Name: &name}
+}
+
+func (b *KopsModelContext) LinkToTargetGroup(prefix string) *awstasks.TargetGroup {
+	name := b.ELBName(prefix)
+	return &awstasks.TargetGroup{Name: &name}
+}
+
 func (b *KopsModelContext) LinkToVPC() *awstasks.VPC {
 	name := b.ClusterName()
 	return &awstasks.VPC{Name: &name}
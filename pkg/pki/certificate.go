@@ -110,6 +110,85 @@ func parsePEMCertificate(pemData []byte) (*x509.Certificate, error) {
 	}
 }
 
+// ParsePEMCertificates parses all the certificates in a PEM bundle, such as a list of trusted CAs
+func ParsePEMCertificates(pemData []byte) ([]*Certificate, error) {
+	var certs []*Certificate
+	for {
+		block, rest := pem.Decode(pemData)
+		if block == nil {
+			break
+		}
+
+		if block.Type == "CERTIFICATE" {
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			certs = append(certs, &Certificate{
+				Subject:     cert.Subject,
+				Certificate: cert,
+				PublicKey:   cert.PublicKey,
+				IsCA:        cert.IsCA,
+			})
+		} else {
+			glog.Infof("Ignoring unexpected PEM block: %q", block.Type)
+		}
+
+		pemData = rest
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("could not parse certificate")
+	}
+	return certs, nil
+}
+
+var keyUsageNames = []struct {
+	usage x509.KeyUsage
+	name  string
+}{
+	{x509.KeyUsageDigitalSignature, "DigitalSignature"},
+	{x509.KeyUsageContentCommitment, "ContentCommitment"},
+	{x509.KeyUsageKeyEncipherment, "KeyEncipherment"},
+	{x509.KeyUsageDataEncipherment, "DataEncipherment"},
+	{x509.KeyUsageKeyAgreement, "KeyAgreement"},
+	{x509.KeyUsageCertSign, "CertSign"},
+	{x509.KeyUsageCRLSign, "CRLSign"},
+	{x509.KeyUsageEncipherOnly, "EncipherOnly"},
+	{x509.KeyUsageDecipherOnly, "DecipherOnly"},
+}
+
+var extKeyUsageNames = map[x509.ExtKeyUsage]string{
+	x509.ExtKeyUsageAny:             "Any",
+	x509.ExtKeyUsageServerAuth:      "ServerAuth",
+	x509.ExtKeyUsageClientAuth:      "ClientAuth",
+	x509.ExtKeyUsageCodeSigning:     "CodeSigning",
+	x509.ExtKeyUsageEmailProtection: "EmailProtection",
+	x509.ExtKeyUsageTimeStamping:    "TimeStamping",
+	x509.ExtKeyUsageOCSPSigning:     "OCSPSigning",
+}
+
+// Usages returns the names of the key usages and extended key usages of the certificate
+func (c *Certificate) Usages() []string {
+	if c == nil || c.Certificate == nil {
+		return nil
+	}
+
+	var usages []string
+	for _, u := range keyUsageNames {
+		if c.Certificate.KeyUsage&u.usage != 0 {
+			usages = append(usages, u.name)
+		}
+	}
+	for _, u := range c.Certificate.ExtKeyUsage {
+		name := extKeyUsageNames[u]
+		if name == "" {
+			name = fmt.Sprintf("ExtKeyUsage(%d)", u)
+		}
+		usages = append(usages, name)
+	}
+	return usages
+}
+
 func (c *Certificate) AsString() (string, error) {
 	// Nicer behaviour because this is called from templates
 	if c == nil {
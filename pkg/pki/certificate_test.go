@@ -22,6 +22,7 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
+	"reflect"
 	"testing"
 )
 
@@ -99,3 +100,62 @@ func TestCertificateRoundTrip(t *testing.T) {
 		t.Fatalf("unexpected output from Certificate WriteTo: %q", b.String())
 	}
 }
+
+func TestParsePEMCertificates(t *testing.T) {
+	key, err := GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("error from GeneratePrivateKey: %v", err)
+	}
+
+	var bundle bytes.Buffer
+	for _, name := range []string{"ca-1", "ca-2"} {
+		template := &x509.Certificate{
+			Subject:               pkix.Name{CommonName: name},
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+			BasicConstraintsValid: true,
+			IsCA: true,
+		}
+		cert, err := SignNewCertificate(key, template, nil, nil)
+		if err != nil {
+			t.Fatalf("error from SignNewCertificate: %v", err)
+		}
+		if _, err := cert.WriteTo(&bundle); err != nil {
+			t.Fatalf("error from Certificate WriteTo: %v", err)
+		}
+	}
+
+	certs, err := ParsePEMCertificates(bundle.Bytes())
+	if err != nil {
+		t.Fatalf("error from ParsePEMCertificates: %v", err)
+	}
+	if len(certs) != 2 || certs[0].Subject.CommonName != "ca-1" || certs[1].Subject.CommonName != "ca-2" {
+		t.Fatalf("unexpected certificates from ParsePEMCertificates: %v", certs)
+	}
+
+	if _, err := ParsePEMCertificates([]byte("not a certificate")); err == nil {
+		t.Fatalf("expected error parsing invalid data")
+	}
+}
+
+func TestCertificateUsages(t *testing.T) {
+	key, err := GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("error from GeneratePrivateKey: %v", err)
+	}
+
+	template := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "kubelet"},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+	}
+	cert, err := SignNewCertificate(key, template, nil, nil)
+	if err != nil {
+		t.Fatalf("error from SignNewCertificate: %v", err)
+	}
+
+	expected := []string{"DigitalSignature", "KeyEncipherment", "ClientAuth"}
+	if actual := cert.Usages(); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("unexpected usages, expected %v, got %v", expected, actual)
+	}
+}
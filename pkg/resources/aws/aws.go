@@ -31,6 +31,7 @@ import (
 	"github.com/aws/aws-sdk-go/service/elbv2"
 	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/sqs"
 	"github.com/golang/glog"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/kops/pkg/dns"
@@ -43,10 +44,12 @@ import (
 
 const (
 	TypeAutoscalingLaunchConfig = "autoscaling-config"
+	TypeLaunchTemplate          = "launch-template"
 	TypeNatGateway              = "nat-gateway"
 	TypeElasticIp               = "elastic-ip"
 	TypeLoadBalancer            = "load-balancer"
 	TypeTargetGroup             = "target-group"
+	TypeSQSQueue                = "sqs-queue"
 )
 
 type listFn func(fi.Cloud, string) ([]*resources.Resource, error)
@@ -82,6 +85,8 @@ func ListResourcesAWS(cloud awsup.AWSCloud, clusterName string) (map[string]*res
 		// IAM
 		ListIAMInstanceProfiles,
 		ListIAMRoles,
+		// SQS
+		ListSQSQueues,
 	}
 
 	if featureflag.Spotinst.Enabled() {
@@ -89,7 +94,7 @@ func ListResourcesAWS(cloud awsup.AWSCloud, clusterName string) (map[string]*res
 		listFunctions = append(listFunctions, ListSpotinstElastigroups)
 	} else {
 		// AutoScaling Groups
-		listFunctions = append(listFunctions, ListAutoScalingGroups)
+		listFunctions = append(listFunctions, ListAutoScalingGroups, ListLaunchTemplates)
 	}
 
 	for _, fn := range listFunctions {
@@ -1159,7 +1164,11 @@ func ListAutoScalingGroups(cloud fi.Cloud, clusterName string) ([]*resources.Res
 			}
 			blocks = append(blocks, "subnet:"+subnet)
 		}
-		blocks = append(blocks, TypeAutoscalingLaunchConfig+":"+aws.StringValue(asg.LaunchConfigurationName))
+		if launchTemplate := awsup.AutoscalingGroupLaunchTemplate(asg); launchTemplate != nil {
+			blocks = append(blocks, TypeLaunchTemplate+":"+aws.StringValue(launchTemplate.LaunchTemplateId))
+		} else {
+			blocks = append(blocks, TypeAutoscalingLaunchConfig+":"+aws.StringValue(asg.LaunchConfigurationName))
+		}
 
 		resourceTracker.Blocks = blocks
 
@@ -1169,6 +1178,51 @@ func ListAutoScalingGroups(cloud fi.Cloud, clusterName string) ([]*resources.Res
 	return resourceTrackers, nil
 }
 
+// ListLaunchTemplates finds the launch templates of the cluster, which are named after its autoscaling groups
+func ListLaunchTemplates(cloud fi.Cloud, clusterName string) ([]*resources.Resource, error) {
+	c := cloud.(awsup.AWSCloud)
+
+	glog.V(2).Infof("Finding all LaunchTemplates")
+
+	request := &ec2.DescribeLaunchTemplatesInput{
+		Filters: []*ec2.Filter{
+			// Terraform adds a suffix to the name
+			awsup.NewEC2Filter("launch-template-name", "*."+clusterName, "*."+clusterName+"-*"),
+		},
+	}
+	response, err := c.EC2().DescribeLaunchTemplates(request)
+	if err != nil {
+		return nil, fmt.Errorf("error listing LaunchTemplates: %v", err)
+	}
+
+	var resourceTrackers []*resources.Resource
+	for _, lt := range response.LaunchTemplates {
+		resourceTrackers = append(resourceTrackers, &resources.Resource{
+			Name:    aws.StringValue(lt.LaunchTemplateName),
+			ID:      aws.StringValue(lt.LaunchTemplateId),
+			Type:    TypeLaunchTemplate,
+			Deleter: DeleteLaunchTemplate,
+		})
+	}
+
+	return resourceTrackers, nil
+}
+
+func DeleteLaunchTemplate(cloud fi.Cloud, r *resources.Resource) error {
+	c := cloud.(awsup.AWSCloud)
+
+	id := r.ID
+	glog.V(2).Infof("Deleting LaunchTemplate %q", id)
+	request := &ec2.DeleteLaunchTemplateInput{
+		LaunchTemplateId: &id,
+	}
+	_, err := c.EC2().DeleteLaunchTemplate(request)
+	if err != nil {
+		return fmt.Errorf("error deleting LaunchTemplate %q: %v", id, err)
+	}
+	return nil
+}
+
 func FindAutoScalingLaunchConfigurations(cloud fi.Cloud, securityGroups sets.String) ([]*resources.Resource, error) {
 	c := cloud.(awsup.AWSCloud)
 
@@ -1920,6 +1974,7 @@ func ListIAMRoles(cloud fi.Cloud, clusterName string) ([]*resources.Resource, er
 	remove["masters."+clusterName] = true
 	remove["nodes."+clusterName] = true
 	remove["bastions."+clusterName] = true
+	remove["lifecycle-hooks."+clusterName] = true
 
 	var roles []*iam.Role
 	// Find roles matching remove map
@@ -1955,6 +2010,74 @@ func ListIAMRoles(cloud fi.Cloud, clusterName string) ([]*resources.Resource, er
 	return resourceTrackers, nil
 }
 
+func DeleteSQSQueue(cloud fi.Cloud, r *resources.Resource) error {
+	c := cloud.(awsup.AWSCloud)
+
+	glog.V(2).Infof("Deleting SQS queue %q", r.Name)
+	request := &sqs.DeleteQueueInput{
+		QueueUrl: aws.String(r.ID),
+	}
+	_, err := c.SQS().DeleteQueue(request)
+	if err != nil {
+		if awsup.AWSErrorCode(err) == sqs.ErrCodeQueueDoesNotExist {
+			glog.V(2).Infof("Got NonExistentQueue deleting SQS queue %q; will treat as already-deleted", r.Name)
+			return nil
+		}
+		return fmt.Errorf("error deleting SQS queue %q: %v", r.Name, err)
+	}
+	return nil
+}
+
+// ListSQSQueues finds the lifecycle hook notification queue, matching on the cluster tags
+func ListSQSQueues(cloud fi.Cloud, clusterName string) ([]*resources.Resource, error) {
+	c := cloud.(awsup.AWSCloud)
+	tags := c.Tags()
+
+	glog.V(2).Infof("Listing all SQS queues")
+
+	request := &sqs.ListQueuesInput{
+		QueueNamePrefix: aws.String("lifecycle-hooks-"),
+	}
+	response, err := c.SQS().ListQueues(request)
+	if err != nil {
+		return nil, fmt.Errorf("error listing SQS queues: %v", err)
+	}
+
+	var resourceTrackers []*resources.Resource
+	for _, queueURL := range response.QueueUrls {
+		url := aws.StringValue(queueURL)
+
+		tagResponse, err := c.SQS().ListQueueTags(&sqs.ListQueueTagsInput{QueueUrl: queueURL})
+		if err != nil {
+			if awsup.AWSErrorCode(err) == sqs.ErrCodeQueueDoesNotExist {
+				continue
+			}
+			return nil, fmt.Errorf("error listing tags for SQS queue %q: %v", url, err)
+		}
+
+		match := true
+		for k, v := range tags {
+			if aws.StringValue(tagResponse.Tags[k]) != v {
+				match = false
+				break
+			}
+		}
+		if !match {
+			continue
+		}
+
+		resourceTracker := &resources.Resource{
+			Name:    url[strings.LastIndex(url, "/")+1:],
+			ID:      url,
+			Type:    TypeSQSQueue,
+			Deleter: DeleteSQSQueue,
+		}
+		resourceTrackers = append(resourceTrackers, resourceTracker)
+	}
+
+	return resourceTrackers, nil
+}
+
 func DeleteIAMInstanceProfile(cloud fi.Cloud, r *resources.Resource) error {
 	c := cloud.(awsup.AWSCloud)
 
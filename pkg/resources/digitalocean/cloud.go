@@ -92,12 +92,24 @@ func (c *Cloud) DeleteGroup(g *cloudinstances.CloudInstanceGroup) error {
 	return fmt.Errorf("digital ocean cloud provider does not support deleting cloud groups at this time")
 }
 
+// ResizeGroup is not implemented yet, is a func that needs to resize a DO instance group.
+func (c *Cloud) ResizeGroup(g *cloudinstances.CloudInstanceGroup, size int) error {
+	glog.V(8).Info("digitalocean cloud provider ResizeGroup not implemented yet")
+	return fmt.Errorf("digital ocean cloud provider does not support resizing cloud groups at this time")
+}
+
 // DeleteInstance is not implemented yet, is func needs to delete a DO instance.
 func (c *Cloud) DeleteInstance(i *cloudinstances.CloudInstanceGroupMember) error {
 	glog.V(8).Info("digitalocean cloud provider DeleteInstance not implemented yet")
 	return fmt.Errorf("digital ocean cloud provider does not support deleting cloud instances at this time")
 }
 
+// DeleteInstanceWithoutReplacement is not implemented yet, is func needs to delete a DO instance and shrink its group.
+func (c *Cloud) DeleteInstanceWithoutReplacement(i *cloudinstances.CloudInstanceGroupMember) error {
+	glog.V(8).Info("digitalocean cloud provider DeleteInstanceWithoutReplacement not implemented yet")
+	return fmt.Errorf("digital ocean cloud provider does not support deleting cloud instances at this time")
+}
+
 // ProviderID returns the kops api identifier for DigitalOcean cloud provider
 func (c *Cloud) ProviderID() kops.CloudProviderID {
 	return kops.CloudProviderDO
@@ -139,6 +139,9 @@ func (e *awsElastigroup) MinSize() int { return fi.IntValue(e.obj.Capacity.Minim
 // MaxSize returns the maximum size of the Elastigroup.
 func (e *awsElastigroup) MaxSize() int { return fi.IntValue(e.obj.Capacity.Maximum) }
 
+// TargetSize returns the target size of the Elastigroup.
+func (e *awsElastigroup) TargetSize() int { return fi.IntValue(e.obj.Capacity.Target) }
+
 // Obj returns the raw object which is a cloud-specific implementation.
 func (e *awsElastigroup) Obj() interface{} { return e.obj }
 
@@ -36,6 +36,9 @@ type (
 		// MaxSize returns the maximum size of the Elastigroup.
 		MaxSize() int
 
+		// TargetSize returns the target size of the Elastigroup.
+		TargetSize() int
+
 		// Obj returns the raw object which is a cloud-specific implementation.
 		Obj() interface{}
 	}
@@ -153,6 +153,7 @@ func buildCloudInstanceGroup(svc Service, ig *kops.InstanceGroup, group Elastigr
 		InstanceGroup: ig,
 		MinSize:       group.MinSize(),
 		MaxSize:       group.MaxSize(),
+		TargetSize:    group.TargetSize(),
 		Raw:           group,
 	}
 
@@ -0,0 +1,301 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statehistory
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/kops/pkg/acls"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/util/localuser"
+	"k8s.io/kops/upup/pkg/fi/utils"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+// PathHistory is the directory under the ConfigBase of a cluster that holds its history
+const PathHistory = "history"
+
+// PathHistoryLatest is the directory under the ConfigBase of a cluster that holds the latest revision of each object,
+// without its data, so that recording a write does not read back the whole history
+const PathHistoryLatest = "history-latest"
+
+// Revision is a record of a write of a Cluster or InstanceGroup to the state store.
+// Revisions are numbered from 1, in the order the writes were made, across all the objects of a cluster.
+type Revision struct {
+	// Revision is the number of the revision
+	Revision int `json:"revision"`
+	// Timestamp is when the object was written
+	Timestamp time.Time `json:"timestamp"`
+	// User is the local user that wrote the object
+	User string `json:"user,omitempty"`
+	// Kind is the kind of the object, Cluster or InstanceGroup
+	Kind string `json:"kind"`
+	// Name is the name of the object
+	Name string `json:"name"`
+	// Hash is the sha256 hash of Data
+	Hash string `json:"hash,omitempty"`
+	// Deleted is true if the object was deleted
+	Deleted bool `json:"deleted,omitempty"`
+	// Data is the object as written to the state store
+	Data string `json:"data,omitempty"`
+}
+
+// Key identifies the object of a revision
+func (r *Revision) Key() string {
+	return r.Kind + "/" + r.Name
+}
+
+// Store records the history of the objects of a cluster, in files under its ConfigBase.
+// It works on any vfs.Path, as it does not rely on versioning by the storage.
+type Store struct {
+	cluster   *kops.Cluster
+	basedir   vfs.Path
+	latestdir vfs.Path
+}
+
+// NewStore builds a Store for the cluster with the ConfigBase
+func NewStore(cluster *kops.Cluster, configBase vfs.Path) *Store {
+	return &Store{
+		cluster:   cluster,
+		basedir:   configBase.Join(PathHistory),
+		latestdir: configBase.Join(PathHistoryLatest),
+	}
+}
+
+// revisionFile is the name of the file for a revision: only the zero-padded revision number, so that the files sort
+// in order, and so that two writers that race for the same revision, even of different objects, conflict on the same file.
+// The kind and name of the object are recorded inside the file.
+func revisionFile(revision int) string {
+	return fmt.Sprintf("%06d", revision)
+}
+
+// parseRevisionFile parses the name of a revision file
+func parseRevisionFile(file string) (int, bool) {
+	if file == "" || strings.Trim(file, "0123456789") != "" {
+		return 0, false
+	}
+	revision, err := strconv.Atoi(file)
+	if err != nil {
+		return 0, false
+	}
+	return revision, true
+}
+
+// HashData returns the hash recorded for the data of an object
+func HashData(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+type revisionFileInfo struct {
+	path     vfs.Path
+	revision int
+}
+
+// listFiles returns the revision files, in order
+func (s *Store) listFiles() ([]*revisionFileInfo, error) {
+	files, err := s.basedir.ReadDir()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error listing history in %s: %v", s.basedir, err)
+	}
+
+	var infos []*revisionFileInfo
+	for _, f := range files {
+		revision, ok := parseRevisionFile(f.Base())
+		if !ok {
+			glog.V(2).Infof("ignoring unexpected file in history: %q", f)
+			continue
+		}
+		infos = append(infos, &revisionFileInfo{path: f, revision: revision})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].revision < infos[j].revision
+	})
+	return infos, nil
+}
+
+func (s *Store) readFile(p vfs.Path) (*Revision, error) {
+	b, err := p.ReadFile()
+	if err != nil {
+		return nil, fmt.Errorf("error reading history %s: %v", p, err)
+	}
+	r := &Revision{}
+	if err := utils.YamlUnmarshal(b, r); err != nil {
+		return nil, fmt.Errorf("error parsing history %s: %v", p, err)
+	}
+	return r, nil
+}
+
+// latest returns the latest revision of the object, or nil if it has none; the data of the revision is not included.
+// The copy under PathHistoryLatest may be behind, if a writer stopped before updating it or if writers raced to update it,
+// so the revision files after it are read too.  There are usually none; without a copy, the whole history is read.
+func (s *Store) latest(files []*revisionFileInfo, kind string, name string) (*Revision, error) {
+	var latest *Revision
+
+	p := s.latestdir.Join(kind, name)
+	b, err := p.ReadFile()
+	if err == nil {
+		latest = &Revision{}
+		if err := utils.YamlUnmarshal(b, latest); err != nil {
+			return nil, fmt.Errorf("error parsing history %s: %v", p, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("error reading history %s: %v", p, err)
+	}
+
+	for i := len(files) - 1; i >= 0; i-- {
+		if latest != nil && files[i].revision <= latest.Revision {
+			break
+		}
+		r, err := s.readFile(files[i].path)
+		if err != nil {
+			return nil, err
+		}
+		if r.Kind == kind && r.Name == name {
+			return r, nil
+		}
+	}
+	return latest, nil
+}
+
+// writeLatest records the revision as the latest of its object.  The copy is only used to avoid reading back the history,
+// so failing to write it is not an error.
+func (s *Store) writeLatest(r *Revision) {
+	latest := *r
+	latest.Data = ""
+
+	p := s.latestdir.Join(r.Kind, r.Name)
+	b, err := utils.YamlMarshal(&latest)
+	if err == nil {
+		var acl vfs.ACL
+		acl, err = acls.GetACL(p, s.cluster)
+		if err == nil {
+			err = p.WriteFile(bytes.NewReader(b), acl)
+		}
+	}
+	if err != nil {
+		glog.Warningf("error writing latest revision of %s %q to %s: %v", r.Kind, r.Name, p, err)
+	}
+}
+
+// Record records a write of an object, unless it is unchanged since the latest revision of the object.
+// data is nil if the object was deleted.
+func (s *Store) Record(kind string, name string, data []byte) (*Revision, error) {
+	if kind == "" || name == "" {
+		return nil, fmt.Errorf("invalid object %q %q for history", kind, name)
+	}
+
+	r := &Revision{
+		Timestamp: time.Now().UTC(),
+		User:      localuser.Describe(),
+		Kind:      kind,
+		Name:      name,
+		Deleted:   data == nil,
+	}
+	if data != nil {
+		r.Hash = HashData(data)
+		r.Data = string(data)
+	}
+
+	// If two writers race, one CreateFile fails, and we try again with the next revision.
+	// This relies on CreateFile failing atomically if the file exists, as it does on S3, GCS, Azure Blob Storage and etcd.
+	for attempt := 0; ; attempt++ {
+		files, err := s.listFiles()
+		if err != nil {
+			return nil, err
+		}
+
+		next := 1
+		if len(files) != 0 {
+			next = files[len(files)-1].revision + 1
+		}
+
+		latest, err := s.latest(files, kind, name)
+		if err != nil {
+			return nil, err
+		}
+		if latest != nil && latest.Deleted == r.Deleted && latest.Hash == r.Hash {
+			glog.V(2).Infof("%s %q unchanged since revision %d, not recording history", kind, name, latest.Revision)
+			return nil, nil
+		}
+
+		r.Revision = next
+		b, err := utils.YamlMarshal(r)
+		if err != nil {
+			return nil, fmt.Errorf("error serializing history: %v", err)
+		}
+
+		p := s.basedir.Join(revisionFile(next))
+		acl, err := acls.GetACL(p, s.cluster)
+		if err != nil {
+			return nil, err
+		}
+		err = p.CreateFile(bytes.NewReader(b), acl)
+		if err == nil {
+			s.writeLatest(r)
+			return r, nil
+		}
+		if !os.IsExist(err) || attempt >= 5 {
+			return nil, fmt.Errorf("error writing history %s: %v", p, err)
+		}
+	}
+}
+
+// List returns all the revisions, in order
+func (s *Store) List() ([]*Revision, error) {
+	files, err := s.listFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var revisions []*Revision
+	for _, f := range files {
+		r, err := s.readFile(f.path)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, r)
+	}
+	return revisions, nil
+}
+
+// StateAt returns the latest revision of each object, as of the revision, keyed by Revision.Key.
+// Objects that had been deleted are included, with Deleted set.
+func StateAt(revisions []*Revision, revision int) map[string]*Revision {
+	state := make(map[string]*Revision)
+	for _, r := range revisions {
+		if r.Revision > revision {
+			continue
+		}
+		if existing := state[r.Key()]; existing == nil || existing.Revision < r.Revision {
+			state[r.Key()] = r
+		}
+	}
+	return state
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statehistory
+
+import (
+	"bytes"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+func TestRecordAndStateAt(t *testing.T) {
+	configBase := vfs.NewMemFSPath(vfs.NewMemFSContext(), "memfs://tests/cluster.example.com")
+	store := NewStore(&kops.Cluster{}, configBase)
+
+	record := func(kind string, name string, data []byte, expectRevision int) {
+		r, err := store.Record(kind, name, data)
+		if err != nil {
+			t.Fatalf("error recording %s/%s: %v", kind, name, err)
+		}
+		if expectRevision == 0 {
+			if r != nil {
+				t.Fatalf("expected unchanged %s/%s not to be recorded, got revision %d", kind, name, r.Revision)
+			}
+			return
+		}
+		if r == nil || r.Revision != expectRevision {
+			t.Fatalf("expected %s/%s to be recorded as revision %d, got %v", kind, name, expectRevision, r)
+		}
+	}
+
+	record("Cluster", "cluster.example.com", []byte("cluster-v1"), 1)
+	record("InstanceGroup", "nodes", []byte("nodes-v1"), 2)
+	record("InstanceGroup", "nodes", []byte("nodes-v1"), 0)
+	record("Cluster", "cluster.example.com", []byte("cluster-v2"), 3)
+	record("InstanceGroup", "nodes", nil, 4)
+	record("InstanceGroup", "nodes", nil, 0)
+	record("InstanceGroup", "nodes-2", []byte("nodes-2-v1"), 5)
+
+	revisions, err := store.List()
+	if err != nil {
+		t.Fatalf("error listing history: %v", err)
+	}
+	if len(revisions) != 5 {
+		t.Fatalf("expected 5 revisions, got %d", len(revisions))
+	}
+	for i, r := range revisions {
+		if r.Revision != i+1 {
+			t.Fatalf("unexpected revision order: %d at %d", r.Revision, i)
+		}
+	}
+	if revisions[0].Hash != HashData([]byte("cluster-v1")) || revisions[0].Data != "cluster-v1" {
+		t.Fatalf("unexpected revision 1: %v", revisions[0])
+	}
+
+	state := StateAt(revisions, 2)
+	if len(state) != 2 {
+		t.Fatalf("expected 2 objects at revision 2, got %v", state)
+	}
+	if state["Cluster/cluster.example.com"].Data != "cluster-v1" || state["InstanceGroup/nodes"].Data != "nodes-v1" {
+		t.Fatalf("unexpected state at revision 2: %v", state)
+	}
+
+	state = StateAt(revisions, 5)
+	if state["Cluster/cluster.example.com"].Data != "cluster-v2" {
+		t.Fatalf("unexpected cluster at revision 5: %v", state["Cluster/cluster.example.com"])
+	}
+	if !state["InstanceGroup/nodes"].Deleted {
+		t.Fatalf("expected nodes to be deleted at revision 5")
+	}
+	if state["InstanceGroup/nodes-2"].Data != "nodes-2-v1" {
+		t.Fatalf("unexpected nodes-2 at revision 5: %v", state["InstanceGroup/nodes-2"])
+	}
+}
+
+func TestParseRevisionFile(t *testing.T) {
+	revision, ok := parseRevisionFile(revisionFile(12))
+	if !ok || revision != 12 {
+		t.Fatalf("unexpected parse: %d %v", revision, ok)
+	}
+
+	for _, file := range []string{"README", "000012-InstanceGroup-nodes", ""} {
+		if _, ok := parseRevisionFile(file); ok {
+			t.Fatalf("expected unexpected file %q not to parse", file)
+		}
+	}
+}
+
+func TestRecordConflictsOnRevision(t *testing.T) {
+	configBase := vfs.NewMemFSPath(vfs.NewMemFSContext(), "memfs://tests/cluster.example.com")
+	store := NewStore(&kops.Cluster{}, configBase)
+
+	if _, err := store.Record("Cluster", "cluster.example.com", []byte("cluster-v1")); err != nil {
+		t.Fatalf("error recording cluster: %v", err)
+	}
+
+	// Another writer has reserved revision 2 for a different object; the file for the revision is the same,
+	// so the writers cannot both record revision 2
+	if err := configBase.Join(PathHistory, revisionFile(2)).CreateFile(bytes.NewReader([]byte("revision: 2\nkind: InstanceGroup\nname: nodes\n")), nil); err != nil {
+		t.Fatalf("error creating revision 2: %v", err)
+	}
+	if err := configBase.Join(PathHistory, revisionFile(2)).CreateFile(bytes.NewReader(nil), nil); !os.IsExist(err) {
+		t.Fatalf("expected creating revision 2 twice to conflict, got %v", err)
+	}
+
+	r, err := store.Record("InstanceGroup", "masters", []byte("masters-v1"))
+	if err != nil {
+		t.Fatalf("error recording instance group: %v", err)
+	}
+	if r.Revision != 3 {
+		t.Fatalf("expected revision 3, got %d", r.Revision)
+	}
+
+	files, err := configBase.Join(PathHistory).ReadDir()
+	if err != nil {
+		t.Fatalf("error listing history: %v", err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Base())
+	}
+	sort.Strings(names)
+	if strings.Join(names, ",") != "000001,000002,000003" {
+		t.Fatalf("unexpected history files: %v", names)
+	}
+}
+
+func TestRecordReadsOnlyRevisionsAfterLatest(t *testing.T) {
+	configBase := vfs.NewMemFSPath(vfs.NewMemFSContext(), "memfs://tests/cluster.example.com")
+	store := NewStore(&kops.Cluster{}, configBase)
+
+	if _, err := store.Record("Cluster", "cluster.example.com", []byte("cluster-v1")); err != nil {
+		t.Fatalf("error recording cluster: %v", err)
+	}
+	if _, err := store.Record("InstanceGroup", "nodes", []byte("nodes-v1")); err != nil {
+		t.Fatalf("error recording instance group: %v", err)
+	}
+
+	// Revision 1 is not read again, as the latest revision of the cluster is recorded separately
+	if err := configBase.Join(PathHistory, revisionFile(1)).WriteFile(bytes.NewReader([]byte("{not yaml")), nil); err != nil {
+		t.Fatalf("error overwriting revision 1: %v", err)
+	}
+	r, err := store.Record("Cluster", "cluster.example.com", []byte("cluster-v1"))
+	if err != nil {
+		t.Fatalf("error recording unchanged cluster: %v", err)
+	}
+	if r != nil {
+		t.Fatalf("expected unchanged cluster not to be recorded, got revision %d", r.Revision)
+	}
+
+	// A writer that stopped before updating the latest revision of the object leaves it behind; the revisions after it are read
+	if err := configBase.Join(PathHistory, revisionFile(3)).CreateFile(bytes.NewReader([]byte("revision: 3\nkind: Cluster\nname: cluster.example.com\nhash: "+HashData([]byte("cluster-v2"))+"\n")), nil); err != nil {
+		t.Fatalf("error creating revision 3: %v", err)
+	}
+	r, err = store.Record("Cluster", "cluster.example.com", []byte("cluster-v2"))
+	if err != nil {
+		t.Fatalf("error recording cluster: %v", err)
+	}
+	if r != nil {
+		t.Fatalf("expected cluster unchanged since revision 3 not to be recorded, got revision %d", r.Revision)
+	}
+
+	r, err = store.Record("Cluster", "cluster.example.com", []byte("cluster-v1"))
+	if err != nil {
+		t.Fatalf("error recording cluster: %v", err)
+	}
+	if r == nil || r.Revision != 4 {
+		t.Fatalf("expected cluster to be recorded as revision 4, got %v", r)
+	}
+}
@@ -0,0 +1,378 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statelock
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/kops/pkg/acls"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/util/localuser"
+	"k8s.io/kops/upup/pkg/fi/utils"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+// PathLock is the file under the ConfigBase of a cluster that holds its lock
+const PathLock = "lock"
+
+// DefaultTTL is how long a lock is held without being renewed; a lock is renewed while it is held,
+// so it only expires if the process holding it has gone away
+const DefaultTTL = 10 * time.Minute
+
+// LockRecord is the lock document we store in the state store
+type LockRecord struct {
+	// ID identifies the holder of the lock, so that it only renews and releases its own lock
+	ID string `json:"id"`
+	// Owner is the user and host that holds the lock
+	Owner string `json:"owner"`
+	// Operation is what the lock is held for, e.g. update cluster
+	Operation string `json:"operation"`
+	// AcquiredAt is when the lock was acquired
+	AcquiredAt time.Time `json:"acquiredAt"`
+	// ExpiresAt is when the lock expires, unless it is renewed
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// LockedError is returned when the cluster is locked by someone else
+type LockedError struct {
+	ClusterName string
+	Record      *LockRecord
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("cluster %q is locked by %s for %q since %s (the lock expires at %s unless renewed)",
+		e.ClusterName, e.Record.Owner, e.Record.Operation, e.Record.AcquiredAt.Format(time.RFC3339), e.Record.ExpiresAt.Format(time.RFC3339))
+}
+
+// IsLocked returns true if the error is a LockedError
+func IsLocked(err error) bool {
+	_, ok := err.(*LockedError)
+	return ok
+}
+
+// Lock is an advisory lock on a cluster, held while it is being changed.
+// The lock is a file under the ConfigBase; it is created with CreateFile, so it is only as exclusive as the
+// CreateFile of the state store, and it is renewed in the background until it is released.
+// Where the state store supports conditional writes, renewing and taking over an expired lock only
+// succeed if nobody else has written the lock since it was read.
+type Lock struct {
+	cluster *kops.Cluster
+	path    vfs.Path
+	ttl     time.Duration
+
+	mutex  sync.Mutex
+	record *LockRecord
+	stop   chan struct{}
+	done   chan struct{}
+
+	// lost is closed if the lock is lost while it is held, and lostErr records why
+	lost    chan struct{}
+	lostErr error
+}
+
+// lockPath returns the path of the lock for the cluster with the ConfigBase
+func lockPath(configBase vfs.Path) vfs.Path {
+	return configBase.Join(PathLock)
+}
+
+func newLockID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating lock id: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Read returns the lock on the cluster, or nil if it is not locked
+func Read(configBase vfs.Path) (*LockRecord, error) {
+	record, _, err := readRecord(lockPath(configBase))
+	return record, err
+}
+
+// readRecord returns the lock at the path, or nil if there is none, along with its version.
+// The version is empty if the state store does not support conditional writes.
+func readRecord(p vfs.Path) (*LockRecord, string, error) {
+	var data []byte
+	var version string
+	var err error
+	if conditional, ok := p.(vfs.HasConditionalWrite); ok {
+		data, version, err = conditional.ReadFileVersion()
+	} else {
+		data, err = p.ReadFile()
+	}
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, "", nil
+		}
+		return nil, "", fmt.Errorf("error reading lock %s: %v", p, err)
+	}
+
+	record := &LockRecord{}
+	if err := utils.YamlUnmarshal(data, record); err != nil {
+		return nil, "", fmt.Errorf("error parsing lock %s: %v", p, err)
+	}
+	return record, version, nil
+}
+
+// ForceUnlock removes the lock on the cluster, whoever holds it
+func ForceUnlock(configBase vfs.Path) error {
+	p := lockPath(configBase)
+	if err := p.Remove(); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error removing lock %s: %v", p, err)
+	}
+	return nil
+}
+
+// Acquire locks the cluster for the operation, failing with a LockedError if someone else holds the lock.
+// An expired lock is taken over. The lock is renewed every ttl/3 until it is released.
+func Acquire(cluster *kops.Cluster, configBase vfs.Path, operation string, ttl time.Duration) (*Lock, error) {
+	id, err := newLockID()
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Lock{
+		cluster: cluster,
+		path:    lockPath(configBase),
+		ttl:     ttl,
+	}
+
+	now := time.Now().UTC()
+	record := &LockRecord{
+		ID:         id,
+		Owner:      localuser.Describe(),
+		Operation:  operation,
+		AcquiredAt: now,
+		ExpiresAt:  now.Add(ttl),
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := l.write(record, "", true)
+		if err == nil {
+			break
+		}
+		if !os.IsExist(err) || attempt >= 3 {
+			return nil, err
+		}
+
+		existing, version, err := readRecord(l.path)
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil {
+			// Released while we were looking
+			continue
+		}
+		if time.Now().Before(existing.ExpiresAt) {
+			return nil, &LockedError{ClusterName: cluster.ObjectMeta.Name, Record: existing}
+		}
+
+		glog.Warningf("taking over expired lock held by %s for %q since %s", existing.Owner, existing.Operation, existing.AcquiredAt.Format(time.RFC3339))
+		if version == "" {
+			// Without conditional writes, another command taking over the lock at the same time may remove ours
+			if err := ForceUnlock(configBase); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		err = l.write(record, version, false)
+		if err == nil {
+			break
+		}
+		if err != vfs.ErrVersionConflict {
+			return nil, err
+		}
+		// The lock was renewed, released or taken over since we read it, so we look again
+	}
+
+	glog.V(2).Infof("acquired lock %s for %q", l.path, operation)
+
+	l.record = record
+	l.stop = make(chan struct{})
+	l.done = make(chan struct{})
+	l.lost = make(chan struct{})
+	go l.renewLoop()
+
+	return l, nil
+}
+
+// write writes the lock record: with CreateFile if create is set, otherwise only if the lock is still at
+// version, or unconditionally if version is empty.  It returns os.ErrExist and vfs.ErrVersionConflict unwrapped.
+func (l *Lock) write(record *LockRecord, version string, create bool) error {
+	data, err := utils.YamlMarshal(record)
+	if err != nil {
+		return fmt.Errorf("error serializing lock: %v", err)
+	}
+
+	acl, err := acls.GetACL(l.path, l.cluster)
+	if err != nil {
+		return err
+	}
+
+	if create {
+		err = l.path.CreateFile(bytes.NewReader(data), acl)
+		if os.IsExist(err) {
+			return err
+		}
+	} else if version != "" {
+		conditional, ok := l.path.(vfs.HasConditionalWrite)
+		if !ok {
+			return fmt.Errorf("lock %s does not support conditional writes", l.path)
+		}
+		err = conditional.WriteFileIfVersion(bytes.NewReader(data), acl, version)
+		if err == vfs.ErrVersionConflict {
+			return err
+		}
+	} else {
+		err = l.path.WriteFile(bytes.NewReader(data), acl)
+	}
+	if err != nil {
+		return fmt.Errorf("error writing lock %s: %v", l.path, err)
+	}
+	return nil
+}
+
+// owned returns true if the lock in the state store is still ours, along with its version
+func (l *Lock) owned() (bool, string, error) {
+	record, version, err := readRecord(l.path)
+	if err != nil {
+		return false, "", err
+	}
+	return record != nil && record.ID == l.record.ID, version, nil
+}
+
+// errNotHeld is returned by Renew when the lock has been removed or taken over
+type errNotHeld struct {
+	path vfs.Path
+}
+
+func (e *errNotHeld) Error() string {
+	return fmt.Sprintf("lock %s is no longer held; it was removed or taken over", e.path)
+}
+
+// Renew extends the expiry of the lock
+func (l *Lock) Renew() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	owned, version, err := l.owned()
+	if err != nil {
+		return err
+	}
+	if !owned {
+		return &errNotHeld{path: l.path}
+	}
+
+	l.record.ExpiresAt = time.Now().UTC().Add(l.ttl)
+	err = l.write(l.record, version, false)
+	if err == vfs.ErrVersionConflict {
+		// Removed or taken over since we read it
+		return &errNotHeld{path: l.path}
+	}
+	return err
+}
+
+// Lost returns a channel that is closed if the lock is lost while it is held: because it was removed or taken over,
+// or because it could not be renewed before it expired.  Once the lock is lost, it is no longer renewed.
+func (l *Lock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Err returns why the lock was lost, or nil if it is still held
+func (l *Lock) Err() error {
+	select {
+	case <-l.lost:
+		return l.lostErr
+	default:
+		return nil
+	}
+}
+
+func (l *Lock) renewLoop() {
+	defer close(l.done)
+
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			err := l.Renew()
+			if err == nil {
+				continue
+			}
+
+			_, notHeld := err.(*errNotHeld)
+			if !notHeld && time.Now().Before(l.expiresAt()) {
+				glog.Warningf("error renewing lock, will retry before it expires at %s: %v", l.expiresAt().Format(time.RFC3339), err)
+				continue
+			}
+
+			// Someone else may now change the cluster while we are still changing it
+			glog.Errorf("LOST the lock on cluster %q: %v", l.cluster.ObjectMeta.Name, err)
+			glog.Errorf("Another kops command may now change the cluster at the same time as this one; this command will stop as soon as it can")
+			l.lostErr = fmt.Errorf("lost the lock on cluster %q: %v", l.cluster.ObjectMeta.Name, err)
+			close(l.lost)
+			return
+		}
+	}
+}
+
+// expiresAt returns when the lock expires, unless it is renewed
+func (l *Lock) expiresAt() time.Time {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.record.ExpiresAt
+}
+
+// Release stops renewing the lock and removes it, unless it has been taken over.
+// It returns an error if the lock was lost while it was held.
+func (l *Lock) Release() error {
+	close(l.stop)
+	<-l.done
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if err := l.Err(); err != nil {
+		return err
+	}
+
+	owned, _, err := l.owned()
+	if err != nil {
+		return err
+	}
+	if !owned {
+		return fmt.Errorf("lock %s was lost before it was released; it was removed or taken over", l.path)
+	}
+	if err := l.path.Remove(); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error removing lock %s: %v", l.path, err)
+	}
+	glog.V(2).Infof("released lock %s", l.path)
+	return nil
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statelock
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/upup/pkg/fi/utils"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+func TestAcquireRelease(t *testing.T) {
+	configBase := vfs.NewMemFSPath(vfs.NewMemFSContext(), "memfs://tests/cluster.example.com")
+	cluster := &kops.Cluster{}
+	cluster.ObjectMeta.Name = "cluster.example.com"
+
+	lock, err := Acquire(cluster, configBase, "update cluster", time.Hour)
+	if err != nil {
+		t.Fatalf("error acquiring lock: %v", err)
+	}
+
+	record, err := Read(configBase)
+	if err != nil {
+		t.Fatalf("error reading lock: %v", err)
+	}
+	if record == nil || record.Operation != "update cluster" || record.Owner == "" {
+		t.Fatalf("unexpected lock record: %v", record)
+	}
+
+	if _, err := Acquire(cluster, configBase, "rolling-update cluster", time.Hour); !IsLocked(err) {
+		t.Fatalf("expected locked error acquiring held lock, got %v", err)
+	}
+
+	if err := lock.Renew(); err != nil {
+		t.Fatalf("error renewing lock: %v", err)
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("error releasing lock: %v", err)
+	}
+	if record, err := Read(configBase); err != nil || record != nil {
+		t.Fatalf("expected lock to be removed, got %v %v", record, err)
+	}
+
+	lock, err = Acquire(cluster, configBase, "rolling-update cluster", time.Hour)
+	if err != nil {
+		t.Fatalf("error acquiring released lock: %v", err)
+	}
+
+	// A forced unlock means the holder no longer owns the lock
+	if err := ForceUnlock(configBase); err != nil {
+		t.Fatalf("error forcing unlock: %v", err)
+	}
+	if err := lock.Renew(); err == nil {
+		t.Fatalf("expected error renewing lock that was removed")
+	}
+	if err := lock.Release(); err == nil {
+		t.Fatalf("expected error releasing lock that was removed")
+	}
+	if record, err := Read(configBase); err != nil || record != nil {
+		t.Fatalf("expected removed lock not to be recreated, got %v %v", record, err)
+	}
+}
+
+func TestLockLost(t *testing.T) {
+	configBase := vfs.NewMemFSPath(vfs.NewMemFSContext(), "memfs://tests/cluster.example.com")
+	cluster := &kops.Cluster{}
+	cluster.ObjectMeta.Name = "cluster.example.com"
+
+	lock, err := Acquire(cluster, configBase, "rolling-update cluster", 30*time.Millisecond)
+	if err != nil {
+		t.Fatalf("error acquiring lock: %v", err)
+	}
+	if err := lock.Err(); err != nil {
+		t.Fatalf("unexpected error from held lock: %v", err)
+	}
+
+	// Someone else takes over the lock
+	if err := ForceUnlock(configBase); err != nil {
+		t.Fatalf("error forcing unlock: %v", err)
+	}
+	other, err := Acquire(cluster, configBase, "update cluster", time.Hour)
+	if err != nil {
+		t.Fatalf("error acquiring lock: %v", err)
+	}
+	defer other.Release()
+
+	select {
+	case <-lock.Lost():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected lock to be reported lost")
+	}
+	if err := lock.Err(); err == nil {
+		t.Fatalf("expected error from lost lock")
+	}
+
+	if err := lock.Release(); err == nil {
+		t.Fatalf("expected error releasing lost lock")
+	}
+	record, err := Read(configBase)
+	if err != nil {
+		t.Fatalf("error reading lock: %v", err)
+	}
+	if record == nil || record.ID != other.record.ID {
+		t.Fatalf("expected lock taken over to be kept, got %v", record)
+	}
+}
+
+func TestAcquireExpired(t *testing.T) {
+	configBase := vfs.NewMemFSPath(vfs.NewMemFSContext(), "memfs://tests/cluster.example.com")
+	cluster := &kops.Cluster{}
+	cluster.ObjectMeta.Name = "cluster.example.com"
+
+	expired := &LockRecord{
+		ID:         "abandoned",
+		Owner:      "someone@somewhere",
+		Operation:  "update cluster",
+		AcquiredAt: time.Now().Add(-2 * time.Hour),
+		ExpiresAt:  time.Now().Add(-time.Hour),
+	}
+	data, err := utils.YamlMarshal(expired)
+	if err != nil {
+		t.Fatalf("error serializing lock: %v", err)
+	}
+	if err := configBase.Join(PathLock).WriteFile(bytes.NewReader(data), nil); err != nil {
+		t.Fatalf("error writing lock: %v", err)
+	}
+
+	lock, err := Acquire(cluster, configBase, "update cluster", time.Hour)
+	if err != nil {
+		t.Fatalf("error taking over expired lock: %v", err)
+	}
+	defer lock.Release()
+
+	record, err := Read(configBase)
+	if err != nil {
+		t.Fatalf("error reading lock: %v", err)
+	}
+	if record.ID == "abandoned" {
+		t.Fatalf("expected expired lock to be replaced")
+	}
+}
+
+// racingPath is a memfs path where another command writes the lock just before we write it conditionally
+type racingPath struct {
+	*vfs.MemFSPath
+	race func()
+}
+
+func (p *racingPath) Join(relativePath ...string) vfs.Path {
+	return &racingPath{MemFSPath: p.MemFSPath.Join(relativePath...).(*vfs.MemFSPath), race: p.race}
+}
+
+func (p *racingPath) WriteFileIfVersion(data io.ReadSeeker, acl vfs.ACL, version string) error {
+	if p.race != nil {
+		p.race()
+	}
+	return p.MemFSPath.WriteFileIfVersion(data, acl, version)
+}
+
+func writeLockRecord(t *testing.T, p vfs.Path, record *LockRecord) {
+	data, err := utils.YamlMarshal(record)
+	if err != nil {
+		t.Fatalf("error serializing lock: %v", err)
+	}
+	if err := p.WriteFile(bytes.NewReader(data), nil); err != nil {
+		t.Fatalf("error writing lock: %v", err)
+	}
+}
+
+func TestAcquireExpiredTakenOverMeanwhile(t *testing.T) {
+	memfsBase := vfs.NewMemFSPath(vfs.NewMemFSContext(), "memfs://tests/cluster.example.com")
+	cluster := &kops.Cluster{}
+	cluster.ObjectMeta.Name = "cluster.example.com"
+
+	writeLockRecord(t, memfsBase.Join(PathLock), &LockRecord{
+		ID:         "abandoned",
+		Owner:      "someone@somewhere",
+		Operation:  "update cluster",
+		AcquiredAt: time.Now().Add(-2 * time.Hour),
+		ExpiresAt:  time.Now().Add(-time.Hour),
+	})
+
+	configBase := &racingPath{
+		MemFSPath: memfsBase,
+		race: func() {
+			writeLockRecord(t, memfsBase.Join(PathLock), &LockRecord{
+				ID:         "other",
+				Owner:      "someone@elsewhere",
+				Operation:  "rolling-update cluster",
+				AcquiredAt: time.Now(),
+				ExpiresAt:  time.Now().Add(time.Hour),
+			})
+		},
+	}
+
+	_, err := Acquire(cluster, configBase, "update cluster", time.Hour)
+	if !IsLocked(err) {
+		t.Fatalf("expected the lock taken over by the other command to be reported, got %v", err)
+	}
+
+	record, err := Read(memfsBase)
+	if err != nil {
+		t.Fatalf("error reading lock: %v", err)
+	}
+	if record == nil || record.ID != "other" {
+		t.Fatalf("expected the lock of the other command to be kept, got %v", record)
+	}
+}
@@ -0,0 +1,284 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statemigrate
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/golang/glog"
+	"k8s.io/kops/pkg/acls"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/apis/kops/registry"
+	"k8s.io/kops/pkg/kopscodecs"
+	"k8s.io/kops/pkg/statehistory"
+	"k8s.io/kops/pkg/statelock"
+	"k8s.io/kops/upup/pkg/fi/utils"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+// Migration copies the state of a cluster from one state store to another.
+// Every file under the ConfigBase is copied - the cluster config, instance groups, keysets, secrets, SSH keys,
+// addon manifests and history - and the ConfigBase, KeyStore and SecretStore in the spec are rewritten to the
+// new location, including in every revision of the cluster in the history, so that rolling back to an earlier
+// revision does not point the cluster back at the old state store.
+type Migration struct {
+	ClusterName string
+	// From is the state store the cluster is copied from
+	From vfs.Path
+	// To is the state store the cluster is copied to
+	To vfs.Path
+}
+
+// File is a file to be copied by the migration
+type File struct {
+	// RelativePath is the path of the file relative to the ConfigBase
+	RelativePath string
+	Source       vfs.Path
+	Dest         vfs.Path
+	// Data is what will be written to Dest
+	Data []byte
+	// Rewritten is true if Data is the source file with the store locations rewritten
+	Rewritten bool
+}
+
+// Plan reads the files to be copied, and rewrites the cluster spec for the new location.
+// It does not write anything, so is also used for a dry run.
+func (m *Migration) Plan() ([]*File, *kops.Cluster, error) {
+	src := m.From.Join(m.ClusterName)
+	dest := m.To.Join(m.ClusterName)
+
+	if src.Path() == dest.Path() {
+		return nil, nil, fmt.Errorf("source and destination are the same: %s", src)
+	}
+
+	if _, err := dest.Join(registry.PathCluster).ReadFile(); err == nil {
+		return nil, nil, fmt.Errorf("cluster %q already exists in %s", m.ClusterName, m.To)
+	} else if !os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("error checking for cluster in %s: %v", m.To, err)
+	}
+
+	srcFiles, err := src.ReadTree()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading %s: %v", src, err)
+	}
+
+	var cluster *kops.Cluster
+	var files []*File
+	for _, srcFile := range srcFiles {
+		relativePath, err := vfs.RelativePath(src, srcFile)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// The lock belongs to whoever is changing the cluster in the old state store
+		if relativePath == statelock.PathLock {
+			continue
+		}
+		// The latest revisions of the objects have the hashes from before the history is rewritten; they are
+		// recorded again by the next write, which reads the rewritten history instead
+		if strings.HasPrefix(relativePath, statehistory.PathHistoryLatest+"/") {
+			continue
+		}
+
+		data, err := srcFile.ReadFile()
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading %s: %v", srcFile, err)
+		}
+
+		f := &File{
+			RelativePath: relativePath,
+			Source:       srcFile,
+			Dest:         dest.Join(relativePath),
+			Data:         data,
+		}
+
+		switch {
+		case relativePath == registry.PathCluster:
+			cluster, f.Data, err = m.rewriteConfig(data)
+			f.Rewritten = true
+		case relativePath == registry.PathClusterCompleted:
+			f.Data, err = m.rewriteCompletedConfig(data)
+			f.Rewritten = true
+		case path.Dir(relativePath) == statehistory.PathHistory:
+			f.Data, f.Rewritten, err = m.rewriteRevision(data)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("error rewriting %s: %v", srcFile, err)
+		}
+
+		files = append(files, f)
+	}
+
+	if cluster == nil {
+		return nil, nil, fmt.Errorf("cluster %q not found in %s", m.ClusterName, m.From)
+	}
+
+	// The config is written last, so the cluster only appears in the new state store once everything else is there
+	sort.SliceStable(files, func(i, j int) bool {
+		iConfig := files[i].RelativePath == registry.PathCluster
+		jConfig := files[j].RelativePath == registry.PathCluster
+		if iConfig != jConfig {
+			return jConfig
+		}
+		return files[i].RelativePath < files[j].RelativePath
+	})
+
+	return files, cluster, nil
+}
+
+// Apply writes the planned files, and verifies that the hash of each written file matches what was read.
+// cluster is the rewritten cluster returned by Plan, which is used to choose the ACLs.
+func (m *Migration) Apply(files []*File, cluster *kops.Cluster) error {
+	for _, f := range files {
+		acl, err := acls.GetACL(f.Dest, cluster)
+		if err != nil {
+			return err
+		}
+
+		glog.V(2).Infof("Copying %s to %s", f.Source, f.Dest)
+		if err := f.Dest.WriteFile(bytes.NewReader(f.Data), acl); err != nil {
+			return fmt.Errorf("error writing %s: %v", f.Dest, err)
+		}
+
+		if err := verifyFile(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyFile checks that the file in the destination has the contents we wrote.
+// We use the hash reported by the destination where we can, otherwise we read the file back.
+func verifyFile(f *File) error {
+	if hasHash, ok := f.Dest.(vfs.HasHash); ok {
+		destHash, err := hasHash.PreferredHash()
+		if err != nil {
+			return fmt.Errorf("error getting hash of %s: %v", f.Dest, err)
+		}
+		if destHash != nil {
+			expected, err := destHash.Algorithm.Hash(bytes.NewReader(f.Data))
+			if err != nil {
+				return fmt.Errorf("error hashing %s: %v", f.Source, err)
+			}
+			if !destHash.Equal(expected) {
+				return fmt.Errorf("hash of %s was %s after copying, expected %s", f.Dest, destHash, expected)
+			}
+			return nil
+		}
+	}
+
+	data, err := f.Dest.ReadFile()
+	if err != nil {
+		return fmt.Errorf("error reading back %s: %v", f.Dest, err)
+	}
+	if !bytes.Equal(data, f.Data) {
+		return fmt.Errorf("contents of %s did not match after copying", f.Dest)
+	}
+	return nil
+}
+
+// rewriteConfig rewrites the user-specified cluster config
+func (m *Migration) rewriteConfig(data []byte) (*kops.Cluster, []byte, error) {
+	obj, _, err := kopscodecs.ParseVersionedYaml(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing cluster: %v", err)
+	}
+	cluster, ok := obj.(*kops.Cluster)
+	if !ok {
+		return nil, nil, fmt.Errorf("unexpected object type: %T", obj)
+	}
+
+	m.rewriteSpec(&cluster.Spec)
+
+	b, err := kopscodecs.ToVersionedYaml(cluster)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error serializing cluster: %v", err)
+	}
+	return cluster, b, nil
+}
+
+// rewriteCompletedConfig rewrites the completed cluster spec, which is written by update cluster as unversioned yaml
+func (m *Migration) rewriteCompletedConfig(data []byte) ([]byte, error) {
+	cluster := &kops.Cluster{}
+	if err := utils.YamlUnmarshal(data, cluster); err != nil {
+		return nil, fmt.Errorf("error parsing completed cluster spec: %v", err)
+	}
+
+	m.rewriteSpec(&cluster.Spec)
+
+	return utils.YamlMarshal(cluster)
+}
+
+// rewriteRevision rewrites a revision of the cluster in the history, returning false if the revision was not rewritten.
+// Revisions of instance groups, and deletions, do not record any store locations, so are copied unchanged.
+func (m *Migration) rewriteRevision(data []byte) ([]byte, bool, error) {
+	r := &statehistory.Revision{}
+	if err := utils.YamlUnmarshal(data, r); err != nil {
+		return nil, false, fmt.Errorf("error parsing history: %v", err)
+	}
+	if r.Kind != "Cluster" || r.Deleted || r.Data == "" {
+		return data, false, nil
+	}
+
+	_, clusterData, err := m.rewriteConfig([]byte(r.Data))
+	if err != nil {
+		return nil, false, fmt.Errorf("error rewriting revision %d: %v", r.Revision, err)
+	}
+	r.Data = string(clusterData)
+	r.Hash = statehistory.HashData(clusterData)
+
+	b, err := utils.YamlMarshal(r)
+	if err != nil {
+		return nil, false, fmt.Errorf("error serializing history: %v", err)
+	}
+	return b, true, nil
+}
+
+// rewriteSpec points the ConfigBase at the new state store, along with the KeyStore and SecretStore if they
+// were under the old ConfigBase.  Stores elsewhere are not copied, so are left alone.
+func (m *Migration) rewriteSpec(spec *kops.ClusterSpec) {
+	oldBase := m.From.Join(m.ClusterName).Path()
+	newBase := m.To.Join(m.ClusterName).Path()
+
+	if spec.ConfigBase != "" && spec.ConfigBase != oldBase {
+		glog.Warningf("ConfigBase %q was not the location of the cluster in the state store (%s)", spec.ConfigBase, oldBase)
+	}
+	spec.ConfigBase = newBase
+
+	spec.KeyStore = rewriteStorePath(spec.KeyStore, oldBase, newBase, "KeyStore")
+	spec.SecretStore = rewriteStorePath(spec.SecretStore, oldBase, newBase, "SecretStore")
+}
+
+// rewriteStorePath moves p from oldBase to newBase, if it is under oldBase
+func rewriteStorePath(p string, oldBase string, newBase string, field string) string {
+	if p == "" {
+		return p
+	}
+	if p == oldBase {
+		return newBase
+	}
+	if strings.HasPrefix(p, oldBase+"/") {
+		return newBase + strings.TrimPrefix(p, oldBase)
+	}
+	glog.Warningf("%s %q is not under the ConfigBase, so was not copied", field, p)
+	return p
+}
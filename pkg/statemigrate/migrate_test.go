@@ -0,0 +1,204 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statemigrate
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/apis/kops/registry"
+	"k8s.io/kops/pkg/kopscodecs"
+	"k8s.io/kops/pkg/statehistory"
+	"k8s.io/kops/upup/pkg/fi/utils"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+func TestRewriteSpec(t *testing.T) {
+	m := &Migration{
+		ClusterName: "cluster.example.com",
+		From:        vfs.NewMemFSPath(vfs.NewMemFSContext(), "old"),
+		To:          vfs.NewMemFSPath(vfs.NewMemFSContext(), "new"),
+	}
+
+	spec := &kops.ClusterSpec{
+		ConfigBase:  "memfs://old/cluster.example.com",
+		KeyStore:    "memfs://old/cluster.example.com/pki",
+		SecretStore: "memfs://other/secrets",
+	}
+	m.rewriteSpec(spec)
+
+	if spec.ConfigBase != "memfs://new/cluster.example.com" {
+		t.Errorf("unexpected ConfigBase: %q", spec.ConfigBase)
+	}
+	if spec.KeyStore != "memfs://new/cluster.example.com/pki" {
+		t.Errorf("unexpected KeyStore: %q", spec.KeyStore)
+	}
+	if spec.SecretStore != "memfs://other/secrets" {
+		t.Errorf("expected SecretStore outside the ConfigBase to be unchanged, got %q", spec.SecretStore)
+	}
+
+	// A store whose name merely starts with the ConfigBase is not under it
+	if p := rewriteStorePath("memfs://old/cluster.example.com.au/pki", "memfs://old/cluster.example.com", "memfs://new/cluster.example.com", "KeyStore"); p != "memfs://old/cluster.example.com.au/pki" {
+		t.Errorf("unexpected rewrite of sibling path: %q", p)
+	}
+
+	completed := &kops.Cluster{}
+	completed.Spec.ConfigBase = "memfs://old/cluster.example.com"
+	completed.Spec.SecretStore = "memfs://old/cluster.example.com/secrets"
+	data, err := utils.YamlMarshal(completed)
+	if err != nil {
+		t.Fatalf("error serializing cluster: %v", err)
+	}
+	data, err = m.rewriteCompletedConfig(data)
+	if err != nil {
+		t.Fatalf("error rewriting completed cluster spec: %v", err)
+	}
+	rewritten := &kops.Cluster{}
+	if err := utils.YamlUnmarshal(data, rewritten); err != nil {
+		t.Fatalf("error parsing rewritten cluster spec: %v", err)
+	}
+	if rewritten.Spec.ConfigBase != "memfs://new/cluster.example.com" || rewritten.Spec.SecretStore != "memfs://new/cluster.example.com/secrets" {
+		t.Errorf("unexpected rewritten spec: %v", rewritten.Spec)
+	}
+}
+
+func TestPlanRewritesHistory(t *testing.T) {
+	m := &Migration{
+		ClusterName: "cluster.example.com",
+		From:        vfs.NewMemFSPath(vfs.NewMemFSContext(), "old"),
+		To:          vfs.NewMemFSPath(vfs.NewMemFSContext(), "new"),
+	}
+	src := m.From.Join(m.ClusterName)
+
+	cluster := &kops.Cluster{}
+	cluster.ObjectMeta.Name = m.ClusterName
+	cluster.Spec.ConfigBase = src.Path()
+	cluster.Spec.KeyStore = src.Path() + "/pki"
+	clusterData, err := kopscodecs.ToVersionedYaml(cluster)
+	if err != nil {
+		t.Fatalf("error serializing cluster: %v", err)
+	}
+
+	writeFile := func(p vfs.Path, data []byte) {
+		if err := p.WriteFile(bytes.NewReader(data), nil); err != nil {
+			t.Fatalf("error writing %s: %v", p, err)
+		}
+	}
+	writeFile(src.Join(registry.PathCluster), clusterData)
+
+	history := statehistory.NewStore(cluster, src)
+	if _, err := history.Record("Cluster", m.ClusterName, clusterData); err != nil {
+		t.Fatalf("error recording history: %v", err)
+	}
+	if _, err := history.Record("InstanceGroup", "nodes", []byte("kind: InstanceGroup")); err != nil {
+		t.Fatalf("error recording history: %v", err)
+	}
+
+	files, _, err := m.Plan()
+	if err != nil {
+		t.Fatalf("error planning migration: %v", err)
+	}
+
+	revisions := make(map[string]*statehistory.Revision)
+	for _, f := range files {
+		if strings.HasPrefix(f.RelativePath, statehistory.PathHistoryLatest+"/") {
+			t.Errorf("expected %s not to be copied", f.RelativePath)
+		}
+		if !strings.HasPrefix(f.RelativePath, statehistory.PathHistory+"/") {
+			continue
+		}
+		r := &statehistory.Revision{}
+		if err := utils.YamlUnmarshal(f.Data, r); err != nil {
+			t.Fatalf("error parsing %s: %v", f.RelativePath, err)
+		}
+		revisions[f.RelativePath] = r
+	}
+
+	r := revisions["history/000001"]
+	if r == nil || r.Kind != "Cluster" || r.Revision != 1 {
+		t.Fatalf("expected revision 1 of the cluster, got %v", r)
+	}
+	obj, _, err := kopscodecs.ParseVersionedYaml([]byte(r.Data))
+	if err != nil {
+		t.Fatalf("error parsing cluster in history: %v", err)
+	}
+	rewritten := obj.(*kops.Cluster)
+	if rewritten.Spec.ConfigBase != "memfs://new/cluster.example.com" || rewritten.Spec.KeyStore != "memfs://new/cluster.example.com/pki" {
+		t.Errorf("expected stores in history to be rewritten, got %v", rewritten.Spec)
+	}
+	if r.Hash != statehistory.HashData([]byte(r.Data)) {
+		t.Errorf("hash of rewritten revision does not match its data")
+	}
+
+	if r := revisions["history/000002"]; r == nil || r.Kind != "InstanceGroup" || r.Data != "kind: InstanceGroup" {
+		t.Errorf("expected revision of instance group to be copied unchanged, got %v", r)
+	}
+}
+
+func TestApply(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "statemigrate")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	m := &Migration{
+		ClusterName: "cluster.example.com",
+		From:        vfs.NewMemFSPath(vfs.NewMemFSContext(), "old"),
+		To:          vfs.NewFSPath(tmpdir),
+	}
+	src := m.From.Join(m.ClusterName)
+	dest := m.To.Join(m.ClusterName)
+
+	var files []*File
+	for _, p := range []string{"instancegroup/nodes", "pki/ssh/public/admin/abc", "addons/bootstrap-channel.yaml"} {
+		files = append(files, &File{
+			RelativePath: p,
+			Source:       src.Join(p),
+			Dest:         dest.Join(p),
+			Data:         []byte(p),
+		})
+	}
+
+	cluster := &kops.Cluster{}
+	cluster.ObjectMeta.Name = m.ClusterName
+	if err := m.Apply(files, cluster); err != nil {
+		t.Fatalf("error applying migration: %v", err)
+	}
+
+	for _, f := range files {
+		data, err := dest.Join(f.RelativePath).ReadFile()
+		if err != nil {
+			t.Fatalf("error reading copied file: %v", err)
+		}
+		if string(data) != f.RelativePath {
+			t.Errorf("unexpected contents of %s: %q", f.RelativePath, data)
+		}
+		if err := verifyFile(f); err != nil {
+			t.Errorf("unexpected error verifying %s: %v", f.RelativePath, err)
+		}
+	}
+
+	files[0].Data = []byte("changed")
+	if err := verifyFile(files[0]); err == nil {
+		t.Errorf("expected error verifying file with different contents")
+	}
+}
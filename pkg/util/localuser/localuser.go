@@ -0,0 +1,36 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package localuser
+
+import (
+	"os"
+	"os/user"
+)
+
+// Describe returns the local user and host as user@host, to record who made a change to the state store.
+// If the hostname cannot be found, it returns just the user.
+func Describe() string {
+	username := os.Getenv("USER")
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		username = u.Username
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		return username
+	}
+	return username + "@" + hostname
+}
@@ -0,0 +1,429 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/pkg/apis/kops/util"
+	"k8s.io/kops/pkg/dns"
+)
+
+func init() {
+	RegisterValidator(&etcdPodsValidator{})
+	RegisterValidator(&apiLoadBalancerValidator{})
+	RegisterValidator(&dnsValidator{})
+	RegisterValidator(&addonsValidator{})
+	RegisterValidator(&specChecksValidator{})
+}
+
+// lookupHost resolves DNS names; it is a variable so it can be replaced in tests
+var lookupHost = net.LookupHost
+
+// placeholderAddress is the address kops gives the API DNS records until dns-controller updates them
+const placeholderAddress = "203.0.113.123"
+
+// dialTimeout opens network connections; it is a variable so it can be replaced in tests
+var dialTimeout = net.DialTimeout
+
+// etcdMemberHealth checks the health of the etcd member run by a pod; it is a variable so it can be replaced in tests
+var etcdMemberHealth = queryEtcdMemberHealth
+
+// queryEtcdMemberHealth queries the /health endpoint of the etcd member run by the pod, through the API server's pod proxy
+func queryEtcdMemberHealth(k8sClient kubernetes.Interface, pod *v1.Pod, scheme string, port int) error {
+	data, err := k8sClient.CoreV1().RESTClient().Get().
+		Namespace(pod.Namespace).
+		Resource("pods").
+		Name(fmt.Sprintf("%s:%s:%d", scheme, pod.Name, port)).
+		SubResource("proxy").
+		Suffix("health").
+		DoRaw()
+	if err != nil {
+		return err
+	}
+
+	health := struct {
+		Health string `json:"health"`
+	}{}
+	if err := json.Unmarshal(data, &health); err != nil {
+		return fmt.Errorf("error parsing response %q: %v", string(data), err)
+	}
+	if health.Health != "true" {
+		return fmt.Errorf("member reports health %q", health.Health)
+	}
+	return nil
+}
+
+// etcdClientPort returns the port on which the members of the etcd cluster serve clients, or 0 if it is not known
+func etcdClientPort(etcdCluster *kops.EtcdClusterSpec) int {
+	switch etcdCluster.Name {
+	case "main":
+		return 4001
+	case "events":
+		return 4002
+	default:
+		return 0
+	}
+}
+
+// etcdPodsValidator checks that each etcd cluster has a running and ready pod for every member, and that the member
+// run by each ready pod reports itself healthy.
+// The API server cannot present a client certificate to etcd, so when enableTLSAuth is set only the pods are checked.
+type etcdPodsValidator struct{}
+
+func (e *etcdPodsValidator) Name() string {
+	return "etcd-pods"
+}
+
+func (e *etcdPodsValidator) Validate(c *ValidatorContext) ([]*ValidationError, error) {
+	pods, err := c.K8sClient.CoreV1().Pods("kube-system").List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing Pods: %v", err)
+	}
+
+	var failures []*ValidationError
+	for _, etcdCluster := range c.Cluster.Spec.EtcdClusters {
+		// etcd is run by protokube (legacy) or etcd-manager, which name their pods differently
+		apps := sets.NewString("etcd-manager-"+etcdCluster.Name, "etcd-server-"+etcdCluster.Name)
+		if etcdCluster.Name == "main" {
+			apps.Insert("etcd-server")
+		}
+
+		var ready []*v1.Pod
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+			if !apps.Has(pod.Labels["k8s-app"]) {
+				continue
+			}
+			if isPodReady(pod) {
+				ready = append(ready, pod)
+			}
+		}
+
+		expected := len(etcdCluster.Members)
+		if len(ready) < expected {
+			failures = append(failures, &ValidationError{
+				Kind:    "etcd",
+				Name:    etcdCluster.Name,
+				Message: fmt.Sprintf("etcd cluster %q has %d of %d member pods ready", etcdCluster.Name, len(ready), expected),
+			})
+		}
+
+		port := etcdClientPort(etcdCluster)
+		if etcdCluster.EnableTLSAuth || port == 0 {
+			glog.V(2).Infof("not checking the health of the members of etcd cluster %q, only that their pods are ready", etcdCluster.Name)
+			continue
+		}
+		scheme := "http"
+		if etcdCluster.EnableEtcdTLS {
+			scheme = "https"
+		}
+		for _, pod := range ready {
+			if err := etcdMemberHealth(c.K8sClient, pod, scheme, port); err != nil {
+				failures = append(failures, &ValidationError{
+					Kind:    "etcd",
+					Name:    etcdCluster.Name,
+					Message: fmt.Sprintf("etcd member of cluster %q in pod %q is not healthy: %v", etcdCluster.Name, pod.Name, err),
+				})
+			}
+		}
+	}
+
+	return failures, nil
+}
+
+// apiLoadBalancerValidator checks that the API server can be reached through its load balancer
+type apiLoadBalancerValidator struct{}
+
+func (a *apiLoadBalancerValidator) Name() string {
+	return "api-loadbalancer"
+}
+
+func (a *apiLoadBalancerValidator) Validate(c *ValidatorContext) ([]*ValidationError, error) {
+	if c.Cluster.Spec.API == nil || c.Cluster.Spec.API.LoadBalancer == nil {
+		return nil, nil
+	}
+
+	// The name of a gossip cluster is not resolvable, and kubecfg points directly at the load balancer
+	if dns.IsGossipHostname(c.Cluster.Name) {
+		glog.V(2).Infof("not checking API load balancer for gossip cluster")
+		return nil, nil
+	}
+
+	address := net.JoinHostPort(masterPublicName(c.Cluster), "443")
+	conn, err := dialTimeout("tcp", address, 10*time.Second)
+	if err != nil {
+		return []*ValidationError{{
+			Kind:    "LoadBalancer",
+			Name:    "api",
+			Message: fmt.Sprintf("API server is not reachable through its load balancer at %s: %v", address, err),
+		}}, nil
+	}
+	conn.Close()
+
+	return nil, nil
+}
+
+// dnsValidator checks that the internal API DNS record points at the masters
+type dnsValidator struct{}
+
+func (d *dnsValidator) Name() string {
+	return "dns"
+}
+
+func (d *dnsValidator) Validate(c *ValidatorContext) ([]*ValidationError, error) {
+	if dns.IsGossipHostname(c.Cluster.Name) {
+		return nil, nil
+	}
+
+	masterIPs := sets.NewString()
+	for i := range c.Nodes {
+		node := &c.Nodes[i]
+		if util.GetNodeRole(node) != "master" || !isNodeReady(node) {
+			continue
+		}
+		for _, address := range node.Status.Addresses {
+			if address.Type == v1.NodeInternalIP {
+				masterIPs.Insert(address.Address)
+			}
+		}
+	}
+
+	name := masterInternalName(c.Cluster)
+	addresses, err := lookupHost(name)
+	if err != nil {
+		// The record may be in a private zone that cannot be resolved from where kops is running
+		return []*ValidationError{{
+			Kind:     "dns",
+			Name:     name,
+			Message:  fmt.Sprintf("unable to resolve %s: %v", name, err),
+			Severity: kops.ValidationSeverityWarning,
+		}}, nil
+	}
+
+	resolved := sets.NewString(addresses...)
+	if resolved.Has(placeholderAddress) {
+		return []*ValidationError{{
+			Kind:    "dns",
+			Name:    name,
+			Message: fmt.Sprintf("%s still resolves to the placeholder address %s; dns-controller has not yet updated it", name, placeholderAddress),
+		}}, nil
+	}
+
+	var failures []*ValidationError
+	// dns-controller may not yet have removed a master that is being replaced, or that is not yet ready again
+	if stale := resolved.Difference(masterIPs); stale.Len() != 0 {
+		failures = append(failures, &ValidationError{
+			Kind:     "dns",
+			Name:     name,
+			Message:  fmt.Sprintf("%s resolves to %s, which are not the addresses of ready masters", name, strings.Join(stale.List(), ",")),
+			Severity: kops.ValidationSeverityWarning,
+		})
+	}
+	// dns-controller may not yet have added a master that has just become ready
+	if missing := masterIPs.Difference(resolved); missing.Len() != 0 {
+		failures = append(failures, &ValidationError{
+			Kind:     "dns",
+			Name:     name,
+			Message:  fmt.Sprintf("%s does not yet resolve to the addresses of ready masters %s", name, strings.Join(missing.List(), ",")),
+			Severity: kops.ValidationSeverityWarning,
+		})
+	}
+
+	return failures, nil
+}
+
+// addonsValidator checks that the Deployments and DaemonSets in kube-system have all their replicas ready
+type addonsValidator struct{}
+
+func (a *addonsValidator) Name() string {
+	return "addons"
+}
+
+func (a *addonsValidator) Validate(c *ValidatorContext) ([]*ValidationError, error) {
+	var failures []*ValidationError
+
+	deployments, err := c.K8sClient.AppsV1().Deployments("kube-system").List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing Deployments: %v", err)
+	}
+	for i := range deployments.Items {
+		deployment := &deployments.Items[i]
+		desired := int32(1)
+		if deployment.Spec.Replicas != nil {
+			desired = *deployment.Spec.Replicas
+		}
+		if deployment.Status.ReadyReplicas < desired {
+			failures = append(failures, &ValidationError{
+				Kind:     "Deployment",
+				Name:     "kube-system/" + deployment.Name,
+				Message:  fmt.Sprintf("addon deployment %q has %d of %d replicas ready", deployment.Name, deployment.Status.ReadyReplicas, desired),
+				Severity: kops.ValidationSeverityWarning,
+			})
+		}
+	}
+
+	daemonSets, err := c.K8sClient.AppsV1().DaemonSets("kube-system").List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing DaemonSets: %v", err)
+	}
+	for i := range daemonSets.Items {
+		daemonSet := &daemonSets.Items[i]
+		if daemonSet.Status.NumberReady < daemonSet.Status.DesiredNumberScheduled {
+			failures = append(failures, &ValidationError{
+				Kind:     "DaemonSet",
+				Name:     "kube-system/" + daemonSet.Name,
+				Message:  fmt.Sprintf("addon daemonset %q has %d of %d pods ready", daemonSet.Name, daemonSet.Status.NumberReady, daemonSet.Status.DesiredNumberScheduled),
+				Severity: kops.ValidationSeverityWarning,
+			})
+		}
+	}
+
+	return failures, nil
+}
+
+// specChecksValidator runs the additional checks declared in the cluster spec
+type specChecksValidator struct{}
+
+func (s *specChecksValidator) Name() string {
+	return "checks"
+}
+
+func (s *specChecksValidator) Validate(c *ValidatorContext) ([]*ValidationError, error) {
+	if c.Cluster.Spec.Validation == nil {
+		return nil, nil
+	}
+
+	var failures []*ValidationError
+	for i := range c.Cluster.Spec.Validation.Checks {
+		check := &c.Cluster.Spec.Validation.Checks[i]
+		if check.ReadyReplicas == nil {
+			continue
+		}
+
+		message, err := checkReadyReplicas(c, check.ReadyReplicas)
+		if err != nil {
+			return nil, fmt.Errorf("error running check %q: %v", check.Name, err)
+		}
+		if message != "" {
+			failures = append(failures, &ValidationError{
+				Kind:     "Check",
+				Name:     check.Name,
+				Message:  message,
+				Severity: check.Severity,
+			})
+		}
+	}
+
+	return failures, nil
+}
+
+// checkReadyReplicas returns a message describing the failure if the workload does not have enough ready replicas
+func checkReadyReplicas(c *ValidatorContext, check *kops.ReadyReplicasCheck) (string, error) {
+	var ready, desired int32
+	var err error
+
+	switch check.Kind {
+	case "Deployment":
+		var deployment *appsv1.Deployment
+		deployment, err = c.K8sClient.AppsV1().Deployments(check.Namespace).Get(check.Name, metav1.GetOptions{})
+		if err == nil {
+			desired = 1
+			if deployment.Spec.Replicas != nil {
+				desired = *deployment.Spec.Replicas
+			}
+			ready = deployment.Status.ReadyReplicas
+		}
+
+	case "StatefulSet":
+		var statefulSet *appsv1.StatefulSet
+		statefulSet, err = c.K8sClient.AppsV1().StatefulSets(check.Namespace).Get(check.Name, metav1.GetOptions{})
+		if err == nil {
+			desired = 1
+			if statefulSet.Spec.Replicas != nil {
+				desired = *statefulSet.Spec.Replicas
+			}
+			ready = statefulSet.Status.ReadyReplicas
+		}
+
+	case "DaemonSet":
+		var daemonSet *appsv1.DaemonSet
+		daemonSet, err = c.K8sClient.AppsV1().DaemonSets(check.Namespace).Get(check.Name, metav1.GetOptions{})
+		if err == nil {
+			desired = daemonSet.Status.DesiredNumberScheduled
+			ready = daemonSet.Status.NumberReady
+		}
+
+	default:
+		return "", fmt.Errorf("unknown kind %q", check.Kind)
+	}
+
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return fmt.Sprintf("%s %s/%s not found", strings.ToLower(check.Kind), check.Namespace, check.Name), nil
+		}
+		return "", err
+	}
+
+	minimum := desired
+	if check.MinReadyReplicas != nil {
+		minimum = *check.MinReadyReplicas
+	}
+	if ready < minimum {
+		return fmt.Sprintf("%s %s/%s has %d ready replicas, needs %d", strings.ToLower(check.Kind), check.Namespace, check.Name, ready, minimum), nil
+	}
+	return "", nil
+}
+
+// isPodReady returns true if the pod is running and all its containers are ready
+func isPodReady(pod *v1.Pod) bool {
+	if pod.Status.Phase != v1.PodRunning {
+		return false
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if !status.Ready {
+			return false
+		}
+	}
+	return true
+}
+
+func masterPublicName(cluster *kops.Cluster) string {
+	if cluster.Spec.MasterPublicName != "" {
+		return cluster.Spec.MasterPublicName
+	}
+	return "api." + cluster.ObjectMeta.Name
+}
+
+func masterInternalName(cluster *kops.Cluster) string {
+	if cluster.Spec.MasterInternalName != "" {
+		return cluster.Spec.MasterInternalName
+	}
+	return "api.internal." + cluster.ObjectMeta.Name
+}
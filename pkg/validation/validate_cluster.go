@@ -45,12 +45,28 @@ type ValidationError struct {
 	Kind    string `json:"type,omitempty"`
 	Name    string `json:"name,omitempty"`
 	Message string `json:"message,omitempty"`
+	// Severity is Error if the failure means the cluster does not validate, or Warning if it is only reported
+	Severity kops.ValidationSeverity `json:"severity,omitempty"`
 }
 
 func (v *ValidationCluster) addError(failure *ValidationError) {
+	if failure.Severity == "" {
+		failure.Severity = kops.ValidationSeverityError
+	}
 	v.Failures = append(v.Failures, failure)
 }
 
+// Errors returns the failures that mean the cluster does not validate, ignoring warnings
+func (v *ValidationCluster) Errors() []*ValidationError {
+	var errors []*ValidationError
+	for _, failure := range v.Failures {
+		if failure.Severity != kops.ValidationSeverityWarning {
+			errors = append(errors, failure)
+		}
+	}
+	return errors
+}
+
 // ValidationNode represents the validation status for a node
 type ValidationNode struct {
 	Name     string             `json:"name,omitempty"`
@@ -152,6 +168,13 @@ func ValidateCluster(cluster *kops.Cluster, instanceGroupList *kops.InstanceGrou
 		return nil, fmt.Errorf("cannot get pod health for %q: %v", clusterName, err)
 	}
 
+	v.runValidators(&ValidatorContext{
+		Cluster:        cluster,
+		InstanceGroups: instanceGroups,
+		K8sClient:      k8sClient,
+		Nodes:          nodeList.Items,
+	})
+
 	return v, nil
 }
 
@@ -0,0 +1,96 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/golang/glog"
+	"k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/kops/pkg/apis/kops"
+)
+
+// Validator is a check run by ValidateCluster, in addition to the checks of nodes and kube-system pods
+type Validator interface {
+	// Name identifies the validator
+	Name() string
+	// Validate runs the check, returning any failures.  An error means the check itself could not be run.
+	Validate(c *ValidatorContext) ([]*ValidationError, error)
+}
+
+// ValidatorContext holds the state of the cluster being validated
+type ValidatorContext struct {
+	Cluster        *kops.Cluster
+	InstanceGroups []*kops.InstanceGroup
+	K8sClient      kubernetes.Interface
+	// Nodes is the list of kubernetes nodes in the cluster
+	Nodes []v1.Node
+}
+
+var validators = make(map[string]Validator)
+var validatorsMutex sync.Mutex
+
+// RegisterValidator adds a Validator that is run by ValidateCluster, replacing any existing Validator with the same name
+func RegisterValidator(validator Validator) {
+	validatorsMutex.Lock()
+	defer validatorsMutex.Unlock()
+
+	validators[validator.Name()] = validator
+}
+
+// registeredValidators returns the registered validators, sorted by name
+func registeredValidators() []Validator {
+	validatorsMutex.Lock()
+	defer validatorsMutex.Unlock()
+
+	var names []string
+	for name := range validators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var list []Validator
+	for _, name := range names {
+		list = append(list, validators[name])
+	}
+	return list
+}
+
+// runValidators runs every registered validator, recording their failures.
+// A validator that cannot run is recorded as a failure, rather than stopping the validation.
+func (v *ValidationCluster) runValidators(c *ValidatorContext) {
+	for _, validator := range registeredValidators() {
+		glog.V(4).Infof("running validator %q", validator.Name())
+
+		failures, err := validator.Validate(c)
+		if err != nil {
+			v.addError(&ValidationError{
+				Kind:    "Validator",
+				Name:    validator.Name(),
+				Message: fmt.Sprintf("validator %q could not run: %v", validator.Name(), err),
+			})
+			continue
+		}
+
+		for _, failure := range failures {
+			v.addError(failure)
+		}
+	}
+}
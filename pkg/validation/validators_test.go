@@ -0,0 +1,325 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+)
+
+// failingValidator is a Validator that cannot run
+type failingValidator struct{}
+
+func (f *failingValidator) Name() string {
+	return "zz-failing"
+}
+
+func (f *failingValidator) Validate(c *ValidatorContext) ([]*ValidationError, error) {
+	return nil, fmt.Errorf("broken")
+}
+
+func Test_RunValidatorsRecordsValidatorErrors(t *testing.T) {
+	RegisterValidator(&failingValidator{})
+	defer func() {
+		validatorsMutex.Lock()
+		delete(validators, "zz-failing")
+		validatorsMutex.Unlock()
+	}()
+
+	cluster := &kopsapi.Cluster{}
+	cluster.Name = "test.k8s.local"
+
+	v := &ValidationCluster{}
+	v.runValidators(&ValidatorContext{
+		Cluster:   cluster,
+		K8sClient: fake.NewSimpleClientset(),
+	})
+
+	if len(v.Errors()) != 1 || v.Errors()[0].Kind != "Validator" || v.Errors()[0].Name != "zz-failing" {
+		t.Fatalf("expected a single failure from the failing validator, got %v", v.Failures)
+	}
+}
+
+func Test_ErrorsIgnoresWarnings(t *testing.T) {
+	v := &ValidationCluster{}
+	v.addError(&ValidationError{Kind: "a", Message: "error"})
+	v.addError(&ValidationError{Kind: "b", Message: "warning", Severity: kopsapi.ValidationSeverityWarning})
+
+	if len(v.Failures) != 2 {
+		t.Fatalf("expected 2 failures, got %d", len(v.Failures))
+	}
+	if v.Failures[0].Severity != kopsapi.ValidationSeverityError {
+		t.Errorf("expected severity to default to %s, got %q", kopsapi.ValidationSeverityError, v.Failures[0].Severity)
+	}
+	errors := v.Errors()
+	if len(errors) != 1 || errors[0].Kind != "a" {
+		t.Errorf("expected only the error, got %v", errors)
+	}
+}
+
+func Test_EtcdPodsValidator(t *testing.T) {
+	defer func() { etcdMemberHealth = queryEtcdMemberHealth }()
+	etcdMemberHealth = func(k8sClient kubernetes.Interface, pod *v1.Pod, scheme string, port int) error {
+		return nil
+	}
+
+	cluster := &kopsapi.Cluster{}
+	cluster.Name = "test.k8s.local"
+	cluster.Spec.EtcdClusters = []*kopsapi.EtcdClusterSpec{
+		{Name: "main", Members: []*kopsapi.EtcdMemberSpec{{Name: "a"}, {Name: "b"}, {Name: "c"}}},
+		{Name: "events", Members: []*kopsapi.EtcdMemberSpec{{Name: "a"}}},
+	}
+
+	k8sClient := fake.NewSimpleClientset(
+		etcdPod("etcd-server-1", "etcd-server", true),
+		etcdPod("etcd-server-2", "etcd-server", true),
+		etcdPod("etcd-server-3", "etcd-server", false),
+		etcdPod("etcd-server-events-1", "etcd-server-events", true),
+	)
+
+	failures, err := (&etcdPodsValidator{}).Validate(&ValidatorContext{Cluster: cluster, K8sClient: k8sClient})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(failures) != 1 || failures[0].Name != "main" {
+		t.Errorf("expected a failure for the main etcd cluster only, got %v", failures)
+	}
+}
+
+func Test_DNSValidator(t *testing.T) {
+	defer func() { lookupHost = net.LookupHost }()
+
+	cluster := &kopsapi.Cluster{}
+	cluster.Name = "test.example.com"
+
+	nodes := []v1.Node{
+		masterNode("master-1", "10.0.0.1"),
+		masterNode("master-2", "10.0.0.2"),
+	}
+
+	grid := []struct {
+		Addresses []string
+		Errors    int
+		Warnings  int
+	}{
+		{Addresses: []string{"10.0.0.1", "10.0.0.2"}},
+		{Addresses: []string{"10.0.0.1"}, Warnings: 1},
+		{Addresses: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}, Warnings: 1},
+		{Addresses: []string{placeholderAddress}, Errors: 1},
+	}
+
+	for _, g := range grid {
+		var lookedUp string
+		lookupHost = func(host string) ([]string, error) {
+			lookedUp = host
+			return g.Addresses, nil
+		}
+
+		v := &ValidationCluster{}
+		failures, err := (&dnsValidator{}).Validate(&ValidatorContext{Cluster: cluster, Nodes: nodes})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, failure := range failures {
+			v.addError(failure)
+		}
+
+		if lookedUp != "api.internal.test.example.com" {
+			t.Errorf("expected lookup of api.internal.test.example.com, got %q", lookedUp)
+		}
+		if len(v.Errors()) != g.Errors || len(v.Failures)-len(v.Errors()) != g.Warnings {
+			t.Errorf("addresses %v: expected %d errors and %d warnings, got %v", g.Addresses, g.Errors, g.Warnings, v.Failures)
+		}
+	}
+}
+
+func Test_APILoadBalancerValidator(t *testing.T) {
+	defer func() { dialTimeout = net.DialTimeout }()
+
+	cluster := &kopsapi.Cluster{}
+	cluster.Name = "test.example.com"
+	cluster.Spec.API = &kopsapi.AccessSpec{LoadBalancer: &kopsapi.LoadBalancerAccessSpec{}}
+
+	var dialed string
+	dialTimeout = func(network, address string, timeout time.Duration) (net.Conn, error) {
+		dialed = address
+		return nil, fmt.Errorf("connection refused")
+	}
+
+	failures, err := (&apiLoadBalancerValidator{}).Validate(&ValidatorContext{Cluster: cluster})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dialed != "api.test.example.com:443" {
+		t.Errorf("expected to dial api.test.example.com:443, got %q", dialed)
+	}
+	if len(failures) != 1 || failures[0].Kind != "LoadBalancer" {
+		t.Errorf("expected a LoadBalancer failure, got %v", failures)
+	}
+}
+
+func Test_AddonsValidator(t *testing.T) {
+	k8sClient := fake.NewSimpleClientset(
+		deployment("kube-system", "dns-controller", 1, 1),
+		deployment("kube-system", "kube-dns", 2, 1),
+		deployment("default", "app", 2, 0),
+	)
+
+	failures, err := (&addonsValidator{}).Validate(&ValidatorContext{K8sClient: k8sClient})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(failures) != 1 || failures[0].Name != "kube-system/kube-dns" || failures[0].Severity != kopsapi.ValidationSeverityWarning {
+		t.Errorf("expected a warning for kube-dns only, got %v", failures)
+	}
+}
+
+func Test_SpecChecksValidator(t *testing.T) {
+	one := int32(1)
+
+	cluster := &kopsapi.Cluster{}
+	cluster.Name = "test.k8s.local"
+	cluster.Spec.Validation = &kopsapi.ClusterValidationSpec{
+		Checks: []kopsapi.ClusterValidationCheck{
+			{
+				Name:          "app-ready",
+				ReadyReplicas: &kopsapi.ReadyReplicasCheck{Kind: "Deployment", Namespace: "default", Name: "app"},
+			},
+			{
+				Name:          "app-minimum",
+				ReadyReplicas: &kopsapi.ReadyReplicasCheck{Kind: "Deployment", Namespace: "default", Name: "app", MinReadyReplicas: &one},
+			},
+			{
+				Name:          "missing",
+				Severity:      kopsapi.ValidationSeverityWarning,
+				ReadyReplicas: &kopsapi.ReadyReplicasCheck{Kind: "StatefulSet", Namespace: "default", Name: "db"},
+			},
+		},
+	}
+
+	k8sClient := fake.NewSimpleClientset(
+		deployment("default", "app", 3, 1),
+	)
+
+	failures, err := (&specChecksValidator{}).Validate(&ValidatorContext{Cluster: cluster, K8sClient: k8sClient})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(failures) != 2 {
+		t.Fatalf("expected 2 failures, got %v", failures)
+	}
+	if failures[0].Name != "app-ready" || failures[0].Kind != "Check" {
+		t.Errorf("expected failure of app-ready, got %v", failures[0])
+	}
+	if failures[1].Name != "missing" || failures[1].Severity != kopsapi.ValidationSeverityWarning {
+		t.Errorf("expected warning for missing, got %v", failures[1])
+	}
+}
+
+func Test_EtcdPodsValidatorChecksMemberHealth(t *testing.T) {
+	defer func() { etcdMemberHealth = queryEtcdMemberHealth }()
+	checked := make(map[string]string)
+	etcdMemberHealth = func(k8sClient kubernetes.Interface, pod *v1.Pod, scheme string, port int) error {
+		checked[pod.Name] = fmt.Sprintf("%s:%d", scheme, port)
+		if pod.Name == "etcd-server-2" {
+			return fmt.Errorf("member reports health %q", "false")
+		}
+		return nil
+	}
+
+	cluster := &kopsapi.Cluster{}
+	cluster.Name = "test.k8s.local"
+	cluster.Spec.EtcdClusters = []*kopsapi.EtcdClusterSpec{
+		{Name: "main", Members: []*kopsapi.EtcdMemberSpec{{Name: "a"}, {Name: "b"}}},
+		{Name: "events", Members: []*kopsapi.EtcdMemberSpec{{Name: "a"}}, EnableEtcdTLS: true, EnableTLSAuth: true},
+	}
+
+	k8sClient := fake.NewSimpleClientset(
+		etcdPod("etcd-server-1", "etcd-server", true),
+		etcdPod("etcd-server-2", "etcd-server", true),
+		etcdPod("etcd-server-events-1", "etcd-server-events", true),
+	)
+
+	failures, err := (&etcdPodsValidator{}).Validate(&ValidatorContext{Cluster: cluster, K8sClient: k8sClient})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(failures) != 1 || failures[0].Name != "main" || !strings.Contains(failures[0].Message, "etcd-server-2") {
+		t.Errorf("expected a failure for the unhealthy member of the main etcd cluster only, got %v", failures)
+	}
+	expected := map[string]string{"etcd-server-1": "http:4001", "etcd-server-2": "http:4001"}
+	if !reflect.DeepEqual(checked, expected) {
+		t.Errorf("expected only the members of the main cluster to be checked, as the events cluster requires client certificates; checked %v", checked)
+	}
+}
+
+func etcdPod(name string, app string, ready bool) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "kube-system",
+			Labels:    map[string]string{"k8s-app": app},
+		},
+		Status: v1.PodStatus{
+			Phase:             v1.PodRunning,
+			ContainerStatuses: []v1.ContainerStatus{{Ready: ready}},
+		},
+	}
+}
+
+func masterNode(name string, ip string) v1.Node {
+	return v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{"kubernetes.io/role": "master"},
+		},
+		Status: v1.NodeStatus{
+			Conditions: []v1.NodeCondition{
+				{Type: "Ready", Status: v1.ConditionTrue},
+			},
+			Addresses: []v1.NodeAddress{
+				{Type: v1.NodeInternalIP, Address: ip},
+			},
+		},
+	}
+}
+
+func deployment(namespace string, name string, replicas int32, ready int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+		},
+		Status: appsv1.DeploymentStatus{
+			ReadyReplicas: ready,
+		},
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/api/core/v1"
+)
+
+// ValidationAttempt summarizes one attempt at validating the cluster, while waiting for it to validate
+type ValidationAttempt struct {
+	// Attempt is the number of the attempt, starting at 1
+	Attempt int `json:"attempt"`
+	// Time is when the attempt completed
+	Time time.Time `json:"time"`
+	// ElapsedSeconds is the time since the first attempt started
+	ElapsedSeconds float64 `json:"elapsedSeconds"`
+	// Passed is true if the cluster validated, ignoring warnings
+	Passed bool `json:"passed"`
+	// Errors is the number of failures that stop the cluster validating
+	Errors int `json:"errors"`
+	// Warnings is the number of failures that are only reported
+	Warnings int `json:"warnings"`
+	// Nodes is the number of kubernetes nodes found
+	Nodes int `json:"nodes"`
+	// ReadyNodes is the number of those nodes that are ready
+	ReadyNodes int `json:"readyNodes"`
+	// Error is set if the validation could not be run at all
+	Error string `json:"error,omitempty"`
+	// Failures are the failures found by the attempt
+	Failures []*ValidationError `json:"failures,omitempty"`
+
+	// Result is the result of the attempt, or nil if Error is set
+	Result *ValidationCluster `json:"-"`
+}
+
+// newValidationAttempt builds the ValidationAttempt for a result, or the error returned instead of one
+func newValidationAttempt(attempt int, start time.Time, result *ValidationCluster, err error) *ValidationAttempt {
+	now := time.Now()
+	a := &ValidationAttempt{
+		Attempt:        attempt,
+		Time:           now,
+		ElapsedSeconds: now.Sub(start).Seconds(),
+		Result:         result,
+	}
+
+	if err != nil {
+		a.Error = err.Error()
+		return a
+	}
+
+	a.Errors = len(result.Errors())
+	a.Warnings = len(result.Failures) - a.Errors
+	a.Passed = a.Errors == 0
+	a.Failures = result.Failures
+	a.Nodes = len(result.Nodes)
+	for _, node := range result.Nodes {
+		if node.Status == v1.ConditionTrue {
+			a.ReadyNodes++
+		}
+	}
+	return a
+}
+
+// WaitForValidation calls validate until it returns a result without errors, or the timeout expires.
+// validate is always called at least once, even if the timeout is shorter than the interval between attempts.
+// report, if not nil, is called after every attempt.
+// The result of the last attempt is returned, along with an error if the cluster did not validate.
+func WaitForValidation(timeout time.Duration, interval time.Duration, validate func() (*ValidationCluster, error), report func(*ValidationAttempt)) (*ValidationCluster, error) {
+	start := time.Now()
+	attempt := 0
+
+	var lastErr error
+	try := func() *ValidationAttempt {
+		attempt++
+		result, err := validate()
+		lastErr = err
+		a := newValidationAttempt(attempt, start, result, err)
+		if report != nil {
+			report(a)
+		}
+		return a
+	}
+
+	last := try()
+	if last.Passed {
+		return last.Result, nil
+	}
+	if timeout <= 0 {
+		if lastErr != nil {
+			return nil, lastErr
+		}
+		return last.Result, fmt.Errorf("cluster did not validate")
+	}
+
+	deadline := time.After(timeout)
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	// Keep trying until we're timed out or got a result
+	for {
+		select {
+		case <-deadline:
+			if lastErr != nil {
+				return nil, fmt.Errorf("cluster did not validate within a duration of %q: %v", timeout, lastErr)
+			}
+			return last.Result, fmt.Errorf("cluster did not validate within a duration of %q", timeout)
+		case <-tick.C:
+			last = try()
+			if last.Passed {
+				return last.Result, nil
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"k8s.io/api/core/v1"
+	kopsapi "k8s.io/kops/pkg/apis/kops"
+)
+
+func Test_WaitForValidationRetriesUntilValid(t *testing.T) {
+	calls := 0
+	validate := func() (*ValidationCluster, error) {
+		calls++
+		switch calls {
+		case 1:
+			return nil, fmt.Errorf("cannot reach API")
+		case 2:
+			return &ValidationCluster{
+				Failures: []*ValidationError{
+					{Kind: "Node", Severity: kopsapi.ValidationSeverityError},
+					{Kind: "Deployment", Severity: kopsapi.ValidationSeverityWarning},
+				},
+				Nodes: []*ValidationNode{{Status: v1.ConditionTrue}, {Status: v1.ConditionFalse}},
+			}, nil
+		default:
+			return &ValidationCluster{
+				Nodes: []*ValidationNode{{Status: v1.ConditionTrue}, {Status: v1.ConditionTrue}},
+			}, nil
+		}
+	}
+
+	var attempts []*ValidationAttempt
+	report := func(a *ValidationAttempt) {
+		attempts = append(attempts, a)
+	}
+
+	result, err := WaitForValidation(time.Minute, time.Millisecond, validate, report)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result == nil || len(result.Nodes) != 2 {
+		t.Fatalf("expected result of the last attempt, got %v", result)
+	}
+
+	if len(attempts) != 3 {
+		t.Fatalf("expected 3 attempts, got %d", len(attempts))
+	}
+	if attempts[0].Error != "cannot reach API" || attempts[0].Passed {
+		t.Errorf("unexpected first attempt %+v", attempts[0])
+	}
+	if a := attempts[1]; a.Passed || a.Errors != 1 || a.Warnings != 1 || a.Nodes != 2 || a.ReadyNodes != 1 {
+		t.Errorf("unexpected second attempt %+v", a)
+	}
+	if a := attempts[2]; !a.Passed || a.Attempt != 3 || a.ReadyNodes != 2 || a.ElapsedSeconds < attempts[1].ElapsedSeconds {
+		t.Errorf("unexpected third attempt %+v", a)
+	}
+}
+
+func Test_WaitForValidationTimesOut(t *testing.T) {
+	validate := func() (*ValidationCluster, error) {
+		return &ValidationCluster{Failures: []*ValidationError{{Kind: "Node"}}}, nil
+	}
+
+	result, err := WaitForValidation(20*time.Millisecond, time.Millisecond, validate, nil)
+	if err == nil {
+		t.Fatalf("expected timeout error")
+	}
+	if result == nil || len(result.Failures) != 1 {
+		t.Errorf("expected result of the last attempt, got %v", result)
+	}
+}
+
+func Test_WaitForValidationWithoutTimeoutTriesOnce(t *testing.T) {
+	calls := 0
+	validate := func() (*ValidationCluster, error) {
+		calls++
+		return nil, fmt.Errorf("cannot reach API")
+	}
+
+	result, err := WaitForValidation(0, time.Millisecond, validate, nil)
+	if err == nil || err.Error() != "cannot reach API" {
+		t.Errorf("expected validation error, got %v", err)
+	}
+	if result != nil || calls != 1 {
+		t.Errorf("expected a single attempt with no result, got %d attempts and %v", calls, result)
+	}
+}
@@ -216,14 +216,17 @@ func buildVFSPath(target string) (string, error) {
 		}
 		if u.Host == "storage.googleapis.com" {
 			vfsPath = "gs:/" + u.Path
+		} else if azureBlobVfsPath, azureErr := vfs.AzureBlobVFSPath(target); azureErr == nil {
+			vfsPath = azureBlobVfsPath
 		}
 	}
 
 	if vfsPath == "" {
 		glog.Errorf("Unable to determine VFS path from supplied URL: %s", target)
-		glog.Errorf("S3, Google Cloud Storage, and File Paths are supported.")
+		glog.Errorf("S3, Google Cloud Storage, Azure Blob Storage, and File Paths are supported.")
 		glog.Errorf("For S3, please make sure that the supplied file repository URL adhere to S3 naming conventions, https://docs.aws.amazon.com/general/latest/gr/rande.html#s3_region.")
 		glog.Errorf("For GCS, please make sure that the supplied file repository URL adheres to https://storage.googleapis.com/")
+		glog.Errorf("For Azure, please make sure that the supplied file repository URL adheres to https://<account>.blob.core.windows.net/<container>/")
 		if err != nil { // print the S3 error for more details
 			return "", fmt.Errorf("Error Details: %v", err)
 		}
@@ -68,6 +68,12 @@ func Test_BuildVFSPath(t *testing.T) {
 			"gs://k8s-for-greeks-kops/kubernetes-release/release/v1.7.2/bin/linux/amd64/kubectl",
 			true,
 		},
+
+		{
+			"https://myaccount.blob.core.windows.net/k8s-for-greeks-kops/kubernetes-release/release/v1.7.2/bin/linux/amd64/kubectl",
+			"azureblob://k8s-for-greeks-kops/kubernetes-release/release/v1.7.2/bin/linux/amd64/kubectl",
+			true,
+		},
 	}
 
 	for _, test := range grid {
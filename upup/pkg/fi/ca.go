@@ -101,6 +101,10 @@ type CAStore interface {
 
 	// DeleteKeysetItem will delete the specified item from the Keyset
 	DeleteKeysetItem(item *kops.Keyset, id string) error
+
+	// SetKeysetRotation pins the primary item of the Keyset, and records the progress of its rotation.
+	// An empty primaryId restores the default, where the newest item is primary.
+	SetKeysetRotation(name string, primaryId string, rotation *kops.KeysetRotation) error
 }
 
 // SSHCredentialStore holds SSHCredential objects
@@ -23,6 +23,7 @@ import (
 	"crypto/x509"
 	"fmt"
 	"math/big"
+	"sort"
 	"sync"
 	"time"
 
@@ -125,6 +126,10 @@ type keyset struct {
 	format  KeysetFormat
 	items   map[string]*keysetItem
 	primary *keysetItem
+
+	// primaryId and rotation are the PrimaryId and Rotation of the Keyset
+	primaryId string
+	rotation  *kops.KeysetRotation
 }
 
 // keysetItem is a parsed KeysetItem
@@ -165,6 +170,8 @@ func parseKeyset(o *kops.Keyset) (*keyset, error) {
 		keyset.items[key.Id] = ki
 	}
 
+	keyset.primaryId = o.Spec.PrimaryId
+	keyset.rotation = o.Spec.Rotation
 	keyset.primary = keyset.findPrimary()
 
 	return keyset, nil
@@ -188,8 +195,15 @@ func (c *ClientsetCAStore) loadKeyset(name string) (*keyset, error) {
 	return keyset, nil
 }
 
-// findPrimary returns the primary keysetItem in the keyset
+// findPrimary returns the primary keysetItem in the keyset: the item with the highest id, unless another is pinned as primary
 func (k *keyset) findPrimary() *keysetItem {
+	if k.primaryId != "" {
+		if item := k.items[k.primaryId]; item != nil {
+			return item
+		}
+		glog.Warningf("Ignoring primary key %q, which is not in the keyset", k.primaryId)
+	}
+
 	var primary *keysetItem
 	var primaryVersion *big.Int
 
@@ -208,8 +222,27 @@ func (k *keyset) findPrimary() *keysetItem {
 	return primary
 }
 
+// sortedIds returns the ids of the items in the keyset, in order
+func (k *keyset) sortedIds() []string {
+	var ids []string
+	for id := range k.items {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
 // FindPrimary returns the primary KeysetItem in the Keyset
 func FindPrimary(keyset *kops.Keyset) *kops.KeysetItem {
+	if keyset.Spec.PrimaryId != "" {
+		for i := range keyset.Spec.Keys {
+			if keyset.Spec.Keys[i].Id == keyset.Spec.PrimaryId {
+				return &keyset.Spec.Keys[i]
+			}
+		}
+		glog.Warningf("Ignoring primary key %q, which is not in keyset %q", keyset.Spec.PrimaryId, keyset.Name)
+	}
+
 	var primary *kops.KeysetItem
 	var primaryVersion *big.Int
 	for i := range keyset.Spec.Keys {
@@ -284,11 +317,11 @@ func (c *ClientsetCAStore) FindCertificatePool(name string) (*CertificatePool, e
 			pool.Primary = keyset.primary.certificate
 		}
 
-		for id, item := range keyset.items {
-			if id == keyset.primary.id {
+		for _, id := range keyset.sortedIds() {
+			if keyset.primary != nil && id == keyset.primary.id {
 				continue
 			}
-			pool.Secondary = append(pool.Secondary, item.certificate)
+			pool.Secondary = append(pool.Secondary, keyset.items[id].certificate)
 		}
 	}
 	return pool, nil
@@ -526,6 +559,9 @@ func DeleteKeysetItem(client kopsinternalversion.KeysetInterface, name string, k
 	if !found {
 		return fmt.Errorf("KeysetItem %q not found in Keyset %q", id, name)
 	}
+	if keyset.Spec.PrimaryId == id {
+		keyset.Spec.PrimaryId = ""
+	}
 	if len(newKeys) == 0 {
 		if err := client.Delete(name, &v1.DeleteOptions{}); err != nil {
 			return fmt.Errorf("error deleting Keyset %q: %v", name, err)
@@ -650,6 +686,41 @@ func (c *ClientsetCAStore) DeleteKeysetItem(item *kops.Keyset, id string) error
 	}
 }
 
+// SetKeysetRotation implements CAStore::SetKeysetRotation
+func (c *ClientsetCAStore) SetKeysetRotation(name string, primaryId string, rotation *kops.KeysetRotation) error {
+	client := c.clientset.Keysets(c.namespace)
+	keyset, err := client.Get(name, v1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("error reading keyset %q: %v", name, err)
+	}
+
+	if primaryId != "" && !hasKeysetItem(keyset, primaryId) {
+		return fmt.Errorf("KeysetItem %q not found in Keyset %q", primaryId, name)
+	}
+	keyset.Spec.PrimaryId = primaryId
+	keyset.Spec.Rotation = rotation
+
+	if _, err := client.Update(keyset); err != nil {
+		return fmt.Errorf("error updating keyset %q: %v", name, err)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.cachedCaKeysets, name)
+
+	return nil
+}
+
+// hasKeysetItem returns true if the Keyset has an item with the id
+func hasKeysetItem(keyset *kops.Keyset, id string) bool {
+	for _, ki := range keyset.Spec.Keys {
+		if ki.Id == id {
+			return true
+		}
+	}
+	return false
+}
+
 // DeleteSSHCredential implements SSHCredentialStore::DeleteSSHCredential
 func (c *ClientsetCAStore) DeleteSSHCredential(item *kops.SSHCredential) error {
 	return c.deleteSSHCredential(item.Name)
@@ -34,9 +34,15 @@ type Cloud interface {
 	// DeleteInstance deletes a cloud instance
 	DeleteInstance(instance *cloudinstances.CloudInstanceGroupMember) error
 
+	// DeleteInstanceWithoutReplacement deletes a cloud instance and lowers the desired number of instances in its group, so that it is not replaced
+	DeleteInstanceWithoutReplacement(instance *cloudinstances.CloudInstanceGroupMember) error
+
 	// DeleteGroup deletes the cloud resources that make up a CloudInstanceGroup, including the instances
 	DeleteGroup(group *cloudinstances.CloudInstanceGroup) error
 
+	// ResizeGroup sets the desired number of instances in a CloudInstanceGroup
+	ResizeGroup(group *cloudinstances.CloudInstanceGroup, size int) error
+
 	// GetCloudGroups returns a map of cloud instances that back a kops cluster
 	GetCloudGroups(cluster *kops.Cluster, instancegroups []*kops.InstanceGroup, warnUnmatched bool, nodes []v1.Node) (map[string]*cloudinstances.CloudInstanceGroup, error)
 }
@@ -133,10 +133,18 @@ func (c *aliCloudImplementation) DeleteGroup(g *cloudinstances.CloudInstanceGrou
 	return errors.New("DeleteGroup not implemented on aliCloud")
 }
 
+func (c *aliCloudImplementation) ResizeGroup(g *cloudinstances.CloudInstanceGroup, size int) error {
+	return errors.New("ResizeGroup not implemented on aliCloud")
+}
+
 func (c *aliCloudImplementation) DeleteInstance(i *cloudinstances.CloudInstanceGroupMember) error {
 	return errors.New("DeleteInstance not implemented on aliCloud")
 }
 
+func (c *aliCloudImplementation) DeleteInstanceWithoutReplacement(i *cloudinstances.CloudInstanceGroupMember) error {
+	return errors.New("DeleteInstanceWithoutReplacement not implemented on aliCloud")
+}
+
 func (c *aliCloudImplementation) FindVPCInfo(id string) (*fi.VPCInfo, error) {
 	request := &ecs.DescribeVpcsArgs{
 		RegionId: common.Region(c.Region()),
@@ -247,6 +247,7 @@ func buildCloudInstanceGroup(c ALICloud, ig *kops.InstanceGroup, g ess.ScalingGr
 		InstanceGroup: ig,
 		MinSize:       g.MinSize,
 		MaxSize:       g.MaxSize,
+		TargetSize:    g.TotalCapacity,
 		Raw:           g,
 	}
 
@@ -18,6 +18,7 @@ package cloudup
 
 import (
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"path"
@@ -142,6 +143,9 @@ type ApplyClusterCmd struct {
 
 	// TaskMap is the map of tasks that we built (output)
 	TaskMap map[string]fi.Task
+
+	// DryRunOutput is where a dry run prints the changes it would make; defaults to stdout
+	DryRunOutput io.Writer
 }
 
 func (c *ApplyClusterCmd) Run() error {
@@ -429,10 +433,17 @@ func (c *ApplyClusterCmd) Run() error {
 				// ELB
 				"loadBalancer":           &awstasks.LoadBalancer{},
 				"loadBalancerAttachment": &awstasks.LoadBalancerAttachment{},
+				"networkLoadBalancer":    &awstasks.NetworkLoadBalancer{},
+				"targetGroup":            &awstasks.TargetGroup{},
+				"targetGroupAttachment":  &awstasks.TargetGroupAttachment{},
 
 				// Autoscaling
 				"autoscalingGroup":    &awstasks.AutoscalingGroup{},
 				"launchConfiguration": &awstasks.LaunchConfiguration{},
+				"lifecycleHook":       &awstasks.LifecycleHook{},
+
+				// SQS
+				"sqsQueue": &awstasks.SQSQueue{},
 
 				// Spotinst
 				"spotinstElastigroup": &spotinsttasks.Elastigroup{},
@@ -586,6 +597,7 @@ func (c *ApplyClusterCmd) Run() error {
 					Lifecycle:    &clusterLifecycle,
 					assetBuilder: assetBuilder,
 					cluster:      cluster,
+					modelContext: modelContext,
 					templates:    templates,
 				},
 				&model.PKIModelBuilder{
@@ -725,6 +737,12 @@ func (c *ApplyClusterCmd) Run() error {
 				SecurityLifecycle: &securityLifecycle,
 			})
 		}
+
+		l.Builders = append(l.Builders, &awsmodel.LifecycleHookModelBuilder{
+			AWSModelContext:   awsModelContext,
+			Lifecycle:         &clusterLifecycle,
+			SecurityLifecycle: &securityLifecycle,
+		})
 	case kops.CloudProviderDO:
 		doModelContext := &domodel.DOModelContext{
 			KopsModelContext: modelContext,
@@ -856,7 +874,11 @@ func (c *ApplyClusterCmd) Run() error {
 		shouldPrecreateDNS = false
 
 	case TargetDryRun:
-		target = fi.NewDryRunTarget(assetBuilder, os.Stdout)
+		out := c.DryRunOutput
+		if out == nil {
+			out = os.Stdout
+		}
+		target = fi.NewDryRunTarget(assetBuilder, out)
 		dryRun = true
 
 		// Avoid making changes on a dry-run
@@ -48,6 +48,19 @@ type AutoscalingGroup struct {
 	Metrics     []string
 
 	LaunchConfiguration *LaunchConfiguration
+	// LaunchTemplate is used instead of LaunchConfiguration if it is set; the group always uses its latest version
+	LaunchTemplate *LaunchTemplate
+
+	// MixedInstanceOverrides is the list of instance types of a mixed instances policy; the policy is used if it is set
+	MixedInstanceOverrides []string
+	// MixedOnDemandBase is the number of instances that are always on-demand
+	MixedOnDemandBase *int64
+	// MixedOnDemandAboveBase is the percentage of instances above MixedOnDemandBase that are on-demand
+	MixedOnDemandAboveBase *int64
+	// MixedSpotAllocationStrategy is how spot instances are spread across the instance types
+	MixedSpotAllocationStrategy *string
+	// MixedSpotMaxPrice is the maximum price for spot instances, defaulting to the on-demand price
+	MixedSpotMaxPrice *string
 
 	SuspendProcesses *[]string
 }
@@ -136,7 +149,33 @@ func (e *AutoscalingGroup) Find(c *fi.Context) (*AutoscalingGroup, error) {
 		}
 	}
 
-	if fi.StringValue(g.LaunchConfigurationName) == "" {
+	if g.MixedInstancesPolicy != nil {
+		policy := g.MixedInstancesPolicy
+		// A group that should no longer have a mixed instances policy is left without a launch template,
+		// so that it is updated to use the launch template directly, which removes the policy
+		if policy.LaunchTemplate != nil && e.UseMixedInstancesPolicy() {
+			if spec := policy.LaunchTemplate.LaunchTemplateSpecification; spec != nil {
+				actual.LaunchTemplate = &LaunchTemplate{
+					ID:   spec.LaunchTemplateId,
+					Name: spec.LaunchTemplateName,
+				}
+			}
+			for _, override := range policy.LaunchTemplate.Overrides {
+				actual.MixedInstanceOverrides = append(actual.MixedInstanceOverrides, aws.StringValue(override.InstanceType))
+			}
+		}
+		if policy.InstancesDistribution != nil {
+			actual.MixedOnDemandBase = policy.InstancesDistribution.OnDemandBaseCapacity
+			actual.MixedOnDemandAboveBase = policy.InstancesDistribution.OnDemandPercentageAboveBaseCapacity
+			actual.MixedSpotAllocationStrategy = policy.InstancesDistribution.SpotAllocationStrategy
+			actual.MixedSpotMaxPrice = policy.InstancesDistribution.SpotMaxPrice
+		}
+	} else if g.LaunchTemplate != nil {
+		actual.LaunchTemplate = &LaunchTemplate{
+			ID:   g.LaunchTemplate.LaunchTemplateId,
+			Name: g.LaunchTemplate.LaunchTemplateName,
+		}
+	} else if fi.StringValue(g.LaunchConfigurationName) == "" {
 		glog.Warningf("autoscaling Group %q had no LaunchConfiguration", fi.StringValue(g.AutoScalingGroupName))
 	} else {
 		actual.LaunchConfiguration = &LaunchConfiguration{ID: g.LaunchConfigurationName}
@@ -175,6 +214,16 @@ func (e *AutoscalingGroup) Run(c *fi.Context) error {
 }
 
 func (s *AutoscalingGroup) CheckChanges(a, e, changes *AutoscalingGroup) error {
+	if e.LaunchConfiguration == nil && e.LaunchTemplate == nil {
+		return fi.RequiredField("LaunchConfiguration")
+	}
+	if e.LaunchConfiguration != nil && e.LaunchTemplate != nil {
+		return fmt.Errorf("only one of LaunchConfiguration and LaunchTemplate can be set")
+	}
+	if e.UseMixedInstancesPolicy() && e.LaunchTemplate == nil {
+		return fmt.Errorf("a mixed instances policy requires a LaunchTemplate")
+	}
+
 	if a != nil {
 		if e.Name == nil {
 			return fi.RequiredField("Name")
@@ -208,7 +257,13 @@ func (_ *AutoscalingGroup) RenderAWS(t *awsup.AWSAPITarget, a, e, changes *Autos
 
 		request := &autoscaling.CreateAutoScalingGroupInput{}
 		request.AutoScalingGroupName = e.Name
-		request.LaunchConfigurationName = e.LaunchConfiguration.ID
+		if e.UseMixedInstancesPolicy() {
+			request.MixedInstancesPolicy = e.mixedInstancesPolicy()
+		} else if e.LaunchTemplate != nil {
+			request.LaunchTemplate = e.launchTemplateSpecification()
+		} else {
+			request.LaunchConfigurationName = e.LaunchConfiguration.ID
+		}
 		request.MinSize = e.MinSize
 		request.MaxSize = e.MaxSize
 
@@ -254,7 +309,27 @@ func (_ *AutoscalingGroup) RenderAWS(t *awsup.AWSAPITarget, a, e, changes *Autos
 			AutoScalingGroupName: e.Name,
 		}
 
-		if changes.LaunchConfiguration != nil {
+		if e.UseMixedInstancesPolicy() {
+			if changes.LaunchTemplate != nil || changes.MixedInstanceOverrides != nil || changes.MixedOnDemandBase != nil ||
+				changes.MixedOnDemandAboveBase != nil || changes.MixedSpotAllocationStrategy != nil || changes.MixedSpotMaxPrice != nil {
+				request.MixedInstancesPolicy = e.mixedInstancesPolicy()
+				changes.LaunchTemplate = nil
+				changes.MixedInstanceOverrides = nil
+				changes.MixedOnDemandBase = nil
+				changes.MixedOnDemandAboveBase = nil
+				changes.MixedSpotAllocationStrategy = nil
+				changes.MixedSpotMaxPrice = nil
+			}
+			// Switching to a mixed instances policy replaces the launch configuration
+			changes.LaunchConfiguration = nil
+		} else if e.LaunchTemplate != nil {
+			if changes.LaunchTemplate != nil {
+				request.LaunchTemplate = e.launchTemplateSpecification()
+				changes.LaunchTemplate = nil
+			}
+			// Switching to a launch template replaces the launch configuration
+			changes.LaunchConfiguration = nil
+		} else if changes.LaunchConfiguration != nil {
 			request.LaunchConfigurationName = e.LaunchConfiguration.ID
 			changes.LaunchConfiguration = nil
 		}
@@ -360,6 +435,52 @@ func (_ *AutoscalingGroup) RenderAWS(t *awsup.AWSAPITarget, a, e, changes *Autos
 	return nil // We have
 }
 
+// launchTemplateSpecification references the latest version of the launch template, so that the group picks up
+// new versions without being changed itself
+func (e *AutoscalingGroup) launchTemplateSpecification() *autoscaling.LaunchTemplateSpecification {
+	return &autoscaling.LaunchTemplateSpecification{
+		LaunchTemplateId: e.LaunchTemplate.ID,
+		Version:          aws.String("$Latest"),
+	}
+}
+
+// mixedInstancesPolicy builds the mixed instances policy of the group, which uses the latest version of the launch template
+func (e *AutoscalingGroup) mixedInstancesPolicy() *autoscaling.MixedInstancesPolicy {
+	policy := &autoscaling.MixedInstancesPolicy{
+		LaunchTemplate: &autoscaling.LaunchTemplate{
+			LaunchTemplateSpecification: e.launchTemplateSpecification(),
+		},
+		InstancesDistribution: &autoscaling.InstancesDistribution{
+			OnDemandBaseCapacity:                e.MixedOnDemandBase,
+			OnDemandPercentageAboveBaseCapacity: e.MixedOnDemandAboveBase,
+			SpotAllocationStrategy:              e.MixedSpotAllocationStrategy,
+			SpotMaxPrice:                        e.MixedSpotMaxPrice,
+		},
+	}
+	for _, instanceType := range e.MixedInstanceOverrides {
+		policy.LaunchTemplate.Overrides = append(policy.LaunchTemplate.Overrides, &autoscaling.LaunchTemplateOverrides{
+			InstanceType: aws.String(instanceType),
+		})
+	}
+	return policy
+}
+
+// UseMixedInstancesPolicy is true if the group has a mixed instances policy
+func (e *AutoscalingGroup) UseMixedInstancesPolicy() bool {
+	return len(e.MixedInstanceOverrides) != 0
+}
+
+// securityGroups returns the security groups of the launch configuration or launch template
+func (e *AutoscalingGroup) securityGroups() []*SecurityGroup {
+	if e.LaunchTemplate != nil {
+		return e.LaunchTemplate.SecurityGroups
+	}
+	if e.LaunchConfiguration != nil {
+		return e.LaunchConfiguration.SecurityGroups
+	}
+	return nil
+}
+
 // processCompare returns processes that exist in a but not in b
 func processCompare(a *[]string, b *[]string) []*string {
 	notInB := []*string{}
@@ -402,27 +523,90 @@ type terraformASGTag struct {
 	Value             *string `json:"value"`
 	PropagateAtLaunch *bool   `json:"propagate_at_launch"`
 }
+type terraformAutoscalingLaunchTemplateSpecification struct {
+	ID      *terraform.Literal `json:"id,omitempty"`
+	Version *terraform.Literal `json:"version,omitempty"`
+}
+
+type terraformAutoscalingLaunchTemplateOverride struct {
+	InstanceType *string `json:"instance_type,omitempty"`
+}
+
+type terraformAutoscalingMixedInstancesLaunchTemplate struct {
+	LaunchTemplateSpecification []*terraformAutoscalingLaunchTemplateSpecification `json:"launch_template_specification,omitempty"`
+	Override                    []*terraformAutoscalingLaunchTemplateOverride      `json:"override,omitempty"`
+}
+
+type terraformAutoscalingInstancesDistribution struct {
+	OnDemandBaseCapacity                *int64  `json:"on_demand_base_capacity,omitempty"`
+	OnDemandPercentageAboveBaseCapacity *int64  `json:"on_demand_percentage_above_base_capacity,omitempty"`
+	SpotAllocationStrategy              *string `json:"spot_allocation_strategy,omitempty"`
+	SpotMaxPrice                        *string `json:"spot_max_price,omitempty"`
+}
+
+type terraformAutoscalingMixedInstancesPolicy struct {
+	LaunchTemplate        []*terraformAutoscalingMixedInstancesLaunchTemplate `json:"launch_template,omitempty"`
+	InstancesDistribution []*terraformAutoscalingInstancesDistribution        `json:"instances_distribution,omitempty"`
+}
+
 type terraformAutoscalingGroup struct {
-	Name                    *string              `json:"name,omitempty"`
-	LaunchConfigurationName *terraform.Literal   `json:"launch_configuration,omitempty"`
-	MaxSize                 *int64               `json:"max_size,omitempty"`
-	MinSize                 *int64               `json:"min_size,omitempty"`
-	VPCZoneIdentifier       []*terraform.Literal `json:"vpc_zone_identifier,omitempty"`
-	Tags                    []*terraformASGTag   `json:"tag,omitempty"`
-	MetricsGranularity      *string              `json:"metrics_granularity,omitempty"`
-	EnabledMetrics          []*string            `json:"enabled_metrics,omitempty"`
-	SuspendedProcesses      []*string            `json:"suspended_processes,omitempty"`
+	Name                    *string                                          `json:"name,omitempty"`
+	LaunchConfigurationName *terraform.Literal                               `json:"launch_configuration,omitempty"`
+	LaunchTemplate          *terraformAutoscalingLaunchTemplateSpecification `json:"launch_template,omitempty"`
+	MixedInstancesPolicy    []*terraformAutoscalingMixedInstancesPolicy      `json:"mixed_instances_policy,omitempty"`
+	MaxSize                 *int64                                           `json:"max_size,omitempty"`
+	MinSize                 *int64                                           `json:"min_size,omitempty"`
+	VPCZoneIdentifier       []*terraform.Literal                             `json:"vpc_zone_identifier,omitempty"`
+	Tags                    []*terraformASGTag                               `json:"tag,omitempty"`
+	MetricsGranularity      *string                                          `json:"metrics_granularity,omitempty"`
+	EnabledMetrics          []*string                                        `json:"enabled_metrics,omitempty"`
+	SuspendedProcesses      []*string                                        `json:"suspended_processes,omitempty"`
 }
 
 func (_ *AutoscalingGroup) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *AutoscalingGroup) error {
 
 	tf := &terraformAutoscalingGroup{
-		Name:                    e.Name,
-		MinSize:                 e.MinSize,
-		MaxSize:                 e.MaxSize,
-		LaunchConfigurationName: e.LaunchConfiguration.TerraformLink(),
-		MetricsGranularity:      e.Granularity,
-		EnabledMetrics:          aws.StringSlice(e.Metrics),
+		Name:               e.Name,
+		MinSize:            e.MinSize,
+		MaxSize:            e.MaxSize,
+		MetricsGranularity: e.Granularity,
+		EnabledMetrics:     aws.StringSlice(e.Metrics),
+	}
+
+	if e.UseMixedInstancesPolicy() {
+		launchTemplate := &terraformAutoscalingMixedInstancesLaunchTemplate{
+			LaunchTemplateSpecification: []*terraformAutoscalingLaunchTemplateSpecification{
+				{
+					ID:      e.LaunchTemplate.TerraformLink(),
+					Version: e.LaunchTemplate.TerraformLinkLatestVersion(),
+				},
+			},
+		}
+		for _, instanceType := range e.MixedInstanceOverrides {
+			launchTemplate.Override = append(launchTemplate.Override, &terraformAutoscalingLaunchTemplateOverride{
+				InstanceType: fi.String(instanceType),
+			})
+		}
+		tf.MixedInstancesPolicy = []*terraformAutoscalingMixedInstancesPolicy{
+			{
+				LaunchTemplate: []*terraformAutoscalingMixedInstancesLaunchTemplate{launchTemplate},
+				InstancesDistribution: []*terraformAutoscalingInstancesDistribution{
+					{
+						OnDemandBaseCapacity:                e.MixedOnDemandBase,
+						OnDemandPercentageAboveBaseCapacity: e.MixedOnDemandAboveBase,
+						SpotAllocationStrategy:              e.MixedSpotAllocationStrategy,
+						SpotMaxPrice:                        e.MixedSpotMaxPrice,
+					},
+				},
+			},
+		}
+	} else if e.LaunchTemplate != nil {
+		tf.LaunchTemplate = &terraformAutoscalingLaunchTemplateSpecification{
+			ID:      e.LaunchTemplate.TerraformLink(),
+			Version: e.LaunchTemplate.TerraformLinkLatestVersion(),
+		}
+	} else {
+		tf.LaunchConfigurationName = e.LaunchConfiguration.TerraformLink()
 	}
 
 	for _, s := range e.Subnets {
@@ -445,7 +629,7 @@ func (_ *AutoscalingGroup) RenderTerraform(t *terraform.TerraformTarget, a, e, c
 		})
 	}
 
-	if e.LaunchConfiguration != nil {
+	if e.LaunchConfiguration != nil || e.LaunchTemplate != nil {
 		// Create TF output variable with security group ids
 		// This is in the launch configuration, but the ASG has the information about the instance group type
 
@@ -461,7 +645,7 @@ func (_ *AutoscalingGroup) RenderTerraform(t *terraform.TerraformTarget, a, e, c
 		}
 
 		if role != "" {
-			for _, sg := range e.LaunchConfiguration.SecurityGroups {
+			for _, sg := range e.securityGroups() {
 				if err := t.AddOutputVariableArray(role+"_security_group_ids", sg.TerraformLink()); err != nil {
 					return err
 				}
@@ -505,14 +689,42 @@ type cloudformationASGMetricsCollection struct {
 	Granularity *string   `json:"Granularity"`
 	Metrics     []*string `json:"Metrics"`
 }
+type cloudformationAutoscalingLaunchTemplateSpecification struct {
+	LaunchTemplateID *cloudformation.Literal `json:"LaunchTemplateId,omitempty"`
+	Version          *cloudformation.Literal `json:"Version,omitempty"`
+}
+
+type cloudformationAutoscalingLaunchTemplateOverride struct {
+	InstanceType *string `json:"InstanceType,omitempty"`
+}
+
+type cloudformationAutoscalingMixedInstancesLaunchTemplate struct {
+	LaunchTemplateSpecification *cloudformationAutoscalingLaunchTemplateSpecification `json:"LaunchTemplateSpecification,omitempty"`
+	Overrides                   []*cloudformationAutoscalingLaunchTemplateOverride    `json:"Overrides,omitempty"`
+}
+
+type cloudformationAutoscalingInstancesDistribution struct {
+	OnDemandBaseCapacity                *int64  `json:"OnDemandBaseCapacity,omitempty"`
+	OnDemandPercentageAboveBaseCapacity *int64  `json:"OnDemandPercentageAboveBaseCapacity,omitempty"`
+	SpotAllocationStrategy              *string `json:"SpotAllocationStrategy,omitempty"`
+	SpotMaxPrice                        *string `json:"SpotMaxPrice,omitempty"`
+}
+
+type cloudformationAutoscalingMixedInstancesPolicy struct {
+	LaunchTemplate        *cloudformationAutoscalingMixedInstancesLaunchTemplate `json:"LaunchTemplate,omitempty"`
+	InstancesDistribution *cloudformationAutoscalingInstancesDistribution        `json:"InstancesDistribution,omitempty"`
+}
+
 type cloudformationAutoscalingGroup struct {
-	Name                    *string                               `json:"AutoScalingGroupName,omitempty"`
-	LaunchConfigurationName *cloudformation.Literal               `json:"LaunchConfigurationName,omitempty"`
-	MaxSize                 *int64                                `json:"MaxSize,omitempty"`
-	MinSize                 *int64                                `json:"MinSize,omitempty"`
-	VPCZoneIdentifier       []*cloudformation.Literal             `json:"VPCZoneIdentifier,omitempty"`
-	Tags                    []*cloudformationASGTag               `json:"Tags,omitempty"`
-	MetricsCollection       []*cloudformationASGMetricsCollection `json:"MetricsCollection,omitempty"`
+	Name                    *string                                               `json:"AutoScalingGroupName,omitempty"`
+	LaunchConfigurationName *cloudformation.Literal                               `json:"LaunchConfigurationName,omitempty"`
+	LaunchTemplate          *cloudformationAutoscalingLaunchTemplateSpecification `json:"LaunchTemplate,omitempty"`
+	MixedInstancesPolicy    *cloudformationAutoscalingMixedInstancesPolicy        `json:"MixedInstancesPolicy,omitempty"`
+	MaxSize                 *int64                                                `json:"MaxSize,omitempty"`
+	MinSize                 *int64                                                `json:"MinSize,omitempty"`
+	VPCZoneIdentifier       []*cloudformation.Literal                             `json:"VPCZoneIdentifier,omitempty"`
+	Tags                    []*cloudformationASGTag                               `json:"Tags,omitempty"`
+	MetricsCollection       []*cloudformationASGMetricsCollection                 `json:"MetricsCollection,omitempty"`
 
 	LoadBalancerNames []*cloudformation.Literal `json:"LoadBalancerNames,omitempty"`
 	TargetGroupARNs   []*cloudformation.Literal `json:"TargetGroupARNs,omitempty"`
@@ -529,7 +741,36 @@ func (_ *AutoscalingGroup) RenderCloudformation(t *cloudformation.Cloudformation
 				Metrics:     aws.StringSlice(e.Metrics),
 			},
 		},
-		LaunchConfigurationName: e.LaunchConfiguration.CloudformationLink(),
+	}
+
+	if e.UseMixedInstancesPolicy() {
+		launchTemplate := &cloudformationAutoscalingMixedInstancesLaunchTemplate{
+			LaunchTemplateSpecification: &cloudformationAutoscalingLaunchTemplateSpecification{
+				LaunchTemplateID: e.LaunchTemplate.CloudformationLink(),
+				Version:          e.LaunchTemplate.CloudformationLinkLatestVersion(),
+			},
+		}
+		for _, instanceType := range e.MixedInstanceOverrides {
+			launchTemplate.Overrides = append(launchTemplate.Overrides, &cloudformationAutoscalingLaunchTemplateOverride{
+				InstanceType: fi.String(instanceType),
+			})
+		}
+		tf.MixedInstancesPolicy = &cloudformationAutoscalingMixedInstancesPolicy{
+			LaunchTemplate: launchTemplate,
+			InstancesDistribution: &cloudformationAutoscalingInstancesDistribution{
+				OnDemandBaseCapacity:                e.MixedOnDemandBase,
+				OnDemandPercentageAboveBaseCapacity: e.MixedOnDemandAboveBase,
+				SpotAllocationStrategy:              e.MixedSpotAllocationStrategy,
+				SpotMaxPrice:                        e.MixedSpotMaxPrice,
+			},
+		}
+	} else if e.LaunchTemplate != nil {
+		tf.LaunchTemplate = &cloudformationAutoscalingLaunchTemplateSpecification{
+			LaunchTemplateID: e.LaunchTemplate.CloudformationLink(),
+			Version:          e.LaunchTemplate.CloudformationLinkLatestVersion(),
+		}
+	} else {
+		tf.LaunchConfigurationName = e.LaunchConfiguration.CloudformationLink()
 	}
 
 	for _, s := range e.Subnets {
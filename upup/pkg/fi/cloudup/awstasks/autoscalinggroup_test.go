@@ -17,13 +17,19 @@ limitations under the License.
 package awstasks
 
 import (
+	"reflect"
 	"sort"
 	"testing"
 
+	"k8s.io/kops/cloudmock/aws/mockautoscaling"
+	"k8s.io/kops/cloudmock/aws/mockec2"
 	"k8s.io/kops/pkg/diff"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/awsup"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/ghodss/yaml"
 )
 
@@ -196,3 +202,105 @@ func TestProcessCompare(t *testing.T) {
 		}
 	}
 }
+
+func TestAutoscalingGroupMixedInstancesPolicy(t *testing.T) {
+	cloud := awsup.BuildMockAWSCloud("us-east-1", "abc")
+	mockEC2 := &mockec2.MockEC2{}
+	cloud.MockEC2 = mockEC2
+	mockAutoscaling := &mockautoscaling.MockAutoscaling{}
+	cloud.MockAutoscaling = mockAutoscaling
+
+	mockEC2.Images = append(mockEC2.Images, &ec2.Image{
+		CreationDate:   aws.String("2016-10-21T20:07:19.000Z"),
+		ImageId:        aws.String("ami-12345678"),
+		Name:           aws.String("k8s-1.4-debian-jessie-amd64-hvm-ebs-2016-10-21"),
+		OwnerId:        aws.String(awsup.WellKnownAccountKopeio),
+		RootDeviceName: aws.String("/dev/xvda"),
+	})
+
+	// We define a function so we can rebuild the tasks, because we modify in-place when running
+	buildTasks := func(instanceTypes []string) map[string]fi.Task {
+		vpc1 := &VPC{
+			Name: s("vpc1"),
+			CIDR: s("172.20.0.0/16"),
+			Tags: map[string]string{"Name": "vpc1"},
+		}
+		subnet1 := &Subnet{
+			Name: s("subnet1"),
+			VPC:  vpc1,
+			CIDR: s("172.20.1.0/24"),
+			Tags: map[string]string{"Name": "subnet1"},
+		}
+		lt := &LaunchTemplate{
+			Name:           s("lt1"),
+			ImageID:        s("ami-12345678"),
+			InstanceType:   s("m3.medium"),
+			SecurityGroups: []*SecurityGroup{},
+		}
+		asg := &AutoscalingGroup{
+			Name:             s("asg1"),
+			LaunchTemplate:   lt,
+			MinSize:          aws.Int64(1),
+			MaxSize:          aws.Int64(2),
+			Subnets:          []*Subnet{subnet1},
+			Granularity:      s("1Minute"),
+			Metrics:          []string{"GroupDesiredCapacity"},
+			SuspendProcesses: &[]string{},
+			Tags:             map[string]string{},
+			MixedInstanceOverrides: instanceTypes,
+			MixedOnDemandBase:      aws.Int64(1),
+		}
+		return map[string]fi.Task{
+			"vpc1":    vpc1,
+			"subnet1": subnet1,
+			"lt1":     lt,
+			"asg1":    asg,
+		}
+	}
+
+	for _, instanceTypes := range [][]string{{"m4.large", "m5.large"}, {"m5.large", "c5.large"}} {
+		{
+			allTasks := buildTasks(instanceTypes)
+
+			target := &awsup.AWSAPITarget{
+				Cloud: cloud,
+			}
+
+			context, err := fi.NewContext(target, nil, cloud, nil, nil, nil, true, allTasks)
+			if err != nil {
+				t.Fatalf("error building context: %v", err)
+			}
+
+			if err := context.RunTasks(testRunTasksOptions); err != nil {
+				t.Fatalf("unexpected error during Run: %v", err)
+			}
+
+			g := mockAutoscaling.Groups["asg1"]
+			if g == nil {
+				t.Fatalf("AutoscalingGroup was not created")
+			}
+			if g.LaunchTemplate != nil || g.MixedInstancesPolicy == nil {
+				t.Fatalf("Expected AutoscalingGroup to use a mixed instances policy; found %v", g)
+			}
+			policy := g.MixedInstancesPolicy
+			if aws.StringValue(policy.LaunchTemplate.LaunchTemplateSpecification.LaunchTemplateId) != fi.StringValue(allTasks["lt1"].(*LaunchTemplate).ID) {
+				t.Fatalf("Unexpected launch template in mixed instances policy: %v", policy.LaunchTemplate)
+			}
+			var actual []string
+			for _, override := range policy.LaunchTemplate.Overrides {
+				actual = append(actual, aws.StringValue(override.InstanceType))
+			}
+			if !reflect.DeepEqual(actual, instanceTypes) {
+				t.Fatalf("Unexpected instance types: expected=%v actual=%v", instanceTypes, actual)
+			}
+			if aws.Int64Value(policy.InstancesDistribution.OnDemandBaseCapacity) != 1 {
+				t.Fatalf("Unexpected instances distribution: %v", policy.InstancesDistribution)
+			}
+		}
+
+		{
+			allTasks := buildTasks(instanceTypes)
+			checkNoChanges(t, cloud, allTasks)
+		}
+	}
+}
@@ -83,6 +83,22 @@ func (i *BlockDeviceMapping) ToAutoscaling(deviceName string) *autoscaling.Block
 	return o
 }
 
+func (i *BlockDeviceMapping) ToLaunchTemplate(deviceName string) *ec2.LaunchTemplateBlockDeviceMappingRequest {
+	o := &ec2.LaunchTemplateBlockDeviceMappingRequest{}
+	o.DeviceName = aws.String(deviceName)
+	o.VirtualName = i.VirtualName
+
+	if i.EbsDeleteOnTermination != nil || i.EbsVolumeSize != nil || i.EbsVolumeType != nil {
+		o.Ebs = &ec2.LaunchTemplateEbsBlockDeviceRequest{}
+		o.Ebs.DeleteOnTermination = i.EbsDeleteOnTermination
+		o.Ebs.VolumeSize = i.EbsVolumeSize
+		o.Ebs.VolumeType = i.EbsVolumeType
+		o.Ebs.Iops = i.EbsVolumeIops
+	}
+
+	return o
+}
+
 var _ fi.HasDependencies = &BlockDeviceMapping{}
 
 func (f *BlockDeviceMapping) GetDependencies(tasks map[string]fi.Task) []fi.Task {
@@ -40,6 +40,8 @@ type DNSName struct {
 	ResourceType *string
 
 	TargetLoadBalancer *LoadBalancer
+	// TargetNetworkLoadBalancer is set instead of TargetLoadBalancer when the name is an alias for an NLB
+	TargetNetworkLoadBalancer *NetworkLoadBalancer
 }
 
 func (e *DNSName) Find(c *fi.Context) (*DNSName, error) {
@@ -115,6 +117,20 @@ func (e *DNSName) Find(c *fi.Context) (*DNSName, error) {
 			if err != nil {
 				return nil, fmt.Errorf("error mapping DNSName %q to LoadBalancer: %v", dnsName, err)
 			}
+			if lb == nil && e.TargetNetworkLoadBalancer != nil {
+				nlb, err := findNetworkLoadBalancerByAlias(cloud, found.AliasTarget)
+				if err != nil {
+					return nil, fmt.Errorf("error mapping DNSName %q to NLB: %v", dnsName, err)
+				}
+				if nlb != nil {
+					actual.TargetNetworkLoadBalancer = &NetworkLoadBalancer{
+						Name:             e.TargetNetworkLoadBalancer.Name,
+						LoadBalancerName: nlb.LoadBalancerName,
+						ARN:              nlb.LoadBalancerArn,
+					}
+					return actual, nil
+				}
+			}
 			if lb == nil {
 				glog.Warningf("Unable to find load balancer with DNS name: %q", dnsName)
 			} else {
@@ -162,6 +178,13 @@ func (_ *DNSName) RenderAWS(t *awsup.AWSAPITarget, a, e, changes *DNSName) error
 			HostedZoneId:         e.TargetLoadBalancer.HostedZoneId,
 		}
 	}
+	if e.TargetNetworkLoadBalancer != nil {
+		rrs.AliasTarget = &route53.AliasTarget{
+			DNSName:              e.TargetNetworkLoadBalancer.DNSName,
+			EvaluateTargetHealth: aws.Bool(false),
+			HostedZoneId:         e.TargetNetworkLoadBalancer.HostedZoneId,
+		}
+	}
 
 	change := &route53.Change{
 		Action:            aws.String("UPSERT"),
@@ -217,6 +240,13 @@ func (_ *DNSName) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *D
 			ZoneID:               e.TargetLoadBalancer.TerraformLink("zone_id"),
 		}
 	}
+	if e.TargetNetworkLoadBalancer != nil {
+		tf.Alias = &terraformAlias{
+			Name:                 e.TargetNetworkLoadBalancer.TerraformLink("dns_name"),
+			EvaluateTargetHealth: aws.Bool(false),
+			ZoneID:               e.TargetNetworkLoadBalancer.TerraformLink("zone_id"),
+		}
+	}
 
 	return t.RenderResource("aws_route53_record", *e.Name, tf)
 }
@@ -255,6 +285,13 @@ func (_ *DNSName) RenderCloudformation(t *cloudformation.CloudformationTarget, a
 			ZoneID:               e.TargetLoadBalancer.CloudformationAttrCanonicalHostedZoneNameID(),
 		}
 	}
+	if e.TargetNetworkLoadBalancer != nil {
+		cf.AliasTarget = &cloudformationAlias{
+			DNSName:              e.TargetNetworkLoadBalancer.CloudformationAttrDNSName(),
+			EvaluateTargetHealth: aws.Bool(false),
+			ZoneID:               e.TargetNetworkLoadBalancer.CloudformationAttrCanonicalHostedZoneID(),
+		}
+	}
 
 	return t.RenderResource("AWS::Route53::RecordSet", *e.Name, cf)
 }
@@ -132,12 +132,18 @@ func (e *ElasticIP) find(cloud awsup.AWSCloud) (*ElasticIP, error) {
 		glog.V(2).Infof("Found public IP via tag: %v", *publicIP)
 	}
 
-	if publicIP != nil || allocationID != nil {
+	// Find via the Name tag, for ElasticIPs that are not associated with another resource we create (e.g. those of a network load balancer)
+	nameTag := e.Tags["Name"]
+	findByName := allocationID == nil && publicIP == nil && e.AssociatedNatGatewayRouteTable == nil && e.TagOnSubnet == nil && nameTag != ""
+
+	if publicIP != nil || allocationID != nil || findByName {
 		request := &ec2.DescribeAddressesInput{}
 		if allocationID != nil {
 			request.AllocationIds = []*string{allocationID}
 		} else if publicIP != nil {
 			request.Filters = []*ec2.Filter{awsup.NewEC2Filter("public-ip", *publicIP)}
+		} else {
+			request.Filters = []*ec2.Filter{awsup.NewEC2Filter("tag:Name", nameTag)}
 		}
 
 		response, err := cloud.EC2().DescribeAddresses(request)
@@ -41,6 +41,7 @@ type IAMRole struct {
 	Lifecycle *fi.Lifecycle
 
 	Name               *string
+	ARN                *string
 	RolePolicyDocument *fi.ResourceHolder // "inline" IAM policy
 
 	// ExportWithId will expose the name & ARN for reuse as part of a larger system.  Only supported by terraform currently.
@@ -72,6 +73,7 @@ func (e *IAMRole) Find(c *fi.Context) (*IAMRole, error) {
 	actual := &IAMRole{}
 	actual.ID = r.RoleId
 	actual.Name = r.RoleName
+	actual.ARN = r.Arn
 	if r.AssumeRolePolicyDocument != nil {
 		// The AssumeRolePolicyDocument is URI encoded (?)
 		actualPolicy := *r.AssumeRolePolicyDocument
@@ -109,6 +111,7 @@ func (e *IAMRole) Find(c *fi.Context) (*IAMRole, error) {
 
 	glog.V(2).Infof("found matching IAMRole %q", aws.StringValue(actual.ID))
 	e.ID = actual.ID
+	e.ARN = actual.ARN
 
 	// Avoid spurious changes
 	actual.ExportWithID = e.ExportWithID
@@ -153,6 +156,7 @@ func (_ *IAMRole) RenderAWS(t *awsup.AWSAPITarget, a, e, changes *IAMRole) error
 		}
 
 		e.ID = response.Role.RoleId
+		e.ARN = response.Role.Arn
 	} else {
 		if changes.RolePolicyDocument != nil {
 			glog.V(2).Infof("Updating IAMRole AssumeRolePolicy %q", *e.Name)
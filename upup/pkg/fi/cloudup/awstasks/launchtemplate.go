@@ -0,0 +1,824 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package awstasks
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/awsup"
+	"k8s.io/kops/upup/pkg/fi/cloudup/cloudformation"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
+)
+
+// LaunchTemplate is an EC2 launch template, which is used by an AutoscalingGroup in place of a LaunchConfiguration.
+// Unlike a LaunchConfiguration it can be changed: each change creates a new version, which becomes the default version.
+//
+//go:generate fitask -type=LaunchTemplate
+type LaunchTemplate struct {
+	Name      *string
+	Lifecycle *fi.Lifecycle
+
+	UserData *fi.ResourceHolder
+
+	ImageID            *string
+	InstanceType       *string
+	SSHKey             *SSHKey
+	SecurityGroups     []*SecurityGroup
+	AssociatePublicIP  *bool
+	IAMInstanceProfile *IAMInstanceProfile
+	InstanceMonitoring *bool
+
+	// RootVolumeSize is the size of the EBS root volume to use, in GB
+	RootVolumeSize *int64
+	// RootVolumeType is the type of the EBS root volume to use (e.g. gp2)
+	RootVolumeType *string
+	// If volume type is io1, then we need to specify the number of Iops.
+	RootVolumeIops *int64
+	// RootVolumeOptimization enables EBS optimization for an instance
+	RootVolumeOptimization *bool
+
+	// SpotPrice is set to the spot-price bid if this is a spot pricing request
+	SpotPrice string
+
+	// Tenancy. Can be either default or dedicated.
+	Tenancy *string
+
+	// ID is the id of the launch template
+	ID *string
+	// LatestVersion is the number of the latest version of the launch template
+	LatestVersion *int64
+}
+
+var _ fi.CompareWithID = &LaunchTemplate{}
+
+var _ fi.ProducesDeletions = &LaunchTemplate{}
+
+func (e *LaunchTemplate) CompareWithID() *string {
+	return e.ID
+}
+
+// findLaunchTemplate returns the launch template with the given name, or nil if there is none
+func findLaunchTemplate(cloud awsup.AWSCloud, name string) (*ec2.LaunchTemplate, error) {
+	request := &ec2.DescribeLaunchTemplatesInput{
+		Filters: []*ec2.Filter{awsup.NewEC2Filter("launch-template-name", name)},
+	}
+
+	response, err := cloud.EC2().DescribeLaunchTemplates(request)
+	if err != nil {
+		return nil, fmt.Errorf("error listing LaunchTemplates: %v", err)
+	}
+
+	var found []*ec2.LaunchTemplate
+	for _, lt := range response.LaunchTemplates {
+		if aws.StringValue(lt.LaunchTemplateName) == name {
+			found = append(found, lt)
+		}
+	}
+	if len(found) == 0 {
+		return nil, nil
+	}
+	if len(found) != 1 {
+		return nil, fmt.Errorf("found multiple LaunchTemplates with name %q", name)
+	}
+	return found[0], nil
+}
+
+// findLaunchTemplateVersions returns the versions of the launch template, sorted by version number (ascending)
+func findLaunchTemplateVersions(cloud awsup.AWSCloud, id string, versions ...string) ([]*ec2.LaunchTemplateVersion, error) {
+	request := &ec2.DescribeLaunchTemplateVersionsInput{
+		LaunchTemplateId: aws.String(id),
+		Versions:         aws.StringSlice(versions),
+	}
+
+	var found []*ec2.LaunchTemplateVersion
+	for {
+		response, err := cloud.EC2().DescribeLaunchTemplateVersions(request)
+		if err != nil {
+			return nil, fmt.Errorf("error listing versions of LaunchTemplate %q: %v", id, err)
+		}
+		found = append(found, response.LaunchTemplateVersions...)
+		if aws.StringValue(response.NextToken) == "" {
+			break
+		}
+		request.NextToken = response.NextToken
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		return aws.Int64Value(found[i].VersionNumber) < aws.Int64Value(found[j].VersionNumber)
+	})
+	return found, nil
+}
+
+func (e *LaunchTemplate) Find(c *fi.Context) (*LaunchTemplate, error) {
+	cloud := c.Cloud.(awsup.AWSCloud)
+
+	lt, err := findLaunchTemplate(cloud, fi.StringValue(e.Name))
+	if err != nil {
+		return nil, err
+	}
+	if lt == nil {
+		return nil, nil
+	}
+
+	versions, err := findLaunchTemplateVersions(cloud, aws.StringValue(lt.LaunchTemplateId), "$Latest")
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("LaunchTemplate %q has no versions", aws.StringValue(lt.LaunchTemplateId))
+	}
+	version := versions[len(versions)-1]
+
+	glog.V(2).Infof("found existing LaunchTemplate %q version %d", aws.StringValue(lt.LaunchTemplateId), aws.Int64Value(version.VersionNumber))
+
+	data := version.LaunchTemplateData
+	if data == nil {
+		data = &ec2.ResponseLaunchTemplateData{}
+	}
+
+	actual := &LaunchTemplate{
+		Name:                   e.Name,
+		ID:                     lt.LaunchTemplateId,
+		LatestVersion:          version.VersionNumber,
+		ImageID:                data.ImageId,
+		InstanceType:           data.InstanceType,
+		RootVolumeOptimization: data.EbsOptimized,
+	}
+
+	if data.KeyName != nil {
+		actual.SSHKey = &SSHKey{Name: data.KeyName}
+	}
+
+	if data.IamInstanceProfile != nil {
+		actual.IAMInstanceProfile = &IAMInstanceProfile{Name: data.IamInstanceProfile.Name}
+	}
+
+	if data.Monitoring != nil {
+		actual.InstanceMonitoring = data.Monitoring.Enabled
+	}
+
+	if data.Placement != nil {
+		actual.Tenancy = data.Placement.Tenancy
+	}
+
+	if data.InstanceMarketOptions != nil && data.InstanceMarketOptions.SpotOptions != nil {
+		actual.SpotPrice = aws.StringValue(data.InstanceMarketOptions.SpotOptions.MaxPrice)
+	}
+
+	securityGroups := []*SecurityGroup{}
+	for _, sgID := range data.SecurityGroupIds {
+		securityGroups = append(securityGroups, &SecurityGroup{ID: sgID})
+	}
+	for _, ni := range data.NetworkInterfaces {
+		if aws.Int64Value(ni.DeviceIndex) != 0 {
+			continue
+		}
+		actual.AssociatePublicIP = ni.AssociatePublicIpAddress
+		for _, sgID := range ni.Groups {
+			securityGroups = append(securityGroups, &SecurityGroup{ID: sgID})
+		}
+	}
+	sort.Sort(OrderSecurityGroupsById(securityGroups))
+	actual.SecurityGroups = securityGroups
+
+	// Find the root volume
+	for _, b := range data.BlockDeviceMappings {
+		if b.Ebs == nil || b.Ebs.SnapshotId != nil {
+			// Not the root
+			continue
+		}
+		actual.RootVolumeSize = b.Ebs.VolumeSize
+		actual.RootVolumeType = b.Ebs.VolumeType
+		actual.RootVolumeIops = b.Ebs.Iops
+	}
+
+	if data.UserData != nil {
+		userData, err := base64.StdEncoding.DecodeString(aws.StringValue(data.UserData))
+		if err != nil {
+			return nil, fmt.Errorf("error decoding UserData: %v", err)
+		}
+		actual.UserData = fi.WrapResource(fi.NewStringResource(string(userData)))
+	}
+
+	// Avoid spurious changes on ImageId
+	if e.ImageID != nil && actual.ImageID != nil && *actual.ImageID != *e.ImageID {
+		image, err := cloud.ResolveImage(*e.ImageID)
+		if err != nil {
+			glog.Warningf("unable to resolve image: %q: %v", *e.ImageID, err)
+		} else if image == nil {
+			glog.Warningf("unable to resolve image: %q: not found", *e.ImageID)
+		} else if aws.StringValue(image.ImageId) == *actual.ImageID {
+			glog.V(4).Infof("Returning matching ImageId as expected name: %q -> %q", *actual.ImageID, *e.ImageID)
+			actual.ImageID = e.ImageID
+		}
+	}
+
+	// Avoid spurious changes
+	actual.Lifecycle = e.Lifecycle
+
+	if e.ID == nil {
+		e.ID = actual.ID
+	}
+	if e.LatestVersion == nil {
+		e.LatestVersion = actual.LatestVersion
+	}
+
+	return actual, nil
+}
+
+func (e *LaunchTemplate) buildRootDevice(cloud awsup.AWSCloud) (map[string]*BlockDeviceMapping, error) {
+	imageID := fi.StringValue(e.ImageID)
+	image, err := cloud.ResolveImage(imageID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve image: %q: %v", imageID, err)
+	} else if image == nil {
+		return nil, fmt.Errorf("unable to resolve image: %q: not found", imageID)
+	}
+
+	blockDeviceMappings := make(map[string]*BlockDeviceMapping)
+	blockDeviceMappings[aws.StringValue(image.RootDeviceName)] = &BlockDeviceMapping{
+		EbsDeleteOnTermination: aws.Bool(true),
+		EbsVolumeSize:          e.RootVolumeSize,
+		EbsVolumeType:          e.RootVolumeType,
+		EbsVolumeIops:          e.RootVolumeIops,
+	}
+	return blockDeviceMappings, nil
+}
+
+func (e *LaunchTemplate) Run(c *fi.Context) error {
+	e.Normalize()
+
+	return fi.DefaultDeltaRunMethod(e, c)
+}
+
+func (e *LaunchTemplate) Normalize() {
+	// We need to sort our arrays consistently, so we don't get spurious changes
+	sort.Stable(OrderSecurityGroupsById(e.SecurityGroups))
+}
+
+func (s *LaunchTemplate) CheckChanges(a, e, changes *LaunchTemplate) error {
+	if e.ImageID == nil {
+		return fi.RequiredField("ImageID")
+	}
+	if e.InstanceType == nil {
+		return fi.RequiredField("InstanceType")
+	}
+
+	if a != nil {
+		if e.Name == nil {
+			return fi.RequiredField("Name")
+		}
+	}
+	return nil
+}
+
+// buildLaunchTemplateData builds the data of a new version of the launch template
+func (e *LaunchTemplate) buildLaunchTemplateData(cloud awsup.AWSCloud) (*ec2.RequestLaunchTemplateData, error) {
+	image, err := cloud.ResolveImage(fi.StringValue(e.ImageID))
+	if err != nil {
+		return nil, err
+	}
+
+	data := &ec2.RequestLaunchTemplateData{
+		ImageId:      image.ImageId,
+		InstanceType: e.InstanceType,
+		EbsOptimized: e.RootVolumeOptimization,
+	}
+
+	if e.SSHKey != nil {
+		data.KeyName = e.SSHKey.Name
+	}
+
+	if e.Tenancy != nil {
+		data.Placement = &ec2.LaunchTemplatePlacementRequest{Tenancy: e.Tenancy}
+	}
+
+	// The public IP can only be set on a network interface, so the security groups must be set there too
+	networkInterface := &ec2.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest{
+		AssociatePublicIpAddress: e.AssociatePublicIP,
+		DeleteOnTermination:      aws.Bool(true),
+		DeviceIndex:              aws.Int64(0),
+	}
+	for _, sg := range e.SecurityGroups {
+		networkInterface.Groups = append(networkInterface.Groups, sg.ID)
+	}
+	data.NetworkInterfaces = []*ec2.LaunchTemplateInstanceNetworkInterfaceSpecificationRequest{networkInterface}
+
+	if e.SpotPrice != "" {
+		data.InstanceMarketOptions = &ec2.LaunchTemplateInstanceMarketOptionsRequest{
+			MarketType: aws.String(ec2.MarketTypeSpot),
+			SpotOptions: &ec2.LaunchTemplateSpotMarketOptionsRequest{
+				MaxPrice: aws.String(e.SpotPrice),
+			},
+		}
+	}
+
+	// Build up the actual block device mappings
+	{
+		rootDevices, err := e.buildRootDevice(cloud)
+		if err != nil {
+			return nil, err
+		}
+
+		ephemeralDevices, err := buildEphemeralDevices(e.InstanceType)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, device := range sets.StringKeySet(rootDevices).List() {
+			data.BlockDeviceMappings = append(data.BlockDeviceMappings, rootDevices[device].ToLaunchTemplate(device))
+		}
+		for _, device := range sets.StringKeySet(ephemeralDevices).List() {
+			data.BlockDeviceMappings = append(data.BlockDeviceMappings, ephemeralDevices[device].ToLaunchTemplate(device))
+		}
+	}
+
+	if e.UserData != nil {
+		d, err := e.UserData.AsBytes()
+		if err != nil {
+			return nil, fmt.Errorf("error rendering LaunchTemplate UserData: %v", err)
+		}
+		data.UserData = aws.String(base64.StdEncoding.EncodeToString(d))
+	}
+	if e.IAMInstanceProfile != nil {
+		data.IamInstanceProfile = &ec2.LaunchTemplateIamInstanceProfileSpecificationRequest{
+			Name: e.IAMInstanceProfile.Name,
+		}
+	}
+	data.Monitoring = &ec2.LaunchTemplatesMonitoringRequest{Enabled: fi.Bool(fi.BoolValue(e.InstanceMonitoring))}
+
+	return data, nil
+}
+
+func (_ *LaunchTemplate) RenderAWS(t *awsup.AWSAPITarget, a, e, changes *LaunchTemplate) error {
+	if e.ImageID == nil {
+		return fi.RequiredField("ImageID")
+	}
+
+	data, err := e.buildLaunchTemplateData(t.Cloud)
+	if err != nil {
+		return err
+	}
+
+	if a == nil {
+		glog.V(2).Infof("Creating LaunchTemplate with Name:%q", fi.StringValue(e.Name))
+
+		request := &ec2.CreateLaunchTemplateInput{
+			LaunchTemplateName: e.Name,
+			LaunchTemplateData: data,
+		}
+		response, err := t.Cloud.EC2().CreateLaunchTemplate(request)
+		if err != nil {
+			return fmt.Errorf("error creating LaunchTemplate: %v", err)
+		}
+
+		e.ID = response.LaunchTemplate.LaunchTemplateId
+		e.LatestVersion = response.LaunchTemplate.LatestVersionNumber
+		return nil
+	}
+
+	// Launch templates can't be changed, but we can add a new version; the AutoscalingGroup uses the latest version
+	glog.V(2).Infof("Creating new version of LaunchTemplate %q", fi.StringValue(a.ID))
+
+	request := &ec2.CreateLaunchTemplateVersionInput{
+		LaunchTemplateId:   a.ID,
+		LaunchTemplateData: data,
+	}
+	response, err := t.Cloud.EC2().CreateLaunchTemplateVersion(request)
+	if err != nil {
+		return fmt.Errorf("error creating version of LaunchTemplate %q: %v", fi.StringValue(a.ID), err)
+	}
+	version := response.LaunchTemplateVersion.VersionNumber
+
+	// Make the new version the default too, so instances launched from the template outside of the group get it
+	_, err = t.Cloud.EC2().ModifyLaunchTemplate(&ec2.ModifyLaunchTemplateInput{
+		LaunchTemplateId: a.ID,
+		DefaultVersion:   aws.String(strconv.FormatInt(aws.Int64Value(version), 10)),
+	})
+	if err != nil {
+		return fmt.Errorf("error setting default version of LaunchTemplate %q: %v", fi.StringValue(a.ID), err)
+	}
+
+	e.ID = a.ID
+	e.LatestVersion = version
+	return nil
+}
+
+type terraformLaunchTemplateNetworkInterface struct {
+	AssociatePublicIPAddress *bool                `json:"associate_public_ip_address,omitempty"`
+	DeleteOnTermination      *bool                `json:"delete_on_termination,omitempty"`
+	SecurityGroups           []*terraform.Literal `json:"security_groups,omitempty"`
+}
+
+type terraformLaunchTemplateBlockDeviceEBS struct {
+	VolumeType          *string `json:"volume_type,omitempty"`
+	VolumeSize          *int64  `json:"volume_size,omitempty"`
+	IOPS                *int64  `json:"iops,omitempty"`
+	DeleteOnTermination *bool   `json:"delete_on_termination,omitempty"`
+}
+
+type terraformLaunchTemplateBlockDevice struct {
+	DeviceName  *string                                `json:"device_name,omitempty"`
+	VirtualName *string                                `json:"virtual_name,omitempty"`
+	EBS         *terraformLaunchTemplateBlockDeviceEBS `json:"ebs,omitempty"`
+}
+
+type terraformLaunchTemplateIAMInstanceProfile struct {
+	Name *terraform.Literal `json:"name,omitempty"`
+}
+
+type terraformLaunchTemplateMonitoring struct {
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+type terraformLaunchTemplatePlacement struct {
+	Tenancy *string `json:"tenancy,omitempty"`
+}
+
+type terraformLaunchTemplateSpotOptions struct {
+	MaxPrice *string `json:"max_price,omitempty"`
+}
+
+type terraformLaunchTemplateMarketOptions struct {
+	MarketType  *string                             `json:"market_type,omitempty"`
+	SpotOptions *terraformLaunchTemplateSpotOptions `json:"spot_options,omitempty"`
+}
+
+type terraformLaunchTemplate struct {
+	NamePrefix            *string                                    `json:"name_prefix,omitempty"`
+	ImageID               *string                                    `json:"image_id,omitempty"`
+	InstanceType          *string                                    `json:"instance_type,omitempty"`
+	KeyName               *terraform.Literal                         `json:"key_name,omitempty"`
+	IAMInstanceProfile    *terraformLaunchTemplateIAMInstanceProfile `json:"iam_instance_profile,omitempty"`
+	NetworkInterfaces     []*terraformLaunchTemplateNetworkInterface `json:"network_interfaces,omitempty"`
+	UserData              *terraform.Literal                         `json:"user_data,omitempty"`
+	BlockDeviceMappings   []*terraformLaunchTemplateBlockDevice      `json:"block_device_mappings,omitempty"`
+	EBSOptimized          *bool                                      `json:"ebs_optimized,omitempty"`
+	Monitoring            *terraformLaunchTemplateMonitoring         `json:"monitoring,omitempty"`
+	Placement             *terraformLaunchTemplatePlacement          `json:"placement,omitempty"`
+	InstanceMarketOptions *terraformLaunchTemplateMarketOptions      `json:"instance_market_options,omitempty"`
+	Lifecycle             *terraform.Lifecycle                       `json:"lifecycle,omitempty"`
+}
+
+func (_ *LaunchTemplate) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *LaunchTemplate) error {
+	cloud := t.Cloud.(awsup.AWSCloud)
+
+	if e.ImageID == nil {
+		return fi.RequiredField("ImageID")
+	}
+	image, err := cloud.ResolveImage(*e.ImageID)
+	if err != nil {
+		return err
+	}
+
+	tf := &terraformLaunchTemplate{
+		NamePrefix:   fi.String(*e.Name + "-"),
+		ImageID:      image.ImageId,
+		InstanceType: e.InstanceType,
+		EBSOptimized: e.RootVolumeOptimization,
+	}
+
+	if e.SSHKey != nil {
+		tf.KeyName = e.SSHKey.TerraformLink()
+	}
+
+	if e.Tenancy != nil {
+		tf.Placement = &terraformLaunchTemplatePlacement{Tenancy: e.Tenancy}
+	}
+
+	if e.SpotPrice != "" {
+		tf.InstanceMarketOptions = &terraformLaunchTemplateMarketOptions{
+			MarketType:  aws.String(ec2.MarketTypeSpot),
+			SpotOptions: &terraformLaunchTemplateSpotOptions{MaxPrice: aws.String(e.SpotPrice)},
+		}
+	}
+
+	networkInterface := &terraformLaunchTemplateNetworkInterface{
+		AssociatePublicIPAddress: e.AssociatePublicIP,
+		DeleteOnTermination:      fi.Bool(true),
+	}
+	for _, sg := range e.SecurityGroups {
+		networkInterface.SecurityGroups = append(networkInterface.SecurityGroups, sg.TerraformLink())
+	}
+	tf.NetworkInterfaces = []*terraformLaunchTemplateNetworkInterface{networkInterface}
+
+	{
+		rootDevices, err := e.buildRootDevice(cloud)
+		if err != nil {
+			return err
+		}
+
+		ephemeralDevices, err := buildEphemeralDevices(e.InstanceType)
+		if err != nil {
+			return err
+		}
+
+		for _, deviceName := range sets.StringKeySet(rootDevices).List() {
+			bdm := rootDevices[deviceName]
+			tf.BlockDeviceMappings = append(tf.BlockDeviceMappings, &terraformLaunchTemplateBlockDevice{
+				DeviceName: fi.String(deviceName),
+				EBS: &terraformLaunchTemplateBlockDeviceEBS{
+					VolumeType:          bdm.EbsVolumeType,
+					VolumeSize:          bdm.EbsVolumeSize,
+					IOPS:                bdm.EbsVolumeIops,
+					DeleteOnTermination: fi.Bool(true),
+				},
+			})
+		}
+
+		for _, deviceName := range sets.StringKeySet(ephemeralDevices).List() {
+			bdm := ephemeralDevices[deviceName]
+			tf.BlockDeviceMappings = append(tf.BlockDeviceMappings, &terraformLaunchTemplateBlockDevice{
+				VirtualName: bdm.VirtualName,
+				DeviceName:  fi.String(deviceName),
+			})
+		}
+	}
+
+	if e.UserData != nil {
+		tf.UserData, err = t.AddFileBase64("aws_launch_template", *e.Name, "user_data", e.UserData)
+		if err != nil {
+			return err
+		}
+	}
+	if e.IAMInstanceProfile != nil {
+		tf.IAMInstanceProfile = &terraformLaunchTemplateIAMInstanceProfile{
+			Name: e.IAMInstanceProfile.TerraformLink(),
+		}
+	}
+	tf.Monitoring = &terraformLaunchTemplateMonitoring{Enabled: fi.Bool(fi.BoolValue(e.InstanceMonitoring))}
+
+	// So that we can replace the template if something forces it
+	tf.Lifecycle = &terraform.Lifecycle{CreateBeforeDestroy: fi.Bool(true)}
+
+	return t.RenderResource("aws_launch_template", *e.Name, tf)
+}
+
+func (e *LaunchTemplate) TerraformLink() *terraform.Literal {
+	return terraform.LiteralProperty("aws_launch_template", *e.Name, "id")
+}
+
+// TerraformLinkLatestVersion is the latest version of the launch template, which the AutoscalingGroup uses
+func (e *LaunchTemplate) TerraformLinkLatestVersion() *terraform.Literal {
+	return terraform.LiteralProperty("aws_launch_template", *e.Name, "latest_version")
+}
+
+type cloudformationLaunchTemplateNetworkInterface struct {
+	AssociatePublicIPAddress *bool                     `json:"AssociatePublicIpAddress,omitempty"`
+	DeleteOnTermination      *bool                     `json:"DeleteOnTermination,omitempty"`
+	DeviceIndex              *int64                    `json:"DeviceIndex,omitempty"`
+	Groups                   []*cloudformation.Literal `json:"Groups,omitempty"`
+}
+
+type cloudformationLaunchTemplateIAMInstanceProfile struct {
+	Name *cloudformation.Literal `json:"Name,omitempty"`
+}
+
+type cloudformationLaunchTemplateMonitoring struct {
+	Enabled *bool `json:"Enabled,omitempty"`
+}
+
+type cloudformationLaunchTemplatePlacement struct {
+	Tenancy *string `json:"Tenancy,omitempty"`
+}
+
+type cloudformationLaunchTemplateSpotOptions struct {
+	MaxPrice *string `json:"MaxPrice,omitempty"`
+}
+
+type cloudformationLaunchTemplateMarketOptions struct {
+	MarketType  *string                                  `json:"MarketType,omitempty"`
+	SpotOptions *cloudformationLaunchTemplateSpotOptions `json:"SpotOptions,omitempty"`
+}
+
+type cloudformationLaunchTemplateData struct {
+	BlockDeviceMappings   []*cloudformationBlockDevice                    `json:"BlockDeviceMappings,omitempty"`
+	EBSOptimized          *bool                                           `json:"EbsOptimized,omitempty"`
+	IAMInstanceProfile    *cloudformationLaunchTemplateIAMInstanceProfile `json:"IamInstanceProfile,omitempty"`
+	ImageID               *string                                         `json:"ImageId,omitempty"`
+	InstanceType          *string                                         `json:"InstanceType,omitempty"`
+	InstanceMarketOptions *cloudformationLaunchTemplateMarketOptions      `json:"InstanceMarketOptions,omitempty"`
+	KeyName               *string                                         `json:"KeyName,omitempty"`
+	Monitoring            *cloudformationLaunchTemplateMonitoring         `json:"Monitoring,omitempty"`
+	NetworkInterfaces     []*cloudformationLaunchTemplateNetworkInterface `json:"NetworkInterfaces,omitempty"`
+	Placement             *cloudformationLaunchTemplatePlacement          `json:"Placement,omitempty"`
+	UserData              *string                                         `json:"UserData,omitempty"`
+}
+
+type cloudformationLaunchTemplate struct {
+	LaunchTemplateName *string                           `json:"LaunchTemplateName,omitempty"`
+	LaunchTemplateData *cloudformationLaunchTemplateData `json:"LaunchTemplateData,omitempty"`
+}
+
+func (_ *LaunchTemplate) RenderCloudformation(t *cloudformation.CloudformationTarget, a, e, changes *LaunchTemplate) error {
+	cloud := t.Cloud.(awsup.AWSCloud)
+
+	if e.ImageID == nil {
+		return fi.RequiredField("ImageID")
+	}
+	image, err := cloud.ResolveImage(*e.ImageID)
+	if err != nil {
+		return err
+	}
+
+	data := &cloudformationLaunchTemplateData{
+		ImageID:      image.ImageId,
+		InstanceType: e.InstanceType,
+		EBSOptimized: e.RootVolumeOptimization,
+	}
+
+	if e.SSHKey != nil {
+		if e.SSHKey.Name == nil {
+			return fmt.Errorf("SSHKey Name not set")
+		}
+		data.KeyName = e.SSHKey.Name
+	}
+
+	if e.Tenancy != nil {
+		data.Placement = &cloudformationLaunchTemplatePlacement{Tenancy: e.Tenancy}
+	}
+
+	if e.SpotPrice != "" {
+		data.InstanceMarketOptions = &cloudformationLaunchTemplateMarketOptions{
+			MarketType:  aws.String(ec2.MarketTypeSpot),
+			SpotOptions: &cloudformationLaunchTemplateSpotOptions{MaxPrice: aws.String(e.SpotPrice)},
+		}
+	}
+
+	networkInterface := &cloudformationLaunchTemplateNetworkInterface{
+		AssociatePublicIPAddress: e.AssociatePublicIP,
+		DeleteOnTermination:      fi.Bool(true),
+		DeviceIndex:              fi.Int64(0),
+	}
+	for _, sg := range e.SecurityGroups {
+		networkInterface.Groups = append(networkInterface.Groups, sg.CloudformationLink())
+	}
+	data.NetworkInterfaces = []*cloudformationLaunchTemplateNetworkInterface{networkInterface}
+
+	{
+		rootDevices, err := e.buildRootDevice(cloud)
+		if err != nil {
+			return err
+		}
+
+		ephemeralDevices, err := buildEphemeralDevices(e.InstanceType)
+		if err != nil {
+			return err
+		}
+
+		for _, deviceName := range sets.StringKeySet(rootDevices).List() {
+			bdm := rootDevices[deviceName]
+			data.BlockDeviceMappings = append(data.BlockDeviceMappings, &cloudformationBlockDevice{
+				DeviceName: fi.String(deviceName),
+				Ebs: &cloudformationBlockDeviceEBS{
+					VolumeType:          bdm.EbsVolumeType,
+					VolumeSize:          bdm.EbsVolumeSize,
+					DeleteOnTermination: fi.Bool(true),
+				},
+			})
+		}
+
+		for _, deviceName := range sets.StringKeySet(ephemeralDevices).List() {
+			bdm := ephemeralDevices[deviceName]
+			data.BlockDeviceMappings = append(data.BlockDeviceMappings, &cloudformationBlockDevice{
+				VirtualName: bdm.VirtualName,
+				DeviceName:  fi.String(deviceName),
+			})
+		}
+	}
+
+	if e.UserData != nil {
+		d, err := e.UserData.AsBytes()
+		if err != nil {
+			return fmt.Errorf("error rendering LaunchTemplate UserData: %v", err)
+		}
+		data.UserData = aws.String(base64.StdEncoding.EncodeToString(d))
+	}
+
+	if e.IAMInstanceProfile != nil {
+		data.IAMInstanceProfile = &cloudformationLaunchTemplateIAMInstanceProfile{
+			Name: e.IAMInstanceProfile.CloudformationLink(),
+		}
+	}
+	data.Monitoring = &cloudformationLaunchTemplateMonitoring{Enabled: fi.Bool(fi.BoolValue(e.InstanceMonitoring))}
+
+	cf := &cloudformationLaunchTemplate{
+		LaunchTemplateName: e.Name,
+		LaunchTemplateData: data,
+	}
+
+	return t.RenderResource("AWS::EC2::LaunchTemplate", *e.Name, cf)
+}
+
+func (e *LaunchTemplate) CloudformationLink() *cloudformation.Literal {
+	return cloudformation.Ref("AWS::EC2::LaunchTemplate", *e.Name)
+}
+
+// CloudformationLinkLatestVersion is the latest version of the launch template, which the AutoscalingGroup uses
+func (e *LaunchTemplate) CloudformationLinkLatestVersion() *cloudformation.Literal {
+	return cloudformation.GetAtt("AWS::EC2::LaunchTemplate", *e.Name, "LatestVersionNumber")
+}
+
+// deleteLaunchTemplateVersion tracks an old LaunchTemplate version that we're going to delete
+// It implements fi.Deletion
+type deleteLaunchTemplateVersion struct {
+	id      string
+	version *ec2.LaunchTemplateVersion
+}
+
+var _ fi.Deletion = &deleteLaunchTemplateVersion{}
+
+func (d *deleteLaunchTemplateVersion) TaskName() string {
+	return "LaunchTemplateVersion"
+}
+
+func (d *deleteLaunchTemplateVersion) Item() string {
+	return fmt.Sprintf("%s:%d", aws.StringValue(d.version.LaunchTemplateName), aws.Int64Value(d.version.VersionNumber))
+}
+
+func (d *deleteLaunchTemplateVersion) Delete(t fi.Target) error {
+	glog.V(2).Infof("deleting launch template version %v", d)
+
+	awsTarget, ok := t.(*awsup.AWSAPITarget)
+	if !ok {
+		return fmt.Errorf("unexpected target type for deletion: %T", t)
+	}
+
+	request := &ec2.DeleteLaunchTemplateVersionsInput{
+		LaunchTemplateId: aws.String(d.id),
+		Versions:         []*string{aws.String(strconv.FormatInt(aws.Int64Value(d.version.VersionNumber), 10))},
+	}
+	response, err := awsTarget.Cloud.EC2().DeleteLaunchTemplateVersions(request)
+	if err != nil {
+		return fmt.Errorf("error deleting LaunchTemplate version %s: %v", d.Item(), err)
+	}
+	for _, failure := range response.UnsuccessfullyDeletedLaunchTemplateVersions {
+		if failure.ResponseError != nil {
+			return fmt.Errorf("error deleting LaunchTemplate version %s: %s", d.Item(), aws.StringValue(failure.ResponseError.Message))
+		}
+	}
+
+	return nil
+}
+
+func (d *deleteLaunchTemplateVersion) String() string {
+	return d.TaskName() + "-" + d.Item()
+}
+
+// FindDeletions removes old versions of the launch template, keeping as many as we keep launch configurations
+func (e *LaunchTemplate) FindDeletions(c *fi.Context) ([]fi.Deletion, error) {
+	cloud := c.Cloud.(awsup.AWSCloud)
+
+	lt, err := findLaunchTemplate(cloud, fi.StringValue(e.Name))
+	if err != nil {
+		return nil, err
+	}
+	if lt == nil {
+		return nil, nil
+	}
+
+	id := aws.StringValue(lt.LaunchTemplateId)
+	versions, err := findLaunchTemplateVersions(cloud, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(versions) <= RetainLaunchConfigurationCount() {
+		return nil, nil
+	}
+
+	var removals []fi.Deletion
+	for _, version := range versions[:len(versions)-RetainLaunchConfigurationCount()] {
+		// The default version can't be deleted
+		if aws.BoolValue(version.DefaultVersion) {
+			continue
+		}
+		removals = append(removals, &deleteLaunchTemplateVersion{id: id, version: version})
+	}
+
+	glog.V(2).Infof("will delete launch template versions: %v", removals)
+
+	return removals, nil
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package awstasks
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"k8s.io/kops/cloudmock/aws/mockec2"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/awsup"
+)
+
+func TestLaunchTemplateVersions(t *testing.T) {
+	cloud := awsup.BuildMockAWSCloud("us-east-1", "abc")
+	mockEC2 := &mockec2.MockEC2{}
+	cloud.MockEC2 = mockEC2
+
+	mockEC2.Images = append(mockEC2.Images, &ec2.Image{
+		CreationDate:   aws.String("2016-10-21T20:07:19.000Z"),
+		ImageId:        aws.String("ami-12345678"),
+		Name:           aws.String("k8s-1.4-debian-jessie-amd64-hvm-ebs-2016-10-21"),
+		OwnerId:        aws.String(awsup.WellKnownAccountKopeio),
+		RootDeviceName: aws.String("/dev/xvda"),
+	})
+
+	// We define a function so we can rebuild the tasks, because we modify in-place when running
+	buildTasks := func(spotPrice string) map[string]fi.Task {
+		lt := &LaunchTemplate{
+			Name:           s("lt1"),
+			SpotPrice:      spotPrice,
+			ImageID:        s("ami-12345678"),
+			InstanceType:   s("m3.medium"),
+			SecurityGroups: []*SecurityGroup{},
+		}
+
+		return map[string]fi.Task{
+			"lt1": lt,
+		}
+	}
+
+	// We change the launch template 5 times, verifying that each change creates a new version of the same
+	// launch template, and that older versions are eventually GCed
+	var id string
+	for i := 0; i < 5; i++ {
+		spotPrice := strconv.Itoa(i + 1)
+		{
+			allTasks := buildTasks(spotPrice)
+			lt1 := allTasks["lt1"].(*LaunchTemplate)
+
+			target := &awsup.AWSAPITarget{
+				Cloud: cloud,
+			}
+
+			context, err := fi.NewContext(target, nil, cloud, nil, nil, nil, true, allTasks)
+			if err != nil {
+				t.Fatalf("error building context: %v", err)
+			}
+
+			if err := context.RunTasks(testRunTasksOptions); err != nil {
+				t.Fatalf("unexpected error during Run: %v", err)
+			}
+
+			if fi.StringValue(lt1.ID) == "" {
+				t.Fatalf("ID not set after create")
+			}
+			if id == "" {
+				id = fi.StringValue(lt1.ID)
+			} else if id != fi.StringValue(lt1.ID) {
+				t.Fatalf("Expected launch template %q to be updated, but %q was created", id, fi.StringValue(lt1.ID))
+			}
+
+			if len(mockEC2.LaunchTemplates) != 1 {
+				t.Fatalf("Expected exactly one LaunchTemplate; found %v", mockEC2.LaunchTemplates)
+			}
+
+			response, err := mockEC2.DescribeLaunchTemplateVersions(&ec2.DescribeLaunchTemplateVersionsInput{
+				LaunchTemplateId: aws.String(id),
+			})
+			if err != nil {
+				t.Fatalf("error listing launch template versions: %v", err)
+			}
+
+			expectedCount := i + 1
+			if expectedCount > RetainLaunchConfigurationCount() {
+				expectedCount = RetainLaunchConfigurationCount()
+			}
+			if len(response.LaunchTemplateVersions) != expectedCount {
+				t.Fatalf("Expected exactly %d LaunchTemplateVersions; found %v", expectedCount, response.LaunchTemplateVersions)
+			}
+
+			response, err = mockEC2.DescribeLaunchTemplateVersions(&ec2.DescribeLaunchTemplateVersionsInput{
+				LaunchTemplateId: aws.String(id),
+				Versions:         aws.StringSlice([]string{"$Default"}),
+			})
+			if err != nil {
+				t.Fatalf("error finding default launch template version: %v", err)
+			}
+			if len(response.LaunchTemplateVersions) != 1 {
+				t.Fatalf("Expected exactly one default LaunchTemplateVersion; found %v", response.LaunchTemplateVersions)
+			}
+			actual := response.LaunchTemplateVersions[0]
+			if aws.Int64Value(actual.VersionNumber) != int64(i+1) {
+				t.Fatalf("Unexpected default version: expected=%d actual=%d", i+1, aws.Int64Value(actual.VersionNumber))
+			}
+			if actual.LaunchTemplateData.InstanceMarketOptions == nil || aws.StringValue(actual.LaunchTemplateData.InstanceMarketOptions.SpotOptions.MaxPrice) != spotPrice {
+				t.Fatalf("Unexpected spot options: expected MaxPrice=%v actual=%v", spotPrice, actual.LaunchTemplateData.InstanceMarketOptions)
+			}
+		}
+
+		{
+			allTasks := buildTasks(spotPrice)
+			checkNoChanges(t, cloud, allTasks)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package awstasks
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/golang/glog"
+
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/awsup"
+	"k8s.io/kops/upup/pkg/fi/cloudup/cloudformation"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
+)
+
+// LifecycleHook manages a lifecycle hook of an AutoscalingGroup, which pauses instances as they launch or terminate.
+// The autoscaling group assumes Role to send a notification for each paused instance to the NotificationTarget queue.
+//
+//go:generate fitask -type=LifecycleHook
+type LifecycleHook struct {
+	Name      *string
+	Lifecycle *fi.Lifecycle
+
+	AutoscalingGroup *AutoscalingGroup
+	// HookName is the name of the hook in the autoscaling group
+	HookName *string
+
+	Transition       *string
+	HeartbeatTimeout *int64
+	DefaultResult    *string
+
+	NotificationTarget *SQSQueue
+	Role               *IAMRole
+}
+
+func (e *LifecycleHook) Find(c *fi.Context) (*LifecycleHook, error) {
+	cloud := c.Cloud.(awsup.AWSCloud)
+
+	groupName := fi.StringValue(e.AutoscalingGroup.Name)
+	g, err := findAutoscalingGroup(cloud, groupName)
+	if err != nil {
+		return nil, err
+	}
+	if g == nil {
+		return nil, nil
+	}
+
+	request := &autoscaling.DescribeLifecycleHooksInput{
+		AutoScalingGroupName: g.AutoScalingGroupName,
+		LifecycleHookNames:   []*string{e.HookName},
+	}
+	response, err := cloud.Autoscaling().DescribeLifecycleHooks(request)
+	if err != nil {
+		return nil, fmt.Errorf("error describing lifecycle hooks of AutoscalingGroup %q: %v", groupName, err)
+	}
+	if response == nil || len(response.LifecycleHooks) == 0 {
+		return nil, nil
+	}
+	if len(response.LifecycleHooks) != 1 {
+		return nil, fmt.Errorf("found multiple lifecycle hooks named %q in AutoscalingGroup %q", fi.StringValue(e.HookName), groupName)
+	}
+	hook := response.LifecycleHooks[0]
+
+	actual := &LifecycleHook{
+		Name:             e.Name,
+		Lifecycle:        e.Lifecycle,
+		AutoscalingGroup: &AutoscalingGroup{Name: hook.AutoScalingGroupName},
+		HookName:         hook.LifecycleHookName,
+		Transition:       hook.LifecycleTransition,
+		HeartbeatTimeout: hook.HeartbeatTimeout,
+		DefaultResult:    hook.DefaultResult,
+	}
+
+	// The notification target and role are reported by ARN; we avoid spurious changes if they are ours
+	if e.NotificationTarget != nil && aws.StringValue(e.NotificationTarget.ARN) == aws.StringValue(hook.NotificationTargetARN) {
+		actual.NotificationTarget = e.NotificationTarget
+	} else if hook.NotificationTargetARN != nil {
+		actual.NotificationTarget = &SQSQueue{ARN: hook.NotificationTargetARN}
+	}
+	if e.Role != nil && aws.StringValue(e.Role.ARN) == aws.StringValue(hook.RoleARN) {
+		actual.Role = e.Role
+	} else if hook.RoleARN != nil {
+		actual.Role = &IAMRole{ARN: hook.RoleARN}
+	}
+
+	glog.V(4).Infof("Found lifecycle hook %+v", actual)
+
+	return actual, nil
+}
+
+func (e *LifecycleHook) Run(c *fi.Context) error {
+	return fi.DefaultDeltaRunMethod(e, c)
+}
+
+func (_ *LifecycleHook) CheckChanges(a, e, changes *LifecycleHook) error {
+	if a == nil {
+		if e.AutoscalingGroup == nil {
+			return fi.RequiredField("AutoscalingGroup")
+		}
+		if e.HookName == nil {
+			return fi.RequiredField("HookName")
+		}
+		if e.Transition == nil {
+			return fi.RequiredField("Transition")
+		}
+	} else {
+		if changes.AutoscalingGroup != nil {
+			return fi.CannotChangeField("AutoscalingGroup")
+		}
+		if changes.HookName != nil {
+			return fi.CannotChangeField("HookName")
+		}
+	}
+	return nil
+}
+
+// RenderAWS creates or updates the hook; AWS sends a test notification to the target as it does so,
+// which fails until the role's policy has been applied, in which case the task is retried.
+func (_ *LifecycleHook) RenderAWS(t *awsup.AWSAPITarget, a, e, changes *LifecycleHook) error {
+	request := &autoscaling.PutLifecycleHookInput{
+		AutoScalingGroupName: e.AutoscalingGroup.Name,
+		LifecycleHookName:    e.HookName,
+		LifecycleTransition:  e.Transition,
+		HeartbeatTimeout:     e.HeartbeatTimeout,
+		DefaultResult:        e.DefaultResult,
+	}
+	if e.NotificationTarget != nil {
+		request.NotificationTargetARN = e.NotificationTarget.ARN
+	}
+	if e.Role != nil {
+		request.RoleARN = e.Role.ARN
+	}
+
+	if a == nil {
+		glog.V(2).Infof("Creating lifecycle hook %q in AutoscalingGroup %q", fi.StringValue(e.HookName), fi.StringValue(e.AutoscalingGroup.Name))
+	} else {
+		glog.V(2).Infof("Updating lifecycle hook %q in AutoscalingGroup %q", fi.StringValue(e.HookName), fi.StringValue(e.AutoscalingGroup.Name))
+	}
+	if _, err := t.Cloud.Autoscaling().PutLifecycleHook(request); err != nil {
+		return fmt.Errorf("error putting lifecycle hook %q: %v", fi.StringValue(e.HookName), err)
+	}
+
+	return nil
+}
+
+type terraformLifecycleHook struct {
+	Name                  *string            `json:"name"`
+	AutoscalingGroupName  *terraform.Literal `json:"autoscaling_group_name"`
+	LifecycleTransition   *string            `json:"lifecycle_transition"`
+	HeartbeatTimeout      *int64             `json:"heartbeat_timeout,omitempty"`
+	DefaultResult         *string            `json:"default_result,omitempty"`
+	NotificationTargetARN *terraform.Literal `json:"notification_target_arn,omitempty"`
+	RoleARN               *terraform.Literal `json:"role_arn,omitempty"`
+}
+
+func (_ *LifecycleHook) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *LifecycleHook) error {
+	tf := &terraformLifecycleHook{
+		Name:                 e.HookName,
+		AutoscalingGroupName: e.AutoscalingGroup.TerraformLink(),
+		LifecycleTransition:  e.Transition,
+		HeartbeatTimeout:     e.HeartbeatTimeout,
+		DefaultResult:        e.DefaultResult,
+	}
+	if e.NotificationTarget != nil {
+		tf.NotificationTargetARN = e.NotificationTarget.TerraformLink()
+	}
+	if e.Role != nil {
+		tf.RoleARN = terraform.LiteralProperty("aws_iam_role", *e.Role.Name, "arn")
+	}
+
+	return t.RenderResource("aws_autoscaling_lifecycle_hook", *e.Name, tf)
+}
+
+type cloudformationLifecycleHook struct {
+	LifecycleHookName     *string                 `json:"LifecycleHookName"`
+	AutoScalingGroupName  *cloudformation.Literal `json:"AutoScalingGroupName"`
+	LifecycleTransition   *string                 `json:"LifecycleTransition"`
+	HeartbeatTimeout      *int64                  `json:"HeartbeatTimeout,omitempty"`
+	DefaultResult         *string                 `json:"DefaultResult,omitempty"`
+	NotificationTargetARN *cloudformation.Literal `json:"NotificationTargetARN,omitempty"`
+	RoleARN               *cloudformation.Literal `json:"RoleARN,omitempty"`
+}
+
+func (_ *LifecycleHook) RenderCloudformation(t *cloudformation.CloudformationTarget, a, e, changes *LifecycleHook) error {
+	cf := &cloudformationLifecycleHook{
+		LifecycleHookName:    e.HookName,
+		AutoScalingGroupName: e.AutoscalingGroup.CloudformationLink(),
+		LifecycleTransition:  e.Transition,
+		HeartbeatTimeout:     e.HeartbeatTimeout,
+		DefaultResult:        e.DefaultResult,
+	}
+	if e.NotificationTarget != nil {
+		cf.NotificationTargetARN = e.NotificationTarget.CloudformationLink()
+	}
+	if e.Role != nil {
+		cf.RoleARN = cloudformation.GetAtt("AWS::IAM::Role", *e.Role.Name, "Arn")
+	}
+
+	return t.RenderResource("AWS::AutoScaling::LifecycleHook", *e.Name, cf)
+}
@@ -0,0 +1,622 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package awstasks
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/golang/glog"
+
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/awsup"
+	"k8s.io/kops/upup/pkg/fi/cloudup/cloudformation"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
+)
+
+// NetworkLoadBalancer manages an NLB.  We find the existing NLB by its LoadBalancerName, which is unique in the account and region.
+//
+//go:generate fitask -type=NetworkLoadBalancer
+type NetworkLoadBalancer struct {
+	Name      *string
+	Lifecycle *fi.Lifecycle
+
+	// LoadBalancerName is the name in ELB, possibly different from our name
+	// (ELB names are restricted to 32 characters)
+	LoadBalancerName *string
+
+	ARN          *string
+	DNSName      *string
+	HostedZoneId *string
+
+	Scheme *string
+
+	// SubnetMappings are the subnets the NLB is placed in, each optionally with an ElasticIP for its static address
+	SubnetMappings []*SubnetMapping
+
+	// Listeners are the listeners of the NLB, keyed by port
+	Listeners map[string]*NetworkLoadBalancerListener
+
+	CrossZoneLoadBalancing *bool
+
+	Tags map[string]string
+}
+
+var _ fi.CompareWithID = &NetworkLoadBalancer{}
+
+func (e *NetworkLoadBalancer) CompareWithID() *string {
+	return e.ARN
+}
+
+// SubnetMapping places a NetworkLoadBalancer in a subnet
+type SubnetMapping struct {
+	Subnet *Subnet
+
+	// ElasticIP is the static address of the NLB in the subnet, for an internet-facing NLB
+	ElasticIP *ElasticIP
+}
+
+var _ fi.HasDependencies = &SubnetMapping{}
+
+func (e *SubnetMapping) GetDependencies(tasks map[string]fi.Task) []fi.Task {
+	var deps []fi.Task
+	if e.Subnet != nil {
+		deps = append(deps, e.Subnet)
+	}
+	if e.ElasticIP != nil {
+		deps = append(deps, e.ElasticIP)
+	}
+	return deps
+}
+
+// NetworkLoadBalancerListener forwards TCP connections on a port of the NLB to a TargetGroup
+type NetworkLoadBalancerListener struct {
+	TargetGroup *TargetGroup
+}
+
+var _ fi.HasDependencies = &NetworkLoadBalancerListener{}
+
+func (e *NetworkLoadBalancerListener) GetDependencies(tasks map[string]fi.Task) []fi.Task {
+	if e.TargetGroup == nil {
+		return nil
+	}
+	return []fi.Task{e.TargetGroup}
+}
+
+// OrderSubnetMappingsBySubnetId implements sort.Interface for []*SubnetMapping, based on the subnet ID
+type OrderSubnetMappingsBySubnetId []*SubnetMapping
+
+func (a OrderSubnetMappingsBySubnetId) Len() int      { return len(a) }
+func (a OrderSubnetMappingsBySubnetId) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a OrderSubnetMappingsBySubnetId) Less(i, j int) bool {
+	return fi.StringValue(a[i].Subnet.ID) < fi.StringValue(a[j].Subnet.ID)
+}
+
+// subnetMappingsEqual returns true if the mappings are for the same subnets and ElasticIPs, ignoring order
+func subnetMappingsEqual(l, r []*SubnetMapping) bool {
+	if len(l) != len(r) {
+		return false
+	}
+	allocations := make(map[string]string)
+	for _, m := range l {
+		allocations[fi.StringValue(m.Subnet.ID)] = elasticIPAllocationID(m.ElasticIP)
+	}
+	for _, m := range r {
+		allocationID, found := allocations[fi.StringValue(m.Subnet.ID)]
+		if !found || allocationID != elasticIPAllocationID(m.ElasticIP) {
+			return false
+		}
+	}
+	return true
+}
+
+func elasticIPAllocationID(e *ElasticIP) string {
+	if e == nil {
+		return ""
+	}
+	return fi.StringValue(e.ID)
+}
+
+func findNetworkLoadBalancerByName(cloud awsup.AWSCloud, name string) (*elbv2.LoadBalancer, error) {
+	request := &elbv2.DescribeLoadBalancersInput{
+		Names: []*string{aws.String(name)},
+	}
+
+	response, err := cloud.ELBV2().DescribeLoadBalancers(request)
+	if err != nil {
+		if awsup.AWSErrorCode(err) == elbv2.ErrCodeLoadBalancerNotFoundException {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error describing load balancer %q: %v", name, err)
+	}
+
+	if response == nil || len(response.LoadBalancers) == 0 {
+		return nil, nil
+	}
+	if len(response.LoadBalancers) != 1 {
+		return nil, fmt.Errorf("found multiple load balancers with name %q", name)
+	}
+	return response.LoadBalancers[0], nil
+}
+
+// findNetworkLoadBalancerByAlias finds the NLB that is the target of a route53 alias
+func findNetworkLoadBalancerByAlias(cloud awsup.AWSCloud, alias *route53.AliasTarget) (*elbv2.LoadBalancer, error) {
+	dnsName := aws.StringValue(alias.DNSName)
+	matchDnsName := strings.TrimSuffix(dnsName, ".")
+	if matchDnsName == "" {
+		return nil, fmt.Errorf("DNSName not set on AliasTarget")
+	}
+
+	matchHostedZoneId := aws.StringValue(alias.HostedZoneId)
+
+	var found []*elbv2.LoadBalancer
+	err := cloud.ELBV2().DescribeLoadBalancersPages(&elbv2.DescribeLoadBalancersInput{}, func(p *elbv2.DescribeLoadBalancersOutput, lastPage bool) bool {
+		for _, lb := range p.LoadBalancers {
+			if matchHostedZoneId != aws.StringValue(lb.CanonicalHostedZoneId) {
+				continue
+			}
+
+			lbDnsName := strings.TrimSuffix(aws.StringValue(lb.DNSName), ".")
+			if lbDnsName == matchDnsName || "dualstack."+lbDnsName == matchDnsName {
+				found = append(found, lb)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing NLBs: %v", err)
+	}
+
+	if len(found) == 0 {
+		return nil, nil
+	}
+	if len(found) != 1 {
+		return nil, fmt.Errorf("Found multiple NLBs with DNSName %q", dnsName)
+	}
+	return found[0], nil
+}
+
+func (e *NetworkLoadBalancer) Find(c *fi.Context) (*NetworkLoadBalancer, error) {
+	cloud := c.Cloud.(awsup.AWSCloud)
+
+	lb, err := findNetworkLoadBalancerByName(cloud, fi.StringValue(e.LoadBalancerName))
+	if err != nil {
+		return nil, err
+	}
+	if lb == nil {
+		return nil, nil
+	}
+
+	actual := &NetworkLoadBalancer{}
+	actual.Name = e.Name
+	actual.Lifecycle = e.Lifecycle
+	actual.LoadBalancerName = lb.LoadBalancerName
+	actual.ARN = lb.LoadBalancerArn
+	actual.DNSName = lb.DNSName
+	actual.HostedZoneId = lb.CanonicalHostedZoneId
+	actual.Scheme = lb.Scheme
+
+	for _, az := range lb.AvailabilityZones {
+		mapping := &SubnetMapping{
+			Subnet: &Subnet{ID: az.SubnetId},
+		}
+		for _, address := range az.LoadBalancerAddresses {
+			if address.AllocationId != nil {
+				mapping.ElasticIP = &ElasticIP{ID: address.AllocationId}
+			}
+		}
+		actual.SubnetMappings = append(actual.SubnetMappings, mapping)
+	}
+
+	listeners, err := cloud.ELBV2().DescribeListeners(&elbv2.DescribeListenersInput{LoadBalancerArn: lb.LoadBalancerArn})
+	if err != nil {
+		return nil, fmt.Errorf("error describing listeners of load balancer %q: %v", aws.StringValue(lb.LoadBalancerName), err)
+	}
+	actual.Listeners = make(map[string]*NetworkLoadBalancerListener)
+	for _, l := range listeners.Listeners {
+		port := strconv.FormatInt(aws.Int64Value(l.Port), 10)
+		actualListener := &NetworkLoadBalancerListener{}
+		for _, action := range l.DefaultActions {
+			if aws.StringValue(action.Type) == elbv2.ActionTypeEnumForward {
+				actualListener.TargetGroup = &TargetGroup{ARN: action.TargetGroupArn}
+			}
+		}
+
+		// Avoid spurious changes
+		if expected := e.Listeners[port]; expected != nil && expected.TargetGroup != nil && actualListener.TargetGroup != nil {
+			if fi.StringValue(expected.TargetGroup.ARN) == fi.StringValue(actualListener.TargetGroup.ARN) {
+				actualListener.TargetGroup = expected.TargetGroup
+			}
+		}
+		actual.Listeners[port] = actualListener
+	}
+
+	attributes, err := cloud.ELBV2().DescribeLoadBalancerAttributes(&elbv2.DescribeLoadBalancerAttributesInput{LoadBalancerArn: lb.LoadBalancerArn})
+	if err != nil {
+		return nil, fmt.Errorf("error describing attributes of load balancer %q: %v", aws.StringValue(lb.LoadBalancerName), err)
+	}
+	actual.CrossZoneLoadBalancing = fi.Bool(false)
+	for _, attribute := range attributes.Attributes {
+		if aws.StringValue(attribute.Key) == "load_balancing.cross_zone.enabled" {
+			actual.CrossZoneLoadBalancing = fi.Bool(aws.StringValue(attribute.Value) == "true")
+		}
+	}
+
+	tags, err := cloud.GetELBV2Tags(aws.StringValue(lb.LoadBalancerArn))
+	if err != nil {
+		return nil, err
+	}
+	actual.Tags = tags
+
+	// Avoid spurious mismatches
+	if subnetMappingsEqual(actual.SubnetMappings, e.SubnetMappings) {
+		actual.SubnetMappings = e.SubnetMappings
+	}
+
+	e.ARN = actual.ARN
+	if e.DNSName == nil {
+		e.DNSName = actual.DNSName
+	}
+	if e.HostedZoneId == nil {
+		e.HostedZoneId = actual.HostedZoneId
+	}
+
+	glog.V(4).Infof("Found NLB %+v", actual)
+
+	return actual, nil
+}
+
+var _ fi.HasAddress = &NetworkLoadBalancer{}
+
+func (e *NetworkLoadBalancer) FindIPAddress(context *fi.Context) (*string, error) {
+	cloud := context.Cloud.(awsup.AWSCloud)
+
+	lb, err := findNetworkLoadBalancerByName(cloud, fi.StringValue(e.LoadBalancerName))
+	if err != nil {
+		return nil, err
+	}
+	if lb == nil {
+		return nil, nil
+	}
+
+	lbDnsName := fi.StringValue(lb.DNSName)
+	if lbDnsName == "" {
+		return nil, nil
+	}
+	return &lbDnsName, nil
+}
+
+func (e *NetworkLoadBalancer) Run(c *fi.Context) error {
+	// We need to sort our arrays consistently, so we don't get spurious changes
+	sort.Stable(OrderSubnetMappingsBySubnetId(e.SubnetMappings))
+
+	return fi.DefaultDeltaRunMethod(e, c)
+}
+
+func (_ *NetworkLoadBalancer) CheckChanges(a, e, changes *NetworkLoadBalancer) error {
+	if a == nil {
+		if fi.StringValue(e.LoadBalancerName) == "" {
+			return fi.RequiredField("LoadBalancerName")
+		}
+		if len(e.SubnetMappings) == 0 {
+			return fi.RequiredField("SubnetMappings")
+		}
+		for _, mapping := range e.SubnetMappings {
+			if mapping.Subnet == nil {
+				return fi.RequiredField("SubnetMappings.Subnet")
+			}
+		}
+	} else {
+		// The subnets and addresses of an NLB are fixed when it is created
+		if changes.SubnetMappings != nil {
+			return fi.CannotChangeField("SubnetMappings")
+		}
+		if changes.Scheme != nil {
+			return fi.CannotChangeField("Scheme")
+		}
+	}
+	return nil
+}
+
+func (_ *NetworkLoadBalancer) RenderAWS(t *awsup.AWSAPITarget, a, e, changes *NetworkLoadBalancer) error {
+	if a == nil {
+		request := &elbv2.CreateLoadBalancerInput{
+			Name:   e.LoadBalancerName,
+			Scheme: e.Scheme,
+			Type:   aws.String(elbv2.LoadBalancerTypeEnumNetwork),
+		}
+		for _, mapping := range e.SubnetMappings {
+			m := &elbv2.SubnetMapping{SubnetId: mapping.Subnet.ID}
+			if mapping.ElasticIP != nil {
+				m.AllocationId = mapping.ElasticIP.ID
+			}
+			request.SubnetMappings = append(request.SubnetMappings, m)
+		}
+
+		glog.V(2).Infof("Creating NLB %q", fi.StringValue(e.LoadBalancerName))
+		response, err := t.Cloud.ELBV2().CreateLoadBalancer(request)
+		if err != nil {
+			return fmt.Errorf("error creating NLB: %v", err)
+		}
+		if len(response.LoadBalancers) != 1 {
+			return fmt.Errorf("unexpected response creating NLB %q: %v", fi.StringValue(e.LoadBalancerName), response)
+		}
+		lb := response.LoadBalancers[0]
+		e.ARN = lb.LoadBalancerArn
+		e.DNSName = lb.DNSName
+		e.HostedZoneId = lb.CanonicalHostedZoneId
+	}
+
+	if changes.CrossZoneLoadBalancing != nil {
+		request := &elbv2.ModifyLoadBalancerAttributesInput{
+			LoadBalancerArn: e.ARN,
+			Attributes: []*elbv2.LoadBalancerAttribute{
+				{
+					Key:   aws.String("load_balancing.cross_zone.enabled"),
+					Value: aws.String(strconv.FormatBool(fi.BoolValue(e.CrossZoneLoadBalancing))),
+				},
+			},
+		}
+
+		glog.V(2).Infof("Configuring cross-zone load balancing of NLB %q", fi.StringValue(e.LoadBalancerName))
+		if _, err := t.Cloud.ELBV2().ModifyLoadBalancerAttributes(request); err != nil {
+			return fmt.Errorf("error configuring NLB attributes: %v", err)
+		}
+	}
+
+	if changes.Listeners != nil {
+		existing := make(map[int64]*elbv2.Listener)
+		if a != nil {
+			response, err := t.Cloud.ELBV2().DescribeListeners(&elbv2.DescribeListenersInput{LoadBalancerArn: e.ARN})
+			if err != nil {
+				return fmt.Errorf("error describing NLB listeners: %v", err)
+			}
+			for _, l := range response.Listeners {
+				existing[aws.Int64Value(l.Port)] = l
+			}
+		}
+
+		for port, listener := range e.Listeners {
+			portInt, err := strconv.ParseInt(port, 10, 64)
+			if err != nil {
+				return fmt.Errorf("error parsing NLB listener port: %q", port)
+			}
+			actions := []*elbv2.Action{
+				{
+					Type:           aws.String(elbv2.ActionTypeEnumForward),
+					TargetGroupArn: listener.TargetGroup.ARN,
+				},
+			}
+
+			if l := existing[portInt]; l != nil {
+				glog.V(2).Infof("Updating NLB listener on port %d", portInt)
+				request := &elbv2.ModifyListenerInput{
+					ListenerArn:    l.ListenerArn,
+					DefaultActions: actions,
+				}
+				if _, err := t.Cloud.ELBV2().ModifyListener(request); err != nil {
+					return fmt.Errorf("error updating NLB listener: %v", err)
+				}
+			} else {
+				glog.V(2).Infof("Creating NLB listener on port %d", portInt)
+				request := &elbv2.CreateListenerInput{
+					LoadBalancerArn: e.ARN,
+					Port:            aws.Int64(portInt),
+					Protocol:        aws.String(elbv2.ProtocolEnumTcp),
+					DefaultActions:  actions,
+				}
+				if _, err := t.Cloud.ELBV2().CreateListener(request); err != nil {
+					return fmt.Errorf("error creating NLB listener: %v", err)
+				}
+			}
+		}
+	}
+
+	return t.AddELBV2Tags(fi.StringValue(e.ARN), e.Tags)
+}
+
+type terraformNetworkLoadBalancer struct {
+	Name                   *string                                `json:"name"`
+	Internal               *bool                                  `json:"internal,omitempty"`
+	LoadBalancerType       *string                                `json:"load_balancer_type"`
+	SubnetMappings         []*terraformNetworkLoadBalancerMapping `json:"subnet_mapping"`
+	CrossZoneLoadBalancing *bool                                  `json:"enable_cross_zone_load_balancing,omitempty"`
+	Tags                   map[string]string                      `json:"tags,omitempty"`
+}
+
+type terraformNetworkLoadBalancerMapping struct {
+	Subnet       *terraform.Literal `json:"subnet_id"`
+	AllocationID *terraform.Literal `json:"allocation_id,omitempty"`
+}
+
+type terraformNetworkLoadBalancerListener struct {
+	LoadBalancer  *terraform.Literal                            `json:"load_balancer_arn"`
+	Port          int64                                         `json:"port"`
+	Protocol      string                                        `json:"protocol"`
+	DefaultAction []*terraformNetworkLoadBalancerListenerAction `json:"default_action"`
+}
+
+type terraformNetworkLoadBalancerListenerAction struct {
+	Type        string             `json:"type"`
+	TargetGroup *terraform.Literal `json:"target_group_arn"`
+}
+
+func (_ *NetworkLoadBalancer) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *NetworkLoadBalancer) error {
+	tf := &terraformNetworkLoadBalancer{
+		Name:                   e.LoadBalancerName,
+		LoadBalancerType:       aws.String(elbv2.LoadBalancerTypeEnumNetwork),
+		CrossZoneLoadBalancing: e.CrossZoneLoadBalancing,
+		Tags: e.Tags,
+	}
+	if fi.StringValue(e.Scheme) == elbv2.LoadBalancerSchemeEnumInternal {
+		tf.Internal = fi.Bool(true)
+	}
+	for _, mapping := range e.SubnetMappings {
+		m := &terraformNetworkLoadBalancerMapping{
+			Subnet: mapping.Subnet.TerraformLink(),
+		}
+		if mapping.ElasticIP != nil {
+			m.AllocationID = mapping.ElasticIP.TerraformLink()
+		}
+		tf.SubnetMappings = append(tf.SubnetMappings, m)
+	}
+
+	if err := t.RenderResource("aws_lb", *e.Name, tf); err != nil {
+		return err
+	}
+
+	for _, port := range sortedListenerPorts(e.Listeners) {
+		portInt, err := strconv.ParseInt(port, 10, 64)
+		if err != nil {
+			return fmt.Errorf("error parsing NLB listener port: %q", port)
+		}
+		listener := &terraformNetworkLoadBalancerListener{
+			LoadBalancer: e.TerraformLink("arn"),
+			Port:         portInt,
+			Protocol:     elbv2.ProtocolEnumTcp,
+			DefaultAction: []*terraformNetworkLoadBalancerListenerAction{
+				{
+					Type:        elbv2.ActionTypeEnumForward,
+					TargetGroup: e.Listeners[port].TargetGroup.TerraformLink(),
+				},
+			},
+		}
+		if err := t.RenderResource("aws_lb_listener", *e.Name+"-"+port, listener); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *NetworkLoadBalancer) TerraformLink(params ...string) *terraform.Literal {
+	prop := "id"
+	if len(params) > 0 {
+		prop = params[0]
+	}
+	return terraform.LiteralProperty("aws_lb", *e.Name, prop)
+}
+
+type cloudformationNetworkLoadBalancer struct {
+	Name           *string                                     `json:"Name"`
+	Scheme         *string                                     `json:"Scheme,omitempty"`
+	Type           *string                                     `json:"Type"`
+	SubnetMappings []*cloudformationNetworkLoadBalancerMapping `json:"SubnetMappings"`
+	Attributes     []*cloudformationLoadBalancerAttribute      `json:"LoadBalancerAttributes,omitempty"`
+	Tags           []cloudformationTag                         `json:"Tags,omitempty"`
+}
+
+type cloudformationNetworkLoadBalancerMapping struct {
+	Subnet       *cloudformation.Literal `json:"SubnetId"`
+	AllocationID *cloudformation.Literal `json:"AllocationId,omitempty"`
+}
+
+type cloudformationLoadBalancerAttribute struct {
+	Key   string `json:"Key"`
+	Value string `json:"Value"`
+}
+
+type cloudformationNetworkLoadBalancerListener struct {
+	LoadBalancer   *cloudformation.Literal                            `json:"LoadBalancerArn"`
+	Port           int64                                              `json:"Port"`
+	Protocol       string                                             `json:"Protocol"`
+	DefaultActions []*cloudformationNetworkLoadBalancerListenerAction `json:"DefaultActions"`
+}
+
+type cloudformationNetworkLoadBalancerListenerAction struct {
+	Type        string                  `json:"Type"`
+	TargetGroup *cloudformation.Literal `json:"TargetGroupArn"`
+}
+
+func (_ *NetworkLoadBalancer) RenderCloudformation(t *cloudformation.CloudformationTarget, a, e, changes *NetworkLoadBalancer) error {
+	cf := &cloudformationNetworkLoadBalancer{
+		Name:   e.LoadBalancerName,
+		Scheme: e.Scheme,
+		Type:   aws.String(elbv2.LoadBalancerTypeEnumNetwork),
+		Tags:   buildCloudformationTags(e.Tags),
+	}
+	for _, mapping := range e.SubnetMappings {
+		m := &cloudformationNetworkLoadBalancerMapping{
+			Subnet: mapping.Subnet.CloudformationLink(),
+		}
+		if mapping.ElasticIP != nil {
+			m.AllocationID = mapping.ElasticIP.CloudformationAllocationID()
+		}
+		cf.SubnetMappings = append(cf.SubnetMappings, m)
+	}
+	if e.CrossZoneLoadBalancing != nil {
+		cf.Attributes = append(cf.Attributes, &cloudformationLoadBalancerAttribute{
+			Key:   "load_balancing.cross_zone.enabled",
+			Value: strconv.FormatBool(*e.CrossZoneLoadBalancing),
+		})
+	}
+
+	if err := t.RenderResource("AWS::ElasticLoadBalancingV2::LoadBalancer", *e.Name, cf); err != nil {
+		return err
+	}
+
+	for _, port := range sortedListenerPorts(e.Listeners) {
+		portInt, err := strconv.ParseInt(port, 10, 64)
+		if err != nil {
+			return fmt.Errorf("error parsing NLB listener port: %q", port)
+		}
+		listener := &cloudformationNetworkLoadBalancerListener{
+			LoadBalancer: e.CloudformationLink(),
+			Port:         portInt,
+			Protocol:     elbv2.ProtocolEnumTcp,
+			DefaultActions: []*cloudformationNetworkLoadBalancerListenerAction{
+				{
+					Type:        elbv2.ActionTypeEnumForward,
+					TargetGroup: e.Listeners[port].TargetGroup.CloudformationLink(),
+				},
+			},
+		}
+		if err := t.RenderResource("AWS::ElasticLoadBalancingV2::Listener", *e.Name+"-"+port, listener); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CloudformationLink returns the ARN of the NLB
+func (e *NetworkLoadBalancer) CloudformationLink() *cloudformation.Literal {
+	return cloudformation.Ref("AWS::ElasticLoadBalancingV2::LoadBalancer", *e.Name)
+}
+
+func (e *NetworkLoadBalancer) CloudformationAttrCanonicalHostedZoneID() *cloudformation.Literal {
+	return cloudformation.GetAtt("AWS::ElasticLoadBalancingV2::LoadBalancer", *e.Name, "CanonicalHostedZoneID")
+}
+
+func (e *NetworkLoadBalancer) CloudformationAttrDNSName() *cloudformation.Literal {
+	return cloudformation.GetAtt("AWS::ElasticLoadBalancingV2::LoadBalancer", *e.Name, "DNSName")
+}
+
+// sortedListenerPorts returns the ports of the listeners in order, so the rendered output is stable
+func sortedListenerPorts(listeners map[string]*NetworkLoadBalancerListener) []string {
+	var ports []string
+	for port := range listeners {
+		ports = append(ports, port)
+	}
+	sort.Strings(ports)
+	return ports
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package awstasks
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"k8s.io/kops/cloudmock/aws/mockec2"
+	"k8s.io/kops/cloudmock/aws/mockelbv2"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/awsup"
+)
+
+func TestNetworkLoadBalancerCreate(t *testing.T) {
+	cloud := awsup.BuildMockAWSCloud("us-east-1", "abc")
+	mockEC2 := &mockec2.MockEC2{}
+	cloud.MockEC2 = mockEC2
+	mockELBV2 := &mockelbv2.MockELBV2{}
+	cloud.MockELBV2 = mockELBV2
+
+	// We define a function so we can rebuild the tasks, because we modify in-place when running
+	buildTasks := func(crossZone bool) map[string]fi.Task {
+		vpc1 := &VPC{
+			Name: s("vpc1"),
+			CIDR: s("172.20.0.0/16"),
+			Tags: map[string]string{"Name": "vpc1"},
+		}
+		subnet1 := &Subnet{
+			Name: s("subnet1"),
+			VPC:  vpc1,
+			CIDR: s("172.20.1.0/24"),
+			Tags: map[string]string{"Name": "subnet1"},
+		}
+		eip1 := &ElasticIP{
+			Name: s("eip1"),
+			Tags: map[string]string{"Name": "eip1"},
+		}
+		tg1 := &TargetGroup{
+			Name:               s("tg1"),
+			TargetGroupName:    s("tg1"),
+			VPC:                vpc1,
+			Port:               fi.Int64(443),
+			Protocol:           s("TCP"),
+			HealthyThreshold:   fi.Int64(2),
+			UnhealthyThreshold: fi.Int64(2),
+			Interval:           fi.Int64(10),
+			Tags:               map[string]string{"Name": "tg1"},
+		}
+		nlb1 := &NetworkLoadBalancer{
+			Name:             s("nlb1"),
+			LoadBalancerName: s("nlb1"),
+			Scheme:           s("internet-facing"),
+			SubnetMappings: []*SubnetMapping{
+				{Subnet: subnet1, ElasticIP: eip1},
+			},
+			Listeners: map[string]*NetworkLoadBalancerListener{
+				"443": {TargetGroup: tg1},
+			},
+			CrossZoneLoadBalancing: fi.Bool(crossZone),
+			Tags: map[string]string{"Name": "nlb1"},
+		}
+
+		return map[string]fi.Task{
+			"vpc1":    vpc1,
+			"subnet1": subnet1,
+			"eip1":    eip1,
+			"tg1":     tg1,
+			"nlb1":    nlb1,
+		}
+	}
+
+	for _, crossZone := range []bool{true, false} {
+		{
+			allTasks := buildTasks(crossZone)
+			nlb1 := allTasks["nlb1"].(*NetworkLoadBalancer)
+			tg1 := allTasks["tg1"].(*TargetGroup)
+			eip1 := allTasks["eip1"].(*ElasticIP)
+
+			target := &awsup.AWSAPITarget{
+				Cloud: cloud,
+			}
+
+			context, err := fi.NewContext(target, nil, cloud, nil, nil, nil, true, allTasks)
+			if err != nil {
+				t.Fatalf("error building context: %v", err)
+			}
+
+			if err := context.RunTasks(testRunTasksOptions); err != nil {
+				t.Fatalf("unexpected error during Run: %v", err)
+			}
+
+			if fi.StringValue(nlb1.ARN) == "" || fi.StringValue(nlb1.DNSName) == "" {
+				t.Fatalf("ARN and DNSName not set after create")
+			}
+			if len(mockELBV2.LoadBalancers) != 1 {
+				t.Fatalf("Expected exactly one load balancer; found %v", mockELBV2.LoadBalancers)
+			}
+			if len(mockELBV2.TargetGroups) != 1 {
+				t.Fatalf("Expected exactly one target group; found %v", mockELBV2.TargetGroups)
+			}
+
+			lbs, err := mockELBV2.DescribeLoadBalancers(&elbv2.DescribeLoadBalancersInput{})
+			if err != nil {
+				t.Fatalf("error describing load balancers: %v", err)
+			}
+			lb := lbs.LoadBalancers[0]
+			if aws.StringValue(lb.Type) != elbv2.LoadBalancerTypeEnumNetwork {
+				t.Fatalf("Unexpected load balancer type %q", aws.StringValue(lb.Type))
+			}
+			if len(lb.AvailabilityZones) != 1 || len(lb.AvailabilityZones[0].LoadBalancerAddresses) != 1 ||
+				aws.StringValue(lb.AvailabilityZones[0].LoadBalancerAddresses[0].AllocationId) != fi.StringValue(eip1.ID) {
+				t.Fatalf("Expected load balancer to use ElasticIP %q; found %v", fi.StringValue(eip1.ID), lb.AvailabilityZones)
+			}
+
+			listeners, err := mockELBV2.DescribeListeners(&elbv2.DescribeListenersInput{LoadBalancerArn: nlb1.ARN})
+			if err != nil {
+				t.Fatalf("error describing listeners: %v", err)
+			}
+			if len(listeners.Listeners) != 1 {
+				t.Fatalf("Expected exactly one listener; found %v", listeners.Listeners)
+			}
+			listener := listeners.Listeners[0]
+			if aws.Int64Value(listener.Port) != 443 || aws.StringValue(listener.Protocol) != elbv2.ProtocolEnumTcp ||
+				aws.StringValue(listener.DefaultActions[0].TargetGroupArn) != fi.StringValue(tg1.ARN) {
+				t.Fatalf("Unexpected listener %v", listener)
+			}
+
+			attributes, err := mockELBV2.DescribeLoadBalancerAttributes(&elbv2.DescribeLoadBalancerAttributesInput{LoadBalancerArn: nlb1.ARN})
+			if err != nil {
+				t.Fatalf("error describing load balancer attributes: %v", err)
+			}
+			expected := "false"
+			if crossZone {
+				expected = "true"
+			}
+			if len(attributes.Attributes) != 1 || aws.StringValue(attributes.Attributes[0].Value) != expected {
+				t.Fatalf("Expected cross-zone load balancing to be %s; found %v", expected, attributes.Attributes)
+			}
+		}
+
+		{
+			allTasks := buildTasks(crossZone)
+			checkNoChanges(t, cloud, allTasks)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package awstasks
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/golang/glog"
+
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/awsup"
+	"k8s.io/kops/upup/pkg/fi/cloudup/cloudformation"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
+)
+
+// SQSQueue manages an SQS queue, to which LifecycleHooks send their notifications.
+// We find the existing queue by its QueueName, which is unique in the account and region.
+//
+//go:generate fitask -type=SQSQueue
+type SQSQueue struct {
+	Name      *string
+	Lifecycle *fi.Lifecycle
+
+	// QueueName is the name in SQS, which can only contain letters, digits, hyphens and underscores
+	QueueName *string
+
+	URL *string
+	ARN *string
+
+	// MessageRetentionPeriod is the number of seconds SQS keeps a message that has not been deleted
+	MessageRetentionPeriod *int64
+
+	Tags map[string]string
+}
+
+var _ fi.CompareWithID = &SQSQueue{}
+
+func (e *SQSQueue) CompareWithID() *string {
+	return e.ARN
+}
+
+func (e *SQSQueue) Find(c *fi.Context) (*SQSQueue, error) {
+	cloud := c.Cloud.(awsup.AWSCloud)
+
+	response, err := cloud.SQS().GetQueueUrl(&sqs.GetQueueUrlInput{QueueName: e.QueueName})
+	if err != nil {
+		if awsup.AWSErrorCode(err) == sqs.ErrCodeQueueDoesNotExist {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error getting URL of SQS queue %q: %v", fi.StringValue(e.QueueName), err)
+	}
+
+	actual := &SQSQueue{
+		Name:      e.Name,
+		Lifecycle: e.Lifecycle,
+		QueueName: e.QueueName,
+		URL:       response.QueueUrl,
+	}
+
+	attributes, err := cloud.SQS().GetQueueAttributes(&sqs.GetQueueAttributesInput{
+		QueueUrl: actual.URL,
+		AttributeNames: []*string{
+			aws.String(sqs.QueueAttributeNameQueueArn),
+			aws.String(sqs.QueueAttributeNameMessageRetentionPeriod),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting attributes of SQS queue %q: %v", fi.StringValue(e.QueueName), err)
+	}
+	actual.ARN = attributes.Attributes[sqs.QueueAttributeNameQueueArn]
+	if v, ok := attributes.Attributes[sqs.QueueAttributeNameMessageRetentionPeriod]; ok {
+		period, err := strconv.ParseInt(aws.StringValue(v), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing MessageRetentionPeriod %q of SQS queue %q: %v", aws.StringValue(v), fi.StringValue(e.QueueName), err)
+		}
+		actual.MessageRetentionPeriod = aws.Int64(period)
+	}
+
+	tags, err := cloud.SQS().ListQueueTags(&sqs.ListQueueTagsInput{QueueUrl: actual.URL})
+	if err != nil {
+		return nil, fmt.Errorf("error listing tags of SQS queue %q: %v", fi.StringValue(e.QueueName), err)
+	}
+	if len(tags.Tags) != 0 {
+		actual.Tags = make(map[string]string)
+		for k, v := range tags.Tags {
+			actual.Tags[k] = aws.StringValue(v)
+		}
+	}
+
+	e.URL = actual.URL
+	e.ARN = actual.ARN
+
+	glog.V(4).Infof("Found SQS queue %+v", actual)
+
+	return actual, nil
+}
+
+func (e *SQSQueue) Run(c *fi.Context) error {
+	return fi.DefaultDeltaRunMethod(e, c)
+}
+
+func (_ *SQSQueue) CheckChanges(a, e, changes *SQSQueue) error {
+	if a == nil {
+		if e.QueueName == nil {
+			return fi.RequiredField("QueueName")
+		}
+	}
+	return nil
+}
+
+func (_ *SQSQueue) RenderAWS(t *awsup.AWSAPITarget, a, e, changes *SQSQueue) error {
+	attributes := make(map[string]*string)
+	if e.MessageRetentionPeriod != nil {
+		attributes[sqs.QueueAttributeNameMessageRetentionPeriod] = aws.String(strconv.FormatInt(*e.MessageRetentionPeriod, 10))
+	}
+
+	if a == nil {
+		glog.V(2).Infof("Creating SQS queue %q", fi.StringValue(e.QueueName))
+		response, err := t.Cloud.SQS().CreateQueue(&sqs.CreateQueueInput{
+			QueueName:  e.QueueName,
+			Attributes: attributes,
+		})
+		if err != nil {
+			return fmt.Errorf("error creating SQS queue: %v", err)
+		}
+		e.URL = response.QueueUrl
+
+		queueAttributes, err := t.Cloud.SQS().GetQueueAttributes(&sqs.GetQueueAttributesInput{
+			QueueUrl:       e.URL,
+			AttributeNames: []*string{aws.String(sqs.QueueAttributeNameQueueArn)},
+		})
+		if err != nil {
+			return fmt.Errorf("error getting ARN of SQS queue %q: %v", fi.StringValue(e.QueueName), err)
+		}
+		e.ARN = queueAttributes.Attributes[sqs.QueueAttributeNameQueueArn]
+	} else if changes.MessageRetentionPeriod != nil {
+		glog.V(2).Infof("Updating SQS queue %q", fi.StringValue(e.QueueName))
+		_, err := t.Cloud.SQS().SetQueueAttributes(&sqs.SetQueueAttributesInput{
+			QueueUrl:   a.URL,
+			Attributes: attributes,
+		})
+		if err != nil {
+			return fmt.Errorf("error updating SQS queue: %v", err)
+		}
+	}
+
+	if len(e.Tags) != 0 && (a == nil || changes.Tags != nil) {
+		tags := make(map[string]*string)
+		for k, v := range e.Tags {
+			tags[k] = aws.String(v)
+		}
+		if _, err := t.Cloud.SQS().TagQueue(&sqs.TagQueueInput{QueueUrl: e.URL, Tags: tags}); err != nil {
+			return fmt.Errorf("error tagging SQS queue %q: %v", fi.StringValue(e.QueueName), err)
+		}
+	}
+
+	return nil
+}
+
+type terraformSQSQueue struct {
+	Name                    *string           `json:"name"`
+	MessageRetentionSeconds *int64            `json:"message_retention_seconds,omitempty"`
+	Tags                    map[string]string `json:"tags,omitempty"`
+}
+
+func (_ *SQSQueue) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *SQSQueue) error {
+	tf := &terraformSQSQueue{
+		Name: e.QueueName,
+		MessageRetentionSeconds: e.MessageRetentionPeriod,
+		Tags: e.Tags,
+	}
+
+	return t.RenderResource("aws_sqs_queue", *e.Name, tf)
+}
+
+func (e *SQSQueue) TerraformLink() *terraform.Literal {
+	return terraform.LiteralProperty("aws_sqs_queue", *e.Name, "arn")
+}
+
+type cloudformationSQSQueue struct {
+	QueueName              *string             `json:"QueueName"`
+	MessageRetentionPeriod *int64              `json:"MessageRetentionPeriod,omitempty"`
+	Tags                   []cloudformationTag `json:"Tags,omitempty"`
+}
+
+func (_ *SQSQueue) RenderCloudformation(t *cloudformation.CloudformationTarget, a, e, changes *SQSQueue) error {
+	cf := &cloudformationSQSQueue{
+		QueueName:              e.QueueName,
+		MessageRetentionPeriod: e.MessageRetentionPeriod,
+		Tags: buildCloudformationTags(e.Tags),
+	}
+
+	return t.RenderResource("AWS::SQS::Queue", *e.Name, cf)
+}
+
+// CloudformationLink returns the ARN of the queue
+func (e *SQSQueue) CloudformationLink() *cloudformation.Literal {
+	return cloudformation.GetAtt("AWS::SQS::Queue", *e.Name, "Arn")
+}
@@ -0,0 +1,265 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package awstasks
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/golang/glog"
+
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/awsup"
+	"k8s.io/kops/upup/pkg/fi/cloudup/cloudformation"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
+)
+
+// TargetGroup manages an ELBV2 target group, to which a NetworkLoadBalancer forwards traffic.
+// We find the existing target group by its TargetGroupName, which is unique in the account and region.
+//
+//go:generate fitask -type=TargetGroup
+type TargetGroup struct {
+	Name      *string
+	Lifecycle *fi.Lifecycle
+
+	// TargetGroupName is the name in ELB, possibly different from our name
+	// (ELB names are restricted to 32 characters)
+	TargetGroupName *string
+
+	ARN *string
+	VPC *VPC
+
+	Port     *int64
+	Protocol *string
+
+	// HealthyThreshold and UnhealthyThreshold are the number of health checks before a target is marked healthy or unhealthy;
+	// they must be equal for a network load balancer
+	HealthyThreshold   *int64
+	UnhealthyThreshold *int64
+	// Interval is the number of seconds between health checks, which must be 10 or 30 for a network load balancer
+	Interval *int64
+
+	Tags map[string]string
+}
+
+var _ fi.CompareWithID = &TargetGroup{}
+
+func (e *TargetGroup) CompareWithID() *string {
+	return e.ARN
+}
+
+func findTargetGroupByName(cloud awsup.AWSCloud, name string) (*elbv2.TargetGroup, error) {
+	request := &elbv2.DescribeTargetGroupsInput{
+		Names: []*string{aws.String(name)},
+	}
+
+	response, err := cloud.ELBV2().DescribeTargetGroups(request)
+	if err != nil {
+		if awsup.AWSErrorCode(err) == elbv2.ErrCodeTargetGroupNotFoundException {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error describing target group %q: %v", name, err)
+	}
+
+	if response == nil || len(response.TargetGroups) == 0 {
+		return nil, nil
+	}
+	if len(response.TargetGroups) != 1 {
+		return nil, fmt.Errorf("found multiple target groups with name %q", name)
+	}
+	return response.TargetGroups[0], nil
+}
+
+func (e *TargetGroup) Find(c *fi.Context) (*TargetGroup, error) {
+	cloud := c.Cloud.(awsup.AWSCloud)
+
+	tg, err := findTargetGroupByName(cloud, fi.StringValue(e.TargetGroupName))
+	if err != nil {
+		return nil, err
+	}
+	if tg == nil {
+		return nil, nil
+	}
+
+	actual := &TargetGroup{
+		Name:               e.Name,
+		Lifecycle:          e.Lifecycle,
+		TargetGroupName:    tg.TargetGroupName,
+		ARN:                tg.TargetGroupArn,
+		VPC:                &VPC{ID: tg.VpcId},
+		Port:               tg.Port,
+		Protocol:           tg.Protocol,
+		HealthyThreshold:   tg.HealthyThresholdCount,
+		UnhealthyThreshold: tg.UnhealthyThresholdCount,
+		Interval:           tg.HealthCheckIntervalSeconds,
+	}
+
+	tags, err := cloud.GetELBV2Tags(aws.StringValue(tg.TargetGroupArn))
+	if err != nil {
+		return nil, err
+	}
+	actual.Tags = tags
+
+	e.ARN = actual.ARN
+
+	glog.V(4).Infof("Found target group %+v", actual)
+
+	return actual, nil
+}
+
+func (e *TargetGroup) Run(c *fi.Context) error {
+	return fi.DefaultDeltaRunMethod(e, c)
+}
+
+func (_ *TargetGroup) CheckChanges(a, e, changes *TargetGroup) error {
+	if a == nil {
+		if e.TargetGroupName == nil {
+			return fi.RequiredField("TargetGroupName")
+		}
+		if e.VPC == nil {
+			return fi.RequiredField("VPC")
+		}
+		if e.Port == nil {
+			return fi.RequiredField("Port")
+		}
+		if e.Protocol == nil {
+			return fi.RequiredField("Protocol")
+		}
+	} else {
+		if changes.Port != nil {
+			return fi.CannotChangeField("Port")
+		}
+		if changes.Protocol != nil {
+			return fi.CannotChangeField("Protocol")
+		}
+		if changes.VPC != nil {
+			return fi.CannotChangeField("VPC")
+		}
+	}
+	return nil
+}
+
+func (_ *TargetGroup) RenderAWS(t *awsup.AWSAPITarget, a, e, changes *TargetGroup) error {
+	if a == nil {
+		request := &elbv2.CreateTargetGroupInput{
+			Name:                       e.TargetGroupName,
+			Port:                       e.Port,
+			Protocol:                   e.Protocol,
+			VpcId:                      e.VPC.ID,
+			HealthCheckProtocol:        aws.String(elbv2.ProtocolEnumTcp),
+			HealthyThresholdCount:      e.HealthyThreshold,
+			UnhealthyThresholdCount:    e.UnhealthyThreshold,
+			HealthCheckIntervalSeconds: e.Interval,
+		}
+
+		glog.V(2).Infof("Creating target group %q", fi.StringValue(e.TargetGroupName))
+		response, err := t.Cloud.ELBV2().CreateTargetGroup(request)
+		if err != nil {
+			return fmt.Errorf("error creating target group: %v", err)
+		}
+		if len(response.TargetGroups) != 1 {
+			return fmt.Errorf("unexpected response creating target group %q: %v", fi.StringValue(e.TargetGroupName), response)
+		}
+		e.ARN = response.TargetGroups[0].TargetGroupArn
+	} else if changes.HealthyThreshold != nil || changes.UnhealthyThreshold != nil || changes.Interval != nil {
+		request := &elbv2.ModifyTargetGroupInput{
+			TargetGroupArn:             a.ARN,
+			HealthyThresholdCount:      e.HealthyThreshold,
+			UnhealthyThresholdCount:    e.UnhealthyThreshold,
+			HealthCheckIntervalSeconds: e.Interval,
+		}
+
+		glog.V(2).Infof("Updating health check of target group %q", fi.StringValue(e.TargetGroupName))
+		if _, err := t.Cloud.ELBV2().ModifyTargetGroup(request); err != nil {
+			return fmt.Errorf("error updating target group: %v", err)
+		}
+	}
+
+	return t.AddELBV2Tags(fi.StringValue(e.ARN), e.Tags)
+}
+
+type terraformTargetGroup struct {
+	Name        *string                          `json:"name"`
+	Port        *int64                           `json:"port"`
+	Protocol    *string                          `json:"protocol"`
+	VPC         *terraform.Literal               `json:"vpc_id"`
+	HealthCheck *terraformTargetGroupHealthCheck `json:"health_check,omitempty"`
+	Tags        map[string]string                `json:"tags,omitempty"`
+}
+
+type terraformTargetGroupHealthCheck struct {
+	Protocol           *string `json:"protocol"`
+	HealthyThreshold   *int64  `json:"healthy_threshold,omitempty"`
+	UnhealthyThreshold *int64  `json:"unhealthy_threshold,omitempty"`
+	Interval           *int64  `json:"interval,omitempty"`
+}
+
+func (_ *TargetGroup) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *TargetGroup) error {
+	tf := &terraformTargetGroup{
+		Name:     e.TargetGroupName,
+		Port:     e.Port,
+		Protocol: e.Protocol,
+		VPC:      e.VPC.TerraformLink(),
+		HealthCheck: &terraformTargetGroupHealthCheck{
+			Protocol:           aws.String(elbv2.ProtocolEnumTcp),
+			HealthyThreshold:   e.HealthyThreshold,
+			UnhealthyThreshold: e.UnhealthyThreshold,
+			Interval:           e.Interval,
+		},
+		Tags: e.Tags,
+	}
+
+	return t.RenderResource("aws_lb_target_group", *e.Name, tf)
+}
+
+func (e *TargetGroup) TerraformLink() *terraform.Literal {
+	return terraform.LiteralProperty("aws_lb_target_group", *e.Name, "arn")
+}
+
+type cloudformationTargetGroup struct {
+	Name                       *string                 `json:"Name"`
+	Port                       *int64                  `json:"Port"`
+	Protocol                   *string                 `json:"Protocol"`
+	VPC                        *cloudformation.Literal `json:"VpcId"`
+	HealthCheckProtocol        *string                 `json:"HealthCheckProtocol,omitempty"`
+	HealthyThresholdCount      *int64                  `json:"HealthyThresholdCount,omitempty"`
+	UnhealthyThresholdCount    *int64                  `json:"UnhealthyThresholdCount,omitempty"`
+	HealthCheckIntervalSeconds *int64                  `json:"HealthCheckIntervalSeconds,omitempty"`
+	Tags                       []cloudformationTag     `json:"Tags,omitempty"`
+}
+
+func (_ *TargetGroup) RenderCloudformation(t *cloudformation.CloudformationTarget, a, e, changes *TargetGroup) error {
+	cf := &cloudformationTargetGroup{
+		Name:                       e.TargetGroupName,
+		Port:                       e.Port,
+		Protocol:                   e.Protocol,
+		VPC:                        e.VPC.CloudformationLink(),
+		HealthCheckProtocol:        aws.String(elbv2.ProtocolEnumTcp),
+		HealthyThresholdCount:      e.HealthyThreshold,
+		UnhealthyThresholdCount:    e.UnhealthyThreshold,
+		HealthCheckIntervalSeconds: e.Interval,
+		Tags: buildCloudformationTags(e.Tags),
+	}
+
+	return t.RenderResource("AWS::ElasticLoadBalancingV2::TargetGroup", *e.Name, cf)
+}
+
+// CloudformationLink returns the ARN of the target group
+func (e *TargetGroup) CloudformationLink() *cloudformation.Literal {
+	return cloudformation.Ref("AWS::ElasticLoadBalancingV2::TargetGroup", *e.Name)
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package awstasks
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/golang/glog"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/upup/pkg/fi/cloudup/awsup"
+	"k8s.io/kops/upup/pkg/fi/cloudup/cloudformation"
+	"k8s.io/kops/upup/pkg/fi/cloudup/terraform"
+)
+
+// TargetGroupAttachment registers the instances of an AutoscalingGroup with a TargetGroup we manage
+//
+//go:generate fitask -type=TargetGroupAttachment
+type TargetGroupAttachment struct {
+	Name      *string
+	Lifecycle *fi.Lifecycle
+
+	TargetGroup      *TargetGroup
+	AutoscalingGroup *AutoscalingGroup
+}
+
+func (e *TargetGroupAttachment) Find(c *fi.Context) (*TargetGroupAttachment, error) {
+	cloud := c.Cloud.(awsup.AWSCloud)
+
+	if e.TargetGroup == nil || e.AutoscalingGroup == nil {
+		return nil, fmt.Errorf("TargetGroupAttachment must specify TargetGroup and AutoscalingGroup")
+	}
+	if e.TargetGroup.ARN == nil {
+		// The target group does not exist yet, so cannot be attached
+		return nil, nil
+	}
+
+	g, err := findAutoscalingGroup(cloud, *e.AutoscalingGroup.Name)
+	if err != nil {
+		return nil, err
+	}
+	if g == nil {
+		return nil, nil
+	}
+
+	for _, arn := range g.TargetGroupARNs {
+		if aws.StringValue(arn) != aws.StringValue(e.TargetGroup.ARN) {
+			continue
+		}
+
+		actual := &TargetGroupAttachment{}
+		actual.TargetGroup = e.TargetGroup
+		actual.AutoscalingGroup = e.AutoscalingGroup
+
+		// Prevent spurious changes
+		actual.Name = e.Name // attachments don't have tags
+		actual.Lifecycle = e.Lifecycle
+
+		return actual, nil
+	}
+
+	return nil, nil
+}
+
+func (e *TargetGroupAttachment) Run(c *fi.Context) error {
+	return fi.DefaultDeltaRunMethod(e, c)
+}
+
+func (s *TargetGroupAttachment) CheckChanges(a, e, changes *TargetGroupAttachment) error {
+	if a == nil {
+		if e.TargetGroup == nil {
+			return fi.RequiredField("TargetGroup")
+		}
+		if e.AutoscalingGroup == nil {
+			return fi.RequiredField("AutoscalingGroup")
+		}
+	}
+	return nil
+}
+
+func (_ *TargetGroupAttachment) RenderAWS(t *awsup.AWSAPITarget, a, e, changes *TargetGroupAttachment) error {
+	targetGroupARN := fi.StringValue(e.TargetGroup.ARN)
+	if targetGroupARN == "" {
+		return fi.RequiredField("TargetGroup.ARN")
+	}
+
+	request := &autoscaling.AttachLoadBalancerTargetGroupsInput{}
+	request.AutoScalingGroupName = e.AutoscalingGroup.Name
+	request.TargetGroupARNs = aws.StringSlice([]string{targetGroupARN})
+
+	glog.V(2).Infof("Attaching autoscaling group %q to target group %q", fi.StringValue(e.AutoscalingGroup.Name), targetGroupARN)
+	_, err := t.Cloud.Autoscaling().AttachLoadBalancerTargetGroups(request)
+	if err != nil {
+		return fmt.Errorf("error attaching autoscaling group to target group: %v", err)
+	}
+
+	return nil
+}
+
+type terraformTargetGroupAttachment struct {
+	TargetGroupARN   *terraform.Literal `json:"alb_target_group_arn"`
+	AutoscalingGroup *terraform.Literal `json:"autoscaling_group_name"`
+}
+
+func (_ *TargetGroupAttachment) RenderTerraform(t *terraform.TerraformTarget, a, e, changes *TargetGroupAttachment) error {
+	tf := &terraformTargetGroupAttachment{
+		TargetGroupARN:   e.TargetGroup.TerraformLink(),
+		AutoscalingGroup: e.AutoscalingGroup.TerraformLink(),
+	}
+
+	return t.RenderResource("aws_autoscaling_attachment", *e.Name, tf)
+}
+
+func (e *TargetGroupAttachment) TerraformLink() *terraform.Literal {
+	return terraform.LiteralProperty("aws_autoscaling_attachment", *e.Name, "id")
+}
+
+func (_ *TargetGroupAttachment) RenderCloudformation(t *cloudformation.CloudformationTarget, a, e, changes *TargetGroupAttachment) error {
+	cfObj, ok := t.Find(e.AutoscalingGroup.CloudformationLink())
+	if !ok {
+		// topo-sort fail?
+		return fmt.Errorf("AutoScalingGroup not yet rendered")
+	}
+	cf, ok := cfObj.(*cloudformationAutoscalingGroup)
+	if !ok {
+		return fmt.Errorf("unexpected type for CF record: %T", cfObj)
+	}
+
+	cf.TargetGroupARNs = append(cf.TargetGroupARNs, e.TargetGroup.CloudformationLink())
+	return nil
+}
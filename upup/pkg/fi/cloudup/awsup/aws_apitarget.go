@@ -80,6 +80,32 @@ func (t *AWSAPITarget) AddELBTags(loadBalancerName string, expected map[string]s
 	return nil
 }
 
+func (t *AWSAPITarget) AddELBV2Tags(ResourceArn string, expected map[string]string) error {
+	actual, err := t.Cloud.GetELBV2Tags(ResourceArn)
+	if err != nil {
+		return fmt.Errorf("unexpected error fetching tags for resource: %v", err)
+	}
+
+	missing := map[string]string{}
+	for k, v := range expected {
+		actualValue, found := actual[k]
+		if found && actualValue == v {
+			continue
+		}
+		missing[k] = v
+	}
+
+	if len(missing) != 0 {
+		glog.V(4).Infof("adding tags to %q: %v", ResourceArn, missing)
+		err := t.Cloud.CreateELBV2Tags(ResourceArn, missing)
+		if err != nil {
+			return fmt.Errorf("error adding tags to %q: %v", ResourceArn, err)
+		}
+	}
+
+	return nil
+}
+
 func (t *AWSAPITarget) WaitForInstanceRunning(instanceID string) error {
 	attempt := 0
 	for {
@@ -18,6 +18,7 @@ package awsup
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -39,6 +40,8 @@ import (
 	"github.com/aws/aws-sdk-go/service/iam/iamiface"
 	"github.com/aws/aws-sdk-go/service/route53"
 	"github.com/aws/aws-sdk-go/service/route53/route53iface"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
 	"github.com/golang/glog"
 
 	"k8s.io/api/core/v1"
@@ -104,6 +107,7 @@ type AWSCloud interface {
 	ELBV2() elbv2iface.ELBV2API
 	Autoscaling() autoscalingiface.AutoScalingAPI
 	Route53() route53iface.Route53API
+	SQS() sqsiface.SQSAPI
 	Spotinst() spotinst.Service
 
 	// TODO: Document and rationalize these tags/filters methods
@@ -124,6 +128,12 @@ type AWSCloud interface {
 	// CreateELBTags will add tags to the specified loadBalancer, retrying up to MaxCreateTagsAttempts times if it hits an eventual-consistency type error
 	CreateELBTags(loadBalancerName string, tags map[string]string) error
 
+	// GetELBV2Tags will fetch the tags for the specified NLB, ALB or target group
+	GetELBV2Tags(ResourceArn string) (map[string]string, error)
+
+	// CreateELBV2Tags will add tags to the specified NLB, ALB or target group, retrying up to MaxCreateTagsAttempts times if it hits an eventual-consistency type error
+	CreateELBV2Tags(ResourceArn string, tags map[string]string) error
+
 	// DeleteTags will delete tags from the specified resource, retrying up to MaxCreateTagsAttempts times if it hits an eventual-consistency type error
 	DeleteTags(id string, tags map[string]string) error
 
@@ -160,6 +170,7 @@ type awsCloudImplementation struct {
 	elbv2       *elbv2.ELBV2
 	autoscaling *autoscaling.AutoScaling
 	route53     *route53.Route53
+	sqs         *sqs.SQS
 	spotinst    spotinst.Service
 
 	region string
@@ -269,6 +280,14 @@ func NewAWSCloud(region string, tags map[string]string) (AWSCloud, error) {
 		c.route53.Handlers.Send.PushFront(requestLogger)
 		c.addHandlers(region, &c.route53.Handlers)
 
+		sess, err = session.NewSession(config)
+		if err != nil {
+			return c, err
+		}
+		c.sqs = sqs.New(sess, config)
+		c.sqs.Handlers.Send.PushFront(requestLogger)
+		c.addHandlers(region, &c.sqs.Handlers)
+
 		if featureflag.Spotinst.Enabled() {
 			c.spotinst, err = spotinst.NewService(kops.CloudProviderAWS)
 			if err != nil {
@@ -348,6 +367,7 @@ func deleteGroup(c AWSCloud, g *cloudinstances.CloudInstanceGroup) error {
 
 	name := aws.StringValue(asg.AutoScalingGroupName)
 	template := aws.StringValue(asg.LaunchConfigurationName)
+	launchTemplate := AutoscalingGroupLaunchTemplate(asg)
 
 	// Delete ASG
 	{
@@ -362,8 +382,23 @@ func deleteGroup(c AWSCloud, g *cloudinstances.CloudInstanceGroup) error {
 		}
 	}
 
-	// Delete LaunchConfig
-	{
+	if launchTemplate != nil {
+		// Delete LaunchTemplate, along with all its versions
+		id := aws.StringValue(launchTemplate.LaunchTemplateId)
+		request := &ec2.DeleteLaunchTemplateInput{}
+		if id != "" {
+			request.LaunchTemplateId = launchTemplate.LaunchTemplateId
+		} else {
+			id = aws.StringValue(launchTemplate.LaunchTemplateName)
+			request.LaunchTemplateName = launchTemplate.LaunchTemplateName
+		}
+		glog.V(2).Infof("Deleting launch template %q", id)
+		_, err := c.EC2().DeleteLaunchTemplate(request)
+		if err != nil {
+			return fmt.Errorf("error deleting launch template %q: %v", id, err)
+		}
+	} else {
+		// Delete LaunchConfig
 		glog.V(2).Infof("Deleting autoscaling launch configuration %q", template)
 		request := &autoscaling.DeleteLaunchConfigurationInput{
 			LaunchConfigurationName: aws.String(template),
@@ -379,16 +414,59 @@ func deleteGroup(c AWSCloud, g *cloudinstances.CloudInstanceGroup) error {
 	return nil
 }
 
+// ResizeGroup sets the desired capacity of an aws autoscaling group
+func (c *awsCloudImplementation) ResizeGroup(g *cloudinstances.CloudInstanceGroup, size int) error {
+	if c.spotinst != nil {
+		return fmt.Errorf("resizing groups is not supported with spotinst")
+	}
+
+	return resizeGroup(c, g, size)
+}
+
+func resizeGroup(c AWSCloud, g *cloudinstances.CloudInstanceGroup, size int) error {
+	asg := g.Raw.(*autoscaling.Group)
+
+	name := aws.StringValue(asg.AutoScalingGroupName)
+
+	// We raise the MaxSize if we are surging past it, and return it to the group's
+	// original MaxSize when we shrink back down
+	maxSize := g.MaxSize
+	if size > maxSize {
+		maxSize = size
+	}
+
+	glog.V(2).Infof("Resizing autoscaling group %q to %d (maxSize %d)", name, size, maxSize)
+	request := &autoscaling.UpdateAutoScalingGroupInput{
+		AutoScalingGroupName: aws.String(name),
+		DesiredCapacity:      aws.Int64(int64(size)),
+		MaxSize:              aws.Int64(int64(maxSize)),
+	}
+	if _, err := c.Autoscaling().UpdateAutoScalingGroup(request); err != nil {
+		return fmt.Errorf("error resizing autoscaling group %q: %v", name, err)
+	}
+
+	return nil
+}
+
 // DeleteInstance deletes an aws instance
 func (c *awsCloudImplementation) DeleteInstance(i *cloudinstances.CloudInstanceGroupMember) error {
 	if c.spotinst != nil {
 		return spotinst.DeleteInstance(c.spotinst, i)
 	}
 
-	return deleteInstance(c, i)
+	return deleteInstance(c, i, false)
+}
+
+// DeleteInstanceWithoutReplacement terminates an instance and decrements the desired capacity of its autoscaling group
+func (c *awsCloudImplementation) DeleteInstanceWithoutReplacement(i *cloudinstances.CloudInstanceGroupMember) error {
+	if c.spotinst != nil {
+		return fmt.Errorf("deleting instances without replacement is not supported with spotinst")
+	}
+
+	return deleteInstance(c, i, true)
 }
 
-func deleteInstance(c AWSCloud, i *cloudinstances.CloudInstanceGroupMember) error {
+func deleteInstance(c AWSCloud, i *cloudinstances.CloudInstanceGroupMember, decrementDesiredCapacity bool) error {
 	id := i.ID
 	if id == "" {
 		return fmt.Errorf("id was not set on CloudInstanceGroupMember: %v", i)
@@ -396,7 +474,7 @@ func deleteInstance(c AWSCloud, i *cloudinstances.CloudInstanceGroupMember) erro
 
 	request := &autoscaling.TerminateInstanceInAutoScalingGroupInput{
 		InstanceId:                     aws.String(id),
-		ShouldDecrementDesiredCapacity: aws.Bool(false),
+		ShouldDecrementDesiredCapacity: aws.Bool(decrementDesiredCapacity),
 	}
 
 	if _, err := c.Autoscaling().TerminateInstanceInAutoScalingGroup(request); err != nil {
@@ -548,14 +626,72 @@ func matchesAsgTags(tags map[string]string, actual []*autoscaling.TagDescription
 	return true
 }
 
+// findInstanceLaunchConfiguration identifies the launch configuration an instance was launched from,
+// which for a launch template is the name and version of the template
+func findInstanceLaunchConfiguration(i *autoscaling.Instance) string {
+	if i.LaunchTemplate != nil {
+		return aws.StringValue(i.LaunchTemplate.LaunchTemplateName) + ":" + aws.StringValue(i.LaunchTemplate.Version)
+	}
+	return aws.StringValue(i.LaunchConfigurationName)
+}
+
+// findAutoscalingGroupLaunchConfiguration identifies the launch configuration new instances in the group are launched from,
+// in the same form as findInstanceLaunchConfiguration.  A group that uses the latest or default version of a launch template
+// is resolved to the version number, so that instances launched from older versions are found to need updating.
+func findAutoscalingGroupLaunchConfiguration(c AWSCloud, g *autoscaling.Group) (string, error) {
+	spec := AutoscalingGroupLaunchTemplate(g)
+	if spec == nil {
+		return aws.StringValue(g.LaunchConfigurationName), nil
+	}
+
+	version := aws.StringValue(spec.Version)
+	name := aws.StringValue(spec.LaunchTemplateName)
+	if version == "" || version == "$Latest" || version == "$Default" {
+		request := &ec2.DescribeLaunchTemplatesInput{}
+		if spec.LaunchTemplateId != nil {
+			request.LaunchTemplateIds = []*string{spec.LaunchTemplateId}
+		} else {
+			request.LaunchTemplateNames = []*string{spec.LaunchTemplateName}
+		}
+		response, err := c.EC2().DescribeLaunchTemplates(request)
+		if err != nil {
+			return "", fmt.Errorf("error describing launch template of autoscaling group %q: %v", aws.StringValue(g.AutoScalingGroupName), err)
+		}
+		if len(response.LaunchTemplates) != 1 {
+			return "", fmt.Errorf("found %d launch templates for autoscaling group %q", len(response.LaunchTemplates), aws.StringValue(g.AutoScalingGroupName))
+		}
+		lt := response.LaunchTemplates[0]
+		name = aws.StringValue(lt.LaunchTemplateName)
+		if version == "$Latest" {
+			version = strconv.FormatInt(aws.Int64Value(lt.LatestVersionNumber), 10)
+		} else {
+			version = strconv.FormatInt(aws.Int64Value(lt.DefaultVersionNumber), 10)
+		}
+	}
+	return name + ":" + version, nil
+}
+
+// AutoscalingGroupLaunchTemplate returns the launch template of the group, which is part of the mixed instances policy
+// if it has one, or nil if the group uses a launch configuration
+func AutoscalingGroupLaunchTemplate(g *autoscaling.Group) *autoscaling.LaunchTemplateSpecification {
+	if g.MixedInstancesPolicy != nil && g.MixedInstancesPolicy.LaunchTemplate != nil {
+		return g.MixedInstancesPolicy.LaunchTemplate.LaunchTemplateSpecification
+	}
+	return g.LaunchTemplate
+}
+
 func awsBuildCloudInstanceGroup(c AWSCloud, ig *kops.InstanceGroup, g *autoscaling.Group, nodeMap map[string]*v1.Node) (*cloudinstances.CloudInstanceGroup, error) {
-	newLaunchConfigName := aws.StringValue(g.LaunchConfigurationName)
+	newLaunchConfigName, err := findAutoscalingGroupLaunchConfiguration(c, g)
+	if err != nil {
+		return nil, err
+	}
 
 	cg := &cloudinstances.CloudInstanceGroup{
 		HumanName:     aws.StringValue(g.AutoScalingGroupName),
 		InstanceGroup: ig,
 		MinSize:       int(aws.Int64Value(g.MinSize)),
 		MaxSize:       int(aws.Int64Value(g.MaxSize)),
+		TargetSize:    int(aws.Int64Value(g.DesiredCapacity)),
 		Raw:           g,
 	}
 
@@ -565,7 +701,7 @@ func awsBuildCloudInstanceGroup(c AWSCloud, ig *kops.InstanceGroup, g *autoscali
 			glog.Warningf("ignoring instance with no instance id: %s", i)
 			continue
 		}
-		err := cg.NewCloudInstanceGroupMember(instanceId, newLaunchConfigName, aws.StringValue(i.LaunchConfigurationName), nodeMap)
+		err := cg.NewCloudInstanceGroupMember(instanceId, newLaunchConfigName, findInstanceLaunchConfiguration(i), nodeMap)
 		if err != nil {
 			return nil, fmt.Errorf("error creating cloud instance group member: %v", err)
 		}
@@ -1175,6 +1311,10 @@ func (c *awsCloudImplementation) Route53() route53iface.Route53API {
 	return c.route53
 }
 
+func (c *awsCloudImplementation) SQS() sqsiface.SQSAPI {
+	return c.sqs
+}
+
 func (c *awsCloudImplementation) Spotinst() spotinst.Service {
 	return c.spotinst
 }
@@ -28,6 +28,7 @@ import (
 	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
 	"github.com/aws/aws-sdk-go/service/iam/iamiface"
 	"github.com/aws/aws-sdk-go/service/route53/route53iface"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
 	"github.com/golang/glog"
 	"k8s.io/api/core/v1"
 	"k8s.io/kops/dnsprovider/pkg/dnsprovider"
@@ -79,6 +80,7 @@ type MockCloud struct {
 	MockRoute53        route53iface.Route53API
 	MockELB            elbiface.ELBAPI
 	MockELBV2          elbv2iface.ELBV2API
+	MockSQS            sqsiface.SQSAPI
 	MockSpotinst       spotinst.Service
 }
 
@@ -86,8 +88,16 @@ func (c *MockAWSCloud) DeleteGroup(g *cloudinstances.CloudInstanceGroup) error {
 	return deleteGroup(c, g)
 }
 
+func (c *MockAWSCloud) ResizeGroup(g *cloudinstances.CloudInstanceGroup, size int) error {
+	return resizeGroup(c, g, size)
+}
+
 func (c *MockAWSCloud) DeleteInstance(i *cloudinstances.CloudInstanceGroupMember) error {
-	return deleteInstance(c, i)
+	return deleteInstance(c, i, false)
+}
+
+func (c *MockAWSCloud) DeleteInstanceWithoutReplacement(i *cloudinstances.CloudInstanceGroupMember) error {
+	return deleteInstance(c, i, true)
 }
 
 func (c *MockAWSCloud) GetCloudGroups(cluster *kops.Cluster, instancegroups []*kops.InstanceGroup, warnUnmatched bool, nodes []v1.Node) (map[string]*cloudinstances.CloudInstanceGroup, error) {
@@ -231,6 +241,13 @@ func (c *MockAWSCloud) Autoscaling() autoscalingiface.AutoScalingAPI {
 	return c.MockAutoscaling
 }
 
+func (c *MockAWSCloud) SQS() sqsiface.SQSAPI {
+	if c.MockSQS == nil {
+		glog.Fatalf("MockAWSCloud MockSQS not set")
+	}
+	return c.MockSQS
+}
+
 func (c *MockAWSCloud) Route53() route53iface.Route53API {
 	if c.MockRoute53 == nil {
 		glog.Fatalf("MockRoute53 not set")
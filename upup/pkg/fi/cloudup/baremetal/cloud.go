@@ -64,9 +64,23 @@ func (c *Cloud) DeleteGroup(g *cloudinstances.CloudInstanceGroup) error {
 	return fmt.Errorf("baremetal cloud provider does not support deleting cloud groups at this time")
 }
 
+// ResizeGroup is not implemented yet, is a func that needs to resize a baremetal instance group.
+// Baremetal may not support this.
+func (c *Cloud) ResizeGroup(g *cloudinstances.CloudInstanceGroup, size int) error {
+	glog.V(8).Infof("baremetal cloud provider ResizeGroup not implemented yet")
+	return fmt.Errorf("baremetal cloud provider does not support resizing cloud groups at this time")
+}
+
 //DeleteInstance is not implemented yet, is func needs to delete a DO instance.
 //Baremetal may not support this.
 func (c *Cloud) DeleteInstance(instance *cloudinstances.CloudInstanceGroupMember) error {
 	glog.V(8).Infof("baremetal cloud provider DeleteInstance not implemented yet")
 	return fmt.Errorf("baremetal cloud provider does not support deleting cloud instances at this time")
 }
+
+// DeleteInstanceWithoutReplacement is not implemented yet, is func needs to delete an instance and shrink its group.
+// Baremetal may not support this.
+func (c *Cloud) DeleteInstanceWithoutReplacement(instance *cloudinstances.CloudInstanceGroupMember) error {
+	glog.V(8).Infof("baremetal cloud provider DeleteInstanceWithoutReplacement not implemented yet")
+	return fmt.Errorf("baremetal cloud provider does not support deleting cloud instances at this time")
+}
@@ -23,6 +23,7 @@ import (
 	"k8s.io/kops/pkg/apis/kops"
 	"k8s.io/kops/pkg/assets"
 	"k8s.io/kops/pkg/featureflag"
+	"k8s.io/kops/pkg/model"
 	"k8s.io/kops/pkg/templates"
 	"k8s.io/kops/upup/pkg/fi"
 	"k8s.io/kops/upup/pkg/fi/fitasks"
@@ -32,6 +33,7 @@ import (
 // BootstrapChannelBuilder is responsible for handling the addons in channels
 type BootstrapChannelBuilder struct {
 	cluster      *kops.Cluster
+	modelContext *model.KopsModelContext
 	Lifecycle    *fi.Lifecycle
 	templates    *templates.Templates
 	assetBuilder *assets.AssetBuilder
@@ -318,6 +320,29 @@ func (b *BootstrapChannelBuilder) buildManifest() (*channelsapi.Addons, map[stri
 		}
 	}
 
+	// The node-drainer drains the nodes of instances paused by a terminating lifecycle hook
+	if b.modelContext.UseLifecycleHooks() {
+		{
+			key := "node-drainer.addons.k8s.io"
+			version := "1.11.0-alpha.1"
+
+			{
+				location := key + "/k8s-1.8.yaml"
+				id := "k8s-1.8"
+
+				addons.Spec.Addons = append(addons.Spec.Addons, &channelsapi.AddonSpec{
+					Name:              fi.String(key),
+					Version:           fi.String(version),
+					Selector:          map[string]string{"k8s-addon": key},
+					Manifest:          fi.String(location),
+					KubernetesVersion: ">=1.8.0",
+					Id:                id,
+				})
+				manifests[key+"-"+id] = "addons/" + location
+			}
+		}
+	}
+
 	if featureflag.EnableExternalDNS.Enabled() {
 		{
 			key := "external-dns.addons.k8s.io"
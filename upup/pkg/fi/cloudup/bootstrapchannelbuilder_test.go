@@ -95,6 +95,7 @@ func runChannelBuilderTest(t *testing.T, key string) {
 
 	bcb := BootstrapChannelBuilder{
 		cluster:      cluster,
+		modelContext: tf.modelContext,
 		templates:    templates,
 		assetBuilder: assets.NewAssetBuilder(cluster, ""),
 	}
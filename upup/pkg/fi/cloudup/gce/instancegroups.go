@@ -51,6 +51,35 @@ func (c *mockGCECloud) DeleteGroup(g *cloudinstances.CloudInstanceGroup) error {
 	return deleteCloudInstanceGroup(c, g)
 }
 
+// ResizeGroup sets the target size of an Instance Group Manager
+func (c *gceCloudImplementation) ResizeGroup(g *cloudinstances.CloudInstanceGroup, size int) error {
+	return resizeCloudInstanceGroup(c, g, size)
+}
+
+// ResizeGroup implements fi.Cloud::ResizeGroup
+func (c *mockGCECloud) ResizeGroup(g *cloudinstances.CloudInstanceGroup, size int) error {
+	return resizeCloudInstanceGroup(c, g, size)
+}
+
+// resizeCloudInstanceGroup sets the target size of the InstanceGroupManager
+func resizeCloudInstanceGroup(c GCECloud, g *cloudinstances.CloudInstanceGroup, size int) error {
+	mig := g.Raw.(*compute.InstanceGroupManager)
+
+	glog.V(2).Infof("Resizing GCE MIG %s to %d", mig.Name, size)
+
+	migURL, err := ParseGoogleCloudURL(mig.SelfLink)
+	if err != nil {
+		return err
+	}
+
+	op, err := c.Compute().InstanceGroupManagers.Resize(migURL.Project, migURL.Zone, migURL.Name, int64(size)).Do()
+	if err != nil {
+		return fmt.Errorf("error resizing InstanceGroupManager %s: %v", mig.Name, err)
+	}
+
+	return c.WaitForOp(op)
+}
+
 // DeleteInstance deletes a GCE instance
 func (c *gceCloudImplementation) DeleteInstance(i *cloudinstances.CloudInstanceGroupMember) error {
 	return recreateCloudInstanceGroupMember(c, i)
@@ -61,6 +90,44 @@ func (c *mockGCECloud) DeleteInstance(i *cloudinstances.CloudInstanceGroupMember
 	return recreateCloudInstanceGroupMember(c, i)
 }
 
+// DeleteInstanceWithoutReplacement deletes a GCE instance and lowers the target size of its MIG
+func (c *gceCloudImplementation) DeleteInstanceWithoutReplacement(i *cloudinstances.CloudInstanceGroupMember) error {
+	return deleteCloudInstanceGroupMember(c, i)
+}
+
+// DeleteInstanceWithoutReplacement implements fi.Cloud::DeleteInstanceWithoutReplacement
+func (c *mockGCECloud) DeleteInstanceWithoutReplacement(i *cloudinstances.CloudInstanceGroupMember) error {
+	return deleteCloudInstanceGroupMember(c, i)
+}
+
+// deleteCloudInstanceGroupMember deletes the specified instance from an InstanceGroupManager, which also lowers its target size
+func deleteCloudInstanceGroupMember(c GCECloud, i *cloudinstances.CloudInstanceGroupMember) error {
+	mig := i.CloudInstanceGroup.Raw.(*compute.InstanceGroupManager)
+
+	glog.V(2).Infof("Deleting GCE Instance %s from MIG %s", i.ID, mig.Name)
+
+	migURL, err := ParseGoogleCloudURL(mig.SelfLink)
+	if err != nil {
+		return err
+	}
+
+	req := &compute.InstanceGroupManagersDeleteInstancesRequest{
+		Instances: []string{
+			i.ID,
+		},
+	}
+	op, err := c.Compute().InstanceGroupManagers.DeleteInstances(migURL.Project, migURL.Zone, migURL.Name, req).Do()
+	if err != nil {
+		if IsNotFound(err) {
+			glog.Infof("Instance not found, assuming deleted: %q", i.ID)
+			return nil
+		}
+		return fmt.Errorf("error deleting Instance %s: %v", i.ID, err)
+	}
+
+	return c.WaitForOp(op)
+}
+
 // recreateCloudInstanceGroupMember recreates the specified instances, managed by an InstanceGroupManager
 func recreateCloudInstanceGroupMember(c GCECloud, i *cloudinstances.CloudInstanceGroupMember) error {
 	mig := i.CloudInstanceGroup.Raw.(*compute.InstanceGroupManager)
@@ -158,6 +225,7 @@ func getCloudGroups(c GCECloud, cluster *kops.Cluster, instancegroups []*kops.In
 					InstanceGroup: ig,
 					MinSize:       int(mig.TargetSize),
 					MaxSize:       int(mig.TargetSize),
+					TargetSize:    int(mig.TargetSize),
 					Raw:           mig,
 				}
 				groups[mig.Name] = g
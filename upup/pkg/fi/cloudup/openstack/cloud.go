@@ -209,10 +209,18 @@ func (c *openstackCloud) DeleteInstance(i *cloudinstances.CloudInstanceGroupMemb
 	return fmt.Errorf("openstackCloud::DeleteInstance not implemented")
 }
 
+func (c *openstackCloud) DeleteInstanceWithoutReplacement(i *cloudinstances.CloudInstanceGroupMember) error {
+	return fmt.Errorf("openstackCloud::DeleteInstanceWithoutReplacement not implemented")
+}
+
 func (c *openstackCloud) DeleteGroup(g *cloudinstances.CloudInstanceGroup) error {
 	return fmt.Errorf("openstackCloud::DeleteGroup not implemented")
 }
 
+func (c *openstackCloud) ResizeGroup(g *cloudinstances.CloudInstanceGroup, size int) error {
+	return fmt.Errorf("openstackCloud::ResizeGroup not implemented")
+}
+
 func (c *openstackCloud) GetCloudGroups(cluster *kops.Cluster, instancegroups []*kops.InstanceGroup, warnUnmatched bool, nodes []v1.Node) (map[string]*cloudinstances.CloudInstanceGroup, error) {
 	return nil, fmt.Errorf("openstackCloud::GetCloudGroups not implemented")
 }
@@ -0,0 +1,227 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/kops"
+	api "k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+// stateStoreVersionIgnoredPaths are the paths in the state store that change without changing what kops update cluster would do
+var stateStoreVersionIgnoredPaths = []string{
+	"rollingupdate/",
+	"history/",
+	"audit/",
+	"lock",
+}
+
+// Plan is the set of changes computed by a dry-run of kops update cluster, saved so that it can be reviewed and then applied
+type Plan struct {
+	// ClusterName is the name of the cluster the plan is for
+	ClusterName string `json:"clusterName"`
+	// CreatedAt is when the plan was computed
+	CreatedAt time.Time `json:"createdAt"`
+	// KopsVersion is the version of kops that computed the plan
+	KopsVersion string `json:"kopsVersion"`
+
+	// Phase is the subset of tasks the plan was computed for, if not all of them
+	Phase Phase `json:"phase,omitempty"`
+	// LifecycleOverrides are the overrides of task lifecycles the plan was computed with
+	LifecycleOverrides map[string]fi.Lifecycle `json:"lifecycleOverrides,omitempty"`
+
+	// SpecHash is a hash of the cluster and instance group specs the plan was computed from
+	SpecHash string `json:"specHash"`
+	// StateStoreVersion is a hash of the contents of the cluster's state store when the plan was computed
+	StateStoreVersion string `json:"stateStoreVersion"`
+
+	// Changes are the changes that applying the plan will make
+	Changes []*fi.TaskChange `json:"changes,omitempty"`
+}
+
+// NewPlan builds a Plan for the cluster, computing the hashes of its current spec and state store
+func NewPlan(cluster *api.Cluster, instanceGroups []*api.InstanceGroup, configBase vfs.Path) (*Plan, error) {
+	specHash, err := ComputeSpecHash(cluster, instanceGroups)
+	if err != nil {
+		return nil, err
+	}
+
+	stateStoreVersion, err := ComputeStateStoreVersion(configBase)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Plan{
+		ClusterName:       cluster.ObjectMeta.Name,
+		CreatedAt:         time.Now().UTC(),
+		KopsVersion:       kops.Version,
+		SpecHash:          specHash,
+		StateStoreVersion: stateStoreVersion,
+	}, nil
+}
+
+// LoadPlan reads a Plan from a local file
+func LoadPlan(p string) (*Plan, error) {
+	data, err := ioutil.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("error reading plan file %q: %v", p, err)
+	}
+
+	plan := &Plan{}
+	if err := yaml.Unmarshal(data, plan); err != nil {
+		return nil, fmt.Errorf("error parsing plan file %q: %v", p, err)
+	}
+	return plan, nil
+}
+
+// Save writes the Plan to a local file, as YAML
+func (p *Plan) Save(path string) error {
+	data, err := yaml.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("error serializing plan: %v", err)
+	}
+
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("error writing plan file %q: %v", path, err)
+	}
+	return nil
+}
+
+// CheckCurrent returns an error if the cluster spec or the state store has changed since the plan was computed
+func (p *Plan) CheckCurrent(current *Plan) error {
+	if p.ClusterName != current.ClusterName {
+		return fmt.Errorf("plan is for cluster %q, not %q", p.ClusterName, current.ClusterName)
+	}
+	if p.SpecHash != current.SpecHash {
+		return fmt.Errorf("the cluster or instance group specs have changed since the plan was computed; please compute a new plan")
+	}
+	if p.StateStoreVersion != current.StateStoreVersion {
+		return fmt.Errorf("the state store has changed since the plan was computed; please compute a new plan")
+	}
+	return nil
+}
+
+// CheckChanges returns an error if the changes are not exactly those in the plan, which means the cloud has changed since the plan was computed
+func (p *Plan) CheckChanges(changes []*fi.TaskChange) error {
+	planned := make(map[string]*fi.TaskChange)
+	for _, c := range p.Changes {
+		planned[string(c.Action)+" "+c.Key] = c
+	}
+	found := make(map[string]*fi.TaskChange)
+	for _, c := range changes {
+		found[string(c.Action)+" "+c.Key] = c
+	}
+
+	var drifted []string
+	for k, c := range found {
+		if !reflect.DeepEqual(planned[k], c) {
+			drifted = append(drifted, k)
+		}
+	}
+	for k := range planned {
+		if found[k] == nil {
+			drifted = append(drifted, k)
+		}
+	}
+
+	if len(drifted) != 0 {
+		sort.Strings(drifted)
+		return fmt.Errorf("the cloud resources have changed since the plan was computed, so it would no longer make exactly the planned changes (differences in: %s); please compute a new plan", strings.Join(drifted, ", "))
+	}
+	return nil
+}
+
+// ComputeSpecHash returns a hash of the cluster and instance group specs, as stored in the registry
+func ComputeSpecHash(cluster *api.Cluster, instanceGroups []*api.InstanceGroup) (string, error) {
+	sorted := make([]*api.InstanceGroup, len(instanceGroups))
+	copy(sorted, instanceGroups)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ObjectMeta.Name < sorted[j].ObjectMeta.Name
+	})
+
+	h := sha256.New()
+	for _, obj := range []interface{}{cluster.ObjectMeta.Name, cluster.Spec} {
+		data, err := json.Marshal(obj)
+		if err != nil {
+			return "", fmt.Errorf("error serializing cluster: %v", err)
+		}
+		h.Write(data)
+	}
+	for _, ig := range sorted {
+		data, err := json.Marshal([]interface{}{ig.ObjectMeta.Name, ig.Spec})
+		if err != nil {
+			return "", fmt.Errorf("error serializing instance group %q: %v", ig.ObjectMeta.Name, err)
+		}
+		h.Write(data)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ComputeStateStoreVersion returns a hash of the names and contents of the files in the cluster's state store
+func ComputeStateStoreVersion(configBase vfs.Path) (string, error) {
+	files, err := configBase.ReadTree()
+	if err != nil {
+		return "", fmt.Errorf("error listing state store %q: %v", configBase, err)
+	}
+
+	prefix := configBase.Path() + "/"
+	contents := make(map[string]vfs.Path)
+	var names []string
+	for _, f := range files {
+		name := strings.TrimPrefix(f.Path(), prefix)
+		if isIgnoredForStateStoreVersion(name) {
+			continue
+		}
+		names = append(names, name)
+		contents[name] = f
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		data, err := contents[name].ReadFile()
+		if err != nil {
+			return "", fmt.Errorf("error reading %q: %v", contents[name], err)
+		}
+		fileHash := sha256.Sum256(data)
+		fmt.Fprintf(h, "%s %s\n", name, hex.EncodeToString(fileHash[:]))
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func isIgnoredForStateStoreVersion(name string) bool {
+	for _, ignored := range stateStoreVersionIgnoredPaths {
+		if strings.HasPrefix(name, ignored) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudup
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"k8s.io/kops/pkg/apis/kops"
+	"k8s.io/kops/upup/pkg/fi"
+	"k8s.io/kops/util/pkg/vfs"
+)
+
+func buildPlanTestCluster() (*kops.Cluster, []*kops.InstanceGroup) {
+	cluster := &kops.Cluster{}
+	cluster.ObjectMeta.Name = "test.example.com"
+	cluster.Spec.KubernetesVersion = "1.10.0"
+
+	ig := &kops.InstanceGroup{}
+	ig.ObjectMeta.Name = "nodes"
+	ig.Spec.MachineType = "m4.large"
+
+	return cluster, []*kops.InstanceGroup{ig}
+}
+
+func TestPlanCheckCurrent(t *testing.T) {
+	vfs.Context.ResetMemfsContext(true)
+	configBase, err := vfs.Context.BuildVfsPath("memfs://tests/test.example.com")
+	if err != nil {
+		t.Fatalf("error building path: %v", err)
+	}
+	if err := configBase.Join("config").WriteFile(bytes.NewReader([]byte("config")), nil); err != nil {
+		t.Fatalf("error writing file: %v", err)
+	}
+
+	cluster, instanceGroups := buildPlanTestCluster()
+	plan, err := NewPlan(cluster, instanceGroups, configBase)
+	if err != nil {
+		t.Fatalf("error building plan: %v", err)
+	}
+
+	current, err := NewPlan(cluster, instanceGroups, configBase)
+	if err != nil {
+		t.Fatalf("error building plan: %v", err)
+	}
+	if err := plan.CheckCurrent(current); err != nil {
+		t.Errorf("unexpected error for unchanged cluster: %v", err)
+	}
+
+	// Progress of a rolling update does not affect the plan
+	if err := configBase.Join("rollingupdate", "progress").WriteFile(bytes.NewReader([]byte("progress")), nil); err != nil {
+		t.Fatalf("error writing file: %v", err)
+	}
+	current, err = NewPlan(cluster, instanceGroups, configBase)
+	if err != nil {
+		t.Fatalf("error building plan: %v", err)
+	}
+	if err := plan.CheckCurrent(current); err != nil {
+		t.Errorf("unexpected error after writing rolling update progress: %v", err)
+	}
+
+	instanceGroups[0].Spec.MachineType = "m4.xlarge"
+	current, err = NewPlan(cluster, instanceGroups, configBase)
+	if err != nil {
+		t.Fatalf("error building plan: %v", err)
+	}
+	if err := plan.CheckCurrent(current); err == nil {
+		t.Errorf("expected error after changing instance group spec")
+	}
+
+	cluster, instanceGroups = buildPlanTestCluster()
+	if err := configBase.Join("secrets", "admin").WriteFile(bytes.NewReader([]byte("secret")), nil); err != nil {
+		t.Fatalf("error writing file: %v", err)
+	}
+	current, err = NewPlan(cluster, instanceGroups, configBase)
+	if err != nil {
+		t.Fatalf("error building plan: %v", err)
+	}
+	if err := plan.CheckCurrent(current); err == nil {
+		t.Errorf("expected error after changing the state store")
+	}
+}
+
+func TestPlanCheckChanges(t *testing.T) {
+	plan := &Plan{
+		Changes: []*fi.TaskChange{
+			{Key: "VPC/test", Action: fi.ChangeActionCreate, Fields: []fi.FieldChange{{Field: "CIDR", Description: "10.0.0.0/16"}}},
+			{Key: "SecurityGroup/nodes", Action: fi.ChangeActionDelete},
+		},
+	}
+
+	grid := []struct {
+		Changes     []*fi.TaskChange
+		ExpectError bool
+	}{
+		{
+			Changes: []*fi.TaskChange{
+				{Key: "SecurityGroup/nodes", Action: fi.ChangeActionDelete},
+				{Key: "VPC/test", Action: fi.ChangeActionCreate, Fields: []fi.FieldChange{{Field: "CIDR", Description: "10.0.0.0/16"}}},
+			},
+		},
+		{
+			// A field differs
+			Changes: []*fi.TaskChange{
+				{Key: "VPC/test", Action: fi.ChangeActionCreate, Fields: []fi.FieldChange{{Field: "CIDR", Description: "10.1.0.0/16"}}},
+				{Key: "SecurityGroup/nodes", Action: fi.ChangeActionDelete},
+			},
+			ExpectError: true,
+		},
+		{
+			// A planned change is no longer needed
+			Changes: []*fi.TaskChange{
+				{Key: "VPC/test", Action: fi.ChangeActionCreate, Fields: []fi.FieldChange{{Field: "CIDR", Description: "10.0.0.0/16"}}},
+			},
+			ExpectError: true,
+		},
+		{
+			// An unplanned change is needed
+			Changes: []*fi.TaskChange{
+				{Key: "VPC/test", Action: fi.ChangeActionCreate, Fields: []fi.FieldChange{{Field: "CIDR", Description: "10.0.0.0/16"}}},
+				{Key: "SecurityGroup/nodes", Action: fi.ChangeActionDelete},
+				{Key: "Subnet/a", Action: fi.ChangeActionCreate},
+			},
+			ExpectError: true,
+		},
+	}
+
+	for i, g := range grid {
+		err := plan.CheckChanges(g.Changes)
+		if g.ExpectError && err == nil {
+			t.Errorf("case %d: expected error", i)
+		}
+		if !g.ExpectError && err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+		}
+	}
+}
+
+func TestPlanSaveAndLoad(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "plan")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	plan := &Plan{
+		ClusterName:        "test.example.com",
+		Phase:              PhaseNetwork,
+		LifecycleOverrides: map[string]fi.Lifecycle{"InternetGateway": fi.LifecycleExistsAndWarnIfChanges},
+		SpecHash:           "abc",
+		StateStoreVersion:  "def",
+		Changes: []*fi.TaskChange{
+			{Key: "VPC/test", Action: fi.ChangeActionUpdate, Fields: []fi.FieldChange{{Field: "CIDR", Description: " 10.0.0.0/16 -> 10.1.0.0/16"}}},
+		},
+	}
+
+	p := path.Join(tmpDir, "cluster.plan")
+	if err := plan.Save(p); err != nil {
+		t.Fatalf("error saving plan: %v", err)
+	}
+	loaded, err := LoadPlan(p)
+	if err != nil {
+		t.Fatalf("error loading plan: %v", err)
+	}
+	if !loaded.CreatedAt.Equal(plan.CreatedAt) {
+		t.Errorf("CreatedAt changed: %v -> %v", plan.CreatedAt, loaded.CreatedAt)
+	}
+	loaded.CreatedAt = plan.CreatedAt
+	if !reflect.DeepEqual(plan, loaded) {
+		t.Errorf("plan changed by saving and loading: %+v -> %+v", plan, loaded)
+	}
+	if err := loaded.CheckChanges(plan.Changes); err != nil {
+		t.Errorf("unexpected error checking changes of loaded plan: %v", err)
+	}
+}
@@ -91,6 +91,7 @@ func (tf *TemplateFunctions) AddTo(dest template.FuncMap, secretStore fi.SecretS
 
 	dest["DnsControllerArgv"] = tf.DnsControllerArgv
 	dest["ExternalDnsArgv"] = tf.ExternalDnsArgv
+	dest["NodeDrainerArgv"] = tf.NodeDrainerArgv
 
 	// TODO: Only for GCE?
 	dest["EncodeGCELabel"] = gce.EncodeGCELabel
@@ -247,6 +248,19 @@ func (tf *TemplateFunctions) DnsControllerArgv() ([]string, error) {
 	return argv, nil
 }
 
+// NodeDrainerArgv returns the args to the node drainer
+func (tf *TemplateFunctions) NodeDrainerArgv() ([]string, error) {
+	var argv []string
+
+	argv = append(argv, "/usr/bin/node-drainer")
+	argv = append(argv, "--queue-name="+tf.modelContext.LifecycleHookQueueName())
+	argv = append(argv, "--region="+tf.region)
+	// Verbose, but not crazy logging
+	argv = append(argv, "-v=2")
+
+	return argv, nil
+}
+
 func (tf *TemplateFunctions) ExternalDnsArgv() ([]string, error) {
 	var argv []string
 
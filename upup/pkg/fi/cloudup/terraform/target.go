@@ -86,6 +86,15 @@ func tfSanitize(name string) string {
 }
 
 func (t *TerraformTarget) AddFile(resourceType string, resourceName string, key string, r fi.Resource) (*Literal, error) {
+	return t.addFile(resourceType, resourceName, key, r, "${file(%q)}")
+}
+
+// AddFileBase64 is AddFile for arguments that must be base64 encoded, such as the user_data of an aws_launch_template
+func (t *TerraformTarget) AddFileBase64(resourceType string, resourceName string, key string, r fi.Resource) (*Literal, error) {
+	return t.addFile(resourceType, resourceName, key, r, "${base64encode(file(%q))}")
+}
+
+func (t *TerraformTarget) addFile(resourceType string, resourceName string, key string, r fi.Resource, expression string) (*Literal, error) {
 	id := resourceType + "_" + resourceName + "_" + key
 
 	d, err := fi.ResourceAsBytes(r)
@@ -99,7 +108,7 @@ func (t *TerraformTarget) AddFile(resourceType string, resourceName string, key
 	p := path.Join("data", id)
 	t.files[p] = d
 
-	l := LiteralExpression(fmt.Sprintf("${file(%q)}", path.Join("${path.module}", p)))
+	l := LiteralExpression(fmt.Sprintf(expression, path.Join("${path.module}", p)))
 	return l, nil
 }
 
@@ -118,12 +118,24 @@ func (c *VSphereCloud) DeleteGroup(g *cloudinstances.CloudInstanceGroup) error {
 	return fmt.Errorf("vSphere cloud provider does not support deleting cloud groups at this time.")
 }
 
+// ResizeGroup is not implemented yet, is a func that needs to resize a vSphere instance group.
+func (c *VSphereCloud) ResizeGroup(g *cloudinstances.CloudInstanceGroup, size int) error {
+	glog.V(8).Infof("vSphere cloud provider ResizeGroup not implemented yet")
+	return fmt.Errorf("vSphere cloud provider does not support resizing cloud groups at this time.")
+}
+
 // DeleteInstance is not implemented yet, is func needs to delete a vSphereCloud instance.
 func (c *VSphereCloud) DeleteInstance(i *cloudinstances.CloudInstanceGroupMember) error {
 	glog.V(8).Infof("vSphere cloud provider DeleteInstance not implemented yet")
 	return fmt.Errorf("vSphere cloud provider does not support deleting cloud instances at this time.")
 }
 
+// DeleteInstanceWithoutReplacement is not implemented yet, is func needs to delete a vSphereCloud instance and shrink its group.
+func (c *VSphereCloud) DeleteInstanceWithoutReplacement(i *cloudinstances.CloudInstanceGroupMember) error {
+	glog.V(8).Infof("vSphere cloud provider DeleteInstanceWithoutReplacement not implemented yet")
+	return fmt.Errorf("vSphere cloud provider does not support deleting cloud instances at this time.")
+}
+
 // DNS returns dnsprovider interface for this vSphere cloud.
 func (c *VSphereCloud) DNS() (dnsprovider.Interface, error) {
 	var provider dnsprovider.Interface
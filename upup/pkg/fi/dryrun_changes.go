@@ -0,0 +1,147 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"sort"
+)
+
+// ChangeAction is what a change will do to a task's cloud object
+type ChangeAction string
+
+const (
+	ChangeActionCreate ChangeAction = "create"
+	ChangeActionUpdate ChangeAction = "update"
+	ChangeActionDelete ChangeAction = "delete"
+)
+
+// TaskChange is a serializable description of a change found by a DryRunTarget
+type TaskChange struct {
+	// Key identifies the task, as type/name
+	Key string `json:"key"`
+	// Type is the type of the task, e.g. LaunchConfiguration
+	Type string `json:"type"`
+	// Name is the name of the task, or the item being deleted
+	Name string `json:"name"`
+	// Lifecycle is the lifecycle of the task, if it has one
+	Lifecycle Lifecycle `json:"lifecycle,omitempty"`
+	// Action is whether the object will be created, updated or deleted
+	Action ChangeAction `json:"action"`
+	// Fields are the fields that will be set or changed
+	Fields []FieldChange `json:"fields,omitempty"`
+}
+
+// FieldChange describes the change to a single field of a task
+type FieldChange struct {
+	// Field is the name of the field
+	Field string `json:"field"`
+	// Old is the current value of the field, when it is updated
+	Old string `json:"old,omitempty"`
+	// New is the value the field will be set to
+	New string `json:"new,omitempty"`
+	// Description is the new value, or the difference between the old and new values.
+	// For resources, which can be large, Old and New are not set, and Description holds a diff or a hash of the contents.
+	Description string `json:"description"`
+}
+
+// Changes returns the changes the DryRunTarget recorded, sorted by key and action
+func (t *DryRunTarget) Changes(taskMap map[string]Task) ([]*TaskChange, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	var taskChanges []*TaskChange
+	for _, r := range t.changes {
+		taskChange := &TaskChange{
+			Type: getTaskName(r.changes),
+			Name: idForTask(taskMap, r.e),
+		}
+		taskChange.Key = taskChange.Type + "/" + taskChange.Name
+		if hl, ok := r.e.(HasLifecycle); ok && hl.GetLifecycle() != nil {
+			taskChange.Lifecycle = *hl.GetLifecycle()
+		}
+
+		var fields []change
+		if r.aIsNil {
+			taskChange.Action = ChangeActionCreate
+			fields = buildCreateList(r.changes)
+			// The text report does not show resources when they are created, but we need to record their contents
+			fields = append(fields, buildResourceHashes(r.changes)...)
+		} else {
+			taskChange.Action = ChangeActionUpdate
+			changeList, err := buildChangeList(r.a, r.e, r.changes)
+			if err != nil {
+				return nil, err
+			}
+			fields = changeList
+		}
+
+		for _, f := range fields {
+			taskChange.Fields = append(taskChange.Fields, FieldChange{Field: f.FieldName, Old: f.Old, New: f.New, Description: f.Description})
+		}
+		taskChanges = append(taskChanges, taskChange)
+	}
+
+	for _, d := range t.deletions {
+		taskChanges = append(taskChanges, &TaskChange{
+			Key:    d.TaskName() + "/" + d.Item(),
+			Type:   d.TaskName(),
+			Name:   d.Item(),
+			Action: ChangeActionDelete,
+		})
+	}
+
+	sort.Slice(taskChanges, func(i, j int) bool {
+		if taskChanges[i].Key != taskChanges[j].Key {
+			return taskChanges[i].Key < taskChanges[j].Key
+		}
+		return taskChanges[i].Action < taskChanges[j].Action
+	})
+
+	return taskChanges, nil
+}
+
+// buildResourceHashes returns the sha256 hash of the contents of each resource field of the task
+func buildResourceHashes(changes Task) []change {
+	var changeList []change
+
+	valC := reflect.ValueOf(changes)
+	if valC.Kind() == reflect.Ptr && !valC.IsNil() {
+		valC = valC.Elem()
+	}
+	if valC.Kind() != reflect.Struct {
+		return nil
+	}
+
+	for i := 0; i < valC.NumField(); i++ {
+		if valC.Type().Field(i).PkgPath != "" {
+			// Not exported
+			continue
+		}
+
+		contents, ok := tryResourceAsString(valC.Field(i))
+		if !ok {
+			continue
+		}
+		hash := sha256.Sum256([]byte(contents))
+		changeList = append(changeList, change{FieldName: valC.Type().Field(i).Name, Description: "sha256:" + hex.EncodeToString(hash[:])})
+	}
+
+	return changeList
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package fi
 
 import (
+	"encoding/json"
 	"reflect"
 	"testing"
 )
@@ -48,3 +49,108 @@ func Test_tryResourceAsString(t *testing.T) {
 		}
 	}
 }
+
+// testTask is a minimal Task for exercising the DryRunTarget
+type testTask struct {
+	Name      *string
+	Lifecycle *Lifecycle
+	Size      *int64
+	Data      Resource
+}
+
+func (t *testTask) Run(c *Context) error {
+	return nil
+}
+
+func (t *testTask) GetLifecycle() *Lifecycle {
+	return t.Lifecycle
+}
+
+func (t *testTask) SetLifecycle(lifecycle Lifecycle) {
+	t.Lifecycle = &lifecycle
+}
+
+// testDeletion is a Deletion for exercising the DryRunTarget
+type testDeletion struct{}
+
+func (d *testDeletion) Delete(target Target) error {
+	return nil
+}
+
+func (d *testDeletion) TaskName() string {
+	return "testTask"
+}
+
+func (d *testDeletion) Item() string {
+	return "old-item"
+}
+
+func Test_DryRunTargetChanges(t *testing.T) {
+	lifecycle := LifecycleSync
+
+	updateA := &testTask{Name: String("update"), Lifecycle: &lifecycle, Size: Int64(1)}
+	updateE := &testTask{Name: String("update"), Lifecycle: &lifecycle, Size: Int64(2)}
+	updateC := &testTask{}
+	BuildChanges(updateA, updateE, updateC)
+
+	var createA *testTask
+	createE := &testTask{Name: String("create"), Lifecycle: &lifecycle, Size: Int64(3), Data: NewStringResource("hello")}
+	createC := &testTask{}
+	BuildChanges(createA, createE, createC)
+
+	taskMap := map[string]Task{
+		"testTask/update": updateE,
+		"testTask/create": createE,
+	}
+
+	target := NewDryRunTarget(nil, nil)
+	if err := target.Render(updateA, updateE, updateC); err != nil {
+		t.Fatalf("error rendering: %v", err)
+	}
+	if err := target.Render(createA, createE, createC); err != nil {
+		t.Fatalf("error rendering: %v", err)
+	}
+	if err := target.Delete(&testDeletion{}); err != nil {
+		t.Fatalf("error deleting: %v", err)
+	}
+
+	changes, err := target.Changes(taskMap)
+	if err != nil {
+		t.Fatalf("error building changes: %v", err)
+	}
+
+	expected := []*TaskChange{
+		{
+			Key:       "testTask/create",
+			Type:      "testTask",
+			Name:      "create",
+			Lifecycle: LifecycleSync,
+			Action:    ChangeActionCreate,
+			Fields: []FieldChange{
+				{Field: "Size", New: "3", Description: "3"},
+				{Field: "Data", Description: "sha256:2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+			},
+		},
+		{
+			Key:    "testTask/old-item",
+			Type:   "testTask",
+			Name:   "old-item",
+			Action: ChangeActionDelete,
+		},
+		{
+			Key:       "testTask/update",
+			Type:      "testTask",
+			Name:      "update",
+			Lifecycle: LifecycleSync,
+			Action:    ChangeActionUpdate,
+			Fields: []FieldChange{
+				{Field: "Size", Old: "1", New: "2", Description: " 1 -> 2"},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(changes, expected) {
+		actual, _ := json.MarshalIndent(changes, "", "  ")
+		t.Errorf("unexpected changes: %s", actual)
+	}
+}
@@ -18,13 +18,18 @@ package fi
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/golang/glog"
+	"k8s.io/kops/pkg/backoff"
 )
 
+// reportSlowestTasks is the number of tasks whose timings are always logged when the tasks complete
+const reportSlowestTasks = 10
+
 type executor struct {
 	context *Context
 
@@ -38,16 +43,119 @@ type taskState struct {
 	deadline     time.Time
 	lastError    error
 	dependencies []*taskState
+
+	// failures is the number of consecutive failures, and throttled the number of those caused by rate-limiting
+	failures  int
+	throttled int
+	// nextAttempt is when the task can be retried, after backing off
+	nextAttempt time.Time
+
+	// attempts is the number of times the task has been run
+	attempts int
+	// runTime is the total time spent running the task, over all attempts
+	runTime time.Duration
+	// firstAttempt is when the task was first run, and completed when it succeeded
+	firstAttempt time.Time
+	completed    time.Time
 }
 
 type RunTasksOptions struct {
 	MaxTaskDuration         time.Duration
 	WaitAfterAllTasksFailed time.Duration
+
+	// MaxParallelism is the maximum number of tasks that run at once; if zero there is no limit
+	MaxParallelism int
+
+	// RetryPolicies override how tasks are retried, keyed by the type of the task, e.g. LaunchConfiguration
+	RetryPolicies map[string]RetryPolicy
+
+	// ThrottlingBackoff is the pause before retrying a task that failed because the cloud API is rate-limiting requests
+	ThrottlingBackoff backoff.Backoff
+}
+
+// RetryPolicy controls how a task that fails is retried
+type RetryPolicy struct {
+	// MaxTaskDuration is how long to keep retrying the task; if zero the MaxTaskDuration of the RunTasksOptions applies
+	MaxTaskDuration time.Duration
+
+	// Backoff is the pause before retrying the task after it fails
+	Backoff backoff.Backoff
 }
 
 func (o *RunTasksOptions) InitDefaults() {
 	o.MaxTaskDuration = 10 * time.Minute
 	o.WaitAfterAllTasksFailed = 10 * time.Second
+	o.ThrottlingBackoff = backoff.Backoff{Initial: 2 * time.Second, Max: time.Minute}
+	o.RetryPolicies = DefaultRetryPolicies()
+}
+
+// DefaultRetryPolicies returns the retry policies for the types of task that call APIs AWS rate-limits per account,
+// or that wait for IAM changes to propagate, so that they back off rather than retrying every few seconds
+func DefaultRetryPolicies() map[string]RetryPolicy {
+	return map[string]RetryPolicy{
+		"AutoscalingGroup":       {Backoff: backoff.Backoff{Initial: 5 * time.Second, Max: time.Minute}},
+		"LaunchConfiguration":    {Backoff: backoff.Backoff{Initial: 5 * time.Second, Max: time.Minute}},
+		"LaunchTemplate":         {Backoff: backoff.Backoff{Initial: 5 * time.Second, Max: time.Minute}},
+		"IAMInstanceProfileRole": {Backoff: backoff.Backoff{Initial: 5 * time.Second, Max: 30 * time.Second}},
+		"SecurityGroupRule":      {Backoff: backoff.Backoff{Initial: 2 * time.Second, Max: 30 * time.Second}},
+	}
+}
+
+// SetRetryPolicy parses an override of the retry policy of a type of task, in the form
+// TaskType=maxTaskDuration[:initialBackoff[:maxBackoff]], e.g. AutoscalingGroup=20m:10s:2m.
+// Values that are left empty keep the existing policy for the type.
+func (o *RunTasksOptions) SetRetryPolicy(override string) error {
+	tokens := strings.SplitN(override, "=", 2)
+	if len(tokens) != 2 || tokens[0] == "" {
+		return fmt.Errorf("incorrect syntax for task retry policy %q, expected TaskType=maxTaskDuration[:initialBackoff[:maxBackoff]]", override)
+	}
+	taskType := tokens[0]
+
+	values := strings.Split(tokens[1], ":")
+	if len(values) > 3 {
+		return fmt.Errorf("incorrect syntax for task retry policy %q, expected TaskType=maxTaskDuration[:initialBackoff[:maxBackoff]]", override)
+	}
+
+	var durations [3]*time.Duration
+	for i, value := range values {
+		if value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("error parsing task retry policy %q: %v", override, err)
+		}
+		if d < 0 {
+			return fmt.Errorf("task retry policy %q cannot have a negative duration", override)
+		}
+		durations[i] = &d
+	}
+
+	if o.RetryPolicies == nil {
+		o.RetryPolicies = make(map[string]RetryPolicy)
+	}
+	policy := o.RetryPolicies[taskType]
+	if durations[0] != nil {
+		policy.MaxTaskDuration = *durations[0]
+	}
+	if durations[1] != nil {
+		policy.Backoff.Initial = *durations[1]
+	}
+	if durations[2] != nil {
+		policy.Backoff.Max = *durations[2]
+	}
+	o.RetryPolicies[taskType] = policy
+
+	return nil
+}
+
+// retryPolicy returns the RetryPolicy for the task, with the defaults filled in
+func (o *RunTasksOptions) retryPolicy(task Task) RetryPolicy {
+	policy := o.RetryPolicies[TypeNameForTask(task)]
+	if policy.MaxTaskDuration == 0 {
+		policy.MaxTaskDuration = o.MaxTaskDuration
+	}
+	return policy
 }
 
 // RunTasks executes all the tasks, considering their dependencies
@@ -75,9 +183,13 @@ func (e *executor) RunTasks(taskMap map[string]Task) error {
 		}
 	}
 
+	defer logTaskTimings(taskStates)
+
 	for {
 		var canRun []*taskState
+		var waiting []*taskState
 		doneCount := 0
+		now := time.Now()
 		for _, ts := range taskStates {
 			if ts.done {
 				doneCount++
@@ -92,20 +204,38 @@ func (e *executor) RunTasks(taskMap map[string]Task) error {
 			}
 			if ready {
 				if ts.deadline.IsZero() {
-					ts.deadline = time.Now().Add(e.options.MaxTaskDuration)
-				} else if time.Now().After(ts.deadline) {
+					ts.deadline = now.Add(e.options.retryPolicy(ts.task).MaxTaskDuration)
+				} else if now.After(ts.deadline) {
 					return fmt.Errorf("deadline exceeded executing task %v. Example error: %v", ts.key, ts.lastError)
 				}
-				canRun = append(canRun, ts)
+				if now.Before(ts.nextAttempt) {
+					waiting = append(waiting, ts)
+				} else {
+					canRun = append(canRun, ts)
+				}
 			}
 		}
 
 		glog.Infof("Tasks: %d done / %d total; %d can run", doneCount, len(taskStates), len(canRun))
 		if len(canRun) == 0 {
-			break
+			if len(waiting) == 0 {
+				break
+			}
+
+			// All the tasks that can run are backing off; wait for the first of them
+			next := waiting[0].nextAttempt
+			for _, ts := range waiting {
+				if ts.nextAttempt.Before(next) {
+					next = ts.nextAttempt
+				}
+			}
+			glog.Infof("Waiting %v before retrying %d task(s)", next.Sub(now).Round(time.Second), len(waiting))
+			time.Sleep(next.Sub(now))
+			continue
 		}
 
 		progress := false
+		backingOff := true
 
 		var tasks []*taskState
 		for _, ts := range canRun {
@@ -120,19 +250,24 @@ func (e *executor) RunTasks(taskMap map[string]Task) error {
 				//  print warning message and continue like the task succeeded
 				if _, ok := err.(*ExistsAndWarnIfChangesError); ok {
 					glog.Warningf(err.Error())
-					ts.done = true
-					ts.lastError = nil
+					ts.markDone()
 					progress = true
 					continue
 				}
 
 				remaining := time.Second * time.Duration(int(ts.deadline.Sub(time.Now()).Seconds()))
-				glog.Warningf("error running task %q (%v remaining to succeed): %v", ts.key, remaining, err)
+				pause := e.recordFailure(ts, err)
+				if IsThrottlingError(err) {
+					glog.Warningf("task %q was throttled by the cloud API, retrying in %v (%v remaining to succeed): %v", ts.key, pause, remaining, err)
+				} else {
+					glog.Warningf("error running task %q (%v remaining to succeed): %v", ts.key, remaining, err)
+				}
+				if pause == 0 {
+					backingOff = false
+				}
 				errors = append(errors, err)
-				ts.lastError = err
 			} else {
-				ts.done = true
-				ts.lastError = nil
+				ts.markDone()
 				progress = true
 			}
 		}
@@ -142,8 +277,10 @@ func (e *executor) RunTasks(taskMap map[string]Task) error {
 				// Logic error!
 				panic("did not make progress executing tasks; but no errors reported")
 			}
-			glog.Infof("No progress made, sleeping before retrying %d failed task(s)", len(errors))
-			time.Sleep(e.options.WaitAfterAllTasksFailed)
+			if !backingOff {
+				glog.Infof("No progress made, sleeping before retrying %d failed task(s)", len(errors))
+				time.Sleep(e.options.WaitAfterAllTasksFailed)
+			}
 		}
 	}
 
@@ -161,6 +298,31 @@ func (e *executor) RunTasks(taskMap map[string]Task) error {
 	return nil
 }
 
+// markDone records that the task has completed
+func (ts *taskState) markDone() {
+	ts.done = true
+	ts.lastError = nil
+	ts.completed = time.Now()
+}
+
+// recordFailure records that the task failed with err, and sets when it should next be attempted.
+// It returns the pause before the next attempt.
+func (e *executor) recordFailure(ts *taskState, err error) time.Duration {
+	ts.lastError = err
+	ts.failures++
+
+	pause := e.options.retryPolicy(ts.task).Backoff.Duration(ts.failures)
+	if IsThrottlingError(err) {
+		ts.throttled++
+		if throttlingPause := e.options.ThrottlingBackoff.Duration(ts.throttled); throttlingPause > pause {
+			pause = throttlingPause
+		}
+	}
+
+	ts.nextAttempt = time.Now().Add(pause)
+	return pause
+}
+
 type runnable func() error
 
 func (e *executor) forkJoin(tasks []*taskState) []error {
@@ -168,6 +330,12 @@ func (e *executor) forkJoin(tasks []*taskState) []error {
 		return nil
 	}
 
+	// limit bounds the number of tasks that run at once
+	var limit chan struct{}
+	if e.options.MaxParallelism > 0 {
+		limit = make(chan struct{}, e.options.MaxParallelism)
+	}
+
 	var wg sync.WaitGroup
 	results := make([]error, len(tasks))
 	for i := 0; i < len(tasks); i++ {
@@ -175,6 +343,18 @@ func (e *executor) forkJoin(tasks []*taskState) []error {
 		go func(ts *taskState, index int) {
 			results[index] = fmt.Errorf("function panic")
 			defer wg.Done()
+			if limit != nil {
+				limit <- struct{}{}
+				defer func() { <-limit }()
+			}
+
+			start := time.Now()
+			if ts.firstAttempt.IsZero() {
+				ts.firstAttempt = start
+			}
+			ts.attempts++
+			defer func() { ts.runTime += time.Since(start) }()
+
 			glog.V(2).Infof("Executing task %q: %v\n", ts.key, ts.task)
 			results[index] = ts.task.Run(e.context)
 		}(tasks[i], i)
@@ -184,3 +364,36 @@ func (e *executor) forkJoin(tasks []*taskState) []error {
 
 	return results
 }
+
+// logTaskTimings logs how long the tasks took, slowest first, so that slow tasks are visible
+func logTaskTimings(taskStates map[string]*taskState) {
+	var ran []*taskState
+	for _, ts := range taskStates {
+		if ts.attempts != 0 {
+			ran = append(ran, ts)
+		}
+	}
+	if len(ran) == 0 {
+		return
+	}
+
+	sort.Slice(ran, func(i, j int) bool {
+		if ran[i].runTime != ran[j].runTime {
+			return ran[i].runTime > ran[j].runTime
+		}
+		return ran[i].key < ran[j].key
+	})
+
+	glog.Infof("Task timings (slowest first):")
+	for i, ts := range ran {
+		if i >= reportSlowestTasks && !glog.V(2) {
+			glog.Infof("\t... and %d more; use -v=2 to see all task timings", len(ran)-i)
+			break
+		}
+		elapsed := "not completed"
+		if ts.done {
+			elapsed = ts.completed.Sub(ts.firstAttempt).Round(time.Millisecond).String()
+		}
+		glog.Infof("\t%s: %v running, %d attempt(s), %s to complete", ts.key, ts.runTime.Round(time.Millisecond), ts.attempts, elapsed)
+	}
+}
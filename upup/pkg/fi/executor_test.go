@@ -0,0 +1,244 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fi
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/kops/pkg/backoff"
+)
+
+// executorTestTask is a Task that records how many tasks are running at once, and fails a number of times
+type executorTestTask struct {
+	Name *string
+
+	failures int
+	err      error
+	state    *executorTestState
+}
+
+type executorTestState struct {
+	mutex   sync.Mutex
+	running int
+	peak    int
+	runs    []time.Time
+}
+
+func (t *executorTestTask) Run(c *Context) error {
+	s := t.state
+	s.mutex.Lock()
+	s.running++
+	if s.running > s.peak {
+		s.peak = s.running
+	}
+	s.runs = append(s.runs, time.Now())
+	s.mutex.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	s.mutex.Lock()
+	s.running--
+	s.mutex.Unlock()
+
+	if t.failures > 0 {
+		t.failures--
+		return t.err
+	}
+	return nil
+}
+
+// throttlingTestError is an error with an AWS style error code
+type throttlingTestError struct {
+	code string
+}
+
+func (e *throttlingTestError) Code() string {
+	return e.code
+}
+
+func (e *throttlingTestError) Error() string {
+	return e.code + ": Rate exceeded"
+}
+
+func TestExecutorMaxParallelism(t *testing.T) {
+	state := &executorTestState{}
+	tasks := make(map[string]Task)
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("task%d", i)
+		tasks["executorTestTask/"+name] = &executorTestTask{Name: String(name), state: state}
+	}
+
+	e := &executor{
+		options: RunTasksOptions{
+			MaxTaskDuration: time.Minute,
+			MaxParallelism:  3,
+		},
+	}
+	if err := e.RunTasks(tasks); err != nil {
+		t.Fatalf("unexpected error running tasks: %v", err)
+	}
+
+	if len(state.runs) != 10 {
+		t.Errorf("expected 10 runs, got %d", len(state.runs))
+	}
+	if state.peak > 3 {
+		t.Errorf("expected at most 3 tasks to run at once, got %d", state.peak)
+	}
+}
+
+func TestExecutorRetryBackoff(t *testing.T) {
+	grid := []struct {
+		Name    string
+		Err     error
+		Options RunTasksOptions
+	}{
+		{
+			Name: "throttling",
+			Err:  fmt.Errorf("error creating VPC: %v", &throttlingTestError{code: "RequestLimitExceeded"}),
+			Options: RunTasksOptions{
+				MaxTaskDuration:   time.Minute,
+				ThrottlingBackoff: backoff.Backoff{Initial: 50 * time.Millisecond},
+			},
+		},
+		{
+			Name: "retry policy",
+			Err:  errors.New("not ready yet"),
+			Options: RunTasksOptions{
+				MaxTaskDuration: time.Minute,
+				RetryPolicies: map[string]RetryPolicy{
+					"executorTestTask": {Backoff: backoff.Backoff{Initial: 50 * time.Millisecond}},
+				},
+			},
+		},
+	}
+
+	for _, g := range grid {
+		state := &executorTestState{}
+		tasks := map[string]Task{
+			"executorTestTask/flaky": &executorTestTask{Name: String("flaky"), failures: 2, err: g.Err, state: state},
+		}
+
+		e := &executor{options: g.Options}
+		if err := e.RunTasks(tasks); err != nil {
+			t.Fatalf("%s: unexpected error running tasks: %v", g.Name, err)
+		}
+
+		if len(state.runs) != 3 {
+			t.Fatalf("%s: expected 3 runs, got %d", g.Name, len(state.runs))
+		}
+		// The pause doubles after each failure; allow for the time the task takes to run
+		if d := state.runs[1].Sub(state.runs[0]); d < 50*time.Millisecond {
+			t.Errorf("%s: expected backoff of at least 50ms before the first retry, got %v", g.Name, d)
+		}
+		if d := state.runs[2].Sub(state.runs[1]); d < 100*time.Millisecond {
+			t.Errorf("%s: expected backoff of at least 100ms before the second retry, got %v", g.Name, d)
+		}
+	}
+}
+
+func TestExecutorRetryPolicyDeadline(t *testing.T) {
+	state := &executorTestState{}
+	tasks := map[string]Task{
+		"executorTestTask/broken": &executorTestTask{Name: String("broken"), failures: 1000, err: errors.New("broken"), state: state},
+	}
+
+	e := &executor{
+		options: RunTasksOptions{
+			MaxTaskDuration: time.Hour,
+			RetryPolicies: map[string]RetryPolicy{
+				"executorTestTask": {
+					MaxTaskDuration: 100 * time.Millisecond,
+					Backoff:         backoff.Backoff{Initial: 20 * time.Millisecond},
+				},
+			},
+		},
+	}
+	if err := e.RunTasks(tasks); err == nil {
+		t.Fatalf("expected deadline to be exceeded")
+	}
+}
+
+func TestIsThrottlingError(t *testing.T) {
+	grid := []struct {
+		Err      error
+		Expected bool
+	}{
+		{Err: nil, Expected: false},
+		{Err: errors.New("InvalidVpcID.NotFound: The vpc ID 'vpc-1' does not exist"), Expected: false},
+		{Err: &throttlingTestError{code: "Throttling"}, Expected: true},
+		{Err: fmt.Errorf("error listing ELBs: %v", &throttlingTestError{code: "Throttling"}), Expected: true},
+		{Err: errors.New("googleapi: Error 403: Rate Limit Exceeded, rateLimitExceeded"), Expected: true},
+	}
+
+	for i, g := range grid {
+		if actual := IsThrottlingError(g.Err); actual != g.Expected {
+			t.Errorf("case %d: IsThrottlingError(%v) was %v, expected %v", i, g.Err, actual, g.Expected)
+		}
+	}
+}
+
+func TestSetRetryPolicy(t *testing.T) {
+	grid := []struct {
+		Override string
+		Expected RetryPolicy
+		Error    bool
+	}{
+		{
+			Override: "AutoscalingGroup=20m:10s:2m",
+			Expected: RetryPolicy{MaxTaskDuration: 20 * time.Minute, Backoff: backoff.Backoff{Initial: 10 * time.Second, Max: 2 * time.Minute}},
+		},
+		{
+			Override: "AutoscalingGroup=:30s",
+			Expected: RetryPolicy{Backoff: backoff.Backoff{Initial: 30 * time.Second, Max: time.Minute}},
+		},
+		{
+			Override: "Keypair=15m",
+			Expected: RetryPolicy{MaxTaskDuration: 15 * time.Minute},
+		},
+		{Override: "AutoscalingGroup", Error: true},
+		{Override: "AutoscalingGroup=1m:2m:3m:4m", Error: true},
+		{Override: "AutoscalingGroup=soon", Error: true},
+		{Override: "=1m", Error: true},
+	}
+
+	for _, g := range grid {
+		var options RunTasksOptions
+		options.InitDefaults()
+
+		err := options.SetRetryPolicy(g.Override)
+		if g.Error {
+			if err == nil {
+				t.Errorf("%s: expected error", g.Override)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", g.Override, err)
+			continue
+		}
+
+		taskType := strings.SplitN(g.Override, "=", 2)[0]
+		if actual := options.RetryPolicies[taskType]; actual != g.Expected {
+			t.Errorf("%s: expected policy %+v, got %+v", g.Override, g.Expected, actual)
+		}
+	}
+}
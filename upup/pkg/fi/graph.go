@@ -0,0 +1,139 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fi
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// TaskGraph is the dependency graph of a task map, as used by the executor to order the tasks
+type TaskGraph struct {
+	Nodes []*TaskGraphNode `json:"nodes"`
+}
+
+// TaskGraphNode is a task in a TaskGraph, or an object that will be deleted
+type TaskGraphNode struct {
+	// Key identifies the task, as type/name
+	Key string `json:"key"`
+	// Type is the type of the task
+	Type string `json:"type"`
+	// Name is the name of the task
+	Name string `json:"name"`
+	// Lifecycle is the lifecycle of the task, if it has one
+	Lifecycle Lifecycle `json:"lifecycle,omitempty"`
+	// Change is the pending change to the task's object, if any
+	Change ChangeAction `json:"change,omitempty"`
+	// Dependencies are the keys of the tasks that must complete before this task runs
+	Dependencies []string `json:"dependencies,omitempty"`
+}
+
+// BuildTaskGraph builds the dependency graph of the tasks, labelling each with the pending change found by a dry run.
+// Changes that are not for a task in the map, i.e. deletions, are included as nodes without dependencies.
+func BuildTaskGraph(tasks map[string]Task, changes []*TaskChange) *TaskGraph {
+	changeMap := make(map[string]*TaskChange)
+	for _, c := range changes {
+		changeMap[c.Key] = c
+	}
+
+	graph := &TaskGraph{}
+	for key, deps := range FindTaskDependencies(tasks) {
+		node := &TaskGraphNode{
+			Key:          key,
+			Type:         TypeNameForTask(tasks[key]),
+			Dependencies: deps,
+		}
+		node.Name = strings.TrimPrefix(key, node.Type+"/")
+		if hl, ok := tasks[key].(HasLifecycle); ok && hl.GetLifecycle() != nil {
+			node.Lifecycle = *hl.GetLifecycle()
+		}
+		if c := changeMap[key]; c != nil {
+			node.Change = c.Action
+		}
+		sort.Strings(node.Dependencies)
+		graph.Nodes = append(graph.Nodes, node)
+	}
+
+	for _, c := range changes {
+		if _, found := tasks[c.Key]; found {
+			continue
+		}
+		graph.Nodes = append(graph.Nodes, &TaskGraphNode{
+			Key:    c.Key,
+			Type:   c.Type,
+			Name:   c.Name,
+			Change: c.Action,
+		})
+	}
+
+	sort.Slice(graph.Nodes, func(i, j int) bool {
+		return graph.Nodes[i].Key < graph.Nodes[j].Key
+	})
+
+	return graph
+}
+
+// dotColors are the colors of the nodes with pending changes in the DOT rendering
+var dotColors = map[ChangeAction]string{
+	ChangeActionCreate: "palegreen",
+	ChangeActionUpdate: "lightgoldenrod",
+	ChangeActionDelete: "lightpink",
+}
+
+// WriteDOT renders the graph in the DOT language of graphviz.  Edges point from a task to the tasks that depend on it,
+// so they follow the order in which the tasks run.
+func (g *TaskGraph) WriteDOT(out io.Writer) error {
+	b := &bytes.Buffer{}
+
+	fmt.Fprintf(b, "digraph tasks {\n")
+	fmt.Fprintf(b, "  node [shape=box, style=filled, fillcolor=white];\n")
+	for _, node := range g.Nodes {
+		lines := []string{node.Type, node.Name}
+		if node.Lifecycle != "" {
+			lines = append(lines, "lifecycle: "+string(node.Lifecycle))
+		}
+		if node.Change != "" {
+			lines = append(lines, "change: "+string(node.Change))
+		}
+
+		attributes := "label=" + dotQuote(strings.Join(lines, "\n"))
+		if color := dotColors[node.Change]; color != "" {
+			attributes += ", fillcolor=" + dotQuote(color)
+		}
+		fmt.Fprintf(b, "  %s [%s];\n", dotQuote(node.Key), attributes)
+	}
+	for _, node := range g.Nodes {
+		for _, dep := range node.Dependencies {
+			fmt.Fprintf(b, "  %s -> %s;\n", dotQuote(dep), dotQuote(node.Key))
+		}
+	}
+	fmt.Fprintf(b, "}\n")
+
+	_, err := out.Write(b.Bytes())
+	return err
+}
+
+// dotQuote returns s as a quoted DOT string, with newlines as line breaks
+func dotQuote(s string) string {
+	s = strings.Replace(s, "\\", "\\\\", -1)
+	s = strings.Replace(s, "\"", "\\\"", -1)
+	s = strings.Replace(s, "\n", "\\n", -1)
+	return "\"" + s + "\""
+}
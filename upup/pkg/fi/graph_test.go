@@ -0,0 +1,77 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fi
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// childTask is a Task that depends on a testTask
+type childTask struct {
+	Name   *string
+	Parent *testTask
+}
+
+func (t *childTask) Run(c *Context) error {
+	return nil
+}
+
+func Test_BuildTaskGraph(t *testing.T) {
+	lifecycle := LifecycleSync
+	parent := &testTask{Name: String("parent"), Lifecycle: &lifecycle}
+	child := &childTask{Name: String("child"), Parent: parent}
+
+	tasks := map[string]Task{
+		"testTask/parent": parent,
+		"childTask/child": child,
+	}
+	changes := []*TaskChange{
+		{Key: "childTask/child", Type: "childTask", Name: "child", Action: ChangeActionCreate},
+		{Key: "testTask/old-item", Type: "testTask", Name: "old-item", Action: ChangeActionDelete},
+	}
+
+	graph := BuildTaskGraph(tasks, changes)
+
+	expected := &TaskGraph{
+		Nodes: []*TaskGraphNode{
+			{Key: "childTask/child", Type: "childTask", Name: "child", Change: ChangeActionCreate, Dependencies: []string{"testTask/parent"}},
+			{Key: "testTask/old-item", Type: "testTask", Name: "old-item", Change: ChangeActionDelete},
+			{Key: "testTask/parent", Type: "testTask", Name: "parent", Lifecycle: LifecycleSync},
+		},
+	}
+	if !reflect.DeepEqual(graph, expected) {
+		t.Fatalf("unexpected graph: %s", DebugAsJsonStringIndent(graph))
+	}
+
+	var b bytes.Buffer
+	if err := graph.WriteDOT(&b); err != nil {
+		t.Fatalf("error writing DOT: %v", err)
+	}
+	expectedDOT := `digraph tasks {
+  node [shape=box, style=filled, fillcolor=white];
+  "childTask/child" [label="childTask\nchild\nchange: create", fillcolor="palegreen"];
+  "testTask/old-item" [label="testTask\nold-item\nchange: delete", fillcolor="lightpink"];
+  "testTask/parent" [label="testTask\nparent\nlifecycle: Sync"];
+  "testTask/parent" -> "childTask/child";
+}
+`
+	if b.String() != expectedDOT {
+		t.Fatalf("unexpected DOT output.  Expected:\n%s\nActual:\n%s", expectedDOT, b.String())
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fi
+
+import (
+	"strings"
+)
+
+// throttlingErrorCodes are the error codes returned by the AWS APIs when requests are being rate-limited
+var throttlingErrorCodes = []string{
+	"Throttling",
+	"ThrottlingException",
+	"ThrottledException",
+	"RequestThrottled",
+	"RequestThrottledException",
+	"RequestLimitExceeded",
+	"TooManyRequestsException",
+	"PriorRequestNotComplete",
+	"SlowDown",
+}
+
+// throttlingErrorMessages are found in the messages of rate-limiting errors, including those from GCE,
+// which reports the reason only in the message
+var throttlingErrorMessages = []string{
+	"Rate exceeded",
+	"Error 429",
+	"rateLimitExceeded",
+	"RateLimitExceeded",
+}
+
+// IsThrottlingError returns true if the error indicates that a cloud API is rate-limiting our requests.
+// Tasks usually wrap the errors from the cloud, so as well as the error code we check the message.
+func IsThrottlingError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if codeErr, ok := err.(interface {
+		Code() string
+	}); ok {
+		for _, code := range throttlingErrorCodes {
+			if codeErr.Code() == code {
+				return true
+			}
+		}
+	}
+
+	if statusErr, ok := err.(interface {
+		StatusCode() int
+	}); ok && statusErr.StatusCode() == 429 {
+		return true
+	}
+
+	message := err.Error()
+	for _, code := range throttlingErrorCodes {
+		if strings.Contains(message, code+":") {
+			return true
+		}
+	}
+	for _, s := range throttlingErrorMessages {
+		if strings.Contains(message, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -299,9 +299,33 @@ func (k *keyset) ToAPIObject(name string, includePrivateKeyMaterial bool) (*kops
 
 		o.Spec.Keys = append(o.Spec.Keys, oki)
 	}
+
+	o.Spec.PrimaryId = k.primaryId
+	o.Spec.Rotation = k.rotation
+
 	return o, nil
 }
 
+// loadKeysetMetadata copies the primary id and rotation state from the bundle at p, if there is one, to the keyset.
+// They are only recorded in the bundle, so are lost when a keyset is loaded by listing its files.
+func (c *VFSCAStore) loadKeysetMetadata(p vfs.Path, ks *keyset) error {
+	bundle, err := c.loadKeysetBundle(p.Join("keyset.yaml"))
+	if err != nil {
+		return err
+	}
+	if bundle == nil {
+		return nil
+	}
+
+	ks.primaryId = bundle.primaryId
+	ks.rotation = bundle.rotation
+	if ks.primaryId != "" && ks.items[ks.primaryId] == nil {
+		ks.primaryId = ""
+	}
+	ks.primary = ks.findPrimary()
+	return nil
+}
+
 // writeKeysetBundle writes a keyset bundle to VFS
 func (c *VFSCAStore) writeKeysetBundle(p vfs.Path, name string, keyset *keyset, includePrivateKeyMaterial bool) error {
 	p = p.Join("keyset.yaml")
@@ -509,10 +533,11 @@ func (c *VFSCAStore) FindCertificatePool(name string) (*CertificatePool, error)
 			pool.Primary = certs.primary.certificate
 		}
 
-		for k, cert := range certs.items {
+		for _, k := range certs.sortedIds() {
 			if certs.primary != nil && k == certs.primary.id {
 				continue
 			}
+			cert := certs.items[k]
 			if cert.certificate == nil {
 				continue
 			}
@@ -973,6 +998,9 @@ func (c *VFSCAStore) storePrivateKey(name string, ki *keysetItem) error {
 			ks.items = make(map[string]*keysetItem)
 		}
 		ks.items[ki.id] = ki
+		if err := c.loadKeysetMetadata(p, ks); err != nil {
+			return err
+		}
 
 		if err := c.writeKeysetBundle(p, name, ks, true); err != nil {
 			return fmt.Errorf("error writing bundle: %v", err)
@@ -1015,6 +1043,9 @@ func (c *VFSCAStore) storeCertificate(name string, ki *keysetItem) error {
 			ks.items = make(map[string]*keysetItem)
 		}
 		ks.items[ki.id] = ki
+		if err := c.loadKeysetMetadata(p, ks); err != nil {
+			return err
+		}
 
 		if err := c.writeKeysetBundle(p, name, ks, false); err != nil {
 			return fmt.Errorf("error writing bundle: %v", err)
@@ -1050,6 +1081,9 @@ func (c *VFSCAStore) deletePrivateKey(name string, id string) (bool, error) {
 			return false, nil
 		}
 		delete(ks.items, id)
+		if err := c.loadKeysetMetadata(p, ks); err != nil {
+			return false, err
+		}
 
 		if err := c.writeKeysetBundle(p, name, ks, true); err != nil {
 			return false, fmt.Errorf("error writing bundle: %v", err)
@@ -1070,7 +1104,7 @@ func (c *VFSCAStore) deletePrivateKey(name string, id string) (bool, error) {
 func (c *VFSCAStore) deleteCertificate(name string, id string) (bool, error) {
 	// Update the bundle
 	{
-		p := c.buildPrivateKeyPoolPath(name)
+		p := c.buildCertificatePoolPath(name)
 		ks, err := c.loadCertificates(p, false)
 		if err != nil {
 			return false, err
@@ -1080,6 +1114,9 @@ func (c *VFSCAStore) deleteCertificate(name string, id string) (bool, error) {
 			return false, nil
 		}
 		delete(ks.items, id)
+		if err := c.loadKeysetMetadata(p, ks); err != nil {
+			return false, err
+		}
 
 		if err := c.writeKeysetBundle(p, name, ks, false); err != nil {
 			return false, fmt.Errorf("error writing bundle: %v", err)
@@ -1196,6 +1233,44 @@ func (c *VFSCAStore) DeleteKeysetItem(item *kops.Keyset, id string) error {
 	}
 }
 
+// SetKeysetRotation implements CAStore::SetKeysetRotation
+func (c *VFSCAStore) SetKeysetRotation(name string, primaryId string, rotation *kops.KeysetRotation) error {
+	certificates, err := c.loadCertificates(c.buildCertificatePoolPath(name), true)
+	if err != nil {
+		return err
+	}
+	privateKeys, err := c.loadPrivateKeys(c.buildPrivateKeyPoolPath(name), true)
+	if err != nil {
+		return err
+	}
+	if certificates == nil {
+		return fmt.Errorf("keyset %q not found", name)
+	}
+	if primaryId != "" && certificates.items[primaryId] == nil {
+		return fmt.Errorf("KeysetItem %q not found in Keyset %q", primaryId, name)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.cachedCAs, name)
+
+	if privateKeys != nil {
+		privateKeys.primaryId = primaryId
+		privateKeys.rotation = rotation
+		if err := c.writeKeysetBundle(c.buildPrivateKeyPoolPath(name), name, privateKeys, true); err != nil {
+			return fmt.Errorf("error writing bundle: %v", err)
+		}
+	}
+
+	certificates.primaryId = primaryId
+	certificates.rotation = rotation
+	if err := c.writeKeysetBundle(c.buildCertificatePoolPath(name), name, certificates, false); err != nil {
+		return fmt.Errorf("error writing bundle: %v", err)
+	}
+
+	return nil
+}
+
 func (c *VFSCAStore) DeleteSSHCredential(item *kops.SSHCredential) error {
 	if item.Spec.PublicKey == "" {
 		return fmt.Errorf("must specific public key to delete SSHCredential")
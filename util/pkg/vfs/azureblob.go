@@ -0,0 +1,606 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vfs
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/kops/util/pkg/hashing"
+)
+
+// AzureBlobPath is a vfs path for Azure Blob Storage, written as azureblob://<container>/<key>.
+// The storage account is configured with AZURE_STORAGE_ACCOUNT.
+type AzureBlobPath struct {
+	context   *AzureBlobContext
+	container string
+	key       string
+
+	// md5Hash is the MD5 of the blob, if known from a listing
+	md5Hash []byte
+}
+
+var _ Path = &AzureBlobPath{}
+var _ HasHash = &AzureBlobPath{}
+var _ HasClusterReadable = &AzureBlobPath{}
+
+// AzureBlobACL is the ACL for a blob.  Azure does not have ACLs on individual blobs: anonymous read access is
+// set on the container, so a PublicRead ACL is only accepted when the container allows it.
+type AzureBlobACL struct {
+	// PublicRead is set if the blob should be readable without credentials
+	PublicRead bool
+}
+
+// AzureBlobContext holds the credentials for Azure Blob Storage, which are shared by all AzureBlobPaths
+type AzureBlobContext struct {
+	mutex      sync.Mutex
+	httpClient *http.Client
+
+	// account is the storage account, from AZURE_STORAGE_ACCOUNT
+	account string
+	// accountKey is the (decoded) shared key of the storage account, from AZURE_STORAGE_KEY
+	accountKey []byte
+	// sasToken is a shared access signature, from AZURE_STORAGE_SAS_TOKEN, used if there is no account key
+	sasToken string
+	// endpoint is the blob service URL; it defaults to https://<account>.blob.core.windows.net,
+	// and can be set with AZURE_STORAGE_BLOB_ENDPOINT for sovereign clouds or the storage emulator
+	endpoint string
+}
+
+// NewAzureBlobContext builds an AzureBlobContext; the credentials are read when they are first needed
+func NewAzureBlobContext() *AzureBlobContext {
+	return &AzureBlobContext{
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// azureStorageVersion is the version of the Blob service REST API we use
+const azureStorageVersion = "2018-03-28"
+
+// azureBlobBackoff is the backoff strategy for retrying Azure requests that fail with a server error
+var azureBlobBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   1.5,
+	Jitter:   0.1,
+	Steps:    4,
+}
+
+func newAzureBlobPath(context *AzureBlobContext, container string, key string) *AzureBlobPath {
+	return &AzureBlobPath{
+		context:   context,
+		container: strings.Trim(container, "/"),
+		key:       strings.TrimPrefix(key, "/"),
+	}
+}
+
+// AzureBlobVFSPath converts a blob URL, https://<account>.blob.core.windows.net/<container>/<key>,
+// to the equivalent azureblob://<container>/<key> vfs path
+func AzureBlobVFSPath(blobURL string) (string, error) {
+	u, err := url.Parse(blobURL)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse azure blob URL %q: %v", blobURL, err)
+	}
+	if u.Scheme != "https" || !strings.HasSuffix(u.Host, ".blob.core.windows.net") {
+		return "", fmt.Errorf("%s is not a valid azure blob URL", blobURL)
+	}
+	p := strings.TrimPrefix(u.Path, "/")
+	if p == "" || strings.HasPrefix(p, "/") {
+		return "", fmt.Errorf("%s is not a valid azure blob URL: no container defined", blobURL)
+	}
+	return "azureblob://" + p, nil
+}
+
+func (c *AzureBlobContext) init() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.account != "" {
+		return nil
+	}
+
+	account := os.Getenv("AZURE_STORAGE_ACCOUNT")
+	if account == "" {
+		return fmt.Errorf("AZURE_STORAGE_ACCOUNT must be set to use azureblob:// paths")
+	}
+
+	if key := os.Getenv("AZURE_STORAGE_KEY"); key != "" {
+		decoded, err := base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			return fmt.Errorf("AZURE_STORAGE_KEY is not valid base64: %v", err)
+		}
+		c.accountKey = decoded
+	} else {
+		c.sasToken = strings.TrimPrefix(os.Getenv("AZURE_STORAGE_SAS_TOKEN"), "?")
+		if c.sasToken == "" {
+			return fmt.Errorf("AZURE_STORAGE_KEY or AZURE_STORAGE_SAS_TOKEN must be set to use azureblob:// paths")
+		}
+	}
+
+	c.endpoint = os.Getenv("AZURE_STORAGE_BLOB_ENDPOINT")
+	if c.endpoint == "" {
+		c.endpoint = "https://" + account + ".blob.core.windows.net"
+	}
+	c.account = account
+	return nil
+}
+
+// azureBlobError is the error document returned by the Blob service
+type azureBlobError struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// azureBlobResponse is a response from the Blob service
+type azureBlobResponse struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+	err        azureBlobError
+}
+
+// canonicalizedHeaders returns the x-ms- headers, as they are signed
+func canonicalizedHeaders(header http.Header) string {
+	var names []string
+	for name := range header {
+		name = strings.ToLower(name)
+		if strings.HasPrefix(name, "x-ms-") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var b bytes.Buffer
+	for _, name := range names {
+		b.WriteString(name + ":" + strings.TrimSpace(header.Get(name)) + "\n")
+	}
+	return b.String()
+}
+
+// canonicalizedResource returns the account, path and query of the request, as they are signed
+func canonicalizedResource(account string, u *url.URL) string {
+	s := "/" + account + u.EscapedPath()
+
+	query := u.Query()
+	var names []string
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		values := query[name]
+		sort.Strings(values)
+		s += "\n" + strings.ToLower(name) + ":" + strings.Join(values, ",")
+	}
+	return s
+}
+
+// azureStringToSign returns the string that is signed with the shared key, for the request
+func azureStringToSign(account string, req *http.Request) string {
+	contentLength := ""
+	if req.ContentLength > 0 {
+		contentLength = strconv.FormatInt(req.ContentLength, 10)
+	}
+	return strings.Join([]string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		contentLength,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		"", // Date, which is sent as x-ms-date
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+		canonicalizedHeaders(req.Header) + canonicalizedResource(account, req.URL),
+	}, "\n")
+}
+
+// sign adds the credentials to the request
+func (c *AzureBlobContext) sign(req *http.Request) {
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("x-ms-version", azureStorageVersion)
+
+	if c.accountKey == nil {
+		// The SAS token is already in the query
+		return
+	}
+
+	mac := hmac.New(sha256.New, c.accountKey)
+	mac.Write([]byte(azureStringToSign(c.account, req)))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	req.Header.Set("Authorization", "SharedKey "+c.account+":"+signature)
+}
+
+// do performs a request against the container, for the blob (which may be empty for container requests)
+func (p *AzureBlobPath) do(method string, blob string, query url.Values, header http.Header, body []byte) (*azureBlobResponse, error) {
+	if err := p.context.init(); err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(p.context.endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid azure blob endpoint %q: %v", p.context.endpoint, err)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + p.container
+	if blob != "" {
+		u.Path += "/" + blob
+	}
+	if query == nil {
+		query = url.Values{}
+	}
+	if p.context.sasToken != "" {
+		sas, err := url.ParseQuery(p.context.sasToken)
+		if err != nil {
+			return nil, fmt.Errorf("invalid AZURE_STORAGE_SAS_TOKEN: %v", err)
+		}
+		for k, v := range sas {
+			query[k] = v
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	response := &azureBlobResponse{}
+	done, err := RetryWithBackoff(azureBlobBackoff, func() (bool, error) {
+		glog.V(8).Infof("Performing azure blob request: %s %s", method, u.Path)
+		req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+		if err != nil {
+			return true, err
+		}
+		req.ContentLength = int64(len(body))
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		p.context.sign(req)
+
+		resp, err := p.context.httpClient.Do(req)
+		if err != nil {
+			return false, fmt.Errorf("error performing azure blob request %s %s: %v", method, u.Path, err)
+		}
+		defer resp.Body.Close()
+
+		b, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return false, fmt.Errorf("error reading azure blob response: %v", err)
+		}
+
+		*response = azureBlobResponse{
+			statusCode: resp.StatusCode,
+			header:     resp.Header,
+			body:       b,
+		}
+		if resp.StatusCode >= 300 && len(b) != 0 {
+			// Errors are described by an XML document; if it cannot be parsed we report the status alone
+			xml.Unmarshal(b, &response.err)
+		}
+		if resp.StatusCode >= 500 {
+			return false, fmt.Errorf("azure blob request %s %s failed with status %d: %s %s", method, u.Path, resp.StatusCode, response.err.Code, response.err.Message)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	} else if !done {
+		// Shouldn't happen - we always return a non-nil error with false
+		return nil, wait.ErrWaitTimeout
+	}
+	return response, nil
+}
+
+func (p *AzureBlobPath) azureError(op string, response *azureBlobResponse) error {
+	return fmt.Errorf("error %s %s: azure returned status %d: %s %s", op, p, response.statusCode, response.err.Code, response.err.Message)
+}
+
+// WriteTo implements io.WriterTo
+func (p *AzureBlobPath) WriteTo(out io.Writer) (int64, error) {
+	data, err := p.ReadFile()
+	if err != nil {
+		return 0, err
+	}
+	n, err := out.Write(data)
+	return int64(n), err
+}
+
+func (p *AzureBlobPath) Join(relativePath ...string) Path {
+	args := []string{p.key}
+	args = append(args, relativePath...)
+	joined := path.Join(args...)
+	return newAzureBlobPath(p.context, p.container, joined)
+}
+
+// ReadFile implements Path::ReadFile
+func (p *AzureBlobPath) ReadFile() ([]byte, error) {
+	glog.V(4).Infof("Reading file %q", p)
+
+	response, err := p.do("GET", p.key, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if response.statusCode == http.StatusNotFound {
+		return nil, os.ErrNotExist
+	}
+	if response.statusCode != http.StatusOK {
+		return nil, p.azureError("reading", response)
+	}
+	return response.body, nil
+}
+
+// checkACL checks that the ACL can be honoured, which for a public blob means the container allows anonymous reads
+func (p *AzureBlobPath) checkACL(acl ACL) error {
+	if acl == nil {
+		return nil
+	}
+	azureACL, ok := acl.(*AzureBlobACL)
+	if !ok {
+		return fmt.Errorf("write to %s with ACL of unexpected type %T", p, acl)
+	}
+	if !azureACL.PublicRead {
+		return nil
+	}
+
+	query := url.Values{}
+	query.Set("restype", "container")
+	response, err := p.do("HEAD", "", query, nil, nil)
+	if err != nil {
+		return err
+	}
+	if response.statusCode != http.StatusOK {
+		return p.azureError("reading container properties of", response)
+	}
+	access := response.header.Get("x-ms-blob-public-access")
+	if access != "blob" && access != "container" {
+		return fmt.Errorf("cannot write %s with public read access: container %q does not allow anonymous access to blobs", p, p.container)
+	}
+	return nil
+}
+
+func (p *AzureBlobPath) write(data io.ReadSeeker, acl ACL, create bool) error {
+	if err := p.checkACL(acl); err != nil {
+		return err
+	}
+
+	if _, err := data.Seek(0, 0); err != nil {
+		return fmt.Errorf("error seeking to start of data stream for write to %s: %v", p, err)
+	}
+	b, err := ioutil.ReadAll(data)
+	if err != nil {
+		return fmt.Errorf("error reading from data stream: %v", err)
+	}
+
+	md5Hash := md5.Sum(b)
+	header := http.Header{}
+	header.Set("x-ms-blob-type", "BlockBlob")
+	header.Set("Content-Type", "application/octet-stream")
+	header.Set("Content-MD5", base64.StdEncoding.EncodeToString(md5Hash[:]))
+	if create {
+		// The write fails if the blob already exists
+		header.Set("If-None-Match", "*")
+	}
+
+	response, err := p.do("PUT", p.key, nil, header, b)
+	if err != nil {
+		return err
+	}
+	if create && (response.statusCode == http.StatusConflict || response.statusCode == http.StatusPreconditionFailed) {
+		return os.ErrExist
+	}
+	if response.statusCode != http.StatusCreated {
+		return p.azureError("writing", response)
+	}
+	return nil
+}
+
+// WriteFile implements Path::WriteFile
+func (p *AzureBlobPath) WriteFile(data io.ReadSeeker, acl ACL) error {
+	glog.V(4).Infof("Writing file %q", p)
+	return p.write(data, acl, false)
+}
+
+// CreateFile implements Path::CreateFile, using a conditional write so that an existing blob is never replaced
+func (p *AzureBlobPath) CreateFile(data io.ReadSeeker, acl ACL) error {
+	glog.V(4).Infof("Creating file %q", p)
+	return p.write(data, acl, true)
+}
+
+// Remove implements Path::Remove
+func (p *AzureBlobPath) Remove() error {
+	glog.V(8).Infof("removing file %s", p)
+
+	response, err := p.do("DELETE", p.key, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	if response.statusCode == http.StatusNotFound {
+		return os.ErrNotExist
+	}
+	if response.statusCode != http.StatusAccepted {
+		return p.azureError("deleting", response)
+	}
+	return nil
+}
+
+func (p *AzureBlobPath) Base() string {
+	return path.Base(p.key)
+}
+
+func (p *AzureBlobPath) String() string {
+	return p.Path()
+}
+
+func (p *AzureBlobPath) Path() string {
+	return "azureblob://" + p.container + "/" + p.key
+}
+
+// Container returns the name of the container
+func (p *AzureBlobPath) Container() string {
+	return p.container
+}
+
+// Key returns the name of the blob in the container
+func (p *AzureBlobPath) Key() string {
+	return p.key
+}
+
+// IsClusterReadable implements HasClusterReadable.
+// Instances can read the state store, as long as they are given the storage account credentials.
+func (p *AzureBlobPath) IsClusterReadable() bool {
+	return true
+}
+
+// azureBlobList is the subset of the List Blobs response that we use
+type azureBlobList struct {
+	Blobs struct {
+		Blob []struct {
+			Name       string `xml:"Name"`
+			Properties struct {
+				ContentMD5 string `xml:"Content-MD5"`
+			} `xml:"Properties"`
+		} `xml:"Blob"`
+		BlobPrefix []struct {
+			Name string `xml:"Name"`
+		} `xml:"BlobPrefix"`
+	} `xml:"Blobs"`
+	NextMarker string `xml:"NextMarker"`
+}
+
+// list returns the blobs (and, with a delimiter, the directories) under the prefix, following pagination
+func (p *AzureBlobPath) list(prefix string, delimiter string) ([]Path, error) {
+	var paths []Path
+	marker := ""
+	for {
+		query := url.Values{}
+		query.Set("restype", "container")
+		query.Set("comp", "list")
+		query.Set("prefix", prefix)
+		if delimiter != "" {
+			query.Set("delimiter", delimiter)
+		}
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+
+		response, err := p.do("GET", "", query, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		if response.statusCode != http.StatusOK {
+			return nil, p.azureError("listing", response)
+		}
+
+		list := &azureBlobList{}
+		if err := xml.Unmarshal(response.body, list); err != nil {
+			return nil, fmt.Errorf("error parsing listing of %s: %v", p, err)
+		}
+
+		for _, blob := range list.Blobs.Blob {
+			child := newAzureBlobPath(p.context, p.container, blob.Name)
+			if blob.Properties.ContentMD5 != "" {
+				md5Hash, err := base64.StdEncoding.DecodeString(blob.Properties.ContentMD5)
+				if err == nil {
+					child.md5Hash = md5Hash
+				}
+			}
+			paths = append(paths, child)
+		}
+		for _, dir := range list.Blobs.BlobPrefix {
+			paths = append(paths, newAzureBlobPath(p.context, p.container, strings.TrimSuffix(dir.Name, "/")))
+		}
+
+		if list.NextMarker == "" {
+			break
+		}
+		marker = list.NextMarker
+	}
+	return paths, nil
+}
+
+// ReadDir implements Path::ReadDir
+func (p *AzureBlobPath) ReadDir() ([]Path, error) {
+	prefix := p.key
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	paths, err := p.list(prefix, "/")
+	if err != nil {
+		return nil, err
+	}
+	glog.V(8).Infof("Listed files in %v: %v", p, paths)
+	return paths, nil
+}
+
+// ReadTree implements Path::ReadTree
+func (p *AzureBlobPath) ReadTree() ([]Path, error) {
+	prefix := p.key
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return p.list(prefix, "")
+}
+
+func (p *AzureBlobPath) PreferredHash() (*hashing.Hash, error) {
+	return p.Hash(hashing.HashAlgorithmMD5)
+}
+
+// Hash implements HasHash, returning the MD5 that Azure stores with the blob
+func (p *AzureBlobPath) Hash(a hashing.HashAlgorithm) (*hashing.Hash, error) {
+	if a != hashing.HashAlgorithmMD5 {
+		return nil, nil
+	}
+
+	md5Hash := p.md5Hash
+	if md5Hash == nil {
+		response, err := p.do("HEAD", p.key, nil, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		if response.statusCode == http.StatusNotFound {
+			return nil, os.ErrNotExist
+		}
+		if response.statusCode != http.StatusOK {
+			return nil, p.azureError("reading properties of", response)
+		}
+		contentMD5 := response.header.Get("Content-MD5")
+		if contentMD5 == "" {
+			return nil, nil
+		}
+		md5Hash, err = base64.StdEncoding.DecodeString(contentMD5)
+		if err != nil {
+			return nil, fmt.Errorf("Content-MD5 of %s was not valid: %q", p, contentMD5)
+		}
+	}
+
+	return &hashing.Hash{Algorithm: hashing.HashAlgorithmMD5, HashValue: md5Hash}, nil
+}
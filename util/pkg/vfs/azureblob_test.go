@@ -0,0 +1,227 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vfs
+
+import (
+	"bytes"
+	"crypto/md5"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"k8s.io/kops/util/pkg/hashing"
+	"k8s.io/kops/util/pkg/vfs/azureblobfake"
+)
+
+func Test_AzureBlobPath_Parse(t *testing.T) {
+	grid := []struct {
+		Input             string
+		ExpectError       bool
+		ExpectedContainer string
+		ExpectedKey       string
+	}{
+		{
+			Input:             "azureblob://kops-state",
+			ExpectedContainer: "kops-state",
+			ExpectedKey:       "",
+		},
+		{
+			Input:             "azureblob://kops-state/cluster.example.com/config",
+			ExpectedContainer: "kops-state",
+			ExpectedKey:       "cluster.example.com/config",
+		},
+		{
+			Input:       "azureblob:///cluster.example.com",
+			ExpectError: true,
+		},
+	}
+	for _, g := range grid {
+		azurePath, err := Context.buildAzureBlobPath(g.Input)
+		if !g.ExpectError {
+			if err != nil {
+				t.Fatalf("unexpected error parsing azure blob path: %v", err)
+			}
+			if azurePath.Container() != g.ExpectedContainer || azurePath.Key() != g.ExpectedKey {
+				t.Fatalf("unexpected azure blob path: %#v", azurePath)
+			}
+			if azurePath.Path() != g.Input && azurePath.Path() != g.Input+"/" {
+				t.Fatalf("azure blob path %q did not round trip: %q", g.Input, azurePath.Path())
+			}
+		} else {
+			if err == nil {
+				t.Fatalf("expected error parsing %q", g.Input)
+			}
+		}
+	}
+}
+
+func Test_AzureBlobStringToSign(t *testing.T) {
+	req, err := http.NewRequest("PUT", "https://account.blob.core.windows.net/kops-state/cluster/config?comp=block&blockid=b", bytes.NewReader([]byte("data")))
+	if err != nil {
+		t.Fatalf("error building request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("If-None-Match", "*")
+	req.Header.Set("x-ms-version", azureStorageVersion)
+	req.Header.Set("x-ms-date", "Mon, 01 Jan 2018 00:00:00 GMT")
+	req.Header.Set("x-ms-blob-type", "BlockBlob")
+
+	expected := strings.Join([]string{
+		"PUT",
+		"",
+		"",
+		"4",
+		"",
+		"application/octet-stream",
+		"",
+		"",
+		"",
+		"*",
+		"",
+		"",
+		"x-ms-blob-type:BlockBlob",
+		"x-ms-date:Mon, 01 Jan 2018 00:00:00 GMT",
+		"x-ms-version:" + azureStorageVersion,
+		"/account/kops-state/cluster/config",
+		"blockid:b",
+		"comp:block",
+	}, "\n")
+	if actual := azureStringToSign("account", req); actual != expected {
+		t.Fatalf("unexpected string to sign:\n%s\nexpected:\n%s", actual, expected)
+	}
+}
+
+func Test_AzureBlobPath_ReadWrite(t *testing.T) {
+	server := azureblobfake.NewServer("testaccount")
+	defer server.Close()
+	server.PageSize = 2
+
+	context := NewAzureBlobContext()
+	context.account = "testaccount"
+	context.accountKey = []byte("secret")
+	context.endpoint = server.URL
+	base := newAzureBlobPath(context, "kops-state", "cluster.example.com")
+
+	p := base.Join("pki", "private", "ca", "1.key")
+	if _, err := p.ReadFile(); !os.IsNotExist(err) {
+		t.Fatalf("expected not exist reading missing file, got %v", err)
+	}
+
+	if err := p.CreateFile(bytes.NewReader([]byte("key1")), nil); err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	if err := p.CreateFile(bytes.NewReader([]byte("key2")), nil); !os.IsExist(err) {
+		t.Fatalf("expected exists error creating existing file, got %v", err)
+	}
+	data, err := p.ReadFile()
+	if err != nil {
+		t.Fatalf("error reading file: %v", err)
+	}
+	if string(data) != "key1" {
+		t.Fatalf("unexpected file contents: %q", data)
+	}
+
+	for _, f := range []string{"config", "instancegroup/nodes", "instancegroup/master-us-east-1a", "pki/issued/ca/1.crt"} {
+		if err := base.Join(f).WriteFile(bytes.NewReader([]byte(f)), nil); err != nil {
+			t.Fatalf("error writing file: %v", err)
+		}
+	}
+
+	children, err := base.ReadDir()
+	if err != nil {
+		t.Fatalf("error reading dir: %v", err)
+	}
+	if actual, expected := azureBlobPaths(children), []string{"config", "instancegroup", "pki"}; !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("unexpected ReadDir, expected %v, got %v", expected, actual)
+	}
+
+	// The listing is paginated, two to a page
+	tree, err := base.ReadTree()
+	if err != nil {
+		t.Fatalf("error reading tree: %v", err)
+	}
+	expectedTree := []string{"config", "instancegroup/master-us-east-1a", "instancegroup/nodes", "pki/issued/ca/1.crt", "pki/private/ca/1.key"}
+	if actual := azureBlobPaths(tree); !reflect.DeepEqual(actual, expectedTree) {
+		t.Fatalf("unexpected ReadTree, expected %v, got %v", expectedTree, actual)
+	}
+
+	expectedHash := md5.Sum([]byte("config"))
+	for _, child := range tree {
+		if child.Base() != "config" {
+			continue
+		}
+		hash, err := child.(HasHash).Hash(hashing.HashAlgorithmMD5)
+		if err != nil {
+			t.Fatalf("error getting hash from listing: %v", err)
+		}
+		if !bytes.Equal(hash.HashValue, expectedHash[:]) {
+			t.Fatalf("unexpected hash from listing: %v", hash)
+		}
+	}
+	hash, err := base.Join("config").(HasHash).Hash(hashing.HashAlgorithmMD5)
+	if err != nil {
+		t.Fatalf("error getting hash: %v", err)
+	}
+	if !bytes.Equal(hash.HashValue, expectedHash[:]) {
+		t.Fatalf("unexpected hash: %v", hash)
+	}
+
+	if err := base.Join("public").WriteFile(bytes.NewReader([]byte("asset")), &AzureBlobACL{PublicRead: true}); err == nil {
+		t.Fatalf("expected error writing public blob to private container")
+	}
+	server.PublicContainers["kops-state"] = true
+	if err := base.Join("public").WriteFile(bytes.NewReader([]byte("asset")), &AzureBlobACL{PublicRead: true}); err != nil {
+		t.Fatalf("error writing public blob to public container: %v", err)
+	}
+	if err := base.Join("public").WriteFile(bytes.NewReader([]byte("asset")), &S3Acl{}); err == nil {
+		t.Fatalf("expected error writing with S3 ACL")
+	}
+
+	if err := p.Remove(); err != nil {
+		t.Fatalf("error removing file: %v", err)
+	}
+	if _, err := p.ReadFile(); !os.IsNotExist(err) {
+		t.Fatalf("expected not exist reading removed file, got %v", err)
+	}
+
+	empty, err := base.Join("missing").ReadTree()
+	if err != nil {
+		t.Fatalf("error reading missing tree: %v", err)
+	}
+	if len(empty) != 0 {
+		t.Fatalf("unexpected files in missing tree: %v", empty)
+	}
+
+	context.accountKey = nil
+	context.sasToken = "sig=invalid"
+	if _, err := base.Join("config").ReadFile(); err == nil || os.IsNotExist(err) {
+		t.Fatalf("expected authentication error without the account key, got %v", err)
+	}
+}
+
+// azureBlobPaths returns the sorted keys of the paths, relative to cluster.example.com
+func azureBlobPaths(paths []Path) []string {
+	var keys []string
+	for _, p := range paths {
+		keys = append(keys, strings.TrimPrefix(p.(*AzureBlobPath).Key(), "cluster.example.com/"))
+	}
+	sort.Strings(keys)
+	return keys
+}
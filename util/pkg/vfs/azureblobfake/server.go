@@ -0,0 +1,253 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package azureblobfake is a fake of the Azure Blob Storage service, for tests.
+// It implements only the requests made by vfs.AzureBlobPath.
+package azureblobfake
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Server is a fake Blob service for a single storage account
+type Server struct {
+	*httptest.Server
+
+	// Account is the storage account that requests must be signed for
+	Account string
+	// PageSize is the maximum number of entries returned by each List Blobs request
+	PageSize int
+	// PublicContainers are the containers that allow anonymous access to blobs
+	PublicContainers map[string]bool
+
+	mutex sync.Mutex
+	// blobs maps <container>/<blob> to the contents of the blob
+	blobs map[string][]byte
+}
+
+// NewServer starts a fake Blob service for the account
+func NewServer(account string) *Server {
+	s := &Server{
+		Account:          account,
+		PageSize:         5000,
+		PublicContainers: make(map[string]bool),
+		blobs:            make(map[string][]byte),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Keys returns the names of all the blobs, as <container>/<blob>
+func (s *Server) Keys() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var keys []string
+	for k := range s.blobs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func writeError(w http.ResponseWriter, statusCode int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(statusCode)
+	xml.NewEncoder(w).Encode(struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string   `xml:"Code"`
+		Message string   `xml:"Message"`
+	}{Code: code, Message: code})
+}
+
+func contentMD5(data []byte) string {
+	hash := md5.Sum(data)
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+type listBlob struct {
+	Name       string `xml:"Name"`
+	Properties struct {
+		ContentMD5 string `xml:"Content-MD5"`
+	} `xml:"Properties"`
+}
+
+type listPrefix struct {
+	Name string `xml:"Name"`
+}
+
+type listResult struct {
+	XMLName xml.Name `xml:"EnumerationResults"`
+	Blobs   struct {
+		Blob       []listBlob   `xml:"Blob"`
+		BlobPrefix []listPrefix `xml:"BlobPrefix"`
+	} `xml:"Blobs"`
+	NextMarker string `xml:"NextMarker"`
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "SharedKey "+s.Account+":") || r.Header.Get("x-ms-version") == "" {
+		writeError(w, http.StatusForbidden, "AuthenticationFailed")
+		return
+	}
+
+	// Paths are /<container> or /<container>/<blob>
+	tokens := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	container := tokens[0]
+	query := r.URL.Query()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(tokens) == 1 {
+		if query.Get("restype") != "container" {
+			writeError(w, http.StatusBadRequest, "InvalidQueryParameterValue")
+			return
+		}
+		if r.Method == "HEAD" {
+			if s.PublicContainers[container] {
+				w.Header().Set("x-ms-blob-public-access", "blob")
+			}
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		if r.Method == "GET" && query.Get("comp") == "list" {
+			s.list(w, container, query.Get("prefix"), query.Get("delimiter"), query.Get("marker"))
+			return
+		}
+		writeError(w, http.StatusMethodNotAllowed, "UnsupportedHttpVerb")
+		return
+	}
+
+	key := container + "/" + tokens[1]
+	switch r.Method {
+	case "GET", "HEAD":
+		data, found := s.blobs[key]
+		if !found {
+			if r.Method == "HEAD" {
+				w.WriteHeader(http.StatusNotFound)
+			} else {
+				writeError(w, http.StatusNotFound, "BlobNotFound")
+			}
+			return
+		}
+		w.Header().Set("Content-MD5", contentMD5(data))
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.WriteHeader(http.StatusOK)
+		if r.Method == "GET" {
+			w.Write(data)
+		}
+
+	case "PUT":
+		if r.Header.Get("x-ms-blob-type") != "BlockBlob" {
+			writeError(w, http.StatusBadRequest, "MissingRequiredHeader")
+			return
+		}
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "InvalidInput")
+			return
+		}
+		if md5 := r.Header.Get("Content-MD5"); md5 != "" && md5 != contentMD5(data) {
+			writeError(w, http.StatusBadRequest, "Md5Mismatch")
+			return
+		}
+		if _, found := s.blobs[key]; found && r.Header.Get("If-None-Match") == "*" {
+			writeError(w, http.StatusConflict, "BlobAlreadyExists")
+			return
+		}
+		s.blobs[key] = data
+		w.WriteHeader(http.StatusCreated)
+
+	case "DELETE":
+		if _, found := s.blobs[key]; !found {
+			writeError(w, http.StatusNotFound, "BlobNotFound")
+			return
+		}
+		delete(s.blobs, key)
+		w.WriteHeader(http.StatusAccepted)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "UnsupportedHttpVerb")
+	}
+}
+
+// list writes a page of the blobs in the container with the prefix; the marker is the name to continue from
+func (s *Server) list(w http.ResponseWriter, container string, prefix string, delimiter string, marker string) {
+	type entry struct {
+		name   string
+		prefix bool
+	}
+
+	seen := make(map[string]bool)
+	var entries []entry
+	for k := range s.blobs {
+		if !strings.HasPrefix(k, container+"/") {
+			continue
+		}
+		name := strings.TrimPrefix(k, container+"/")
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if delimiter != "" {
+			if i := strings.Index(name[len(prefix):], delimiter); i != -1 {
+				dir := name[:len(prefix)+i+len(delimiter)]
+				if !seen[dir] {
+					seen[dir] = true
+					entries = append(entries, entry{name: dir, prefix: true})
+				}
+				continue
+			}
+		}
+		entries = append(entries, entry{name: name})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].name < entries[j].name
+	})
+
+	result := &listResult{}
+	count := 0
+	for _, e := range entries {
+		if e.name < marker {
+			continue
+		}
+		if count == s.PageSize {
+			result.NextMarker = e.name
+			break
+		}
+		count++
+		if e.prefix {
+			result.Blobs.BlobPrefix = append(result.Blobs.BlobPrefix, listPrefix{Name: e.name})
+		} else {
+			b := listBlob{Name: e.name}
+			b.Properties.ContentMD5 = contentMD5(s.blobs[container+"/"+e.name])
+			result.Blobs.Blob = append(result.Blobs.Blob, b)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusOK)
+	xml.NewEncoder(w).Encode(result)
+}
@@ -41,6 +41,13 @@ type VFSContext struct {
 	s3Context    *S3Context
 	k8sContext   *KubernetesContext
 	memfsContext *MemFSContext
+	vaultContext *VaultContext
+	// azureBlobContext holds the credentials for Azure Blob Storage
+	azureBlobContext *AzureBlobContext
+	// etcdContext holds the etcd clients
+	etcdContext *EtcdContext
+	// encryptionContext holds the client-side encryption configuration
+	encryptionContext *EncryptionContext
 	// mutex guards gcsClient
 	mutex sync.Mutex
 	// The google cloud storage client, if initialized
@@ -52,8 +59,14 @@ type VFSContext struct {
 }
 
 var Context = VFSContext{
-	s3Context:  NewS3Context(),
-	k8sContext: NewKubernetesContext(),
+	s3Context:    NewS3Context(),
+	k8sContext:   NewKubernetesContext(),
+	vaultContext: NewVaultContext(),
+
+	azureBlobContext: NewAzureBlobContext(),
+	etcdContext:      NewEtcdContext(),
+
+	encryptionContext: NewEncryptionContext(),
 }
 
 // ReadLocation reads a file from a vfs URL
@@ -101,7 +114,23 @@ func (c *VFSContext) ReadFile(location string) ([]byte, error) {
 	return p.ReadFile()
 }
 
+// BuildVfsPath builds a Path for the URL.  Paths under a prefix configured for client-side encryption
+// are returned as EncryptedPaths.
 func (c *VFSContext) BuildVfsPath(p string) (Path, error) {
+	vfsPath, err := c.buildVfsPath(p)
+	if err != nil {
+		return nil, err
+	}
+	return c.encryptionContext.wrap(vfsPath)
+}
+
+// WithEncryption returns p as an EncryptedPath if it is under a prefix configured for client-side encryption.
+// It is for paths that were not built by BuildVfsPath, such as those joined to a ConfigBase.
+func (c *VFSContext) WithEncryption(p Path) (Path, error) {
+	return c.encryptionContext.wrap(p)
+}
+
+func (c *VFSContext) buildVfsPath(p string) (Path, error) {
 	if !strings.Contains(p, "://") {
 		return NewFSPath(p), nil
 	}
@@ -139,6 +168,18 @@ func (c *VFSContext) BuildVfsPath(p string) (Path, error) {
 		return c.buildOSSPath(p)
 	}
 
+	if strings.HasPrefix(p, "vault://") {
+		return c.buildVaultPath(p)
+	}
+
+	if strings.HasPrefix(p, "azureblob://") {
+		return c.buildAzureBlobPath(p)
+	}
+
+	if strings.HasPrefix(p, "etcd://") {
+		return c.buildEtcdPath(p)
+	}
+
 	return nil, fmt.Errorf("unknown / unhandled path type: %q", p)
 }
 
@@ -406,3 +447,73 @@ func (c *VFSContext) buildOSSPath(p string) (*OSSPath, error) {
 
 	return NewOSSPath(c.ossClient, bucket, u.Path)
 }
+
+func (c *VFSContext) buildVaultPath(p string) (*VaultPath, error) {
+	u, err := url.Parse(p)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vault path: %q", p)
+	}
+
+	if u.Scheme != "vault" {
+		return nil, fmt.Errorf("invalid vault path: %q", p)
+	}
+
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid vault path, no host: %q", p)
+	}
+
+	tokens := strings.SplitN(strings.Trim(u.Path, "/"), "/", 2)
+	if tokens[0] == "" {
+		return nil, fmt.Errorf("invalid vault path, no secrets engine mount: %q", p)
+	}
+	mount := tokens[0]
+	key := ""
+	if len(tokens) == 2 {
+		key = tokens[1]
+	}
+
+	// We connect over https, unless VAULT_ADDR points to the same server over http (e.g. a dev server)
+	scheme := "https"
+	if addr := os.Getenv("VAULT_ADDR"); addr != "" {
+		if vaultAddr, err := url.Parse(addr); err == nil && vaultAddr.Host == u.Host {
+			scheme = vaultAddr.Scheme
+		}
+	}
+
+	return newVaultPath(c.vaultContext, scheme, u.Host, mount, key), nil
+}
+
+func (c *VFSContext) buildAzureBlobPath(p string) (*AzureBlobPath, error) {
+	u, err := url.Parse(p)
+	if err != nil {
+		return nil, fmt.Errorf("invalid azure blob path: %q", p)
+	}
+
+	if u.Scheme != "azureblob" {
+		return nil, fmt.Errorf("invalid azure blob path: %q", p)
+	}
+
+	container := strings.TrimSuffix(u.Host, "/")
+	if container == "" {
+		return nil, fmt.Errorf("invalid azure blob path, no container: %q", p)
+	}
+
+	return newAzureBlobPath(c.azureBlobContext, container, u.Path), nil
+}
+
+func (c *VFSContext) buildEtcdPath(p string) (*EtcdPath, error) {
+	u, err := url.Parse(p)
+	if err != nil {
+		return nil, fmt.Errorf("invalid etcd path: %q", p)
+	}
+
+	if u.Scheme != "etcd" {
+		return nil, fmt.Errorf("invalid etcd path: %q", p)
+	}
+
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid etcd path, no host: %q", p)
+	}
+
+	return newEtcdPath(c.etcdContext, u.Host, u.Path), nil
+}
@@ -0,0 +1,329 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vfs
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/golang/glog"
+)
+
+const (
+	// EnvEncryptionPrefixes is the comma-separated list of vfs path prefixes whose files are encrypted
+	EnvEncryptionPrefixes = "KOPS_STATE_ENCRYPTION_PREFIXES"
+	// EnvEncryptionKey is the base64 encoded AES-256 key
+	EnvEncryptionKey = "KOPS_STATE_ENCRYPTION_KEY"
+	// EnvEncryptionKeyFile is a local file holding the base64 encoded AES-256 key, used if EnvEncryptionKey is not set
+	EnvEncryptionKeyFile = "KOPS_STATE_ENCRYPTION_KEY_FILE"
+	// EnvEncryptionAllowUnencrypted allows unencrypted files under the prefixes to be read, when set to true.
+	// It is for migrating an existing state store to encryption, and should be unset once every file has been rewritten.
+	EnvEncryptionAllowUnencrypted = "KOPS_STATE_ENCRYPTION_ALLOW_UNENCRYPTED"
+)
+
+// encryptedHeader marks a file that was encrypted by EncryptedPath; it is followed by the nonce and the ciphertext
+var encryptedHeader = []byte("kops-aes-gcm-v1\n")
+
+// EncryptedPath wraps a Path, encrypting files with AES-GCM when they are written and decrypting them when they are read.
+// The path of each file relative to the encrypted prefix is authenticated along with its contents, so that an encrypted
+// file cannot be swapped for another one; a whole prefix can still be copied elsewhere, as by migrate-state.
+// Unencrypted files are refused, unless allowUnencrypted is set while migrating to encryption; they are then read
+// as they are, and are encrypted when they are next written.
+type EncryptedPath struct {
+	inner Path
+	aead  cipher.AEAD
+	// prefix is the path of the root of the encrypted files, to which the authenticated path of each file is relative
+	prefix string
+	// allowUnencrypted allows files without the encryptedHeader to be read
+	allowUnencrypted bool
+}
+
+var _ Path = &EncryptedPath{}
+var _ HasClusterReadable = &EncryptedPath{}
+
+// NewEncryptedPath wraps p, encrypting the files at and under it with the AES key, which must be 16, 24 or 32 bytes long
+func NewEncryptedPath(p Path, key []byte) (*EncryptedPath, error) {
+	return newEncryptedPath(p, p.Path(), key)
+}
+
+// newEncryptedPath wraps p, which is at or under prefix
+func newEncryptedPath(p Path, prefix string, key []byte) (*EncryptedPath, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error building AES cipher: %v", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("error building AES-GCM cipher: %v", err)
+	}
+	return &EncryptedPath{inner: p, aead: aead, prefix: prefix}, nil
+}
+
+// Inner returns the underlying path, to which the encrypted data is written
+func (p *EncryptedPath) Inner() Path {
+	return p.inner
+}
+
+// UnwrapEncryption returns the underlying path if p is an EncryptedPath, otherwise p itself.
+// It is for callers that need to know the type of the underlying store, e.g. to build IAM policies or ACLs.
+func UnwrapEncryption(p Path) Path {
+	if e, ok := p.(*EncryptedPath); ok {
+		return e.inner
+	}
+	return p
+}
+
+func (p *EncryptedPath) wrap(inner Path) *EncryptedPath {
+	return &EncryptedPath{inner: inner, aead: p.aead, prefix: p.prefix, allowUnencrypted: p.allowUnencrypted}
+}
+
+// additionalData is the data authenticated along with the contents of the file: its path relative to the prefix
+func (p *EncryptedPath) additionalData() []byte {
+	return []byte(strings.TrimPrefix(strings.TrimPrefix(p.inner.Path(), p.prefix), "/"))
+}
+
+func (p *EncryptedPath) wrapAll(inner []Path) []Path {
+	var paths []Path
+	for _, child := range inner {
+		paths = append(paths, p.wrap(child))
+	}
+	return paths
+}
+
+func (p *EncryptedPath) encrypt(data io.ReadSeeker) (io.ReadSeeker, error) {
+	if _, err := data.Seek(0, 0); err != nil {
+		return nil, fmt.Errorf("error seeking to start of data stream for write to %s: %v", p, err)
+	}
+	plaintext, err := ioutil.ReadAll(data)
+	if err != nil {
+		return nil, fmt.Errorf("error reading from data stream: %v", err)
+	}
+
+	nonce := make([]byte, p.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("error generating nonce: %v", err)
+	}
+
+	var b bytes.Buffer
+	b.Write(encryptedHeader)
+	b.Write(nonce)
+	b.Write(p.aead.Seal(nil, nonce, plaintext, p.additionalData()))
+	return bytes.NewReader(b.Bytes()), nil
+}
+
+func (p *EncryptedPath) decrypt(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, encryptedHeader) {
+		if !p.allowUnencrypted {
+			return nil, fmt.Errorf("file %s is not encrypted; set %s=true to read unencrypted files while migrating to encryption", p, EnvEncryptionAllowUnencrypted)
+		}
+		glog.V(2).Infof("File %s is not encrypted", p)
+		return data, nil
+	}
+
+	data = data[len(encryptedHeader):]
+	nonceSize := p.aead.NonceSize()
+	if len(data) < nonceSize {
+		return nil, fmt.Errorf("encrypted file %s was truncated", p)
+	}
+	plaintext, err := p.aead.Open(nil, data[:nonceSize], data[nonceSize:], p.additionalData())
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting %s (is the encryption key correct, and is the file where it was written?): %v", p, err)
+	}
+	return plaintext, nil
+}
+
+// WriteTo implements io.WriterTo
+func (p *EncryptedPath) WriteTo(out io.Writer) (int64, error) {
+	data, err := p.ReadFile()
+	if err != nil {
+		return 0, err
+	}
+	n, err := out.Write(data)
+	return int64(n), err
+}
+
+func (p *EncryptedPath) Join(relativePath ...string) Path {
+	return p.wrap(p.inner.Join(relativePath...))
+}
+
+// ReadFile implements Path::ReadFile
+func (p *EncryptedPath) ReadFile() ([]byte, error) {
+	data, err := p.inner.ReadFile()
+	if err != nil {
+		return nil, err
+	}
+	return p.decrypt(data)
+}
+
+// WriteFile implements Path::WriteFile
+func (p *EncryptedPath) WriteFile(data io.ReadSeeker, acl ACL) error {
+	encrypted, err := p.encrypt(data)
+	if err != nil {
+		return err
+	}
+	return p.inner.WriteFile(encrypted, acl)
+}
+
+// CreateFile implements Path::CreateFile
+func (p *EncryptedPath) CreateFile(data io.ReadSeeker, acl ACL) error {
+	encrypted, err := p.encrypt(data)
+	if err != nil {
+		return err
+	}
+	return p.inner.CreateFile(encrypted, acl)
+}
+
+func (p *EncryptedPath) Remove() error {
+	return p.inner.Remove()
+}
+
+func (p *EncryptedPath) Base() string {
+	return p.inner.Base()
+}
+
+func (p *EncryptedPath) Path() string {
+	return p.inner.Path()
+}
+
+func (p *EncryptedPath) String() string {
+	return p.Path()
+}
+
+func (p *EncryptedPath) ReadDir() ([]Path, error) {
+	children, err := p.inner.ReadDir()
+	if err != nil {
+		return nil, err
+	}
+	return p.wrapAll(children), nil
+}
+
+func (p *EncryptedPath) ReadTree() ([]Path, error) {
+	children, err := p.inner.ReadTree()
+	if err != nil {
+		return nil, err
+	}
+	return p.wrapAll(children), nil
+}
+
+// IsClusterReadable implements HasClusterReadable; instances can read the files if they can read the
+// underlying store, as long as they are also given the key
+func (p *EncryptedPath) IsClusterReadable() bool {
+	return IsClusterReadable(p.inner)
+}
+
+// EncryptionContext holds the client-side encryption configuration, which is read from the environment
+type EncryptionContext struct {
+	mutex  sync.Mutex
+	loaded bool
+
+	// prefixes are the paths under which files are encrypted
+	prefixes []string
+	// key is the AES key
+	key []byte
+	// allowUnencrypted allows unencrypted files under the prefixes to be read
+	allowUnencrypted bool
+}
+
+// NewEncryptionContext builds an EncryptionContext; the configuration is read when it is first needed
+func NewEncryptionContext() *EncryptionContext {
+	return &EncryptionContext{}
+}
+
+func (c *EncryptionContext) load() error {
+	if c.loaded {
+		return nil
+	}
+
+	var prefixes []string
+	for _, prefix := range strings.Split(os.Getenv(EnvEncryptionPrefixes), ",") {
+		prefix = strings.TrimSuffix(strings.TrimSpace(prefix), "/")
+		if prefix != "" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+
+	var key []byte
+	if len(prefixes) != 0 {
+		encoded := os.Getenv(EnvEncryptionKey)
+		if encoded == "" {
+			keyFile := os.Getenv(EnvEncryptionKeyFile)
+			if keyFile == "" {
+				return fmt.Errorf("%s is set, but neither %s nor %s is set", EnvEncryptionPrefixes, EnvEncryptionKey, EnvEncryptionKeyFile)
+			}
+			b, err := ioutil.ReadFile(keyFile)
+			if err != nil {
+				return fmt.Errorf("error reading encryption key from %s: %v", keyFile, err)
+			}
+			encoded = string(b)
+		}
+
+		var err error
+		key, err = base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return fmt.Errorf("encryption key was not valid base64: %v", err)
+		}
+		if len(key) != 32 {
+			return fmt.Errorf("encryption key must be 32 bytes (AES-256), was %d bytes", len(key))
+		}
+	}
+
+	c.prefixes = prefixes
+	c.key = key
+	c.allowUnencrypted = os.Getenv(EnvEncryptionAllowUnencrypted) == "true"
+	c.loaded = true
+	return nil
+}
+
+// wrap returns p as an EncryptedPath if it is at or under one of the configured prefixes, otherwise p itself.
+// A nil EncryptionContext never encrypts.
+func (c *EncryptionContext) wrap(p Path) (Path, error) {
+	if c == nil {
+		return p, nil
+	}
+	if _, ok := p.(*EncryptedPath); ok {
+		return p, nil
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+
+	s := p.Path()
+	for _, prefix := range c.prefixes {
+		if s == prefix || strings.HasPrefix(s, prefix+"/") {
+			encrypted, err := newEncryptedPath(p, prefix, c.key)
+			if err != nil {
+				return nil, err
+			}
+			encrypted.allowUnencrypted = c.allowUnencrypted
+			return encrypted, nil
+		}
+	}
+	return p, nil
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vfs
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"testing"
+)
+
+func Test_EncryptedPath_ReadWrite(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+	base := NewMemFSPath(NewMemFSContext(), "cluster.example.com")
+	encrypted, err := NewEncryptedPath(base.Join("secrets"), key)
+	if err != nil {
+		t.Fatalf("error building encrypted path: %v", err)
+	}
+
+	p := encrypted.Join("admin")
+	if err := p.CreateFile(bytes.NewReader([]byte("secret-token")), nil); err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	if err := p.CreateFile(bytes.NewReader([]byte("other-token")), nil); !os.IsExist(err) {
+		t.Fatalf("expected exists error creating existing file, got %v", err)
+	}
+
+	raw, err := base.Join("secrets", "admin").ReadFile()
+	if err != nil {
+		t.Fatalf("error reading underlying file: %v", err)
+	}
+	if bytes.Contains(raw, []byte("secret-token")) {
+		t.Fatalf("underlying file was not encrypted: %q", raw)
+	}
+
+	data, err := p.ReadFile()
+	if err != nil {
+		t.Fatalf("error reading file: %v", err)
+	}
+	if string(data) != "secret-token" {
+		t.Fatalf("unexpected file contents: %q", data)
+	}
+
+	// An encrypted file copied over another is refused, as its path is authenticated
+	swapped, err := NewEncryptedPath(base.Join("swapped"), key)
+	if err != nil {
+		t.Fatalf("error building encrypted path: %v", err)
+	}
+	if err := swapped.Join("admin").WriteFile(bytes.NewReader([]byte("secret-token")), nil); err != nil {
+		t.Fatalf("error writing file: %v", err)
+	}
+	swappedRaw, err := base.Join("swapped", "admin").ReadFile()
+	if err != nil {
+		t.Fatalf("error reading underlying file: %v", err)
+	}
+	if err := base.Join("swapped", "kube").WriteFile(bytes.NewReader(swappedRaw), nil); err != nil {
+		t.Fatalf("error copying underlying file: %v", err)
+	}
+	if _, err := swapped.Join("kube").ReadFile(); err == nil {
+		t.Fatalf("expected error reading encrypted file copied from another path")
+	}
+
+	// The path is authenticated relative to the prefix, so the prefix can be copied elsewhere as a whole
+	moved, err := NewEncryptedPath(base.Join("moved"), key)
+	if err != nil {
+		t.Fatalf("error building encrypted path: %v", err)
+	}
+	if err := base.Join("moved", "admin").WriteFile(bytes.NewReader(raw), nil); err != nil {
+		t.Fatalf("error copying underlying file: %v", err)
+	}
+	if data, err := moved.Join("admin").ReadFile(); err != nil || string(data) != "secret-token" {
+		t.Fatalf("unexpected contents of file in copied prefix %q: %v", data, err)
+	}
+
+	// Files written before encryption was configured are only read while migrating to encryption
+	if err := base.Join("secrets", "kube").WriteFile(bytes.NewReader([]byte("plain-token")), nil); err != nil {
+		t.Fatalf("error writing unencrypted file: %v", err)
+	}
+	if _, err := encrypted.Join("kube").ReadFile(); err == nil {
+		t.Fatalf("expected error reading unencrypted file")
+	}
+	encrypted.allowUnencrypted = true
+	tree, err := encrypted.ReadTree()
+	if err != nil {
+		t.Fatalf("error reading tree: %v", err)
+	}
+	if len(tree) != 2 {
+		t.Fatalf("unexpected tree: %v", tree)
+	}
+	for _, child := range tree {
+		if _, ok := child.(*EncryptedPath); !ok {
+			t.Fatalf("expected ReadTree to return encrypted paths, got %T", child)
+		}
+		if child.Base() != "kube" {
+			continue
+		}
+		data, err := child.ReadFile()
+		if err != nil {
+			t.Fatalf("error reading unencrypted file: %v", err)
+		}
+		if string(data) != "plain-token" {
+			t.Fatalf("unexpected contents of unencrypted file: %q", data)
+		}
+	}
+
+	wrongKey, err := NewEncryptedPath(base.Join("secrets"), bytes.Repeat([]byte{2}, 32))
+	if err != nil {
+		t.Fatalf("error building encrypted path: %v", err)
+	}
+	if _, err := wrongKey.Join("admin").ReadFile(); err == nil {
+		t.Fatalf("expected error decrypting with the wrong key")
+	}
+
+	if UnwrapEncryption(p) != p.(*EncryptedPath).Inner() {
+		t.Fatalf("UnwrapEncryption did not return the inner path")
+	}
+}
+
+func Test_EncryptionContext_Wrap(t *testing.T) {
+	defer os.Unsetenv(EnvEncryptionPrefixes)
+	defer os.Unsetenv(EnvEncryptionKey)
+
+	os.Setenv(EnvEncryptionPrefixes, "memfs://cluster.example.com/secrets, memfs://cluster.example.com/pki/private/")
+	os.Setenv(EnvEncryptionKey, base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32)))
+
+	c := NewEncryptionContext()
+	base := NewMemFSPath(NewMemFSContext(), "cluster.example.com")
+	grid := []struct {
+		Path      Path
+		Encrypted bool
+	}{
+		{Path: base.Join("secrets"), Encrypted: true},
+		{Path: base.Join("secrets", "admin"), Encrypted: true},
+		{Path: base.Join("pki", "private", "ca", "1.key"), Encrypted: true},
+		{Path: base.Join("pki", "issued", "ca", "1.crt"), Encrypted: false},
+		{Path: base.Join("secretsbackup"), Encrypted: false},
+		{Path: base, Encrypted: false},
+	}
+	for _, g := range grid {
+		p, err := c.wrap(g.Path)
+		if err != nil {
+			t.Fatalf("error wrapping %s: %v", g.Path, err)
+		}
+		if _, ok := p.(*EncryptedPath); ok != g.Encrypted {
+			t.Errorf("unexpected encryption of %s: expected %v", g.Path, g.Encrypted)
+		}
+	}
+
+	os.Setenv(EnvEncryptionKey, base64.StdEncoding.EncodeToString([]byte("too-short")))
+	if _, err := NewEncryptionContext().wrap(base); err == nil {
+		t.Errorf("expected error with a short key")
+	}
+}
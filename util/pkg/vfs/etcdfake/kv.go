@@ -0,0 +1,232 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package etcdfake is an in-memory fake of the etcd v3 KV API, for tests.
+// It implements only the requests made by vfs.EtcdPath.
+package etcdfake
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/coreos/etcd/clientv3"
+	pb "github.com/coreos/etcd/etcdserver/etcdserverpb"
+	"github.com/coreos/etcd/mvcc/mvccpb"
+	"golang.org/x/net/context"
+)
+
+// KV is a fake clientv3.KV, holding the keys in memory
+type KV struct {
+	mutex    sync.Mutex
+	revision int64
+	keys     map[string]*mvccpb.KeyValue
+}
+
+var _ clientv3.KV = &KV{}
+
+// NewKV builds an empty KV
+func NewKV() *KV {
+	return &KV{
+		keys: make(map[string]*mvccpb.KeyValue),
+	}
+}
+
+// Keys returns the sorted keys
+func (k *KV) Keys() []string {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	var keys []string
+	for key := range k.keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (k *KV) Put(ctx context.Context, key, val string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	k.put(key, val)
+	return &clientv3.PutResponse{}, nil
+}
+
+func (k *KV) put(key, val string) {
+	k.revision++
+	kv := k.keys[key]
+	if kv == nil {
+		kv = &mvccpb.KeyValue{Key: []byte(key), CreateRevision: k.revision}
+		k.keys[key] = kv
+	}
+	kv.Value = []byte(val)
+	kv.ModRevision = k.revision
+	kv.Version++
+}
+
+// matches returns the sorted keys selected by the op, which is a single key or a range
+func (k *KV) matches(op clientv3.Op) []*mvccpb.KeyValue {
+	key := op.KeyBytes()
+	end := op.RangeBytes()
+
+	var matches []*mvccpb.KeyValue
+	for _, kv := range k.keys {
+		if len(end) == 0 {
+			if !bytes.Equal(kv.Key, key) {
+				continue
+			}
+		} else if bytes.Compare(kv.Key, key) < 0 || bytes.Compare(kv.Key, end) >= 0 {
+			continue
+		}
+		matches = append(matches, kv)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return bytes.Compare(matches[i].Key, matches[j].Key) < 0
+	})
+	return matches
+}
+
+func (k *KV) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	op := clientv3.OpGet(key, opts...)
+	response := &clientv3.GetResponse{}
+	for _, kv := range k.matches(op) {
+		c := *kv
+		if op.IsKeysOnly() {
+			c.Value = nil
+		}
+		response.Kvs = append(response.Kvs, &c)
+	}
+	response.Count = int64(len(response.Kvs))
+	return response, nil
+}
+
+func (k *KV) Delete(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	matches := k.matches(clientv3.OpDelete(key, opts...))
+	for _, kv := range matches {
+		delete(k.keys, string(kv.Key))
+	}
+	if len(matches) != 0 {
+		k.revision++
+	}
+	return &clientv3.DeleteResponse{Deleted: int64(len(matches))}, nil
+}
+
+func (k *KV) Compact(ctx context.Context, rev int64, opts ...clientv3.CompactOption) (*clientv3.CompactResponse, error) {
+	return nil, fmt.Errorf("Compact not implemented by etcdfake")
+}
+
+func (k *KV) Do(ctx context.Context, op clientv3.Op) (clientv3.OpResponse, error) {
+	return clientv3.OpResponse{}, fmt.Errorf("Do not implemented by etcdfake")
+}
+
+func (k *KV) Txn(ctx context.Context) clientv3.Txn {
+	return &txn{kv: k}
+}
+
+// txn is a fake clientv3.Txn; only comparisons of the create and mod revisions are supported
+type txn struct {
+	kv      *KV
+	cmps    []clientv3.Cmp
+	thenOps []clientv3.Op
+	elseOps []clientv3.Op
+}
+
+func (t *txn) If(cs ...clientv3.Cmp) clientv3.Txn {
+	t.cmps = append(t.cmps, cs...)
+	return t
+}
+
+func (t *txn) Then(ops ...clientv3.Op) clientv3.Txn {
+	t.thenOps = append(t.thenOps, ops...)
+	return t
+}
+
+func (t *txn) Else(ops ...clientv3.Op) clientv3.Txn {
+	t.elseOps = append(t.elseOps, ops...)
+	return t
+}
+
+func (t *txn) Commit() (*clientv3.TxnResponse, error) {
+	t.kv.mutex.Lock()
+	defer t.kv.mutex.Unlock()
+
+	succeeded := true
+	for _, cmp := range t.cmps {
+		ok, err := t.kv.compare(cmp)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			succeeded = false
+		}
+	}
+
+	ops := t.thenOps
+	if !succeeded {
+		ops = t.elseOps
+	}
+	for _, op := range ops {
+		if !op.IsPut() {
+			return nil, fmt.Errorf("only put operations are implemented in etcdfake transactions")
+		}
+		t.kv.put(string(op.KeyBytes()), string(op.ValueBytes()))
+	}
+
+	return &clientv3.TxnResponse{Succeeded: succeeded}, nil
+}
+
+func (k *KV) compare(cmp clientv3.Cmp) (bool, error) {
+	c := pb.Compare(cmp)
+	kv := k.keys[string(c.Key)]
+
+	// A key that does not exist has revisions of 0
+	var actual, expected int64
+	switch target := c.TargetUnion.(type) {
+	case *pb.Compare_CreateRevision:
+		expected = target.CreateRevision
+		if kv != nil {
+			actual = kv.CreateRevision
+		}
+	case *pb.Compare_ModRevision:
+		expected = target.ModRevision
+		if kv != nil {
+			actual = kv.ModRevision
+		}
+	default:
+		return false, fmt.Errorf("comparison target %v not implemented by etcdfake", c.Target)
+	}
+
+	switch c.Result {
+	case pb.Compare_EQUAL:
+		return actual == expected, nil
+	case pb.Compare_NOT_EQUAL:
+		return actual != expected, nil
+	case pb.Compare_GREATER:
+		return actual > expected, nil
+	case pb.Compare_LESS:
+		return actual < expected, nil
+	default:
+		return false, fmt.Errorf("comparison result %v not implemented by etcdfake", c.Result)
+	}
+}
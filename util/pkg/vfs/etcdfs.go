@@ -0,0 +1,481 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vfs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/pkg/transport"
+	"github.com/golang/glog"
+	"golang.org/x/net/context"
+	"k8s.io/kops/util/pkg/hashing"
+)
+
+// EtcdPath is a vfs path for an etcd v3 cluster, written as etcd://<host[:port]>/<prefix>/<key>.
+// Each file is stored under the etcd key /<prefix>/<key>, along with its SHA256 digest.
+type EtcdPath struct {
+	context  *EtcdContext
+	endpoint string
+	key      string
+
+	// digest is the SHA256 of the file, if known from a listing
+	digest []byte
+}
+
+var _ Path = &EtcdPath{}
+var _ HasHash = &EtcdPath{}
+var _ HasClusterReadable = &EtcdPath{}
+var _ HasConditionalWrite = &EtcdPath{}
+
+// EtcdContext holds the etcd clients, which are shared by all EtcdPaths for the same endpoint
+type EtcdContext struct {
+	mutex sync.Mutex
+	// kvs holds the etcd client for each endpoint
+	kvs map[string]clientv3.KV
+}
+
+// NewEtcdContext builds an EtcdContext; clients are created when they are first needed
+func NewEtcdContext() *EtcdContext {
+	return &EtcdContext{
+		kvs: make(map[string]clientv3.KV),
+	}
+}
+
+// etcdRequestTimeout is the timeout for each request to etcd
+const etcdRequestTimeout = 30 * time.Second
+
+// etcdFile is the value we store in etcd for each file
+type etcdFile struct {
+	// Data is the contents of the file
+	Data []byte `json:"data"`
+	// SHA256 is the hex encoded SHA256 digest of Data
+	SHA256 string `json:"sha256"`
+}
+
+func newEtcdPath(context *EtcdContext, endpoint string, key string) *EtcdPath {
+	return &EtcdPath{
+		context:  context,
+		endpoint: endpoint,
+		key:      strings.Trim(key, "/"),
+	}
+}
+
+// getKV returns the client for the endpoint.
+// The client uses TLS if ETCDCTL_CACERT is set, with the client certificate in ETCDCTL_CERT and ETCDCTL_KEY,
+// and authenticates as ETCDCTL_USER (user:password) if it is set; these are the variables read by etcdctl.
+func (c *EtcdContext) getKV(endpoint string) (clientv3.KV, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if kv := c.kvs[endpoint]; kv != nil {
+		return kv, nil
+	}
+
+	// etcd listens for clients on port 2379 by default
+	address := endpoint
+	if _, _, err := net.SplitHostPort(endpoint); err != nil {
+		address = net.JoinHostPort(endpoint, "2379")
+	}
+
+	config := clientv3.Config{
+		Endpoints:   []string{"http://" + address},
+		DialTimeout: 10 * time.Second,
+	}
+
+	if caFile := os.Getenv("ETCDCTL_CACERT"); caFile != "" {
+		tlsInfo := transport.TLSInfo{
+			TrustedCAFile: caFile,
+			CertFile:      os.Getenv("ETCDCTL_CERT"),
+			KeyFile:       os.Getenv("ETCDCTL_KEY"),
+		}
+		tlsConfig, err := tlsInfo.ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("error building etcd TLS configuration: %v", err)
+		}
+		config.TLS = tlsConfig
+		config.Endpoints = []string{"https://" + address}
+	}
+
+	if user := os.Getenv("ETCDCTL_USER"); user != "" {
+		tokens := strings.SplitN(user, ":", 2)
+		if len(tokens) != 2 {
+			return nil, fmt.Errorf("ETCDCTL_USER must be user:password")
+		}
+		config.Username = tokens[0]
+		config.Password = tokens[1]
+	}
+
+	client, err := clientv3.New(config)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to etcd at %s: %v", endpoint, err)
+	}
+	c.kvs[endpoint] = client
+	return client, nil
+}
+
+// etcdKey is the etcd key for the path
+func (p *EtcdPath) etcdKey() string {
+	return "/" + p.key
+}
+
+func (p *EtcdPath) kv() (clientv3.KV, context.Context, context.CancelFunc, error) {
+	kv, err := p.context.getKV(p.endpoint)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), etcdRequestTimeout)
+	return kv, ctx, cancel, nil
+}
+
+// WriteTo implements io.WriterTo
+func (p *EtcdPath) WriteTo(out io.Writer) (int64, error) {
+	data, err := p.ReadFile()
+	if err != nil {
+		return 0, err
+	}
+	n, err := out.Write(data)
+	return int64(n), err
+}
+
+func (p *EtcdPath) Join(relativePath ...string) Path {
+	args := []string{p.key}
+	args = append(args, relativePath...)
+	joined := path.Join(args...)
+	return newEtcdPath(p.context, p.endpoint, joined)
+}
+
+func parseEtcdFile(key string, value []byte) (*etcdFile, error) {
+	f := &etcdFile{}
+	if err := json.Unmarshal(value, f); err != nil {
+		return nil, fmt.Errorf("error parsing etcd key %s: %v", key, err)
+	}
+	return f, nil
+}
+
+// ReadFile implements Path::ReadFile
+func (p *EtcdPath) ReadFile() ([]byte, error) {
+	glog.V(4).Infof("Reading file %q", p)
+
+	f, err := p.read()
+	if err != nil {
+		return nil, err
+	}
+	return f.Data, nil
+}
+
+func (p *EtcdPath) read() (*etcdFile, error) {
+	f, _, err := p.readWithRevision()
+	return f, err
+}
+
+// readWithRevision returns the file, and the etcd revision at which its key was last modified
+func (p *EtcdPath) readWithRevision() (*etcdFile, int64, error) {
+	kv, ctx, cancel, err := p.kv()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cancel()
+
+	response, err := kv.Get(ctx, p.etcdKey())
+	if err != nil {
+		return nil, 0, fmt.Errorf("error reading %s: %v", p, err)
+	}
+	if len(response.Kvs) == 0 {
+		return nil, 0, os.ErrNotExist
+	}
+	f, err := parseEtcdFile(p.etcdKey(), response.Kvs[0].Value)
+	if err != nil {
+		return nil, 0, err
+	}
+	return f, response.Kvs[0].ModRevision, nil
+}
+
+// ReadFileVersion implements HasConditionalWrite::ReadFileVersion; the version is the revision at which the key was last modified
+func (p *EtcdPath) ReadFileVersion() ([]byte, string, error) {
+	glog.V(4).Infof("Reading file %q", p)
+
+	f, revision, err := p.readWithRevision()
+	if err != nil {
+		return nil, "", err
+	}
+	return f.Data, strconv.FormatInt(revision, 10), nil
+}
+
+// WriteFileIfVersion implements HasConditionalWrite::WriteFileIfVersion, using a transaction that compares the revision of the key
+func (p *EtcdPath) WriteFileIfVersion(data io.ReadSeeker, acl ACL, version string) error {
+	glog.V(4).Infof("Writing file %q if at revision %s", p, version)
+
+	revision, err := strconv.ParseInt(version, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid version %q for %s", version, p)
+	}
+
+	value, err := p.encode(data)
+	if err != nil {
+		return err
+	}
+
+	kv, ctx, cancel, err := p.kv()
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	response, err := kv.Txn(ctx).
+		If(clientv3.Compare(clientv3.ModRevision(p.etcdKey()), "=", revision)).
+		Then(clientv3.OpPut(p.etcdKey(), value)).
+		Commit()
+	if err != nil {
+		return fmt.Errorf("error writing %s: %v", p, err)
+	}
+	if !response.Succeeded {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+func (p *EtcdPath) encode(data io.ReadSeeker) (string, error) {
+	if _, err := data.Seek(0, 0); err != nil {
+		return "", fmt.Errorf("error seeking to start of data stream for write to %s: %v", p, err)
+	}
+	b, err := ioutil.ReadAll(data)
+	if err != nil {
+		return "", fmt.Errorf("error reading from data stream: %v", err)
+	}
+
+	digest := sha256.Sum256(b)
+	value, err := json.Marshal(&etcdFile{Data: b, SHA256: hex.EncodeToString(digest[:])})
+	if err != nil {
+		return "", fmt.Errorf("error encoding %s: %v", p, err)
+	}
+	return string(value), nil
+}
+
+// WriteFile implements Path::WriteFile; the ACL is ignored, as access is controlled by etcd roles
+func (p *EtcdPath) WriteFile(data io.ReadSeeker, acl ACL) error {
+	glog.V(4).Infof("Writing file %q", p)
+
+	value, err := p.encode(data)
+	if err != nil {
+		return err
+	}
+
+	kv, ctx, cancel, err := p.kv()
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	if _, err := kv.Put(ctx, p.etcdKey(), value); err != nil {
+		return fmt.Errorf("error writing %s: %v", p, err)
+	}
+	return nil
+}
+
+// CreateFile implements Path::CreateFile, using a transaction so that an existing key is never replaced
+func (p *EtcdPath) CreateFile(data io.ReadSeeker, acl ACL) error {
+	glog.V(4).Infof("Creating file %q", p)
+
+	value, err := p.encode(data)
+	if err != nil {
+		return err
+	}
+
+	kv, ctx, cancel, err := p.kv()
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	// A key that does not exist has a create revision of 0
+	response, err := kv.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(p.etcdKey()), "=", 0)).
+		Then(clientv3.OpPut(p.etcdKey(), value)).
+		Commit()
+	if err != nil {
+		return fmt.Errorf("error creating %s: %v", p, err)
+	}
+	if !response.Succeeded {
+		return os.ErrExist
+	}
+	return nil
+}
+
+// Remove implements Path::Remove
+func (p *EtcdPath) Remove() error {
+	glog.V(8).Infof("removing file %s", p)
+
+	kv, ctx, cancel, err := p.kv()
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	response, err := kv.Delete(ctx, p.etcdKey())
+	if err != nil {
+		return fmt.Errorf("error deleting %s: %v", p, err)
+	}
+	if response.Deleted == 0 {
+		return os.ErrNotExist
+	}
+	return nil
+}
+
+func (p *EtcdPath) Base() string {
+	return path.Base(p.key)
+}
+
+// Endpoint returns the etcd host (and port) of the path
+func (p *EtcdPath) Endpoint() string {
+	return p.endpoint
+}
+
+// Key returns the key of the path, without the leading /
+func (p *EtcdPath) Key() string {
+	return p.key
+}
+
+func (p *EtcdPath) String() string {
+	return p.Path()
+}
+
+func (p *EtcdPath) Path() string {
+	return "etcd://" + p.endpoint + "/" + p.key
+}
+
+// IsClusterReadable implements HasClusterReadable.
+// Instances can read the state store, as long as they can reach etcd and are given credentials for it.
+func (p *EtcdPath) IsClusterReadable() bool {
+	return true
+}
+
+// list returns the keys and values under the path
+func (p *EtcdPath) list(keysOnly bool) ([][]byte, [][]byte, error) {
+	kv, ctx, cancel, err := p.kv()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cancel()
+
+	prefix := p.etcdKey()
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	opts := []clientv3.OpOption{clientv3.WithPrefix(), clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend)}
+	if keysOnly {
+		opts = append(opts, clientv3.WithKeysOnly())
+	}
+	response, err := kv.Get(ctx, prefix, opts...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error listing %s: %v", p, err)
+	}
+
+	var keys, values [][]byte
+	for _, kv := range response.Kvs {
+		keys = append(keys, kv.Key)
+		values = append(values, kv.Value)
+	}
+	return keys, values, nil
+}
+
+// ReadDir implements Path::ReadDir
+func (p *EtcdPath) ReadDir() ([]Path, error) {
+	keys, _, err := p.list(true)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := p.key + "/"
+	if p.key == "" {
+		prefix = ""
+	}
+	seen := make(map[string]bool)
+	var paths []Path
+	for _, k := range keys {
+		child := strings.TrimPrefix(strings.TrimPrefix(string(k), "/"), prefix)
+		if i := strings.Index(child, "/"); i != -1 {
+			child = child[:i]
+		}
+		if seen[child] {
+			continue
+		}
+		seen[child] = true
+		paths = append(paths, p.Join(child))
+	}
+	glog.V(8).Infof("Listed files in %v: %v", p, paths)
+	return paths, nil
+}
+
+// ReadTree implements Path::ReadTree
+func (p *EtcdPath) ReadTree() ([]Path, error) {
+	keys, values, err := p.list(false)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []Path
+	for i, k := range keys {
+		child := newEtcdPath(p.context, p.endpoint, string(k))
+		f, err := parseEtcdFile(string(k), values[i])
+		if err != nil {
+			return nil, err
+		}
+		if digest, err := hex.DecodeString(f.SHA256); err == nil {
+			child.digest = digest
+		}
+		paths = append(paths, child)
+	}
+	return paths, nil
+}
+
+func (p *EtcdPath) PreferredHash() (*hashing.Hash, error) {
+	return p.Hash(hashing.HashAlgorithmSHA256)
+}
+
+// Hash implements HasHash, returning the SHA256 digest stored with the file
+func (p *EtcdPath) Hash(a hashing.HashAlgorithm) (*hashing.Hash, error) {
+	if a != hashing.HashAlgorithmSHA256 {
+		return nil, nil
+	}
+
+	digest := p.digest
+	if digest == nil {
+		f, err := p.read()
+		if err != nil {
+			return nil, err
+		}
+		digest, err = hex.DecodeString(f.SHA256)
+		if err != nil {
+			return nil, fmt.Errorf("stored digest of %s was not valid: %q", p, f.SHA256)
+		}
+	}
+
+	return &hashing.Hash{Algorithm: hashing.HashAlgorithmSHA256, HashValue: digest}, nil
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vfs
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test_EtcdPath_Integration runs against a real etcd, which the fake does not stand in for in every respect
+// (transactions, prefix ranges, revisions).  It is skipped unless ETCD_ENDPOINT is set, for example:
+//   ETCD_ENDPOINT=127.0.0.1:2379 go test ./util/pkg/vfs/ -run Integration
+// The ETCDCTL_* variables are honoured, as they are by kops.  Everything is written under a unique prefix, which is removed afterwards.
+func Test_EtcdPath_Integration(t *testing.T) {
+	endpoint := os.Getenv("ETCD_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("ETCD_ENDPOINT not set; skipping etcd integration test")
+	}
+
+	base, err := Context.BuildVfsPath(fmt.Sprintf("etcd://%s/kops-test-%d/cluster.example.com", endpoint, time.Now().UnixNano()))
+	if err != nil {
+		t.Fatalf("error building etcd path: %v", err)
+	}
+	defer func() {
+		files, err := base.ReadTree()
+		if err != nil {
+			t.Errorf("error listing files to clean up: %v", err)
+			return
+		}
+		for _, f := range files {
+			if err := f.Remove(); err != nil {
+				t.Errorf("error removing %s: %v", f, err)
+			}
+		}
+	}()
+
+	p := base.Join("pki", "private", "ca", "1.key")
+	if _, err := p.ReadFile(); !os.IsNotExist(err) {
+		t.Fatalf("expected not exist reading missing file, got %v", err)
+	}
+	if err := p.CreateFile(bytes.NewReader([]byte("key1")), nil); err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	if err := p.CreateFile(bytes.NewReader([]byte("key2")), nil); !os.IsExist(err) {
+		t.Fatalf("expected exists error creating existing file, got %v", err)
+	}
+	if data, err := p.ReadFile(); err != nil || string(data) != "key1" {
+		t.Fatalf("unexpected file contents %q: %v", data, err)
+	}
+
+	for _, f := range []string{"config", "instancegroup/nodes", "pki/issued/ca/1.crt"} {
+		if err := base.Join(f).WriteFile(bytes.NewReader([]byte(f)), nil); err != nil {
+			t.Fatalf("error writing file: %v", err)
+		}
+	}
+
+	children, err := base.ReadDir()
+	if err != nil {
+		t.Fatalf("error reading dir: %v", err)
+	}
+	if actual, expected := relativeEtcdPaths(base, children), []string{"config", "instancegroup", "pki"}; !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("unexpected ReadDir, expected %v, got %v", expected, actual)
+	}
+	tree, err := base.ReadTree()
+	if err != nil {
+		t.Fatalf("error reading tree: %v", err)
+	}
+	expectedTree := []string{"config", "instancegroup/nodes", "pki/issued/ca/1.crt", "pki/private/ca/1.key"}
+	if actual := relativeEtcdPaths(base, tree); !reflect.DeepEqual(actual, expectedTree) {
+		t.Fatalf("unexpected ReadTree, expected %v, got %v", expectedTree, actual)
+	}
+
+	config := base.Join("config").(HasConditionalWrite)
+	_, version, err := config.ReadFileVersion()
+	if err != nil {
+		t.Fatalf("error reading file: %v", err)
+	}
+	if err := base.Join("config").WriteFile(bytes.NewReader([]byte("other")), nil); err != nil {
+		t.Fatalf("error writing file: %v", err)
+	}
+	if err := config.WriteFileIfVersion(bytes.NewReader([]byte("mine")), nil, version); err != ErrVersionConflict {
+		t.Fatalf("expected version conflict writing changed file, got %v", err)
+	}
+
+	if err := p.Remove(); err != nil {
+		t.Fatalf("error removing file: %v", err)
+	}
+	if _, err := p.ReadFile(); !os.IsNotExist(err) {
+		t.Fatalf("expected not exist reading removed file, got %v", err)
+	}
+}
+
+// relativeEtcdPaths returns the sorted keys of the paths, relative to base
+func relativeEtcdPaths(base Path, paths []Path) []string {
+	var keys []string
+	for _, p := range paths {
+		keys = append(keys, strings.TrimPrefix(p.(*EtcdPath).Key(), base.(*EtcdPath).Key()+"/"))
+	}
+	sort.Strings(keys)
+	return keys
+}
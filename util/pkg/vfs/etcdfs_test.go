@@ -0,0 +1,212 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vfs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"k8s.io/kops/util/pkg/hashing"
+	"k8s.io/kops/util/pkg/vfs/etcdfake"
+)
+
+func Test_EtcdPath_Parse(t *testing.T) {
+	grid := []struct {
+		Input            string
+		ExpectError      bool
+		ExpectedEndpoint string
+		ExpectedKey      string
+	}{
+		{
+			Input:            "etcd://etcd.example.com:2379/kops",
+			ExpectedEndpoint: "etcd.example.com:2379",
+			ExpectedKey:      "kops",
+		},
+		{
+			Input:            "etcd://127.0.0.1/kops/cluster.example.com/config",
+			ExpectedEndpoint: "127.0.0.1",
+			ExpectedKey:      "kops/cluster.example.com/config",
+		},
+		{
+			Input:       "etcd:///kops",
+			ExpectError: true,
+		},
+	}
+	for _, g := range grid {
+		etcdPath, err := Context.buildEtcdPath(g.Input)
+		if !g.ExpectError {
+			if err != nil {
+				t.Fatalf("unexpected error parsing etcd path: %v", err)
+			}
+			if etcdPath.Endpoint() != g.ExpectedEndpoint || etcdPath.Key() != g.ExpectedKey {
+				t.Fatalf("unexpected etcd path: %#v", etcdPath)
+			}
+			if etcdPath.Path() != g.Input {
+				t.Fatalf("etcd path %q did not round trip: %q", g.Input, etcdPath.Path())
+			}
+		} else {
+			if err == nil {
+				t.Fatalf("expected error parsing %q", g.Input)
+			}
+		}
+	}
+}
+
+func Test_EtcdPath_ReadWrite(t *testing.T) {
+	kv := etcdfake.NewKV()
+	context := NewEtcdContext()
+	context.kvs["etcd.example.com:2379"] = kv
+	base := newEtcdPath(context, "etcd.example.com:2379", "kops/cluster.example.com")
+
+	p := base.Join("pki", "private", "ca", "1.key")
+	if _, err := p.ReadFile(); !os.IsNotExist(err) {
+		t.Fatalf("expected not exist reading missing file, got %v", err)
+	}
+
+	if err := p.CreateFile(bytes.NewReader([]byte("key1")), nil); err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	if err := p.CreateFile(bytes.NewReader([]byte("key2")), nil); !os.IsExist(err) {
+		t.Fatalf("expected exists error creating existing file, got %v", err)
+	}
+	data, err := p.ReadFile()
+	if err != nil {
+		t.Fatalf("error reading file: %v", err)
+	}
+	if string(data) != "key1" {
+		t.Fatalf("unexpected file contents: %q", data)
+	}
+
+	for _, f := range []string{"config", "instancegroup/nodes", "instancegroup/master-us-east-1a", "pki/issued/ca/1.crt"} {
+		if err := base.Join(f).WriteFile(bytes.NewReader([]byte(f)), nil); err != nil {
+			t.Fatalf("error writing file: %v", err)
+		}
+	}
+	// A sibling cluster whose name shares our prefix must not be listed
+	if err := newEtcdPath(context, "etcd.example.com:2379", "kops/cluster.example.com.au/config").WriteFile(bytes.NewReader([]byte("other")), nil); err != nil {
+		t.Fatalf("error writing file: %v", err)
+	}
+
+	children, err := base.ReadDir()
+	if err != nil {
+		t.Fatalf("error reading dir: %v", err)
+	}
+	if actual, expected := etcdPaths(children), []string{"config", "instancegroup", "pki"}; !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("unexpected ReadDir, expected %v, got %v", expected, actual)
+	}
+
+	tree, err := base.ReadTree()
+	if err != nil {
+		t.Fatalf("error reading tree: %v", err)
+	}
+	expectedTree := []string{"config", "instancegroup/master-us-east-1a", "instancegroup/nodes", "pki/issued/ca/1.crt", "pki/private/ca/1.key"}
+	if actual := etcdPaths(tree); !reflect.DeepEqual(actual, expectedTree) {
+		t.Fatalf("unexpected ReadTree, expected %v, got %v", expectedTree, actual)
+	}
+
+	expectedHash := sha256.Sum256([]byte("config"))
+	for _, child := range tree {
+		if child.Base() != "config" {
+			continue
+		}
+		hash, err := child.(HasHash).Hash(hashing.HashAlgorithmSHA256)
+		if err != nil {
+			t.Fatalf("error getting hash from listing: %v", err)
+		}
+		if !bytes.Equal(hash.HashValue, expectedHash[:]) {
+			t.Fatalf("unexpected hash from listing: %v", hash)
+		}
+	}
+	hash, err := base.Join("config").(HasHash).PreferredHash()
+	if err != nil {
+		t.Fatalf("error getting hash: %v", err)
+	}
+	if !bytes.Equal(hash.HashValue, expectedHash[:]) {
+		t.Fatalf("unexpected hash: %v", hash)
+	}
+
+	if err := p.Remove(); err != nil {
+		t.Fatalf("error removing file: %v", err)
+	}
+	if _, err := p.ReadFile(); !os.IsNotExist(err) {
+		t.Fatalf("expected not exist reading removed file, got %v", err)
+	}
+	if err := p.Remove(); !os.IsNotExist(err) {
+		t.Fatalf("expected not exist removing removed file, got %v", err)
+	}
+
+	empty, err := base.Join("missing").ReadTree()
+	if err != nil {
+		t.Fatalf("error reading missing tree: %v", err)
+	}
+	if len(empty) != 0 {
+		t.Fatalf("unexpected files in missing tree: %v", empty)
+	}
+}
+
+// etcdPaths returns the sorted keys of the paths, relative to kops/cluster.example.com
+func etcdPaths(paths []Path) []string {
+	var keys []string
+	for _, p := range paths {
+		keys = append(keys, strings.TrimPrefix(p.(*EtcdPath).Key(), "kops/cluster.example.com/"))
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func Test_EtcdPath_WriteFileIfVersion(t *testing.T) {
+	kv := etcdfake.NewKV()
+	context := NewEtcdContext()
+	context.kvs["etcd.example.com:2379"] = kv
+	p := newEtcdPath(context, "etcd.example.com:2379", "kops/cluster.example.com/config")
+
+	if err := p.WriteFile(bytes.NewReader([]byte("first")), nil); err != nil {
+		t.Fatalf("error writing file: %v", err)
+	}
+	data, version, err := p.ReadFileVersion()
+	if err != nil {
+		t.Fatalf("error reading file: %v", err)
+	}
+	if string(data) != "first" || version == "" {
+		t.Fatalf("unexpected read: %q version %q", data, version)
+	}
+
+	// Someone else changes the file after we read it
+	if err := p.WriteFile(bytes.NewReader([]byte("other")), nil); err != nil {
+		t.Fatalf("error writing file: %v", err)
+	}
+	if err := p.WriteFileIfVersion(bytes.NewReader([]byte("second")), nil, version); err != ErrVersionConflict {
+		t.Fatalf("expected version conflict writing changed file, got %v", err)
+	}
+
+	_, version, err = p.ReadFileVersion()
+	if err != nil {
+		t.Fatalf("error reading file: %v", err)
+	}
+	if err := p.WriteFileIfVersion(bytes.NewReader([]byte("second")), nil, version); err != nil {
+		t.Fatalf("error writing unchanged file: %v", err)
+	}
+	data, err = p.ReadFile()
+	if err != nil || string(data) != "second" {
+		t.Fatalf("unexpected contents %q: %v", data, err)
+	}
+}
@@ -24,8 +24,8 @@ import (
 	"net/http"
 	"os"
 	"path"
+	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/golang/glog"
@@ -46,6 +46,7 @@ type GSPath struct {
 
 var _ Path = &GSPath{}
 var _ HasHash = &GSPath{}
+var _ HasConditionalWrite = &GSPath{}
 
 // gcsReadBackoff is the backoff strategy for GCS read retries
 var gcsReadBackoff = wait.Backoff{
@@ -135,6 +136,29 @@ func (p *GSPath) Join(relativePath ...string) Path {
 }
 
 func (p *GSPath) WriteFile(data io.ReadSeeker, acl ACL) error {
+	return p.writeFile(data, acl, "")
+}
+
+// WriteFileIfVersion implements HasConditionalWrite::WriteFileIfVersion, using the generation of the object as the version
+func (p *GSPath) WriteFileIfVersion(data io.ReadSeeker, acl ACL, version string) error {
+	if version == "" {
+		return fmt.Errorf("no generation to write %s conditionally", p)
+	}
+	return p.writeFile(data, acl, version)
+}
+
+// writeFile writes the file; if ifGeneration is set, only if the generation of the existing object matches it.
+// Generation 0 matches only if there is no existing object.
+func (p *GSPath) writeFile(data io.ReadSeeker, acl ACL, ifGeneration string) error {
+	var generation int64
+	if ifGeneration != "" {
+		g, err := strconv.ParseInt(ifGeneration, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid generation %q for %s", ifGeneration, p)
+		}
+		generation = g
+	}
+
 	done, err := RetryWithBackoff(gcsWriteBackoff, func() (bool, error) {
 		glog.V(4).Infof("Writing file %q", p)
 
@@ -160,8 +184,15 @@ func (p *GSPath) WriteFile(data io.ReadSeeker, acl ACL) error {
 			return false, fmt.Errorf("error seeking to start of data stream for write to %s: %v", p, err)
 		}
 
-		_, err = p.client.Objects.Insert(p.bucket, obj).Media(data).Do()
+		call := p.client.Objects.Insert(p.bucket, obj).Media(data)
+		if ifGeneration != "" {
+			call = call.IfGenerationMatch(generation)
+		}
+		_, err = call.Do()
 		if err != nil {
+			if ae, ok := err.(*googleapi.Error); ok && ifGeneration != "" && ae.Code == http.StatusPreconditionFailed {
+				return true, ErrVersionConflict
+			}
 			return false, fmt.Errorf("error writing %s: %v", p, err)
 		}
 
@@ -177,35 +208,29 @@ func (p *GSPath) WriteFile(data io.ReadSeeker, acl ACL) error {
 	}
 }
 
-// To prevent concurrent creates on the same file while maintaining atomicity of writes,
-// we take a process-wide lock during the operation.
-// Not a great approach, but fine for a single process (with low concurrency)
-// TODO: should we enable versioning?
-var createFileLockGCS sync.Mutex
-
+// CreateFile implements Path::CreateFile, writing the object only if there is no live generation of it
 func (p *GSPath) CreateFile(data io.ReadSeeker, acl ACL) error {
-	createFileLockGCS.Lock()
-	defer createFileLockGCS.Unlock()
-
-	// Check if exists
-	_, err := p.ReadFile()
-	if err == nil {
+	err := p.writeFile(data, acl, "0")
+	if err == ErrVersionConflict {
 		return os.ErrExist
 	}
-
-	if !os.IsNotExist(err) {
-		return err
-	}
-
-	return p.WriteFile(data, acl)
+	return err
 }
 
 // ReadFile implements Path::ReadFile
 func (p *GSPath) ReadFile() ([]byte, error) {
+	data, _, err := p.ReadFileVersion()
+	return data, err
+}
+
+// ReadFileVersion implements HasConditionalWrite::ReadFileVersion, returning the generation of the object as the version
+func (p *GSPath) ReadFileVersion() ([]byte, string, error) {
 	var b bytes.Buffer
+	var generation string
 	done, err := RetryWithBackoff(gcsReadBackoff, func() (bool, error) {
 		b.Reset()
-		_, err := p.WriteTo(&b)
+		var err error
+		_, generation, err = p.writeTo(&b)
 		if err != nil {
 			if os.IsNotExist(err) {
 				// Not recoverable
@@ -217,32 +242,39 @@ func (p *GSPath) ReadFile() ([]byte, error) {
 		return true, nil
 	})
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	} else if done {
-		return b.Bytes(), nil
+		return b.Bytes(), generation, nil
 	} else {
 		// Shouldn't happen - we always return a non-nil error with false
-		return nil, wait.ErrWaitTimeout
+		return nil, "", wait.ErrWaitTimeout
 	}
 }
 
 // WriteTo implements io.WriterTo::WriteTo
 func (p *GSPath) WriteTo(out io.Writer) (int64, error) {
+	n, _, err := p.writeTo(out)
+	return n, err
+}
+
+// writeTo copies the object to out, and returns its generation
+func (p *GSPath) writeTo(out io.Writer) (int64, string, error) {
 	glog.V(4).Infof("Reading file %q", p)
 
 	response, err := p.client.Objects.Get(p.bucket, p.key).Download()
 	if err != nil {
 		if isGCSNotFound(err) {
-			return 0, os.ErrNotExist
+			return 0, "", os.ErrNotExist
 		}
-		return 0, fmt.Errorf("error reading %s: %v", p, err)
+		return 0, "", fmt.Errorf("error reading %s: %v", p, err)
 	}
 	if response == nil {
-		return 0, fmt.Errorf("no response returned from reading %s", p)
+		return 0, "", fmt.Errorf("no response returned from reading %s", p)
 	}
 	defer response.Body.Close()
 
-	return io.Copy(out, response.Body)
+	n, err := io.Copy(out, response.Body)
+	return n, response.Header.Get("X-Goog-Generation"), err
 }
 
 // ReadDir implements Path::ReadDir
@@ -22,6 +22,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"strconv"
 	"strings"
 	"sync"
 )
@@ -33,9 +34,13 @@ type MemFSPath struct {
 	mutex    sync.Mutex
 	contents []byte
 	children map[string]*MemFSPath
+
+	// version counts the writes to the file
+	version int
 }
 
 var _ Path = &MemFSPath{}
+var _ HasConditionalWrite = &MemFSPath{}
 
 type MemFSContext struct {
 	clusterReadable bool
@@ -100,6 +105,7 @@ func (p *MemFSPath) WriteFile(r io.ReadSeeker, acl ACL) error {
 		return fmt.Errorf("error reading data: %v", err)
 	}
 	p.contents = data
+	p.version++
 	return nil
 }
 
@@ -121,6 +127,23 @@ func (p *MemFSPath) ReadFile() ([]byte, error) {
 	return p.contents, nil
 }
 
+// ReadFileVersion implements HasConditionalWrite::ReadFileVersion
+func (p *MemFSPath) ReadFileVersion() ([]byte, string, error) {
+	data, err := p.ReadFile()
+	if err != nil {
+		return nil, "", err
+	}
+	return data, strconv.Itoa(p.version), nil
+}
+
+// WriteFileIfVersion implements HasConditionalWrite::WriteFileIfVersion
+func (p *MemFSPath) WriteFileIfVersion(data io.ReadSeeker, acl ACL, version string) error {
+	if p.contents == nil || strconv.Itoa(p.version) != version {
+		return ErrVersionConflict
+	}
+	return p.WriteFile(data, acl)
+}
+
 // WriteTo implements io.WriterTo
 func (p *MemFSPath) WriteTo(out io.Writer) (int64, error) {
 	if p.contents == nil {
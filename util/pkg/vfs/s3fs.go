@@ -28,6 +28,7 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	awsrequest "github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/golang/glog"
 	"k8s.io/kops/util/pkg/hashing"
@@ -48,6 +49,7 @@ type S3Path struct {
 }
 
 var _ Path = &S3Path{}
+var _ HasConditionalWrite = &S3Path{}
 var _ HasHash = &S3Path{}
 
 // S3Acl is an ACL implementation for objects on S3
@@ -120,6 +122,21 @@ func (p *S3Path) Join(relativePath ...string) Path {
 }
 
 func (p *S3Path) WriteFile(data io.ReadSeeker, aclObj ACL) error {
+	return p.writeFile(data, aclObj, "", "")
+}
+
+// WriteFileIfVersion implements HasConditionalWrite::WriteFileIfVersion, using the ETag as the version.
+// S3 implementations that do not support conditional writes ignore the If-Match header, and write unconditionally.
+func (p *S3Path) WriteFileIfVersion(data io.ReadSeeker, aclObj ACL, version string) error {
+	if version == "" {
+		return fmt.Errorf("no ETag to write %s conditionally", p)
+	}
+	return p.writeFile(data, aclObj, version, "")
+}
+
+// writeFile writes the file; if ifMatch is set, only if the ETag of the existing object matches it,
+// and if ifNoneMatch is "*", only if there is no existing object
+func (p *S3Path) writeFile(data io.ReadSeeker, aclObj ACL, ifMatch string, ifNoneMatch string) error {
 	client, err := p.client()
 	if err != nil {
 		return err
@@ -165,8 +182,29 @@ func (p *S3Path) WriteFile(data io.ReadSeeker, aclObj ACL) error {
 
 	glog.V(8).Infof("Calling S3 PutObject Bucket=%q Key=%q SSE=%q ACL=%q", p.bucket, p.key, sseLog, acl)
 
-	_, err = client.PutObject(request)
+	req, _ := client.PutObjectRequest(request)
+	// PutObjectInput has no IfMatch or IfNoneMatch fields, so we add the headers when the request is built
+	if ifMatch != "" {
+		req.Handlers.Build.PushBack(func(r *awsrequest.Request) {
+			r.HTTPRequest.Header.Set("If-Match", ifMatch)
+		})
+	}
+	if ifNoneMatch != "" {
+		req.Handlers.Build.PushBack(func(r *awsrequest.Request) {
+			r.HTTPRequest.Header.Set("If-None-Match", ifNoneMatch)
+		})
+	}
+	err = req.Send()
 	if err != nil {
+		// S3 returns ConditionalRequestConflict if a conflicting write is in progress
+		if code := AWSErrorCode(err); code == "PreconditionFailed" || code == "ConditionalRequestConflict" {
+			if ifNoneMatch != "" {
+				return os.ErrExist
+			}
+			if ifMatch != "" {
+				return ErrVersionConflict
+			}
+		}
 		if acl != "" {
 			return fmt.Errorf("error writing %s (with ACL=%q): %v", p, acl, err)
 		} else {
@@ -177,12 +215,12 @@ func (p *S3Path) WriteFile(data io.ReadSeeker, aclObj ACL) error {
 	return nil
 }
 
-// To prevent concurrent creates on the same file while maintaining atomicity of writes,
-// we take a process-wide lock during the operation.
-// Not a great approach, but fine for a single process (with low concurrency)
-// TODO: should we enable versioning?
+// createFileLockS3 serializes creates within this process. Across processes, CreateFile relies on the
+// write being conditional on there being no object; S3 implementations that ignore If-None-Match
+// are only protected by the process-wide lock and the existence check.
 var createFileLockS3 sync.Mutex
 
+// CreateFile implements Path::CreateFile, writing the object with If-None-Match: * so that it is never overwritten
 func (p *S3Path) CreateFile(data io.ReadSeeker, acl ACL) error {
 	createFileLockS3.Lock()
 	defer createFileLockS3.Unlock()
@@ -197,7 +235,7 @@ func (p *S3Path) CreateFile(data io.ReadSeeker, acl ACL) error {
 		return err
 	}
 
-	return p.WriteFile(data, acl)
+	return p.writeFile(data, acl, "", "*")
 }
 
 // ReadFile implements Path::ReadFile
@@ -210,11 +248,27 @@ func (p *S3Path) ReadFile() ([]byte, error) {
 	return b.Bytes(), nil
 }
 
+// ReadFileVersion implements HasConditionalWrite::ReadFileVersion, returning the ETag as the version
+func (p *S3Path) ReadFileVersion() ([]byte, string, error) {
+	var b bytes.Buffer
+	_, etag, err := p.writeTo(&b)
+	if err != nil {
+		return nil, "", err
+	}
+	return b.Bytes(), etag, nil
+}
+
 // WriteTo implements io.WriterTo
 func (p *S3Path) WriteTo(out io.Writer) (int64, error) {
+	n, _, err := p.writeTo(out)
+	return n, err
+}
+
+// writeTo copies the object to out, and returns its ETag
+func (p *S3Path) writeTo(out io.Writer) (int64, string, error) {
 	client, err := p.client()
 	if err != nil {
-		return 0, err
+		return 0, "", err
 	}
 
 	glog.V(4).Infof("Reading file %q", p)
@@ -226,17 +280,17 @@ func (p *S3Path) WriteTo(out io.Writer) (int64, error) {
 	response, err := client.GetObject(request)
 	if err != nil {
 		if AWSErrorCode(err) == "NoSuchKey" {
-			return 0, os.ErrNotExist
+			return 0, "", os.ErrNotExist
 		}
-		return 0, fmt.Errorf("error fetching %s: %v", p, err)
+		return 0, "", fmt.Errorf("error fetching %s: %v", p, err)
 	}
 	defer response.Body.Close()
 
 	n, err := io.Copy(out, response.Body)
 	if err != nil {
-		return n, fmt.Errorf("error reading %s: %v", p, err)
+		return n, "", fmt.Errorf("error reading %s: %v", p, err)
 	}
-	return n, nil
+	return n, aws.StringValue(response.ETag), nil
 }
 
 func (p *S3Path) ReadDir() ([]Path, error) {
@@ -0,0 +1,186 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package vaultfake is a fake of the KV (version 2) secrets engine of HashiCorp Vault, for tests.
+// It implements only the requests made by vfs.VaultPath.
+package vaultfake
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Server is a fake Vault server, with a KV secrets engine at every mount
+type Server struct {
+	*httptest.Server
+
+	// Token is the token that requests must present
+	Token string
+
+	mutex   sync.Mutex
+	secrets map[string]*secret
+}
+
+type secret struct {
+	version int
+	data    map[string]string
+}
+
+// NewServer starts a fake Vault server, which accepts requests with the token
+func NewServer(token string) *Server {
+	s := &Server{
+		Token:   token,
+		secrets: make(map[string]*secret),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Keys returns the keys of all the secrets, as <mount>/<key>
+func (s *Server) Keys() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var keys []string
+	for k := range s.secrets {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Data returns the data of a secret, or nil if it does not exist
+func (s *Server) Data(key string) map[string]string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if secret := s.secrets[key]; secret != nil {
+		return secret.data
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeErrors(w http.ResponseWriter, statusCode int, errors ...string) {
+	if errors == nil {
+		errors = []string{}
+	}
+	writeJSON(w, statusCode, map[string]interface{}{"errors": errors})
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Vault-Token") != s.Token {
+		writeErrors(w, http.StatusForbidden, "permission denied")
+		return
+	}
+
+	// Paths are /v1/<mount>/<data|metadata>/<key>
+	tokens := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/v1/"), "/", 3)
+	if len(tokens) != 3 {
+		writeErrors(w, http.StatusNotFound)
+		return
+	}
+	mount, api, key := tokens[0], tokens[1], tokens[2]
+	fullKey := mount + "/" + strings.Trim(key, "/")
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch {
+	case api == "data" && r.Method == "GET":
+		secret := s.secrets[fullKey]
+		if secret == nil {
+			writeErrors(w, http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data": map[string]interface{}{
+				"data":     secret.data,
+				"metadata": map[string]interface{}{"version": secret.version},
+			},
+		})
+
+	case api == "data" && (r.Method == "POST" || r.Method == "PUT"):
+		var request struct {
+			Data    map[string]string `json:"data"`
+			Options struct {
+				CAS *int `json:"cas"`
+			} `json:"options"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			writeErrors(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		existing := s.secrets[fullKey]
+		version := 0
+		if existing != nil {
+			version = existing.version
+		}
+		if request.Options.CAS != nil && *request.Options.CAS != version {
+			writeErrors(w, http.StatusBadRequest, "check-and-set parameter did not match the current version")
+			return
+		}
+		s.secrets[fullKey] = &secret{version: version + 1, data: request.Data}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data": map[string]interface{}{"version": version + 1},
+		})
+
+	case api == "metadata" && r.Method == "DELETE":
+		delete(s.secrets, fullKey)
+		w.WriteHeader(http.StatusNoContent)
+
+	case api == "metadata" && (r.Method == "LIST" || (r.Method == "GET" && r.URL.Query().Get("list") == "true")):
+		prefix := fullKey + "/"
+		if strings.Trim(key, "/") == "" {
+			prefix = mount + "/"
+		}
+		found := make(map[string]bool)
+		for k := range s.secrets {
+			if !strings.HasPrefix(k, prefix) {
+				continue
+			}
+			child := strings.TrimPrefix(k, prefix)
+			if i := strings.Index(child, "/"); i != -1 {
+				child = child[:i+1]
+			}
+			found[child] = true
+		}
+		if len(found) == 0 {
+			writeErrors(w, http.StatusNotFound)
+			return
+		}
+		var keys []string
+		for k := range found {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data": map[string]interface{}{"keys": keys},
+		})
+
+	default:
+		writeErrors(w, http.StatusMethodNotAllowed, "unsupported request")
+	}
+}
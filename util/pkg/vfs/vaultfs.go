@@ -0,0 +1,390 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vfs
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// VaultPath is a vfs path for the KV (version 2) secrets engine of HashiCorp Vault.
+// It is written as vault://<host[:port]>/<mount>/<key>, where mount is the path the KV engine is mounted at.
+// Each file is stored as a secret, with its contents base64 encoded in the "content" field.
+type VaultPath struct {
+	context *VaultContext
+	scheme  string
+	host    string
+	mount   string
+	key     string
+}
+
+var _ Path = &VaultPath{}
+var _ HasClusterReadable = &VaultPath{}
+
+// VaultContext holds the credentials for Vault, which are shared by all VaultPaths
+type VaultContext struct {
+	mutex      sync.Mutex
+	httpClient *http.Client
+
+	// token is the Vault token, read from VAULT_TOKEN or ~/.vault-token when first needed
+	token string
+	// namespace is the Vault Enterprise namespace, from VAULT_NAMESPACE
+	namespace string
+}
+
+// NewVaultContext builds a VaultContext; the token is read when it is first needed
+func NewVaultContext() *VaultContext {
+	return &VaultContext{
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		namespace:  os.Getenv("VAULT_NAMESPACE"),
+	}
+}
+
+// vaultFileField is the field of the secret that holds the (base64 encoded) contents of the file
+const vaultFileField = "content"
+
+// vaultBackoff is the backoff strategy for retrying Vault requests that fail with a server error
+var vaultBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   1.5,
+	Jitter:   0.1,
+	Steps:    4,
+}
+
+// vaultCASMismatch is found in the error Vault returns when a check-and-set write fails
+const vaultCASMismatch = "check-and-set parameter did not match"
+
+func newVaultPath(context *VaultContext, scheme string, host string, mount string, key string) *VaultPath {
+	return &VaultPath{
+		context: context,
+		scheme:  scheme,
+		host:    host,
+		mount:   strings.Trim(mount, "/"),
+		key:     strings.Trim(key, "/"),
+	}
+}
+
+func (c *VaultContext) getToken() (string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.token != "" {
+		return c.token, nil
+	}
+
+	token := os.Getenv("VAULT_TOKEN")
+	if token == "" {
+		// The vault CLI stores the token from vault login in ~/.vault-token
+		home := os.Getenv("HOME")
+		if home == "" {
+			// HOME is not always set when nodeup runs at boot
+			if u, err := user.Current(); err == nil {
+				home = u.HomeDir
+			}
+		}
+		if home != "" {
+			b, err := ioutil.ReadFile(filepath.Join(home, ".vault-token"))
+			if err != nil && !os.IsNotExist(err) {
+				return "", fmt.Errorf("error reading vault token: %v", err)
+			}
+			token = strings.TrimSpace(string(b))
+		}
+	}
+	if token == "" {
+		return "", fmt.Errorf("vault token not found; set VAULT_TOKEN or run vault login")
+	}
+	c.token = token
+	return token, nil
+}
+
+// vaultResponse is the subset of the responses of the KV secrets engine that we use
+type vaultResponse struct {
+	Data struct {
+		// Data is returned when reading a secret
+		Data map[string]string `json:"data"`
+		// Keys is returned when listing secrets
+		Keys []string `json:"keys"`
+	} `json:"data"`
+	Errors []string `json:"errors"`
+}
+
+// do performs a request against the KV secrets engine, returning the status code and parsed response.
+// api is "data" or "metadata".
+func (p *VaultPath) do(method string, api string, key string, query url.Values, body interface{}) (int, *vaultResponse, error) {
+	token, err := p.context.getToken()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var data []byte
+	if body != nil {
+		data, err = json.Marshal(body)
+		if err != nil {
+			return 0, nil, fmt.Errorf("error building vault request: %v", err)
+		}
+	}
+
+	u := &url.URL{
+		Scheme:   p.scheme,
+		Host:     p.host,
+		Path:     "/v1/" + p.mount + "/" + api + "/" + key,
+		RawQuery: query.Encode(),
+	}
+
+	var statusCode int
+	response := &vaultResponse{}
+	done, err := RetryWithBackoff(vaultBackoff, func() (bool, error) {
+		glog.V(8).Infof("Performing vault request: %s %s", method, u)
+		req, err := http.NewRequest(method, u.String(), bytes.NewReader(data))
+		if err != nil {
+			return true, err
+		}
+		req.Header.Set("X-Vault-Token", token)
+		req.Header.Set("X-Vault-Request", "true")
+		if p.context.namespace != "" {
+			req.Header.Set("X-Vault-Namespace", p.context.namespace)
+		}
+		if data != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := p.context.httpClient.Do(req)
+		if err != nil {
+			return false, fmt.Errorf("error performing vault request %s %s: %v", method, u, err)
+		}
+		defer resp.Body.Close()
+
+		b, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return false, fmt.Errorf("error reading vault response: %v", err)
+		}
+
+		statusCode = resp.StatusCode
+		*response = vaultResponse{}
+		if len(b) != 0 {
+			if err := json.Unmarshal(b, response); err != nil {
+				return statusCode < 500, fmt.Errorf("error parsing vault response (status %d): %v", statusCode, err)
+			}
+		}
+		if statusCode >= 500 {
+			return false, fmt.Errorf("vault request %s %s failed with status %d: %s", method, u, statusCode, strings.Join(response.Errors, "; "))
+		}
+		return true, nil
+	})
+	if err != nil {
+		return statusCode, nil, err
+	} else if !done {
+		// Shouldn't happen - we always return a non-nil error with false
+		return statusCode, nil, wait.ErrWaitTimeout
+	}
+	return statusCode, response, nil
+}
+
+func (p *VaultPath) vaultError(op string, statusCode int, response *vaultResponse) error {
+	return fmt.Errorf("error %s %s: vault returned status %d: %s", op, p, statusCode, strings.Join(response.Errors, "; "))
+}
+
+// WriteTo implements io.WriterTo
+func (p *VaultPath) WriteTo(out io.Writer) (int64, error) {
+	data, err := p.ReadFile()
+	if err != nil {
+		return 0, err
+	}
+	n, err := out.Write(data)
+	return int64(n), err
+}
+
+func (p *VaultPath) Join(relativePath ...string) Path {
+	args := []string{p.key}
+	args = append(args, relativePath...)
+	joined := path.Join(args...)
+	return newVaultPath(p.context, p.scheme, p.host, p.mount, joined)
+}
+
+// ReadFile implements Path::ReadFile
+func (p *VaultPath) ReadFile() ([]byte, error) {
+	glog.V(4).Infof("Reading file %q", p)
+
+	statusCode, response, err := p.do("GET", "data", p.key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if statusCode == http.StatusNotFound {
+		return nil, os.ErrNotExist
+	}
+	if statusCode != http.StatusOK {
+		return nil, p.vaultError("reading", statusCode, response)
+	}
+
+	encoded, found := response.Data.Data[vaultFileField]
+	if !found {
+		return nil, fmt.Errorf("error reading %s: secret has no %q field", p, vaultFileField)
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding %s: %v", p, err)
+	}
+	return data, nil
+}
+
+func (p *VaultPath) write(data io.ReadSeeker, options map[string]interface{}) error {
+	if _, err := data.Seek(0, 0); err != nil {
+		return fmt.Errorf("error seeking to start of data stream for write to %s: %v", p, err)
+	}
+	b, err := ioutil.ReadAll(data)
+	if err != nil {
+		return fmt.Errorf("error reading from data stream: %v", err)
+	}
+
+	body := map[string]interface{}{
+		"data": map[string]string{
+			vaultFileField: base64.StdEncoding.EncodeToString(b),
+		},
+	}
+	if options != nil {
+		body["options"] = options
+	}
+
+	statusCode, response, err := p.do("POST", "data", p.key, nil, body)
+	if err != nil {
+		return err
+	}
+	if statusCode == http.StatusBadRequest && strings.Contains(strings.Join(response.Errors, "; "), vaultCASMismatch) {
+		return os.ErrExist
+	}
+	if statusCode != http.StatusOK && statusCode != http.StatusNoContent {
+		return p.vaultError("writing", statusCode, response)
+	}
+	return nil
+}
+
+// WriteFile implements Path::WriteFile; the ACL is ignored, as access is controlled by Vault policies
+func (p *VaultPath) WriteFile(data io.ReadSeeker, acl ACL) error {
+	glog.V(4).Infof("Writing file %q", p)
+	return p.write(data, nil)
+}
+
+// CreateFile implements Path::CreateFile, using a check-and-set write so that an existing secret is never replaced
+func (p *VaultPath) CreateFile(data io.ReadSeeker, acl ACL) error {
+	glog.V(4).Infof("Creating file %q", p)
+	return p.write(data, map[string]interface{}{"cas": 0})
+}
+
+// Remove implements Path::Remove, deleting every version of the secret
+func (p *VaultPath) Remove() error {
+	glog.V(8).Infof("removing file %s", p)
+
+	statusCode, response, err := p.do("DELETE", "metadata", p.key, nil, nil)
+	if err != nil {
+		return err
+	}
+	if statusCode != http.StatusOK && statusCode != http.StatusNoContent && statusCode != http.StatusNotFound {
+		return p.vaultError("deleting", statusCode, response)
+	}
+	return nil
+}
+
+func (p *VaultPath) Base() string {
+	return path.Base(p.key)
+}
+
+func (p *VaultPath) String() string {
+	return p.Path()
+}
+
+func (p *VaultPath) Path() string {
+	return "vault://" + p.host + "/" + p.mount + "/" + p.key
+}
+
+// IsClusterReadable implements HasClusterReadable.
+// Instances can read the secrets, as long as they are given a Vault token with a policy that allows it.
+func (p *VaultPath) IsClusterReadable() bool {
+	return true
+}
+
+// list returns the keys under the path; keys of directories end in /
+func (p *VaultPath) list(key string) ([]string, error) {
+	query := url.Values{}
+	query.Set("list", "true")
+	statusCode, response, err := p.do("GET", "metadata", key+"/", query, nil)
+	if err != nil {
+		return nil, err
+	}
+	if statusCode == http.StatusNotFound {
+		// Vault returns 404 when there are no keys, as S3 would return an empty list
+		return nil, nil
+	}
+	if statusCode != http.StatusOK {
+		return nil, p.vaultError("listing", statusCode, response)
+	}
+	return response.Data.Keys, nil
+}
+
+// ReadDir implements Path::ReadDir
+func (p *VaultPath) ReadDir() ([]Path, error) {
+	keys, err := p.list(p.key)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []Path
+	for _, k := range keys {
+		paths = append(paths, p.Join(strings.TrimSuffix(k, "/")))
+	}
+	glog.V(8).Infof("Listed files in %v: %v", p, paths)
+	return paths, nil
+}
+
+// ReadTree implements Path::ReadTree
+func (p *VaultPath) ReadTree() ([]Path, error) {
+	var paths []Path
+	dirs := []string{p.key}
+	for len(dirs) != 0 {
+		dir := dirs[0]
+		dirs = dirs[1:]
+
+		keys, err := p.list(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range keys {
+			child := path.Join(dir, k)
+			if strings.HasSuffix(k, "/") {
+				dirs = append(dirs, child)
+			} else {
+				paths = append(paths, newVaultPath(p.context, p.scheme, p.host, p.mount, child))
+			}
+		}
+	}
+	return paths, nil
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vfs
+
+import (
+	"bytes"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+
+	"k8s.io/kops/util/pkg/vfs/vaultfake"
+)
+
+func Test_VaultPath_Parse(t *testing.T) {
+	grid := []struct {
+		Input         string
+		ExpectError   bool
+		ExpectedHost  string
+		ExpectedMount string
+		ExpectedKey   string
+	}{
+		{
+			Input:         "vault://vault.example.com/secret",
+			ExpectedHost:  "vault.example.com",
+			ExpectedMount: "secret",
+			ExpectedKey:   "",
+		},
+		{
+			Input:         "vault://vault.example.com:8200/secret/kops/cluster/pki",
+			ExpectedHost:  "vault.example.com:8200",
+			ExpectedMount: "secret",
+			ExpectedKey:   "kops/cluster/pki",
+		},
+		{
+			Input:       "vault://vault.example.com",
+			ExpectError: true,
+		},
+		{
+			Input:       "vault:///secret/kops",
+			ExpectError: true,
+		},
+	}
+	for _, g := range grid {
+		vaultPath, err := Context.buildVaultPath(g.Input)
+		if !g.ExpectError {
+			if err != nil {
+				t.Fatalf("unexpected error parsing vault path: %v", err)
+			}
+			if vaultPath.host != g.ExpectedHost || vaultPath.mount != g.ExpectedMount || vaultPath.key != g.ExpectedKey {
+				t.Fatalf("unexpected vault path: %#v", vaultPath)
+			}
+			if vaultPath.scheme != "https" {
+				t.Fatalf("unexpected scheme for vault path: %q", vaultPath.scheme)
+			}
+			if vaultPath.Path() != g.Input && vaultPath.Path() != g.Input+"/" {
+				t.Fatalf("vault path %q did not round trip: %q", g.Input, vaultPath.Path())
+			}
+		} else {
+			if err == nil {
+				t.Fatalf("expected error parsing %q", g.Input)
+			}
+		}
+	}
+}
+
+func Test_VaultPath_ReadWrite(t *testing.T) {
+	server := vaultfake.NewServer("test-token")
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("error parsing server url: %v", err)
+	}
+
+	context := NewVaultContext()
+	context.token = "test-token"
+	base := newVaultPath(context, u.Scheme, u.Host, "secret", "kops/cluster")
+
+	p := base.Join("pki", "private", "ca", "1.key")
+	if _, err := p.ReadFile(); !os.IsNotExist(err) {
+		t.Fatalf("expected not exist reading missing file, got %v", err)
+	}
+
+	if err := p.CreateFile(bytes.NewReader([]byte("key1")), nil); err != nil {
+		t.Fatalf("error creating file: %v", err)
+	}
+	if err := p.CreateFile(bytes.NewReader([]byte("key2")), nil); !os.IsExist(err) {
+		t.Fatalf("expected exists error creating existing file, got %v", err)
+	}
+	data, err := p.ReadFile()
+	if err != nil {
+		t.Fatalf("error reading file: %v", err)
+	}
+	if string(data) != "key1" {
+		t.Fatalf("unexpected file contents: %q", data)
+	}
+
+	// The contents are stored in the secret, not in plaintext
+	if stored := server.Data("secret/kops/cluster/pki/private/ca/1.key"); stored["content"] != "a2V5MQ==" {
+		t.Fatalf("unexpected secret data: %v", stored)
+	}
+
+	if err := base.Join("pki", "issued", "ca", "1.crt").WriteFile(bytes.NewReader([]byte("cert1")), nil); err != nil {
+		t.Fatalf("error writing file: %v", err)
+	}
+	if err := base.Join("secrets", "admin").WriteFile(bytes.NewReader([]byte("password")), nil); err != nil {
+		t.Fatalf("error writing file: %v", err)
+	}
+
+	children, err := base.ReadDir()
+	if err != nil {
+		t.Fatalf("error reading dir: %v", err)
+	}
+	if actual, expected := vaultPaths(children), []string{"pki", "secrets"}; !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("unexpected ReadDir, expected %v, got %v", expected, actual)
+	}
+
+	tree, err := base.Join("pki").ReadTree()
+	if err != nil {
+		t.Fatalf("error reading tree: %v", err)
+	}
+	if actual, expected := vaultPaths(tree), []string{"pki/issued/ca/1.crt", "pki/private/ca/1.key"}; !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("unexpected ReadTree, expected %v, got %v", expected, actual)
+	}
+
+	if err := p.Remove(); err != nil {
+		t.Fatalf("error removing file: %v", err)
+	}
+	if _, err := p.ReadFile(); !os.IsNotExist(err) {
+		t.Fatalf("expected not exist reading removed file, got %v", err)
+	}
+
+	empty, err := base.Join("missing").ReadTree()
+	if err != nil {
+		t.Fatalf("error reading missing tree: %v", err)
+	}
+	if len(empty) != 0 {
+		t.Fatalf("unexpected files in missing tree: %v", empty)
+	}
+
+	context.token = "wrong-token"
+	if _, err := base.Join("secrets", "admin").ReadFile(); err == nil || os.IsNotExist(err) {
+		t.Fatalf("expected permission error with wrong token, got %v", err)
+	}
+}
+
+// vaultPaths returns the sorted keys of the paths, relative to secret/kops/cluster
+func vaultPaths(paths []Path) []string {
+	var keys []string
+	for _, p := range paths {
+		keys = append(keys, p.(*VaultPath).key[len("kops/cluster/"):])
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package vfs
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -73,6 +74,20 @@ type Path interface {
 	ReadTree() ([]Path, error)
 }
 
+// HasConditionalWrite is implemented by the paths whose storage can write a file only if it has not changed since it was read
+type HasConditionalWrite interface {
+	// ReadFileVersion returns the contents of the file, and an opaque version that changes whenever the file is written.
+	// If the file did not exist, err = os.ErrNotExist
+	ReadFileVersion() ([]byte, string, error)
+
+	// WriteFileIfVersion writes the file contents, but only if the file is still at the version returned by ReadFileVersion;
+	// otherwise it returns ErrVersionConflict
+	WriteFileIfVersion(data io.ReadSeeker, acl ACL, version string) error
+}
+
+// ErrVersionConflict is returned by WriteFileIfVersion when the file has been written since it was read
+var ErrVersionConflict = errors.New("file has been changed since it was read")
+
 type HasHash interface {
 	// Returns the hash of the file contents, with the preferred hash algorithm
 	PreferredHash() (*hashing.Hash, error)